@@ -0,0 +1,330 @@
+package astc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"slices"
+)
+
+// The acceleration cache holds the per-footprint tables the codec builds on first use: the
+// encoder's block mode lists, the partition tables and the weight decimation tables. Building
+// them takes a few milliseconds per footprint (more for large 2D and 3D footprints on slow
+// machines), which dominates short-lived processes such as a CLI converting one small texture.
+
+var accelCacheMagic = [4]byte{'A', 'A', 'C', 'C'}
+
+const accelCacheVersion = 1
+
+var errInvalidAccelCache = errors.New("astc: invalid acceleration cache")
+
+// PrecomputeAccelerationTables builds the tables used to encode and decode blocks of the given
+// footprint, so that a following SaveAccelerationCache includes them.
+func PrecomputeAccelerationTables(blockX, blockY, blockZ int) error {
+	if err := validateBlockSize(blockX, blockY, blockZ); err != nil {
+		return err
+	}
+	validBlockModes(blockX, blockY, blockZ)
+	getDecodeContext(blockX, blockY, blockZ)
+	return nil
+}
+
+// SaveAccelerationCache writes the tables of every block footprint this process has built so far
+// (by encoding or decoding, or with PrecomputeAccelerationTables) to w, for LoadAccelerationCache.
+// The encoding is specific to this version of the package and ends with a CRC-32 checksum.
+func SaveAccelerationCache(w io.Writer) error {
+	footprints := map[blockModeCacheKey]bool{}
+	blockModeCacheMu.RLock()
+	for k := range blockModeCache {
+		footprints[k] = true
+	}
+	blockModeCacheMu.RUnlock()
+	partitionTables.mu.RLock()
+	for k := range partitionTables.m {
+		footprints[makeBlockModeCacheKey(int(k.bx), int(k.by), int(k.bz))] = true
+	}
+	partitionTables.mu.RUnlock()
+	decimationTables.mu.RLock()
+	for k := range decimationTables.m {
+		footprints[makeBlockModeCacheKey(int(k.bx), int(k.by), int(k.bz))] = true
+	}
+	decimationTables.mu.RUnlock()
+
+	keys := make([]blockModeCacheKey, 0, len(footprints))
+	for k := range footprints {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	out := append([]byte(nil), accelCacheMagic[:]...)
+	out = append(out, accelCacheVersion)
+	out = binary.LittleEndian.AppendUint16(out, uint16(len(keys)))
+	for _, k := range keys {
+		out = appendAccelFootprint(out, int(k&0xFF), int(k>>8&0xFF), int(k>>16&0xFF))
+	}
+	out = binary.LittleEndian.AppendUint32(out, crc32.ChecksumIEEE(out[len(accelCacheMagic)+1:]))
+	_, err := w.Write(out)
+	return err
+}
+
+// appendAccelFootprint appends the cached tables of one footprint: its dimensions, a bitmask of
+// the partition counts with a table followed by those tables, the block mode list (a uint16 count
+// and the modes in search order, count 0xFFFF when not built) and the decimation tables.
+func appendAccelFootprint(out []byte, blockX, blockY, blockZ int) []byte {
+	out = append(out, uint8(blockX), uint8(blockY), uint8(blockZ))
+
+	var tables [blockMaxPartitions + 1]*partitionTable
+	mask := uint8(0)
+	partitionTables.mu.RLock()
+	for pc := 2; pc <= blockMaxPartitions; pc++ {
+		key := partitionTableKey{bx: uint8(blockX), by: uint8(blockY), bz: uint8(blockZ), pc: uint8(pc)}
+		if t := partitionTables.m[key]; t != nil {
+			tables[pc] = t
+			mask |= 1 << pc
+		}
+	}
+	partitionTables.mu.RUnlock()
+	out = append(out, mask)
+	for _, t := range tables {
+		if t != nil {
+			out = append(out, t.data...)
+		}
+	}
+
+	blockModeCacheMu.RLock()
+	modes, ok := blockModeCache[makeBlockModeCacheKey(blockX, blockY, blockZ)]
+	blockModeCacheMu.RUnlock()
+	if !ok {
+		out = binary.LittleEndian.AppendUint16(out, 0xFFFF)
+	} else {
+		out = binary.LittleEndian.AppendUint16(out, uint16(len(modes)))
+		for _, m := range modes {
+			out = binary.LittleEndian.AppendUint16(out, uint16(m.mode))
+		}
+	}
+
+	type grid struct {
+		key   decimationKey
+		table []decimationEntry
+	}
+	var grids []grid
+	decimationTables.mu.RLock()
+	for k, t := range decimationTables.m {
+		if int(k.bx) == blockX && int(k.by) == blockY && int(k.bz) == blockZ {
+			grids = append(grids, grid{k, t})
+		}
+	}
+	decimationTables.mu.RUnlock()
+	slices.SortFunc(grids, func(a, b grid) int {
+		return int(a.key.wx)<<16 | int(a.key.wy)<<8 | int(a.key.wz) - (int(b.key.wx)<<16 | int(b.key.wy)<<8 | int(b.key.wz))
+	})
+	out = binary.LittleEndian.AppendUint16(out, uint16(len(grids)))
+	for _, g := range grids {
+		out = append(out, g.key.wx, g.key.wy, g.key.wz)
+		for _, e := range g.table {
+			out = append(out, e.idx[:]...)
+			out = append(out, e.w[:]...)
+		}
+	}
+	return out
+}
+
+// LoadAccelerationCache reads tables written by SaveAccelerationCache and installs those of
+// footprints this process has not built yet; tables already built are kept. The whole cache is
+// validated before anything is installed, so a damaged cache is rejected without effect.
+func LoadAccelerationCache(r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if len(data) < len(accelCacheMagic)+1+2+4 || !bytes.Equal(data[:len(accelCacheMagic)], accelCacheMagic[:]) {
+		return errInvalidAccelCache
+	}
+	if data[len(accelCacheMagic)] != accelCacheVersion {
+		return errors.New("astc: unsupported acceleration cache version")
+	}
+	body := data[len(accelCacheMagic)+1 : len(data)-4]
+	if crc32.ChecksumIEEE(body) != binary.LittleEndian.Uint32(data[len(data)-4:]) {
+		return errors.New("astc: acceleration cache checksum mismatch")
+	}
+
+	type footprint struct {
+		blockX, blockY, blockZ int
+		partitions             [blockMaxPartitions + 1][]uint8
+		modes                  []int
+		haveModes              bool
+		decimation             map[decimationKey][]decimationEntry
+	}
+	b := body
+	take := func(n int) ([]byte, bool) {
+		if n < 0 || len(b) < n {
+			return nil, false
+		}
+		v := b[:n:n]
+		b = b[n:]
+		return v, true
+	}
+	u16 := func() (int, bool) {
+		v, ok := take(2)
+		if !ok {
+			return 0, false
+		}
+		return int(binary.LittleEndian.Uint16(v)), true
+	}
+
+	count, _ := u16()
+	parsed := make([]footprint, 0, count)
+	for range count {
+		dims, ok := take(4)
+		if !ok {
+			return errInvalidAccelCache
+		}
+		f := footprint{blockX: int(dims[0]), blockY: int(dims[1]), blockZ: int(dims[2]), decimation: map[decimationKey][]decimationEntry{}}
+		texelCount := f.blockX * f.blockY * f.blockZ
+		if texelCount == 0 || texelCount > blockMaxTexels || dims[3]&^0x1C != 0 {
+			return errInvalidAccelCache
+		}
+		for pc := 2; pc <= blockMaxPartitions; pc++ {
+			if dims[3]&(1<<pc) == 0 {
+				continue
+			}
+			t, ok := take((1 << partitionIndexBits) * texelCount)
+			if !ok || !partitionTableValid(t, f.blockX, f.blockY, f.blockZ, pc) {
+				return errInvalidAccelCache
+			}
+			f.partitions[pc] = t
+		}
+
+		n, ok := u16()
+		if !ok {
+			return errInvalidAccelCache
+		}
+		if n != 0xFFFF {
+			f.haveModes = true
+			for range n {
+				mode, ok := u16()
+				if !ok {
+					return errInvalidAccelCache
+				}
+				f.modes = append(f.modes, mode)
+			}
+			// The list must be the one this build searches, in the same order, or the encoder
+			// output would depend on whether the cache was loaded.
+			if !slices.Equal(f.modes, blockModeOrder(f.blockX, f.blockY, f.blockZ)) {
+				return errInvalidAccelCache
+			}
+		}
+
+		grids, ok := u16()
+		if !ok {
+			return errInvalidAccelCache
+		}
+		for range grids {
+			wdims, ok := take(3)
+			if !ok {
+				return errInvalidAccelCache
+			}
+			weights := int(wdims[0]) * int(wdims[1]) * int(wdims[2])
+			if int(wdims[0]) > f.blockX || int(wdims[1]) > f.blockY || int(wdims[2]) > f.blockZ {
+				return errInvalidAccelCache
+			}
+			raw, ok := take(texelCount * 8)
+			if !ok {
+				return errInvalidAccelCache
+			}
+			table := make([]decimationEntry, texelCount)
+			for i := range table {
+				copy(table[i].idx[:], raw[i*8:])
+				copy(table[i].w[:], raw[i*8+4:])
+				sum := 0
+				for j := range 4 {
+					if int(table[i].idx[j]) >= weights {
+						return errInvalidAccelCache
+					}
+					sum += int(table[i].w[j])
+				}
+				if sum != 16 {
+					return errInvalidAccelCache
+				}
+			}
+			key := decimationKey{bx: dims[0], by: dims[1], bz: dims[2], wx: wdims[0], wy: wdims[1], wz: wdims[2]}
+			f.decimation[key] = table
+		}
+		parsed = append(parsed, f)
+	}
+	if len(b) != 0 {
+		return errInvalidAccelCache
+	}
+
+	for _, f := range parsed {
+		partitionTables.mu.Lock()
+		if partitionTables.m == nil {
+			partitionTables.m = make(map[partitionTableKey]*partitionTable)
+		}
+		for pc, t := range f.partitions {
+			key := partitionTableKey{bx: uint8(f.blockX), by: uint8(f.blockY), bz: uint8(f.blockZ), pc: uint8(pc)}
+			if t != nil && partitionTables.m[key] == nil {
+				partitionTables.m[key] = newPartitionTable(f.blockX*f.blockY*f.blockZ, pc, t)
+			}
+		}
+		partitionTables.mu.Unlock()
+
+		decimationTables.mu.Lock()
+		if decimationTables.m == nil {
+			decimationTables.m = make(map[decimationKey][]decimationEntry)
+		}
+		for k, t := range f.decimation {
+			if _, ok := decimationTables.m[k]; !ok {
+				decimationTables.m[k] = t
+			}
+		}
+		decimationTables.mu.Unlock()
+
+		if f.haveModes {
+			key := makeBlockModeCacheKey(f.blockX, f.blockY, f.blockZ)
+			blockModeCacheMu.RLock()
+			_, have := blockModeCache[key]
+			blockModeCacheMu.RUnlock()
+			if !have {
+				modes := make([]blockModeDesc, len(f.modes))
+				for i, mode := range f.modes {
+					modes[i] = makeBlockModeDesc(mode, f.blockX, f.blockY, f.blockZ)
+				}
+				blockModeCacheMu.Lock()
+				if _, have := blockModeCache[key]; !have {
+					blockModeCache[key] = modes
+				}
+				blockModeCacheMu.Unlock()
+			}
+		}
+	}
+	return nil
+}
+
+// partitionTableValid checks a loaded partition table: every entry must name one of the
+// partitionCount partitions, which decoding relies on, and a few recomputed partitionings must
+// match, which catches caches written by a build with different partition tables.
+func partitionTableValid(data []uint8, blockX, blockY, blockZ, partitionCount int) bool {
+	for _, p := range data {
+		if int(p) >= partitionCount {
+			return false
+		}
+	}
+	texelCount := blockX * blockY * blockZ
+	for _, pidx := range [...]int{0, 1, 341, 682, 1023} {
+		tix := pidx * texelCount
+		for z := 0; z < blockZ; z++ {
+			for y := 0; y < blockY; y++ {
+				for x := 0; x < blockX; x++ {
+					if data[tix] != selectPartition(pidx, x, y, z, partitionCount, texelCount < 32) {
+						return false
+					}
+					tix++
+				}
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,206 @@
+package astc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"maps"
+	"reflect"
+	"testing"
+
+	"github.com/arm-software/astc-encoder/astc/testimage"
+)
+
+// forgetAccelerationTables removes the cached tables of a footprint, as in a fresh process.
+func forgetAccelerationTables(blockX, blockY, blockZ int) {
+	blockModeCacheMu.Lock()
+	delete(blockModeCache, makeBlockModeCacheKey(blockX, blockY, blockZ))
+	blockModeCacheMu.Unlock()
+	partitionTables.mu.Lock()
+	for pc := 2; pc <= blockMaxPartitions; pc++ {
+		delete(partitionTables.m, partitionTableKey{bx: uint8(blockX), by: uint8(blockY), bz: uint8(blockZ), pc: uint8(pc)})
+	}
+	partitionTables.mu.Unlock()
+	decimationTables.mu.Lock()
+	for k := range decimationTables.m {
+		if int(k.bx) == blockX && int(k.by) == blockY && int(k.bz) == blockZ {
+			delete(decimationTables.m, k)
+		}
+	}
+	decimationTables.mu.Unlock()
+	decodeContexts.mu.Lock()
+	if old := decodeContexts.m.Load(); old != nil {
+		m := maps.Clone(*old)
+		delete(m, decodeContextKey{bx: uint8(blockX), by: uint8(blockY), bz: uint8(blockZ)})
+		decodeContexts.m.Store(&m)
+	}
+	decodeContexts.mu.Unlock()
+}
+
+func TestAccelerationCache_RoundTrip(t *testing.T) {
+	footprints := [][3]int{{10, 8, 1}, {4, 4, 4}}
+	type tables struct {
+		modes      []blockModeDesc
+		partitions [blockMaxPartitions + 1][]uint8
+		decoded    [1 << 11]blockModeInfo
+	}
+	snapshot := func(f [3]int) tables {
+		var s tables
+		s.modes = validBlockModes(f[0], f[1], f[2])
+		for pc := 2; pc <= blockMaxPartitions; pc++ {
+			s.partitions[pc] = getPartitionTable(f[0], f[1], f[2], pc).data
+		}
+		ctx := getDecodeContext(f[0], f[1], f[2])
+		for mode := range s.decoded {
+			s.decoded[mode] = *ctx.blockMode(mode)
+		}
+		return s
+	}
+
+	var want []tables
+	for _, f := range footprints {
+		if err := PrecomputeAccelerationTables(f[0], f[1], f[2]); err != nil {
+			t.Fatalf("PrecomputeAccelerationTables(%v): %v", f, err)
+		}
+		want = append(want, snapshot(f))
+	}
+	var saved bytes.Buffer
+	if err := SaveAccelerationCache(&saved); err != nil {
+		t.Fatalf("SaveAccelerationCache: %v", err)
+	}
+
+	for _, f := range footprints {
+		forgetAccelerationTables(f[0], f[1], f[2])
+	}
+	if err := LoadAccelerationCache(bytes.NewReader(saved.Bytes())); err != nil {
+		t.Fatalf("LoadAccelerationCache: %v", err)
+	}
+	for i, f := range footprints {
+		// validBlockModes must return the loaded list rather than build a new one.
+		blockModeCacheMu.RLock()
+		_, loaded := blockModeCache[makeBlockModeCacheKey(f[0], f[1], f[2])]
+		blockModeCacheMu.RUnlock()
+		if !loaded {
+			t.Fatalf("%v: block modes not loaded", f)
+		}
+		if got := snapshot(f); !reflect.DeepEqual(got, want[i]) {
+			t.Fatalf("%v: loaded tables differ from the built ones", f)
+		}
+	}
+
+	var again bytes.Buffer
+	if err := SaveAccelerationCache(&again); err != nil {
+		t.Fatalf("SaveAccelerationCache: %v", err)
+	}
+	if !bytes.Equal(again.Bytes(), saved.Bytes()) {
+		t.Fatalf("re-saved cache differs")
+	}
+}
+
+func TestAccelerationCache_Errors(t *testing.T) {
+	if err := PrecomputeAccelerationTables(7, 7, 1); err == nil {
+		t.Fatalf("expected error for an invalid footprint")
+	}
+	if err := PrecomputeAccelerationTables(5, 5, 1); err != nil {
+		t.Fatalf("PrecomputeAccelerationTables: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := SaveAccelerationCache(&buf); err != nil {
+		t.Fatalf("SaveAccelerationCache: %v", err)
+	}
+	good := buf.Bytes()
+
+	corrupt := append([]byte(nil), good...)
+	corrupt[len(corrupt)/2] ^= 1
+	badVersion := append([]byte(nil), good...)
+	badVersion[4] = accelCacheVersion + 1
+	for name, data := range map[string][]byte{
+		"empty":     nil,
+		"magic":     append([]byte("XXXX"), good[4:]...),
+		"version":   badVersion,
+		"checksum":  corrupt,
+		"truncated": good[:len(good)-5],
+	} {
+		if err := LoadAccelerationCache(bytes.NewReader(data)); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestAccelerationCache_RejectsBadTables(t *testing.T) {
+	const bx, by, texels = 4, 4, 16
+	if err := PrecomputeAccelerationTables(bx, by, 1); err != nil {
+		t.Fatalf("PrecomputeAccelerationTables: %v", err)
+	}
+	// A well-formed cache holding the 4x4 tables only; mutate edits its body before the
+	// checksum is computed, as a crafted or stale cache would be.
+	build := func(mutate func(body []byte)) []byte {
+		body := binary.LittleEndian.AppendUint16(nil, 1)
+		body = appendAccelFootprint(body, bx, by, 1)
+		mutate(body)
+		out := append(append([]byte(nil), accelCacheMagic[:]...), accelCacheVersion)
+		out = append(out, body...)
+		return binary.LittleEndian.AppendUint32(out, crc32.ChecksumIEEE(body))
+	}
+	// Offsets of the first partition table, the first block mode and the first decimation weight.
+	const partitions = 2 + 4
+	const modes = partitions + 3*(1<<10)*texels + 2
+	decimation := func(body []byte) int {
+		n := int(binary.LittleEndian.Uint16(body[modes-2:]))
+		return modes + 2*n + 2 + 3 + 4
+	}
+
+	if err := LoadAccelerationCache(bytes.NewReader(build(func([]byte) {}))); err != nil {
+		t.Fatalf("unmodified cache: %v", err)
+	}
+	for name, mutate := range map[string]func([]byte){
+		// Partition index 2 is not among the recomputed ones.
+		"partition out of range": func(b []byte) { b[partitions+2*texels] = 2 },
+		"mode order": func(b []byte) {
+			copy(b[modes:modes+2], b[modes+2:modes+4])
+		},
+		"decimation weights": func(b []byte) { b[decimation(b)]++ },
+		"decimation index":   func(b []byte) { b[decimation(b)-4] = 255 },
+	} {
+		if err := LoadAccelerationCache(bytes.NewReader(build(mutate))); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestAccelerationCache_SameEncoderOutput(t *testing.T) {
+	const w, h = 128, 128
+	pix, err := testimage.RGBA8(testimage.KindPerlin, w, h, 1, testimage.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	encode := func() []byte {
+		cfg, err := ConfigInit(ProfileLDR, 6, 6, 1, EncodeMedium.Level(), 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx, err := ContextAlloc(&cfg, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out := make([]byte, ((w+5)/6)*((h+5)/6)*BlockBytes)
+		img := Image{DimX: w, DimY: h, DimZ: 1, DataType: TypeU8, DataU8: pix}
+		if err := ctx.CompressImage(&img, SwizzleRGBA, out, 0); err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+
+	built := encode()
+	var saved bytes.Buffer
+	if err := SaveAccelerationCache(&saved); err != nil {
+		t.Fatalf("SaveAccelerationCache: %v", err)
+	}
+	forgetAccelerationTables(6, 6, 1)
+	if err := LoadAccelerationCache(bytes.NewReader(saved.Bytes())); err != nil {
+		t.Fatalf("LoadAccelerationCache: %v", err)
+	}
+	if !bytes.Equal(encode(), built) {
+		t.Fatalf("encoding with loaded tables differs from encoding with built tables")
+	}
+}
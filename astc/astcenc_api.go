@@ -3,6 +3,8 @@ package astc
 import (
 	"math"
 	"runtime"
+	"sync/atomic"
+	"time"
 )
 
 // ConfigInit populates a Config using defaults equivalent to upstream astcenc_config_init.
@@ -12,7 +14,7 @@ func ConfigInit(profile Profile, blockX, blockY, blockZ int, quality float32, fl
 		blockZ = 1
 	}
 
-	if quality < 0 || quality > 100 {
+	if !(quality >= 0 && quality <= 100) {
 		return Config{}, newError(ErrBadQuality, "astc: invalid quality")
 	}
 	if err := validateBlockSize(blockX, blockY, blockZ); err != nil {
@@ -226,9 +228,41 @@ func (c *Context) Close() error {
 }
 
 func (c *Context) CompressImage(img *Image, swizzle Swizzle, out []byte, threadIndex int) error {
+	return c.compressImage(nil, img, nil, 0, swizzle, out, threadIndex)
+}
+
+// CompressImageWithHint compresses img like CompressImage, reusing the block at the same position
+// in prevBlocks where it is still close enough, typically the previous frame of a flipbook or
+// dynamically painted texture. The hint does not steer the search of the other blocks.
+//
+// A previous block whose channel-weighted mean squared error against the new texels (in 8-bit
+// units, averaged over texels and normalized by the sum of the channel weights) is at most maxMSE
+// is reused without searching; with maxMSE 0 only exact matches are reused. Other blocks are
+// searched as usual, and the previous block is still kept if the search finds nothing better.
+//
+// prevBlocks must hold a block for every block of img (with the same block size and profile) and
+// may alias out; nil disables the hint. Hints are only supported for LDR profiles. When compressing
+// with multiple threads, every thread must pass the same arguments.
+func (c *Context) CompressImageWithHint(img *Image, prevBlocks []byte, maxMSE float32, swizzle Swizzle, out []byte, threadIndex int) error {
+	return c.compressImage(nil, img, prevBlocks, maxMSE, swizzle, out, threadIndex)
+}
+
+// compressImage implements CompressImageWithHint. Closing done stops the thread between blocks like
+// CompressCancel, but only for this call; nil never stops it.
+func (c *Context) compressImage(done <-chan struct{}, img *Image, prevBlocks []byte, maxMSE float32, swizzle Swizzle, out []byte, threadIndex int) (err error) {
 	if c == nil {
 		return newError(ErrBadContext, "astc: nil context")
 	}
+	// Failed images are counted once in endCompress; a call rejected before joining one is counted
+	// by thread 0 alone, so an image rejected on every thread still counts once.
+	rejected := true
+	if m := c.cfg.Metrics; m != nil {
+		defer func() {
+			if err != nil && rejected && threadIndex == 0 {
+				m.Count(MetricErrors, 1)
+			}
+		}()
+	}
 	if img == nil {
 		return newError(ErrBadParam, "astc: nil image")
 	}
@@ -260,57 +294,125 @@ func (c *Context) CompressImage(img *Image, swizzle Swizzle, out []byte, threadI
 	if blocksX <= 0 || blocksY <= 0 || blocksZ <= 0 {
 		return newError(ErrBadParam, "astc: invalid image dimensions")
 	}
+	aligned := img.DimX%blockX == 0 && img.DimY%blockY == 0 && img.DimZ%blockZ == 0
+	if c.cfg.EdgeMode == EdgeError && !aligned {
+		return newError(ErrBadParam, "astc: image dimensions are not multiples of the block size")
+	}
+	padEdges := c.cfg.EdgeMode == EdgePad && !aligned
 	totalBlocks := blocksX * blocksY * blocksZ
+	if err := validateBlockMask(img.BlockMask, totalBlocks); err != nil {
+		return err
+	}
 	needOut := totalBlocks * BlockBytes
 	if len(out) < needOut {
 		return newError(ErrOutOfMem, "astc: output buffer too small")
 	}
+	var hint *blockHint
+	if prevBlocks != nil {
+		if c.cfg.Profile != ProfileLDR && c.cfg.Profile != ProfileLDRSRGB {
+			return newError(ErrBadParam, "astc: block hints require an LDR profile")
+		}
+		if len(prevBlocks) < needOut {
+			return newError(ErrBadParam, "astc: hint blocks too short")
+		}
+		if !(maxMSE >= 0) {
+			return newError(ErrBadParam, "astc: invalid hint error threshold")
+		}
+		hint = newBlockHint(c.cfg.Profile, c.decodeCtx, prevBlocks, maxMSE)
+	}
 
+	// A thread which fails to join, e.g. one arriving after the image was finished, does not fail
+	// the image.
+	rejected = false
 	if err := c.beginCompress(uint32(totalBlocks), img, swizzle, inType); err != nil {
 		return err
 	}
-	defer c.endCompress()
+	defer func() {
+		if logErr := c.endCompress(); err == nil {
+			err = logErr
+		}
+	}()
+	if c.compress.sanitizeErr != nil {
+		return c.compress.sanitizeErr
+	}
+	if c.compress.blockOrderErr != nil {
+		return c.compress.blockOrderErr
+	}
+	if c.compress.sanitized != nil {
+		img = c.compress.sanitized
+	}
+	var diffusion *blockDiffusion
+	if c.cfg.ExperimentalBlockErrorDiffusion && inType == TypeU8 && (c.cfg.Profile == ProfileLDR || c.cfg.Profile == ProfileLDRSRGB) {
+		// Each block depends on the previous one, so one thread encodes the whole image.
+		if !c.compress.diffusionClaimed.CompareAndSwap(false, true) {
+			return nil
+		}
+		diffusion = &blockDiffusion{decoded: make([]byte, c.blockX*c.blockY*c.blockZ*4)}
+	}
 
 	planeBlocks := blocksX * blocksY
-
 	texelCount := blockX * blockY * blockZ
-	u8BlockTexels := make([]byte, texelCount*4)
-	f32BlockTexels := make([]float32, texelCount*4)
+	storedIndex := c.compress.storedIndex
 
 	quality := encodeQualityFromConfig(c.cfg)
 	baseWeight := [4]float32{c.cfg.CWRWeight, c.cfg.CWGWeight, c.cfg.CWBWeight, c.cfg.CWAWeight}
 	tune := encoderTuningFromConfig(c.cfg)
+	if hint != nil {
+		hint.restrict(&tune)
+	}
+	hdr := c.cfg.Profile == ProfileHDR || c.cfg.Profile == ProfileHDRRGBLDRAlpha
+	encProfile := c.cfg.Profile
+	if encProfile == ProfileHDR && c.cfg.HDRInput&HDRAlphaUNORM != 0 {
+		encProfile = ProfileHDRRGBLDRAlpha
+	}
+
+	var padU8 [4]uint8
+	for ch, v := range c.cfg.EdgePadColor {
+		padU8[ch] = float01ToUnorm8(v)
+	}
 
 	total := int(c.compress.totalBlocks.Load())
-	for {
-		if c.compress.cancel.Load() != 0 {
-			break
+	decisions := c.compress.decisions
+	varianceWeights := c.compress.inputVarianceWeights
+	var decodedU8 []byte
+	var decodedF32 []float32
+	if decisions != nil {
+		decodedU8 = make([]byte, texelCount*4)
+		decodedF32 = make([]float32, texelCount*4)
+	}
+
+	// extract claims the next block and fills job with its swizzled texels. It returns false once
+	// all blocks are claimed or the compression is cancelled.
+	extract := func(job *compressJob) bool {
+		if c.compress.cancel.Load() != 0 || isDone(done) {
+			return false
 		}
 		i := int(c.compress.nextBlock.Add(1) - 1)
 		if i < 0 || i >= total {
-			break
+			return false
 		}
 
 		bz := i / planeBlocks
 		rem := i - bz*planeBlocks
 		by := rem / blocksX
 		bx := rem - by*blocksX
+		if diffusion != nil && by%2 == 1 {
+			bx = blocksX - 1 - bx
+			i = bz*planeBlocks + by*blocksX + bx
+		}
+		job.index, job.bx, job.by, job.bz = i, bx, by, bz
 
 		x0 := bx * blockX
 		y0 := by * blockY
 		z0 := bz * blockZ
 
-		dstOff := i * BlockBytes
-		dst := out[dstOff : dstOff+BlockBytes]
-
-		var blk [BlockBytes]byte
-		useFullBlock := true
+		job.fullBlock = true
 		if c.cfg.AScaleRadius != 0 && blockZ == 1 {
 			switch swizzle.A {
 			case Swz1:
-				useFullBlock = true
+				job.fullBlock = true
 			case Swz0:
-				useFullBlock = false
+				job.fullBlock = false
 			default:
 				alphaAverages := c.compress.inputAlphaAverages
 				if alphaAverages != nil {
@@ -337,7 +439,7 @@ func (c *Context) CompressImage(img *Image, swizzle Swizzle, out []byte, threadI
 						threshold = 0.9 / (255.0 * footprint)
 					}
 
-					useFullBlock = false
+					useFullBlock := false
 					zBase := z0 * img.DimY * img.DimX
 					for ay := startY; ay < endY && !useFullBlock; ay++ {
 						rowBase := zBase + ay*img.DimX
@@ -348,11 +450,136 @@ func (c *Context) CompressImage(img *Image, swizzle Swizzle, out []byte, threadI
 							}
 						}
 					}
+					job.fullBlock = useFullBlock
 				}
 			}
 		}
+		if !blockResident(img.BlockMask, i) {
+			job.fullBlock = false
+		}
+		if !job.fullBlock && decisions == nil {
+			return true
+		}
+
+		job.varianceScale = 1
+		if c.cfg.VarianceRadius != 0 {
+			ext := int(c.cfg.VarianceRadius) - 1
+			sd := blockLumaStdDev(img, inType, x0, y0, z0, blockX, blockY, blockZ, ext)
+			job.varianceScale = varianceScale(sd, c.cfg.VariancePower)
+		}
+		job.importanceEffort = 1
+		if img.Importance != nil {
+			job.importanceEffort = importanceEffort(blockImportance(img, x0, y0, z0, blockX, blockY, blockZ))
+		}
+		job.texelWeighted = varianceWeights != nil || img.Importance != nil
+		if job.texelWeighted {
+			for t := range job.texelWeights {
+				job.texelWeights[t] = 1
+			}
+			if varianceWeights != nil {
+				varianceTexelWeights(img, varianceWeights, x0, y0, z0, blockX, blockY, blockZ, job.texelWeights)
+			}
+			if img.Importance != nil {
+				importanceTexelWeights(img, x0, y0, z0, blockX, blockY, blockZ, job.texelWeights)
+			}
+		}
+
+		switch inType {
+		case TypeU8:
+			extractBlockRGBA8VolumeLayout(img.DataU8, img.Layout, img.DimX, img.DimY, img.DimZ, x0, y0, z0, blockX, blockY, blockZ, job.u8)
+			if padEdges {
+				padBlockEdgeRGBA8(img.DimX, img.DimY, img.DimZ, x0, y0, z0, blockX, blockY, blockZ, padU8, job.u8)
+			}
+			applySwizzleRGBA8InPlace(job.u8, swizzle)
+		case TypeF16, TypeF32:
+			if inType == TypeF16 {
+				extractBlockRGBAF16ToF32Volume(img.DataF16, img.DimX, img.DimY, img.DimZ, x0, y0, z0, blockX, blockY, blockZ, job.f32)
+			} else {
+				extractBlockRGBAF32Volume(img.DataF32, img.DimX, img.DimY, img.DimZ, x0, y0, z0, blockX, blockY, blockZ, job.f32)
+			}
+			if padEdges {
+				padBlockEdgeRGBAF32(img.DimX, img.DimY, img.DimZ, x0, y0, z0, blockX, blockY, blockZ, c.cfg.EdgePadColor, job.f32)
+			}
+			applySwizzleRGBAF32InPlace(job.f32, swizzle)
+			if hdr {
+				applyHDRInputPolicy(job.f32, c.cfg.HDRInput)
+			}
+		}
+		return true
+	}
+
+	// Two-stage pipeline: a helper goroutine claims blocks and extracts their texels into one of
+	// two buffers while this goroutine encodes the other. Each block is still encoded from its own
+	// texels only, so the output does not depend on the pipelining.
+	free := make(chan *compressJob, 2)
+	jobs := make(chan *compressJob, 2)
+	for k := 0; k < 2; k++ {
+		free <- &compressJob{u8: make([]byte, texelCount*4), f32: make([]float32, texelCount*4), texelWeights: make([]float32, texelCount)}
+	}
+	stop := make(chan struct{})
+	go func() {
+		defer close(jobs)
+		for {
+			var job *compressJob
+			select {
+			case job = <-free:
+			case <-stop:
+				return
+			}
+			if !extract(job) {
+				return
+			}
+			jobs <- job
+		}
+	}()
+	defer func() {
+		close(stop)
+		for range jobs {
+		}
+	}()
+
+	for job := range jobs {
+		if c.compress.cancel.Load() != 0 || isDone(done) {
+			break
+		}
+		i := job.index
+		// The payload and hint blocks are in the configured block order; decisions are linear.
+		outIdx := i
+		if storedIndex != nil {
+			outIdx = storedIndex[i]
+		}
+		dstOff := outIdx * BlockBytes
+		dst := out[dstOff : dstOff+BlockBytes]
+		if tune.stochasticIterations > 0 {
+			tune.stochasticSeed = stochasticBlockSeed(job.bx, job.by, job.bz)
+		}
+		if seeds := c.compress.partitionSeeds; seeds != nil {
+			tune.partitionSeeds = [2]uint16{}
+			if job.bx > 0 {
+				tune.partitionSeeds[0] = uint16(seeds[i-1].Load())
+			}
+			if job.by > 0 {
+				tune.partitionSeeds[1] = uint16(seeds[i-blocksX].Load())
+			}
+		}
 
-		if !useFullBlock {
+		var blk [BlockBytes]byte
+		blockWeight := baseWeight
+		blockTune := &tune
+		if c.cfg.VarianceRadius != 0 {
+			varianceTune := tune.withVarianceEffort(job.varianceScale)
+			blockTune = &varianceTune
+		}
+		if img.Importance != nil {
+			importanceTune := blockTune.withEffortScale(job.importanceEffort)
+			blockTune = &importanceTune
+		}
+		if job.texelWeighted {
+			weightedTune := *blockTune
+			weightedTune.texelWeights = job.texelWeights
+			blockTune = &weightedTune
+		}
+		if !job.fullBlock {
 			if c.cfg.Profile == ProfileLDR || c.cfg.Profile == ProfileLDRSRGB {
 				blk = EncodeConstBlockRGBA8(0, 0, 0, 0)
 			} else {
@@ -362,14 +589,10 @@ func (c *Context) CompressImage(img *Image, swizzle Swizzle, out []byte, threadI
 		} else {
 			switch inType {
 			case TypeU8:
-				extractBlockRGBA8Volume(img.DataU8, img.DimX, img.DimY, img.DimZ, x0, y0, z0, blockX, blockY, blockZ, u8BlockTexels)
-				applySwizzleRGBA8InPlace(u8BlockTexels[:texelCount*4], swizzle)
-
-				blockWeight := baseWeight
 				if (c.cfg.Flags & FlagUseAlphaWeight) != 0 {
 					maxA := uint8(0)
 					for t := 0; t < texelCount; t++ {
-						a := u8BlockTexels[t*4+3]
+						a := job.u8[t*4+3]
 						if a > maxA {
 							maxA = a
 						}
@@ -380,18 +603,24 @@ func (c *Context) CompressImage(img *Image, swizzle Swizzle, out []byte, threadI
 					blockWeight[2] *= alphaScale
 				}
 
-				blk, err = encodeBlockRGBA8LDR(c.cfg.Profile, blockX, blockY, blockZ, u8BlockTexels[:texelCount*4], quality, blockWeight, c.cfg.Flags, c.cfg.RGBMMScale, &tune)
-			case TypeF16:
-				extractBlockRGBAF16ToF32Volume(img.DataF16, img.DimX, img.DimY, img.DimZ, x0, y0, z0, blockX, blockY, blockZ, f32BlockTexels)
-				applySwizzleRGBAF32InPlace(f32BlockTexels[:texelCount*4], swizzle)
-
-				blockWeight := baseWeight
+				if diffusion != nil {
+					diffusion.apply(job.u8)
+				}
+				if hint != nil && hint.reuse(outIdx, job.u8, blockWeight, &blk) {
+					c.compress.hintHits.Add(1)
+					break
+				}
+				blk, err = encodeBlockRGBA8LDR(c.cfg.Profile, blockX, blockY, blockZ, job.u8, quality, blockWeight, c.cfg.Flags, c.cfg.RGBMMScale, blockTune)
+				if hint != nil && err == nil {
+					hint.keepBetter(outIdx, job.u8, blockWeight, &blk)
+				}
+			case TypeF16, TypeF32:
 				if (c.cfg.Flags & FlagUseAlphaWeight) != 0 {
 					alphaScale := float32(0)
-					if c.cfg.Profile == ProfileHDR {
+					if encProfile == ProfileHDR {
 						maxCode := uint16(0)
 						for t := 0; t < texelCount; t++ {
-							code := hdrTexelToLNS(f32BlockTexels[t*4+3])
+							code := hdrTexelToLNS(job.f32[t*4+3])
 							if code > maxCode {
 								maxCode = code
 							}
@@ -399,7 +628,7 @@ func (c *Context) CompressImage(img *Image, swizzle Swizzle, out []byte, threadI
 						alphaScale = float32(maxCode) * (1.0 / 65535.0)
 					} else {
 						for t := 0; t < texelCount; t++ {
-							a := f32BlockTexels[t*4+3]
+							a := job.f32[t*4+3]
 							if a > alphaScale {
 								alphaScale = a
 							}
@@ -413,57 +642,74 @@ func (c *Context) CompressImage(img *Image, swizzle Swizzle, out []byte, threadI
 					blockWeight[2] *= alphaScale
 				}
 
-				blk, err = encodeBlockForF32Input(c.cfg.Profile, blockX, blockY, blockZ, f32BlockTexels[:texelCount*4], quality, blockWeight, c.cfg.Flags, c.cfg.RGBMMScale, &tune)
-			case TypeF32:
-				extractBlockRGBAF32Volume(img.DataF32, img.DimX, img.DimY, img.DimZ, x0, y0, z0, blockX, blockY, blockZ, f32BlockTexels)
-				applySwizzleRGBAF32InPlace(f32BlockTexels[:texelCount*4], swizzle)
-
-				blockWeight := baseWeight
-				if (c.cfg.Flags & FlagUseAlphaWeight) != 0 {
-					alphaScale := float32(0)
-					if c.cfg.Profile == ProfileHDR {
-						maxCode := uint16(0)
-						for t := 0; t < texelCount; t++ {
-							code := hdrTexelToLNS(f32BlockTexels[t*4+3])
-							if code > maxCode {
-								maxCode = code
-							}
-						}
-						alphaScale = float32(maxCode) * (1.0 / 65535.0)
-					} else {
-						for t := 0; t < texelCount; t++ {
-							a := f32BlockTexels[t*4+3]
-							if a > alphaScale {
-								alphaScale = a
-							}
-						}
-						if !(alphaScale >= 0) {
-							alphaScale = 0
-						}
+				if hint != nil {
+					quantizeRGBAF32ToU8(job.f32, job.u8)
+					if hint.reuse(outIdx, job.u8, blockWeight, &blk) {
+						c.compress.hintHits.Add(1)
+						break
 					}
-					blockWeight[0] *= alphaScale
-					blockWeight[1] *= alphaScale
-					blockWeight[2] *= alphaScale
 				}
-
-				blk, err = encodeBlockForF32Input(c.cfg.Profile, blockX, blockY, blockZ, f32BlockTexels[:texelCount*4], quality, blockWeight, c.cfg.Flags, c.cfg.RGBMMScale, &tune)
+				blk, err = encodeBlockForF32Input(encProfile, blockX, blockY, blockZ, job.f32, quality, blockWeight, c.cfg.Flags, c.cfg.RGBMMScale, blockTune)
+				if hint != nil && err == nil {
+					hint.keepBetter(outIdx, job.u8, blockWeight, &blk)
+				}
 			default:
 				return newError(ErrBadParam, "astc: unsupported image data type")
 			}
 		}
+		if diffusion != nil && err == nil {
+			if job.fullBlock {
+				diffusion.update(c.cfg.Profile, c.decodeCtx, blk[:])
+			} else {
+				diffusion.carry = [4]float32{}
+			}
+		}
+		if decisions != nil && err == nil {
+			d := c.blockDecision(i, blk[:])
+			if inType == TypeU8 {
+				d.Error = c.blockErrorU8(blk[:], job.u8, blockWeight, decodedU8)
+			} else {
+				d.Error = c.blockErrorF32(blk[:], job.f32, blockWeight, decodedF32)
+			}
+			decisions[i] = d
+		}
+		free <- job
 
 		if err != nil {
 			return err
 		}
 		copy(dst, blk[:])
+		if seeds := c.compress.partitionSeeds; seeds != nil {
+			seeds[i].Store(uint32(blockPartitionSeed(blk[:], c.decodeCtx)))
+		}
 
-		done := c.compress.doneBlocks.Add(1)
-		c.maybeReportProgress(done, uint32(total), c.cfg.ProgressCallback)
+		doneCount := c.compress.doneBlocks.Add(1)
+		c.maybeReportProgress(doneCount, uint32(total), c.cfg.ProgressCallback)
 	}
 
 	return nil
 }
 
+// compressJob is one extracted block in the CompressImage pipeline.
+type compressJob struct {
+	index      int
+	bx, by, bz int
+	fullBlock  bool
+
+	// varianceScale is the Config.VarianceRadius scale of the block, or 1.
+	varianceScale float32
+
+	// importanceEffort is the Image.Importance search effort factor of the block, or 1.
+	importanceEffort float32
+	// texelWeights holds the error weight of each texel from Config.VarianceRadius and
+	// Image.Importance if texelWeighted is set.
+	texelWeights  []float32
+	texelWeighted bool
+
+	u8  []byte
+	f32 []float32
+}
+
 func (c *Context) CompressReset() error {
 	if c == nil {
 		return newError(ErrBadContext, "astc: nil context")
@@ -485,10 +731,32 @@ func (c *Context) CompressCancel() error {
 	return nil
 }
 
+// DecompressImage decodes the block payload data into imgOut. The pixel buffer of imgOut may
+// overlap data (e.g. when both live in one arena); the payload is then copied before decoding.
 func (c *Context) DecompressImage(data []byte, imgOut *Image, swizzle Swizzle, threadIndex int) error {
+	return c.DecompressImageWithOptions(data, imgOut, swizzle, threadIndex, DecodeOptions{})
+}
+
+// DecompressImageWithOptions is DecompressImage with additional post-processing selected by opts.
+func (c *Context) DecompressImageWithOptions(data []byte, imgOut *Image, swizzle Swizzle, threadIndex int, opts DecodeOptions) error {
+	return c.decompressImage(nil, data, imgOut, swizzle, threadIndex, opts)
+}
+
+// decompressImage implements DecompressImageWithOptions. Closing done stops the thread between
+// blocks; nil never stops it.
+func (c *Context) decompressImage(done <-chan struct{}, data []byte, imgOut *Image, swizzle Swizzle, threadIndex int, opts DecodeOptions) (err error) {
 	if c == nil {
 		return newError(ErrBadContext, "astc: nil context")
 	}
+	// Failed images are counted once in endDecompress; see compressImage.
+	rejected := true
+	if m := c.cfg.Metrics; m != nil {
+		defer func() {
+			if err != nil && rejected && threadIndex == 0 {
+				m.Count(MetricErrors, 1)
+			}
+		}()
+	}
 	if imgOut == nil {
 		return newError(ErrBadParam, "astc: nil output image")
 	}
@@ -501,6 +769,21 @@ func (c *Context) DecompressImage(data []byte, imgOut *Image, swizzle Swizzle, t
 	if err := validateDecompressionSwizzle(swizzle); err != nil {
 		return err
 	}
+	if opts.Tonemap > TonemapACES {
+		return newError(ErrBadParam, "astc: invalid tonemap")
+	}
+	if !(opts.Exposure >= 0) || math.IsInf(float64(opts.Exposure), 1) {
+		return newError(ErrBadParam, "astc: invalid exposure")
+	}
+	if opts.Transform != nil && !opts.Transform.valid() {
+		return newError(ErrBadParam, "astc: invalid channel transform")
+	}
+	if opts.RoundingProfile > RoundingRTN {
+		return newError(ErrBadParam, "astc: invalid rounding profile")
+	}
+	if !(opts.Deblock >= 0 && opts.Deblock <= 1) {
+		return newError(ErrBadParam, "astc: invalid deblocking strength")
+	}
 
 	// Single-threaded contexts implicitly reset between images (matches upstream).
 	if c.threadCount == 1 {
@@ -519,25 +802,56 @@ func (c *Context) DecompressImage(data []byte, imgOut *Image, swizzle Swizzle, t
 		return newError(ErrBadParam, "astc: invalid image dimensions")
 	}
 	totalBlocks := blocksX * blocksY * blocksZ
+	if err := validateBlockMask(imgOut.BlockMask, totalBlocks); err != nil {
+		return err
+	}
+	limitHeader := Header{
+		BlockX: uint8(blockX), BlockY: uint8(blockY), BlockZ: uint8(blockZ),
+		SizeX: uint32(imgOut.DimX), SizeY: uint32(imgOut.DimY), SizeZ: uint32(imgOut.DimZ),
+	}
+	pixels := int64(imgOut.DimX) * int64(imgOut.DimY) * int64(imgOut.DimZ)
+	if err := checkDecodeSize(limitHeader, pixels, int64(totalBlocks), opts.MaxPixels, opts.MaxBlocks); err != nil {
+		return err
+	}
 	needBlocks := totalBlocks * BlockBytes
 	if len(data) < needBlocks {
 		return newError(ErrOutOfMem, "astc: block buffer too small")
 	}
 
-	if err := c.beginDecompress(uint32(totalBlocks)); err != nil {
+	rejected = false
+	if err := c.beginDecompress(uint32(totalBlocks), data[:needBlocks], imgOut); err != nil {
 		return err
 	}
 	defer c.endDecompress()
+	data = c.decompress.input
+	if c.decompress.blockOrderErr != nil {
+		return c.decompress.blockOrderErr
+	}
 
 	planeBlocks := blocksX * blocksY
+	storedIndex := c.decompress.storedIndex
 
 	texelCount := blockX * blockY * blockZ
 	u8Decoded := make([]byte, texelCount*4)
 	f32Decoded := make([]float32, texelCount*4)
 
+	// Float outputs use decode_unorm8 rounding when requested, or when linearizing sRGB data.
+	profile := c.cfg.Profile
+	isLDR := profile == ProfileLDR || profile == ProfileLDRSRGB
+	linearize := profile == ProfileLDRSRGB && c.cfg.DecodeOutputColorSpace == ColorSpaceLinear
+	floatViaU8 := isLDR && (linearize || (c.cfg.Flags&FlagUseDecodeUNORM8) != 0)
+	decodeFloat := func(block []byte) {
+		if !floatViaU8 {
+			decodeBlockToRGBAF32(profile, c.decodeCtx, block, f32Decoded)
+			return
+		}
+		decodeBlockToRGBA8(profile, c.decodeCtx, block, u8Decoded)
+		unorm8ToRGBAF32(u8Decoded[:texelCount*4], f32Decoded, linearize)
+	}
+
 	// All threads run until no work remaining.
 	total := int(c.decompress.totalBlocks.Load())
-	for {
+	for !isDone(done) {
 		i := int(c.decompress.nextBlock.Add(1) - 1)
 		if i < 0 || i >= total {
 			break
@@ -552,31 +866,66 @@ func (c *Context) DecompressImage(data []byte, imgOut *Image, swizzle Swizzle, t
 		y0 := by * blockY
 		z0 := bz * blockZ
 
+		if !blockResident(imgOut.BlockMask, i) {
+			if int(c.decompress.doneBlocks.Add(1)) == total && opts.Deblock > 0 {
+				deblockImage(imgOut, blockX, blockY, blockZ, opts.Deblock, opts.FlipY, imgOut.BlockMask)
+			}
+			continue
+		}
+
 		srcOff := i * BlockBytes
+		if storedIndex != nil {
+			srcOff = storedIndex[i] * BlockBytes
+		}
 		block := data[srcOff : srcOff+BlockBytes]
 
 		switch imgOut.DataType {
 		case TypeU8:
-			if c.cfg.Profile == ProfileLDR || c.cfg.Profile == ProfileLDRSRGB {
+			if isLDR && opts.RoundingProfile != RoundingReference {
+				decodeBlockToRGBA8Rounded(c.cfg.Profile, c.decodeCtx, block, u8Decoded, opts.RoundingProfile)
+			} else if isLDR {
 				decodeBlockToRGBA8(c.cfg.Profile, c.decodeCtx, block, u8Decoded)
 			} else {
-				// HDR decode to U8: decode to float and quantize.
+				// HDR decode to U8: decode to float, tonemap and quantize.
 				decodeBlockToRGBAF32(c.cfg.Profile, c.decodeCtx, block, f32Decoded)
+				tonemapRGBAF32(f32Decoded[:texelCount*4], opts.Tonemap, opts.Exposure)
 				quantizeRGBAF32ToU8(f32Decoded, u8Decoded)
 			}
+			if opts.RenormalizeNormals {
+				renormalizeNormalsRGBA8(u8Decoded[:texelCount*4])
+			}
 			applySwizzleRGBA8InPlace(u8Decoded[:texelCount*4], swizzle)
-			storeBlockRGBA8Volume(imgOut.DataU8, imgOut.DimX, imgOut.DimY, imgOut.DimZ, x0, y0, z0, blockX, blockY, blockZ, u8Decoded)
+			if opts.Transform != nil {
+				opts.Transform.applyRGBA8(u8Decoded[:texelCount*4])
+			}
+			storeBlockRGBA8Volume(imgOut.DataU8, imgOut.DimX, imgOut.DimY, imgOut.DimZ, x0, y0, z0, blockX, blockY, blockZ, u8Decoded, opts.FlipY)
 		case TypeF32:
-			decodeBlockToRGBAF32(c.cfg.Profile, c.decodeCtx, block, f32Decoded)
+			decodeFloat(block)
+			if opts.RenormalizeNormals {
+				renormalizeNormalsRGBAF32(f32Decoded[:texelCount*4])
+			}
 			applySwizzleRGBAF32InPlace(f32Decoded[:texelCount*4], swizzle)
-			storeBlockRGBAF32Volume(imgOut.DataF32, imgOut.DimX, imgOut.DimY, imgOut.DimZ, x0, y0, z0, blockX, blockY, blockZ, f32Decoded)
+			if opts.Transform != nil {
+				opts.Transform.applyRGBAF32(f32Decoded[:texelCount*4])
+			}
+			storeBlockRGBAF32Volume(imgOut.DataF32, imgOut.DimX, imgOut.DimY, imgOut.DimZ, x0, y0, z0, blockX, blockY, blockZ, f32Decoded, opts.FlipY)
 		case TypeF16:
-			decodeBlockToRGBAF32(c.cfg.Profile, c.decodeCtx, block, f32Decoded)
+			decodeFloat(block)
+			if opts.RenormalizeNormals {
+				renormalizeNormalsRGBAF32(f32Decoded[:texelCount*4])
+			}
 			applySwizzleRGBAF32InPlace(f32Decoded[:texelCount*4], swizzle)
-			storeBlockRGBAF32AsF16Volume(imgOut.DataF16, imgOut.DimX, imgOut.DimY, imgOut.DimZ, x0, y0, z0, blockX, blockY, blockZ, f32Decoded)
+			if opts.Transform != nil {
+				opts.Transform.applyRGBAF32(f32Decoded[:texelCount*4])
+			}
+			storeBlockRGBAF32AsF16Volume(imgOut.DataF16, imgOut.DimX, imgOut.DimY, imgOut.DimZ, x0, y0, z0, blockX, blockY, blockZ, f32Decoded, opts.FlipY)
 		default:
 			return newError(ErrBadParam, "astc: unsupported output image type")
 		}
+
+		if int(c.decompress.doneBlocks.Add(1)) == total && opts.Deblock > 0 {
+			deblockImage(imgOut, blockX, blockY, blockZ, opts.Deblock, opts.FlipY, imgOut.BlockMask)
+		}
 	}
 
 	return nil
@@ -617,7 +966,7 @@ func (c *Context) GetBlockInfo(block [BlockBytes]byte) (BlockInfo, error) {
 		return info, nil
 	}
 
-	bmi := c.decodeCtx.blockModes[scb.blockMode]
+	bmi := c.decodeCtx.blockMode(int(scb.blockMode))
 	if !bmi.ok {
 		info.IsErrorBlock = true
 		return info, nil
@@ -636,6 +985,7 @@ func (c *Context) GetBlockInfo(block [BlockBytes]byte) (BlockInfo, error) {
 	info.WeightLevelCount = uint32(quantLevel(bmi.weightQuant))
 
 	// Unpack color endpoints.
+	unorm16Table, lnsTable := float32Tables()
 	for p := 0; p < int(scb.partitionCount); p++ {
 		format := scb.colorFormats[p]
 		info.ColorEndpointModes[p] = uint32(format)
@@ -654,15 +1004,15 @@ func (c *Context) GetBlockInfo(block [BlockBytes]byte) (BlockInfo, error) {
 				u := uint16(v[cch])
 				if cch < 3 {
 					if rgbHDR {
-						info.ColorEndpoints[p][j][cch] = lnsToFloat32Table[u]
+						info.ColorEndpoints[p][j][cch] = lnsTable[u]
 					} else {
-						info.ColorEndpoints[p][j][cch] = unorm16ToFloat32Table[u]
+						info.ColorEndpoints[p][j][cch] = unorm16Table[u]
 					}
 				} else {
 					if alphaHDR {
-						info.ColorEndpoints[p][j][cch] = lnsToFloat32Table[u]
+						info.ColorEndpoints[p][j][cch] = lnsTable[u]
 					} else {
-						info.ColorEndpoints[p][j][cch] = unorm16ToFloat32Table[u]
+						info.ColorEndpoints[p][j][cch] = unorm16Table[u]
 					}
 				}
 			}
@@ -869,6 +1219,30 @@ func validateAndClampConfig(cfg *Config) error {
 	if err := validateBlockSize(int(cfg.BlockX), int(cfg.BlockY), int(cfg.BlockZ)); err != nil {
 		return err
 	}
+	if cfg.EdgeMode > EdgePad {
+		return newError(ErrBadParam, "astc: invalid edge mode")
+	}
+	if cfg.DecodeOutputColorSpace > ColorSpaceLinear {
+		return newError(ErrBadParam, "astc: invalid decode output color space")
+	}
+	if cfg.BlockOrder > BlockOrderMorton {
+		return newError(ErrBadParam, "astc: invalid block order")
+	}
+	if cfg.InputSanitize > SanitizeError {
+		return newError(ErrBadParam, "astc: invalid input sanitize mode")
+	}
+	if cfg.HDRInput&^hdrInputAll != 0 {
+		return newError(ErrBadParam, "astc: invalid HDR input policy")
+	}
+	if cfg.CompatLevel > compatNewest {
+		return newError(ErrBadParam, "astc: unknown compatibility level")
+	}
+	if cfg.WeightSampling > weightSamplingLast {
+		return newError(ErrBadParam, "astc: invalid weight sampling policy")
+	}
+	if cfg.MaxPartitionCountHard > blockMaxPartitions {
+		return newError(ErrBadParam, "astc: invalid hard partition count limit")
+	}
 
 	if cfg.RGBMMScale < 1 {
 		cfg.RGBMMScale = 1
@@ -883,6 +1257,13 @@ func validateAndClampConfig(cfg *Config) error {
 		cfg.TuneRefinementLimit = 1
 	}
 	cfg.TuneCandidateLimit = clampU32(cfg.TuneCandidateLimit, 1, 8)
+	cfg.TuneStochasticIterations = clampU32(cfg.TuneStochasticIterations, 0, 65536)
+	if !validQuantBounds(cfg.TuneColorQuantMin, cfg.TuneColorQuantMax, quant6, quant256) {
+		return newError(ErrBadParam, "astc: invalid color quant bounds")
+	}
+	if !validQuantBounds(cfg.TuneWeightQuantMin, cfg.TuneWeightQuantMax, quant2, quant32) {
+		return newError(ErrBadParam, "astc: invalid weight quant bounds")
+	}
 	cfg.Tune2PartitioningCandidateLimit = clampU32(cfg.Tune2PartitioningCandidateLimit, 1, 8)
 	cfg.Tune3PartitioningCandidateLimit = clampU32(cfg.Tune3PartitioningCandidateLimit, 1, 8)
 	cfg.Tune4PartitioningCandidateLimit = clampU32(cfg.Tune4PartitioningCandidateLimit, 1, 8)
@@ -902,6 +1283,13 @@ func validateAndClampConfig(cfg *Config) error {
 	if cfg.Tune2PlaneEarlyOutLimitCorrelation < 0 {
 		cfg.Tune2PlaneEarlyOutLimitCorrelation = 0
 	}
+	cfg.VarianceRadius = clampU32(cfg.VarianceRadius, 0, 32)
+	if !(cfg.VariancePower >= 0) || math.IsInf(float64(cfg.VariancePower), 1) {
+		return newError(ErrBadParam, "astc: invalid variance power")
+	}
+	if cfg.VarianceRadius != 0 && cfg.VariancePower == 0 {
+		cfg.VariancePower = 1
+	}
 
 	maxWeight := max4(cfg.CWRWeight, cfg.CWGWeight, cfg.CWBWeight, cfg.CWAWeight)
 	if !(maxWeight > 0) {
@@ -1057,11 +1445,29 @@ func (c *Context) beginCompress(totalBlocks uint32, img *Image, swizzle Swizzle,
 			break
 		}
 		if st == 0 && c.compress.initState.CompareAndSwap(0, 1) {
+			c.compress.doneBlocks.Store(0)
+			c.compress.startNanos.Store(int64(time.Since(progressEpoch)))
 			c.compress.totalBlocks.Store(totalBlocks)
 			c.compress.nextBlock.Store(0)
-			c.compress.doneBlocks.Store(0)
 			c.compress.cancel.Store(0)
+			c.compress.hintHits.Store(0)
+			c.countContextReuse()
 			c.compress.inputAlphaAverages = nil
+			c.compress.inputVarianceWeights = nil
+			c.compress.decisions = nil
+			c.compress.partitionSeeds = nil
+			c.compress.diffusionClaimed.Store(false)
+			if c.cfg.TunePartitionNeighborSeeding && (c.cfg.Profile == ProfileLDR || c.cfg.Profile == ProfileLDRSRGB) {
+				c.compress.partitionSeeds = make([]atomic.Uint32, totalBlocks)
+			}
+			if c.cfg.DecisionLog != nil {
+				c.compress.decisions = make([]BlockDecision, totalBlocks)
+				c.compress.decisionsBlocks = [3]int{
+					(img.DimX + c.blockX - 1) / c.blockX,
+					(img.DimY + c.blockY - 1) / c.blockY,
+					(img.DimZ + c.blockZ - 1) / c.blockZ,
+				}
+			}
 
 			// Report every 1% or 4096 blocks, whichever is larger (matches upstream).
 			minDiff := float32(1.0)
@@ -1074,10 +1480,20 @@ func (c *Context) beginCompress(totalBlocks uint32, img *Image, swizzle Swizzle,
 			c.compress.progressMinDiffBits.Store(math.Float32bits(minDiff))
 			c.compress.progressLastValueBits.Store(math.Float32bits(0.0))
 
+			c.compress.storedIndex, c.compress.blockOrderErr = storedBlockIndices(c.cfg.BlockOrder, c.cfg.BlockOrderFunc,
+				(img.DimX+c.blockX-1)/c.blockX, (img.DimY+c.blockY-1)/c.blockY, (img.DimZ+c.blockZ-1)/c.blockZ)
+			c.compress.sanitized, c.compress.sanitizeErr = sanitizeInput(img, inType, c.cfg.InputSanitize)
+			if c.compress.sanitized != nil {
+				img = c.compress.sanitized
+			}
+
 			// Precompute alpha averages for alpha-scale RDO (matches upstream input_alpha_averages).
 			if c.cfg.AScaleRadius != 0 && c.blockZ == 1 && swizzle.A != Swz0 && swizzle.A != Swz1 {
 				c.compress.inputAlphaAverages = computeInputAlphaAverages(img, inType, swizzle.A, int(c.cfg.AScaleRadius))
 			}
+			if c.cfg.VarianceRadius != 0 {
+				c.compress.inputVarianceWeights = computeInputVarianceWeights(img, inType, int(c.cfg.VarianceRadius), c.cfg.VariancePower)
+			}
 
 			c.compress.initState.Store(2)
 			break
@@ -1089,21 +1505,46 @@ func (c *Context) beginCompress(totalBlocks uint32, img *Image, swizzle Swizzle,
 	return nil
 }
 
-func (c *Context) endCompress() {
+// endCompress leaves the compression started by beginCompress. The last thread to leave writes the
+// decision log of a completed image and returns any write error.
+func (c *Context) endCompress() error {
 	if c.compress.workers.Add(-1) != 0 {
-		return
+		return nil
 	}
 
 	if c.threadCount > 1 {
 		c.compress.needsReset.Store(1)
 	}
 
+	var err error
+	completed := c.compress.cancel.Load() == 0 && c.compress.doneBlocks.Load() == c.compress.totalBlocks.Load()
+	if c.compress.decisions != nil && completed {
+		b := c.compress.decisionsBlocks
+		err = c.writeDecisionLog(b[0], b[1], b[2])
+	}
+	if m := c.cfg.Metrics; m != nil {
+		if hits := c.compress.hintHits.Load(); hits != 0 {
+			m.Count(MetricHintHits, int64(hits))
+		}
+		reportImageMetrics(m, true, int(c.compress.doneBlocks.Load()), c.compress.elapsed(), completed)
+		if (!completed && c.compress.cancel.Load() == 0) || err != nil {
+			m.Count(MetricErrors, 1)
+		}
+	}
+
 	c.compress.inputAlphaAverages = nil
+	c.compress.inputVarianceWeights = nil
+	c.compress.decisions = nil
+	c.compress.sanitized, c.compress.sanitizeErr = nil, nil
+	c.compress.storedIndex, c.compress.blockOrderErr = nil, nil
 	c.compress.initState.Store(0)
 	c.state.Store(uint32(ctxIdle))
+	return err
 }
 
-func (c *Context) beginDecompress(totalBlocks uint32) error {
+// beginDecompress joins the decompression of data into imgOut. The thread which initializes it
+// stores the input for all threads in c.decompress.input, copying it when it overlaps imgOut.
+func (c *Context) beginDecompress(totalBlocks uint32, data []byte, imgOut *Image) error {
 	if c.decompress.needsReset.Load() != 0 {
 		return newError(ErrBadContext, "astc: decompress requires reset")
 	}
@@ -1130,9 +1571,14 @@ func (c *Context) beginDecompress(totalBlocks uint32) error {
 			break
 		}
 		if st == 0 && c.decompress.initState.CompareAndSwap(0, 1) {
+			c.decompress.input = detachBlocksFromImage(data, imgOut)
+			c.decompress.storedIndex, c.decompress.blockOrderErr = storedBlockIndices(c.cfg.BlockOrder, c.cfg.BlockOrderFunc,
+				(imgOut.DimX+c.blockX-1)/c.blockX, (imgOut.DimY+c.blockY-1)/c.blockY, (imgOut.DimZ+c.blockZ-1)/c.blockZ)
 			c.decompress.totalBlocks.Store(totalBlocks)
 			c.decompress.nextBlock.Store(0)
 			c.decompress.doneBlocks.Store(0)
+			c.decompress.startNanos.Store(int64(time.Since(progressEpoch)))
+			c.countContextReuse()
 			c.decompress.initState.Store(2)
 			break
 		}
@@ -1151,23 +1597,46 @@ func (c *Context) endDecompress() {
 	if c.threadCount > 1 {
 		c.decompress.needsReset.Store(1)
 	}
+	if m := c.cfg.Metrics; m != nil {
+		done := c.decompress.doneBlocks.Load()
+		completed := done == c.decompress.totalBlocks.Load()
+		reportImageMetrics(m, false, int(done), c.decompress.elapsed(), completed)
+		if !completed {
+			m.Count(MetricErrors, 1)
+		}
+	}
 
+	c.decompress.input = nil
+	c.decompress.storedIndex, c.decompress.blockOrderErr = nil, nil
 	c.decompress.initState.Store(0)
 	c.state.Store(uint32(ctxIdle))
 }
 
+// imageTexels returns x*y*z, or false if a dimension is not positive or four channels of that
+// many texels overflow int.
+func imageTexels(x, y, z int) (int, bool) {
+	if x <= 0 || y <= 0 || z <= 0 || y > math.MaxInt/4/x || z > math.MaxInt/4/(x*y) {
+		return 0, false
+	}
+	return x * y * z, true
+}
+
 func validateImageIn(img *Image) (DataType, error) {
-	if img.DimX <= 0 || img.DimY <= 0 || img.DimZ <= 0 {
+	texelCount, ok := imageTexels(img.DimX, img.DimY, img.DimZ)
+	if !ok {
 		return 0, newError(ErrBadParam, "astc: invalid image dimensions")
 	}
-	texelCount := img.DimX * img.DimY * img.DimZ
-	if texelCount <= 0 {
-		return 0, newError(ErrBadParam, "astc: invalid image dimensions")
+
+	if img.Layout > LayoutLA || (img.Layout != LayoutRGBA && img.DataType != TypeU8) {
+		return 0, newError(ErrBadParam, "astc: invalid pixel layout")
+	}
+	if img.Importance != nil && len(img.Importance) != texelCount {
+		return 0, newError(ErrBadParam, "astc: invalid importance map length")
 	}
 
 	switch img.DataType {
 	case TypeU8:
-		if len(img.DataU8) != texelCount*4 {
+		if len(img.DataU8) != texelCount*img.Layout.bytesPerTexel() {
 			return 0, newError(ErrBadParam, "astc: invalid RGBA8 buffer length")
 		}
 		return TypeU8, nil
@@ -1190,8 +1659,11 @@ func validateImageOut(img *Image) (DataType, error) {
 	if img.DimX <= 0 || img.DimY <= 0 || img.DimZ <= 0 {
 		return 0, newError(ErrBadParam, "astc: invalid image dimensions")
 	}
-	texelCount := img.DimX * img.DimY * img.DimZ
-	if texelCount <= 0 {
+	if img.Layout != LayoutRGBA {
+		return 0, newError(ErrBadParam, "astc: pixel layouts are only supported for compression")
+	}
+	texelCount, ok := imageTexels(img.DimX, img.DimY, img.DimZ)
+	if !ok {
 		return 0, newError(ErrBadParam, "astc: invalid image dimensions")
 	}
 
@@ -1285,11 +1757,7 @@ func computeInputAlphaAverages(img *Image, inType DataType, alphaSwz Swz, radius
 	case TypeU8:
 		const inv255 = 1.0 / 255.0
 		for i := 0; i < texelCount; i++ {
-			off := i * 4
-			r := img.DataU8[off+0]
-			g := img.DataU8[off+1]
-			b := img.DataU8[off+2]
-			a := img.DataU8[off+3]
+			r, g, b, a := img.Layout.loadRGBA8(img.DataU8, i)
 			alpha[i] = float32(swzU8(alphaSwz, r, g, b, a)) * inv255
 		}
 	case TypeF16:
@@ -1297,13 +1765,13 @@ func computeInputAlphaAverages(img *Image, inType DataType, alphaSwz Swz, radius
 			off := i * 4
 			switch alphaSwz {
 			case SwzR:
-				alpha[i] = halfToFloat32(img.DataF16[off+0])
+				alpha[i] = HalfToFloat32(img.DataF16[off+0])
 			case SwzG:
-				alpha[i] = halfToFloat32(img.DataF16[off+1])
+				alpha[i] = HalfToFloat32(img.DataF16[off+1])
 			case SwzB:
-				alpha[i] = halfToFloat32(img.DataF16[off+2])
+				alpha[i] = HalfToFloat32(img.DataF16[off+2])
 			case SwzA:
-				alpha[i] = halfToFloat32(img.DataF16[off+3])
+				alpha[i] = HalfToFloat32(img.DataF16[off+3])
 			case Swz1:
 				alpha[i] = 1
 			default:
@@ -1570,6 +2038,166 @@ func swzF32(s Swz, r, g, b, a float32) float32 {
 	}
 }
 
+// unorm8ToRGBAF32 converts decode_unorm8 texels to float, applying the sRGB EOTF to RGB when
+// linearize is set.
+func unorm8ToRGBAF32(src []byte, dst []float32, linearize bool) {
+	for i, v := range src {
+		if linearize && i&3 != 3 {
+			dst[i] = srgb8ToLinearTable[v]
+		} else {
+			dst[i] = float32(v) * (1.0 / 255.0)
+		}
+	}
+}
+
+// SRGB8ToLinear applies the sRGB EOTF to an 8-bit sRGB-encoded value, returning linear light in
+// 0..1 as DecompressImage does for ColorSpaceLinear.
+func SRGB8ToLinear(v uint8) float32 {
+	return srgb8ToLinearTable[v]
+}
+
+// srgb8ToLinearTable maps an sRGB-encoded 8-bit value to linear light.
+var srgb8ToLinearTable = func() (t [256]float32) {
+	for i := range t {
+		v := float64(i) / 255
+		if v <= 0.04045 {
+			t[i] = float32(v / 12.92)
+		} else {
+			t[i] = float32(math.Pow((v+0.055)/1.055, 2.4))
+		}
+	}
+	return t
+}()
+
+// renormalizeNormal maps a FlagMapNormal X/Y pair in 0..1 to a unit normal in 0..1, reconstructing
+// Z.
+func renormalizeNormal(r, a float32) (x, y, z float32) {
+	xN := r*2 - 1
+	yN := a*2 - 1
+	zN := float32(0)
+	if l2 := xN*xN + yN*yN; l2 > 1 {
+		s := float32(1 / math.Sqrt(float64(l2)))
+		xN *= s
+		yN *= s
+	} else {
+		zN = float32(math.Sqrt(float64(1 - l2)))
+	}
+	return xN*0.5 + 0.5, yN*0.5 + 0.5, zN*0.5 + 0.5
+}
+
+func renormalizeNormalsRGBA8(pix []byte) {
+	for i := 0; i < len(pix); i += 4 {
+		x, y, z := renormalizeNormal(float32(pix[i+0])*(1.0/255.0), float32(pix[i+3])*(1.0/255.0))
+		pix[i+0] = uint8(flt2intRTN(x * 255.0))
+		pix[i+1] = uint8(flt2intRTN(y * 255.0))
+		pix[i+2] = uint8(flt2intRTN(z * 255.0))
+	}
+}
+
+func renormalizeNormalsRGBAF32(pix []float32) {
+	for i := 0; i < len(pix); i += 4 {
+		pix[i+0], pix[i+1], pix[i+2] = renormalizeNormal(pix[i+0], pix[i+3])
+	}
+}
+
+// tonemapRGBAF32 scales RGB by exposure (zero means 1) and applies tm in place. Alpha is unchanged.
+func tonemapRGBAF32(pix []float32, tm Tonemap, exposure float32) {
+	if exposure == 0 {
+		exposure = 1
+	}
+	if tm == TonemapClamp && exposure == 1 {
+		return
+	}
+	for i := 0; i < len(pix); i += 4 {
+		for ch := 0; ch < 3; ch++ {
+			v := pix[i+ch] * exposure
+			if !(v > 0) {
+				// Negative and NaN values map to black under every curve.
+				pix[i+ch] = 0
+				continue
+			}
+			if math.IsInf(float64(v), 1) {
+				pix[i+ch] = 1
+				continue
+			}
+			switch tm {
+			case TonemapReinhard:
+				v = v / (1 + v)
+			case TonemapACES:
+				v = (v * (2.51*v + 0.03)) / (v*(2.43*v+0.59) + 0.14)
+			}
+			pix[i+ch] = v
+		}
+	}
+}
+
+// valid reports whether every coefficient is finite and, when clamping, Min <= Max.
+func (t *ChannelTransform) valid() bool {
+	for c := 0; c < 4; c++ {
+		for _, v := range [...]float32{t.Scale[c], t.Bias[c], t.Min[c], t.Max[c]} {
+			if math.IsNaN(float64(v)) || math.IsInf(float64(v), 0) {
+				return false
+			}
+		}
+		if t.Clamp && t.Min[c] > t.Max[c] {
+			return false
+		}
+	}
+	return true
+}
+
+func (t *ChannelTransform) apply(c int, v float32) float32 {
+	v = v*t.Scale[c] + t.Bias[c]
+	if t.Clamp {
+		v = min(max(v, t.Min[c]), t.Max[c])
+	}
+	return v
+}
+
+func (t *ChannelTransform) applyRGBA8(pix []byte) {
+	for i := 0; i < len(pix); i++ {
+		v := min(max(t.apply(i&3, float32(pix[i])*(1.0/255.0)), 0), 1)
+		pix[i] = uint8(flt2intRTN(v * 255.0))
+	}
+}
+
+func (t *ChannelTransform) applyRGBAF32(pix []float32) {
+	for i := range pix {
+		pix[i] = t.apply(i&3, pix[i])
+	}
+}
+
+// padBlockEdgeRGBA8 overwrites the texels of an extracted block which lie outside the image with
+// color.
+func padBlockEdgeRGBA8(width, height, depth, x0, y0, z0, blockX, blockY, blockZ int, color [4]uint8, dst []byte) {
+	for zz := 0; zz < blockZ; zz++ {
+		for yy := 0; yy < blockY; yy++ {
+			for xx := 0; xx < blockX; xx++ {
+				if x0+xx < width && y0+yy < height && z0+zz < depth {
+					continue
+				}
+				off := ((zz*blockY+yy)*blockX + xx) * 4
+				copy(dst[off:off+4], color[:])
+			}
+		}
+	}
+}
+
+// padBlockEdgeRGBAF32 is the float equivalent of padBlockEdgeRGBA8.
+func padBlockEdgeRGBAF32(width, height, depth, x0, y0, z0, blockX, blockY, blockZ int, color [4]float32, dst []float32) {
+	for zz := 0; zz < blockZ; zz++ {
+		for yy := 0; yy < blockY; yy++ {
+			for xx := 0; xx < blockX; xx++ {
+				if x0+xx < width && y0+yy < height && z0+zz < depth {
+					continue
+				}
+				off := ((zz*blockY+yy)*blockX + xx) * 4
+				copy(dst[off:off+4], color[:])
+			}
+		}
+	}
+}
+
 func extractBlockRGBAF16ToF32Volume(pix []uint16, width, height, depth, x0, y0, z0, blockX, blockY, blockZ int, dst []float32) {
 	texelCount := blockX * blockY * blockZ
 	if len(dst) < texelCount*4 {
@@ -1605,10 +2233,10 @@ func extractBlockRGBAF16ToF32Volume(pix []uint16, width, height, depth, x0, y0,
 
 				srcOff := ((z*height+y)*width + x) * 4
 				dstOff := ((zz*blockY+yy)*blockX + xx) * 4
-				dst[dstOff+0] = halfToFloat32(pix[srcOff+0])
-				dst[dstOff+1] = halfToFloat32(pix[srcOff+1])
-				dst[dstOff+2] = halfToFloat32(pix[srcOff+2])
-				dst[dstOff+3] = halfToFloat32(pix[srcOff+3])
+				dst[dstOff+0] = HalfToFloat32(pix[srcOff+0])
+				dst[dstOff+1] = HalfToFloat32(pix[srcOff+1])
+				dst[dstOff+2] = HalfToFloat32(pix[srcOff+2])
+				dst[dstOff+3] = HalfToFloat32(pix[srcOff+3])
 			}
 		}
 	}
@@ -1654,7 +2282,7 @@ func quantizeRGBAF32ToU8(src []float32, dst []byte) {
 	}
 }
 
-func storeBlockRGBA8Volume(dst []byte, width, height, depth, x0, y0, z0, blockX, blockY, blockZ int, block []byte) {
+func storeBlockRGBA8Volume(dst []byte, width, height, depth, x0, y0, z0, blockX, blockY, blockZ int, block []byte, flipY bool) {
 	dstRowStride := width * 4
 	dstSliceStride := height * dstRowStride
 	srcRowBytes := blockX * 4
@@ -1670,6 +2298,9 @@ func storeBlockRGBA8Volume(dst []byte, width, height, depth, x0, y0, z0, blockX,
 			if y >= height {
 				break
 			}
+			if flipY {
+				y = height - 1 - y
+			}
 			dstOff := dstSliceBase + y*dstRowStride + x0*4
 			srcOff := srcSliceBase + yy*srcRowBytes
 			rowCopyBytes := srcRowBytes
@@ -1681,7 +2312,7 @@ func storeBlockRGBA8Volume(dst []byte, width, height, depth, x0, y0, z0, blockX,
 	}
 }
 
-func storeBlockRGBAF32Volume(dst []float32, width, height, depth, x0, y0, z0, blockX, blockY, blockZ int, block []float32) {
+func storeBlockRGBAF32Volume(dst []float32, width, height, depth, x0, y0, z0, blockX, blockY, blockZ int, block []float32, flipY bool) {
 	dstRowStride := width * 4
 	dstSliceStride := height * dstRowStride
 	srcRowStride := blockX * 4
@@ -1697,6 +2328,9 @@ func storeBlockRGBAF32Volume(dst []float32, width, height, depth, x0, y0, z0, bl
 			if y >= height {
 				break
 			}
+			if flipY {
+				y = height - 1 - y
+			}
 			dstOff := dstSliceBase + y*dstRowStride + x0*4
 			srcOff := srcSliceBase + yy*srcRowStride
 			rowCopy := srcRowStride
@@ -1708,7 +2342,7 @@ func storeBlockRGBAF32Volume(dst []float32, width, height, depth, x0, y0, z0, bl
 	}
 }
 
-func storeBlockRGBAF32AsF16Volume(dst []uint16, width, height, depth, x0, y0, z0, blockX, blockY, blockZ int, block []float32) {
+func storeBlockRGBAF32AsF16Volume(dst []uint16, width, height, depth, x0, y0, z0, blockX, blockY, blockZ int, block []float32, flipY bool) {
 	dstRowStride := width * 4
 	dstSliceStride := height * dstRowStride
 	srcRowStride := blockX * 4
@@ -1724,6 +2358,9 @@ func storeBlockRGBAF32AsF16Volume(dst []uint16, width, height, depth, x0, y0, z0
 			if y >= height {
 				break
 			}
+			if flipY {
+				y = height - 1 - y
+			}
 			dstOff := dstSliceBase + y*dstRowStride + x0*4
 			srcOff := srcSliceBase + yy*srcRowStride
 			rowTexels := blockX
@@ -1733,10 +2370,10 @@ func storeBlockRGBAF32AsF16Volume(dst []uint16, width, height, depth, x0, y0, z0
 			for xx := 0; xx < rowTexels; xx++ {
 				di := dstOff + xx*4
 				si := srcOff + xx*4
-				dst[di+0] = float32ToHalf(block[si+0])
-				dst[di+1] = float32ToHalf(block[si+1])
-				dst[di+2] = float32ToHalf(block[si+2])
-				dst[di+3] = float32ToHalf(block[si+3])
+				dst[di+0] = Float32ToHalf(block[si+0])
+				dst[di+1] = Float32ToHalf(block[si+1])
+				dst[di+2] = Float32ToHalf(block[si+2])
+				dst[di+3] = Float32ToHalf(block[si+3])
 			}
 		}
 	}
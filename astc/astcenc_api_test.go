@@ -2,10 +2,14 @@ package astc_test
 
 import (
 	"bytes"
+	"encoding/json"
+	"math"
+	"strings"
 	"sync"
 	"testing"
 
 	"github.com/arm-software/astc-encoder/astc"
+	"github.com/arm-software/astc-encoder/astc/testimage"
 )
 
 func blocksLenBytes(width, height, depth, blockX, blockY, blockZ int) int {
@@ -151,3 +155,1168 @@ func TestContext_MultiThread_ResetRequired(t *testing.T) {
 		t.Fatalf("CompressImage after reset: %v", err)
 	}
 }
+
+func TestContext_CompressImage_EdgeMode(t *testing.T) {
+	const w, h, d = 6, 6, 1
+	src := make([]byte, w*h*d*4)
+	for i := 0; i < len(src); i += 4 {
+		src[i+0] = 10
+		src[i+1] = 20
+		src[i+2] = 30
+		src[i+3] = 40
+	}
+	img := astc.Image{DimX: w, DimY: h, DimZ: d, DataType: astc.TypeU8, DataU8: src}
+
+	compress := func(mode astc.EdgeMode) ([]byte, error) {
+		cfg, err := astc.ConfigInit(astc.ProfileLDR, 4, 4, 1, 60, 0)
+		if err != nil {
+			t.Fatalf("ConfigInit: %v", err)
+		}
+		cfg.EdgeMode = mode
+		cfg.EdgePadColor = [4]float32{1, 1, 1, 1}
+		ctx, err := astc.ContextAlloc(&cfg, 1)
+		if err != nil {
+			t.Fatalf("ContextAlloc: %v", err)
+		}
+		blocks := make([]byte, blocksLenBytes(w, h, d, 4, 4, 1))
+		return blocks, ctx.CompressImage(&img, astc.SwizzleRGBA, blocks, 0)
+	}
+
+	if _, err := compress(astc.EdgeError); astc.ErrorCodeOf(err) != astc.ErrBadParam {
+		t.Fatalf("EdgeError: expected ErrBadParam, got %v", err)
+	}
+
+	// Decode the padded 8x8 footprint to inspect texels outside the image.
+	decodePadded := func(blocks []byte) []byte {
+		hdr := astc.Header{BlockX: 4, BlockY: 4, BlockZ: 1, SizeX: 8, SizeY: 8, SizeZ: 1}
+		out := make([]byte, 8*8*4)
+		if err := astc.DecodeRGBA8VolumeFromParsedWithProfileInto(astc.ProfileLDR, hdr, blocks, out); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		return out
+	}
+
+	blocks, err := compress(astc.EdgeReplicate)
+	if err != nil {
+		t.Fatalf("EdgeReplicate: %v", err)
+	}
+	if px := decodePadded(blocks)[(7*8+7)*4:][:4]; !bytes.Equal(px, []byte{10, 20, 30, 40}) {
+		t.Fatalf("EdgeReplicate: unexpected padding texel %v", px)
+	}
+
+	blocks, err = compress(astc.EdgePad)
+	if err != nil {
+		t.Fatalf("EdgePad: %v", err)
+	}
+	padded := decodePadded(blocks)
+	for _, c := range padded[(7*8+7)*4:][:4] {
+		if c < 240 {
+			t.Fatalf("EdgePad: padding texel not filled with pad color: %v", padded[(7*8+7)*4:][:4])
+		}
+	}
+	for _, c := range [][2]int{{0, 0}, {5, 0}, {0, 5}} {
+		px := padded[(c[1]*8+c[0])*4:][:4]
+		for ch, want := range []int{10, 20, 30, 40} {
+			if d := int(px[ch]) - want; d < -8 || d > 8 {
+				t.Fatalf("EdgePad: image texel %v changed too much: %v", c, px)
+			}
+		}
+	}
+}
+
+func TestContext_BlockOrderMorton(t *testing.T) {
+	const w, h = 20, 12 // 5x3 blocks
+	pix := make([]byte, w*h*4)
+	for i := range pix {
+		pix[i] = uint8(i * 13)
+	}
+
+	run := func(order astc.BlockOrder) ([]byte, []byte) {
+		cfg, err := astc.ConfigInit(astc.ProfileLDR, 4, 4, 1, 10, 0)
+		if err != nil {
+			t.Fatalf("ConfigInit: %v", err)
+		}
+		cfg.BlockOrder = order
+		ctx, err := astc.ContextAlloc(&cfg, 1)
+		if err != nil {
+			t.Fatalf("ContextAlloc: %v", err)
+		}
+		img := astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeU8, DataU8: pix}
+		blocks := make([]byte, blocksLenBytes(w, h, 1, 4, 4, 1))
+		if err := ctx.CompressImage(&img, astc.SwizzleRGBA, blocks, 0); err != nil {
+			t.Fatalf("CompressImage: %v", err)
+		}
+		out := astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeU8, DataU8: make([]byte, w*h*4)}
+		if err := ctx.DecompressImage(blocks, &out, astc.SwizzleRGBA, 0); err != nil {
+			t.Fatalf("DecompressImage: %v", err)
+		}
+		return blocks, out.DataU8
+	}
+
+	linear, linearPix := run(astc.BlockOrderLinear)
+	morton, mortonPix := run(astc.BlockOrderMorton)
+	if bytes.Equal(linear, morton) {
+		t.Fatalf("Morton payload equals the linear payload")
+	}
+	if !bytes.Equal(linearPix, mortonPix) {
+		t.Fatalf("Morton round trip decoded a different image")
+	}
+	got := make([]byte, len(morton))
+	if err := astc.ReorderBlocks(got, morton, 5, 3, 1, astc.BlockOrderMorton, astc.BlockOrderLinear); err != nil {
+		t.Fatalf("ReorderBlocks: %v", err)
+	}
+	if !bytes.Equal(got, linear) {
+		t.Fatalf("reordered Morton payload differs from the linear payload")
+	}
+
+	cfg, err := astc.ConfigInit(astc.ProfileLDR, 4, 4, 1, 10, 0)
+	if err != nil {
+		t.Fatalf("ConfigInit: %v", err)
+	}
+	cfg.BlockOrder = astc.BlockOrderMorton + 1
+	if _, err := astc.ContextAlloc(&cfg, 1); astc.ErrorCodeOf(err) != astc.ErrBadParam {
+		t.Fatalf("invalid block order: got %v, want ErrBadParam", err)
+	}
+}
+
+func TestContext_BlockOrderFunc(t *testing.T) {
+	const w, h = 20, 12 // 5x3 blocks
+	const blocksX, blocksY = 5, 3
+	pix := make([]byte, w*h*4)
+	for i := range pix {
+		pix[i] = uint8(i * 29)
+	}
+
+	run := func(fn astc.BlockOrderFunc) ([]byte, []byte, error) {
+		cfg, err := astc.ConfigInit(astc.ProfileLDR, 4, 4, 1, 10, 0)
+		if err != nil {
+			t.Fatalf("ConfigInit: %v", err)
+		}
+		cfg.BlockOrderFunc = fn
+		ctx, err := astc.ContextAlloc(&cfg, 1)
+		if err != nil {
+			t.Fatalf("ContextAlloc: %v", err)
+		}
+		img := astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeU8, DataU8: pix}
+		blocks := make([]byte, blocksLenBytes(w, h, 1, 4, 4, 1))
+		if err := ctx.CompressImage(&img, astc.SwizzleRGBA, blocks, 0); err != nil {
+			return nil, nil, err
+		}
+		out := astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeU8, DataU8: make([]byte, w*h*4)}
+		if err := ctx.DecompressImage(blocks, &out, astc.SwizzleRGBA, 0); err != nil {
+			return nil, nil, err
+		}
+		return blocks, out.DataU8, nil
+	}
+
+	// Column-major: a layout no BlockOrder constant produces.
+	columnMajor := func(bx, by, bz int) int { return bx*blocksY + by }
+	linear, linearPix, err := run(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	custom, customPix, err := run(columnMajor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(linearPix, customPix) {
+		t.Fatalf("custom order round trip decoded a different image")
+	}
+	for by := 0; by < blocksY; by++ {
+		for bx := 0; bx < blocksX; bx++ {
+			src := (by*blocksX + bx) * astc.BlockBytes
+			dst := columnMajor(bx, by, 0) * astc.BlockBytes
+			if !bytes.Equal(linear[src:src+astc.BlockBytes], custom[dst:dst+astc.BlockBytes]) {
+				t.Fatalf("block (%d,%d) not stored at index %d", bx, by, dst/astc.BlockBytes)
+			}
+		}
+	}
+
+	for name, fn := range map[string]astc.BlockOrderFunc{
+		"duplicate":    func(bx, by, bz int) int { return by },
+		"out of range": func(bx, by, bz int) int { return by*blocksX + bx + 1 },
+		"negative":     func(bx, by, bz int) int { return -1 },
+	} {
+		if _, _, err := run(fn); astc.ErrorCodeOf(err) != astc.ErrBadParam {
+			t.Errorf("%s: CompressImage got %v, want ErrBadParam", name, err)
+		}
+		cfg, err := astc.ConfigInit(astc.ProfileLDR, 4, 4, 1, 10, astc.FlagDecompressOnly)
+		if err != nil {
+			t.Fatalf("ConfigInit: %v", err)
+		}
+		cfg.BlockOrderFunc = fn
+		ctx, err := astc.ContextAlloc(&cfg, 1)
+		if err != nil {
+			t.Fatalf("ContextAlloc: %v", err)
+		}
+		out := astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeU8, DataU8: make([]byte, w*h*4)}
+		if err := ctx.DecompressImage(linear, &out, astc.SwizzleRGBA, 0); astc.ErrorCodeOf(err) != astc.ErrBadParam {
+			t.Errorf("%s: DecompressImage got %v, want ErrBadParam", name, err)
+		}
+	}
+}
+
+func TestContext_CompressImage_PixelLayout(t *testing.T) {
+	const w, h = 10, 7
+	rgba := make([]byte, w*h*4)
+	for i := range rgba {
+		rgba[i] = uint8(i*29 + i/13)
+	}
+	opaque := append([]byte(nil), rgba...)
+	bgra := make([]byte, w*h*4)
+	rgbx := make([]byte, w*h*4)
+	rgb := make([]byte, w*h*3)
+	la := make([]byte, w*h*2)
+	lumaAlpha := make([]byte, w*h*4)
+	for i := 0; i < w*h; i++ {
+		r, g, b, a := rgba[i*4], rgba[i*4+1], rgba[i*4+2], rgba[i*4+3]
+		copy(bgra[i*4:], []byte{b, g, r, a})
+		copy(rgbx[i*4:], []byte{r, g, b, 7})
+		copy(rgb[i*3:], []byte{r, g, b})
+		copy(la[i*2:], []byte{r, a})
+		copy(lumaAlpha[i*4:], []byte{r, r, r, a})
+		opaque[i*4+3] = 255
+	}
+
+	cfg, err := astc.ConfigInit(astc.ProfileLDR, 4, 4, 1, 60, 0)
+	if err != nil {
+		t.Fatalf("ConfigInit: %v", err)
+	}
+	ctx, err := astc.ContextAlloc(&cfg, 1)
+	if err != nil {
+		t.Fatalf("ContextAlloc: %v", err)
+	}
+	compress := func(pix []byte, layout astc.PixelLayout) []byte {
+		img := astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeU8, DataU8: pix, Layout: layout}
+		out := make([]byte, blocksLenBytes(w, h, 1, 4, 4, 1))
+		if err := ctx.CompressImage(&img, astc.SwizzleRGBA, out, 0); err != nil {
+			t.Fatalf("CompressImage(layout %d): %v", layout, err)
+		}
+		return out
+	}
+
+	want := compress(rgba, astc.LayoutRGBA)
+	if got := compress(bgra, astc.LayoutBGRA); !bytes.Equal(got, want) {
+		t.Fatalf("LayoutBGRA output differs from LayoutRGBA")
+	}
+	wantOpaque := compress(opaque, astc.LayoutRGBA)
+	if got := compress(rgbx, astc.LayoutRGBX); !bytes.Equal(got, wantOpaque) {
+		t.Fatalf("LayoutRGBX output differs from opaque LayoutRGBA")
+	}
+	if got := compress(rgb, astc.LayoutRGB); !bytes.Equal(got, wantOpaque) {
+		t.Fatalf("LayoutRGB output differs from opaque LayoutRGBA")
+	}
+	if got := compress(la, astc.LayoutLA); !bytes.Equal(got, compress(lumaAlpha, astc.LayoutRGBA)) {
+		t.Fatalf("LayoutLA output differs from L, L, L, A LayoutRGBA")
+	}
+
+	out := make([]byte, blocksLenBytes(w, h, 1, 4, 4, 1))
+	bad := astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeU8, DataU8: rgba, Layout: astc.LayoutRGB}
+	if err := ctx.CompressImage(&bad, astc.SwizzleRGBA, out, 0); astc.ErrorCodeOf(err) != astc.ErrBadParam {
+		t.Fatalf("LayoutRGB with a 4-byte buffer: expected ErrBadParam, got %v", err)
+	}
+	bad = astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeF32, DataF32: make([]float32, w*h*4), Layout: astc.LayoutBGRA}
+	if err := ctx.CompressImage(&bad, astc.SwizzleRGBA, out, 0); astc.ErrorCodeOf(err) != astc.ErrBadParam {
+		t.Fatalf("LayoutBGRA with float data: expected ErrBadParam, got %v", err)
+	}
+	dec := astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeU8, DataU8: make([]byte, w*h*4), Layout: astc.LayoutBGRA}
+	if err := ctx.DecompressImage(want, &dec, astc.SwizzleRGBA, 0); astc.ErrorCodeOf(err) != astc.ErrBadParam {
+		t.Fatalf("decompress to LayoutBGRA: expected ErrBadParam, got %v", err)
+	}
+}
+
+func TestContext_CompressImageWithHint(t *testing.T) {
+	const w, h = 16, 16
+	frame := func(paint bool) []byte {
+		pix := make([]byte, w*h*4)
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				off := (y*w + x) * 4
+				copy(pix[off:], []byte{uint8(x * 15), uint8(y * 15), uint8((x + y) * 7), 255})
+				if paint && x >= 4 && x < 8 && y < 4 {
+					copy(pix[off:], []byte{255, uint8(x * 30), 0, 255})
+				}
+			}
+		}
+		return pix
+	}
+
+	cfg, err := astc.ConfigInit(astc.ProfileLDR, 4, 4, 1, 60, 0)
+	if err != nil {
+		t.Fatalf("ConfigInit: %v", err)
+	}
+	ctx, err := astc.ContextAlloc(&cfg, 1)
+	if err != nil {
+		t.Fatalf("ContextAlloc: %v", err)
+	}
+	n := blocksLenBytes(w, h, 1, 4, 4, 1)
+	compress := func(pix, prev []byte, maxMSE float32) []byte {
+		img := astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeU8, DataU8: pix}
+		out := make([]byte, n)
+		if err := ctx.CompressImageWithHint(&img, prev, maxMSE, astc.SwizzleRGBA, out, 0); err != nil {
+			t.Fatalf("CompressImageWithHint: %v", err)
+		}
+		return out
+	}
+
+	first := compress(frame(false), nil, 0)
+
+	// Any previous block is reused under a large enough threshold, even a worse one.
+	stale := append([]byte(nil), first...)
+	blk := astc.EncodeConstBlockRGBA8(128, 128, 128, 255)
+	copy(stale[5*astc.BlockBytes:], blk[:])
+	if got := compress(frame(false), stale, 1e9); !bytes.Equal(got, stale) {
+		t.Fatalf("expected every hinted block to be reused")
+	}
+
+	// Only the repainted block is searched again; the rest are kept from the previous frame.
+	painted := frame(true)
+	second := compress(painted, first, 1)
+	fresh := compress(painted, nil, 0)
+	for i := 0; i < n/astc.BlockBytes; i++ {
+		got := second[i*astc.BlockBytes : (i+1)*astc.BlockBytes]
+		want := first[i*astc.BlockBytes : (i+1)*astc.BlockBytes]
+		if i == 1 {
+			want = fresh[i*astc.BlockBytes : (i+1)*astc.BlockBytes]
+		}
+		if !bytes.Equal(got, want) {
+			t.Fatalf("block %d: unexpected hinted result", i)
+		}
+	}
+
+	// The hint may alias the output.
+	inPlace := append([]byte(nil), first...)
+	img := astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeU8, DataU8: painted}
+	if err := ctx.CompressImageWithHint(&img, inPlace, 1, astc.SwizzleRGBA, inPlace, 0); err != nil {
+		t.Fatalf("CompressImageWithHint in place: %v", err)
+	}
+	if !bytes.Equal(inPlace, second) {
+		t.Fatalf("in-place hinted compression differs")
+	}
+
+	out := make([]byte, n)
+	if err := ctx.CompressImageWithHint(&img, first[:n-1], 1, astc.SwizzleRGBA, out, 0); astc.ErrorCodeOf(err) != astc.ErrBadParam {
+		t.Fatalf("short hint: expected ErrBadParam, got %v", err)
+	}
+	if err := ctx.CompressImageWithHint(&img, first, -1, astc.SwizzleRGBA, out, 0); astc.ErrorCodeOf(err) != astc.ErrBadParam {
+		t.Fatalf("negative threshold: expected ErrBadParam, got %v", err)
+	}
+
+	hdrCfg, err := astc.ConfigInit(astc.ProfileHDR, 4, 4, 1, 60, 0)
+	if err != nil {
+		t.Fatalf("ConfigInit: %v", err)
+	}
+	hdrCtx, err := astc.ContextAlloc(&hdrCfg, 1)
+	if err != nil {
+		t.Fatalf("ContextAlloc: %v", err)
+	}
+	if err := hdrCtx.CompressImageWithHint(&img, first, 1, astc.SwizzleRGBA, out, 0); astc.ErrorCodeOf(err) != astc.ErrBadParam {
+		t.Fatalf("HDR profile: expected ErrBadParam, got %v", err)
+	}
+}
+
+func TestContext_CompressImage_DecisionLog(t *testing.T) {
+	const w, h = 14, 10
+	pix := make([]byte, w*h*4)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			off := (y*w + x) * 4
+			copy(pix[off:], []byte{uint8(x * 18), uint8(y * 25), uint8(x * y), 255})
+		}
+	}
+	// One constant block.
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			copy(pix[(y*w+x)*4:], []byte{10, 20, 30, 255})
+		}
+	}
+
+	var log bytes.Buffer
+	cfg, err := astc.ConfigInit(astc.ProfileLDR, 4, 4, 1, 60, 0)
+	if err != nil {
+		t.Fatalf("ConfigInit: %v", err)
+	}
+	cfg.DecisionLog = &log
+	ctx, err := astc.ContextAlloc(&cfg, 1)
+	if err != nil {
+		t.Fatalf("ContextAlloc: %v", err)
+	}
+
+	img := astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeU8, DataU8: pix}
+	out := make([]byte, blocksLenBytes(w, h, 1, 4, 4, 1))
+	if err := ctx.CompressImage(&img, astc.SwizzleRGBA, out, 0); err != nil {
+		t.Fatalf("CompressImage: %v", err)
+	}
+
+	var doc astc.DecisionLog
+	dec := json.NewDecoder(&log)
+	if err := dec.Decode(&doc); err != nil {
+		t.Fatalf("decode log: %v", err)
+	}
+	if dec.More() {
+		t.Fatalf("expected exactly one log document")
+	}
+	if doc.BlockX != 4 || doc.BlockY != 4 || doc.BlockZ != 1 || doc.BlocksX != 4 || doc.BlocksY != 3 || doc.BlocksZ != 1 {
+		t.Fatalf("unexpected log header: %+v", doc)
+	}
+	if len(doc.Blocks) != 12 {
+		t.Fatalf("expected 12 blocks, got %d", len(doc.Blocks))
+	}
+	for i, d := range doc.Blocks {
+		if d.Index != i {
+			t.Fatalf("block %d: index %d", i, d.Index)
+		}
+		var blk [astc.BlockBytes]byte
+		copy(blk[:], out[i*astc.BlockBytes:])
+		info, err := ctx.GetBlockInfo(blk)
+		if err != nil {
+			t.Fatalf("GetBlockInfo(%d): %v", i, err)
+		}
+		if d.Constant != info.IsConstantBlock || d.DualPlane != info.IsDualPlaneBlock {
+			t.Fatalf("block %d: decision %+v does not match block info", i, d)
+		}
+		if info.IsConstantBlock {
+			continue
+		}
+		if d.Partitions != int(info.PartitionCount) || d.ColorLevels != int(info.ColorLevelCount) ||
+			d.WeightLevels != int(info.WeightLevelCount) || d.WeightGrid != [3]int{int(info.WeightX), int(info.WeightY), int(info.WeightZ)} {
+			t.Fatalf("block %d: decision %+v does not match block info", i, d)
+		}
+		for p, f := range d.EndpointFormats {
+			if f != int(info.ColorEndpointModes[p]) {
+				t.Fatalf("block %d: partition %d format %d, want %d", i, p, f, info.ColorEndpointModes[p])
+			}
+		}
+		if d.Error < 0 {
+			t.Fatalf("block %d: negative error %v", i, d.Error)
+		}
+	}
+	if !doc.Blocks[0].Constant || doc.Blocks[0].Error != 0 {
+		t.Fatalf("block 0: expected exact constant block, got %+v", doc.Blocks[0])
+	}
+
+	// Without a writer nothing is recorded.
+	cfg.DecisionLog = nil
+	plain, err := astc.ContextAlloc(&cfg, 1)
+	if err != nil {
+		t.Fatalf("ContextAlloc: %v", err)
+	}
+	plainOut := make([]byte, len(out))
+	if err := plain.CompressImage(&img, astc.SwizzleRGBA, plainOut, 0); err != nil {
+		t.Fatalf("CompressImage: %v", err)
+	}
+	if !bytes.Equal(plainOut, out) {
+		t.Fatalf("decision logging changed the encoded output")
+	}
+}
+
+func TestContext_CompressImage_VarianceEffort(t *testing.T) {
+	// The left half holds a gentle gradient, the right half noise.
+	const w, h = 64, 32
+	pix := make([]byte, w*h*4)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			v := uint8(60 + x + 2*y)
+			if x >= w/2 {
+				v = uint8((x*97 + y*57) * 31)
+			}
+			copy(pix[(y*w+x)*4:], []byte{v, 255 - v, v / 2, 255})
+		}
+	}
+	img := astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeU8, DataU8: pix}
+
+	halfErrors := func(radius uint32) (flat, noisy float64) {
+		var log bytes.Buffer
+		cfg, err := astc.ConfigInit(astc.ProfileLDR, 8, 8, 1, astc.EncodeFast.Level(), 0)
+		if err != nil {
+			t.Fatalf("ConfigInit: %v", err)
+		}
+		cfg.VarianceRadius = radius
+		cfg.DecisionLog = &log
+		ctx, err := astc.ContextAlloc(&cfg, 1)
+		if err != nil {
+			t.Fatalf("ContextAlloc: %v", err)
+		}
+		out := make([]byte, blocksLenBytes(w, h, 1, 8, 8, 1))
+		if err := ctx.CompressImage(&img, astc.SwizzleRGBA, out, 0); err != nil {
+			t.Fatalf("CompressImage: %v", err)
+		}
+		var doc astc.DecisionLog
+		if err := json.NewDecoder(&log).Decode(&doc); err != nil {
+			t.Fatalf("decode log: %v", err)
+		}
+		for _, b := range doc.Blocks {
+			if b.Index%(w/8) < w/16 {
+				flat += b.Error
+			} else {
+				noisy += b.Error
+			}
+		}
+		return flat, noisy
+	}
+
+	// At radius 1 each texel's variance covers only itself, so only the search effort changes: the
+	// flat half gains from the longer search and the noisy half loses from the shorter one.
+	plainFlat, plainNoisy := halfErrors(0)
+	flat, noisy := halfErrors(1)
+	if flat > plainFlat || noisy < plainNoisy || (flat == plainFlat && noisy == plainNoisy) {
+		t.Fatalf("variance effort: error flat %.0f noisy %.0f, without it flat %.0f noisy %.0f", flat, noisy, plainFlat, plainNoisy)
+	}
+
+	cfg, err := astc.ConfigInit(astc.ProfileLDR, 4, 4, 1, 60, 0)
+	if err != nil {
+		t.Fatalf("ConfigInit: %v", err)
+	}
+	cfg.VarianceRadius, cfg.VariancePower = 2, -1
+	if _, err := astc.ContextAlloc(&cfg, 1); astc.ErrorCodeOf(err) != astc.ErrBadParam {
+		t.Fatalf("negative variance power: got %v, want ErrBadParam", err)
+	}
+}
+
+func TestContext_CompressImage_PartitionNeighborSeeding(t *testing.T) {
+	const w, h = 48, 48
+	src := make([]byte, w*h*4)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			// Diagonal bands whose edges run across many blocks.
+			v := uint8(40 + (x+y)%7*4)
+			if (x+2*y)/9%2 == 0 {
+				v += 150
+			}
+			copy(src[(y*w+x)*4:], []byte{v, v/2 + uint8(x), 255 - v, 255})
+		}
+	}
+
+	encode := func(seeding bool) []byte {
+		t.Helper()
+		cfg, err := astc.ConfigInit(astc.ProfileLDR, 6, 6, 1, 60, 0)
+		if err != nil {
+			t.Fatalf("ConfigInit: %v", err)
+		}
+		cfg.TunePartitionNeighborSeeding = seeding
+		ctx, err := astc.ContextAlloc(&cfg, 1)
+		if err != nil {
+			t.Fatalf("ContextAlloc: %v", err)
+		}
+		blocks := make([]byte, blocksLenBytes(w, h, 1, 6, 6, 1))
+		img := astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeU8, DataU8: src}
+		if err := ctx.CompressImage(&img, astc.SwizzleRGBA, blocks, 0); err != nil {
+			t.Fatalf("CompressImage: %v", err)
+		}
+		out := astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeU8, DataU8: make([]byte, w*h*4)}
+		if err := ctx.DecompressImage(blocks, &out, astc.SwizzleRGBA, 0); err != nil {
+			t.Fatalf("DecompressImage: %v", err)
+		}
+		return out.DataU8
+	}
+
+	plain := encode(false)
+	seeded := encode(true)
+	if !bytes.Equal(encode(true), seeded) {
+		t.Fatalf("single-threaded seeded encode is not reproducible")
+	}
+	if bytes.Equal(seeded, plain) {
+		t.Fatalf("partition seeding had no effect")
+	}
+	plainPSNR, seededPSNR := psnrU8(src, plain, 4), psnrU8(src, seeded, 4)
+	if seededPSNR < plainPSNR-0.5 {
+		t.Fatalf("seeded PSNR %.2f dB, want within 0.5 dB of %.2f dB", seededPSNR, plainPSNR)
+	}
+}
+
+func TestContext_CompressImage_BlockErrorDiffusion(t *testing.T) {
+	const w, h, bs, region = 96, 96, 6, 12
+	src, err := testimage.RGBA8(testimage.KindPerlin, w, h, 1, testimage.Options{})
+	if err != nil {
+		t.Fatalf("RGBA8: %v", err)
+	}
+
+	encode := func(diffusion bool, threads int) []byte {
+		t.Helper()
+		cfg, err := astc.ConfigInit(astc.ProfileLDR, bs, bs, 1, 60, 0)
+		if err != nil {
+			t.Fatalf("ConfigInit: %v", err)
+		}
+		cfg.ExperimentalBlockErrorDiffusion = diffusion
+		ctx, err := astc.ContextAlloc(&cfg, threads)
+		if err != nil {
+			t.Fatalf("ContextAlloc: %v", err)
+		}
+		blocks := make([]byte, blocksLenBytes(w, h, 1, bs, bs, 1))
+		img := astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeU8, DataU8: src}
+		errs := make([]error, threads)
+		var wg sync.WaitGroup
+		for i := range threads {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs[i] = ctx.CompressImage(&img, astc.SwizzleRGBA, blocks, i)
+			}()
+		}
+		wg.Wait()
+		// A thread joining after the image is done reports that the context needs a reset.
+		for _, err := range errs {
+			if err != nil && !strings.Contains(err.Error(), "requires reset") {
+				t.Fatalf("CompressImage: %v", err)
+			}
+		}
+		dcfg, err := astc.ConfigInit(astc.ProfileLDR, bs, bs, 1, 0, astc.FlagDecompressOnly)
+		if err != nil {
+			t.Fatalf("ConfigInit: %v", err)
+		}
+		dctx, err := astc.ContextAlloc(&dcfg, 1)
+		if err != nil {
+			t.Fatalf("ContextAlloc: %v", err)
+		}
+		out := astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeU8, DataU8: make([]byte, w*h*4)}
+		if err := dctx.DecompressImage(blocks, &out, astc.SwizzleRGBA, 0); err != nil {
+			t.Fatalf("DecompressImage: %v", err)
+		}
+		return out.DataU8
+	}
+
+	// regionError is the mean absolute difference of the RGB means of region x region tiles.
+	regionError := func(got []byte) float64 {
+		var sum float64
+		for y0 := 0; y0 < h; y0 += region {
+			for x0 := 0; x0 < w; x0 += region {
+				for ch := 0; ch < 3; ch++ {
+					var d float64
+					for y := y0; y < y0+region; y++ {
+						for x := x0; x < x0+region; x++ {
+							i := (y*w+x)*4 + ch
+							d += float64(int(src[i]) - int(got[i]))
+						}
+					}
+					sum += math.Abs(d) / (region * region)
+				}
+			}
+		}
+		return sum / float64(w/region*h/region*3)
+	}
+
+	plain := encode(false, 1)
+	diffused := encode(true, 1)
+	if !bytes.Equal(encode(true, 3), diffused) {
+		t.Fatalf("multi-threaded diffused encode differs from single-threaded")
+	}
+	if got, want := regionError(diffused), regionError(plain); got >= want {
+		t.Fatalf("diffused regional error %.3f, want below %.3f", got, want)
+	}
+	plainPSNR, diffusedPSNR := psnrU8(src, plain, 3), psnrU8(src, diffused, 3)
+	if diffusedPSNR < plainPSNR-0.5 {
+		t.Fatalf("diffused PSNR %.2f dB, want within 0.5 dB of %.2f dB", diffusedPSNR, plainPSNR)
+	}
+}
+
+func TestContext_DecompressImage_RenormalizeNormals(t *testing.T) {
+	const w, h = 16, 16
+	src := make([]byte, w*h*4)
+	want := make([][3]float64, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			nx := (float64(x)+0.5)/w*1.2 - 0.6
+			ny := (float64(y)+0.5)/h*1.2 - 0.6
+			nz := math.Sqrt(1 - nx*nx - ny*ny)
+			want[y*w+x] = [3]float64{nx, ny, nz}
+			r := uint8(math.Round((nx*0.5 + 0.5) * 255))
+			a := uint8(math.Round((ny*0.5 + 0.5) * 255))
+			copy(src[(y*w+x)*4:], []byte{r, r, r, a})
+		}
+	}
+
+	cfg, err := astc.ConfigInit(astc.ProfileLDR, 4, 4, 1, 60, astc.FlagMapNormal)
+	if err != nil {
+		t.Fatalf("ConfigInit: %v", err)
+	}
+	ctx, err := astc.ContextAlloc(&cfg, 1)
+	if err != nil {
+		t.Fatalf("ContextAlloc: %v", err)
+	}
+	defer ctx.Close()
+
+	blocks := make([]byte, blocksLenBytes(w, h, 1, 4, 4, 1))
+	in := astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeU8, DataU8: src}
+	if err := ctx.CompressImage(&in, astc.SwizzleRGBA, blocks, 0); err != nil {
+		t.Fatalf("CompressImage: %v", err)
+	}
+
+	opts := astc.DecodeOptions{RenormalizeNormals: true}
+	outU8 := astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeU8, DataU8: make([]byte, w*h*4)}
+	if err := ctx.DecompressImageWithOptions(blocks, &outU8, astc.SwizzleRGBA, 0, opts); err != nil {
+		t.Fatalf("DecompressImageWithOptions(U8): %v", err)
+	}
+	outF32 := astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeF32, DataF32: make([]float32, w*h*4)}
+	if err := ctx.DecompressImageWithOptions(blocks, &outF32, astc.SwizzleRGBA, 0, opts); err != nil {
+		t.Fatalf("DecompressImageWithOptions(F32): %v", err)
+	}
+	plain := astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeU8, DataU8: make([]byte, w*h*4)}
+	if err := ctx.DecompressImage(blocks, &plain, astc.SwizzleRGBA, 0); err != nil {
+		t.Fatalf("DecompressImage: %v", err)
+	}
+
+	for i := 0; i < w*h; i++ {
+		var n8, n32 [3]float64
+		for c := 0; c < 3; c++ {
+			n8[c] = float64(outU8.DataU8[i*4+c])/255*2 - 1
+			n32[c] = float64(outF32.DataF32[i*4+c])*2 - 1
+		}
+		if l := math.Sqrt(n32[0]*n32[0] + n32[1]*n32[1] + n32[2]*n32[2]); math.Abs(l-1) > 1e-4 {
+			t.Fatalf("texel %d: F32 normal length %f", i, l)
+		}
+		if l := math.Sqrt(n8[0]*n8[0] + n8[1]*n8[1] + n8[2]*n8[2]); math.Abs(l-1) > 0.02 {
+			t.Fatalf("texel %d: U8 normal length %f", i, l)
+		}
+		if outU8.DataU8[i*4+3] != plain.DataU8[i*4+3] {
+			t.Fatalf("texel %d: alpha changed", i)
+		}
+		if dot := n32[0]*want[i][0] + n32[1]*want[i][1] + n32[2]*want[i][2]; dot < 0.995 {
+			t.Fatalf("texel %d: normal %v too far from %v", i, n32, want[i])
+		}
+	}
+}
+
+func TestContext_DecompressImage_Tonemap(t *testing.T) {
+	const w, h = 16, 8
+	src := make([]float32, w*h*4)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			v := float32(x*y) * 0.1
+			copy(src[(y*w+x)*4:], []float32{v, v * 0.5, 0.25, 1})
+		}
+	}
+
+	cfg, err := astc.ConfigInit(astc.ProfileHDR, 4, 4, 1, 60, 0)
+	if err != nil {
+		t.Fatalf("ConfigInit: %v", err)
+	}
+	ctx, err := astc.ContextAlloc(&cfg, 1)
+	if err != nil {
+		t.Fatalf("ContextAlloc: %v", err)
+	}
+	blocks := make([]byte, blocksLenBytes(w, h, 1, 4, 4, 1))
+	in := astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeF32, DataF32: src}
+	if err := ctx.CompressImage(&in, astc.SwizzleRGBA, blocks, 0); err != nil {
+		t.Fatalf("CompressImage: %v", err)
+	}
+	ref := astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeF32, DataF32: make([]float32, w*h*4)}
+	if err := ctx.DecompressImage(blocks, &ref, astc.SwizzleRGBA, 0); err != nil {
+		t.Fatalf("DecompressImage: %v", err)
+	}
+
+	clamp01 := func(v float64) float64 { return math.Min(math.Max(v, 0), 1) }
+	cases := []struct {
+		opts  astc.DecodeOptions
+		curve func(v float64) float64
+	}{
+		{astc.DecodeOptions{}, clamp01},
+		{astc.DecodeOptions{Exposure: 0.125}, func(v float64) float64 { return clamp01(v * 0.125) }},
+		{astc.DecodeOptions{Tonemap: astc.TonemapReinhard}, func(v float64) float64 { return v / (1 + v) }},
+		{astc.DecodeOptions{Tonemap: astc.TonemapACES, Exposure: 2}, func(v float64) float64 {
+			v *= 2
+			return clamp01((v * (2.51*v + 0.03)) / (v*(2.43*v+0.59) + 0.14))
+		}},
+	}
+	for ci, tc := range cases {
+		out := astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeU8, DataU8: make([]byte, w*h*4)}
+		if err := ctx.DecompressImageWithOptions(blocks, &out, astc.SwizzleRGBA, 0, tc.opts); err != nil {
+			t.Fatalf("case %d: DecompressImageWithOptions: %v", ci, err)
+		}
+		for i := 0; i < w*h*4; i++ {
+			want := clamp01(float64(ref.DataF32[i]))
+			if i%4 != 3 {
+				want = tc.curve(float64(ref.DataF32[i]))
+			}
+			if d := math.Abs(float64(out.DataU8[i]) - want*255); d > 1 {
+				t.Fatalf("case %d: value %d = %d, want %.2f", ci, i, out.DataU8[i], want*255)
+			}
+		}
+	}
+
+	out := astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeU8, DataU8: make([]byte, w*h*4)}
+	for _, opts := range []astc.DecodeOptions{
+		{Tonemap: astc.TonemapACES + 1},
+		{Exposure: -1},
+		{Exposure: float32(math.NaN())},
+	} {
+		if err := ctx.DecompressImageWithOptions(blocks, &out, astc.SwizzleRGBA, 0, opts); astc.ErrorCodeOf(err) != astc.ErrBadParam {
+			t.Fatalf("%+v: expected ErrBadParam, got %v", opts, err)
+		}
+	}
+}
+
+func TestContext_DecompressImage_ChannelTransform(t *testing.T) {
+	const w, h = 8, 8
+	src := make([]byte, w*h*4)
+	for i := 0; i < w*h; i++ {
+		copy(src[i*4:], []byte{uint8(i * 4), uint8(255 - i*4), uint8(i), 200})
+	}
+	cfg, err := astc.ConfigInit(astc.ProfileLDR, 4, 4, 1, 60, 0)
+	if err != nil {
+		t.Fatalf("ConfigInit: %v", err)
+	}
+	ctx, err := astc.ContextAlloc(&cfg, 1)
+	if err != nil {
+		t.Fatalf("ContextAlloc: %v", err)
+	}
+	blocks := make([]byte, blocksLenBytes(w, h, 1, 4, 4, 1))
+	in := astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeU8, DataU8: src}
+	if err := ctx.CompressImage(&in, astc.SwizzleRGBA, blocks, 0); err != nil {
+		t.Fatalf("CompressImage: %v", err)
+	}
+
+	// Expand RG to -1..1, leave B alone and force A to 1; the swizzle runs first.
+	swz := astc.Swizzle{R: astc.SwzG, G: astc.SwzR, B: astc.SwzB, A: astc.SwzA}
+	xf := &astc.ChannelTransform{Scale: [4]float32{2, 2, 1, 0}, Bias: [4]float32{-1, -1, 0, 1}}
+	ref := astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeF32, DataF32: make([]float32, w*h*4)}
+	if err := ctx.DecompressImage(blocks, &ref, swz, 0); err != nil {
+		t.Fatalf("DecompressImage: %v", err)
+	}
+	out := astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeF32, DataF32: make([]float32, w*h*4)}
+	if err := ctx.DecompressImageWithOptions(blocks, &out, swz, 0, astc.DecodeOptions{Transform: xf}); err != nil {
+		t.Fatalf("DecompressImageWithOptions(F32): %v", err)
+	}
+	for i, v := range out.DataF32 {
+		c := i % 4
+		if want := ref.DataF32[i]*xf.Scale[c] + xf.Bias[c]; math.Abs(float64(v-want)) > 1e-6 {
+			t.Fatalf("F32 value %d = %v, want %v", i, v, want)
+		}
+	}
+
+	// TypeU8 results are clamped to 0..1; the optional clamp applies before that.
+	xf.Clamp = true
+	xf.Min = [4]float32{-1, -1, 0, 0}
+	xf.Max = [4]float32{0.5, 1, 1, 1}
+	refU8 := astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeU8, DataU8: make([]byte, w*h*4)}
+	if err := ctx.DecompressImage(blocks, &refU8, swz, 0); err != nil {
+		t.Fatalf("DecompressImage: %v", err)
+	}
+	outU8 := astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeU8, DataU8: make([]byte, w*h*4)}
+	if err := ctx.DecompressImageWithOptions(blocks, &outU8, swz, 0, astc.DecodeOptions{Transform: xf}); err != nil {
+		t.Fatalf("DecompressImageWithOptions(U8): %v", err)
+	}
+	for i, v := range outU8.DataU8 {
+		c := i % 4
+		want := float64(refU8.DataU8[i])/255*float64(xf.Scale[c]) + float64(xf.Bias[c])
+		want = math.Min(math.Max(want, float64(xf.Min[c])), float64(xf.Max[c]))
+		want = math.Min(math.Max(want, 0), 1)
+		if math.Abs(float64(v)-want*255) > 0.5001 {
+			t.Fatalf("U8 value %d = %d, want %.2f", i, v, want*255)
+		}
+	}
+
+	for _, bad := range []astc.ChannelTransform{
+		{Scale: [4]float32{float32(math.NaN())}},
+		{Bias: [4]float32{0, float32(math.Inf(1))}},
+		{Clamp: true, Min: [4]float32{1}},
+	} {
+		if err := ctx.DecompressImageWithOptions(blocks, &out, swz, 0, astc.DecodeOptions{Transform: &bad}); astc.ErrorCodeOf(err) != astc.ErrBadParam {
+			t.Fatalf("%+v: expected ErrBadParam, got %v", bad, err)
+		}
+	}
+}
+
+func TestContext_DecompressImage_RoundingProfile(t *testing.T) {
+	const w, h = 24, 24
+	src := make([]byte, w*h*4)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			copy(src[(y*w+x)*4:], []byte{uint8(x * 10), uint8(y * 7), uint8((x ^ y) * 9), uint8(255 - x*y/3)})
+		}
+	}
+	cfg, err := astc.ConfigInit(astc.ProfileLDR, 6, 6, 1, 60, 0)
+	if err != nil {
+		t.Fatalf("ConfigInit: %v", err)
+	}
+	ctx, err := astc.ContextAlloc(&cfg, 1)
+	if err != nil {
+		t.Fatalf("ContextAlloc: %v", err)
+	}
+	blocks := make([]byte, blocksLenBytes(w, h, 1, 6, 6, 1))
+	in := astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeU8, DataU8: src}
+	if err := ctx.CompressImage(&in, astc.SwizzleRGBA, blocks, 0); err != nil {
+		t.Fatalf("CompressImage: %v", err)
+	}
+	decode := func(opts astc.DecodeOptions) []byte {
+		t.Helper()
+		out := astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeU8, DataU8: make([]byte, w*h*4)}
+		if err := ctx.DecompressImageWithOptions(blocks, &out, astc.SwizzleRGBA, 0, opts); err != nil {
+			t.Fatalf("%v: DecompressImageWithOptions: %v", opts.RoundingProfile, err)
+		}
+		return out.DataU8
+	}
+
+	ref := decode(astc.DecodeOptions{})
+	if got := decode(astc.DecodeOptions{RoundingProfile: astc.RoundingReference}); !bytes.Equal(got, ref) {
+		t.Fatalf("RoundingReference differs from the default decode")
+	}
+	for _, r := range []astc.RoundingProfile{astc.RoundingRTZ, astc.RoundingRTN} {
+		got := decode(astc.DecodeOptions{RoundingProfile: r})
+		diffs := 0
+		for i := range got {
+			d := int(got[i]) - int(ref[i])
+			if d < -1 || d > 1 {
+				t.Fatalf("%v: value %d = %d, reference %d", r, i, got[i], ref[i])
+			}
+			if d != 0 {
+				diffs++
+			}
+		}
+		if diffs == 0 {
+			t.Fatalf("%v: output identical to the reference", r)
+		}
+	}
+
+	out := astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeU8, DataU8: make([]byte, w*h*4)}
+	if err := ctx.DecompressImageWithOptions(blocks, &out, astc.SwizzleRGBA, 0, astc.DecodeOptions{RoundingProfile: astc.RoundingRTN + 1}); astc.ErrorCodeOf(err) != astc.ErrBadParam {
+		t.Fatalf("invalid rounding profile: expected ErrBadParam, got %v", err)
+	}
+}
+
+func TestContext_DecompressImage_FloatOutputColorSpace(t *testing.T) {
+	const w, h = 8, 8
+	src := make([]byte, w*h*4)
+	for i := range src {
+		src[i] = uint8(i*29 + i/4)
+	}
+
+	decode := func(profile astc.Profile, flags astc.Flags, cs astc.ColorSpace) ([]byte, []float32, []uint16) {
+		cfg, err := astc.ConfigInit(profile, 4, 4, 1, 60, flags)
+		if err != nil {
+			t.Fatalf("ConfigInit: %v", err)
+		}
+		cfg.DecodeOutputColorSpace = cs
+		ctx, err := astc.ContextAlloc(&cfg, 1)
+		if err != nil {
+			t.Fatalf("ContextAlloc: %v", err)
+		}
+		defer ctx.Close()
+
+		blocks := make([]byte, blocksLenBytes(w, h, 1, 4, 4, 1))
+		in := astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeU8, DataU8: src}
+		if err := ctx.CompressImage(&in, astc.SwizzleRGBA, blocks, 0); err != nil {
+			t.Fatalf("CompressImage: %v", err)
+		}
+		u8 := astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeU8, DataU8: make([]byte, w*h*4)}
+		f32 := astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeF32, DataF32: make([]float32, w*h*4)}
+		f16 := astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeF16, DataF16: make([]uint16, w*h*4)}
+		for _, img := range []*astc.Image{&u8, &f32, &f16} {
+			if err := ctx.DecompressImage(blocks, img, astc.SwizzleRGBA, 0); err != nil {
+				t.Fatalf("DecompressImage: %v", err)
+			}
+		}
+		return u8.DataU8, f32.DataF32, f16.DataF16
+	}
+
+	// FlagUseDecodeUNORM8: float outputs match the 8-bit decode.
+	u8, f32, _ := decode(astc.ProfileLDR, astc.FlagUseDecodeUNORM8, astc.ColorSpaceEncoded)
+	for i := range u8 {
+		if want := float32(u8[i]) / 255; math.Abs(float64(f32[i]-want)) > 1e-6 {
+			t.Fatalf("decode_unorm8 F32[%d] = %f, want %f", i, f32[i], want)
+		}
+	}
+
+	// ColorSpaceLinear: RGB is linearized from the 8-bit decode, alpha is not.
+	u8, f32, f16 := decode(astc.ProfileLDRSRGB, 0, astc.ColorSpaceLinear)
+	_, encoded, _ := decode(astc.ProfileLDRSRGB, 0, astc.ColorSpaceEncoded)
+	linearized := false
+	for i := range u8 {
+		v := float64(u8[i]) / 255
+		want := v
+		if i%4 != 3 {
+			if v <= 0.04045 {
+				want = v / 12.92
+			} else {
+				want = math.Pow((v+0.055)/1.055, 2.4)
+			}
+		}
+		if math.Abs(float64(f32[i])-want) > 1e-6 {
+			t.Fatalf("linear F32[%d] = %f, want %f", i, f32[i], want)
+		}
+		if math.Abs(float64(astc.HalfToFloat32(f16[i]))-want) > 1e-3 {
+			t.Fatalf("linear F16[%d] = %f, want %f", i, astc.HalfToFloat32(f16[i]), want)
+		}
+		if math.Abs(float64(encoded[i])-want) > 0.01 {
+			linearized = true
+		}
+	}
+	if !linearized {
+		t.Fatalf("ColorSpaceEncoded output is already linear")
+	}
+
+	cfg, _ := astc.ConfigInit(astc.ProfileLDRSRGB, 4, 4, 1, 60, 0)
+	cfg.DecodeOutputColorSpace = astc.ColorSpaceLinear + 1
+	if _, err := astc.ContextAlloc(&cfg, 1); astc.ErrorCodeOf(err) != astc.ErrBadParam {
+		t.Fatalf("expected ErrBadParam for invalid color space, got %v", err)
+	}
+}
+
+func TestContext_CompressImage_HDRInput(t *testing.T) {
+	const w, h = 8, 4
+	encode := func(profile astc.Profile, policy astc.HDRInputPolicy, pix []float32) []byte {
+		t.Helper()
+		cfg, err := astc.ConfigInit(profile, 4, 4, 1, 10, 0)
+		if err != nil {
+			t.Fatalf("ConfigInit: %v", err)
+		}
+		cfg.HDRInput = policy
+		ctx, err := astc.ContextAlloc(&cfg, 1)
+		if err != nil {
+			t.Fatalf("ContextAlloc: %v", err)
+		}
+		blocks := make([]byte, blocksLenBytes(w, h, 1, 4, 4, 1))
+		img := astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeF32, DataF32: pix}
+		if err := ctx.CompressImage(&img, astc.SwizzleRGBA, blocks, 0); err != nil {
+			t.Fatalf("CompressImage(policy %d): %v", policy, err)
+		}
+		return blocks
+	}
+
+	// The left block holds a constant subnormal color, the right one NaN.
+	pix := make([]float32, w*h*4)
+	zeroed := make([]float32, w*h*4)
+	for i := range pix {
+		if i/4%w < 4 {
+			pix[i] = 1e-5
+		} else {
+			pix[i] = float32(math.NaN())
+		}
+	}
+	flushed := append([]float32(nil), pix...)
+	for i := range flushed {
+		if i/4%w < 4 {
+			flushed[i] = 0
+		}
+	}
+	def := encode(astc.ProfileHDR, 0, pix)
+	out := make([]float32, w*h*4)
+	hdr := astc.Header{BlockX: 4, BlockY: 4, BlockZ: 1, SizeX: w, SizeY: h, SizeZ: 1}
+	if err := astc.DecodeRGBAF32VolumeFromParsedWithProfileInto(astc.ProfileHDR, hdr, def, out); err != nil {
+		t.Fatal(err)
+	}
+	if want := float32(0xa8) / (1 << 24); out[0] != want { // The nearest FP16 subnormal.
+		t.Fatalf("subnormal decoded to %g, want %g", out[0], want)
+	}
+	if !math.IsNaN(float64(out[4*4])) {
+		t.Fatalf("NaN block decoded to %g", out[4*4])
+	}
+	if !bytes.Equal(encode(astc.ProfileHDR, astc.HDRFlushSubnormals, pix), encode(astc.ProfileHDR, 0, flushed)) {
+		t.Fatalf("HDRFlushSubnormals differs from encoding zeros")
+	}
+	if !bytes.Equal(encode(astc.ProfileHDR, astc.HDRFlushSubnormals|astc.HDRFlushNaN, pix), encode(astc.ProfileHDR, 0, zeroed)) {
+		t.Fatalf("HDRFlushNaN differs from encoding zeros")
+	}
+
+	// HDRAlphaUNORM encodes ProfileHDR alpha like ProfileHDRRGBLDRAlpha.
+	for i := range pix {
+		pix[i] = float32(i%13) * []float32{3, 2, 1, 0.07}[i%4]
+	}
+	unorm := encode(astc.ProfileHDR, astc.HDRAlphaUNORM, pix)
+	if !bytes.Equal(unorm, encode(astc.ProfileHDRRGBLDRAlpha, 0, pix)) {
+		t.Fatalf("HDRAlphaUNORM differs from ProfileHDRRGBLDRAlpha")
+	}
+	if bytes.Equal(unorm, encode(astc.ProfileHDR, 0, pix)) {
+		t.Fatalf("HDRAlphaUNORM had no effect")
+	}
+
+	cfg, err := astc.ConfigInit(astc.ProfileHDR, 4, 4, 1, 10, 0)
+	if err != nil {
+		t.Fatalf("ConfigInit: %v", err)
+	}
+	cfg.HDRInput = 1 << 7
+	if _, err := astc.ContextAlloc(&cfg, 1); astc.ErrorCodeOf(err) != astc.ErrBadParam {
+		t.Fatalf("ContextAlloc(invalid HDRInput) = %v", err)
+	}
+}
+
+func TestContext_CompressImage_InputSanitize(t *testing.T) {
+	const w, h = 16, 8
+	clean := make([]float32, w*h*4)
+	for i := range clean {
+		clean[i] = []float32{0.5, 2, 8, 1}[i%4]
+	}
+	dirty := append([]float32(nil), clean...)
+	dirty[(2*w+3)*4+0] = float32(math.NaN())
+	dirty[(5*w+10)*4+1] = -1
+	dirty[(1*w+12)*4+2] = float32(math.Inf(1))
+	dirtyCopy := append([]float32(nil), dirty...)
+
+	encode := func(mode astc.SanitizeMode, pix []float32) ([]byte, error) {
+		t.Helper()
+		cfg, err := astc.ConfigInit(astc.ProfileHDR, 4, 4, 1, 10, 0)
+		if err != nil {
+			t.Fatalf("ConfigInit: %v", err)
+		}
+		cfg.InputSanitize = mode
+		ctx, err := astc.ContextAlloc(&cfg, 1)
+		if err != nil {
+			t.Fatalf("ContextAlloc: %v", err)
+		}
+		blocks := make([]byte, blocksLenBytes(w, h, 1, 4, 4, 1))
+		img := astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeF32, DataF32: pix}
+		return blocks, ctx.CompressImage(&img, astc.SwizzleRGBA, blocks, 0)
+	}
+	mustEncode := func(mode astc.SanitizeMode, pix []float32) []byte {
+		t.Helper()
+		blocks, err := encode(mode, pix)
+		if err != nil {
+			t.Fatalf("CompressImage(mode %d): %v", mode, err)
+		}
+		return blocks
+	}
+
+	// Every neighbor holds the clean value, so the repaired image is the clean one.
+	if !bytes.Equal(mustEncode(astc.SanitizeNeighborAverage, dirty), mustEncode(astc.SanitizeNone, clean)) {
+		t.Fatalf("SanitizeNeighborAverage did not restore the clean image")
+	}
+	clamped := append([]float32(nil), clean...)
+	clamped[(2*w+3)*4+0] = 0
+	clamped[(5*w+10)*4+1] = 0
+	clamped[(1*w+12)*4+2] = 65504
+	if !bytes.Equal(mustEncode(astc.SanitizeClamp, dirty), mustEncode(astc.SanitizeNone, clamped)) {
+		t.Fatalf("SanitizeClamp differs from the clamped image")
+	}
+	if !bytes.Equal(mustEncode(astc.SanitizeClamp, clean), mustEncode(astc.SanitizeNone, clean)) {
+		t.Fatalf("SanitizeClamp changed a valid image")
+	}
+
+	_, err := encode(astc.SanitizeError, dirty)
+	if astc.ErrorCodeOf(err) != astc.ErrBadParam {
+		t.Fatalf("SanitizeError: %v", err)
+	}
+	for _, want := range []string{"3 texels", "(12,1,0)", "(3,2,0)", "(10,5,0)"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("SanitizeError message %q does not mention %s", err, want)
+		}
+	}
+	for i := range dirty {
+		if math.Float32bits(dirty[i]) != math.Float32bits(dirtyCopy[i]) {
+			t.Fatalf("CompressImage modified the input at %d", i)
+		}
+	}
+
+	// FP16 input is checked the same way; negative zero is valid.
+	half := make([]uint16, w*h*4)
+	for i := range half {
+		half[i] = 0x3C00
+	}
+	half[5] = 0x8000
+	cfg, err := astc.ConfigInit(astc.ProfileHDR, 4, 4, 1, 10, 0)
+	if err != nil {
+		t.Fatalf("ConfigInit: %v", err)
+	}
+	cfg.InputSanitize = astc.SanitizeError
+	ctx, err := astc.ContextAlloc(&cfg, 1)
+	if err != nil {
+		t.Fatalf("ContextAlloc: %v", err)
+	}
+	img := astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeF16, DataF16: half}
+	blocks := make([]byte, blocksLenBytes(w, h, 1, 4, 4, 1))
+	if err := ctx.CompressImage(&img, astc.SwizzleRGBA, blocks, 0); err != nil {
+		t.Fatalf("CompressImage(F16): %v", err)
+	}
+	half[(7*w+15)*4+3] = 0x7E00 // NaN
+	if err := ctx.CompressImage(&img, astc.SwizzleRGBA, blocks, 0); err == nil || !strings.Contains(err.Error(), "(15,7,0)") {
+		t.Fatalf("CompressImage(F16 NaN) = %v", err)
+	}
+}
@@ -1,6 +1,7 @@
 package astc
 
 import (
+	"io"
 	"sync"
 	"sync/atomic"
 )
@@ -51,6 +52,123 @@ const (
 	TypeF32
 )
 
+// PixelLayout is the memory layout of TypeU8 image data passed to CompressImage.
+type PixelLayout uint8
+
+const (
+	// LayoutRGBA stores 4 bytes per texel in R, G, B, A order.
+	LayoutRGBA PixelLayout = iota
+	// LayoutBGRA stores 4 bytes per texel in B, G, R, A order, as produced by Windows screen
+	// capture and many GUI toolkits.
+	LayoutBGRA
+	// LayoutRGBX stores 4 bytes per texel in R, G, B order followed by an ignored byte; alpha is
+	// read as 255.
+	LayoutRGBX
+	// LayoutRGB stores 3 bytes per texel in R, G, B order; alpha is read as 255.
+	LayoutRGB
+	// LayoutLA stores 2 bytes per texel, luminance then alpha, read as L, L, L, A. ASTC's
+	// luminance+alpha endpoint modes encode this efficiently, so it also suits other two-channel
+	// data such as RG detail maps or normal maps, with the first channel in L and the second in A
+	// (upstream's "rrrg" convention).
+	LayoutLA
+)
+
+// EdgeMode selects how CompressImage fills the texels of edge blocks which lie outside the image
+// when the image dimensions are not multiples of the block size.
+type EdgeMode uint8
+
+const (
+	// EdgeReplicate clamps out-of-bounds texel coordinates to the image edge, replicating the last
+	// row/column/slice (matches upstream).
+	EdgeReplicate EdgeMode = iota
+	// EdgeError rejects images whose dimensions are not multiples of the block size.
+	EdgeError
+	// EdgePad fills out-of-bounds texels with Config.EdgePadColor.
+	EdgePad
+)
+
+// ColorSpace selects how DecompressImage returns ProfileLDRSRGB data in TypeF32/TypeF16 images.
+type ColorSpace uint8
+
+const (
+	// ColorSpaceEncoded returns sRGB-encoded values, as stored (matches upstream).
+	ColorSpaceEncoded ColorSpace = iota
+	// ColorSpaceLinear applies the sRGB EOTF to RGB, returning linear-light values as a GPU sampler
+	// does. Texels are first decoded with decode_unorm8 rounding, like GPU sRGB formats; alpha is
+	// not converted.
+	ColorSpaceLinear
+)
+
+// Tonemap selects the curve used to map HDR values into 0..1 when HDR profiles are decoded to
+// TypeU8 images.
+type Tonemap uint8
+
+const (
+	// TonemapClamp clamps values to 0..1 (matches upstream).
+	TonemapClamp Tonemap = iota
+	// TonemapReinhard applies x/(1+x).
+	TonemapReinhard
+	// TonemapACES applies Narkowicz's fit of the ACES filmic curve.
+	TonemapACES
+)
+
+// DecodeOptions selects optional post-processing applied by Context.DecompressImageWithOptions.
+// The zero value applies none and matches DecompressImage.
+type DecodeOptions struct {
+	// RenormalizeNormals treats the decoded data as a FlagMapNormal normal map (X in R, Y in A,
+	// both stored as n*0.5+0.5), reconstructs Z and writes the unit-length normal to RGB. Alpha is
+	// left unchanged. Vectors whose X/Y length drifted past 1 (e.g. after compression or mip
+	// filtering) are rescaled onto the unit circle with Z=0. It runs before the output swizzle.
+	RenormalizeNormals bool
+
+	// Tonemap and Exposure apply when an HDR profile is decoded to a TypeU8 image: RGB is scaled
+	// by Exposure (zero means 1) and mapped through Tonemap before quantization, so previews of
+	// HDR textures are not uniformly blown out. The result is linear; alpha is only clamped. They
+	// have no effect on LDR profiles or float outputs.
+	Tonemap  Tonemap
+	Exposure float32
+
+	// Transform, if set, applies a per-channel scale and bias to the output after the output
+	// swizzle, e.g. to expand RG normal maps from 0..1 to -1..1 floats without another pass over
+	// the image.
+	Transform *ChannelTransform
+
+	// RoundingProfile selects the rounding of LDR profiles decoded to TypeU8 images, to reproduce
+	// the output of specific hardware decoders. Other decodes ignore it.
+	RoundingProfile RoundingProfile
+
+	// Deblock, from 0 (off) to 1, smooths block-boundary artifacts after the whole image is
+	// decoded (see the Deblock function). With multiple threads, the thread which decodes the last
+	// block runs the pass before returning. Boundaries of blocks cleared in Image.BlockMask are not
+	// filtered, so their texels stay untouched.
+	Deblock float32
+
+	// FlipY stores the rows of each 2D slice bottom-up, as OpenGL expects pixel uploads: texel
+	// row y of the image is written to row DimY-1-y. The flip is applied as blocks are stored, so
+	// it costs no extra pass over the image.
+	FlipY bool
+
+	// MaxPixels and MaxBlocks bound the texel and block counts of the images a decode accepts,
+	// guarding services that decode untrusted files against headers describing huge images.
+	// Larger images fail with a *DecodeLimitError before anything is allocated. Zero selects
+	// DefaultMaxPixels and DefaultMaxBlocks; a negative value disables the ceiling.
+	MaxPixels int64
+	MaxBlocks int64
+}
+
+// ChannelTransform is a per-channel linear transform applied by DecompressImageWithOptions. Each
+// output channel c becomes v*Scale[c] + Bias[c], where v is the decoded value (0..1 for TypeU8
+// outputs), optionally clamped to [Min[c], Max[c]]. TypeU8 results are then clamped to 0..1 and
+// rounded back to 8 bits.
+type ChannelTransform struct {
+	Scale [4]float32
+	Bias  [4]float32
+
+	Clamp bool
+	Min   [4]float32
+	Max   [4]float32
+}
+
 // Config is a Go equivalent of upstream astcenc_config.
 type Config struct {
 	Profile Profile
@@ -68,6 +186,19 @@ type Config struct {
 	AScaleRadius uint32
 	RGBMMScale   float32
 
+	// VarianceRadius enables saliency weighting from the luma variance of the source image, in
+	// the spirit of upstream's -v option, since noise masks compression error. Each texel's error
+	// is weighted from 1 where the luma standard deviation over the texels within
+	// VarianceRadius-1 of it in x and y is zero, falling towards 0 as it rises, so blocks spend
+	// their precision on the flat texels. Each block's block mode and partition search limits are
+	// also scaled, from 1.5 times for flat blocks down to half, by the standard deviation over its
+	// footprint extended the same way. Zero (the ConfigInit default) disables it; values above 32
+	// are clamped. Texel weights do not apply to FlagMapNormal and FlagMapRGBM encodes.
+	//
+	// VariancePower sharpens (above 1) or softens (below 1) the weighting; zero means 1.
+	VarianceRadius uint32
+	VariancePower  float32
+
 	TunePartitionCountLimit            uint32
 	Tune2PartitionIndexLimit           uint32
 	Tune3PartitionIndexLimit           uint32
@@ -85,7 +216,121 @@ type Config struct {
 	Tune2PlaneEarlyOutLimitCorrelation float32
 	TuneSearchMode0Enable              float32
 
+	// TuneStochasticIterations enables a simulated-annealing refinement of each block's endpoints
+	// and weights with this many steps. It only applies at the exhaustive preset (TuneBlockModeLimit
+	// above 98) to LDR color data, and is seeded from the block coordinates so output is
+	// reproducible regardless of thread count. Zero (the ConfigInit default) disables it.
+	TuneStochasticIterations uint32
+
+	// TunePartitionNeighborSeeding seeds each block's partition search with the partitionings
+	// chosen by its left and top neighbors, which often continue the same edges. For partition
+	// counts that received a seed, only the seeds and the best-scoring half of the partition
+	// indices up to the index limit are tried, instead of every index. This cuts encode time by
+	// about a quarter on natural images for a PSNR loss of a few hundredths of a dB; hard alpha
+	// edges lose more (up to about 0.8 dB). It applies to LDR profiles.
+	//
+	// With more than one thread, a neighbor still being encoded by another thread contributes no
+	// seed, so the output can vary between runs; compress with one thread for reproducible output.
+	TunePartitionNeighborSeeding bool
+
+	// ExperimentalBlockErrorDiffusion encodes the blocks one at a time in serpentine order (left
+	// to right on even block rows, right to left on odd ones) and adds half of each block's mean
+	// quantization residue per channel to the target colors of the next, dithering at block scale
+	// so that the average color of a region follows the source more closely. It applies to LDR
+	// profiles with TypeU8 images.
+	//
+	// It is experimental: the encoding is serialized on one thread (other threads return
+	// immediately), and it trades per-block error for regional mean error. See the README for
+	// measurements.
+	ExperimentalBlockErrorDiffusion bool
+
+	// TuneColorQuantMin/Max and TuneWeightQuantMin/Max bound the endpoint and weight quantization
+	// the encoder considers, as a number of quantization levels (e.g. TuneWeightQuantMin = 12 never
+	// uses fewer than 12 weight levels). Setting min and max equal forces one level. Zero leaves a
+	// bound open; nonzero values must be valid ASTC levels (2..32 for weights, 6..256 for colors).
+	//
+	// Because ASTC derives the color quantization from the bits left in the block, the color bounds
+	// reject candidate encodings rather than requantizing them. Blocks with no candidate in range
+	// fall back to a constant-color block. These are diagnostic settings; they only slow down or
+	// degrade a normal encode.
+	TuneColorQuantMin  uint32
+	TuneColorQuantMax  uint32
+	TuneWeightQuantMin uint32
+	TuneWeightQuantMax uint32
+
+	// DisallowedBlockModes lists block modes the encoder must not emit, e.g. to work around
+	// decoder errata on a target GPU (see BlockModesForErrata). The modes are interpreted for the
+	// configured block dimensionality. Blocks left with no allowed candidate are encoded as
+	// constant-color blocks.
+	DisallowedBlockModes BlockModeMask
+
+	// ForcedBlockModes, if not empty, restricts the encoder to the listed block modes, e.g. to
+	// reproduce the mode choices of another encoder for differential testing. It combines with
+	// DisallowedBlockModes: a mode in both sets is not used.
+	ForcedBlockModes BlockModeMask
+
+	// DisableDualPlane and MaxPartitionCountHard are hard prohibitions for generating corpora
+	// restricted to a feature subset, e.g. for hardware validation. Unlike the tuning limits, which
+	// ConfigInit presets and flags adjust, they apply to every block the LDR and HDR encoders emit,
+	// including blocks reused from CompressImageWithHint hints. DisableDualPlane forbids dual-plane
+	// block modes; MaxPartitionCountHard, if nonzero, caps the partition count (1 forces single
+	// partition blocks). Values above 4 are rejected.
+	DisableDualPlane      bool
+	MaxPartitionCountHard uint32
+
+	// EdgeMode selects the handling of partial edge blocks; the zero value is EdgeReplicate.
+	EdgeMode EdgeMode
+	// EdgePadColor is the RGBA fill color used by EdgePad, in input (pre-swizzle) channel order.
+	// For TypeU8 images it is normalized to 0..1; for TypeF16/TypeF32 images it is used as-is.
+	EdgePadColor [4]float32
+
+	// InputSanitize selects the handling of NaN, infinite and negative values in TypeF16/TypeF32
+	// input images; the zero value is SanitizeNone. Repairs are made on a copy of the image.
+	InputSanitize SanitizeMode
+	// HDRInput adjusts how ProfileHDR and ProfileHDRRGBLDRAlpha encode FP16 subnormal and NaN input
+	// values and how ProfileHDR encodes alpha; the zero value keeps the defaults documented on each
+	// HDRInputPolicy flag. It is applied after InputSanitize.
+	HDRInput HDRInputPolicy
+
+	// BlockOrder is the order of the blocks in the payloads written by CompressImage and read by
+	// DecompressImage; the zero value is BlockOrderLinear. Block hints passed to
+	// CompressImageWithHint use the same order. .astc files always store linear payloads, so
+	// convert other orders with ReorderBlocks before writing one.
+	BlockOrder BlockOrder
+	// BlockOrderFunc, if set, replaces BlockOrder with a caller-defined layout such as a console
+	// tiling mode: it returns the payload index of the block at block coordinates (bx, by, bz).
+	// CompressImage and DecompressImage call it once per block of each image and fail with
+	// ErrBadParam unless it maps the blocks one-to-one onto the payload. Like ProgressCallback, it
+	// is not serialized by MarshalBinary or MarshalJSON.
+	BlockOrderFunc BlockOrderFunc
+
+	// DecodeOutputColorSpace selects whether ProfileLDRSRGB data decoded to TypeF32/TypeF16 is
+	// linearized. It has no effect on TypeU8 outputs or other profiles.
+	//
+	// Independently of this setting, FlagUseDecodeUNORM8 makes LDR decodes to TypeF32/TypeF16 use
+	// decode_unorm8 rounding, so float outputs equal the TypeU8 output divided by 255.
+	DecodeOutputColorSpace ColorSpace
+
+	// CompatLevel pins the encoder heuristics to a compatibility level, so the output for this
+	// Config stays byte-identical across library upgrades; the zero value, CompatLatest, follows
+	// the newest heuristics. Levels newer than the library knows are rejected.
+	CompatLevel CompatLevel
+
+	// WeightSampling selects how LDR encodes derive decimated weight grids from the ideal texel
+	// weights. The zero value, WeightSamplingAuto, picks by quality; an explicit policy applies
+	// at every quality.
+	WeightSampling WeightSampling
+
 	ProgressCallback func(progress float32)
+
+	// DecisionLog, if set, receives a JSON DecisionLog document describing the encoding chosen for
+	// every block each time CompressImage completes an image. With multiple threads it is written
+	// once, by the last thread to finish, whose CompressImage call returns any write error.
+	DecisionLog io.Writer
+
+	// Metrics, if set, receives the Context's block counts, errors, hint hits, context reuses and
+	// per-image timings under the Metric* names. Like ProgressCallback, it is not serialized.
+	Metrics Metrics
 }
 
 // Image is a tightly-packed RGBA image used for CompressImage/DecompressImage.
@@ -95,6 +340,30 @@ type Image struct {
 	DimZ     int
 	DataType DataType
 
+	// Layout is the byte layout of DataU8 for CompressImage; the zero value is LayoutRGBA. Texels
+	// are converted to RGBA as blocks are extracted, before the compression swizzle. It must be
+	// LayoutRGBA for other data types and for decompression outputs.
+	Layout PixelLayout
+
+	// Importance optionally holds one byte per texel, in the same order as the pixel data, that
+	// steers CompressImage towards the texels artists paint, such as faces or logos, at the expense
+	// of backgrounds; 128 is neutral. Each block sets its search effort from the mean over its
+	// texels, 255 doubling the block mode and partition search limits and 0 halving them, and each
+	// texel's error is weighted from 4 at 255 down to 1/4 at 0 (exponentially in between), so
+	// blocks straddling a painted edge spend their precision on the painted side. Texel weights do
+	// not apply to FlagMapNormal and FlagMapRGBM encodes. nil disables it; it is ignored by
+	// DecompressImage.
+	Importance []byte
+
+	// BlockMask optionally marks which blocks are resident, for sparse and virtual textures whose
+	// tiles are only partly available: bit i%8 of byte i/8 is set for block i, counting blocks in
+	// linear order (x fastest, then y, then z) whatever the Config.BlockOrder, and must exist for
+	// every block. CompressImage writes a constant transparent black placeholder for each cleared
+	// block without searching (the texels of those blocks are not used, but must still be
+	// present), and DecompressImage leaves the texels of cleared blocks untouched. nil marks every
+	// block resident.
+	BlockMask []byte
+
 	DataU8  []byte
 	DataF16 []uint16
 	DataF32 []float32
@@ -162,6 +431,9 @@ type Context struct {
 	// One active operation at a time.
 	state atomic.Uint32
 
+	// images counts the images started on the context, for MetricContextReuses.
+	images atomic.Uint64
+
 	compress   opState
 	decompress opState
 }
@@ -175,16 +447,52 @@ type opState struct {
 
 	cancel atomic.Uint32
 
+	// Decompress input copied at initialization because it overlaps the output image.
+	input []byte
+
 	// Task scheduling.
 	totalBlocks atomic.Uint32
 	nextBlock   atomic.Uint32
 	doneBlocks  atomic.Uint32
 
+	// startNanos is the time the operation started, in nanoseconds since progressEpoch, for
+	// Context.Progress and Config.Metrics.
+	startNanos atomic.Int64
+
+	// hintHits counts the blocks reused by CompressImageWithHint, for Config.Metrics.
+	hintHits atomic.Uint32
+
 	// Progress callback throttling (mirrors upstream ParallelManager behavior).
 	progressMu            sync.Mutex
 	progressMinDiffBits   atomic.Uint32 // float32 bits
 	progressLastValueBits atomic.Uint32 // float32 bits
 
+	// Compress input repaired for Config.InputSanitize (nil if unchanged), or the error rejecting
+	// it.
+	sanitized   *Image
+	sanitizeErr error
+
+	// Payload index of each block, in linear order, for Config.BlockOrder or
+	// Config.BlockOrderFunc (nil for linear payloads), or the error rejecting the order function.
+	storedIndex   []int
+	blockOrderErr error
+
 	// Alpha-scale RDO precompute (mirrors upstream input_alpha_averages).
 	inputAlphaAverages []float32
+
+	// Per-texel error weights for Config.VarianceRadius.
+	inputVarianceWeights []float32
+
+	// Per-block encode decisions for Config.DecisionLog, and the image size in blocks.
+	decisions       []BlockDecision
+	decisionsBlocks [3]int
+
+	// Packed partitionings chosen for each block, in linear order, for
+	// Config.TunePartitionNeighborSeeding; zero until the block is encoded with 2 or more
+	// partitions.
+	partitionSeeds []atomic.Uint32
+
+	// diffusionClaimed is set by the thread which encodes the image for
+	// Config.ExperimentalBlockErrorDiffusion.
+	diffusionClaimed atomic.Bool
 }
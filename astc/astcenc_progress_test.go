@@ -1,6 +1,8 @@
 package astc_test
 
 import (
+	"fmt"
+	"runtime"
 	"testing"
 
 	"github.com/arm-software/astc-encoder/astc"
@@ -41,3 +43,75 @@ func TestContext_ProgressCallback_ThrottledAndHits100(t *testing.T) {
 		t.Fatalf("progress callback calls: got %v want [100]", calls)
 	}
 }
+
+func TestContext_Progress_PollsFromAnotherGoroutine(t *testing.T) {
+	cfg, err := astc.ConfigInit(astc.ProfileLDR, 4, 4, 1, astc.EncodeMedium.Level(), 0)
+	if err != nil {
+		t.Fatalf("ConfigInit: %v", err)
+	}
+	// Poll from the callback's goroutine too: Progress must not deadlock with it.
+	var ctx *astc.Context
+	var inCallback []uint32
+	cfg.ProgressCallback = func(float32) {
+		done, _, _ := ctx.Progress()
+		inCallback = append(inCallback, done)
+	}
+	ctx, err = astc.ContextAlloc(&cfg, 1)
+	if err != nil {
+		t.Fatalf("ContextAlloc: %v", err)
+	}
+	defer ctx.Close()
+	if done, total, eta := ctx.Progress(); done != 0 || total != 0 || eta != 0 {
+		t.Fatalf("Progress before compressing = %d/%d eta %v, want zeros", done, total, eta)
+	}
+
+	const w, h = 96, 64
+	src := make([]byte, w*h*4)
+	for i := range src {
+		src[i] = byte(i*31 + i/7)
+	}
+	const totalBlocks = (w / 4) * (h / 4)
+	blocks := make([]byte, totalBlocks*astc.BlockBytes)
+	img := astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeU8, DataU8: src}
+
+	stop := make(chan struct{})
+	polled := make(chan error, 1)
+	go func() {
+		var last uint32
+		for {
+			done, total, eta := ctx.Progress()
+			switch {
+			case done < last:
+				polled <- fmt.Errorf("done went backwards: %d after %d", done, last)
+				return
+			case total != 0 && total != totalBlocks:
+				polled <- fmt.Errorf("total = %d, want %d", total, totalBlocks)
+				return
+			case done > 0 && done < total && eta <= 0:
+				polled <- fmt.Errorf("eta %v at %d/%d blocks", eta, done, total)
+				return
+			}
+			last = done
+			select {
+			case <-stop:
+				polled <- nil
+				return
+			default:
+				runtime.Gosched()
+			}
+		}
+	}()
+	if err := ctx.CompressImage(&img, astc.SwizzleRGBA, blocks, 0); err != nil {
+		t.Fatalf("CompressImage: %v", err)
+	}
+	close(stop)
+	if err := <-polled; err != nil {
+		t.Fatal(err)
+	}
+	if done, total, eta := ctx.Progress(); done != totalBlocks || total != totalBlocks || eta != 0 {
+		t.Fatalf("Progress after compressing = %d/%d eta %v, want %d/%d eta 0", done, total, eta, totalBlocks, totalBlocks)
+	}
+	if len(inCallback) == 0 || inCallback[len(inCallback)-1] != totalBlocks {
+		t.Fatalf("Progress from the callback = %v, want it to end at %d", inCallback, totalBlocks)
+	}
+}
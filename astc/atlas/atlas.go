@@ -0,0 +1,251 @@
+package atlas
+
+import (
+	"cmp"
+	"errors"
+	"math"
+	"slices"
+
+	"github.com/arm-software/astc-encoder/astc"
+)
+
+// Sprite is one input image: Width x Height RGBA8 texels, 4 bytes per texel in row-major order.
+type Sprite struct {
+	Pix    []byte
+	Width  int
+	Height int
+}
+
+// Options configures packing and encoding.
+type Options struct {
+	// BlockX and BlockY select the ASTC block footprint the atlas is aligned to and encoded with
+	// (default 4x4).
+	BlockX, BlockY int
+	// MaxWidth limits the atlas width in texels; 0 picks a roughly square atlas.
+	MaxWidth int
+	// Gutter is the number of edge-replicated texels kept around each sprite, for mipmapped or
+	// filtered sampling beyond the sprite edge.
+	Gutter int
+	// EncoderOptions are passed to astc.NewEncoder by Build. The block size is always set from
+	// BlockX and BlockY.
+	EncoderOptions []astc.Option
+}
+
+// Rect is the placement of one sprite in the atlas: its texel rectangle and the matching
+// normalized texture coordinates, where (0, 0) is the top-left corner of the atlas and (1, 1) its
+// bottom-right corner.
+type Rect struct {
+	X, Y, Width, Height int
+	U0, V0, U1, V1      float32
+}
+
+// Layout is the result of packing: the atlas size in texels (a multiple of the block footprint)
+// and one Rect per sprite, in input order.
+type Layout struct {
+	Width, Height int
+	Rects         []Rect
+}
+
+// Atlas is a packed and encoded atlas.
+type Atlas struct {
+	Layout
+	// Data is the encoded .astc file.
+	Data []byte
+}
+
+func (o Options) blockSize() (int, int) {
+	if o.BlockX == 0 && o.BlockY == 0 {
+		return 4, 4
+	}
+	return o.BlockX, o.BlockY
+}
+
+// Pack computes the placement of sprites without composing or encoding the atlas. Only the
+// sprite dimensions are used.
+func Pack(sprites []Sprite, opts Options) (Layout, error) {
+	bx, by := opts.blockSize()
+	if bx <= 0 || by <= 0 || bx > 255 || by > 255 {
+		return Layout{}, errors.New("astc/atlas: invalid block size")
+	}
+	if opts.MaxWidth < 0 || opts.Gutter < 0 {
+		return Layout{}, errors.New("astc/atlas: invalid options")
+	}
+	if len(sprites) == 0 {
+		return Layout{}, errors.New("astc/atlas: no sprites")
+	}
+
+	// Each sprite occupies a cell of whole blocks holding the sprite and its gutter.
+	type cell struct{ w, h int }
+	cells := make([]cell, len(sprites))
+	area, widest := 0, 0
+	for i, s := range sprites {
+		if s.Width <= 0 || s.Height <= 0 {
+			return Layout{}, errors.New("astc/atlas: invalid sprite dimensions")
+		}
+		cells[i] = cell{
+			w: (s.Width + 2*opts.Gutter + bx - 1) / bx,
+			h: (s.Height + 2*opts.Gutter + by - 1) / by,
+		}
+		area += cells[i].w * cells[i].h
+		widest = max(widest, cells[i].w)
+	}
+
+	width := opts.MaxWidth / bx
+	if opts.MaxWidth == 0 {
+		// Aim for a square atlas in texels.
+		width = max(int(math.Ceil(math.Sqrt(float64(area)*float64(by)/float64(bx)))), widest)
+	} else if widest > width {
+		return Layout{}, errors.New("astc/atlas: sprite wider than MaxWidth")
+	}
+
+	order := make([]int, len(sprites))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		if c := cmp.Compare(cells[b].h, cells[a].h); c != 0 {
+			return c
+		}
+		return cmp.Compare(cells[b].w, cells[a].w)
+	})
+
+	sky := skyline{{x: 0, y: 0, w: width}}
+	layout := Layout{Rects: make([]Rect, len(sprites))}
+	usedW, usedH := 0, 0
+	for _, i := range order {
+		x, y := sky.place(cells[i].w, cells[i].h)
+		usedW = max(usedW, x+cells[i].w)
+		usedH = max(usedH, y+cells[i].h)
+		layout.Rects[i] = Rect{
+			X:      x*bx + opts.Gutter,
+			Y:      y*by + opts.Gutter,
+			Width:  sprites[i].Width,
+			Height: sprites[i].Height,
+		}
+	}
+
+	layout.Width, layout.Height = usedW*bx, usedH*by
+	if layout.Width > astc.MaxImageDim || layout.Height > astc.MaxImageDim {
+		return Layout{}, errors.New("astc/atlas: atlas too large")
+	}
+	for i := range layout.Rects {
+		r := &layout.Rects[i]
+		r.U0 = float32(r.X) / float32(layout.Width)
+		r.V0 = float32(r.Y) / float32(layout.Height)
+		r.U1 = float32(r.X+r.Width) / float32(layout.Width)
+		r.V1 = float32(r.Y+r.Height) / float32(layout.Height)
+	}
+	return layout, nil
+}
+
+// Compose draws sprites into an RGBA8 atlas image of layout, which must come from Pack with the
+// same sprites and options. Texels outside every sprite cell are transparent black.
+func Compose(sprites []Sprite, layout Layout, opts Options) ([]byte, error) {
+	bx, by := opts.blockSize()
+	if len(layout.Rects) != len(sprites) {
+		return nil, errors.New("astc/atlas: layout does not match the sprites")
+	}
+	pix := make([]byte, layout.Width*layout.Height*4)
+	for i, s := range sprites {
+		r := layout.Rects[i]
+		if len(s.Pix) < s.Width*s.Height*4 {
+			return nil, errors.New("astc/atlas: sprite pixel buffer too small")
+		}
+		if r.Width != s.Width || r.Height != s.Height {
+			return nil, errors.New("astc/atlas: layout does not match the sprites")
+		}
+
+		// Fill the sprite's whole cell, clamping to the sprite edge.
+		x0, y0 := r.X-opts.Gutter, r.Y-opts.Gutter
+		x1 := x0 + (r.Width+2*opts.Gutter+bx-1)/bx*bx
+		y1 := y0 + (r.Height+2*opts.Gutter+by-1)/by*by
+		if x0 < 0 || y0 < 0 || x1 > layout.Width || y1 > layout.Height {
+			return nil, errors.New("astc/atlas: layout does not match the sprites")
+		}
+		for y := y0; y < y1; y++ {
+			sy := min(max(y-r.Y, 0), s.Height-1)
+			for x := x0; x < x1; x++ {
+				sx := min(max(x-r.X, 0), s.Width-1)
+				copy(pix[(y*layout.Width+x)*4:][:4], s.Pix[(sy*s.Width+sx)*4:])
+			}
+		}
+	}
+	return pix, nil
+}
+
+// Build packs sprites, composes the atlas and encodes it.
+func Build(sprites []Sprite, opts Options) (*Atlas, error) {
+	layout, err := Pack(sprites, opts)
+	if err != nil {
+		return nil, err
+	}
+	pix, err := Compose(sprites, layout, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	bx, by := opts.blockSize()
+	encOpts := append(slices.Clip(opts.EncoderOptions), astc.WithBlockSize(bx, by))
+	enc, err := astc.NewEncoder(encOpts...)
+	if err != nil {
+		return nil, err
+	}
+	if cfg := enc.Config(); int(cfg.BlockX) != bx || int(cfg.BlockY) != by || cfg.BlockZ != 1 {
+		return nil, errors.New("astc/atlas: encoder options changed the block size")
+	}
+	data, err := enc.EncodeRGBA8(pix, layout.Width, layout.Height)
+	if err != nil {
+		return nil, err
+	}
+	return &Atlas{Layout: layout, Data: data}, nil
+}
+
+// skyline is the top edge of the packed area, in blocks: segments ordered by x that cover the
+// atlas width.
+type skyline []struct{ x, y, w int }
+
+// place returns the bottom-left position for a w x h cell and raises the skyline over it. The
+// width must not exceed the atlas width.
+func (s *skyline) place(w, h int) (int, int) {
+	sky := *s
+	best, bestX, bestY := -1, 0, 0
+	for i := range sky {
+		x := sky[i].x
+		if x+w > sky[len(sky)-1].x+sky[len(sky)-1].w {
+			break
+		}
+		y := 0
+		for j := i; j < len(sky) && sky[j].x < x+w; j++ {
+			y = max(y, sky[j].y)
+		}
+		if best < 0 || y < bestY {
+			best, bestX, bestY = i, x, y
+		}
+	}
+
+	// Replace the covered part of the skyline with the new segment.
+	end := bestX + w
+	next := []struct{ x, y, w int }{}
+	next = append(next, sky[:best]...)
+	next = append(next, struct{ x, y, w int }{bestX, bestY + h, w})
+	for _, seg := range sky[best:] {
+		if segEnd := seg.x + seg.w; segEnd > end {
+			if seg.x < end {
+				seg.w = segEnd - end
+				seg.x = end
+			}
+			next = append(next, seg)
+		}
+	}
+	// Merge neighbors at the same height.
+	merged := next[:1]
+	for _, seg := range next[1:] {
+		if last := &merged[len(merged)-1]; last.y == seg.y {
+			last.w += seg.w
+		} else {
+			merged = append(merged, seg)
+		}
+	}
+	*s = merged
+	return bestX, bestY
+}
@@ -0,0 +1,135 @@
+package atlas_test
+
+import (
+	"testing"
+
+	"github.com/arm-software/astc-encoder/astc"
+	"github.com/arm-software/astc-encoder/astc/atlas"
+)
+
+// solidSprites returns sprites of awkward sizes, each a distinct opaque color.
+func solidSprites() []atlas.Sprite {
+	sizes := [][2]int{{13, 7}, {5, 5}, {32, 3}, {1, 1}, {20, 18}, {6, 11}, {9, 9}, {4, 4}, {17, 2}}
+	sprites := make([]atlas.Sprite, len(sizes))
+	for i, sz := range sizes {
+		s := atlas.Sprite{Width: sz[0], Height: sz[1], Pix: make([]byte, sz[0]*sz[1]*4)}
+		for t := 0; t < sz[0]*sz[1]; t++ {
+			copy(s.Pix[t*4:], []byte{uint8(i * 29), uint8(255 - i*23), uint8(i * 61), 255})
+		}
+		sprites[i] = s
+	}
+	return sprites
+}
+
+func TestBuild_BlockAlignedNoBleed(t *testing.T) {
+	sprites := solidSprites()
+	for _, opts := range []atlas.Options{
+		{},
+		{BlockX: 6, BlockY: 5, Gutter: 2},
+		{BlockX: 8, BlockY: 8, MaxWidth: 40},
+	} {
+		a, err := atlas.Build(sprites, opts)
+		if err != nil {
+			t.Fatalf("Build(%+v): %v", opts, err)
+		}
+		bx, by := max(opts.BlockX, 4), max(opts.BlockY, 4)
+		if a.Width%bx != 0 || a.Height%by != 0 || (opts.MaxWidth > 0 && a.Width > opts.MaxWidth) {
+			t.Fatalf("Build(%+v): atlas %dx%d", opts, a.Width, a.Height)
+		}
+
+		// Cells must be block aligned and disjoint.
+		owner := make([]int, a.Width*a.Height)
+		for i, r := range a.Rects {
+			if r.Width != sprites[i].Width || r.Height != sprites[i].Height {
+				t.Fatalf("Build(%+v): rect %d = %+v", opts, i, r)
+			}
+			x0, y0 := r.X-opts.Gutter, r.Y-opts.Gutter
+			if x0%bx != 0 || y0%by != 0 {
+				t.Fatalf("Build(%+v): rect %d not block aligned: %+v", opts, i, r)
+			}
+			if r.U0 != float32(r.X)/float32(a.Width) || r.V1 != float32(r.Y+r.Height)/float32(a.Height) {
+				t.Fatalf("Build(%+v): rect %d texture coordinates %+v", opts, i, r)
+			}
+			x1 := x0 + (r.Width+2*opts.Gutter+bx-1)/bx*bx
+			y1 := y0 + (r.Height+2*opts.Gutter+by-1)/by*by
+			for y := y0; y < y1; y++ {
+				for x := x0; x < x1; x++ {
+					if owner[y*a.Width+x] != 0 {
+						t.Fatalf("Build(%+v): sprites %d and %d overlap", opts, owner[y*a.Width+x]-1, i)
+					}
+					owner[y*a.Width+x] = i + 1
+				}
+			}
+		}
+
+		// Every block holds one solid color, so the decoded sprites and gutters are exact.
+		pix, w, _, err := astc.DecodeRGBA8(a.Data)
+		if err != nil {
+			t.Fatalf("DecodeRGBA8: %v", err)
+		}
+		for i, r := range a.Rects {
+			for y := r.Y - opts.Gutter; y < r.Y+r.Height+opts.Gutter; y++ {
+				for x := r.X - opts.Gutter; x < r.X+r.Width+opts.Gutter; x++ {
+					got := pix[(y*w+x)*4:][:4]
+					if want := sprites[i].Pix[:4]; string(got) != string(want) {
+						t.Fatalf("Build(%+v): sprite %d texel (%d,%d) = %v, want %v", opts, i, x, y, got, want)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestPack_Deterministic(t *testing.T) {
+	sprites := solidSprites()
+	a, err := atlas.Pack(sprites, atlas.Options{})
+	if err != nil {
+		t.Fatalf("Pack: %v", err)
+	}
+	b, err := atlas.Pack(sprites, atlas.Options{})
+	if err != nil {
+		t.Fatalf("Pack: %v", err)
+	}
+	if a.Width != b.Width || a.Height != b.Height {
+		t.Fatalf("Pack sizes differ: %dx%d, %dx%d", a.Width, a.Height, b.Width, b.Height)
+	}
+	for i := range a.Rects {
+		if a.Rects[i] != b.Rects[i] {
+			t.Fatalf("Pack rect %d differs: %+v, %+v", i, a.Rects[i], b.Rects[i])
+		}
+	}
+
+	// The packing should not waste more than half the atlas on these sprites.
+	used := 0
+	for _, s := range sprites {
+		used += (s.Width + 3) / 4 * 4 * ((s.Height + 3) / 4 * 4)
+	}
+	if used*2 < a.Width*a.Height {
+		t.Fatalf("atlas %dx%d holds only %d sprite texels", a.Width, a.Height, used)
+	}
+}
+
+func TestPack_Errors(t *testing.T) {
+	sprites := solidSprites()
+	for _, tc := range []struct {
+		name    string
+		sprites []atlas.Sprite
+		opts    atlas.Options
+	}{
+		{"no sprites", nil, atlas.Options{}},
+		{"sprite wider than MaxWidth", sprites, atlas.Options{MaxWidth: 16}},
+		{"invalid block size", sprites, atlas.Options{BlockX: -1, BlockY: 4}},
+		{"zero sprite", []atlas.Sprite{{Width: 0, Height: 4}}, atlas.Options{}},
+	} {
+		if _, err := atlas.Pack(tc.sprites, tc.opts); err == nil {
+			t.Fatalf("%s: Pack succeeded", tc.name)
+		}
+	}
+	if _, err := atlas.Build(sprites, atlas.Options{BlockX: 7, BlockY: 7}); err == nil {
+		t.Fatalf("Build accepted an illegal block footprint")
+	}
+	short := []atlas.Sprite{{Width: 4, Height: 4, Pix: make([]byte, 8)}}
+	if _, err := atlas.Build(short, atlas.Options{}); err == nil {
+		t.Fatalf("Build accepted a short pixel buffer")
+	}
+}
@@ -0,0 +1,12 @@
+// Package atlas packs many small RGBA8 images into one texture atlas and encodes it as a single
+// ASTC image.
+//
+// Sprites are placed on block boundaries, so no ASTC block holds texels of two sprites and the
+// encoder cannot bleed one sprite's colors into its neighbor. The texels between a sprite's edge
+// and the end of its last block (and an optional gutter around it) replicate the sprite's edge
+// texels, which keeps the edge blocks cheap to encode and bilinear filtering at the sprite edge
+// free of foreign colors.
+//
+// Packing uses a skyline (bottom-left) heuristic on the block grid, placing sprites tallest
+// first. It is deterministic: the same sprites and options always give the same layout.
+package atlas
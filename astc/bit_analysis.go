@@ -0,0 +1,109 @@
+package astc
+
+import "errors"
+
+// BlockBits is the split of one 128-bit ASTC block into its bit fields.
+type BlockBits struct {
+	// Config counts block mode, partition count and index, color endpoint mode and dual-plane
+	// selector bits. For constant-color blocks it counts the block mode and void-extent fields.
+	Config int
+	// Endpoint counts the ISE-encoded color endpoint bits (the 64-bit color for constant blocks).
+	Endpoint int
+	// Weight counts the ISE-encoded weight bits.
+	Weight int
+	// Wasted counts bits not used by any field. Error blocks report all 128 bits as wasted.
+	Wasted int
+
+	Constant bool
+	Error    bool
+}
+
+// BitAnalysis reports per-block and aggregate bit allocation for an encoded image.
+type BitAnalysis struct {
+	// Blocks holds one entry per block, in file order.
+	Blocks []BlockBits
+
+	Config   int
+	Endpoint int
+	Weight   int
+	Wasted   int
+
+	ConstantBlocks int
+	ErrorBlocks    int
+}
+
+// AnalyzeBits reports how the bits of each block in an encoded image are spent between
+// configuration fields, color endpoints and weights, plus any unused bits.
+//
+// The blocks slice is the block payload as returned by ParseFile.
+func AnalyzeBits(h Header, blocks []byte) (BitAnalysis, error) {
+	_, _, _, total, err := h.BlockCount()
+	if err != nil {
+		return BitAnalysis{}, err
+	}
+	if len(blocks) < total*BlockBytes {
+		return BitAnalysis{}, ioErrUnexpectedEOF("astc blocks", total*BlockBytes, len(blocks))
+	}
+	texelCount := int(h.BlockX) * int(h.BlockY) * int(h.BlockZ)
+	if texelCount <= 0 || texelCount > blockMaxTexels {
+		return BitAnalysis{}, errors.New("astc: invalid block dimensions")
+	}
+
+	ctx := getDecodeContext(int(h.BlockX), int(h.BlockY), int(h.BlockZ))
+
+	out := BitAnalysis{Blocks: make([]BlockBits, total)}
+	for i := 0; i < total; i++ {
+		bb := analyzeBlockBits(ctx, blocks[i*BlockBytes:(i+1)*BlockBytes])
+		out.Blocks[i] = bb
+		out.Config += bb.Config
+		out.Endpoint += bb.Endpoint
+		out.Weight += bb.Weight
+		out.Wasted += bb.Wasted
+		if bb.Constant {
+			out.ConstantBlocks++
+		}
+		if bb.Error {
+			out.ErrorBlocks++
+		}
+	}
+	return out, nil
+}
+
+func analyzeBlockBits(ctx *decodeContext, block []byte) BlockBits {
+	scb := physicalToSymbolicWithCtx(block, ctx)
+	return symbolicBlockBits(ctx, &scb)
+}
+
+func symbolicBlockBits(ctx *decodeContext, scb *symbolicBlock) BlockBits {
+	switch scb.blockType {
+	case symBlockError:
+		return BlockBits{Wasted: BlockBytes * 8, Error: true}
+	case symBlockConstU16, symBlockConstF16:
+		return BlockBits{Config: 64, Endpoint: 64, Constant: true}
+	}
+
+	bmi := ctx.blockMode(int(scb.blockMode))
+	partitionCount := int(scb.partitionCount)
+
+	bb := BlockBits{Config: 11 + 2}
+	if partitionCount == 1 {
+		bb.Config += 4
+	} else {
+		bb.Config += partitionIndexBits + 6
+		if !scb.formatsMatched {
+			bb.Config += 3*partitionCount - 4
+		}
+	}
+	if bmi.isDualPlane {
+		bb.Config += 2
+	}
+
+	colorIntCount := 0
+	for i := 0; i < partitionCount; i++ {
+		colorIntCount += 2*int(scb.colorFormats[i]>>2) + 2
+	}
+	bb.Endpoint = iseSequenceBitCount(colorIntCount, scb.quantMode)
+	bb.Weight = int(bmi.weightBits)
+	bb.Wasted = BlockBytes*8 - bb.Config - bb.Endpoint - bb.Weight
+	return bb
+}
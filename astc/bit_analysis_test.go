@@ -0,0 +1,85 @@
+package astc_test
+
+import (
+	"testing"
+
+	"github.com/arm-software/astc-encoder/astc"
+)
+
+func TestAnalyzeBits_AccountsForEveryBit(t *testing.T) {
+	const (
+		w = 24
+		h = 16
+	)
+	pix := make([]byte, w*h*4)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			off := (y*w + x) * 4
+			pix[off+0] = uint8(x * 10)
+			pix[off+1] = uint8(y * 15)
+			pix[off+2] = uint8((x ^ y) * 9)
+			pix[off+3] = 255
+		}
+	}
+	// A flat region so at least one block is constant.
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			copy(pix[(y*w+x)*4:], []byte{7, 7, 7, 255})
+		}
+	}
+
+	data, err := astc.EncodeRGBA8WithProfileAndQuality(pix, w, h, 4, 4, astc.ProfileLDR, astc.EncodeMedium)
+	if err != nil {
+		t.Fatalf("EncodeRGBA8WithProfileAndQuality: %v", err)
+	}
+	hdr, blocks, err := astc.ParseFile(data)
+	if err != nil {
+		t.Fatalf("ParseFile: %v", err)
+	}
+
+	a, err := astc.AnalyzeBits(hdr, blocks)
+	if err != nil {
+		t.Fatalf("AnalyzeBits: %v", err)
+	}
+	if len(a.Blocks) != len(blocks)/astc.BlockBytes {
+		t.Fatalf("unexpected block count: %d", len(a.Blocks))
+	}
+
+	var cfg, ep, wt, waste int
+	for i, b := range a.Blocks {
+		if sum := b.Config + b.Endpoint + b.Weight + b.Wasted; sum != 128 {
+			t.Fatalf("block %d: fields sum to %d bits", i, sum)
+		}
+		if b.Error {
+			t.Fatalf("block %d: unexpected error block", i)
+		}
+		if !b.Constant && (b.Weight == 0 || b.Endpoint == 0) {
+			t.Fatalf("block %d: expected weight and endpoint bits: %+v", i, b)
+		}
+		cfg += b.Config
+		ep += b.Endpoint
+		wt += b.Weight
+		waste += b.Wasted
+	}
+	if a.Config != cfg || a.Endpoint != ep || a.Weight != wt || a.Wasted != waste {
+		t.Fatalf("aggregate mismatch: %+v", a)
+	}
+	if a.ConstantBlocks == 0 {
+		t.Fatalf("expected at least one constant block")
+	}
+}
+
+func TestAnalyzeBits_ErrorBlock(t *testing.T) {
+	hdr := astc.Header{BlockX: 4, BlockY: 4, BlockZ: 1, SizeX: 4, SizeY: 4, SizeZ: 1}
+	a, err := astc.AnalyzeBits(hdr, make([]byte, astc.BlockBytes))
+	if err != nil {
+		t.Fatalf("AnalyzeBits: %v", err)
+	}
+	if a.ErrorBlocks != 1 || a.Wasted != 128 {
+		t.Fatalf("expected a single fully wasted error block: %+v", a)
+	}
+
+	if _, err := astc.AnalyzeBits(hdr, nil); err == nil {
+		t.Fatalf("expected error for short block payload")
+	}
+}
@@ -0,0 +1,66 @@
+package astc
+
+import (
+	"math"
+	"slices"
+)
+
+// footprints2D lists the legal 2D block footprints in the order of the ASTC specification.
+var footprints2D = [][2]int{
+	{4, 4}, {5, 4}, {5, 5}, {6, 5}, {6, 6}, {8, 5}, {8, 6}, {8, 8},
+	{10, 5}, {10, 6}, {10, 8}, {10, 10}, {12, 10}, {12, 12},
+}
+
+// Footprints2D returns the 14 legal 2D block footprints in the order of the ASTC specification,
+// which the Vulkan, OpenGL and KTX format enumerations follow, so a footprint's index there is its
+// offset from the 4x4 format.
+func Footprints2D() [][2]int {
+	return slices.Clone(footprints2D)
+}
+
+// blockFootprints2D and blockFootprints3D list the legal block footprints, from highest to lowest
+// bitrate.
+var (
+	blockFootprints2D = [][3]int{
+		{4, 4, 1}, {5, 4, 1}, {5, 5, 1}, {6, 5, 1}, {6, 6, 1}, {8, 5, 1}, {8, 6, 1},
+		{10, 5, 1}, {10, 6, 1}, {8, 8, 1}, {10, 8, 1}, {10, 10, 1}, {12, 10, 1}, {12, 12, 1},
+	}
+	blockFootprints3D = [][3]int{
+		{3, 3, 3}, {4, 3, 3}, {4, 4, 3}, {4, 4, 4}, {5, 4, 4},
+		{5, 5, 4}, {5, 5, 5}, {6, 5, 5}, {6, 6, 5}, {6, 6, 6},
+	}
+)
+
+// BitrateForBlockSize returns the bitrate, in bits per texel, of a legal block footprint. Every
+// ASTC block is 128 bits, so this is 128 / (blockX*blockY*blockZ).
+func BitrateForBlockSize(blockX, blockY, blockZ int) (float64, error) {
+	if err := validateBlockSize(blockX, blockY, blockZ); err != nil {
+		return 0, err
+	}
+	return float64(BlockBytes*8) / float64(blockX*blockY*blockZ), nil
+}
+
+// BlockSizeForBitrate returns the legal block footprint whose bitrate is closest to bitsPerTexel
+// (by ratio), e.g. 8x8 for 2.0 bpp, along with that footprint's actual bitrate. With prefer3D it
+// chooses among the 3D footprints, otherwise among the 2D ones (z is then 1). Targets outside the
+// supported range select the nearest end of it.
+func BlockSizeForBitrate(bitsPerTexel float64, prefer3D bool) (x, y, z int, actualBPP float64, err error) {
+	if !(bitsPerTexel > 0) || math.IsInf(bitsPerTexel, 1) {
+		return 0, 0, 0, 0, newError(ErrBadParam, "astc: invalid bitrate")
+	}
+	footprints := blockFootprints2D
+	if prefer3D {
+		footprints = blockFootprints3D
+	}
+
+	bestDist := math.Inf(1)
+	for _, fp := range footprints {
+		bpp := float64(BlockBytes*8) / float64(fp[0]*fp[1]*fp[2])
+		// Ties keep the earlier, higher-bitrate footprint.
+		if d := math.Abs(math.Log(bpp / bitsPerTexel)); d < bestDist {
+			bestDist = d
+			x, y, z, actualBPP = fp[0], fp[1], fp[2], bpp
+		}
+	}
+	return x, y, z, actualBPP, nil
+}
@@ -0,0 +1,73 @@
+package astc_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/arm-software/astc-encoder/astc"
+)
+
+func TestBlockSizeForBitrate(t *testing.T) {
+	cases := []struct {
+		bpp     float64
+		prefer3 bool
+		x, y, z int
+	}{
+		{8, false, 4, 4, 1},
+		{2, false, 8, 8, 1},
+		{3.56, false, 6, 6, 1},
+		{2.5, false, 10, 5, 1},
+		{0.5, false, 12, 12, 1},
+		{100, false, 4, 4, 1},
+		{2, true, 4, 4, 4},
+		{1, true, 5, 5, 5},
+		{0.1, true, 6, 6, 6},
+	}
+	for _, tc := range cases {
+		x, y, z, bpp, err := astc.BlockSizeForBitrate(tc.bpp, tc.prefer3)
+		if err != nil {
+			t.Fatalf("BlockSizeForBitrate(%v, %v): %v", tc.bpp, tc.prefer3, err)
+		}
+		if x != tc.x || y != tc.y || z != tc.z {
+			t.Fatalf("BlockSizeForBitrate(%v, %v) = %dx%dx%d, want %dx%dx%d", tc.bpp, tc.prefer3, x, y, z, tc.x, tc.y, tc.z)
+		}
+		want, err := astc.BitrateForBlockSize(x, y, z)
+		if err != nil {
+			t.Fatalf("BitrateForBlockSize(%d,%d,%d): %v", x, y, z, err)
+		}
+		if bpp != want {
+			t.Fatalf("actual bitrate %v, want %v", bpp, want)
+		}
+	}
+
+	for _, bad := range []float64{0, -1, math.NaN(), math.Inf(1)} {
+		if _, _, _, _, err := astc.BlockSizeForBitrate(bad, false); astc.ErrorCodeOf(err) != astc.ErrBadParam {
+			t.Fatalf("BlockSizeForBitrate(%v): expected ErrBadParam, got %v", bad, err)
+		}
+	}
+}
+
+func TestBitrateForBlockSize(t *testing.T) {
+	if bpp, err := astc.BitrateForBlockSize(6, 6, 1); err != nil || math.Abs(bpp-128.0/36) > 1e-12 {
+		t.Fatalf("BitrateForBlockSize(6,6,1) = %v, %v", bpp, err)
+	}
+	if _, err := astc.BitrateForBlockSize(7, 7, 1); astc.ErrorCodeOf(err) != astc.ErrBadBlockSize {
+		t.Fatalf("BitrateForBlockSize(7,7,1): expected ErrBadBlockSize, got %v", err)
+	}
+}
+
+func TestFootprints2D(t *testing.T) {
+	fps := astc.Footprints2D()
+	if len(fps) != 14 || fps[0] != [2]int{4, 4} || fps[13] != [2]int{12, 12} {
+		t.Fatalf("Footprints2D() = %v", fps)
+	}
+	for _, fp := range fps {
+		if _, err := astc.BitrateForBlockSize(fp[0], fp[1], 1); err != nil {
+			t.Fatalf("%dx%d: %v", fp[0], fp[1], err)
+		}
+	}
+	fps[0] = [2]int{}
+	if astc.Footprints2D()[0] != [2]int{4, 4} {
+		t.Fatalf("Footprints2D returned the shared table")
+	}
+}
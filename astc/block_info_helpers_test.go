@@ -0,0 +1,48 @@
+package astc_test
+
+import (
+	"testing"
+
+	"github.com/arm-software/astc-encoder/astc"
+)
+
+// compressBlockInfos compresses img with 4x4 blocks at the given profile and quality, after cfgFn
+// (if not nil) adjusts the ConfigInit defaults, reusing hint through CompressImageWithHint if it
+// is not nil. It returns the blocks and the BlockInfo of each.
+func compressBlockInfos(t *testing.T, img *astc.Image, profile astc.Profile, quality float32, cfgFn func(*astc.Config), hint []byte) ([]byte, []astc.BlockInfo) {
+	t.Helper()
+	cfg, err := astc.ConfigInit(profile, 4, 4, 1, quality, 0)
+	if err != nil {
+		t.Fatalf("ConfigInit: %v", err)
+	}
+	if cfgFn != nil {
+		cfgFn(&cfg)
+	}
+	ctx, err := astc.ContextAlloc(&cfg, 1)
+	if err != nil {
+		t.Fatalf("ContextAlloc: %v", err)
+	}
+	defer ctx.Close()
+	out := make([]byte, blocksLenBytes(img.DimX, img.DimY, img.DimZ, 4, 4, 1))
+	if hint != nil {
+		err = ctx.CompressImageWithHint(img, hint, 1e9, astc.SwizzleRGBA, out, 0)
+	} else {
+		err = ctx.CompressImage(img, astc.SwizzleRGBA, out, 0)
+	}
+	if err != nil {
+		t.Fatalf("CompressImage: %v", err)
+	}
+	infos := make([]astc.BlockInfo, len(out)/astc.BlockBytes)
+	for i := range infos {
+		if infos[i], err = ctx.GetBlockInfo([astc.BlockBytes]byte(out[i*astc.BlockBytes:])); err != nil {
+			t.Fatalf("GetBlockInfo: %v", err)
+		}
+	}
+	return out, infos
+}
+
+// blockModeOf returns the block mode field of block i of blocks.
+func blockModeOf(blocks []byte, i int) int {
+	b := blocks[i*astc.BlockBytes:]
+	return int(b[0]) | int(b[1]&7)<<8
+}
@@ -0,0 +1,18 @@
+package astc
+
+// Block validity masks (Image.BlockMask) let virtual texturing bakers encode and decode only the
+// tiles resident at bake time. Bit i%8 of byte i/8 is set when block i, in linear block order, is
+// resident.
+
+// blockResident reports whether block i is set in mask; every block is resident in a nil mask.
+func blockResident(mask []byte, i int) bool {
+	return mask == nil || mask[i>>3]&(1<<(i&7)) != 0
+}
+
+// validateBlockMask checks that mask, if set, has a bit for each of totalBlocks blocks.
+func validateBlockMask(mask []byte, totalBlocks int) error {
+	if mask != nil && len(mask) < (totalBlocks+7)/8 {
+		return newError(ErrBadParam, "astc: block mask too short")
+	}
+	return nil
+}
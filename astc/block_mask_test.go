@@ -0,0 +1,122 @@
+package astc_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/arm-software/astc-encoder/astc"
+)
+
+func TestContext_BlockMask(t *testing.T) {
+	const w, h = 20, 12 // 5x3 blocks
+	const blocksX, blocks = 5, 15
+	pix := make([]byte, w*h*4)
+	for i := range pix {
+		pix[i] = uint8(i*11 + i/97)
+	}
+	// Resident blocks form a checkerboard.
+	mask := make([]byte, 2)
+	for i := 0; i < blocks; i++ {
+		if (i%blocksX+i/blocksX)%2 == 0 {
+			mask[i/8] |= 1 << (i % 8)
+		}
+	}
+	resident := func(i int) bool { return mask[i/8]&(1<<(i%8)) != 0 }
+
+	cfg, err := astc.ConfigInit(astc.ProfileLDR, 4, 4, 1, 60, 0)
+	if err != nil {
+		t.Fatalf("ConfigInit: %v", err)
+	}
+	ctx, err := astc.ContextAlloc(&cfg, 1)
+	if err != nil {
+		t.Fatalf("ContextAlloc: %v", err)
+	}
+	compress := func(mask []byte) ([]byte, error) {
+		img := astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeU8, DataU8: pix, BlockMask: mask}
+		out := make([]byte, blocks*astc.BlockBytes)
+		return out, ctx.CompressImage(&img, astc.SwizzleRGBA, out, 0)
+	}
+	decompress := func(data, mask []byte) ([]byte, error) {
+		out := astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeU8, DataU8: bytes.Repeat([]byte{0xAB}, w*h*4), BlockMask: mask}
+		return out.DataU8, ctx.DecompressImage(data, &out, astc.SwizzleRGBA, 0)
+	}
+
+	full, err := compress(nil)
+	if err != nil {
+		t.Fatalf("CompressImage: %v", err)
+	}
+	sparse, err := compress(mask)
+	if err != nil {
+		t.Fatalf("CompressImage with mask: %v", err)
+	}
+	placeholder := astc.EncodeConstBlockRGBA8(0, 0, 0, 0)
+	for i := 0; i < blocks; i++ {
+		got := sparse[i*astc.BlockBytes : (i+1)*astc.BlockBytes]
+		want := full[i*astc.BlockBytes : (i+1)*astc.BlockBytes]
+		if !resident(i) {
+			want = placeholder[:]
+		}
+		if !bytes.Equal(got, want) {
+			t.Fatalf("block %d (resident %v) differs", i, resident(i))
+		}
+	}
+
+	fullPix, err := decompress(full, nil)
+	if err != nil {
+		t.Fatalf("DecompressImage: %v", err)
+	}
+	sparsePix, err := decompress(full, mask)
+	if err != nil {
+		t.Fatalf("DecompressImage with mask: %v", err)
+	}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			o := (y*w + x) * 4
+			want := fullPix[o : o+4]
+			if !resident(y/4*blocksX + x/4) {
+				want = []byte{0xAB, 0xAB, 0xAB, 0xAB}
+			}
+			if !bytes.Equal(sparsePix[o:o+4], want) {
+				t.Fatalf("texel (%d,%d) = %v, want %v", x, y, sparsePix[o:o+4], want)
+			}
+		}
+	}
+
+	if _, err := compress(mask[:1]); astc.ErrorCodeOf(err) != astc.ErrBadParam {
+		t.Fatalf("CompressImage with short mask: got %v, want ErrBadParam", err)
+	}
+	if _, err := decompress(full, mask[:1]); astc.ErrorCodeOf(err) != astc.ErrBadParam {
+		t.Fatalf("DecompressImage with short mask: got %v, want ErrBadParam", err)
+	}
+}
+
+func TestContext_BlockMask_Deblock(t *testing.T) {
+	// A flat image whose second block is not resident, over a background close enough in value
+	// that deblocking would blend the two across their boundary.
+	const w, h = 16, 8
+	cfg, err := astc.ConfigInit(astc.ProfileLDR, 8, 8, 1, 60, 0)
+	if err != nil {
+		t.Fatalf("ConfigInit: %v", err)
+	}
+	ctx, err := astc.ContextAlloc(&cfg, 1)
+	if err != nil {
+		t.Fatalf("ContextAlloc: %v", err)
+	}
+	block := astc.EncodeConstBlockRGBA8(100, 100, 100, 255)
+	data := append(block[:], block[:]...)
+	out := astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeU8, DataU8: bytes.Repeat([]byte{110}, w*h*4), BlockMask: []byte{1}}
+	if err := ctx.DecompressImageWithOptions(data, &out, astc.SwizzleRGBA, 0, astc.DecodeOptions{Deblock: 1}); err != nil {
+		t.Fatalf("DecompressImageWithOptions: %v", err)
+	}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			want := byte(100)
+			if x >= 8 {
+				want = 110
+			}
+			if got := out.DataU8[(y*w+x)*4]; got != want {
+				t.Fatalf("texel (%d,%d) = %d, want %d", x, y, got, want)
+			}
+		}
+	}
+}
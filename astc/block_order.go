@@ -0,0 +1,141 @@
+package astc
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// BlockOrder is the order in which the blocks of an image are stored in a block payload.
+type BlockOrder uint8
+
+const (
+	// BlockOrderLinear stores blocks in x-major, then y, then z order, as in .astc files (matches
+	// upstream).
+	BlockOrderLinear BlockOrder = iota
+	// BlockOrderMorton stores blocks in Morton (Z-order) order of their block coordinates, with the
+	// x bit lowest in each interleaved group (x, y for 2D images; x, y, z for volumes), as used by
+	// some console texture layouts. When the image is not a power-of-two number of blocks on each
+	// axis, the blocks keep their Morton order with the codes outside the image skipped, so the
+	// payload stays dense.
+	BlockOrderMorton
+)
+
+// BlockOrderFunc returns the index in a block payload of the block at block coordinates
+// (bx, by, bz), for layouts other than the BlockOrder constants (see Config.BlockOrderFunc). It
+// must map the blocks of an image one-to-one onto the indices 0 to blocks-1.
+type BlockOrderFunc func(bx, by, bz int) (outIndex int)
+
+// storedBlockIndices is blockOrderIndices with fn, if non-nil, replacing order. It returns an
+// ErrBadParam error if fn maps a block outside the payload or onto the index of another block.
+func storedBlockIndices(order BlockOrder, fn BlockOrderFunc, blocksX, blocksY, blocksZ int) ([]int, error) {
+	if fn == nil {
+		return blockOrderIndices(order, blocksX, blocksY, blocksZ), nil
+	}
+
+	total := blocksX * blocksY * blocksZ
+	stored := make([]int, total)
+	used := make([]bool, total)
+	i := 0
+	for bz := 0; bz < blocksZ; bz++ {
+		for by := 0; by < blocksY; by++ {
+			for bx := 0; bx < blocksX; bx++ {
+				k := fn(bx, by, bz)
+				if k < 0 || k >= total {
+					return nil, newError(ErrBadParam, fmt.Sprintf("astc: block order function maps block (%d,%d,%d) to index %d of %d", bx, by, bz, k, total))
+				}
+				if used[k] {
+					return nil, newError(ErrBadParam, fmt.Sprintf("astc: block order function maps block (%d,%d,%d) to index %d, which another block uses", bx, by, bz, k))
+				}
+				used[k] = true
+				stored[i] = k
+				i++
+			}
+		}
+	}
+	return stored, nil
+}
+
+// mortonCode interleaves the bits of the block coordinates, x lowest.
+func mortonCode(bx, by, bz int, volume bool) uint64 {
+	var code uint64
+	for bit := 0; bit < 21; bit++ {
+		if volume {
+			code |= uint64(bx>>bit&1) << (3 * bit)
+			code |= uint64(by>>bit&1) << (3*bit + 1)
+			code |= uint64(bz>>bit&1) << (3*bit + 2)
+		} else {
+			code |= uint64(bx>>bit&1) << (2 * bit)
+			code |= uint64(by>>bit&1) << (2*bit + 1)
+		}
+	}
+	return code
+}
+
+// blockOrderIndices returns, for each block in linear order, its index in a payload stored in
+// order. It returns nil for BlockOrderLinear.
+func blockOrderIndices(order BlockOrder, blocksX, blocksY, blocksZ int) []int {
+	if order == BlockOrderLinear {
+		return nil
+	}
+
+	total := blocksX * blocksY * blocksZ
+	volume := blocksZ > 1
+	codes := make([]uint64, total)
+	linear := make([]int, total)
+	for i := range linear {
+		bz := i / (blocksX * blocksY)
+		rem := i - bz*blocksX*blocksY
+		by := rem / blocksX
+		bx := rem - by*blocksX
+		codes[i] = mortonCode(bx, by, bz, volume)
+		linear[i] = i
+	}
+	slices.SortFunc(linear, func(a, b int) int {
+		if codes[a] < codes[b] {
+			return -1
+		}
+		if codes[a] > codes[b] {
+			return 1
+		}
+		return 0
+	})
+
+	stored := make([]int, total)
+	for k, i := range linear {
+		stored[i] = k
+	}
+	return stored
+}
+
+// ReorderBlocks copies the block payload of a blocksX x blocksY x blocksZ image from src, stored in
+// order from, to dst in order to. dst and src must not overlap.
+func ReorderBlocks(dst, src []byte, blocksX, blocksY, blocksZ int, from, to BlockOrder) error {
+	if from > BlockOrderMorton || to > BlockOrderMorton {
+		return errors.New("astc: invalid block order")
+	}
+	if blocksX <= 0 || blocksY <= 0 || blocksZ <= 0 {
+		return errors.New("astc: invalid block counts")
+	}
+	n := blocksX * blocksY * blocksZ * BlockBytes
+	if len(src) < n {
+		return errors.New("astc: block buffer too small")
+	}
+	if len(dst) < n {
+		return errors.New("astc: output buffer too small")
+	}
+
+	fromIdx := blockOrderIndices(from, blocksX, blocksY, blocksZ)
+	toIdx := blockOrderIndices(to, blocksX, blocksY, blocksZ)
+	for i := 0; i < blocksX*blocksY*blocksZ; i++ {
+		s, d := i, i
+		if fromIdx != nil {
+			s = fromIdx[i]
+		}
+		if toIdx != nil {
+			d = toIdx[i]
+		}
+		copy(dst[d*BlockBytes:(d+1)*BlockBytes], src[s*BlockBytes:(s+1)*BlockBytes])
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package astc_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/arm-software/astc-encoder/astc"
+)
+
+func TestReorderBlocks(t *testing.T) {
+	for _, tc := range []struct {
+		name                      string
+		blocksX, blocksY, blocksZ int
+		// want lists the linear index of each block in Morton order.
+		want []byte
+	}{
+		{"4x2", 4, 2, 1, []byte{0, 1, 4, 5, 2, 3, 6, 7}},
+		{"3x3", 3, 3, 1, []byte{0, 1, 3, 4, 2, 5, 6, 7, 8}},
+		{"2x2x2", 2, 2, 2, []byte{0, 1, 2, 3, 4, 5, 6, 7}},
+		{"3x1x2", 3, 1, 2, []byte{0, 1, 3, 4, 2, 5}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			n := tc.blocksX * tc.blocksY * tc.blocksZ
+			linear := make([]byte, n*astc.BlockBytes)
+			for i := 0; i < n; i++ {
+				linear[i*astc.BlockBytes] = byte(i)
+				linear[i*astc.BlockBytes+1] = 0xA5
+			}
+
+			morton := make([]byte, len(linear))
+			if err := astc.ReorderBlocks(morton, linear, tc.blocksX, tc.blocksY, tc.blocksZ, astc.BlockOrderLinear, astc.BlockOrderMorton); err != nil {
+				t.Fatalf("ReorderBlocks: %v", err)
+			}
+			for k, want := range tc.want {
+				if got := morton[k*astc.BlockBytes]; got != want {
+					t.Fatalf("Morton block %d = linear block %d, want %d", k, got, want)
+				}
+			}
+
+			back := make([]byte, len(linear))
+			if err := astc.ReorderBlocks(back, morton, tc.blocksX, tc.blocksY, tc.blocksZ, astc.BlockOrderMorton, astc.BlockOrderLinear); err != nil {
+				t.Fatalf("ReorderBlocks: %v", err)
+			}
+			if !bytes.Equal(back, linear) {
+				t.Fatalf("Morton -> linear did not restore the payload")
+			}
+		})
+	}
+
+	if err := astc.ReorderBlocks(make([]byte, 64), make([]byte, 48), 2, 2, 1, astc.BlockOrderLinear, astc.BlockOrderMorton); err == nil {
+		t.Fatalf("expected error for short source")
+	}
+	if err := astc.ReorderBlocks(make([]byte, 64), make([]byte, 64), 2, 2, 1, astc.BlockOrderLinear, astc.BlockOrder(9)); err == nil {
+		t.Fatalf("expected error for invalid order")
+	}
+}
@@ -0,0 +1,135 @@
+package astc
+
+import (
+	"encoding/json"
+	"errors"
+	"math/bits"
+)
+
+// blockModeCount is the number of 11-bit block mode values.
+const blockModeCount = 1 << 11
+
+// BlockModeMask is a set of ASTC block mode values (the 11-bit mode field of a block, 0..2047).
+// The same value means different weight grids for 2D and 3D blocks, so masks are built for one
+// block dimensionality.
+//
+// The zero value is the empty set. It is marshaled to JSON as a list of block mode values.
+type BlockModeMask [blockModeCount / 64]uint64
+
+// Set adds mode to the set. Values outside 0..2047 are ignored.
+func (m *BlockModeMask) Set(mode int) {
+	if mode >= 0 && mode < blockModeCount {
+		m[mode>>6] |= 1 << (mode & 63)
+	}
+}
+
+// Clear removes mode from the set.
+func (m *BlockModeMask) Clear(mode int) {
+	if mode >= 0 && mode < blockModeCount {
+		m[mode>>6] &^= 1 << (mode & 63)
+	}
+}
+
+// Has reports whether mode is in the set. Values outside 0..2047 are never in the set.
+func (m BlockModeMask) Has(mode int) bool {
+	if mode < 0 || mode >= blockModeCount {
+		return false
+	}
+	return m[mode>>6]&(1<<(mode&63)) != 0
+}
+
+// Union returns the modes in m or o.
+func (m BlockModeMask) Union(o BlockModeMask) BlockModeMask {
+	for i := range m {
+		m[i] |= o[i]
+	}
+	return m
+}
+
+// complement returns the modes not in m.
+func (m BlockModeMask) complement() BlockModeMask {
+	for i := range m {
+		m[i] = ^m[i]
+	}
+	return m
+}
+
+// IsZero reports whether the set is empty.
+func (m BlockModeMask) IsZero() bool {
+	return m == BlockModeMask{}
+}
+
+// Modes returns the modes in the set, in increasing order.
+func (m BlockModeMask) Modes() []int {
+	out := []int{}
+	for i, w := range m {
+		for w != 0 {
+			out = append(out, i*64+bits.TrailingZeros64(w))
+			w &= w - 1
+		}
+	}
+	return out
+}
+
+// MarshalJSON encodes the set as a list of block mode values.
+func (m BlockModeMask) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.Modes())
+}
+
+// UnmarshalJSON decodes a list of block mode values.
+func (m *BlockModeMask) UnmarshalJSON(data []byte) error {
+	var modes []int
+	if err := json.Unmarshal(data, &modes); err != nil {
+		return err
+	}
+	var tmp BlockModeMask
+	for _, mode := range modes {
+		if mode < 0 || mode >= blockModeCount {
+			return errors.New("astc: invalid block mode in mask")
+		}
+		tmp.Set(mode)
+	}
+	*m = tmp
+	return nil
+}
+
+// BlockModeErrata is a bitset of block mode classes that some hardware decoders mishandle. It
+// selects predefined Config.DisallowedBlockModes sets through BlockModesForErrata; combine flags to
+// describe a platform.
+type BlockModeErrata uint32
+
+const (
+	// ErrataDualPlane covers dual-plane block modes.
+	ErrataDualPlane BlockModeErrata = 1 << iota
+	// ErrataHighPrecisionWeights covers the high-precision weight ranges (10 or more weight
+	// levels, selected by the H bit of the block mode).
+	ErrataHighPrecisionWeights
+	// ErrataTritQuintWeights covers weight ranges stored with trit or quint integer sequence
+	// encoding (3, 5, 6, 10, 12, 20 or 24 levels), leaving only power-of-two ranges.
+	ErrataTritQuintWeights
+)
+
+// BlockModesForErrata returns the valid block modes affected by errata, for 2D blocks when blockZ
+// is 1 and 3D blocks otherwise.
+func BlockModesForErrata(errata BlockModeErrata, blockZ int) BlockModeMask {
+	var m BlockModeMask
+	for mode := 0; mode < blockModeCount; mode++ {
+		var dualPlane, ok bool
+		var q quantMethod
+		if blockZ <= 1 {
+			_, _, dualPlane, q, _, ok = decodeBlockMode2D(mode)
+		} else {
+			_, _, _, dualPlane, q, _, ok = decodeBlockMode3D(mode)
+		}
+		if !ok {
+			continue
+		}
+		levels := quantLevel(q)
+		if (errata&ErrataDualPlane != 0 && dualPlane) ||
+			(errata&ErrataHighPrecisionWeights != 0 && levels >= 10) ||
+			(errata&ErrataTritQuintWeights != 0 && levels&(levels-1) != 0) {
+			m.Set(mode)
+		}
+	}
+	return m
+}
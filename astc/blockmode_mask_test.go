@@ -0,0 +1,120 @@
+package astc_test
+
+import (
+	"testing"
+
+	"github.com/arm-software/astc-encoder/astc"
+)
+
+func TestContext_CompressImage_DisallowedBlockModes(t *testing.T) {
+	const w, h = 16, 16
+	pix := make([]byte, w*h*4)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			// Alpha varies independently of color, which favors dual-plane modes.
+			copy(pix[(y*w+x)*4:], []byte{uint8(x * 16), uint8(x * 8), uint8(255 - x*16), uint8(y*16 + x%3)})
+		}
+	}
+	img := astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeU8, DataU8: pix}
+
+	// compress returns the infos of the non-constant blocks.
+	compress := func(disallowed astc.BlockModeMask) []astc.BlockInfo {
+		t.Helper()
+		blocks, all := compressBlockInfos(t, &img, astc.ProfileLDR, 98, func(c *astc.Config) { c.DisallowedBlockModes = disallowed }, nil)
+		var infos []astc.BlockInfo
+		for i, info := range all {
+			if info.IsConstantBlock {
+				continue
+			}
+			if mode := blockModeOf(blocks, i); disallowed.Has(mode) {
+				t.Fatalf("block %d uses disallowed block mode %d", i, mode)
+			}
+			infos = append(infos, info)
+		}
+		return infos
+	}
+
+	// The unrestricted encode uses the modes the errata profile removes.
+	var dualPlane, tritQuint bool
+	for _, info := range compress(astc.BlockModeMask{}) {
+		dualPlane = dualPlane || info.IsDualPlaneBlock
+		tritQuint = tritQuint || info.WeightLevelCount&(info.WeightLevelCount-1) != 0
+	}
+	if !dualPlane || !tritQuint {
+		t.Fatalf("test image does not exercise the errata modes (dual plane %v, trit/quint %v)", dualPlane, tritQuint)
+	}
+
+	infos := compress(astc.BlockModesForErrata(astc.ErrataDualPlane|astc.ErrataTritQuintWeights, 1))
+	if len(infos) == 0 {
+		t.Fatalf("expected non-constant blocks")
+	}
+	for _, info := range infos {
+		if info.IsDualPlaneBlock || info.WeightLevelCount&(info.WeightLevelCount-1) != 0 {
+			t.Fatalf("errata profile: got dual plane %v with %d weight levels", info.IsDualPlaneBlock, info.WeightLevelCount)
+		}
+	}
+
+	// With every mode disallowed, only constant-color blocks remain.
+	var all astc.BlockModeMask
+	for mode := 0; mode < 2048; mode++ {
+		all.Set(mode)
+	}
+	if infos := compress(all); len(infos) != 0 {
+		t.Fatalf("all modes disallowed: got %d non-constant blocks", len(infos))
+	}
+}
+
+func TestContext_CompressImage_ForcedBlockModes(t *testing.T) {
+	const w, h = 16, 16
+	pix := make([]byte, w*h*4)
+	for i := range pix {
+		pix[i] = uint8(i*29 + i/64*7)
+	}
+	img := astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeU8, DataU8: pix}
+
+	// compress returns the block modes of the non-constant blocks, keyed by block index.
+	compress := func(forced, disallowed astc.BlockModeMask) map[int]int {
+		t.Helper()
+		blocks, infos := compressBlockInfos(t, &img, astc.ProfileLDR, 60, func(c *astc.Config) {
+			c.ForcedBlockModes, c.DisallowedBlockModes = forced, disallowed
+		}, nil)
+		modes := map[int]int{}
+		for i, info := range infos {
+			if !info.IsConstantBlock {
+				modes[i] = blockModeOf(blocks, i)
+			}
+		}
+		return modes
+	}
+
+	free := compress(astc.BlockModeMask{}, astc.BlockModeMask{})
+	used := map[int]bool{}
+	for _, mode := range free {
+		used[mode] = true
+	}
+	if len(used) < 3 {
+		t.Fatalf("test image uses only %d block modes", len(used))
+	}
+
+	// Forcing two of the modes the free encode picked keeps every block within them.
+	var forced astc.BlockModeMask
+	for i := 0; len(forced.Modes()) < 2; i++ {
+		if mode, ok := free[i]; ok {
+			forced.Set(mode)
+		}
+	}
+	got := compress(forced, astc.BlockModeMask{})
+	if len(got) == 0 {
+		t.Fatalf("forced modes %v: only constant blocks", forced.Modes())
+	}
+	for i, mode := range got {
+		if !forced.Has(mode) {
+			t.Fatalf("block %d uses mode %d outside the forced modes %v", i, mode, forced.Modes())
+		}
+	}
+
+	// Disallowed modes still win over forced ones.
+	if got := compress(forced, forced); len(got) != 0 {
+		t.Fatalf("forced and disallowed: got %d non-constant blocks", len(got))
+	}
+}
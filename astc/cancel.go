@@ -0,0 +1,142 @@
+package astc
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// This file holds the context.Context variants of the whole-image APIs, for request-scoped
+// services. Each of them runs its own worker goroutines, stops them between blocks (or items) once
+// ctx is cancelled or a worker fails, waits for all of them, and returns ctx.Err() if the work was
+// cut short by ctx. Work that completes before the cancellation is observed is returned normally.
+
+// isDone reports whether done is closed; a nil done is never closed.
+func isDone(done <-chan struct{}) bool {
+	select {
+	case <-done:
+		return true
+	default:
+		return false
+	}
+}
+
+// runWorkersCtx is runWorkers for workers that stop when done is closed. done is closed when ctx
+// is cancelled or a worker returns an error; every worker has returned when runWorkersCtx does.
+func runWorkersCtx(ctx context.Context, n int, fn func(done <-chan struct{}, i int) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	return runWorkers(n, func(i int) error {
+		err := fn(ctx.Done(), i)
+		if err != nil {
+			cancel()
+		}
+		return err
+	})
+}
+
+// CompressImageCtx compresses img into out like CompressImage, running all threads of the context
+// itself. The context is reset before and after the call, so it must not be used by other
+// goroutines meanwhile.
+//
+// If ctx is cancelled first, the threads stop between blocks, out is left partially written and
+// ctx.Err() is returned.
+func (c *Context) CompressImageCtx(ctx context.Context, img *Image, swizzle Swizzle, out []byte) error {
+	if c == nil {
+		return newError(ErrBadContext, "astc: nil context")
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := c.CompressReset(); err != nil {
+		return err
+	}
+	defer c.CompressReset()
+
+	var ok atomic.Bool
+	err := runWorkersCtx(ctx, c.threadCount, func(done <-chan struct{}, i int) error {
+		err := c.compressImage(done, img, nil, 0, swizzle, out, i)
+		if err == nil {
+			ok.Store(true)
+		}
+		return err
+	})
+	// A thread which joins after the others finished the image fails to start; the image is still
+	// complete.
+	if ok.Load() && c.compress.doneBlocks.Load() == c.compress.totalBlocks.Load() {
+		return nil
+	}
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	if err == nil {
+		err = newError(ErrBadContext, "astc: compression cancelled")
+	}
+	return err
+}
+
+// DecompressImageCtx decodes data into imgOut like DecompressImage, running all threads of the
+// context itself. The context is reset before and after the call, so it must not be used by other
+// goroutines meanwhile.
+//
+// If ctx is cancelled first, the threads stop between blocks, imgOut is left partially written and
+// ctx.Err() is returned.
+func (c *Context) DecompressImageCtx(ctx context.Context, data []byte, imgOut *Image, swizzle Swizzle) error {
+	if c == nil {
+		return newError(ErrBadContext, "astc: nil context")
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := c.DecompressReset(); err != nil {
+		return err
+	}
+	defer c.DecompressReset()
+
+	var ok atomic.Bool
+	err := runWorkersCtx(ctx, c.threadCount, func(done <-chan struct{}, i int) error {
+		err := c.decompressImage(done, data, imgOut, swizzle, i, DecodeOptions{})
+		if err == nil {
+			ok.Store(true)
+		}
+		return err
+	})
+	if ok.Load() && c.decompress.doneBlocks.Load() == c.decompress.totalBlocks.Load() {
+		return nil
+	}
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	return err
+}
+
+// DecodeBatchCtx is DecodeBatch that stops starting new items once ctx is cancelled, returning
+// ctx.Err() if any item was skipped. Items already being decoded are finished.
+func DecodeBatchCtx(ctx context.Context, items []DecodeItem, workers int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return decodeBatch(ctx, items, workers)
+}
+
+// EncodeCtx is Encode that stops between blocks once ctx is cancelled and returns ctx.Err().
+func (e *Encoder) EncodeCtx(ctx context.Context, img *Image) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return e.encode(ctx, img, &e.cfg)
+}
+
+// EncodeMipChainCtx is EncodeMipChain that stops between blocks once ctx is cancelled and returns
+// ctx.Err().
+func (e *Encoder) EncodeMipChainCtx(ctx context.Context, levels []*Image) ([][]byte, error) {
+	return e.encodeMipChain(ctx, levels)
+}
+
+// DecodeIntoCtx is DecodeInto that stops between blocks once ctx is cancelled and returns
+// ctx.Err(), leaving img partially written.
+func (d *Decoder) DecodeIntoCtx(ctx context.Context, astcData []byte, img *Image) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return d.decodeInto(ctx, astcData, img)
+}
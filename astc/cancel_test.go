@@ -0,0 +1,215 @@
+package astc_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/arm-software/astc-encoder/astc"
+)
+
+// checkNoGoroutineLeak fails if the goroutine count does not return to before within a second.
+func checkNoGoroutineLeak(t *testing.T, before int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d goroutines still running, want %d", runtime.NumGoroutine(), before)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func ctxTestImage(w, h int) *astc.Image {
+	src := make([]byte, w*h*4)
+	for i := range src {
+		src[i] = byte(i*17 + i>>9)
+	}
+	return &astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeU8, DataU8: src}
+}
+
+func TestContext_CompressImageCtx(t *testing.T) {
+	cfg, err := astc.ConfigInit(astc.ProfileLDR, 6, 6, 1, 10, 0)
+	if err != nil {
+		t.Fatalf("ConfigInit: %v", err)
+	}
+	img := ctxTestImage(96, 64)
+	n := blocksLenBytes(96, 64, 1, 6, 6, 1)
+
+	single, err := astc.ContextAlloc(&cfg, 1)
+	if err != nil {
+		t.Fatalf("ContextAlloc: %v", err)
+	}
+	want := make([]byte, n)
+	if err := single.CompressImage(img, astc.SwizzleRGBA, want, 0); err != nil {
+		t.Fatalf("CompressImage: %v", err)
+	}
+
+	before := runtime.NumGoroutine()
+	c, err := astc.ContextAlloc(&cfg, 4)
+	if err != nil {
+		t.Fatalf("ContextAlloc: %v", err)
+	}
+	// The context is reset by each call, so it can be reused without CompressReset.
+	for run := 0; run < 3; run++ {
+		got := make([]byte, n)
+		if err := c.CompressImageCtx(context.Background(), img, astc.SwizzleRGBA, got); err != nil {
+			t.Fatalf("run %d: CompressImageCtx: %v", run, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Fatalf("run %d: output differs from single-threaded CompressImage", run)
+		}
+	}
+	checkNoGoroutineLeak(t, before)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := c.CompressImageCtx(ctx, img, astc.SwizzleRGBA, make([]byte, n)); !errors.Is(err, context.Canceled) {
+		t.Fatalf("pre-cancelled: got %v, want context.Canceled", err)
+	}
+	if err := c.CompressImageCtx(context.Background(), img, astc.SwizzleRGBA, make([]byte, 1)); astc.ErrorCodeOf(err) != astc.ErrOutOfMem {
+		t.Fatalf("short output: got %v, want ErrOutOfMem", err)
+	}
+}
+
+func TestContext_CompressImageCtx_CancelStopsEarly(t *testing.T) {
+	cfg, err := astc.ConfigInit(astc.ProfileLDR, 4, 4, 1, 10, 0)
+	if err != nil {
+		t.Fatalf("ConfigInit: %v", err)
+	}
+	// Progress is reported every 4096 blocks, a quarter of the image.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cfg.ProgressCallback = func(p float32) {
+		if p >= 1 {
+			cancel()
+		}
+	}
+	c, err := astc.ContextAlloc(&cfg, 3)
+	if err != nil {
+		t.Fatalf("ContextAlloc: %v", err)
+	}
+
+	before := runtime.NumGoroutine()
+	const w, h = 512, 512
+	out := bytes.Repeat([]byte{0xCD}, blocksLenBytes(w, h, 1, 4, 4, 1))
+	if err := c.CompressImageCtx(ctx, ctxTestImage(w, h), astc.SwizzleRGBA, out); !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, want context.Canceled", err)
+	}
+	untouched := 0
+	for i := 0; i < len(out); i += astc.BlockBytes {
+		if bytes.Equal(out[i:i+astc.BlockBytes], bytes.Repeat([]byte{0xCD}, astc.BlockBytes)) {
+			untouched++
+		}
+	}
+	if untouched == 0 {
+		t.Fatalf("cancellation did not stop the compression")
+	}
+	checkNoGoroutineLeak(t, before)
+}
+
+func TestContext_DecompressImageCtx(t *testing.T) {
+	cfg, err := astc.ConfigInit(astc.ProfileLDR, 4, 4, 1, 0, astc.FlagDecompressOnly)
+	if err != nil {
+		t.Fatalf("ConfigInit: %v", err)
+	}
+	c, err := astc.ContextAlloc(&cfg, 4)
+	if err != nil {
+		t.Fatalf("ContextAlloc: %v", err)
+	}
+	const w, h = 64, 48
+	data, err := astc.EncodeRGBA8WithProfileAndQuality(ctxTestImage(w, h).DataU8, w, h, 4, 4, astc.ProfileLDR, astc.EncodeFastest)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	want, _, _, err := astc.DecodeRGBA8WithProfile(data, astc.ProfileLDR)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	for run := 0; run < 2; run++ {
+		img := &astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeU8, DataU8: make([]byte, w*h*4)}
+		if err := c.DecompressImageCtx(context.Background(), data[astc.HeaderSize:], img, astc.SwizzleRGBA); err != nil {
+			t.Fatalf("run %d: DecompressImageCtx: %v", run, err)
+		}
+		if !bytes.Equal(img.DataU8, want) {
+			t.Fatalf("run %d: output differs from DecodeRGBA8WithProfile", run)
+		}
+	}
+
+	// A large image takes far longer than the deadline to decode.
+	const bw, bh = 4096, 4096
+	big := make([]byte, blocksLenBytes(bw, bh, 1, 4, 4, 1))
+	img := &astc.Image{DimX: bw, DimY: bh, DimZ: 1, DataType: astc.TypeU8, DataU8: make([]byte, bw*bh*4)}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	before := runtime.NumGoroutine()
+	if err := c.DecompressImageCtx(ctx, big, img, astc.SwizzleRGBA); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want context.DeadlineExceeded", err)
+	}
+	checkNoGoroutineLeak(t, before)
+}
+
+func TestEncoder_EncodeCtx(t *testing.T) {
+	enc, err := astc.NewEncoder(astc.WithBlockSize(4, 4), astc.WithQuality(astc.EncodeFast), astc.WithWorkers(2))
+	if err != nil {
+		t.Fatalf("NewEncoder: %v", err)
+	}
+	small := ctxTestImage(40, 24)
+	want, err := enc.Encode(small)
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	got, err := enc.EncodeCtx(context.Background(), small)
+	if err != nil || !bytes.Equal(got, want) {
+		t.Fatalf("EncodeCtx differs from Encode (err %v)", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	before := runtime.NumGoroutine()
+	if _, err := enc.EncodeCtx(ctx, ctxTestImage(2048, 2048)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("EncodeCtx: got %v, want context.DeadlineExceeded", err)
+	}
+	if _, err := enc.EncodeMipChainCtx(ctx, []*astc.Image{small, small}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("EncodeMipChainCtx: got %v, want context.DeadlineExceeded", err)
+	}
+	checkNoGoroutineLeak(t, before)
+
+	dec, err := astc.NewDecoder(astc.WithWorkers(2))
+	if err != nil {
+		t.Fatalf("NewDecoder: %v", err)
+	}
+	img := &astc.Image{DimX: 40, DimY: 24, DimZ: 1, DataType: astc.TypeU8, DataU8: make([]byte, 40*24*4)}
+	if err := dec.DecodeIntoCtx(context.Background(), want, img); err != nil {
+		t.Fatalf("DecodeIntoCtx: %v", err)
+	}
+	if err := dec.DecodeIntoCtx(ctx, want, img); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("DecodeIntoCtx: got %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestDecodeBatchCtx(t *testing.T) {
+	data, err := astc.EncodeRGBA8WithProfileAndQuality(ctxTestImage(16, 16).DataU8, 16, 16, 4, 4, astc.ProfileLDR, astc.EncodeFastest)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	h, blocks, err := astc.ParseFile(data)
+	if err != nil {
+		t.Fatalf("ParseFile: %v", err)
+	}
+	items := make([]astc.DecodeItem, 8)
+	for i := range items {
+		items[i] = astc.DecodeItem{Profile: astc.ProfileLDR, Header: h, Blocks: blocks, Dst: make([]byte, 16*16*4)}
+	}
+	if err := astc.DecodeBatchCtx(context.Background(), items, 3); err != nil {
+		t.Fatalf("DecodeBatchCtx: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := astc.DecodeBatchCtx(ctx, items, 3); !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, want context.Canceled", err)
+	}
+}
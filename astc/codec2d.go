@@ -55,7 +55,15 @@ func EncodeRGBA8(pix []byte, width, height int, blockX, blockY int) ([]byte, err
 //
 // Note: ASTC files do not store a profile. The profile controls encoder optimization behavior
 // (it matches the profile the caller intends to use when decoding).
+//
+// Deprecated: Use NewEncoder with WithBlockSize, WithProfile and WithQuality, and
+// Encoder.EncodeRGBA8.
 func EncodeRGBA8WithProfileAndQuality(pix []byte, width, height int, blockX, blockY int, profile Profile, quality EncodeQuality) ([]byte, error) {
+	return encode2DLayout(pix, LayoutRGBA, width, height, blockX, blockY, profile, quality)
+}
+
+// encode2DLayout is EncodeRGBA8WithProfileAndQuality for pixel data in any PixelLayout.
+func encode2DLayout(pix []byte, layout PixelLayout, width, height int, blockX, blockY int, profile Profile, quality EncodeQuality) ([]byte, error) {
 	if width <= 0 || height <= 0 {
 		return nil, errors.New("astc: invalid image dimensions")
 	}
@@ -65,7 +73,10 @@ func EncodeRGBA8WithProfileAndQuality(pix []byte, width, height int, blockX, blo
 	if blockX*blockY > blockMaxTexels {
 		return nil, errors.New("astc: invalid block dimensions")
 	}
-	if len(pix) != width*height*4 {
+	if len(pix) != width*height*layout.bytesPerTexel() {
+		if layout == LayoutLA {
+			return nil, errors.New("astc: invalid LA8 buffer length")
+		}
 		return nil, errors.New("astc: invalid RGBA8 buffer length")
 	}
 	if profile != ProfileLDR && profile != ProfileLDRSRGB && profile != ProfileHDRRGBLDRAlpha && profile != ProfileHDR {
@@ -108,7 +119,7 @@ func EncodeRGBA8WithProfileAndQuality(pix []byte, width, height int, blockX, blo
 		blockTexels := make([]byte, blockX*blockY*4)
 		for by := 0; by < blocksY; by++ {
 			for bx := 0; bx < blocksX; bx++ {
-				extractBlockRGBA8(pix, width, height, bx*blockX, by*blockY, blockX, blockY, blockTexels)
+				extractBlockRGBA8Layout(pix, layout, width, height, bx*blockX, by*blockY, blockX, blockY, blockTexels)
 				block, err := encodeBlockRGBA8LDR(profile, blockX, blockY, 1, blockTexels, quality, [4]float32{1, 1, 1, 1}, 0, 1, nil)
 				if err != nil {
 					return nil, err
@@ -142,7 +153,7 @@ func EncodeRGBA8WithProfileAndQuality(pix []byte, width, height int, blockX, blo
 
 				bx := idx % blocksX
 				by := idx / blocksX
-				extractBlockRGBA8(pix, width, height, bx*blockX, by*blockY, blockX, blockY, blockTexels)
+				extractBlockRGBA8Layout(pix, layout, width, height, bx*blockX, by*blockY, blockX, blockY, blockTexels)
 				block, err := encodeBlockRGBA8LDR(profile, blockX, blockY, 1, blockTexels, quality, [4]float32{1, 1, 1, 1}, 0, 1, nil)
 				if err != nil {
 					errOnce.Do(func() {
@@ -0,0 +1,467 @@
+package astc
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// Option configures an Encoder or a Decoder. Options which do not apply to a decoder (block size
+// and quality) are ignored by NewDecoder.
+type Option func(*codecOptions)
+
+type codecOptions struct {
+	blockX, blockY, blockZ int
+	profile                Profile
+	quality                EncodeQuality
+	qualityLevel           float32
+	useQualityLevel        bool
+	qualityPresetName      string
+	useQualityPreset       bool
+	flags                  Flags
+	swizzle                Swizzle
+	workers                int
+	mipCurve               MipQualityCurve
+	alignment              int
+	flipY                  bool
+	maxPixels, maxBlocks   int64
+	configFns              []func(*Config)
+}
+
+func defaultCodecOptions() codecOptions {
+	return codecOptions{
+		blockX:  4,
+		blockY:  4,
+		blockZ:  1,
+		profile: ProfileLDR,
+		quality: EncodeMedium,
+		swizzle: SwizzleRGBA,
+	}
+}
+
+// WithBlockSize selects a 2D block footprint (default 4x4).
+func WithBlockSize(blockX, blockY int) Option {
+	return WithBlockSize3D(blockX, blockY, 1)
+}
+
+// WithBlockSize3D selects a block footprint, 3D when blockZ > 1.
+func WithBlockSize3D(blockX, blockY, blockZ int) Option {
+	return func(o *codecOptions) { o.blockX, o.blockY, o.blockZ = blockX, blockY, blockZ }
+}
+
+// WithProfile selects the color profile (default ProfileLDR).
+func WithProfile(profile Profile) Option {
+	return func(o *codecOptions) { o.profile = profile }
+}
+
+// WithQuality selects the encoder search effort (default EncodeMedium), as the upstream preset of
+// the same name.
+func WithQuality(quality EncodeQuality) Option {
+	return func(o *codecOptions) { o.quality, o.useQualityLevel, o.useQualityPreset = quality, false, false }
+}
+
+// WithQualityLevel selects the encoder search effort as an upstream quality level from 0 (fastest)
+// to 100 (exhaustive), e.g. 75; the tuning is interpolated between the neighboring presets (see
+// ConfigInit). It replaces WithQuality, and the last of the two wins.
+func WithQualityLevel(quality float32) Option {
+	return func(o *codecOptions) { o.qualityLevel, o.useQualityLevel, o.useQualityPreset = quality, true, false }
+}
+
+// WithQualityPreset selects the encoder search effort by preset name: a built-in one such as
+// "thorough" or one defined with RegisterQualityPreset, whose Config adjustments apply before
+// those of WithConfig. It replaces WithQuality and WithQualityLevel, and the last one wins; an
+// unknown name is reported by NewEncoder.
+func WithQualityPreset(name string) Option {
+	return func(o *codecOptions) { o.qualityPresetName, o.useQualityPreset = name, true }
+}
+
+// WithFlags sets the codec flags (default 0).
+func WithFlags(flags Flags) Option {
+	return func(o *codecOptions) { o.flags = flags }
+}
+
+// WithSwizzle sets the swizzle applied to texels before encoding or after decoding (default
+// SwizzleRGBA).
+func WithSwizzle(swizzle Swizzle) Option {
+	return func(o *codecOptions) { o.swizzle = swizzle }
+}
+
+// WithWorkers sets the number of goroutines encoding or decoding one image; <= 0 (the default)
+// uses GOMAXPROCS.
+func WithWorkers(n int) Option {
+	return func(o *codecOptions) { o.workers = n }
+}
+
+// WithMipQualityCurve sets the search effort policy of Encoder.EncodeMipChain for the levels below
+// the base (default: every level at the full quality).
+func WithMipQualityCurve(curve MipQualityCurve) Option {
+	return func(o *codecOptions) { o.mipCurve = curve }
+}
+
+// WithOutputAlignment pads the block region of every encoded file (the bytes after the header) to
+// a multiple of alignment bytes with copies of PaddingBlock, e.g. to the 64 KiB page size of
+// sparse textures. alignment must be a positive multiple of BlockBytes; 0 (the default) disables
+// padding. ParseFile accepts and strips the padding, and BlockRegionSize reports both sizes.
+func WithOutputAlignment(alignment int) Option {
+	return func(o *codecOptions) { o.alignment = alignment }
+}
+
+// WithFlipY makes a Decoder store rows bottom-up, the OpenGL pixel-unpack convention, as
+// DecodeOptions.FlipY does. Encoders ignore it.
+func WithFlipY(flip bool) Option {
+	return func(o *codecOptions) { o.flipY = flip }
+}
+
+// WithDecodeLimits sets the largest images a Decoder accepts, as DecodeOptions.MaxPixels and
+// MaxBlocks do: zero (the default) selects DefaultMaxPixels and DefaultMaxBlocks, a negative value
+// disables the ceiling. Encoders ignore it.
+func WithDecodeLimits(maxPixels, maxBlocks int64) Option {
+	return func(o *codecOptions) { o.maxPixels, o.maxBlocks = maxPixels, maxBlocks }
+}
+
+// WithConfig adjusts the Config derived from the other options, for the settings without an
+// option of their own (tuning limits, channel weights, block order, ...). Functions from several
+// WithConfig options run in order.
+func WithConfig(fn func(*Config)) Option {
+	return func(o *codecOptions) { o.configFns = append(o.configFns, fn) }
+}
+
+func (o *codecOptions) apply(opts []Option) {
+	for _, opt := range opts {
+		if opt != nil {
+			opt(o)
+		}
+	}
+	if o.workers <= 0 {
+		o.workers = runtime.GOMAXPROCS(0)
+	}
+}
+
+// config returns the Config for a block footprint, validated like ContextAlloc does.
+func (o *codecOptions) config(blockX, blockY, blockZ int, quality float32, flags Flags) (Config, error) {
+	cfg, err := ConfigInit(o.profile, blockX, blockY, blockZ, quality, flags)
+	if err != nil {
+		return Config{}, err
+	}
+	for _, fn := range o.configFns {
+		fn(&cfg)
+	}
+	check := cfg
+	if err := validateAndClampConfig(&check); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// Encoder encodes images into .astc files with a fixed set of options. It is the primary API for
+// encoding: every setting of the Context API is reachable through options, and the Context is
+// allocated and driven internally. An Encoder is safe for concurrent use.
+//
+// Encoders search with the Context API tuning of the selected preset, so their output can differ
+// from the deprecated Encode*WithProfileAndQuality functions at the same EncodeQuality.
+type Encoder struct {
+	opts codecOptions
+	cfg  Config
+}
+
+// NewEncoder returns an Encoder configured by opts. Invalid options (an illegal block footprint,
+// a compression swizzle using Z, ...) are reported here rather than on first use.
+func NewEncoder(opts ...Option) (*Encoder, error) {
+	o := defaultCodecOptions()
+	o.apply(opts)
+	quality := o.qualityLevel
+	switch {
+	case o.useQualityPreset:
+		p, err := lookupQualityPreset(o.qualityPresetName)
+		if err != nil {
+			return nil, err
+		}
+		quality = p.level
+		if p.cfgDelta != nil {
+			o.configFns = append([]func(*Config){p.cfgDelta}, o.configFns...)
+		}
+	case !o.useQualityLevel:
+		if o.quality > EncodeExhaustive {
+			return nil, newError(ErrBadQuality, "astc: invalid quality")
+		}
+		quality = o.quality.Level()
+	}
+	if err := validateCompressionSwizzle(o.swizzle); err != nil {
+		return nil, err
+	}
+	cfg, err := o.config(o.blockX, o.blockY, o.blockZ, quality, o.flags)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Flags&FlagDecompressOnly != 0 {
+		return nil, newError(ErrBadFlags, "astc: encoder cannot be decompress-only")
+	}
+	if !o.mipCurve.valid() {
+		return nil, newError(ErrBadParam, "astc: invalid mip quality curve")
+	}
+	if o.alignment < 0 || o.alignment%BlockBytes != 0 {
+		return nil, newError(ErrBadParam, "astc: output alignment must be a multiple of the block size")
+	}
+	return &Encoder{opts: o, cfg: cfg}, nil
+}
+
+// Config returns the Config the encoder compresses with.
+func (e *Encoder) Config() Config {
+	return e.cfg
+}
+
+// Encode encodes img (any data type and layout accepted by Context.CompressImage) into a .astc
+// file.
+func (e *Encoder) Encode(img *Image) ([]byte, error) {
+	return e.encode(context.Background(), img, &e.cfg)
+}
+
+// EncodeMipChain encodes the levels of a mip chain, levels[0] being the base, into one .astc file
+// per level. Levels below the base are encoded with the Config adjusted by the encoder's
+// MipQualityCurve (see WithMipQualityCurve). The level dimensions are not checked against each
+// other.
+func (e *Encoder) EncodeMipChain(levels []*Image) ([][]byte, error) {
+	return e.encodeMipChain(context.Background(), levels)
+}
+
+func (e *Encoder) encodeMipChain(ctx context.Context, levels []*Image) ([][]byte, error) {
+	out := make([][]byte, len(levels))
+	for i, img := range levels {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		cfg := e.opts.mipCurve.Apply(e.cfg, i)
+		data, err := e.encode(ctx, img, &cfg)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = data
+	}
+	return out, nil
+}
+
+func (e *Encoder) encode(goCtx context.Context, img *Image, cfg *Config) ([]byte, error) {
+	headerBytes, _, total, err := encodeHeader(img, cfg)
+	if err != nil {
+		return nil, err
+	}
+	size := total * BlockBytes
+	out := make([]byte, HeaderSize+size+e.paddingBytes(size))
+	copy(out, headerBytes[:])
+	if err := e.compressBlocks(goCtx, img, cfg, total, out[HeaderSize:HeaderSize+size]); err != nil {
+		return nil, err
+	}
+	fillPadding(out[HeaderSize+size:])
+	return out, nil
+}
+
+// paddingBytes returns the number of padding bytes WithOutputAlignment adds after size bytes of
+// blocks.
+func (e *Encoder) paddingBytes(size int) int {
+	if e.opts.alignment == 0 || size%e.opts.alignment == 0 {
+		return 0
+	}
+	return e.opts.alignment - size%e.opts.alignment
+}
+
+// encodeHeader returns the .astc header of img encoded with cfg, its block grid width, and its
+// block count. It validates img, including its buffer length, so that callers can size their
+// output from the result.
+func encodeHeader(img *Image, cfg *Config) (headerBytes [HeaderSize]byte, blocksX, total int, err error) {
+	if img == nil {
+		return headerBytes, 0, 0, newError(ErrBadParam, "astc: nil image")
+	}
+	if _, err := validateImageIn(img); err != nil {
+		return headerBytes, 0, 0, err
+	}
+	h := Header{
+		BlockX: uint8(cfg.BlockX),
+		BlockY: uint8(cfg.BlockY),
+		BlockZ: uint8(cfg.BlockZ),
+		SizeX:  uint32(img.DimX),
+		SizeY:  uint32(img.DimY),
+		SizeZ:  uint32(img.DimZ),
+	}
+	headerBytes, err = MarshalHeader(h)
+	if err != nil {
+		return headerBytes, 0, 0, err
+	}
+	blocksX, _, _, total, err = h.BlockCount()
+	if err != nil {
+		return headerBytes, 0, 0, err
+	}
+	return headerBytes, blocksX, total, nil
+}
+
+// compressBlocks compresses the total blocks of img into blocks.
+func (e *Encoder) compressBlocks(goCtx context.Context, img *Image, cfg *Config, total int, blocks []byte) error {
+	workers := min(e.opts.workers, total)
+	ctx, err := ContextAlloc(cfg, workers)
+	if err != nil {
+		return err
+	}
+	defer ctx.Close()
+
+	err = runWorkersCtx(goCtx, workers, func(done <-chan struct{}, i int) error {
+		return ctx.compressImage(done, img, nil, 0, e.opts.swizzle, blocks, i)
+	})
+	// A worker which joins after the others finished the image fails to start; the image is still
+	// complete.
+	if int(ctx.compress.doneBlocks.Load()) == total {
+		return nil
+	}
+	if ctxErr := goCtx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	if err == nil {
+		err = errors.New("astc: incomplete compression")
+	}
+	return err
+}
+
+// EncodeRGBA8 encodes a width x height RGBA8 pixel buffer into a .astc file.
+func (e *Encoder) EncodeRGBA8(pix []byte, width, height int) ([]byte, error) {
+	return e.Encode(&Image{DimX: width, DimY: height, DimZ: 1, DataType: TypeU8, DataU8: pix})
+}
+
+// EncodeRGBAF32 encodes a width x height RGBA float32 pixel buffer into a .astc file.
+func (e *Encoder) EncodeRGBAF32(pix []float32, width, height int) ([]byte, error) {
+	return e.Encode(&Image{DimX: width, DimY: height, DimZ: 1, DataType: TypeF32, DataF32: pix})
+}
+
+// Decoder decodes .astc files with a fixed set of options; the block footprint is read from each
+// file. A Decoder is safe for concurrent use.
+type Decoder struct {
+	opts codecOptions
+}
+
+// NewDecoder returns a Decoder configured by opts.
+func NewDecoder(opts ...Option) (*Decoder, error) {
+	o := defaultCodecOptions()
+	o.apply(opts)
+	if err := validateDecompressionSwizzle(o.swizzle); err != nil {
+		return nil, err
+	}
+	// Validate the profile, flags and config adjustments with a representative footprint.
+	if _, err := o.config(4, 4, 1, 0, o.flags|FlagDecompressOnly); err != nil {
+		return nil, err
+	}
+	return &Decoder{opts: o}, nil
+}
+
+// DecodeInto decodes astcData into img, whose dimensions must match the file and whose data type
+// selects the output format.
+func (d *Decoder) DecodeInto(astcData []byte, img *Image) error {
+	return d.decodeInto(context.Background(), astcData, img)
+}
+
+func (d *Decoder) decodeInto(goCtx context.Context, astcData []byte, img *Image) error {
+	if img == nil {
+		return newError(ErrBadParam, "astc: nil output image")
+	}
+	h, blocks, err := ParseFile(astcData)
+	if err != nil {
+		return err
+	}
+	if img.DimX != int(h.SizeX) || img.DimY != int(h.SizeY) || img.DimZ != int(h.SizeZ) {
+		return newError(ErrBadParam, "astc: output image dimensions do not match the file")
+	}
+	cfg, err := d.opts.config(int(h.BlockX), int(h.BlockY), int(h.BlockZ), 0, d.opts.flags|FlagDecompressOnly)
+	if err != nil {
+		return err
+	}
+	_, _, _, total, err := h.BlockCount()
+	if err != nil {
+		return err
+	}
+
+	workers := min(d.opts.workers, total)
+	ctx, err := ContextAlloc(&cfg, workers)
+	if err != nil {
+		return err
+	}
+	defer ctx.Close()
+
+	var ok atomic.Bool
+	err = runWorkersCtx(goCtx, workers, func(done <-chan struct{}, i int) error {
+		err := ctx.decompressImage(done, blocks, img, d.opts.swizzle, i, d.decodeOptions())
+		if err == nil {
+			ok.Store(true)
+		}
+		return err
+	})
+	// Decompression has no per-block failures: once one worker returns successfully, every block
+	// was decoded (unless goCtx stopped it), even if a late worker failed to join.
+	if ok.Load() && int(ctx.decompress.doneBlocks.Load()) == total {
+		return nil
+	}
+	if ctxErr := goCtx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	return err
+}
+
+// decodeOptions returns the DecodeOptions the Decoder's options select.
+func (d *Decoder) decodeOptions() DecodeOptions {
+	return DecodeOptions{FlipY: d.opts.flipY, MaxPixels: d.opts.maxPixels, MaxBlocks: d.opts.maxBlocks}
+}
+
+// DecodeRGBA8 decodes astcData into an RGBA8 pixel buffer.
+func (d *Decoder) DecodeRGBA8(astcData []byte) (pix []byte, width, height, depth int, err error) {
+	h, err := ParseHeader(astcData)
+	if err != nil {
+		return nil, 0, 0, 0, err
+	}
+	if err := d.decodeOptions().CheckLimits(h); err != nil {
+		return nil, 0, 0, 0, err
+	}
+	img := &Image{DimX: int(h.SizeX), DimY: int(h.SizeY), DimZ: int(h.SizeZ), DataType: TypeU8}
+	img.DataU8 = make([]byte, img.DimX*img.DimY*img.DimZ*4)
+	if err := d.DecodeInto(astcData, img); err != nil {
+		return nil, 0, 0, 0, err
+	}
+	return img.DataU8, img.DimX, img.DimY, img.DimZ, nil
+}
+
+// DecodeRGBAF32 decodes astcData into an RGBA float32 pixel buffer.
+func (d *Decoder) DecodeRGBAF32(astcData []byte) (pix []float32, width, height, depth int, err error) {
+	h, err := ParseHeader(astcData)
+	if err != nil {
+		return nil, 0, 0, 0, err
+	}
+	if err := d.decodeOptions().CheckLimits(h); err != nil {
+		return nil, 0, 0, 0, err
+	}
+	img := &Image{DimX: int(h.SizeX), DimY: int(h.SizeY), DimZ: int(h.SizeZ), DataType: TypeF32}
+	img.DataF32 = make([]float32, img.DimX*img.DimY*img.DimZ*4)
+	if err := d.DecodeInto(astcData, img); err != nil {
+		return nil, 0, 0, 0, err
+	}
+	return img.DataF32, img.DimX, img.DimY, img.DimZ, nil
+}
+
+// runWorkers runs fn(0) ... fn(n-1) concurrently and returns the error of the lowest failing
+// index.
+func runWorkers(n int, fn func(i int) error) error {
+	if n == 1 {
+		return fn(0)
+	}
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := range n {
+		go func() {
+			defer wg.Done()
+			errs[i] = fn(i)
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
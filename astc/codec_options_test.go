@@ -0,0 +1,245 @@
+package astc_test
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/arm-software/astc-encoder/astc"
+)
+
+func TestEncoder_MatchesContext(t *testing.T) {
+	const w, h = 45, 23
+	pix := make([]byte, w*h*4)
+	for i := range pix {
+		pix[i] = uint8(i*11 + i/9)
+	}
+
+	cfg, err := astc.ConfigInit(astc.ProfileLDR, 6, 6, 1, 10, 0)
+	if err != nil {
+		t.Fatalf("ConfigInit: %v", err)
+	}
+	ctx, err := astc.ContextAlloc(&cfg, 1)
+	if err != nil {
+		t.Fatalf("ContextAlloc: %v", err)
+	}
+	defer ctx.Close()
+	want := make([]byte, ((w+5)/6)*((h+5)/6)*astc.BlockBytes)
+	img := &astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeU8, DataU8: pix}
+	if err := ctx.CompressImage(img, astc.SwizzleRGBA, want, 0); err != nil {
+		t.Fatalf("CompressImage: %v", err)
+	}
+
+	for _, workers := range []int{1, 4} {
+		enc, err := astc.NewEncoder(astc.WithBlockSize(6, 6), astc.WithQuality(astc.EncodeFast), astc.WithWorkers(workers))
+		if err != nil {
+			t.Fatalf("NewEncoder: %v", err)
+		}
+		data, err := enc.EncodeRGBA8(pix, w, h)
+		if err != nil {
+			t.Fatalf("EncodeRGBA8 (%d workers): %v", workers, err)
+		}
+		hdr, blocks, err := astc.ParseFile(data)
+		if err != nil {
+			t.Fatalf("ParseFile: %v", err)
+		}
+		if hdr.BlockX != 6 || hdr.BlockY != 6 || hdr.SizeX != w || hdr.SizeY != h {
+			t.Fatalf("unexpected header %+v", hdr)
+		}
+		if !bytes.Equal(blocks, want) {
+			t.Fatalf("EncodeRGBA8 (%d workers): blocks differ from Context.CompressImage", workers)
+		}
+	}
+}
+
+func TestDecoder_MatchesDecode(t *testing.T) {
+	const w, h, d = 17, 9, 3
+	pix := make([]byte, w*h*d*4)
+	for i := range pix {
+		pix[i] = uint8(i*5 + i/3)
+	}
+	data, err := astc.EncodeRGBA8VolumeWithProfileAndQuality(pix, w, h, d, 4, 4, 1, astc.ProfileLDR, astc.EncodeFast)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	want, _, _, _, err := astc.DecodeRGBA8VolumeWithProfile(data, astc.ProfileLDR)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	for _, workers := range []int{1, 3} {
+		dec, err := astc.NewDecoder(astc.WithWorkers(workers))
+		if err != nil {
+			t.Fatalf("NewDecoder: %v", err)
+		}
+		got, gw, gh, gd, err := dec.DecodeRGBA8(data)
+		if err != nil {
+			t.Fatalf("DecodeRGBA8 (%d workers): %v", workers, err)
+		}
+		if gw != w || gh != h || gd != d || !bytes.Equal(got, want) {
+			t.Fatalf("DecodeRGBA8 (%d workers): output differs", workers)
+		}
+	}
+
+	// Swap R and B on output.
+	dec, err := astc.NewDecoder(astc.WithSwizzle(astc.Swizzle{R: astc.SwzB, G: astc.SwzG, B: astc.SwzR, A: astc.SwzA}))
+	if err != nil {
+		t.Fatalf("NewDecoder: %v", err)
+	}
+	got, _, _, _, err := dec.DecodeRGBA8(data)
+	if err != nil {
+		t.Fatalf("DecodeRGBA8: %v", err)
+	}
+	for i := 0; i < len(want); i += 4 {
+		if got[i] != want[i+2] || got[i+1] != want[i+1] || got[i+2] != want[i] || got[i+3] != want[i+3] {
+			t.Fatalf("swizzled texel %d = %v, want BGRA of %v", i/4, got[i:i+4], want[i:i+4])
+		}
+	}
+
+	img := &astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeU8, DataU8: make([]byte, w*h*4)}
+	if err := dec.DecodeInto(data, img); err == nil {
+		t.Fatalf("DecodeInto with mismatched dimensions: expected error")
+	}
+}
+
+func TestNewEncoder_Errors(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		opts []astc.Option
+		code astc.ErrorCode
+	}{
+		{"block size", []astc.Option{astc.WithBlockSize(7, 7)}, astc.ErrBadBlockSize},
+		{"quality", []astc.Option{astc.WithQuality(astc.EncodeExhaustive + 1)}, astc.ErrBadQuality},
+		{"quality level", []astc.Option{astc.WithQualityLevel(100.5)}, astc.ErrBadQuality},
+		{"NaN quality level", []astc.Option{astc.WithQualityLevel(float32(math.NaN()))}, astc.ErrBadQuality},
+		{"swizzle", []astc.Option{astc.WithSwizzle(astc.Swizzle{R: astc.SwzZ, G: astc.SwzG, B: astc.SwzB, A: astc.SwzA})}, astc.ErrBadSwizzle},
+		{"decompress only", []astc.Option{astc.WithFlags(astc.FlagDecompressOnly)}, astc.ErrBadFlags},
+		{"config", []astc.Option{astc.WithConfig(func(c *astc.Config) { c.BlockOrder = astc.BlockOrderMorton + 1 })}, astc.ErrBadParam},
+		{"alignment", []astc.Option{astc.WithOutputAlignment(100)}, astc.ErrBadParam},
+		{"negative alignment", []astc.Option{astc.WithOutputAlignment(-16)}, astc.ErrBadParam},
+	} {
+		_, err := astc.NewEncoder(tc.opts...)
+		var e *astc.Error
+		if !errors.As(err, &e) || e.Code != tc.code {
+			t.Fatalf("%s: expected error code %v, got %v", tc.name, tc.code, err)
+		}
+	}
+}
+
+func TestEncoder_BufferLength(t *testing.T) {
+	enc, err := astc.NewEncoder(astc.WithQuality(astc.EncodeFast))
+	if err != nil {
+		t.Fatalf("NewEncoder: %v", err)
+	}
+	// The buffer is checked before the output is sized from the dimensions.
+	if _, err := enc.EncodeRGBA8(make([]byte, 4), 1<<20, 1<<20); astc.ErrorCodeOf(err) != astc.ErrBadParam {
+		t.Fatalf("EncodeRGBA8 with a short buffer: expected ErrBadParam, got %v", err)
+	}
+	img := &astc.Image{DimX: 1 << 20, DimY: 1 << 20, DimZ: 1, DataType: astc.TypeF32, DataF32: make([]float32, 4)}
+	if _, err := enc.EncodeSegments(img, &astc.SegmentBuffer{}); astc.ErrorCodeOf(err) != astc.ErrBadParam {
+		t.Fatalf("EncodeSegments with a short buffer: expected ErrBadParam, got %v", err)
+	}
+	img = &astc.Image{DimX: 1 << 24, DimY: 1 << 24, DimZ: 1 << 24, DataType: astc.TypeU8}
+	if _, err := enc.Encode(img); astc.ErrorCodeOf(err) != astc.ErrBadParam {
+		t.Fatalf("Encode with overflowing dimensions: expected ErrBadParam, got %v", err)
+	}
+}
+
+func TestEncoder_QualityLevel(t *testing.T) {
+	const w, h = 24, 24
+	pix := make([]byte, w*h*4)
+	for i := range pix {
+		pix[i] = uint8(i*7 + i/13*3)
+	}
+	encode := func(opts ...astc.Option) (*astc.Encoder, []byte) {
+		t.Helper()
+		enc, err := astc.NewEncoder(append(opts, astc.WithBlockSize(6, 6), astc.WithWorkers(1))...)
+		if err != nil {
+			t.Fatalf("NewEncoder: %v", err)
+		}
+		data, err := enc.EncodeRGBA8(pix, w, h)
+		if err != nil {
+			t.Fatalf("EncodeRGBA8: %v", err)
+		}
+		return enc, data
+	}
+
+	// A level at a preset's value is that preset; the last quality option wins.
+	_, medium := encode(astc.WithQuality(astc.EncodeMedium))
+	if _, level := encode(astc.WithQualityLevel(60)); !bytes.Equal(level, medium) {
+		t.Fatalf("WithQualityLevel(60) differs from WithQuality(EncodeMedium)")
+	}
+	if _, last := encode(astc.WithQualityLevel(10), astc.WithQuality(astc.EncodeMedium)); !bytes.Equal(last, medium) {
+		t.Fatalf("WithQuality after WithQualityLevel was not applied")
+	}
+
+	// Levels between presets interpolate the tuning.
+	encMedium, _ := encode(astc.WithQuality(astc.EncodeMedium))
+	encThorough, _ := encode(astc.WithQuality(astc.EncodeThorough))
+	enc75, _ := encode(astc.WithQualityLevel(75))
+	lo, mid, hi := encMedium.Config().TuneBlockModeLimit, enc75.Config().TuneBlockModeLimit, encThorough.Config().TuneBlockModeLimit
+	if !(lo < mid && mid < hi) {
+		t.Fatalf("TuneBlockModeLimit at level 75 = %d, want between %d and %d", mid, lo, hi)
+	}
+}
+
+func TestMipQualityCurve(t *testing.T) {
+	cfg, err := astc.ConfigInit(astc.ProfileLDR, 6, 6, 1, 98, 0)
+	if err != nil {
+		t.Fatalf("ConfigInit: %v", err)
+	}
+	curve := astc.DefaultMipQualityCurve
+	if got := curve.Apply(cfg, 0); got.TuneBlockModeLimit != cfg.TuneBlockModeLimit {
+		t.Fatalf("Apply changed the base level")
+	}
+	prev := cfg.TuneBlockModeLimit
+	for level := 1; level <= 6; level++ {
+		got := curve.Apply(cfg, level).TuneBlockModeLimit
+		if got > prev || got < cfg.TuneBlockModeLimit/8 {
+			t.Fatalf("level %d: TuneBlockModeLimit %d (previous %d, base %d)", level, got, prev, cfg.TuneBlockModeLimit)
+		}
+		prev = got
+	}
+	if got := curve.Apply(cfg, 1).Tune2PartitionIndexLimit; got != (cfg.Tune2PartitionIndexLimit+1)/2 {
+		t.Fatalf("level 1: Tune2PartitionIndexLimit %d, base %d", got, cfg.Tune2PartitionIndexLimit)
+	}
+
+	const w, h = 32, 32
+	var levels []*astc.Image
+	for s := w; s >= 1; s /= 2 {
+		pix := make([]byte, s*s*4)
+		for i := range pix {
+			pix[i] = uint8(i*7 + i/5*3 + s)
+		}
+		levels = append(levels, &astc.Image{DimX: s, DimY: s, DimZ: 1, DataType: astc.TypeU8, DataU8: pix})
+	}
+	enc, err := astc.NewEncoder(astc.WithBlockSize(6, 6), astc.WithQuality(astc.EncodeThorough), astc.WithMipQualityCurve(curve))
+	if err != nil {
+		t.Fatalf("NewEncoder: %v", err)
+	}
+	chain, err := enc.EncodeMipChain(levels)
+	if err != nil {
+		t.Fatalf("EncodeMipChain: %v", err)
+	}
+	if len(chain) != len(levels) {
+		t.Fatalf("EncodeMipChain returned %d levels, want %d", len(chain), len(levels))
+	}
+	base, err := enc.Encode(levels[0])
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	if !bytes.Equal(chain[0], base) {
+		t.Fatalf("base level differs from Encode")
+	}
+	for i, data := range chain {
+		hdr, err := astc.ParseHeader(data)
+		if err != nil || int(hdr.SizeX) != levels[i].DimX {
+			t.Fatalf("level %d: header %+v, %v", i, hdr, err)
+		}
+	}
+
+	if _, err := astc.NewEncoder(astc.WithMipQualityCurve(astc.MipQualityCurve{EffortDecay: 2})); astc.ErrorCodeOf(err) != astc.ErrBadParam {
+		t.Fatalf("NewEncoder accepted EffortDecay 2: %v", err)
+	}
+}
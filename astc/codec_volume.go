@@ -5,7 +5,8 @@ import "errors"
 // DecodeRGBA8VolumeWithProfileInto decodes a .astc file into a caller-provided RGBA8 pixel buffer.
 //
 // The dst slice must have length at least `width*height*depth*4`. Pixels are laid out in x-major
-// order, then y, then z: `((z*height+y)*width + x) * 4`.
+// order, then y, then z: `((z*height+y)*width + x) * 4`. dst may overlap astcData (e.g. when both
+// live in one arena); the blocks are then copied before decoding.
 //
 // Limitations:
 //   - Only LDR profiles (ProfileLDR, ProfileLDRSRGB).
@@ -35,6 +36,7 @@ func DecodeRGBA8VolumeWithProfileInto(astcData []byte, profile Profile, dst []by
 // caller-provided RGBA8 buffer.
 //
 // This avoids parsing overhead when decoding the same payload multiple times (e.g. in benchmarks).
+// dst may overlap blocks.
 func DecodeRGBA8VolumeFromParsedWithProfileInto(profile Profile, h Header, blocks []byte, dst []byte) error {
 	width := int(h.SizeX)
 	height := int(h.SizeY)
@@ -48,7 +50,32 @@ func DecodeRGBA8VolumeFromParsedWithProfileInto(profile Profile, h Header, block
 	return decodeRGBA8VolumeFromParsed(profile, h, blocks, dst[:width*height*depth*4])
 }
 
+// DecodeRGBA8FromBlocksInto decodes a headerless block payload of a width x height x depth image
+// with the given block size into a caller-provided RGBA8 buffer, for archives that store the image
+// dimensions elsewhere. It is DecodeRGBA8VolumeFromParsedWithProfileInto with the header built from
+// the arguments.
+func DecodeRGBA8FromBlocksInto(blocks []byte, width, height, depth, blockX, blockY, blockZ int, profile Profile, dst []byte) error {
+	if width <= 0 || height <= 0 || depth <= 0 || width > MaxImageDim || height > MaxImageDim || depth > MaxImageDim {
+		return errors.New("astc: invalid image dimensions")
+	}
+	if err := validateBlockSize(blockX, blockY, blockZ); err != nil {
+		return err
+	}
+	h := Header{
+		BlockX: uint8(blockX), BlockY: uint8(blockY), BlockZ: uint8(blockZ),
+		SizeX: uint32(width), SizeY: uint32(height), SizeZ: uint32(depth),
+	}
+	return DecodeRGBA8VolumeFromParsedWithProfileInto(profile, h, blocks, dst)
+}
+
 func decodeRGBA8VolumeFromParsed(profile Profile, h Header, blocks []byte, dst []byte) error {
+	return decodeRGBA8VolumeFromParsedWithContext(profile, nil, h, blocks, dst, 0, 0)
+}
+
+// decodeRGBA8VolumeFromParsedWithContext is decodeRGBA8VolumeFromParsed with an optional
+// pre-resolved decode context and destination pitches. A nil ctx is looked up from the header's
+// block footprint; zero pitches mean tightly packed rows and slices.
+func decodeRGBA8VolumeFromParsedWithContext(profile Profile, ctx *decodeContext, h Header, blocks []byte, dst []byte, rowPitch, slicePitch int) error {
 	blocksX, blocksY, blocksZ, total, err := h.BlockCount()
 	if err != nil {
 		return err
@@ -56,6 +83,7 @@ func decodeRGBA8VolumeFromParsed(profile Profile, h Header, blocks []byte, dst [
 	if len(blocks) < total*BlockBytes {
 		return ioErrUnexpectedEOF("astc blocks", total*BlockBytes, len(blocks))
 	}
+	blocks = detachBlocks(blocks[:total*BlockBytes], dst)
 
 	width := int(h.SizeX)
 	height := int(h.SizeY)
@@ -76,13 +104,21 @@ func decodeRGBA8VolumeFromParsed(profile Profile, h Header, blocks []byte, dst [
 		return errUnsupportedProfileRGBA8
 	}
 
-	ctx := getDecodeContext(blockX, blockY, blockZ)
+	if ctx == nil {
+		ctx = getDecodeContext(blockX, blockY, blockZ)
+	}
 
 	var decodedBlock [blockMaxTexels * 4]byte
 	decoded := decodedBlock[:texelCount*4]
 
 	dstRowStride := width * 4
+	if rowPitch != 0 {
+		dstRowStride = rowPitch
+	}
 	dstSliceStride := height * dstRowStride
+	if slicePitch != 0 {
+		dstSliceStride = slicePitch
+	}
 	srcRowBytes := blockX * 4
 	for bz := 0; bz < blocksZ; bz++ {
 		for by := 0; by < blocksY; by++ {
@@ -154,6 +190,9 @@ func DecodeRGBA8VolumeWithProfile(astcData []byte, profile Profile) (pix []byte,
 	if width <= 0 || height <= 0 || depth <= 0 {
 		return nil, 0, 0, 0, errors.New("astc: invalid image dimensions")
 	}
+	if err := checkDecodeLimits(h, 0, 0); err != nil {
+		return nil, 0, 0, 0, err
+	}
 
 	pix = make([]byte, width*height*depth*4)
 	if err := decodeRGBA8VolumeFromParsed(profile, h, blocks, pix); err != nil {
@@ -179,7 +218,7 @@ func fillErrorRGBAF32(dst []float32) {
 // pixel buffer.
 //
 // The dst slice must have length at least `width*height*depth*4`. Pixels are laid out in x-major
-// order, then y, then z: `((z*height+y)*width + x) * 4`.
+// order, then y, then z: `((z*height+y)*width + x) * 4`. dst may overlap astcData.
 func DecodeRGBAF32VolumeWithProfileInto(astcData []byte, profile Profile, dst []float32) (width, height, depth int, err error) {
 	h, blocks, err := ParseFile(astcData)
 	if err != nil {
@@ -206,6 +245,7 @@ func DecodeRGBAF32VolumeWithProfileInto(astcData []byte, profile Profile, dst []
 // caller-provided RGBA float32 buffer.
 //
 // This avoids parsing overhead when decoding the same payload multiple times (e.g. in benchmarks).
+// dst may overlap blocks.
 func DecodeRGBAF32VolumeFromParsedWithProfileInto(profile Profile, h Header, blocks []byte, dst []float32) error {
 	width := int(h.SizeX)
 	height := int(h.SizeY)
@@ -227,6 +267,7 @@ func decodeRGBAF32VolumeFromParsed(profile Profile, h Header, blocks []byte, dst
 	if len(blocks) < total*BlockBytes {
 		return ioErrUnexpectedEOF("astc blocks", total*BlockBytes, len(blocks))
 	}
+	blocks = detachBlocks(blocks[:total*BlockBytes], dst)
 
 	width := int(h.SizeX)
 	height := int(h.SizeY)
@@ -302,6 +343,71 @@ func decodeRGBAF32VolumeFromParsed(profile Profile, h Header, blocks []byte, dst
 	return nil
 }
 
+// DecodeRGBAF16VolumeWithProfileInto decodes a .astc file into a caller-provided RGBA buffer of
+// IEEE 754 half-floats, the layout of TypeF16 images and of float16 GPU uploads.
+//
+// The dst slice must have length at least `width*height*depth*4`, laid out like
+// DecodeRGBAF32VolumeWithProfileInto. Values beyond the half-float range saturate to infinity.
+func DecodeRGBAF16VolumeWithProfileInto(astcData []byte, profile Profile, dst []uint16) (width, height, depth int, err error) {
+	h, blocks, err := ParseFile(astcData)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	width = int(h.SizeX)
+	height = int(h.SizeY)
+	depth = int(h.SizeZ)
+	if width <= 0 || height <= 0 || depth <= 0 {
+		return 0, 0, 0, errors.New("astc: invalid image dimensions")
+	}
+	if len(dst) < width*height*depth*4 {
+		return 0, 0, 0, errors.New("astc: output buffer too small")
+	}
+
+	if err := decodeRGBAF16VolumeFromParsed(profile, h, blocks, dst[:width*height*depth*4]); err != nil {
+		return 0, 0, 0, err
+	}
+	return width, height, depth, nil
+}
+
+func decodeRGBAF16VolumeFromParsed(profile Profile, h Header, blocks []byte, dst []uint16) error {
+	blocksX, blocksY, blocksZ, total, err := h.BlockCount()
+	if err != nil {
+		return err
+	}
+	if len(blocks) < total*BlockBytes {
+		return ioErrUnexpectedEOF("astc blocks", total*BlockBytes, len(blocks))
+	}
+	blocks = detachBlocks(blocks[:total*BlockBytes], dst)
+
+	blockX := int(h.BlockX)
+	blockY := int(h.BlockY)
+	blockZ := int(h.BlockZ)
+	texelCount := blockX * blockY * blockZ
+	if texelCount <= 0 || texelCount > blockMaxTexels {
+		return errors.New("astc: invalid block dimensions")
+	}
+	ctx := getDecodeContext(blockX, blockY, blockZ)
+
+	var decodedBlockArr [blockMaxTexels * 4]float32
+	decodedBlock := decodedBlockArr[:texelCount*4]
+
+	width := int(h.SizeX)
+	height := int(h.SizeY)
+	depth := int(h.SizeZ)
+	blockOff := 0
+	for bz := 0; bz < blocksZ; bz++ {
+		for by := 0; by < blocksY; by++ {
+			for bx := 0; bx < blocksX; bx++ {
+				decodeBlockToRGBAF32(profile, ctx, blocks[blockOff:blockOff+BlockBytes], decodedBlock)
+				storeBlockRGBAF32AsF16Volume(dst, width, height, depth, bx*blockX, by*blockY, bz*blockZ, blockX, blockY, blockZ, decodedBlock, false)
+				blockOff += BlockBytes
+			}
+		}
+	}
+	return nil
+}
+
 // DecodeRGBAF32VolumeWithProfile decodes a .astc file into an RGBA float32 pixel buffer.
 //
 // The returned pixel buffer is laid out in x-major order, then y, then z:
@@ -318,6 +424,9 @@ func DecodeRGBAF32VolumeWithProfile(astcData []byte, profile Profile) (pix []flo
 	if width <= 0 || height <= 0 || depth <= 0 {
 		return nil, 0, 0, 0, errors.New("astc: invalid image dimensions")
 	}
+	if err := checkDecodeLimits(h, 0, 0); err != nil {
+		return nil, 0, 0, 0, err
+	}
 
 	pix = make([]float32, width*height*depth*4)
 
@@ -160,15 +160,15 @@ func TestDecodeRGBAF32_HDR_A_1x1_ConstBlockMatchesPayload(t *testing.T) {
 
 	var want [4]float32
 	if isU16 {
-		want[0] = halfToFloat32(unorm16ToSF16(r))
-		want[1] = halfToFloat32(unorm16ToSF16(g))
-		want[2] = halfToFloat32(unorm16ToSF16(b))
-		want[3] = halfToFloat32(unorm16ToSF16(a))
+		want[0] = astc.HalfToFloat32(unorm16ToSF16(r))
+		want[1] = astc.HalfToFloat32(unorm16ToSF16(g))
+		want[2] = astc.HalfToFloat32(unorm16ToSF16(b))
+		want[3] = astc.HalfToFloat32(unorm16ToSF16(a))
 	} else {
-		want[0] = halfToFloat32(r)
-		want[1] = halfToFloat32(g)
-		want[2] = halfToFloat32(b)
-		want[3] = halfToFloat32(a)
+		want[0] = astc.HalfToFloat32(r)
+		want[1] = astc.HalfToFloat32(g)
+		want[2] = astc.HalfToFloat32(b)
+		want[3] = astc.HalfToFloat32(a)
 	}
 
 	pix, w, h2, err := astc.DecodeRGBAF32WithProfile(astcData, astc.ProfileHDR)
@@ -215,35 +215,76 @@ func TestDecodeRGBAF32VolumeFromParsedWithProfileInto_MatchesDecode(t *testing.T
 	}
 }
 
-// halfToFloat32 converts an IEEE 754 binary16 float to float32.
-func halfToFloat32(h uint16) float32 {
-	sign := uint32(h>>15) & 0x1
-	exp := uint32(h>>10) & 0x1F
-	mant := uint32(h) & 0x3FF
+func TestDecodeRGBAF16VolumeWithProfileInto_MatchesF32(t *testing.T) {
+	const w, h, d = 13, 7, 5
+	src := make([]float32, w*h*d*4)
+	for i := range src {
+		src[i] = float32(i%97) * 0.37
+	}
+	astcData, err := astc.EncodeRGBAF32VolumeWithProfileAndQuality(src, w, h, d, 4, 4, 4, astc.ProfileHDR, astc.EncodeFastest)
+	if err != nil {
+		t.Fatalf("EncodeRGBAF32VolumeWithProfileAndQuality: %v", err)
+	}
 
-	switch exp {
-	case 0:
-		if mant == 0 {
-			return math.Float32frombits(sign << 31)
-		}
-		// Subnormal -> normalized float32.
-		e := int32(-14)
-		for (mant & 0x400) == 0 {
-			mant <<= 1
-			e--
+	want := make([]float32, w*h*d*4)
+	if _, _, _, err := astc.DecodeRGBAF32VolumeWithProfileInto(astcData, astc.ProfileHDR, want); err != nil {
+		t.Fatalf("DecodeRGBAF32VolumeWithProfileInto: %v", err)
+	}
+	got := make([]uint16, w*h*d*4+3)
+	gw, gh, gd, err := astc.DecodeRGBAF16VolumeWithProfileInto(astcData, astc.ProfileHDR, got)
+	if err != nil {
+		t.Fatalf("DecodeRGBAF16VolumeWithProfileInto: %v", err)
+	}
+	if gw != w || gh != h || gd != d {
+		t.Fatalf("dimensions = %dx%dx%d, want %dx%dx%d", gw, gh, gd, w, h, d)
+	}
+	// HDR texels are half-floats before widening, so the conversion is exact.
+	for i, v := range want {
+		if math.Float32bits(astc.HalfToFloat32(got[i])) != math.Float32bits(v) {
+			t.Fatalf("texel value %d: got %v want %v", i, astc.HalfToFloat32(got[i]), v)
 		}
-		mant &= 0x3FF
-		exp32 := uint32(e + 127)
-		mant32 := mant << 13
-		return math.Float32frombits((sign << 31) | (exp32 << 23) | mant32)
-	case 0x1F:
-		// Inf/NaN
-		return math.Float32frombits((sign << 31) | 0x7F800000 | (mant << 13))
-	default:
-		// Normal number.
-		exp32 := exp + (127 - 15)
-		mant32 := mant << 13
-		return math.Float32frombits((sign << 31) | (exp32 << 23) | mant32)
+	}
+	if got[len(got)-1] != 0 {
+		t.Fatalf("wrote past the image")
+	}
+
+	if _, _, _, err := astc.DecodeRGBAF16VolumeWithProfileInto(astcData, astc.ProfileHDR, got[:w*h*d*4-1]); err == nil {
+		t.Fatalf("expected error for short output buffer")
+	}
+}
+
+func TestDecodeRGBA8FromBlocksInto_MatchesDecode(t *testing.T) {
+	const w, h, d = 20, 12, 3
+	src := make([]byte, w*h*d*4)
+	for i := range src {
+		src[i] = uint8(i*29 + i/5)
+	}
+	astcData, err := astc.EncodeRGBA8VolumeWithProfileAndQuality(src, w, h, d, 4, 4, 4, astc.ProfileLDR, astc.EncodeFastest)
+	if err != nil {
+		t.Fatalf("EncodeRGBA8VolumeWithProfileAndQuality: %v", err)
+	}
+	want, _, _, _, err := astc.DecodeRGBA8VolumeWithProfile(astcData, astc.ProfileLDR)
+	if err != nil {
+		t.Fatalf("DecodeRGBA8VolumeWithProfile: %v", err)
+	}
+
+	blocks := astcData[astc.HeaderSize:]
+	got := make([]byte, w*h*d*4)
+	if err := astc.DecodeRGBA8FromBlocksInto(blocks, w, h, d, 4, 4, 4, astc.ProfileLDR, got); err != nil {
+		t.Fatalf("DecodeRGBA8FromBlocksInto: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("headerless decode differs from file decode")
+	}
+
+	if err := astc.DecodeRGBA8FromBlocksInto(blocks[:len(blocks)-1], w, h, d, 4, 4, 4, astc.ProfileLDR, got); err == nil {
+		t.Fatalf("expected error for short block buffer")
+	}
+	if err := astc.DecodeRGBA8FromBlocksInto(blocks, w, h, d, 4, 4, 4, astc.ProfileLDR, got[:len(got)-1]); err == nil {
+		t.Fatalf("expected error for short output buffer")
+	}
+	if err := astc.DecodeRGBA8FromBlocksInto(blocks, w, h, d, 4, 7, 4, astc.ProfileLDR, got); astc.ErrorCodeOf(err) != astc.ErrBadBlockSize {
+		t.Fatalf("invalid block size: err = %v, want ErrBadBlockSize", err)
 	}
 }
 
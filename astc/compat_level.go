@@ -0,0 +1,25 @@
+package astc
+
+// CompatLevel selects the generation of encoder heuristics a Config encodes with. Search changes
+// that alter the blocks the encoder emits for a Config (new candidate modes, seeds, refinement
+// passes) are introduced at a new level, and a Config pinned to an older level keeps producing
+// byte-identical output across library upgrades, so caches keyed on the Config and source data
+// stay valid until their owner opts into the new level.
+//
+// Pinning covers the Context and Encoder paths for a given Config, input and thread-independent
+// settings; TunePartitionNeighborSeeding and ExperimentalBlockErrorDiffusion are reproducible only
+// with one thread, as documented on them. The deprecated Encode*WithProfileAndQuality functions
+// have no Config and always use the newest heuristics.
+type CompatLevel uint8
+
+const (
+	// CompatLatest, the zero value, uses the newest heuristics of the library version in use, so
+	// output may change on upgrade.
+	CompatLatest CompatLevel = iota
+	// CompatV1 freezes the heuristics of the first release with compatibility levels. The SHA-256
+	// of its output for a set of reference encodes is published in astc/testdata/compat_v1.txt.
+	CompatV1
+
+	// compatNewest is the newest pinned level; CompatLatest behaves like it.
+	compatNewest = CompatV1
+)
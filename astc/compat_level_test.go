@@ -0,0 +1,139 @@
+package astc_test
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/arm-software/astc-encoder/astc"
+	"github.com/arm-software/astc-encoder/astc/testimage"
+)
+
+// compatV1Golden lists the SHA-256 of reference encodes with CompatV1, one per line as
+// "kind block quality profile sha256". Set ASTC_UPDATE_COMPAT_GOLDEN=1 to rewrite it, which is
+// only legitimate when adding cases: existing lines must never change.
+const compatV1Golden = "testdata/compat_v1.txt"
+
+func compatGoldenEncode(t *testing.T, kind testimage.Kind, block int, quality astc.EncodeQuality, profile astc.Profile, level astc.CompatLevel) []byte {
+	t.Helper()
+	return compatGoldenEncodeVolume(t, kind, 48, 40, 1, [3]int{block, block, 1}, quality, profile, level)
+}
+
+// compatGoldenEncodeVolume encodes a reference image of the given kind, from float data for HDR
+// profiles.
+func compatGoldenEncodeVolume(t *testing.T, kind testimage.Kind, w, h, d int, block [3]int, quality astc.EncodeQuality, profile astc.Profile, level astc.CompatLevel) []byte {
+	t.Helper()
+	img := &astc.Image{DimX: w, DimY: h, DimZ: d}
+	var err error
+	if profile == astc.ProfileHDR || profile == astc.ProfileHDRRGBLDRAlpha {
+		img.DataType = astc.TypeF32
+		img.DataF32, err = testimage.RGBAF32(kind, w, h, d, testimage.Options{})
+	} else {
+		img.DataType = astc.TypeU8
+		img.DataU8, err = testimage.RGBA8(kind, w, h, d, testimage.Options{})
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc, err := astc.NewEncoder(astc.WithBlockSize3D(block[0], block[1], block[2]), astc.WithProfile(profile), astc.WithQuality(quality),
+		astc.WithWorkers(1), astc.WithConfig(func(c *astc.Config) { c.CompatLevel = level }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := enc.Encode(img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestCompatV1Golden(t *testing.T) {
+	var lines []string
+	add := func(kind testimage.Kind, block string, quality astc.EncodeQuality, profile string, out []byte) {
+		sum := sha256.Sum256(out)
+		lines = append(lines, fmt.Sprintf("%v %s %v %s %s", kind, block, quality, profile, hex.EncodeToString(sum[:])))
+	}
+	for _, kind := range []testimage.Kind{testimage.KindGradient, testimage.KindPerlin, testimage.KindText, testimage.KindAlphaCutout} {
+		for _, block := range []int{4, 6, 8} {
+			for _, quality := range []astc.EncodeQuality{astc.EncodeFast, astc.EncodeMedium, astc.EncodeThorough} {
+				for profile, name := range map[astc.Profile]string{astc.ProfileLDR: "ldr", astc.ProfileLDRSRGB: "srgb"} {
+					add(kind, fmt.Sprintf("%dx%d", block, block), quality, name, compatGoldenEncode(t, kind, block, quality, profile, astc.CompatV1))
+				}
+			}
+		}
+	}
+	// Exhaustive searches are slow, so they cover a smaller image.
+	for _, kind := range []testimage.Kind{testimage.KindPerlin, testimage.KindAlphaCutout} {
+		for _, block := range []int{4, 8} {
+			out := compatGoldenEncodeVolume(t, kind, 16, 16, 1, [3]int{block, block, 1}, astc.EncodeExhaustive, astc.ProfileLDR, astc.CompatV1)
+			add(kind, fmt.Sprintf("%dx%d", block, block), astc.EncodeExhaustive, "ldr", out)
+		}
+	}
+	for _, kind := range []testimage.Kind{testimage.KindGradient, testimage.KindPerlin} {
+		for _, block := range []int{3, 4, 5} {
+			for _, quality := range []astc.EncodeQuality{astc.EncodeFast, astc.EncodeMedium, astc.EncodeThorough} {
+				out := compatGoldenEncodeVolume(t, kind, 16, 16, 8, [3]int{block, block, block}, quality, astc.ProfileLDR, astc.CompatV1)
+				add(kind, fmt.Sprintf("%dx%dx%d", block, block, block), quality, "ldr", out)
+			}
+		}
+	}
+	for _, block := range []int{4, 6, 8} {
+		for _, quality := range []astc.EncodeQuality{astc.EncodeFast, astc.EncodeMedium, astc.EncodeThorough} {
+			for profile, name := range map[astc.Profile]string{astc.ProfileHDR: "hdr", astc.ProfileHDRRGBLDRAlpha: "hdr-rgb-ldr-a"} {
+				out := compatGoldenEncodeVolume(t, testimage.KindHDRSky, 48, 40, 1, [3]int{block, block, 1}, quality, profile, astc.CompatV1)
+				add(testimage.KindHDRSky, fmt.Sprintf("%dx%d", block, block), quality, name, out)
+			}
+		}
+	}
+
+	if os.Getenv("ASTC_UPDATE_COMPAT_GOLDEN") != "" {
+		slices.Sort(lines)
+		if err := os.WriteFile(compatV1Golden, []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f, err := os.Open(compatV1Golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	want := map[string]bool{}
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		want[sc.Text()] = true
+	}
+	if len(want) != len(lines) {
+		t.Fatalf("%s has %d entries, want %d", compatV1Golden, len(want), len(lines))
+	}
+	for _, l := range lines {
+		if !want[l] {
+			t.Errorf("CompatV1 output changed: %s", l)
+		}
+	}
+}
+
+func TestCompatLevelConfig(t *testing.T) {
+	// CompatLatest currently resolves to CompatV1.
+	for _, kind := range []testimage.Kind{testimage.KindPerlin, testimage.KindAlphaCutout} {
+		latest := compatGoldenEncode(t, kind, 6, astc.EncodeThorough, astc.ProfileLDR, astc.CompatLatest)
+		v1 := compatGoldenEncode(t, kind, 6, astc.EncodeThorough, astc.ProfileLDR, astc.CompatV1)
+		if string(latest) != string(v1) {
+			t.Errorf("%v: CompatLatest and CompatV1 differ", kind)
+		}
+	}
+
+	cfg, err := astc.ConfigInit(astc.ProfileLDR, 4, 4, 1, 60, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.CompatLevel = astc.CompatLevel(255)
+	if _, err := astc.ContextAlloc(&cfg, 1); !errors.Is(err, astc.ErrBadParam) {
+		t.Fatalf("ContextAlloc with unknown level: %v, want ErrBadParam", err)
+	}
+}
@@ -0,0 +1,92 @@
+package astc
+
+// blockHint holds the previous encode passed to CompressImageWithHint. Each compressing thread
+// owns its own blockHint.
+type blockHint struct {
+	profile Profile
+	ctx     *decodeContext
+	prev    []byte
+	maxMSE  float32
+
+	// Hard restrictions of the encoder tuning that reused blocks must satisfy too.
+	disallowedModes   *BlockModeMask
+	noDualPlane       bool
+	maxPartitionCount int
+
+	decoded []byte
+}
+
+func newBlockHint(profile Profile, ctx *decodeContext, prev []byte, maxMSE float32) *blockHint {
+	return &blockHint{
+		profile: profile,
+		ctx:     ctx,
+		prev:    prev,
+		maxMSE:  maxMSE,
+		decoded: make([]byte, ctx.texelCount*4),
+	}
+}
+
+// restrict makes the hint reject previous blocks that the encoder with tuning tune may not emit.
+func (h *blockHint) restrict(tune *encoderTuning) {
+	h.disallowedModes = tune.disallowedModes
+	h.noDualPlane = tune.noDualPlane
+	h.maxPartitionCount = tune.hardMaxPartitionCount
+}
+
+// permitted reports whether block satisfies the restrictions of the hint.
+func (h *blockHint) permitted(block []byte) bool {
+	if h.disallowedModes == nil && !h.noDualPlane && h.maxPartitionCount == 0 {
+		return true
+	}
+	scb := physicalToSymbolicWithCtx(block, h.ctx)
+	if scb.blockType != symBlockNonConst {
+		return true
+	}
+	if h.disallowedModes != nil && h.disallowedModes.Has(int(scb.blockMode)) {
+		return false
+	}
+	if h.noDualPlane && scb.plane2Component >= 0 {
+		return false
+	}
+	return h.maxPartitionCount == 0 || int(scb.partitionCount) <= h.maxPartitionCount
+}
+
+// blockError returns the channel-weighted mean squared error of block against texels, in 8-bit
+// units and normalized by the sum of the channel weights.
+func (h *blockHint) blockError(block []byte, texels []byte, weight [4]float32) float32 {
+	wsum := weight[0] + weight[1] + weight[2] + weight[3]
+	if !(wsum > 0) {
+		return 0
+	}
+	decodeBlockToRGBA8(h.profile, h.ctx, block, h.decoded)
+	var sum [4]uint64
+	for i := 0; i < len(texels); i += 4 {
+		for c := 0; c < 4; c++ {
+			d := int(texels[i+c]) - int(h.decoded[i+c])
+			sum[c] += uint64(d * d)
+		}
+	}
+	var err float32
+	for c := 0; c < 4; c++ {
+		err += weight[c] * float32(sum[c])
+	}
+	return err / (wsum * float32(len(texels)/4))
+}
+
+// reuse reports whether previous block i is within the threshold for texels, storing it in blk.
+func (h *blockHint) reuse(i int, texels []byte, weight [4]float32, blk *[BlockBytes]byte) bool {
+	prev := h.prev[i*BlockBytes : (i+1)*BlockBytes]
+	if !h.permitted(prev) || h.blockError(prev, texels, weight) > h.maxMSE {
+		return false
+	}
+	copy(blk[:], prev)
+	return true
+}
+
+// keepBetter replaces blk with previous block i if that has a lower error for texels.
+func (h *blockHint) keepBetter(i int, texels []byte, weight [4]float32, blk *[BlockBytes]byte) {
+	prev := h.prev[i*BlockBytes : (i+1)*BlockBytes]
+	if h.permitted(prev) && h.blockError(prev, texels, weight) < h.blockError(blk[:], texels, weight) {
+		copy(blk[:], prev)
+	}
+}
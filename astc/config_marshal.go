@@ -0,0 +1,291 @@
+package astc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+)
+
+// configJSON is the JSON form of Config. Field names follow upstream astcenc_config.
+//
+// It must declare exactly the fields of Config, in the same order, so the two types are
+// convertible; adding a Config field without updating it is a compile error.
+type configJSON struct {
+	Profile Profile `json:"profile"`
+	Flags   Flags   `json:"flags"`
+
+	BlockX uint32 `json:"block_x"`
+	BlockY uint32 `json:"block_y"`
+	BlockZ uint32 `json:"block_z"`
+
+	CWRWeight float32 `json:"cw_r_weight"`
+	CWGWeight float32 `json:"cw_g_weight"`
+	CWBWeight float32 `json:"cw_b_weight"`
+	CWAWeight float32 `json:"cw_a_weight"`
+
+	AScaleRadius uint32  `json:"a_scale_radius"`
+	RGBMMScale   float32 `json:"rgbm_m_scale"`
+
+	VarianceRadius uint32  `json:"variance_radius"`
+	VariancePower  float32 `json:"variance_power"`
+
+	TunePartitionCountLimit            uint32  `json:"tune_partition_count_limit"`
+	Tune2PartitionIndexLimit           uint32  `json:"tune_2partition_index_limit"`
+	Tune3PartitionIndexLimit           uint32  `json:"tune_3partition_index_limit"`
+	Tune4PartitionIndexLimit           uint32  `json:"tune_4partition_index_limit"`
+	TuneBlockModeLimit                 uint32  `json:"tune_block_mode_limit"`
+	TuneRefinementLimit                uint32  `json:"tune_refinement_limit"`
+	TuneCandidateLimit                 uint32  `json:"tune_candidate_limit"`
+	Tune2PartitioningCandidateLimit    uint32  `json:"tune_2partitioning_candidate_limit"`
+	Tune3PartitioningCandidateLimit    uint32  `json:"tune_3partitioning_candidate_limit"`
+	Tune4PartitioningCandidateLimit    uint32  `json:"tune_4partitioning_candidate_limit"`
+	TuneDBLimit                        float32 `json:"tune_db_limit"`
+	TuneMSEOvershoot                   float32 `json:"tune_mse_overshoot"`
+	Tune2PartitionEarlyOutLimitFactor  float32 `json:"tune_2partition_early_out_limit_factor"`
+	Tune3PartitionEarlyOutLimitFactor  float32 `json:"tune_3partition_early_out_limit_factor"`
+	Tune2PlaneEarlyOutLimitCorrelation float32 `json:"tune_2plane_early_out_limit_correlation"`
+	TuneSearchMode0Enable              float32 `json:"tune_search_mode0_enable"`
+	TuneStochasticIterations           uint32  `json:"tune_stochastic_iterations"`
+	TunePartitionNeighborSeeding       bool    `json:"tune_partition_neighbor_seeding"`
+	ExperimentalBlockErrorDiffusion    bool    `json:"experimental_block_error_diffusion"`
+	TuneColorQuantMin                  uint32  `json:"tune_color_quant_min"`
+	TuneColorQuantMax                  uint32  `json:"tune_color_quant_max"`
+	TuneWeightQuantMin                 uint32  `json:"tune_weight_quant_min"`
+	TuneWeightQuantMax                 uint32  `json:"tune_weight_quant_max"`
+
+	DisallowedBlockModes BlockModeMask `json:"disallowed_block_modes"`
+	ForcedBlockModes     BlockModeMask `json:"forced_block_modes"`
+
+	DisableDualPlane      bool   `json:"disable_dual_plane"`
+	MaxPartitionCountHard uint32 `json:"max_partition_count_hard"`
+
+	EdgeMode     EdgeMode   `json:"edge_mode"`
+	EdgePadColor [4]float32 `json:"edge_pad_color"`
+
+	InputSanitize SanitizeMode   `json:"input_sanitize"`
+	HDRInput      HDRInputPolicy `json:"hdr_input"`
+
+	BlockOrder     BlockOrder     `json:"block_order"`
+	BlockOrderFunc BlockOrderFunc `json:"-"`
+
+	DecodeOutputColorSpace ColorSpace `json:"decode_output_color_space"`
+
+	CompatLevel CompatLevel `json:"compat_level"`
+
+	WeightSampling WeightSampling `json:"weight_sampling"`
+
+	ProgressCallback func(progress float32) `json:"-"`
+
+	DecisionLog io.Writer `json:"-"`
+
+	Metrics Metrics `json:"-"`
+}
+
+// MarshalJSON encodes every Config field except BlockOrderFunc, ProgressCallback, DecisionLog and
+// Metrics.
+func (c Config) MarshalJSON() ([]byte, error) {
+	return json.Marshal(configJSON(c))
+}
+
+// UnmarshalJSON decodes a Config produced by MarshalJSON. Unknown fields are rejected so that a
+// stored configuration cannot silently lose settings; fields not present are left zero.
+// BlockOrderFunc, ProgressCallback, DecisionLog and Metrics are preserved.
+func (c *Config) UnmarshalJSON(data []byte) error {
+	var j configJSON
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&j); err != nil {
+		return err
+	}
+	j.BlockOrderFunc = c.BlockOrderFunc
+	j.ProgressCallback = c.ProgressCallback
+	j.DecisionLog = c.DecisionLog
+	j.Metrics = c.Metrics
+	*c = Config(j)
+	return nil
+}
+
+// configFieldPtrs returns pointers to the serialized Config fields, in binary encoding order.
+func configFieldPtrs(c *Config) []any {
+	return []any{
+		&c.Profile, &c.Flags, &c.BlockX, &c.BlockY, &c.BlockZ,
+		&c.CWRWeight, &c.CWGWeight, &c.CWBWeight, &c.CWAWeight,
+		&c.AScaleRadius, &c.RGBMMScale,
+		&c.VarianceRadius, &c.VariancePower,
+		&c.TunePartitionCountLimit, &c.Tune2PartitionIndexLimit, &c.Tune3PartitionIndexLimit,
+		&c.Tune4PartitionIndexLimit, &c.TuneBlockModeLimit, &c.TuneRefinementLimit,
+		&c.TuneCandidateLimit, &c.Tune2PartitioningCandidateLimit,
+		&c.Tune3PartitioningCandidateLimit, &c.Tune4PartitioningCandidateLimit,
+		&c.TuneDBLimit, &c.TuneMSEOvershoot, &c.Tune2PartitionEarlyOutLimitFactor,
+		&c.Tune3PartitionEarlyOutLimitFactor, &c.Tune2PlaneEarlyOutLimitCorrelation,
+		&c.TuneSearchMode0Enable, &c.TuneStochasticIterations,
+		&c.TunePartitionNeighborSeeding, &c.ExperimentalBlockErrorDiffusion,
+		&c.TuneColorQuantMin, &c.TuneColorQuantMax, &c.TuneWeightQuantMin, &c.TuneWeightQuantMax,
+		&c.DisallowedBlockModes, &c.ForcedBlockModes,
+		&c.DisableDualPlane, &c.MaxPartitionCountHard,
+		&c.EdgeMode, &c.EdgePadColor,
+		&c.InputSanitize, &c.HDRInput,
+		&c.BlockOrder,
+		&c.DecodeOutputColorSpace,
+		&c.CompatLevel,
+		&c.WeightSampling,
+	}
+}
+
+var configBinaryMagic = [4]byte{'A', 'C', 'F', 'G'}
+
+// configBinaryVersion is the encoding version. New fields are appended to configFieldPtrs under
+// a new version, whose decoder leaves them zero when reading older versions.
+const configBinaryVersion = 1
+
+// MarshalBinary encodes every serializable Config field into a compact little-endian form.
+// Float fields are stored as raw bits so the configuration round-trips exactly, and block mode
+// masks as a uint16 count followed by the uint16 modes. BlockOrderFunc, ProgressCallback,
+// DecisionLog and Metrics are not encoded.
+func (c Config) MarshalBinary() ([]byte, error) {
+	out := make([]byte, 0, 128)
+	out = append(out, configBinaryMagic[:]...)
+	out = append(out, configBinaryVersion)
+	for _, f := range configFieldPtrs(&c) {
+		switch p := f.(type) {
+		case *Profile:
+			out = append(out, byte(*p))
+		case *EdgeMode:
+			out = append(out, byte(*p))
+		case *ColorSpace:
+			out = append(out, byte(*p))
+		case *BlockOrder:
+			out = append(out, byte(*p))
+		case *SanitizeMode:
+			out = append(out, byte(*p))
+		case *HDRInputPolicy:
+			out = append(out, byte(*p))
+		case *CompatLevel:
+			out = append(out, byte(*p))
+		case *WeightSampling:
+			out = append(out, byte(*p))
+		case *bool:
+			if *p {
+				out = append(out, 1)
+			} else {
+				out = append(out, 0)
+			}
+		case *Flags:
+			out = binary.LittleEndian.AppendUint32(out, uint32(*p))
+		case *uint32:
+			out = binary.LittleEndian.AppendUint32(out, *p)
+		case *float32:
+			out = binary.LittleEndian.AppendUint32(out, math.Float32bits(*p))
+		case *[4]float32:
+			for _, v := range p {
+				out = binary.LittleEndian.AppendUint32(out, math.Float32bits(v))
+			}
+		case *BlockModeMask:
+			modes := p.Modes()
+			out = binary.LittleEndian.AppendUint16(out, uint16(len(modes)))
+			for _, m := range modes {
+				out = binary.LittleEndian.AppendUint16(out, uint16(m))
+			}
+		}
+	}
+	return out, nil
+}
+
+// UnmarshalBinary decodes a Config produced by MarshalBinary. BlockOrderFunc, ProgressCallback,
+// DecisionLog and Metrics are preserved.
+func (c *Config) UnmarshalBinary(data []byte) error {
+	if len(data) < 5 || !bytes.Equal(data[:4], configBinaryMagic[:]) {
+		return errors.New("astc: invalid config encoding")
+	}
+	if data[4] != configBinaryVersion {
+		return errors.New("astc: unsupported config encoding version")
+	}
+
+	var tmp Config
+	b := data[5:]
+	u32 := func() uint32 {
+		v := binary.LittleEndian.Uint32(b)
+		b = b[4:]
+		return v
+	}
+	for _, f := range configFieldPtrs(&tmp) {
+		need := 4
+		switch f.(type) {
+		case *Profile, *EdgeMode, *ColorSpace, *BlockOrder, *SanitizeMode, *HDRInputPolicy, *CompatLevel, *WeightSampling, *bool:
+			need = 1
+		case *[4]float32:
+			need = 16
+		case *BlockModeMask:
+			need = 2
+			if len(b) >= 2 {
+				need += 2 * int(binary.LittleEndian.Uint16(b))
+			}
+		}
+		if len(b) < need {
+			return ioErrUnexpectedEOF("astc config", len(data)-len(b)+need, len(data))
+		}
+		switch p := f.(type) {
+		case *Profile:
+			*p = Profile(b[0])
+			b = b[1:]
+		case *EdgeMode:
+			*p = EdgeMode(b[0])
+			b = b[1:]
+		case *ColorSpace:
+			*p = ColorSpace(b[0])
+			b = b[1:]
+		case *BlockOrder:
+			*p = BlockOrder(b[0])
+			b = b[1:]
+		case *SanitizeMode:
+			*p = SanitizeMode(b[0])
+			b = b[1:]
+		case *HDRInputPolicy:
+			*p = HDRInputPolicy(b[0])
+			b = b[1:]
+		case *CompatLevel:
+			*p = CompatLevel(b[0])
+			b = b[1:]
+		case *WeightSampling:
+			*p = WeightSampling(b[0])
+			b = b[1:]
+		case *bool:
+			*p = b[0] != 0
+			b = b[1:]
+		case *Flags:
+			*p = Flags(u32())
+		case *uint32:
+			*p = u32()
+		case *float32:
+			*p = math.Float32frombits(u32())
+		case *[4]float32:
+			for i := range p {
+				p[i] = math.Float32frombits(u32())
+			}
+		case *BlockModeMask:
+			n := int(binary.LittleEndian.Uint16(b))
+			b = b[2:]
+			for range n {
+				mode := int(binary.LittleEndian.Uint16(b))
+				b = b[2:]
+				if mode >= blockModeCount {
+					return errors.New("astc: invalid block mode in config encoding")
+				}
+				p.Set(mode)
+			}
+		}
+	}
+	if len(b) != 0 {
+		return errors.New("astc: trailing data after config encoding")
+	}
+
+	tmp.BlockOrderFunc = c.BlockOrderFunc
+	tmp.ProgressCallback = c.ProgressCallback
+	tmp.DecisionLog = c.DecisionLog
+	tmp.Metrics = c.Metrics
+	*c = tmp
+	return nil
+}
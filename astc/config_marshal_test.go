@@ -0,0 +1,110 @@
+package astc_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/arm-software/astc-encoder/astc"
+)
+
+func TestConfig_MarshalRoundTrip(t *testing.T) {
+	cfg, err := astc.ConfigInit(astc.ProfileLDR, 6, 5, 1, 98, astc.FlagUsePerceptual)
+	if err != nil {
+		t.Fatalf("ConfigInit: %v", err)
+	}
+	cfg.CWGWeight = 0.3
+	cfg.TuneDBLimit = 41.123456
+	cfg.EdgeMode = astc.EdgePad
+	cfg.EdgePadColor = [4]float32{0.25, 0.5, 0.75, 1}
+	cfg.DecodeOutputColorSpace = astc.ColorSpaceLinear
+	cfg.TuneStochasticIterations = 17
+	cfg.TuneWeightQuantMin = 12
+	cfg.TuneColorQuantMax = 64
+	cfg.DisallowedBlockModes = astc.BlockModesForErrata(astc.ErrataDualPlane, 1)
+	cfg.BlockOrder = astc.BlockOrderMorton
+	cfg.VarianceRadius, cfg.VariancePower = 3, 1.5
+	cfg.TunePartitionNeighborSeeding = true
+	cfg.InputSanitize = astc.SanitizeNeighborAverage
+	cfg.ExperimentalBlockErrorDiffusion = true
+	cfg.DisableDualPlane, cfg.MaxPartitionCountHard = true, 2
+	cfg.ForcedBlockModes.Set(66)
+	cfg.ForcedBlockModes.Set(1090)
+	cfg.HDRInput = astc.HDRFlushNaN | astc.HDRAlphaUNORM
+	cfg.CompatLevel = astc.CompatV1
+	cfg.WeightSampling = astc.WeightSamplingArea
+
+	js, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatalf("json.Marshal: %v", err)
+	}
+	var fromJSON astc.Config
+	if err := json.Unmarshal(js, &fromJSON); err != nil {
+		t.Fatalf("json.Unmarshal: %v", err)
+	}
+	if !reflect.DeepEqual(fromJSON, cfg) {
+		t.Fatalf("JSON round-trip mismatch:\n got %+v\nwant %+v", fromJSON, cfg)
+	}
+
+	bin, err := cfg.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary: %v", err)
+	}
+	var fromBin astc.Config
+	if err := fromBin.UnmarshalBinary(bin); err != nil {
+		t.Fatalf("UnmarshalBinary: %v", err)
+	}
+	if !reflect.DeepEqual(fromBin, cfg) {
+		t.Fatalf("binary round-trip mismatch:\n got %+v\nwant %+v", fromBin, cfg)
+	}
+
+	// A restored config must reproduce the encode bit-exactly.
+	const w, h = 13, 11
+	pix := make([]byte, w*h*4)
+	for i := range pix {
+		pix[i] = uint8(i * 37)
+	}
+	encode := func(c astc.Config) []byte {
+		ctx, err := astc.ContextAlloc(&c, 1)
+		if err != nil {
+			t.Fatalf("ContextAlloc: %v", err)
+		}
+		out := make([]byte, blocksLenBytes(w, h, 1, 6, 5, 1))
+		img := astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeU8, DataU8: pix}
+		if err := ctx.CompressImage(&img, astc.SwizzleRGBA, out, 0); err != nil {
+			t.Fatalf("CompressImage: %v", err)
+		}
+		return out
+	}
+	if !bytes.Equal(encode(cfg), encode(fromBin)) {
+		t.Fatalf("restored config produced different blocks")
+	}
+}
+
+func TestConfig_UnmarshalErrors(t *testing.T) {
+	var cfg astc.Config
+	if err := json.Unmarshal([]byte(`{"block_x":4,"no_such_field":1}`), &cfg); err == nil {
+		t.Fatalf("expected error for unknown JSON field")
+	}
+
+	bin, err := astc.Config{BlockX: 4, BlockY: 4, BlockZ: 1}.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary: %v", err)
+	}
+	if err := cfg.UnmarshalBinary(bin[:len(bin)-1]); err == nil {
+		t.Fatalf("expected error for truncated binary config")
+	}
+	if err := cfg.UnmarshalBinary(append(bin, 0)); err == nil {
+		t.Fatalf("expected error for trailing data")
+	}
+	if err := cfg.UnmarshalBinary([]byte("nope!")); err == nil {
+		t.Fatalf("expected error for bad magic")
+	}
+
+	unknown := append([]byte(nil), bin...)
+	unknown[4] = 2
+	if err := cfg.UnmarshalBinary(unknown); err == nil {
+		t.Fatalf("expected error for unknown version")
+	}
+}
@@ -3,7 +3,6 @@ package astc
 import (
 	"encoding/binary"
 	"errors"
-	"math"
 )
 
 const (
@@ -69,10 +68,10 @@ func DecodeConstBlockRGBA8(block []byte) (r, g, b, a uint8, err error) {
 	}
 
 	if isF16ConstBlock(block) {
-		rf := halfToFloat32(binary.LittleEndian.Uint16(block[8:10]))
-		gf := halfToFloat32(binary.LittleEndian.Uint16(block[10:12]))
-		bf := halfToFloat32(binary.LittleEndian.Uint16(block[12:14]))
-		af := halfToFloat32(binary.LittleEndian.Uint16(block[14:16]))
+		rf := HalfToFloat32(binary.LittleEndian.Uint16(block[8:10]))
+		gf := HalfToFloat32(binary.LittleEndian.Uint16(block[10:12]))
+		bf := HalfToFloat32(binary.LittleEndian.Uint16(block[12:14]))
+		af := HalfToFloat32(binary.LittleEndian.Uint16(block[14:16]))
 		return float01ToUnorm8(rf), float01ToUnorm8(gf), float01ToUnorm8(bf), float01ToUnorm8(af), nil
 	}
 
@@ -127,96 +126,3 @@ func float01ToUnorm8(v float32) uint8 {
 	}
 	return uint8(v*255 + 0.5)
 }
-
-// halfToFloat32 converts an IEEE 754 binary16 float to float32.
-//
-// This is a small helper for future FP16 constant-block support.
-func halfToFloat32(h uint16) float32 {
-	sign := uint32(h>>15) & 0x1
-	exp := uint32(h>>10) & 0x1F
-	mant := uint32(h) & 0x3FF
-
-	switch exp {
-	case 0:
-		if mant == 0 {
-			return math.Float32frombits(sign << 31)
-		}
-		// Subnormal -> normalized float32.
-		// Scale mantissa into float32 and adjust exponent.
-		e := int32(-14)
-		for (mant & 0x400) == 0 {
-			mant <<= 1
-			e--
-		}
-		mant &= 0x3FF
-		exp32 := uint32(e + 127)
-		mant32 := mant << 13
-		return math.Float32frombits((sign << 31) | (exp32 << 23) | mant32)
-	case 0x1F:
-		// Inf/NaN
-		return math.Float32frombits((sign << 31) | 0x7F800000 | (mant << 13))
-	default:
-		// Normal number.
-		exp32 := exp + (127 - 15)
-		mant32 := mant << 13
-		return math.Float32frombits((sign << 31) | (exp32 << 23) | mant32)
-	}
-}
-
-func float32ToHalf(f float32) uint16 {
-	bits := math.Float32bits(f)
-	sign := uint16((bits >> 16) & 0x8000)
-	exp := int32((bits >> 23) & 0xFF)
-	mant := bits & 0x7FFFFF
-
-	// Inf/NaN.
-	if exp == 0xFF {
-		if mant == 0 {
-			return sign | 0x7C00
-		}
-		// Quiet NaN; preserve payload bits where possible.
-		payload := uint16(mant>>13) & 0x03FF
-		if payload == 0 {
-			payload = 1
-		}
-		return sign | 0x7C00 | payload
-	}
-
-	// Convert exponent bias from 127 to 15.
-	exp = exp - 127 + 15
-
-	// Subnormals/underflow.
-	if exp <= 0 {
-		if exp < -10 {
-			// Too small -> signed zero.
-			return sign
-		}
-
-		// Convert to a subnormal half.
-		mant |= 0x800000 // implicit leading 1
-		shift := uint32(1 - exp)
-
-		// Round to nearest, ties to even.
-		roundBit := uint32(0x1000) << shift
-		mant = mant + roundBit
-		halfMant := uint16(mant >> (13 + shift))
-		return sign | halfMant
-	}
-
-	// Overflow -> inf.
-	if exp >= 0x1F {
-		return sign | 0x7C00
-	}
-
-	// Normalized number.
-	// Round to nearest, ties to even.
-	mant = mant + 0x1000
-	if mant&0x800000 != 0 {
-		mant = 0
-		exp++
-		if exp >= 0x1F {
-			return sign | 0x7C00
-		}
-	}
-	return sign | (uint16(exp) << 10) | uint16(mant>>13)
-}
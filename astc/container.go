@@ -4,13 +4,22 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"math"
 )
 
 var astcMagic = [4]byte{0x13, 0xAB, 0xA1, 0x5C}
 
 // Header is the 16-byte ASTC file header, as stored in .astc files.
 //
-// It describes the compressed block footprint and the uncompressed image size.
+// It describes the compressed block footprint and the uncompressed image size. The encoding is:
+//
+//	bytes 0-3    magic 0x13 0xAB 0xA1 0x5C
+//	bytes 4-6    BlockX, BlockY, BlockZ (one byte each)
+//	bytes 7-15   SizeX, SizeY, SizeZ (24-bit little-endian each)
+//
+// The container has a single header layout, identified by the magic; there is no version field.
+// 2D images use BlockZ = 1 and SizeZ = 1. Some legacy writers store zero in those fields instead;
+// see Normalize.
 type Header struct {
 	BlockX uint8
 	BlockY uint8
@@ -27,19 +36,41 @@ func (h Header) String() string {
 		h.SizeX, h.SizeY, h.SizeZ)
 }
 
-func (h Header) validate() error {
+// Normalize returns h with the legacy 2D encoding (BlockZ = 0, or SizeZ = 0 with a 2D block
+// footprint) rewritten as BlockZ = 1 and SizeZ = 1. Other fields are unchanged, so malformed
+// headers remain invalid.
+func (h Header) Normalize() Header {
+	if h.BlockZ == 0 {
+		h.BlockZ = 1
+	}
+	if h.SizeZ == 0 && h.BlockZ == 1 {
+		h.SizeZ = 1
+	}
+	return h
+}
+
+// Validate reports whether h describes a legal ASTC image: a block footprint allowed by the
+// specification and non-zero image dimensions that fit the 24-bit size fields. It does not accept
+// the legacy encoding handled by Normalize.
+func (h Header) Validate() error {
 	if h.BlockX == 0 || h.BlockY == 0 || h.BlockZ == 0 {
 		return errors.New("astc: invalid header: zero block dimension")
 	}
+	if err := validateBlockSize(int(h.BlockX), int(h.BlockY), int(h.BlockZ)); err != nil {
+		return fmt.Errorf("astc: invalid header: unsupported block size %dx%dx%d", h.BlockX, h.BlockY, h.BlockZ)
+	}
 	if h.SizeX == 0 || h.SizeY == 0 || h.SizeZ == 0 {
 		return errors.New("astc: invalid header: zero image dimension")
 	}
+	if h.SizeX > MaxImageDim || h.SizeY > MaxImageDim || h.SizeZ > MaxImageDim {
+		return errors.New("astc: invalid header: image dimension exceeds 24 bits")
+	}
 	return nil
 }
 
 // BlockCount returns the number of compressed blocks for this image.
 func (h Header) BlockCount() (blocksX, blocksY, blocksZ, total int, err error) {
-	if err := h.validate(); err != nil {
+	if err := h.Validate(); err != nil {
 		return 0, 0, 0, 0, err
 	}
 
@@ -60,8 +91,49 @@ func (h Header) BlockCount() (blocksX, blocksY, blocksZ, total int, err error) {
 // HeaderSize is the size in bytes of an ASTC file header.
 const HeaderSize = 16
 
-// ParseHeader parses the 16-byte ASTC file header.
+// MaxImageDim is the largest image dimension an ASTC file header can store in its 24-bit size
+// fields.
+const MaxImageDim = 1<<24 - 1
+
+// EncodedSize returns the size in bytes of the block payload of a width x height x depth image
+// with the given block footprint; a .astc file adds HeaderSize. It rejects dimensions outside
+// 1..MaxImageDim, footprints not allowed by the specification, and sizes that overflow int (which
+// larger images do on 32-bit platforms), so the result can be used to allocate buffers directly.
+func EncodedSize(width, height, depth, blockX, blockY, blockZ int) (int, error) {
+	if width <= 0 || height <= 0 || depth <= 0 {
+		return 0, errors.New("astc: invalid image dimensions")
+	}
+	if width > MaxImageDim || height > MaxImageDim || depth > MaxImageDim {
+		return 0, errors.New("astc: image dimension exceeds 24 bits")
+	}
+	if err := validateBlockSize(blockX, blockY, blockZ); err != nil {
+		return 0, fmt.Errorf("astc: unsupported block size %dx%dx%d", blockX, blockY, blockZ)
+	}
+
+	// Each count is below 2^24, so the product of two fits in 64 bits.
+	blocksX := uint64((width + blockX - 1) / blockX)
+	blocksY := uint64((height + blockY - 1) / blockY)
+	blocksZ := uint64((depth + blockZ - 1) / blockZ)
+	plane := blocksX * blocksY
+	if plane > math.MaxInt/BlockBytes/blocksZ {
+		return 0, errors.New("astc: encoded size overflows int")
+	}
+	return int(plane * blocksZ * BlockBytes), nil
+}
+
+// ParseHeader parses the 16-byte ASTC file header. Headers using the legacy 2D encoding are
+// accepted and returned normalized (see Header.Normalize).
 func ParseHeader(data []byte) (Header, error) {
+	return parseHeader(data, false)
+}
+
+// ParseHeaderStrict is ParseHeader without legacy normalization: headers must pass Header.Validate
+// as stored.
+func ParseHeaderStrict(data []byte) (Header, error) {
+	return parseHeader(data, true)
+}
+
+func parseHeader(data []byte, strict bool) (Header, error) {
 	if len(data) < HeaderSize {
 		return Header{}, ioErrUnexpectedEOF("astc header", HeaderSize, len(data))
 	}
@@ -77,7 +149,10 @@ func ParseHeader(data []byte) (Header, error) {
 		SizeY:  decodeU24LE(data[10:13]),
 		SizeZ:  decodeU24LE(data[13:16]),
 	}
-	if err := h.validate(); err != nil {
+	if !strict {
+		h = h.Normalize()
+	}
+	if err := h.Validate(); err != nil {
 		return Header{}, err
 	}
 	return h, nil
@@ -85,7 +160,7 @@ func ParseHeader(data []byte) (Header, error) {
 
 // MarshalHeader returns the 16-byte ASTC header encoding for h.
 func MarshalHeader(h Header) ([HeaderSize]byte, error) {
-	if err := h.validate(); err != nil {
+	if err := h.Validate(); err != nil {
 		return [HeaderSize]byte{}, err
 	}
 
@@ -102,9 +177,19 @@ func MarshalHeader(h Header) ([HeaderSize]byte, error) {
 
 // ParseFile parses a full .astc file.
 //
-// It returns the header and a slice of 16-byte blocks (the slice aliases data).
+// It returns the header and a slice of 16-byte blocks (the slice aliases data). Like ParseHeader,
+// it normalizes headers using the legacy 2D encoding.
 func ParseFile(data []byte) (Header, []byte, error) {
-	h, err := ParseHeader(data)
+	return parseFile(data, false)
+}
+
+// ParseFileStrict is ParseFile without legacy header normalization.
+func ParseFileStrict(data []byte) (Header, []byte, error) {
+	return parseFile(data, true)
+}
+
+func parseFile(data []byte, strict bool) (Header, []byte, error) {
+	h, err := parseHeader(data, strict)
 	if err != nil {
 		return Header{}, nil, err
 	}
@@ -118,10 +203,10 @@ func ParseFile(data []byte) (Header, []byte, error) {
 	if len(data) < need {
 		return Header{}, nil, ioErrUnexpectedEOF("astc file", need, len(data))
 	}
-	if len(data) > need {
-		// Allow trailing data but reject non-zero padding to catch accidental concatenation.
-		tail := data[need:]
-		for _, b := range tail {
+	if len(data) > need && !isPadding(data[need:]) {
+		// Allow trailing zero or WithOutputAlignment padding but reject anything else to catch
+		// accidental concatenation.
+		for _, b := range data[need:] {
 			if b != 0 {
 				return Header{}, nil, errors.New("astc: trailing non-zero data")
 			}
@@ -131,6 +216,40 @@ func ParseFile(data []byte) (Header, []byte, error) {
 	return h, data[HeaderSize:need], nil
 }
 
+// RewriteHeader applies mutator to the header of the .astc file in data and writes the result
+// back, for fixing wrong metadata (a mistyped SizeZ, a mislabeled block footprint) without
+// re-encoding the blocks. The header is rewritten in place and data is returned; data is left
+// unchanged on error.
+//
+// The rewritten header must pass Header.Validate and describe exactly as many blocks as the file
+// holds, so the payload stays consistent; trailing zero padding is kept. Headers using the legacy
+// 2D encoding are written back normalized.
+func RewriteHeader(data []byte, mutator func(*Header) error) ([]byte, error) {
+	if mutator == nil {
+		return nil, errors.New("astc: nil header mutator")
+	}
+	h, blocks, err := ParseFile(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := mutator(&h); err != nil {
+		return nil, err
+	}
+	enc, err := MarshalHeader(h)
+	if err != nil {
+		return nil, err
+	}
+	_, _, _, total, err := h.BlockCount()
+	if err != nil {
+		return nil, err
+	}
+	if total*BlockBytes != len(blocks) {
+		return nil, fmt.Errorf("astc: rewritten header describes %d blocks, file holds %d", total, len(blocks)/BlockBytes)
+	}
+	copy(data, enc[:])
+	return data, nil
+}
+
 func decodeU24LE(b []byte) uint32 {
 	// b must be at least 3 bytes.
 	_ = b[2]
@@ -140,9 +259,9 @@ func decodeU24LE(b []byte) uint32 {
 func encodeU24LE(dst []byte, v uint32) {
 	// dst must be at least 3 bytes.
 	_ = dst[2]
-	if v > 0xFFFFFF {
-		// Clamp rather than error; the caller's validate() should have caught this already.
-		v = 0xFFFFFF
+	if v > MaxImageDim {
+		// Clamp rather than error; the caller's Validate() should have caught this already.
+		v = MaxImageDim
 	}
 	dst[0] = byte(v)
 	dst[1] = byte(v >> 8)
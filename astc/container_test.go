@@ -2,6 +2,7 @@ package astc_test
 
 import (
 	"bytes"
+	"errors"
 	"testing"
 
 	"github.com/arm-software/astc-encoder/astc"
@@ -35,3 +36,194 @@ func TestHeaderRoundTrip(t *testing.T) {
 		t.Fatalf("unexpected magic: %x", enc[0:4])
 	}
 }
+
+func TestHeader_NormalizeValidate(t *testing.T) {
+	valid := astc.Header{BlockX: 6, BlockY: 6, BlockZ: 1, SizeX: 100, SizeY: 50, SizeZ: 1}
+	tests := []struct {
+		name       string
+		h          astc.Header
+		normalized astc.Header
+		valid      bool // after Normalize
+	}{
+		{"valid 2D", valid, valid, true},
+		{"valid 3D", astc.Header{BlockX: 4, BlockY: 4, BlockZ: 4, SizeX: 8, SizeY: 8, SizeZ: 8}, astc.Header{BlockX: 4, BlockY: 4, BlockZ: 4, SizeX: 8, SizeY: 8, SizeZ: 8}, true},
+		{"legacy BlockZ 0", astc.Header{BlockX: 6, BlockY: 6, SizeX: 100, SizeY: 50, SizeZ: 1}, valid, true},
+		{"legacy SizeZ 0", astc.Header{BlockX: 6, BlockY: 6, BlockZ: 1, SizeX: 100, SizeY: 50}, valid, true},
+		{"legacy both 0", astc.Header{BlockX: 6, BlockY: 6, SizeX: 100, SizeY: 50}, valid, true},
+		{"3D SizeZ 0", astc.Header{BlockX: 4, BlockY: 4, BlockZ: 4, SizeX: 8, SizeY: 8}, astc.Header{BlockX: 4, BlockY: 4, BlockZ: 4, SizeX: 8, SizeY: 8}, false},
+		{"zero BlockX", astc.Header{BlockY: 4, BlockZ: 1, SizeX: 8, SizeY: 8, SizeZ: 1}, astc.Header{BlockY: 4, BlockZ: 1, SizeX: 8, SizeY: 8, SizeZ: 1}, false},
+		{"zero BlockY", astc.Header{BlockX: 4, BlockZ: 1, SizeX: 8, SizeY: 8, SizeZ: 1}, astc.Header{BlockX: 4, BlockZ: 1, SizeX: 8, SizeY: 8, SizeZ: 1}, false},
+		{"zero SizeX", astc.Header{BlockX: 4, BlockY: 4, BlockZ: 1, SizeY: 8, SizeZ: 1}, astc.Header{BlockX: 4, BlockY: 4, BlockZ: 1, SizeY: 8, SizeZ: 1}, false},
+		{"zero SizeY", astc.Header{BlockX: 4, BlockY: 4, BlockZ: 1, SizeX: 8, SizeZ: 1}, astc.Header{BlockX: 4, BlockY: 4, BlockZ: 1, SizeX: 8, SizeZ: 1}, false},
+		{"illegal 2D footprint", astc.Header{BlockX: 7, BlockY: 7, BlockZ: 1, SizeX: 8, SizeY: 8, SizeZ: 1}, astc.Header{BlockX: 7, BlockY: 7, BlockZ: 1, SizeX: 8, SizeY: 8, SizeZ: 1}, false},
+		{"illegal 3D footprint", astc.Header{BlockX: 4, BlockY: 5, BlockZ: 4, SizeX: 8, SizeY: 8, SizeZ: 8}, astc.Header{BlockX: 4, BlockY: 5, BlockZ: 4, SizeX: 8, SizeY: 8, SizeZ: 8}, false},
+		{"oversized block", astc.Header{BlockX: 255, BlockY: 255, BlockZ: 1, SizeX: 8, SizeY: 8, SizeZ: 1}, astc.Header{BlockX: 255, BlockY: 255, BlockZ: 1, SizeX: 8, SizeY: 8, SizeZ: 1}, false},
+		{"size over 24 bits", astc.Header{BlockX: 4, BlockY: 4, BlockZ: 1, SizeX: 1 << 24, SizeY: 8, SizeZ: 1}, astc.Header{BlockX: 4, BlockY: 4, BlockZ: 1, SizeX: 1 << 24, SizeY: 8, SizeZ: 1}, false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := tc.h.Normalize()
+			if got != tc.normalized {
+				t.Fatalf("Normalize = %+v, want %+v", got, tc.normalized)
+			}
+			if err := got.Validate(); (err == nil) != tc.valid {
+				t.Fatalf("Validate(%+v) = %v, want valid=%v", got, err, tc.valid)
+			}
+			if tc.h != got {
+				if err := tc.h.Validate(); err == nil {
+					t.Fatalf("Validate accepted legacy header %+v", tc.h)
+				}
+			}
+		})
+	}
+}
+
+func TestParseFile_LegacyHeader(t *testing.T) {
+	const blocks = 4
+	enc := func(bz byte, sz uint32) []byte {
+		data := make([]byte, astc.HeaderSize+blocks*astc.BlockBytes)
+		copy(data, []byte{0x13, 0xAB, 0xA1, 0x5C, 4, 4, bz, 8, 0, 0, 8, 0, 0, byte(sz), 0, 0})
+		return data
+	}
+	want := astc.Header{BlockX: 4, BlockY: 4, BlockZ: 1, SizeX: 8, SizeY: 8, SizeZ: 1}
+
+	for _, tc := range []struct {
+		bz byte
+		sz uint32
+	}{{1, 1}, {0, 1}, {1, 0}, {0, 0}} {
+		data := enc(tc.bz, tc.sz)
+		h, b, err := astc.ParseFile(data)
+		if err != nil {
+			t.Fatalf("ParseFile(BlockZ=%d, SizeZ=%d): %v", tc.bz, tc.sz, err)
+		}
+		if h != want || len(b) != blocks*astc.BlockBytes {
+			t.Fatalf("ParseFile(BlockZ=%d, SizeZ=%d) = %+v, %d bytes", tc.bz, tc.sz, h, len(b))
+		}
+
+		_, _, err = astc.ParseFileStrict(data)
+		if legacy := tc.bz == 0 || tc.sz == 0; (err != nil) != legacy {
+			t.Fatalf("ParseFileStrict(BlockZ=%d, SizeZ=%d): %v", tc.bz, tc.sz, err)
+		}
+		if _, err := astc.ParseHeaderStrict(data); (err != nil) != (tc.bz == 0 || tc.sz == 0) {
+			t.Fatalf("ParseHeaderStrict(BlockZ=%d, SizeZ=%d): %v", tc.bz, tc.sz, err)
+		}
+	}
+
+	// Normalized headers are re-encoded in the standard form.
+	h, err := astc.ParseHeader(enc(0, 0))
+	if err != nil {
+		t.Fatalf("ParseHeader: %v", err)
+	}
+	out, err := astc.MarshalHeader(h)
+	if err != nil {
+		t.Fatalf("MarshalHeader: %v", err)
+	}
+	if !bytes.Equal(out[:], enc(1, 1)[:astc.HeaderSize]) {
+		t.Fatalf("MarshalHeader = %x", out)
+	}
+	if _, err := astc.MarshalHeader(astc.Header{BlockX: 4, BlockY: 4, SizeX: 8, SizeY: 8}); err == nil {
+		t.Fatalf("MarshalHeader accepted a legacy header")
+	}
+}
+
+func TestRewriteHeader(t *testing.T) {
+	// 8x8x2 texels in 4x4x1 blocks: 8 blocks, which also fit 8x8x1 in 4x4x2 blocks.
+	file := func() []byte {
+		h, err := astc.MarshalHeader(astc.Header{BlockX: 4, BlockY: 4, BlockZ: 1, SizeX: 8, SizeY: 8, SizeZ: 2})
+		if err != nil {
+			t.Fatalf("MarshalHeader: %v", err)
+		}
+		data := make([]byte, astc.HeaderSize+8*astc.BlockBytes)
+		copy(data, h[:])
+		for i := astc.HeaderSize; i < len(data); i++ {
+			data[i] = byte(i)
+		}
+		return data
+	}
+
+	data := file()
+	out, err := astc.RewriteHeader(data, func(h *astc.Header) error {
+		h.SizeX, h.SizeY = 16, 4
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("RewriteHeader: %v", err)
+	}
+	h, blocks, err := astc.ParseFile(out)
+	if err != nil {
+		t.Fatalf("ParseFile: %v", err)
+	}
+	if want := (astc.Header{BlockX: 4, BlockY: 4, BlockZ: 1, SizeX: 16, SizeY: 4, SizeZ: 2}); h != want {
+		t.Fatalf("rewritten header = %+v, want %+v", h, want)
+	}
+	if !bytes.Equal(blocks, file()[astc.HeaderSize:]) {
+		t.Fatalf("RewriteHeader changed the blocks")
+	}
+	if &out[0] != &data[0] {
+		t.Fatalf("RewriteHeader did not rewrite in place")
+	}
+
+	errMutator := errors.New("mutator failed")
+	for _, tc := range []struct {
+		name string
+		fn   func(*astc.Header) error
+	}{
+		{"block count", func(h *astc.Header) error { h.SizeZ = 3; return nil }},
+		{"invalid footprint", func(h *astc.Header) error { h.BlockX = 7; return nil }},
+		{"zero size", func(h *astc.Header) error { h.SizeX = 0; return nil }},
+		{"mutator error", func(h *astc.Header) error { h.SizeZ = 1; return errMutator }},
+	} {
+		data := file()
+		_, err := astc.RewriteHeader(data, tc.fn)
+		if err == nil {
+			t.Fatalf("%s: RewriteHeader succeeded", tc.name)
+		}
+		if tc.name == "mutator error" && !errors.Is(err, errMutator) {
+			t.Fatalf("%s: RewriteHeader = %v, want the mutator's error", tc.name, err)
+		}
+		if !bytes.Equal(data, file()) {
+			t.Fatalf("%s: RewriteHeader modified data on error", tc.name)
+		}
+	}
+	if _, err := astc.RewriteHeader(file(), nil); err == nil {
+		t.Fatalf("RewriteHeader accepted a nil mutator")
+	}
+}
+
+func TestEncodedSize(t *testing.T) {
+	for _, tc := range []struct {
+		w, h, d, bx, by, bz int
+		want                int
+	}{
+		{1, 1, 1, 4, 4, 1, 16},
+		{17, 9, 1, 8, 8, 1, 3 * 2 * 16},
+		{10, 10, 5, 3, 3, 3, 4 * 4 * 2 * 16},
+		{astc.MaxImageDim, 1, 1, 12, 12, 1, (astc.MaxImageDim + 11) / 12 * 16},
+	} {
+		got, err := astc.EncodedSize(tc.w, tc.h, tc.d, tc.bx, tc.by, tc.bz)
+		if err != nil || got != tc.want {
+			t.Fatalf("EncodedSize(%d,%d,%d,%d,%d,%d)=%d,%v want %d", tc.w, tc.h, tc.d, tc.bx, tc.by, tc.bz, got, err, tc.want)
+		}
+		_, _, _, total, err := astc.Header{
+			BlockX: uint8(tc.bx), BlockY: uint8(tc.by), BlockZ: uint8(tc.bz),
+			SizeX: uint32(tc.w), SizeY: uint32(tc.h), SizeZ: uint32(tc.d),
+		}.BlockCount()
+		if err != nil || total*astc.BlockBytes != got {
+			t.Fatalf("BlockCount disagrees: %d blocks, %v", total, err)
+		}
+	}
+
+	for _, tc := range [][6]int{
+		{0, 1, 1, 4, 4, 1},
+		{1, -1, 1, 4, 4, 1},
+		{astc.MaxImageDim + 1, 1, 1, 4, 4, 1},
+		{8, 8, 1, 4, 7, 1},
+		{8, 8, 8, 4, 4, 2},
+		// 2^72 texels overflow any int.
+		{astc.MaxImageDim, astc.MaxImageDim, astc.MaxImageDim, 3, 3, 3},
+	} {
+		if _, err := astc.EncodedSize(tc[0], tc[1], tc[2], tc[3], tc[4], tc[5]); err == nil {
+			t.Fatalf("EncodedSize%v: expected error", tc)
+		}
+	}
+}
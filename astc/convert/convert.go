@@ -0,0 +1,348 @@
+package convert
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/jpeg" // Register the default source formats.
+	_ "image/png"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/arm-software/astc-encoder/astc"
+)
+
+// ManifestName is the name of the file ConvertTree keeps in the destination directory to detect
+// up-to-date outputs.
+const ManifestName = ".astc-convert.json"
+
+// DefaultExtensions are the source file extensions ConvertTree converts when Options.Extensions
+// is empty.
+var DefaultExtensions = []string{".png", ".jpg", ".jpeg"}
+
+// Options configures ConvertTree.
+type Options struct {
+	// EncoderOptions are passed to astc.NewEncoder. They are preceded by astc.WithWorkers(1),
+	// since files are already encoded in parallel.
+	EncoderOptions []astc.Option
+	// Workers is the number of files converted concurrently; <= 0 uses GOMAXPROCS.
+	Workers int
+	// Extensions lists the source file extensions to convert, matched without regard to case
+	// (default DefaultExtensions). Sources are decoded with image.Decode, so other formats need
+	// their decoder registered.
+	Extensions []string
+	// Force converts every source, even when its output is up to date.
+	Force bool
+}
+
+// Stats summarizes a ConvertTree run.
+type Stats struct {
+	Converted int // Files encoded and written.
+	Skipped   int // Files whose output was up to date.
+	Failed    int // Files that could not be converted (see the returned error).
+
+	SourceBytes int64 // Total size of the converted sources.
+	OutputBytes int64 // Total size of the written .astc files.
+
+	Elapsed time.Duration
+}
+
+// FileError is the error of one source file; ConvertTree joins them into its error.
+type FileError struct {
+	Path string // Path of the source, relative to the source directory.
+	Err  error
+}
+
+func (e *FileError) Error() string { return "astc/convert: " + e.Path + ": " + e.Err.Error() }
+
+func (e *FileError) Unwrap() error { return e.Err }
+
+// manifest is the content of ManifestName: the source hash of each output, keyed by the slash
+// separated source path relative to the source directory.
+type manifest struct {
+	Version int               `json:"version"`
+	Files   map[string]string `json:"files"`
+}
+
+const manifestVersion = 1
+
+// result is the outcome of one file.
+type result struct {
+	rel      string
+	hash     string
+	skipped  bool
+	srcBytes int64
+	outBytes int64
+	err      error
+}
+
+// ConvertTree encodes every image below srcDir whose extension is in opts.Extensions to a .astc
+// file at the same relative path below dstDir, with the extension replaced by ".astc", creating
+// directories as needed. The two directories may be the same.
+//
+// An output is up to date, and skipped, when it exists and the manifest in dstDir records the
+// same hash for its source: the SHA-256 of the source content and of the encoder Config. Options
+// of the encoder outside its Config (such as the swizzle or output alignment) are not part of the
+// hash, so use Force after changing them.
+//
+// Files that fail to convert are counted in Stats.Failed and do not stop the others; their
+// *FileError values are joined into the returned error. If ctx is canceled the files in flight
+// are abandoned and ctx.Err() is returned. The manifest is rewritten at the end either way, and
+// keeps the entries of files not visited by a canceled run.
+func ConvertTree(ctx context.Context, srcDir, dstDir string, opts Options) (Stats, error) {
+	start := time.Now()
+	enc, err := astc.NewEncoder(append([]astc.Option{astc.WithWorkers(1)}, opts.EncoderOptions...)...)
+	if err != nil {
+		return Stats{}, err
+	}
+	key, err := enc.Config().MarshalBinary()
+	if err != nil {
+		return Stats{}, err
+	}
+	exts := opts.Extensions
+	if len(exts) == 0 {
+		exts = DefaultExtensions
+	}
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	prev := readManifest(dstDir)
+	next := maps.Clone(prev)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	jobs := make(chan string, workers)
+	results := make(chan result, workers)
+
+	var walkErr error
+	var collisions []error
+	visited := map[string]bool{}
+	go func() {
+		defer close(jobs)
+		outputs := map[string]string{}
+		walkErr = filepath.WalkDir(srcDir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() || !hasExtension(path, exts) {
+				return nil
+			}
+			rel, err := filepath.Rel(srcDir, path)
+			if err != nil {
+				return err
+			}
+			rel = filepath.ToSlash(rel)
+			out := outputPath(rel)
+			if other, ok := outputs[out]; ok {
+				collisions = append(collisions, &FileError{Path: rel, Err: fmt.Errorf("output %s is also the output of %s", out, other)})
+				return nil
+			}
+			outputs[out] = rel
+			visited[rel] = true
+			select {
+			case jobs <- rel:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+	}()
+
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for rel := range jobs {
+				results <- convertFile(ctx, enc, key, srcDir, dstDir, rel, prev[rel], opts.Force)
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var stats Stats
+	var errs []error
+	for r := range results {
+		switch {
+		case r.err != nil:
+			if ctx.Err() != nil {
+				continue
+			}
+			stats.Failed++
+			errs = append(errs, &FileError{Path: r.rel, Err: r.err})
+			delete(next, r.rel)
+		case r.skipped:
+			stats.Skipped++
+		default:
+			stats.Converted++
+			stats.SourceBytes += r.srcBytes
+			stats.OutputBytes += r.outBytes
+			next[r.rel] = r.hash
+		}
+	}
+	stats.Failed += len(collisions)
+	errs = append(collisions, errs...)
+
+	canceled := ctx.Err()
+	if canceled == nil && walkErr == nil {
+		// Drop the entries of sources that no longer exist.
+		for rel := range next {
+			if !visited[rel] {
+				delete(next, rel)
+			}
+		}
+	}
+	if !maps.Equal(prev, next) {
+		if err := writeManifest(dstDir, next); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	stats.Elapsed = time.Since(start)
+
+	if canceled != nil {
+		return stats, canceled
+	}
+	if walkErr != nil {
+		errs = append([]error{walkErr}, errs...)
+	}
+	return stats, errors.Join(errs...)
+}
+
+// convertFile converts the source rel unless its output is up to date with prevHash.
+func convertFile(ctx context.Context, enc *astc.Encoder, key []byte, srcDir, dstDir, rel, prevHash string, force bool) result {
+	r := result{rel: rel}
+	src := filepath.Join(srcDir, filepath.FromSlash(rel))
+	data, err := os.ReadFile(src)
+	if err != nil {
+		r.err = err
+		return r
+	}
+	info, err := os.Stat(src)
+	if err != nil {
+		r.err = err
+		return r
+	}
+	sum := sha256.New()
+	sum.Write(key)
+	sum.Write(data)
+	r.hash = hex.EncodeToString(sum.Sum(nil))
+
+	dst := filepath.Join(dstDir, filepath.FromSlash(outputPath(rel)))
+	if !force && r.hash == prevHash {
+		if _, err := os.Stat(dst); err == nil {
+			r.skipped = true
+			return r
+		}
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		r.err = err
+		return r
+	}
+	b := img.Bounds()
+	pix := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(pix, pix.Bounds(), img, b.Min, draw.Src)
+	out, err := enc.EncodeCtx(ctx, &astc.Image{DimX: b.Dx(), DimY: b.Dy(), DimZ: 1, DataType: astc.TypeU8, DataU8: pix.Pix})
+	if err != nil {
+		r.err = err
+		return r
+	}
+	if err := writeFileAtomic(dst, out, info.ModTime()); err != nil {
+		r.err = err
+		return r
+	}
+	r.srcBytes = int64(len(data))
+	r.outBytes = int64(len(out))
+	return r
+}
+
+// outputPath returns the slash separated output path of the source rel.
+func outputPath(rel string) string {
+	return strings.TrimSuffix(rel, filepath.Ext(rel)) + ".astc"
+}
+
+func hasExtension(path string, exts []string) bool {
+	ext := filepath.Ext(path)
+	for _, e := range exts {
+		if strings.EqualFold(ext, e) {
+			return true
+		}
+	}
+	return false
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames it into place. A
+// non-zero mtime becomes the modification time of the file.
+func writeFileAtomic(path string, data []byte, mtime time.Time) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(tmp, 0o644)
+	}
+	if err == nil && !mtime.IsZero() {
+		err = os.Chtimes(tmp, mtime, mtime)
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+	}
+	return err
+}
+
+// readManifest returns the source hashes recorded in dir, or an empty map if there is no valid
+// manifest.
+func readManifest(dir string) map[string]string {
+	files := map[string]string{}
+	data, err := os.ReadFile(filepath.Join(dir, ManifestName))
+	if err != nil {
+		return files
+	}
+	var m manifest
+	if json.Unmarshal(data, &m) != nil || m.Version != manifestVersion {
+		return files
+	}
+	maps.Copy(files, m.Files)
+	return files
+}
+
+func writeManifest(dir string, files map[string]string) error {
+	data, err := json.MarshalIndent(manifest{Version: manifestVersion, Files: files}, "", "\t")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(dir, ManifestName), append(data, '\n'), time.Time{})
+}
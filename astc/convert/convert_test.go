@@ -0,0 +1,123 @@
+package convert_test
+
+import (
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/arm-software/astc-encoder/astc"
+	"github.com/arm-software/astc-encoder/astc/convert"
+)
+
+func writePNG(t *testing.T, path string, w, h int, shade uint8) {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			img.SetNRGBA(x, y, color.NRGBA{uint8(x * 9), uint8(y * 7), shade, 200})
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := png.Encode(f, img); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConvertTree(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	writePNG(t, filepath.Join(src, "a.png"), 20, 12, 10)
+	writePNG(t, filepath.Join(src, "sub", "deeper", "B.PNG"), 9, 30, 90)
+	if err := os.WriteFile(filepath.Join(src, "broken.png"), []byte("not a png"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "notes.txt"), []byte("ignored"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	mtime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := os.Chtimes(filepath.Join(src, "a.png"), mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := convert.Options{EncoderOptions: []astc.Option{astc.WithBlockSize(6, 6), astc.WithQuality(astc.EncodeFastest)}, Workers: 2}
+	run := func(opts convert.Options) convert.Stats {
+		t.Helper()
+		stats, err := convert.ConvertTree(context.Background(), src, dst, opts)
+		var fe *convert.FileError
+		if !errors.As(err, &fe) || fe.Path != "broken.png" {
+			t.Fatalf("ConvertTree error = %v, want a FileError for broken.png", err)
+		}
+		if stats.Failed != 1 {
+			t.Fatalf("Failed = %d, want 1", stats.Failed)
+		}
+		return stats
+	}
+
+	stats := run(opts)
+	if stats.Converted != 2 || stats.Skipped != 0 || stats.SourceBytes == 0 || stats.OutputBytes == 0 {
+		t.Fatalf("first run: %+v", stats)
+	}
+	for _, tc := range []struct {
+		path string
+		w, h uint32
+	}{{"a.astc", 20, 12}, {"sub/deeper/B.astc", 9, 30}} {
+		data, err := os.ReadFile(filepath.Join(dst, tc.path))
+		if err != nil {
+			t.Fatal(err)
+		}
+		h, err := astc.ParseHeader(data)
+		if err != nil || h.SizeX != tc.w || h.SizeY != tc.h || h.BlockX != 6 {
+			t.Fatalf("%s: header %+v, %v", tc.path, h, err)
+		}
+	}
+	if info, err := os.Stat(filepath.Join(dst, "a.astc")); err != nil || !info.ModTime().Equal(mtime) {
+		t.Fatalf("a.astc mtime = %v, want %v (%v)", info.ModTime(), mtime, err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "notes.astc")); !os.IsNotExist(err) {
+		t.Fatalf("notes.txt was converted: %v", err)
+	}
+
+	if stats := run(opts); stats.Converted != 0 || stats.Skipped != 2 {
+		t.Fatalf("second run: %+v", stats)
+	}
+	writePNG(t, filepath.Join(src, "a.png"), 20, 12, 11)
+	if stats := run(opts); stats.Converted != 1 || stats.Skipped != 1 {
+		t.Fatalf("run after editing a.png: %+v", stats)
+	}
+	// A different encoder configuration invalidates every output, as does Force.
+	changed := opts
+	changed.EncoderOptions = append(changed.EncoderOptions, astc.WithQuality(astc.EncodeFast))
+	if stats := run(changed); stats.Converted != 2 {
+		t.Fatalf("run with new settings: %+v", stats)
+	}
+	changed.Force = true
+	if stats := run(changed); stats.Converted != 2 {
+		t.Fatalf("forced run: %+v", stats)
+	}
+	// Outputs deleted behind the manifest's back are rebuilt.
+	if err := os.Remove(filepath.Join(dst, "a.astc")); err != nil {
+		t.Fatal(err)
+	}
+	changed.Force = false
+	if stats := run(changed); stats.Converted != 1 || stats.Skipped != 1 {
+		t.Fatalf("run after deleting an output: %+v", stats)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := convert.ConvertTree(ctx, src, dst, opts); !errors.Is(err, context.Canceled) {
+		t.Fatalf("canceled ConvertTree error = %v", err)
+	}
+}
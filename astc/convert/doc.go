@@ -0,0 +1,10 @@
+// Package convert cooks directory trees of images into .astc files: the discovery, incremental
+// rebuild and bookkeeping code usually written around the astc package for asset pipelines.
+//
+// ConvertTree walks a source directory while a pool of workers decodes, encodes and writes the
+// images it finds, so discovery, file I/O and encoding overlap. Each output is written to a
+// temporary file and renamed into place, so readers never see a partial file, and takes the
+// modification time of its source. A manifest in the destination directory records the SHA-256
+// of every source together with the encoder configuration, and files whose hash is unchanged
+// since the last run are skipped.
+package convert
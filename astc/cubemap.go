@@ -0,0 +1,204 @@
+package astc
+
+import (
+	"errors"
+	"math"
+)
+
+// CubeFace identifies a cubemap face. Cubemaps are stored with their faces in this order, which is
+// the layer order used by OpenGL, Vulkan, D3D and KTX.
+type CubeFace uint8
+
+const (
+	CubeFacePosX CubeFace = iota
+	CubeFaceNegX
+	CubeFacePosY
+	CubeFaceNegY
+	CubeFacePosZ
+	CubeFaceNegZ
+)
+
+// CubeFaceCount is the number of faces of a cubemap.
+const CubeFaceCount = 6
+
+// String returns the face name, e.g. "+X".
+func (f CubeFace) String() string {
+	switch f {
+	case CubeFacePosX:
+		return "+X"
+	case CubeFaceNegX:
+		return "-X"
+	case CubeFacePosY:
+		return "+Y"
+	case CubeFaceNegY:
+		return "-Y"
+	case CubeFacePosZ:
+		return "+Z"
+	case CubeFaceNegZ:
+		return "-Z"
+	default:
+		return "invalid"
+	}
+}
+
+// IsCubemap reports whether h has the cubemap layout written by EncodeCubemapRGBA8: square faces
+// stored as six slices of 2D blocks. The .astc header has no cubemap flag, so a six-layer square
+// array texture has the same layout.
+func IsCubemap(h Header) bool {
+	h = h.Normalize()
+	return h.SizeX == h.SizeY && h.SizeZ == CubeFaceCount && h.BlockZ == 1
+}
+
+// EncodeCubemapRGBA8 encodes six square edge x edge RGBA8 faces, indexed by CubeFace, into one .astc
+// file holding the faces as consecutive slices of 2D blocks (see IsCubemap).
+//
+// With seamFixup, the texels along each face edge are averaged with the texels across the seam on
+// the neighboring faces (three faces at cube corners) before encoding, so that bilinear filtering
+// without seamless cubemap support shows no discontinuity at the seams. The faces are not modified.
+func EncodeCubemapRGBA8(faces [CubeFaceCount][]byte, edge, blockX, blockY int, profile Profile, quality EncodeQuality, seamFixup bool) ([]byte, error) {
+	if edge <= 0 {
+		return nil, errors.New("astc: invalid cubemap edge")
+	}
+	faceLen := edge * edge * 4
+	for _, f := range faces {
+		if len(f) != faceLen {
+			return nil, errors.New("astc: cubemap faces must all be edge x edge RGBA8 images")
+		}
+	}
+
+	pix := make([]byte, CubeFaceCount*faceLen)
+	for i, f := range faces {
+		copy(pix[i*faceLen:], f)
+	}
+	if seamFixup {
+		fixCubemapSeamsRGBA8(pix, faces, edge)
+	}
+	return EncodeRGBA8VolumeWithProfileAndQuality(pix, edge, edge, CubeFaceCount, blockX, blockY, 1, profile, quality)
+}
+
+// DecodeCubemapRGBA8 decodes a cubemap written by EncodeCubemapRGBA8 into six RGBA8 faces, indexed
+// by CubeFace. It fails if the file does not have the cubemap layout.
+func DecodeCubemapRGBA8(astcData []byte, profile Profile) (faces [CubeFaceCount][]byte, edge int, err error) {
+	h, err := ParseHeader(astcData)
+	if err != nil {
+		return faces, 0, err
+	}
+	if !IsCubemap(h) {
+		return faces, 0, errors.New("astc: not a cubemap (want six square slices of 2D blocks)")
+	}
+	pix, width, _, _, err := DecodeRGBA8VolumeWithProfile(astcData, profile)
+	if err != nil {
+		return faces, 0, err
+	}
+	faceLen := width * width * 4
+	for i := range faces {
+		faces[i] = pix[i*faceLen : (i+1)*faceLen : (i+1)*faceLen]
+	}
+	return faces, width, nil
+}
+
+// fixCubemapSeamsRGBA8 replaces each edge texel of the faces stored in dst with the rounded
+// average of itself and the texels across its edges, read from the unmodified src faces.
+func fixCubemapSeamsRGBA8(dst []byte, src [CubeFaceCount][]byte, edge int) {
+	faceLen := edge * edge * 4
+	for f := 0; f < CubeFaceCount; f++ {
+		for y := 0; y < edge; y++ {
+			for x := 0; x < edge; x++ {
+				if x > 0 && x < edge-1 && y > 0 && y < edge-1 {
+					continue
+				}
+
+				// Texels across the seam are found by stepping one texel past the edge.
+				var steps [2][2]int
+				n := 0
+				if x == 0 {
+					steps[n] = [2]int{-1, 0}
+					n++
+				} else if x == edge-1 {
+					steps[n] = [2]int{1, 0}
+					n++
+				}
+				if y == 0 {
+					steps[n] = [2]int{0, -1}
+					n++
+				} else if y == edge-1 {
+					steps[n] = [2]int{0, 1}
+					n++
+				}
+
+				var sum [4]int
+				off := (y*edge + x) * 4
+				for c := 0; c < 4; c++ {
+					sum[c] = int(src[f][off+c])
+				}
+				for _, s := range steps[:n] {
+					nf, nx, ny := cubeTexelAcrossSeam(CubeFace(f), x+s[0], y+s[1], edge)
+					noff := (ny*edge + nx) * 4
+					for c := 0; c < 4; c++ {
+						sum[c] += int(src[nf][noff+c])
+					}
+				}
+				count := n + 1
+				for c := 0; c < 4; c++ {
+					dst[f*faceLen+off+c] = uint8((sum[c] + count/2) / count)
+				}
+			}
+		}
+	}
+}
+
+// cubeTexelAcrossSeam maps the texel position (x, y) of face f, which lies one texel outside the
+// face, to the face and texel it falls on.
+func cubeTexelAcrossSeam(f CubeFace, x, y, edge int) (CubeFace, int, int) {
+	u := (2*float64(x)+1)/float64(edge) - 1
+	v := (2*float64(y)+1)/float64(edge) - 1
+	dx, dy, dz := cubeFaceDirection(f, u, v)
+	nf, nu, nv := cubeDirectionFace(dx, dy, dz)
+	toTexel := func(c float64) int {
+		t := int(math.Floor((c + 1) / 2 * float64(edge)))
+		return min(max(t, 0), edge-1)
+	}
+	return nf, toTexel(nu), toTexel(nv)
+}
+
+// cubeFaceDirection returns the direction of face coordinates (u, v) in -1..1 of face f, with u
+// increasing along texel rows and v down the face, following the OpenGL cubemap convention.
+func cubeFaceDirection(f CubeFace, u, v float64) (x, y, z float64) {
+	switch f {
+	case CubeFacePosX:
+		return 1, -v, -u
+	case CubeFaceNegX:
+		return -1, -v, u
+	case CubeFacePosY:
+		return u, 1, v
+	case CubeFaceNegY:
+		return u, -1, -v
+	case CubeFacePosZ:
+		return u, -v, 1
+	default:
+		return -u, -v, -1
+	}
+}
+
+// cubeDirectionFace is the inverse of cubeFaceDirection: it selects the face by the major axis of
+// the direction and returns the face coordinates.
+func cubeDirectionFace(x, y, z float64) (f CubeFace, u, v float64) {
+	ax, ay, az := math.Abs(x), math.Abs(y), math.Abs(z)
+	switch {
+	case ax >= ay && ax >= az:
+		if x > 0 {
+			return CubeFacePosX, -z / ax, -y / ax
+		}
+		return CubeFaceNegX, z / ax, -y / ax
+	case ay >= az:
+		if y > 0 {
+			return CubeFacePosY, x / ay, z / ay
+		}
+		return CubeFaceNegY, x / ay, -z / ay
+	default:
+		if z > 0 {
+			return CubeFacePosZ, x / az, -y / az
+		}
+		return CubeFaceNegZ, -x / az, -y / az
+	}
+}
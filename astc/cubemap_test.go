@@ -0,0 +1,120 @@
+package astc_test
+
+import (
+	"testing"
+
+	"github.com/arm-software/astc-encoder/astc"
+)
+
+func cubemapTestFaces(edge int) (faces [astc.CubeFaceCount][]byte, colors [astc.CubeFaceCount][4]byte) {
+	colors = [astc.CubeFaceCount][4]byte{
+		{240, 20, 20, 255}, {20, 240, 20, 255}, {20, 20, 240, 255},
+		{240, 240, 20, 255}, {20, 240, 240, 255}, {240, 20, 240, 255},
+	}
+	for f := range faces {
+		faces[f] = make([]byte, edge*edge*4)
+		for i := 0; i < edge*edge; i++ {
+			copy(faces[f][i*4:], colors[f][:])
+		}
+	}
+	return faces, colors
+}
+
+func TestCubemapRGBA8_RoundTrip(t *testing.T) {
+	const edge = 16
+	faces, colors := cubemapTestFaces(edge)
+
+	data, err := astc.EncodeCubemapRGBA8(faces, edge, 4, 4, astc.ProfileLDR, astc.EncodeMedium, false)
+	if err != nil {
+		t.Fatalf("EncodeCubemapRGBA8: %v", err)
+	}
+	h, err := astc.ParseHeader(data)
+	if err != nil {
+		t.Fatalf("ParseHeader: %v", err)
+	}
+	if !astc.IsCubemap(h) {
+		t.Fatalf("IsCubemap(%+v) = false", h)
+	}
+
+	got, gotEdge, err := astc.DecodeCubemapRGBA8(data, astc.ProfileLDR)
+	if err != nil {
+		t.Fatalf("DecodeCubemapRGBA8: %v", err)
+	}
+	if gotEdge != edge {
+		t.Fatalf("edge = %d, want %d", gotEdge, edge)
+	}
+	for f := range got {
+		for i := 0; i < edge*edge; i++ {
+			if [4]byte(got[f][i*4:]) != colors[f] {
+				t.Fatalf("face %v texel %d = %v, want %v", astc.CubeFace(f), i, got[f][i*4:i*4+4], colors[f])
+			}
+		}
+	}
+}
+
+func TestCubemapRGBA8_SeamFixup(t *testing.T) {
+	const edge = 16
+	faces, colors := cubemapTestFaces(edge)
+
+	data, err := astc.EncodeCubemapRGBA8(faces, edge, 4, 4, astc.ProfileLDR, astc.EncodeThorough, true)
+	if err != nil {
+		t.Fatalf("EncodeCubemapRGBA8: %v", err)
+	}
+	got, _, err := astc.DecodeCubemapRGBA8(data, astc.ProfileLDR)
+	if err != nil {
+		t.Fatalf("DecodeCubemapRGBA8: %v", err)
+	}
+
+	avg := func(fs ...astc.CubeFace) [4]int {
+		var out [4]int
+		for c := 0; c < 4; c++ {
+			sum := 0
+			for _, f := range fs {
+				sum += int(colors[f][c])
+			}
+			out[c] = (sum + len(fs)/2) / len(fs)
+		}
+		return out
+	}
+	texel := func(f astc.CubeFace, x, y int) []byte {
+		off := (y*edge + x) * 4
+		return got[f][off : off+4]
+	}
+	check := func(name string, px []byte, want [4]int) {
+		t.Helper()
+		for c := 0; c < 4; c++ {
+			if d := int(px[c]) - want[c]; d < -6 || d > 6 {
+				t.Fatalf("%s = %v, want about %v", name, px, want)
+			}
+		}
+	}
+
+	// The right edge of +X meets the left edge of -Z, row for row.
+	seam := avg(astc.CubeFacePosX, astc.CubeFaceNegZ)
+	check("+X right edge", texel(astc.CubeFacePosX, edge-1, 8), seam)
+	check("-Z left edge", texel(astc.CubeFaceNegZ, 0, 8), seam)
+	// The top right corner of +X is shared with -Z and +Y.
+	check("+X corner", texel(astc.CubeFacePosX, edge-1, 0), avg(astc.CubeFacePosX, astc.CubeFaceNegZ, astc.CubeFacePosY))
+	// Interior texels keep the face color.
+	check("+X interior", texel(astc.CubeFacePosX, 8, 8), avg(astc.CubeFacePosX))
+	if faces[astc.CubeFacePosX][(8*edge+edge-1)*4] != colors[astc.CubeFacePosX][0] {
+		t.Fatalf("EncodeCubemapRGBA8 modified its input")
+	}
+}
+
+func TestCubemapRGBA8_Errors(t *testing.T) {
+	faces, _ := cubemapTestFaces(8)
+	faces[astc.CubeFaceNegY] = faces[astc.CubeFaceNegY][:len(faces[astc.CubeFaceNegY])-4]
+	if _, err := astc.EncodeCubemapRGBA8(faces, 8, 4, 4, astc.ProfileLDR, astc.EncodeFastest, false); err == nil {
+		t.Fatalf("EncodeCubemapRGBA8 accepted a face of the wrong size")
+	}
+
+	pix := make([]byte, 8*8*4)
+	data, err := astc.EncodeRGBA8(pix, 8, 8, 4, 4)
+	if err != nil {
+		t.Fatalf("EncodeRGBA8: %v", err)
+	}
+	if _, _, err := astc.DecodeCubemapRGBA8(data, astc.ProfileLDR); err == nil {
+		t.Fatalf("DecodeCubemapRGBA8 accepted a 2D image")
+	}
+}
@@ -0,0 +1,122 @@
+package astc
+
+import (
+	"encoding/json"
+	"math"
+)
+
+// DecisionLog is the sidecar document CompressImage writes to Config.DecisionLog: the encoder's
+// choices for every block of one image, for aggregating encode decisions across many textures
+// without calling GetBlockInfo per block.
+type DecisionLog struct {
+	BlockX  int `json:"block_x"`
+	BlockY  int `json:"block_y"`
+	BlockZ  int `json:"block_z"`
+	BlocksX int `json:"blocks_x"`
+	BlocksY int `json:"blocks_y"`
+	BlocksZ int `json:"blocks_z"`
+
+	// Blocks holds one entry per block, in block (file) order.
+	Blocks []BlockDecision `json:"blocks"`
+}
+
+// BlockDecision is one block's entry in a DecisionLog. Mode, partition and quantization fields are
+// zero for constant-color blocks.
+type BlockDecision struct {
+	Index    int  `json:"i"`
+	Constant bool `json:"const,omitempty"`
+
+	// Mode is the 11-bit block mode field.
+	Mode           int  `json:"mode"`
+	Partitions     int  `json:"parts"`
+	PartitionIndex int  `json:"part_index,omitempty"`
+	DualPlane      bool `json:"dual,omitempty"`
+	// Plane2Component is the component stored in the second weight plane (0=R .. 3=A).
+	Plane2Component int `json:"plane2,omitempty"`
+	// EndpointFormats holds the color endpoint mode of each partition.
+	EndpointFormats []int  `json:"formats,omitempty"`
+	ColorLevels     int    `json:"color_levels"`
+	WeightLevels    int    `json:"weight_levels"`
+	WeightGrid      [3]int `json:"grid"`
+
+	// Error is the channel-weighted sum of squared errors between the block's input texels (after
+	// swizzle and edge handling) and its decoded texels, in 8-bit units for TypeU8 inputs and in
+	// input units for float inputs.
+	Error float64 `json:"err"`
+}
+
+// blockDecision describes block, the encoding chosen for block index i.
+func (c *Context) blockDecision(i int, block []byte) BlockDecision {
+	d := BlockDecision{Index: i}
+	scb := physicalToSymbolicWithCtx(block, c.decodeCtx)
+	switch scb.blockType {
+	case symBlockConstU16, symBlockConstF16:
+		d.Constant = true
+		return d
+	case symBlockError:
+		return d
+	}
+
+	bmi := c.decodeCtx.blockMode(int(scb.blockMode))
+	d.Mode = int(scb.blockMode)
+	d.Partitions = int(scb.partitionCount)
+	if d.Partitions > 1 {
+		d.PartitionIndex = int(scb.partitionIndex)
+	}
+	d.DualPlane = bmi.isDualPlane
+	if d.DualPlane {
+		d.Plane2Component = int(scb.plane2Component)
+	}
+	d.EndpointFormats = make([]int, d.Partitions)
+	for p := range d.EndpointFormats {
+		d.EndpointFormats[p] = int(scb.colorFormats[p])
+	}
+	d.ColorLevels = quantLevel(scb.quantMode)
+	d.WeightLevels = quantLevel(bmi.weightQuant)
+	d.WeightGrid = [3]int{int(bmi.xWeights), int(bmi.yWeights), int(bmi.zWeights)}
+	return d
+}
+
+// blockErrorU8 returns the channel-weighted squared error of block against RGBA8 texels.
+func (c *Context) blockErrorU8(block []byte, texels []byte, weight [4]float32, scratch []byte) float64 {
+	decodeBlockToRGBA8(c.cfg.Profile, c.decodeCtx, block, scratch)
+	var err float64
+	for i := 0; i < len(texels); i += 4 {
+		for ch := 0; ch < 4; ch++ {
+			d := float64(int(texels[i+ch]) - int(scratch[i+ch]))
+			err += float64(weight[ch]) * d * d
+		}
+	}
+	return err
+}
+
+// blockErrorF32 returns the channel-weighted squared error of block against RGBA float texels.
+// Non-finite differences are ignored.
+func (c *Context) blockErrorF32(block []byte, texels []float32, weight [4]float32, scratch []float32) float64 {
+	decodeBlockToRGBAF32(c.cfg.Profile, c.decodeCtx, block, scratch)
+	var err float64
+	for i := 0; i < len(texels); i += 4 {
+		for ch := 0; ch < 4; ch++ {
+			d := float64(texels[i+ch]) - float64(scratch[i+ch])
+			if math.IsNaN(d) || math.IsInf(d, 0) {
+				continue
+			}
+			err += float64(weight[ch]) * d * d
+		}
+	}
+	return err
+}
+
+// writeDecisionLog writes the collected decisions of a completed compression.
+func (c *Context) writeDecisionLog(blocksX, blocksY, blocksZ int) error {
+	doc := DecisionLog{
+		BlockX:  c.blockX,
+		BlockY:  c.blockY,
+		BlockZ:  c.blockZ,
+		BlocksX: blocksX,
+		BlocksY: blocksY,
+		BlocksZ: blocksZ,
+		Blocks:  c.compress.decisions,
+	}
+	return json.NewEncoder(c.cfg.DecisionLog).Encode(&doc)
+}
@@ -0,0 +1,126 @@
+package astc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// DecodeItem is a single image decoded by DecodeBatch.
+type DecodeItem struct {
+	Profile Profile
+	Header  Header
+
+	// Blocks holds the block payload (e.g. as returned by ParseFile).
+	Blocks []byte
+
+	// Dst receives RGBA8 pixels in x-major order, then y, then z. It must have length at least
+	// `SizeX*SizeY*SizeZ*4`. It may overlap Blocks, but not the buffers of other items.
+	Dst []byte
+}
+
+// DecodeBatch decodes many (typically small) images into their caller-provided RGBA8 buffers.
+//
+// Decode contexts are resolved once per block footprint and shared by all items using that
+// footprint, and items are decoded in parallel across up to workers goroutines (<= 0 uses
+// GOMAXPROCS). Every item is attempted; the returned error reports the lowest failing item index.
+//
+// Limitations:
+//   - Only LDR profiles (ProfileLDR, ProfileLDRSRGB).
+func DecodeBatch(items []DecodeItem, workers int) error {
+	return decodeBatch(context.Background(), items, workers)
+}
+
+func decodeBatch(goCtx context.Context, items []DecodeItem, workers int) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	ctxs := make([]*decodeContext, len(items))
+	errs := make([]error, len(items))
+	shared := make(map[decodeContextKey]*decodeContext)
+	for i := range items {
+		h := items[i].Header
+		// Validate before resolving the context, which is cached for the life of the process.
+		if err := validateBlockSize(int(h.BlockX), int(h.BlockY), int(h.BlockZ)); err != nil {
+			errs[i] = err
+			continue
+		}
+		key := decodeContextKey{bx: h.BlockX, by: h.BlockY, bz: h.BlockZ}
+		ctx := shared[key]
+		if ctx == nil {
+			ctx = getDecodeContext(int(h.BlockX), int(h.BlockY), int(h.BlockZ))
+			shared[key] = ctx
+		}
+		ctxs[i] = ctx
+	}
+
+	done := goCtx.Done()
+	var skipped atomic.Bool
+	decodeItem := func(i int) {
+		if errs[i] != nil {
+			return
+		}
+		if isDone(done) {
+			skipped.Store(true)
+			return
+		}
+		it := &items[i]
+		width := int(it.Header.SizeX)
+		height := int(it.Header.SizeY)
+		depth := int(it.Header.SizeZ)
+		if width <= 0 || height <= 0 || depth <= 0 {
+			errs[i] = errors.New("astc: invalid image dimensions")
+			return
+		}
+		n := width * height * depth * 4
+		if len(it.Dst) < n {
+			errs[i] = errors.New("astc: output buffer too small")
+			return
+		}
+		errs[i] = decodeRGBA8VolumeFromParsedWithContext(it.Profile, ctxs[i], it.Header, it.Blocks, it.Dst[:n], 0, 0)
+	}
+
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > len(items) {
+		workers = len(items)
+	}
+
+	if workers <= 1 {
+		for i := range items {
+			decodeItem(i)
+		}
+	} else {
+		var next uint32
+		var wg sync.WaitGroup
+		wg.Add(workers)
+		for w := 0; w < workers; w++ {
+			go func() {
+				defer wg.Done()
+				for {
+					i := int(atomic.AddUint32(&next, 1) - 1)
+					if i >= len(items) {
+						return
+					}
+					decodeItem(i)
+				}
+			}()
+		}
+		wg.Wait()
+	}
+
+	if skipped.Load() {
+		return goCtx.Err()
+	}
+	for i, err := range errs {
+		if err != nil {
+			return fmt.Errorf("astc: batch item %d: %w", i, err)
+		}
+	}
+	return nil
+}
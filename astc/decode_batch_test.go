@@ -0,0 +1,84 @@
+package astc_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/arm-software/astc-encoder/astc"
+)
+
+func TestDecodeBatch_MatchesSingleDecode(t *testing.T) {
+	type icon struct {
+		w, h   int
+		bx, by int
+	}
+	icons := []icon{
+		{16, 16, 4, 4},
+		{24, 20, 6, 6},
+		{16, 16, 4, 4},
+		{9, 7, 5, 5},
+		{32, 8, 8, 8},
+	}
+
+	var items []astc.DecodeItem
+	var want [][]byte
+	for n, ic := range icons {
+		pix := make([]byte, ic.w*ic.h*4)
+		for i := range pix {
+			pix[i] = uint8(i*7 + n*31)
+		}
+		data, err := astc.EncodeRGBA8WithProfileAndQuality(pix, ic.w, ic.h, ic.bx, ic.by, astc.ProfileLDR, astc.EncodeFast)
+		if err != nil {
+			t.Fatalf("EncodeRGBA8WithProfileAndQuality: %v", err)
+		}
+		ref, _, _, err := astc.DecodeRGBA8(data)
+		if err != nil {
+			t.Fatalf("DecodeRGBA8: %v", err)
+		}
+		h, blocks, err := astc.ParseFile(data)
+		if err != nil {
+			t.Fatalf("ParseFile: %v", err)
+		}
+		items = append(items, astc.DecodeItem{
+			Profile: astc.ProfileLDR,
+			Header:  h,
+			Blocks:  blocks,
+			Dst:     make([]byte, len(ref)),
+		})
+		want = append(want, ref)
+	}
+
+	for _, workers := range []int{1, 3, 0} {
+		for i := range items {
+			clear(items[i].Dst)
+		}
+		if err := astc.DecodeBatch(items, workers); err != nil {
+			t.Fatalf("DecodeBatch(workers=%d): %v", workers, err)
+		}
+		for i := range items {
+			if !bytes.Equal(items[i].Dst, want[i]) {
+				t.Fatalf("DecodeBatch(workers=%d): item %d mismatch", workers, i)
+			}
+		}
+	}
+}
+
+func TestDecodeBatch_ReportsFailingItem(t *testing.T) {
+	h := astc.Header{BlockX: 4, BlockY: 4, BlockZ: 1, SizeX: 4, SizeY: 4, SizeZ: 1}
+	block := astc.EncodeConstBlockRGBA8(1, 2, 3, 4)
+	items := []astc.DecodeItem{
+		{Profile: astc.ProfileLDR, Header: h, Blocks: block[:], Dst: make([]byte, 64)},
+		{Profile: astc.ProfileLDR, Header: h, Blocks: block[:], Dst: make([]byte, 8)},
+	}
+	if err := astc.DecodeBatch(items, 2); err == nil {
+		t.Fatalf("expected error for short destination buffer")
+	}
+	if items[0].Dst[0] != 1 || items[0].Dst[3] != 4 {
+		t.Fatalf("valid item was not decoded: %v", items[0].Dst[:4])
+	}
+
+	items[1] = astc.DecodeItem{Profile: astc.ProfileLDR, Header: astc.Header{BlockX: 5, BlockY: 7, BlockZ: 1, SizeX: 5, SizeY: 7, SizeZ: 1}, Blocks: block[:], Dst: make([]byte, 5*7*4)}
+	if err := astc.DecodeBatch(items, 2); astc.ErrorCodeOf(err) != astc.ErrBadBlockSize {
+		t.Fatalf("illegal 5x7 footprint: got %v, want ErrBadBlockSize", err)
+	}
+}
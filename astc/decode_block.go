@@ -4,11 +4,41 @@ import "errors"
 
 var errUnsupportedProfileRGBA8 = errors.New("astc: DecodeRGBA8 only supports LDR profiles")
 
+// DecodeBlockRGBA8 decodes a single 2D block with a blockX x blockY footprint into dst, which must
+// hold at least blockX*blockY*4 bytes. Texels are stored row by row, 4 bytes (R, G, B, A) each, as
+// in a decoded image. Blocks which are invalid for the footprint or profile decode to the magenta
+// error color, as on GPUs.
+//
+// It decodes exactly like the full-image functions and is safe for concurrent use; the per-footprint
+// tables are built on first use and shared.
+//
+// Limitations:
+//   - Only LDR profiles (ProfileLDR, ProfileLDRSRGB).
+func DecodeBlockRGBA8(block [BlockBytes]byte, blockX, blockY int, profile Profile, dst []byte) error {
+	if err := validateBlockSize(blockX, blockY, 1); err != nil {
+		return err
+	}
+	if profile != ProfileLDR && profile != ProfileLDRSRGB {
+		return errUnsupportedProfileRGBA8
+	}
+	if len(dst) < blockX*blockY*4 {
+		return errors.New("astc: output buffer too small")
+	}
+	decodeBlockToRGBA8(profile, getDecodeContext(blockX, blockY, 1), block[:], dst)
+	return nil
+}
+
 func decodeBlockToRGBA8(profile Profile, ctx *decodeContext, block []byte, out []byte) {
+	scb := physicalToSymbolicWithCtx(block, ctx)
+	decodeSymbolicToRGBA8(profile, ctx, &scb, out)
+}
+
+// decodeSymbolicToRGBA8 is decodeBlockToRGBA8 for an already parsed block, for callers which
+// evaluate many variants of one block.
+func decodeSymbolicToRGBA8(profile Profile, ctx *decodeContext, scb *symbolicBlock, out []byte) {
 	texelCount := ctx.texelCount
 	dst := out[:texelCount*4]
 
-	scb := physicalToSymbolicWithCtx(block, ctx)
 	switch scb.blockType {
 	case symBlockError:
 		fillErrorRGBA8(dst)
@@ -26,7 +56,7 @@ func decodeBlockToRGBA8(profile Profile, ctx *decodeContext, block []byte, out [
 		return
 	}
 
-	bmi := ctx.blockModes[scb.blockMode]
+	bmi := ctx.blockMode(int(scb.blockMode))
 	if !bmi.ok {
 		fillErrorRGBA8(dst)
 		return
@@ -10,10 +10,11 @@ func decodeBlockToRGBAF32(profile Profile, ctx *decodeContext, block []byte, out
 		fillErrorRGBAF32(dst)
 		return
 	case symBlockConstU16:
-		r := unorm16ToFloat32Table[scb.constantColor[0]]
-		g := unorm16ToFloat32Table[scb.constantColor[1]]
-		b := unorm16ToFloat32Table[scb.constantColor[2]]
-		a := unorm16ToFloat32Table[scb.constantColor[3]]
+		unorm16Table, _ := float32Tables()
+		r := unorm16Table[scb.constantColor[0]]
+		g := unorm16Table[scb.constantColor[1]]
+		b := unorm16Table[scb.constantColor[2]]
+		a := unorm16Table[scb.constantColor[3]]
 		fillConstRGBAF32(dst, r, g, b, a)
 		return
 	case symBlockConstF16:
@@ -22,15 +23,15 @@ func decodeBlockToRGBAF32(profile Profile, ctx *decodeContext, block []byte, out
 			fillErrorRGBAF32(dst)
 			return
 		}
-		r := halfToFloat32(scb.constantColor[0])
-		g := halfToFloat32(scb.constantColor[1])
-		b := halfToFloat32(scb.constantColor[2])
-		a := halfToFloat32(scb.constantColor[3])
+		r := HalfToFloat32(scb.constantColor[0])
+		g := HalfToFloat32(scb.constantColor[1])
+		b := HalfToFloat32(scb.constantColor[2])
+		a := HalfToFloat32(scb.constantColor[3])
 		fillConstRGBAF32(dst, r, g, b, a)
 		return
 	}
 
-	bmi := ctx.blockModes[scb.blockMode]
+	bmi := ctx.blockMode(int(scb.blockMode))
 	if !bmi.ok {
 		fillErrorRGBAF32(dst)
 		return
@@ -67,18 +68,19 @@ func decodeBlockToRGBAF32(profile Profile, ctx *decodeContext, block []byte, out
 		epda[p] = e1[3] - e0[3]
 	}
 
-	var rgbTableByPart [blockMaxPartitions]*[1 << 16]float32
-	var alphaTableByPart [blockMaxPartitions]*[1 << 16]float32
+	unorm16Table, lnsTable := float32Tables()
+	var rgbTableByPart [blockMaxPartitions]*float32Table
+	var alphaTableByPart [blockMaxPartitions]*float32Table
 	for p := 0; p < partitionCount; p++ {
 		if rgbLNS[p] {
-			rgbTableByPart[p] = &lnsToFloat32Table
+			rgbTableByPart[p] = lnsTable
 		} else {
-			rgbTableByPart[p] = &unorm16ToFloat32Table
+			rgbTableByPart[p] = unorm16Table
 		}
 		if alphaLNS[p] {
-			alphaTableByPart[p] = &lnsToFloat32Table
+			alphaTableByPart[p] = lnsTable
 		} else {
-			alphaTableByPart[p] = &unorm16ToFloat32Table
+			alphaTableByPart[p] = unorm16Table
 		}
 	}
 
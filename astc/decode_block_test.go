@@ -0,0 +1,84 @@
+package astc_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/arm-software/astc-encoder/astc"
+)
+
+func TestDecodeBlockRGBA8_MatchesImageDecode(t *testing.T) {
+	for _, bs := range [][2]int{{4, 4}, {6, 5}, {8, 8}, {12, 12}} {
+		bx, by := bs[0], bs[1]
+		pix := make([]byte, bx*by*4)
+		for i := range pix {
+			pix[i] = uint8(i*29 + i/4)
+		}
+		for _, profile := range []astc.Profile{astc.ProfileLDR, astc.ProfileLDRSRGB} {
+			data, err := astc.EncodeRGBA8WithProfileAndQuality(pix, bx, by, bx, by, profile, astc.EncodeMedium)
+			if err != nil {
+				t.Fatalf("encode %dx%d: %v", bx, by, err)
+			}
+			want, _, _, err := astc.DecodeRGBA8WithProfile(data, profile)
+			if err != nil {
+				t.Fatalf("decode %dx%d: %v", bx, by, err)
+			}
+
+			var block [astc.BlockBytes]byte
+			copy(block[:], data[astc.HeaderSize:])
+			got := make([]byte, bx*by*4)
+			if err := astc.DecodeBlockRGBA8(block, bx, by, profile, got); err != nil {
+				t.Fatalf("DecodeBlockRGBA8 %dx%d: %v", bx, by, err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Fatalf("DecodeBlockRGBA8 %dx%d profile %v differs from image decode", bx, by, profile)
+			}
+		}
+	}
+}
+
+func TestDecodeBlockRGBA8_Errors(t *testing.T) {
+	block := astc.EncodeConstBlockRGBA8(1, 2, 3, 4)
+	dst := make([]byte, 6*6*4)
+	if err := astc.DecodeBlockRGBA8(block, 4, 4, astc.ProfileLDR, dst); err != nil || !bytes.Equal(dst[:4], []byte{1, 2, 3, 4}) {
+		t.Fatalf("constant block: %v, %v", dst[:4], err)
+	}
+	if err := astc.DecodeBlockRGBA8(block, 7, 7, astc.ProfileLDR, dst); astc.ErrorCodeOf(err) != astc.ErrBadBlockSize {
+		t.Fatalf("7x7 footprint: got %v, want ErrBadBlockSize", err)
+	}
+	if err := astc.DecodeBlockRGBA8(block, 6, 6, astc.ProfileHDR, dst); err == nil {
+		t.Fatalf("expected error for HDR profile")
+	}
+	if err := astc.DecodeBlockRGBA8(block, 6, 6, astc.ProfileLDR, dst[:6*6*4-1]); err == nil {
+		t.Fatalf("expected error for short dst")
+	}
+
+	// An all-zero block uses a reserved block mode and decodes to the error color.
+	if err := astc.DecodeBlockRGBA8([astc.BlockBytes]byte{}, 4, 4, astc.ProfileLDR, dst); err != nil {
+		t.Fatalf("error block: %v", err)
+	}
+	if !bytes.Equal(dst[:4], []byte{255, 0, 255, 255}) {
+		t.Fatalf("error block decoded to %v, want magenta", dst[:4])
+	}
+}
+
+func BenchmarkDecodeBlockRGBA8(b *testing.B) {
+	pix := make([]byte, 6*6*4)
+	for i := range pix {
+		pix[i] = uint8(i * 29)
+	}
+	data, err := astc.EncodeRGBA8WithProfileAndQuality(pix, 6, 6, 6, 6, astc.ProfileLDR, astc.EncodeMedium)
+	if err != nil {
+		b.Fatal(err)
+	}
+	var block [astc.BlockBytes]byte
+	copy(block[:], data[astc.HeaderSize:])
+	dst := make([]byte, len(pix))
+	b.SetBytes(int64(len(dst)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := astc.DecodeBlockRGBA8(block, 6, 6, astc.ProfileLDR, dst); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -1,6 +1,24 @@
 package astc
 
-import "sync"
+import (
+	"maps"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// Memory model of the codec tables. Everything derived from a block footprint alone is built once
+// per process and shared: the decode contexts below (block mode entries and partition table
+// pointers), the partition tables, the weight decimation tables and the encoder's block mode
+// lists. Each table is fully built before it is published in its registry, and is never modified
+// or freed afterwards, so any number of goroutines may read it without locks. Every entry point
+// validates the footprint before resolving its tables, so these registries grow with the number
+// of distinct footprints used (at most the 14 2D and 10 3D ASTC footprints), not with the number
+// of Contexts, Encoders or Decoders: a Context holds a pointer to the shared decode context plus
+// its own scheduling state, about 1.5 KiB. The one table that also depends on the Config, the
+// block mode list filtered by weight bounds and block mode masks (tunedBlockModes), is cached in a
+// map capped at boundedBlockModeCacheCap lists. Per-image scratch buffers belong to the
+// CompressImage and DecompressImage calls that allocate them.
 
 type blockModeInfo struct {
 	ok            bool
@@ -16,51 +34,66 @@ type blockModeInfo struct {
 	decimation    []decimationEntry
 }
 
+// decodeContext holds the per-footprint tables used to decode blocks. Contexts are immutable once
+// built and shared by every decoder of the footprint, across goroutines.
 type decodeContext struct {
 	blockX     int
 	blockY     int
 	blockZ     int
 	texelCount int
 
-	blockModes [1 << 11]blockModeInfo
+	// modeIndex maps each of the 2048 block modes to its entry in modes. Only modes that are
+	// valid for the footprint get an entry (145 for 4x4, 775 for 12x12); the others map to entry
+	// 0, whose ok is false. This cuts a context from 80 KiB of mode entries to 10-35 KiB.
+	modeIndex [1 << 11]uint16
+	modes     []blockModeInfo
 
 	partitionTables [blockMaxPartitions + 1]*partitionTable
 }
 
+// blockMode returns the decoded block mode; callers must not modify it.
+func (ctx *decodeContext) blockMode(mode int) *blockModeInfo {
+	return &ctx.modes[ctx.modeIndex[mode&(1<<11-1)]]
+}
+
 type decodeContextKey struct {
 	bx uint8
 	by uint8
 	bz uint8
 }
 
+// decodeContexts is the registry of shared contexts: an immutable map replaced wholesale when a
+// footprint is added, so lookups take no lock. mu serializes the (rare) additions.
 var decodeContexts struct {
-	mu sync.RWMutex
-	m  map[decodeContextKey]*decodeContext
+	mu sync.Mutex
+	m  atomic.Pointer[map[decodeContextKey]*decodeContext]
 }
 
 func getDecodeContext(blockX, blockY, blockZ int) *decodeContext {
 	key := decodeContextKey{bx: uint8(blockX), by: uint8(blockY), bz: uint8(blockZ)}
-
-	decodeContexts.mu.RLock()
-	if decodeContexts.m != nil {
-		if ctx := decodeContexts.m[key]; ctx != nil {
-			decodeContexts.mu.RUnlock()
+	if m := decodeContexts.m.Load(); m != nil {
+		if ctx := (*m)[key]; ctx != nil {
 			return ctx
 		}
 	}
-	decodeContexts.mu.RUnlock()
 
 	decodeContexts.mu.Lock()
 	defer decodeContexts.mu.Unlock()
 
-	if decodeContexts.m == nil {
-		decodeContexts.m = make(map[decodeContextKey]*decodeContext)
-	} else if ctx := decodeContexts.m[key]; ctx != nil {
-		return ctx
+	old := decodeContexts.m.Load()
+	if old != nil {
+		if ctx := (*old)[key]; ctx != nil {
+			return ctx
+		}
 	}
 
 	ctx := newDecodeContext(blockX, blockY, blockZ)
-	decodeContexts.m[key] = ctx
+	m := map[decodeContextKey]*decodeContext{}
+	if old != nil {
+		m = maps.Clone(*old)
+	}
+	m[key] = ctx
+	decodeContexts.m.Store(&m)
 	return ctx
 }
 
@@ -76,6 +109,8 @@ func newDecodeContext(blockX, blockY, blockZ int) *decodeContext {
 		ctx.partitionTables[pc] = getPartitionTable(blockX, blockY, blockZ, pc)
 	}
 
+	// Entry 0 stands for every invalid mode.
+	ctx.modes = make([]blockModeInfo, 1, 256)
 	for bm := 0; bm < (1 << 11); bm++ {
 		var (
 			xWeights, yWeights, zWeights int
@@ -108,7 +143,8 @@ func newDecodeContext(blockX, blockY, blockZ int) *decodeContext {
 			realWeightCount *= 2
 		}
 
-		ctx.blockModes[bm] = blockModeInfo{
+		ctx.modeIndex[bm] = uint16(len(ctx.modes))
+		ctx.modes = append(ctx.modes, blockModeInfo{
 			ok:            true,
 			xWeights:      uint8(xWeights),
 			yWeights:      uint8(yWeights),
@@ -120,8 +156,9 @@ func newDecodeContext(blockX, blockY, blockZ int) *decodeContext {
 			realWeightCnt: uint8(realWeightCount),
 			noDecimation:  xWeights == blockX && yWeights == blockY && zWeights == blockZ,
 			decimation:    getDecimationTable(blockX, blockY, blockZ, xWeights, yWeights, zWeights),
-		}
+		})
 	}
+	ctx.modes = slices.Clip(ctx.modes)
 
 	return ctx
 }
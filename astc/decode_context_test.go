@@ -0,0 +1,88 @@
+package astc
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestDecodeContext_CompactModes(t *testing.T) {
+	for _, f := range [][3]int{{4, 4, 1}, {8, 6, 1}, {12, 12, 1}, {3, 3, 3}, {6, 6, 6}} {
+		ctx := newDecodeContext(f[0], f[1], f[2])
+		if ctx.modes[0].ok {
+			t.Fatalf("%v: entry 0 must be the invalid mode", f)
+		}
+		valid := 0
+		for mode := 0; mode < 1<<11; mode++ {
+			bmi := ctx.blockMode(mode)
+			var x, y, z int
+			var ok bool
+			if f[2] == 1 {
+				x, y, _, _, _, ok = decodeBlockMode2D(mode)
+				z = 1
+			} else {
+				x, y, z, _, _, _, ok = decodeBlockMode3D(mode)
+			}
+			ok = ok && x <= f[0] && y <= f[1] && z <= f[2]
+			if bmi.ok != ok {
+				t.Fatalf("%v: mode %d ok=%v, want %v", f, mode, bmi.ok, ok)
+			}
+			if ok {
+				valid++
+				if int(bmi.xWeights) != x || int(bmi.yWeights) != y || int(bmi.zWeights) != z {
+					t.Fatalf("%v: mode %d has a %dx%dx%d grid, want %dx%dx%d", f, mode, bmi.xWeights, bmi.yWeights, bmi.zWeights, x, y, z)
+				}
+			}
+		}
+		if len(ctx.modes) != valid+1 {
+			t.Fatalf("%v: %d mode entries for %d valid modes", f, len(ctx.modes), valid)
+		}
+	}
+}
+
+func TestGetDecodeContext_Shared(t *testing.T) {
+	const goroutines = 16
+	got := make([]*decodeContext, goroutines)
+	var wg sync.WaitGroup
+	for i := range got {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Request a few other footprints too so that registry updates interleave.
+			getDecodeContext(5, 4+i%3, 1)
+			got[i] = getDecodeContext(10, 10, 1)
+		}()
+	}
+	wg.Wait()
+	for i, ctx := range got {
+		if ctx != got[0] {
+			t.Fatalf("goroutine %d got a different context", i)
+		}
+	}
+	if getDecodeContext(10, 10, 1) != got[0] {
+		t.Fatalf("later lookup got a different context")
+	}
+}
+
+func TestContextAlloc_SharesDecodeContext(t *testing.T) {
+	cfg, err := ConfigInit(ProfileLDR, 6, 5, 1, 60, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := ContextAlloc(&cfg, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ContextAlloc(&cfg, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	shared := getDecodeContext(6, 5, 1)
+	if a.decodeCtx != shared || b.decodeCtx != shared {
+		t.Fatalf("contexts of one footprint do not share the decode tables")
+	}
+	for pc := 2; pc <= blockMaxPartitions; pc++ {
+		if shared.partitionTables[pc] != getPartitionTable(6, 5, 1, pc) {
+			t.Fatalf("decode context does not share the %d-partition table", pc)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package astc
+
+import "errors"
+
+// DecodeCoverageMask decodes the alpha channel of a 2D LDR .astc file into a 1-bit coverage mask:
+// a texel's bit is set if its decoded alpha is greater than threshold. Bits are stored row by row,
+// each row starting on a byte boundary ((width+7)/8 bytes per row), least significant bit first:
+// texel (x, y) is bit x%8 of mask[y*((width+7)/8)+x/8].
+//
+// The alpha values equal those of DecodeRGBA8WithProfile with ProfileLDR (ProfileLDRSRGB expands
+// endpoints differently and may differ by one step), but no color is reconstructed. Blocks whose
+// endpoint alphas are all on the same side of the threshold are resolved without interpolating
+// their weights, which makes the decode cheap for cutout sprites, whose blocks are mostly fully
+// opaque or fully transparent.
+func DecodeCoverageMask(data []byte, threshold uint8) (mask []byte, width, height int, err error) {
+	h, blocks, err := ParseFile(data)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	blocksX, blocksY, _, total, err := h.BlockCount()
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if h.SizeZ != 1 || h.BlockZ != 1 {
+		return nil, 0, 0, errors.New("astc: DecodeCoverageMask only supports 2D images (z==1)")
+	}
+	if len(blocks) < total*BlockBytes {
+		return nil, 0, 0, errors.New("astc: block buffer too small")
+	}
+
+	if err := checkDecodeLimits(h, 0, 0); err != nil {
+		return nil, 0, 0, err
+	}
+
+	width, height = int(h.SizeX), int(h.SizeY)
+	blockX, blockY := int(h.BlockX), int(h.BlockY)
+	stride := (width + 7) / 8
+	mask = make([]byte, stride*height)
+	ctx := getDecodeContext(blockX, blockY, 1)
+	covered := make([]bool, blockX*blockY)
+	for by := 0; by < blocksY; by++ {
+		for bx := 0; bx < blocksX; bx++ {
+			off := (by*blocksX + bx) * BlockBytes
+			decodeBlockCoverage(ctx, blocks[off:off+BlockBytes], threshold, covered)
+			for y := 0; y < blockY; y++ {
+				py := by*blockY + y
+				if py >= height {
+					break
+				}
+				row := mask[py*stride:]
+				for x := 0; x < blockX; x++ {
+					px := bx*blockX + x
+					if px >= width {
+						break
+					}
+					if covered[y*blockX+x] {
+						row[px>>3] |= 1 << (px & 7)
+					}
+				}
+			}
+		}
+	}
+	return mask, width, height, nil
+}
+
+// decodeBlockCoverage sets out[t] to whether the decoded LDR alpha of texel t exceeds threshold.
+func decodeBlockCoverage(ctx *decodeContext, block []byte, threshold uint8, out []bool) {
+	texelCount := ctx.texelCount
+	out = out[:texelCount]
+	fill := func(v bool) {
+		for i := range out {
+			out[i] = v
+		}
+	}
+
+	scb := physicalToSymbolicWithCtx(block, ctx)
+	switch scb.blockType {
+	case symBlockError, symBlockConstF16:
+		// The magenta error color is opaque.
+		fill(threshold < 0xFF)
+		return
+	case symBlockConstU16:
+		fill(uint8(scb.constantColor[3]>>8) > threshold)
+		return
+	}
+	bmi := ctx.blockMode(int(scb.blockMode))
+	if !bmi.ok || (bmi.isDualPlane && (scb.plane2Component < 0 || scb.plane2Component > 3)) {
+		fill(threshold < 0xFF)
+		return
+	}
+
+	partitionCount := int(scb.partitionCount)
+	var partByTexel []uint8
+	if partitionCount > 1 {
+		pt := ctx.partitionTables[partitionCount]
+		if pt == nil {
+			fill(threshold < 0xFF)
+			return
+		}
+		pidx := int(scb.partitionIndex) & ((1 << partitionIndexBits) - 1)
+		partByTexel = pt.data[pidx*texelCount : pidx*texelCount+texelCount]
+	}
+
+	// Alpha interpolates monotonically between the endpoints, so a partition whose endpoint alphas
+	// are both above (or both at most) the threshold is uniformly covered (or not).
+	var ep0, epd [blockMaxPartitions]int
+	const mixed = 2
+	var state [blockMaxPartitions]int
+	allUniform := true
+	for p := 0; p < partitionCount; p++ {
+		_, _, e0, e1 := unpackColorEndpoints(ProfileLDR, scb.colorFormats[p], scb.colorValues[p][:])
+		ep0[p], epd[p] = e0[3], e1[3]-e0[3]
+		a0, a1 := uint8(e0[3]>>8) > threshold, uint8(e1[3]>>8) > threshold
+		switch {
+		case a0 && a1:
+			state[p] = 1
+		case !a0 && !a1:
+			state[p] = 0
+		default:
+			state[p] = mixed
+			allUniform = false
+		}
+	}
+	if allUniform && partitionCount == 1 {
+		fill(state[0] == 1)
+		return
+	}
+
+	// Weights of the plane that holds alpha.
+	planeOffset := 0
+	if bmi.isDualPlane && scb.plane2Component == 3 {
+		planeOffset = weightsPlane2Offset
+	}
+	wvals := scb.weights[:]
+	for tix := 0; tix < texelCount; tix++ {
+		part := 0
+		if partByTexel != nil {
+			part = int(partByTexel[tix])
+		}
+		if state[part] != mixed {
+			out[tix] = state[part] == 1
+			continue
+		}
+		var w int
+		if bmi.noDecimation {
+			w = int(wvals[planeOffset+tix])
+		} else {
+			e := bmi.decimation[tix]
+			sum := uint32(8)
+			sum += uint32(wvals[planeOffset+int(e.idx[0])]) * uint32(e.w[0])
+			sum += uint32(wvals[planeOffset+int(e.idx[1])]) * uint32(e.w[1])
+			sum += uint32(wvals[planeOffset+int(e.idx[2])]) * uint32(e.w[2])
+			sum += uint32(wvals[planeOffset+int(e.idx[3])]) * uint32(e.w[3])
+			w = int(sum >> 4)
+		}
+		out[tix] = uint8((ep0[part]+((epd[part]*w+32)>>6))>>8) > threshold
+	}
+}
@@ -0,0 +1,91 @@
+package astc_test
+
+import (
+	"testing"
+
+	"github.com/arm-software/astc-encoder/astc"
+	"github.com/arm-software/astc-encoder/astc/testimage"
+)
+
+func TestDecodeCoverageMask_MatchesDecode(t *testing.T) {
+	const w, h = 70, 45
+	for _, kind := range []testimage.Kind{testimage.KindAlphaCutout, testimage.KindGradient, testimage.KindPattern} {
+		pix, err := testimage.RGBA8(kind, w, h, 1, testimage.Options{})
+		if err != nil {
+			t.Fatalf("%v: RGBA8: %v", kind, err)
+		}
+		for _, bs := range [][2]int{{4, 4}, {6, 6}, {8, 5}} {
+			data, err := astc.EncodeRGBA8WithProfileAndQuality(pix, w, h, bs[0], bs[1], astc.ProfileLDR, astc.EncodeMedium)
+			if err != nil {
+				t.Fatalf("%v %dx%d: encode: %v", kind, bs[0], bs[1], err)
+			}
+			checkCoverageMatchesDecode(t, data, []uint8{0, 64, 127, 200, 254, 255})
+		}
+	}
+}
+
+func TestDecodeCoverageMask_SpecialBlocks(t *testing.T) {
+	// Two 4x4 blocks: a constant block with alpha 100 and an all-zero (reserved mode) error block.
+	data := append([]byte(nil), mustHeader(t, 8, 4, 4)...)
+	constBlock := astc.EncodeConstBlockRGBA8(10, 20, 30, 100)
+	data = append(data, constBlock[:]...)
+	data = append(data, make([]byte, astc.BlockBytes)...)
+	checkCoverageMatchesDecode(t, data, []uint8{0, 99, 100, 254, 255})
+
+	mask, w, h, err := astc.DecodeCoverageMask(data, 99)
+	if err != nil {
+		t.Fatalf("DecodeCoverageMask: %v", err)
+	}
+	if w != 8 || h != 4 || len(mask) != 4 {
+		t.Fatalf("got %dx%d with %d bytes, want 8x4 with 4 bytes", w, h, len(mask))
+	}
+	for y, b := range mask {
+		if b != 0xFF {
+			t.Fatalf("row %d = %#02x, want 0xff", y, b)
+		}
+	}
+}
+
+func TestDecodeCoverageMask_Errors(t *testing.T) {
+	if _, _, _, err := astc.DecodeCoverageMask([]byte{1, 2, 3}, 0); err == nil {
+		t.Fatalf("expected error for truncated header")
+	}
+	hdr, err := astc.MarshalHeader(astc.Header{BlockX: 4, BlockY: 4, BlockZ: 1, SizeX: 4, SizeY: 4, SizeZ: 2})
+	if err != nil {
+		t.Fatalf("MarshalHeader: %v", err)
+	}
+	data := append(hdr[:], make([]byte, 2*astc.BlockBytes)...)
+	if _, _, _, err := astc.DecodeCoverageMask(data, 0); err == nil {
+		t.Fatalf("expected error for 3D image")
+	}
+	if _, _, _, err := astc.DecodeCoverageMask(mustHeader(t, 8, 8, 4), 0); err == nil {
+		t.Fatalf("expected error for missing blocks")
+	}
+}
+
+func checkCoverageMatchesDecode(t *testing.T, data []byte, thresholds []uint8) {
+	t.Helper()
+	pix, w, h, err := astc.DecodeRGBA8WithProfile(data, astc.ProfileLDR)
+	if err != nil {
+		t.Fatalf("DecodeRGBA8WithProfile: %v", err)
+	}
+	for _, threshold := range thresholds {
+		mask, mw, mh, err := astc.DecodeCoverageMask(data, threshold)
+		if err != nil {
+			t.Fatalf("DecodeCoverageMask: %v", err)
+		}
+		stride := (w + 7) / 8
+		if mw != w || mh != h || len(mask) != stride*h {
+			t.Fatalf("got %dx%d with %d bytes, want %dx%d with %d bytes", mw, mh, len(mask), w, h, stride*h)
+		}
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				got := mask[y*stride+x/8]>>(x%8)&1 != 0
+				want := pix[(y*w+x)*4+3] > threshold
+				if got != want {
+					t.Fatalf("threshold %d: texel (%d,%d) covered=%v, want %v (alpha %d)", threshold, x, y, got, want, pix[(y*w+x)*4+3])
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package astc
+
+import "math"
+
+// Deblock smooths block-boundary discontinuities in a decoded image, for magnified display of
+// large-footprint textures (e.g. UI zoomed past 1:1) where 8x8 block edges become visible.
+//
+// At each vertical and horizontal boundary between two blocks, each channel of the two texels on
+// either side is smoothed only when the step across the boundary is larger than the gradient
+// inside both blocks, so the blocks disagree, and smaller than an edge threshold that grows with
+// strength, so real image edges falling on a boundary are kept. Strength ranges from 0 (no
+// change) to 1. The pass runs on the 2D slices of img in a fixed order and is deterministic; 3D
+// block boundaries along z are not filtered.
+func Deblock(img *Image, blockX, blockY int, strength float32) error {
+	if img == nil {
+		return newError(ErrBadParam, "astc: nil image")
+	}
+	if _, err := validateImageOut(img); err != nil {
+		return err
+	}
+	if blockX <= 0 || blockY <= 0 {
+		return newError(ErrBadBlockSize, "astc: invalid block dimensions")
+	}
+	if !(strength >= 0 && strength <= 1) {
+		return newError(ErrBadParam, "astc: invalid deblocking strength")
+	}
+	if strength == 0 {
+		return nil
+	}
+	deblockImage(img, blockX, blockY, 1, strength, false, nil)
+	return nil
+}
+
+// deblockImage is Deblock without argument validation. flipY locates the horizontal block
+// boundaries of an image stored bottom-up (DecodeOptions.FlipY). Boundaries next to a block
+// cleared in mask (Image.BlockMask, nil for all resident) are left alone, since the texels of
+// such blocks were not decoded.
+func deblockImage(img *Image, blockX, blockY, blockZ int, strength float32, flipY bool, mask []byte) {
+	load := func(i int) float32 {
+		switch img.DataType {
+		case TypeU8:
+			return float32(img.DataU8[i]) * (1.0 / 255)
+		case TypeF16:
+			return HalfToFloat32(img.DataF16[i])
+		default:
+			return img.DataF32[i]
+		}
+	}
+	store := func(i int, v float32) {
+		switch img.DataType {
+		case TypeU8:
+			img.DataU8[i] = float01ToUnorm8(v)
+		case TypeF16:
+			img.DataF16[i] = Float32ToHalf(v)
+		default:
+			img.DataF32[i] = v
+		}
+	}
+
+	// Steps up to alpha (relative to the local magnitude for HDR values) are treated as artifacts.
+	alpha := 0.04 + 0.12*strength
+
+	// filter smooths the texels p1, p0 | q0, q1 at element offsets p0-step, p0, p0+step,
+	// p0+2*step.
+	filter := func(p0, step int) {
+		for ch := 0; ch < 4; ch++ {
+			i1, i0, j0, j1 := p0-step+ch, p0+ch, p0+step+ch, p0+2*step+ch
+			vp1, vp0, vq0, vq1 := load(i1), load(i0), load(j0), load(j1)
+			d := vq0 - vp0
+			ad := float32(math.Abs(float64(d)))
+			inner := max(float32(math.Abs(float64(vp1-vp0))), float32(math.Abs(float64(vq1-vq0))))
+			scale := max((float32(math.Abs(float64(vp0)))+float32(math.Abs(float64(vq0))))*0.5, 1)
+			if !(ad > inner) || ad > alpha*scale {
+				continue
+			}
+			delta := (4*d + (vp1 - vq1)) * 0.125 * strength
+			delta = min(max(delta, -ad*0.5), ad*0.5)
+			store(i1, vp1+delta*0.5)
+			store(i0, vp0+delta)
+			store(j0, vq0-delta)
+			store(j1, vq1-delta*0.5)
+		}
+	}
+
+	w, h := img.DimX, img.DimY
+	blocksX, blocksY := (w+blockX-1)/blockX, (h+blockY-1)/blockY
+	resident := func(bx, by, bz int) bool {
+		return blockResident(mask, (bz*blocksY+by)*blocksX+bx)
+	}
+	for z := 0; z < img.DimZ; z++ {
+		slice := z * w * h
+		bz := z / blockZ
+		// Vertical boundaries between columns x-1 and x.
+		for x := blockX; x+1 < w; x += blockX {
+			if x < 2 {
+				continue
+			}
+			bx := x / blockX
+			for y := 0; y < h; y++ {
+				by := y / blockY
+				if flipY {
+					by = (h - 1 - y) / blockY
+				}
+				if mask != nil && !(resident(bx-1, by, bz) && resident(bx, by, bz)) {
+					continue
+				}
+				filter((slice+y*w+x-1)*4, 4)
+			}
+		}
+		// Horizontal boundaries between rows y-1 and y.
+		for y := blockY; y+1 < h; y += blockY {
+			if y < 2 {
+				continue
+			}
+			row := y
+			if flipY {
+				// The filter is symmetric, so filtering the mirrored rows gives the mirrored result.
+				row = h - y
+			}
+			by := y / blockY
+			for x := 0; x < w; x++ {
+				if mask != nil && !(resident(x/blockX, by-1, bz) && resident(x/blockX, by, bz)) {
+					continue
+				}
+				filter((slice+(row-1)*w+x)*4, w*4)
+			}
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package astc_test
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+
+	"github.com/arm-software/astc-encoder/astc"
+)
+
+func TestDecompressImage_Deblock(t *testing.T) {
+	const w, h, bs = 64, 48, 8
+	// A smooth gradient with a hard edge along the x = 32 block boundary.
+	src := make([]byte, w*h*4)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			v := uint8(40 + x*2 + y)
+			if x >= 32 {
+				v += 100
+			}
+			copy(src[(y*w+x)*4:], []byte{v, v / 2, 255 - v, 255})
+		}
+	}
+	cfg, err := astc.ConfigInit(astc.ProfileLDR, bs, bs, 1, 0, 0)
+	if err != nil {
+		t.Fatalf("ConfigInit: %v", err)
+	}
+	ctx, err := astc.ContextAlloc(&cfg, 4)
+	if err != nil {
+		t.Fatalf("ContextAlloc: %v", err)
+	}
+	blocks := make([]byte, blocksLenBytes(w, h, 1, bs, bs, 1))
+	var wg sync.WaitGroup
+	for i := range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = ctx.CompressImage(&astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeU8, DataU8: src}, astc.SwizzleRGBA, blocks, i)
+		}()
+	}
+	wg.Wait()
+
+	decode := func(threads int, strength float32) []byte {
+		t.Helper()
+		dcfg, err := astc.ConfigInit(astc.ProfileLDR, bs, bs, 1, 0, astc.FlagDecompressOnly)
+		if err != nil {
+			t.Fatalf("ConfigInit: %v", err)
+		}
+		dctx, err := astc.ContextAlloc(&dcfg, threads)
+		if err != nil {
+			t.Fatalf("ContextAlloc: %v", err)
+		}
+		out := &astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeU8, DataU8: make([]byte, w*h*4)}
+		errs := make([]error, threads)
+		var wg sync.WaitGroup
+		for i := range threads {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs[i] = dctx.DecompressImageWithOptions(blocks, out, astc.SwizzleRGBA, i, astc.DecodeOptions{Deblock: strength})
+			}()
+		}
+		wg.Wait()
+		// A thread joining after the image is done reports that the context needs a reset; the
+		// image is complete once any thread succeeds.
+		for i, err := range errs {
+			if err == nil {
+				break
+			}
+			if i == threads-1 {
+				t.Fatalf("DecompressImageWithOptions: %v", errs[0])
+			}
+		}
+		return out.DataU8
+	}
+	// boundaryStep is the mean absolute step across the internal block boundaries, excluding the
+	// hard edge at x = 32.
+	boundaryStep := func(pix []byte) float64 {
+		sum, n := 0, 0
+		for y := 0; y < h; y++ {
+			for x := bs; x < w; x += bs {
+				if x == 32 {
+					continue
+				}
+				for c := 0; c < 3; c++ {
+					d := int(pix[(y*w+x)*4+c]) - int(pix[(y*w+x-1)*4+c])
+					sum += max(d, -d)
+					n++
+				}
+			}
+		}
+		return float64(sum) / float64(n)
+	}
+
+	plain := decode(1, 0)
+	want, _, _, err := astc.DecodeRGBA8(append(mustHeader(t, w, h, bs), blocks...))
+	if err != nil {
+		t.Fatalf("DecodeRGBA8: %v", err)
+	}
+	if !bytes.Equal(plain, want) {
+		t.Fatalf("Deblock 0 changed the output")
+	}
+	deblocked := decode(1, 1)
+	if got := decode(4, 1); !bytes.Equal(got, deblocked) {
+		t.Fatalf("multi-threaded deblocking differs from single-threaded")
+	}
+	if before, after := boundaryStep(plain), boundaryStep(deblocked); !(after < before) {
+		t.Fatalf("boundary step %.3f after deblocking, %.3f before", after, before)
+	}
+	// The hard edge on a block boundary is kept.
+	for y := 0; y < h; y++ {
+		i := (y*w + 32) * 4
+		if d := int(deblocked[i]) - int(deblocked[i-4]); d < 80 {
+			t.Fatalf("row %d: edge step %d after deblocking", y, d)
+		}
+	}
+
+	img := &astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeU8, DataU8: append([]byte(nil), plain...)}
+	if err := astc.Deblock(img, bs, bs, 1); err != nil {
+		t.Fatalf("Deblock: %v", err)
+	}
+	if !bytes.Equal(img.DataU8, deblocked) {
+		t.Fatalf("Deblock differs from DecodeOptions.Deblock")
+	}
+	if err := astc.Deblock(img, bs, bs, 1.5); astc.ErrorCodeOf(err) != astc.ErrBadParam {
+		t.Fatalf("Deblock accepted strength 1.5: %v", err)
+	}
+}
+
+func mustHeader(t *testing.T, w, h, bs int) []byte {
+	t.Helper()
+	hdr, err := astc.MarshalHeader(astc.Header{BlockX: uint8(bs), BlockY: uint8(bs), BlockZ: 1, SizeX: uint32(w), SizeY: uint32(h), SizeZ: 1})
+	if err != nil {
+		t.Fatalf("MarshalHeader: %v", err)
+	}
+	return hdr[:]
+}
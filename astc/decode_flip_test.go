@@ -0,0 +1,106 @@
+package astc_test
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+
+	"github.com/arm-software/astc-encoder/astc"
+)
+
+// flipRows returns pix, a w x h x d image of rowLen elements per row, with the rows of each slice
+// reversed.
+func flipRows[T any](pix []T, rowLen, h, d int) []T {
+	out := make([]T, len(pix))
+	for z := range d {
+		for y := range h {
+			src := (z*h + y) * rowLen
+			dst := (z*h + h - 1 - y) * rowLen
+			copy(out[dst:dst+rowLen], pix[src:src+rowLen])
+		}
+	}
+	return out
+}
+
+func TestDecompressImage_FlipY(t *testing.T) {
+	for _, tc := range []struct {
+		w, h, d    int
+		bx, by, bz int
+	}{
+		{45, 23, 1, 6, 5, 1},
+		{17, 10, 3, 3, 3, 3},
+	} {
+		pix := make([]byte, tc.w*tc.h*tc.d*4)
+		for i := range pix {
+			pix[i] = uint8(i*7 + i/13)
+		}
+		enc, err := astc.NewEncoder(astc.WithBlockSize3D(tc.bx, tc.by, tc.bz), astc.WithQuality(astc.EncodeFastest))
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := enc.Encode(&astc.Image{DimX: tc.w, DimY: tc.h, DimZ: tc.d, DataType: astc.TypeU8, DataU8: pix})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, blocks, err := astc.ParseFile(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cfg, err := astc.ConfigInit(astc.ProfileLDR, tc.bx, tc.by, tc.bz, 0, astc.FlagDecompressOnly)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx, err := astc.ContextAlloc(&cfg, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		n := tc.w * tc.h * tc.d * 4
+		decode := func(dt astc.DataType, opts astc.DecodeOptions) *astc.Image {
+			t.Helper()
+			img := &astc.Image{DimX: tc.w, DimY: tc.h, DimZ: tc.d, DataType: dt}
+			switch dt {
+			case astc.TypeU8:
+				img.DataU8 = make([]byte, n)
+			case astc.TypeF16:
+				img.DataF16 = make([]uint16, n)
+			default:
+				img.DataF32 = make([]float32, n)
+			}
+			if err := ctx.DecompressImageWithOptions(blocks, img, astc.SwizzleRGBA, 0, opts); err != nil {
+				t.Fatalf("DecompressImageWithOptions: %v", err)
+			}
+			return img
+		}
+
+		rowLen := tc.w * 4
+		for _, deblock := range []float32{0, 0.8} {
+			plain := decode(astc.TypeU8, astc.DecodeOptions{Deblock: deblock})
+			flipped := decode(astc.TypeU8, astc.DecodeOptions{Deblock: deblock, FlipY: true})
+			if !bytes.Equal(flipped.DataU8, flipRows(plain.DataU8, rowLen, tc.h, tc.d)) {
+				t.Fatalf("%dx%dx%d deblock %v: flipped RGBA8 decode differs", tc.w, tc.h, tc.d, deblock)
+			}
+			plainF := decode(astc.TypeF32, astc.DecodeOptions{Deblock: deblock})
+			flippedF := decode(astc.TypeF32, astc.DecodeOptions{Deblock: deblock, FlipY: true})
+			if !slices.Equal(flippedF.DataF32, flipRows(plainF.DataF32, rowLen, tc.h, tc.d)) {
+				t.Fatalf("%dx%dx%d deblock %v: flipped float32 decode differs", tc.w, tc.h, tc.d, deblock)
+			}
+		}
+		plainH := decode(astc.TypeF16, astc.DecodeOptions{})
+		flippedH := decode(astc.TypeF16, astc.DecodeOptions{FlipY: true})
+		if !slices.Equal(flippedH.DataF16, flipRows(plainH.DataF16, rowLen, tc.h, tc.d)) {
+			t.Fatalf("%dx%dx%d: flipped float16 decode differs", tc.w, tc.h, tc.d)
+		}
+
+		dec, err := astc.NewDecoder(astc.WithFlipY(true), astc.WithWorkers(3))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, _, _, _, err := dec.DecodeRGBA8(data)
+		if err != nil {
+			t.Fatalf("DecodeRGBA8: %v", err)
+		}
+		if !bytes.Equal(got, flipRows(decode(astc.TypeU8, astc.DecodeOptions{}).DataU8, rowLen, tc.h, tc.d)) {
+			t.Fatalf("%dx%dx%d: Decoder WithFlipY output differs", tc.w, tc.h, tc.d)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package astc
+
+import "fmt"
+
+// Default decode ceilings, applied when DecodeOptions.MaxPixels or MaxBlocks is zero. A header
+// can describe images of up to 2^72 texels, so decoders of untrusted content must not size their
+// buffers from it unchecked.
+const (
+	// DefaultMaxPixels is 1 GTexel: 4 GiB as RGBA8, 16 GiB as RGBA float32.
+	DefaultMaxPixels = 1 << 30
+	// DefaultMaxBlocks is 64 Mi blocks, a 1 GiB block payload.
+	DefaultMaxBlocks = 1 << 26
+)
+
+// DecodeLimitError reports an image larger than the ceilings a decoder was given (see
+// DecodeOptions.MaxPixels). It is detected from the header, before any output is allocated.
+// ErrorCodeOf returns ErrOutOfMem for it, and errors.Is(err, ErrOutOfMem) holds.
+type DecodeLimitError struct {
+	Header Header
+	// Pixels and Blocks are the texel and block counts of the image; MaxPixels and MaxBlocks are
+	// the ceilings in effect, negative if disabled.
+	Pixels, Blocks       int64
+	MaxPixels, MaxBlocks int64
+}
+
+func (e *DecodeLimitError) Error() string {
+	if e.MaxPixels >= 0 && e.Pixels > e.MaxPixels {
+		return fmt.Sprintf("astc: image of %d texels exceeds the decode limit of %d", e.Pixels, e.MaxPixels)
+	}
+	return fmt.Sprintf("astc: image of %d blocks exceeds the decode limit of %d", e.Blocks, e.MaxBlocks)
+}
+
+// Unwrap returns ErrOutOfMem, the upstream code for images a decoder will not allocate.
+func (e *DecodeLimitError) Unwrap() error {
+	return ErrOutOfMem
+}
+
+// CheckLimits returns a *DecodeLimitError if an image with header h exceeds the MaxPixels or
+// MaxBlocks ceilings of o, or the error of h.Validate if h is invalid. Callers allocating their own
+// output from an untrusted header can run it first; the decoders of this package already do.
+func (o DecodeOptions) CheckLimits(h Header) error {
+	return checkDecodeLimits(h, o.MaxPixels, o.MaxBlocks)
+}
+
+// checkDecodeLimits implements DecodeOptions.CheckLimits.
+func checkDecodeLimits(h Header, maxPixels, maxBlocks int64) error {
+	if err := h.Validate(); err != nil {
+		return err
+	}
+	pixels := saturatingVolume(uint64(h.SizeX), uint64(h.SizeY), uint64(h.SizeZ))
+	blocks := saturatingVolume(
+		(uint64(h.SizeX)+uint64(h.BlockX)-1)/uint64(h.BlockX),
+		(uint64(h.SizeY)+uint64(h.BlockY)-1)/uint64(h.BlockY),
+		(uint64(h.SizeZ)+uint64(h.BlockZ)-1)/uint64(h.BlockZ))
+	return checkDecodeSize(h, pixels, blocks, maxPixels, maxBlocks)
+}
+
+// saturatingVolume returns x*y*z for dimensions below 2^24, saturated to the largest int64.
+func saturatingVolume(x, y, z uint64) int64 {
+	const maxInt64 = 1<<63 - 1
+	plane := x * y // below 2^48
+	if plane != 0 && z > maxInt64/plane {
+		return maxInt64
+	}
+	return int64(plane * z)
+}
+
+// checkDecodeSize checks the texel and block counts of an image against the ceilings, zero
+// selecting the defaults and negative values disabling them.
+func checkDecodeSize(h Header, pixels, blocks, maxPixels, maxBlocks int64) error {
+	if maxPixels == 0 {
+		maxPixels = DefaultMaxPixels
+	}
+	if maxBlocks == 0 {
+		maxBlocks = DefaultMaxBlocks
+	}
+	if (maxPixels >= 0 && pixels > maxPixels) || (maxBlocks >= 0 && blocks > maxBlocks) {
+		return &DecodeLimitError{Header: h, Pixels: pixels, Blocks: blocks, MaxPixels: maxPixels, MaxBlocks: maxBlocks}
+	}
+	return nil
+}
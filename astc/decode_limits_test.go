@@ -0,0 +1,92 @@
+package astc_test
+
+import (
+	"errors"
+	"testing"
+
+	astc "github.com/arm-software/astc-encoder/astc"
+)
+
+func TestDecodeLimits_RejectHugeHeaderBeforeAllocating(t *testing.T) {
+	// A header-only file claiming a 16M x 16M image: the payload check would fail too, but only
+	// after the Decoder allocated the output.
+	hdr, err := astc.MarshalHeader(astc.Header{BlockX: 4, BlockY: 4, BlockZ: 1, SizeX: astc.MaxImageDim, SizeY: astc.MaxImageDim, SizeZ: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dec, err := astc.NewDecoder()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, decode := range map[string]func() error{
+		"Decoder.DecodeRGBA8":   func() error { _, _, _, _, err := dec.DecodeRGBA8(hdr[:]); return err },
+		"Decoder.DecodeRGBAF32": func() error { _, _, _, _, err := dec.DecodeRGBAF32(hdr[:]); return err },
+	} {
+		err := decode()
+		var limitErr *astc.DecodeLimitError
+		if !errors.As(err, &limitErr) {
+			t.Fatalf("%s: err = %v, want *DecodeLimitError", name, err)
+		}
+		if limitErr.Pixels != int64(astc.MaxImageDim)*astc.MaxImageDim || limitErr.MaxPixels != astc.DefaultMaxPixels {
+			t.Fatalf("%s: %+v", name, limitErr)
+		}
+		if !errors.Is(err, astc.ErrOutOfMem) || astc.ErrorCodeOf(err) != astc.ErrOutOfMem {
+			t.Fatalf("%s: err = %v, want ErrOutOfMem code", name, err)
+		}
+	}
+
+	// 3D texel counts overflow int64 and saturate.
+	h := astc.Header{BlockX: 3, BlockY: 3, BlockZ: 3, SizeX: astc.MaxImageDim, SizeY: astc.MaxImageDim, SizeZ: astc.MaxImageDim}
+	var limitErr *astc.DecodeLimitError
+	if err := (astc.DecodeOptions{MaxBlocks: -1}).CheckLimits(h); !errors.As(err, &limitErr) || limitErr.Pixels != 1<<63-1 {
+		t.Fatalf("CheckLimits(%v) = %v", h, err)
+	}
+	if err := (astc.DecodeOptions{MaxPixels: -1, MaxBlocks: -1}).CheckLimits(h); err != nil {
+		t.Fatalf("CheckLimits without limits = %v", err)
+	}
+}
+
+func TestDecodeLimits_Configurable(t *testing.T) {
+	const w, h = 40, 24
+	data, err := astc.EncodeRGBA8(make([]byte, w*h*4), w, h, 8, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		maxPixels, maxBlocks int64
+		ok                   bool
+	}{
+		{0, 0, true},
+		{w * h, 15, true},
+		{w*h - 1, 0, false},
+		{0, 14, false},
+		{-1, -1, true},
+	} {
+		dec, err := astc.NewDecoder(astc.WithDecodeLimits(tc.maxPixels, tc.maxBlocks))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _, _, _, err = dec.DecodeRGBA8(data)
+		if (err == nil) != tc.ok {
+			t.Fatalf("Decoder with limits %d/%d: err = %v, want ok=%v", tc.maxPixels, tc.maxBlocks, err, tc.ok)
+		}
+
+		cfg, err := astc.ConfigInit(astc.ProfileLDR, 8, 8, 1, 0, astc.FlagDecompressOnly)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx, err := astc.ContextAlloc(&cfg, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		img := &astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeU8, DataU8: make([]byte, w*h*4)}
+		opts := astc.DecodeOptions{MaxPixels: tc.maxPixels, MaxBlocks: tc.maxBlocks}
+		err = ctx.DecompressImageWithOptions(data[astc.HeaderSize:], img, astc.SwizzleRGBA, 0, opts)
+		ctx.Close()
+		var limitErr *astc.DecodeLimitError
+		if tc.ok && err != nil || !tc.ok && !errors.As(err, &limitErr) {
+			t.Fatalf("DecompressImageWithOptions with limits %d/%d: err = %v, want ok=%v", tc.maxPixels, tc.maxBlocks, err, tc.ok)
+		}
+	}
+}
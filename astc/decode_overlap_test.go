@@ -0,0 +1,71 @@
+package astc_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/arm-software/astc-encoder/astc"
+)
+
+func TestDecode_OverlappingOutput(t *testing.T) {
+	const w, h = 37, 29
+	pix := make([]byte, w*h*4)
+	for i := range pix {
+		pix[i] = uint8(i*13 + i/7)
+	}
+	data, err := astc.EncodeRGBA8WithProfileAndQuality(pix, w, h, 4, 4, astc.ProfileLDR, astc.EncodeFast)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	want, _, _, err := astc.DecodeRGBA8WithProfile(data, astc.ProfileLDR)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	hdr, blocks, err := astc.ParseFile(data)
+	if err != nil {
+		t.Fatalf("ParseFile: %v", err)
+	}
+
+	// The payload is placed at the start, in the middle and at the end of the output buffer.
+	offsets := []int{0, len(want)/2 - len(blocks)/2, len(want) - len(blocks)}
+	for _, off := range offsets {
+		arena := make([]byte, len(want))
+		copy(arena[off:], blocks)
+		if err := astc.DecodeRGBA8VolumeFromParsedWithProfileInto(astc.ProfileLDR, hdr, arena[off:off+len(blocks)], arena); err != nil {
+			t.Fatalf("DecodeRGBA8VolumeFromParsedWithProfileInto (offset %d): %v", off, err)
+		}
+		if !bytes.Equal(arena, want) {
+			t.Fatalf("DecodeRGBA8VolumeFromParsedWithProfileInto (offset %d): output differs", off)
+		}
+
+		arena = make([]byte, len(want))
+		fileOff := min(off, len(want)-len(data))
+		copy(arena[fileOff:], data)
+		if _, _, _, err := astc.DecodeRGBA8VolumeWithProfileInto(arena[fileOff:fileOff+len(data)], astc.ProfileLDR, arena); err != nil {
+			t.Fatalf("DecodeRGBA8VolumeWithProfileInto (offset %d): %v", fileOff, err)
+		}
+		if !bytes.Equal(arena, want) {
+			t.Fatalf("DecodeRGBA8VolumeWithProfileInto (offset %d): output differs", fileOff)
+		}
+
+		cfg, err := astc.ConfigInit(astc.ProfileLDR, 4, 4, 1, 10, 0)
+		if err != nil {
+			t.Fatalf("ConfigInit: %v", err)
+		}
+		ctx, err := astc.ContextAlloc(&cfg, 1)
+		if err != nil {
+			t.Fatalf("ContextAlloc: %v", err)
+		}
+		arena = make([]byte, len(want))
+		copy(arena[off:], blocks)
+		img := &astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeU8, DataU8: arena}
+		swz := astc.Swizzle{R: astc.SwzR, G: astc.SwzG, B: astc.SwzB, A: astc.SwzA}
+		if err := ctx.DecompressImage(arena[off:off+len(blocks)], img, swz, 0); err != nil {
+			t.Fatalf("DecompressImage (offset %d): %v", off, err)
+		}
+		ctx.Close()
+		if !bytes.Equal(arena, want) {
+			t.Fatalf("DecompressImage (offset %d): output differs", off)
+		}
+	}
+}
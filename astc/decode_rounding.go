@@ -0,0 +1,129 @@
+package astc
+
+// RoundingProfile selects the rounding used when LDR blocks are decoded to 8-bit texels. ASTC
+// decoding is bit-exact up to the final conversion, but hardware decoders have been observed to
+// differ from the reference in two places: the rounding of the endpoint interpolation and the
+// conversion of the interpolated 16-bit value to 8 bits. The profiles model those differences so
+// device-specific output can be reproduced on the CPU when investigating platform artifacts. Each
+// differs from the reference by at most 1 per channel.
+type RoundingProfile uint8
+
+const (
+	// RoundingReference interpolates with round-half-up and keeps the top 8 bits of the 16-bit
+	// result, as the specification's decode_unorm8 mode (matches upstream).
+	RoundingReference RoundingProfile = iota
+	// RoundingRTZ interpolates with round-toward-zero (no rounding bias) and keeps the top 8 bits,
+	// as decoders which truncate the interpolation.
+	RoundingRTZ
+	// RoundingRTN interpolates like the reference and converts the 16-bit result to 8 bits with
+	// round-to-nearest, as decoders which output UNORM16 and convert it to UNORM8 afterwards.
+	RoundingRTN
+)
+
+// String returns the profile name, e.g. "rtz".
+func (r RoundingProfile) String() string {
+	switch r {
+	case RoundingReference:
+		return "reference"
+	case RoundingRTZ:
+		return "rtz"
+	case RoundingRTN:
+		return "rtn"
+	default:
+		return "invalid"
+	}
+}
+
+// unorm16ToUnorm8 converts an interpolated 16-bit value to 8 bits under rounding.
+func (r RoundingProfile) unorm16ToUnorm8(v int) uint8 {
+	if r == RoundingRTN {
+		return uint8((v*255 + 32767) / 65535)
+	}
+	return uint8(v >> 8)
+}
+
+// decodeBlockToRGBA8Rounded is decodeBlockToRGBA8 with the rounding of rounding. It is a generic
+// per-texel loop; RoundingReference output matches decodeBlockToRGBA8.
+func decodeBlockToRGBA8Rounded(profile Profile, ctx *decodeContext, block []byte, out []byte, rounding RoundingProfile) {
+	texelCount := ctx.texelCount
+	dst := out[:texelCount*4]
+
+	scb := physicalToSymbolicWithCtx(block, ctx)
+	switch scb.blockType {
+	case symBlockError, symBlockConstF16:
+		fillErrorRGBA8(dst)
+		return
+	case symBlockConstU16:
+		var c [4]uint8
+		for i := range c {
+			c[i] = rounding.unorm16ToUnorm8(int(scb.constantColor[i]))
+		}
+		fillConstRGBA8(dst, c[0], c[1], c[2], c[3])
+		return
+	}
+
+	bmi := ctx.blockMode(int(scb.blockMode))
+	if !bmi.ok {
+		fillErrorRGBA8(dst)
+		return
+	}
+
+	partitionCount := int(scb.partitionCount)
+	var partByTexel []uint8
+	if partitionCount > 1 {
+		pt := ctx.partitionTables[partitionCount]
+		if pt == nil {
+			fillErrorRGBA8(dst)
+			return
+		}
+		pidx := int(scb.partitionIndex) & ((1 << partitionIndexBits) - 1)
+		partByTexel = pt.data[pidx*texelCount : pidx*texelCount+texelCount]
+	}
+
+	var ep0, ep1 [blockMaxPartitions][4]int
+	for p := 0; p < partitionCount; p++ {
+		_, _, ep0[p], ep1[p] = unpackColorEndpoints(profile, scb.colorFormats[p], scb.colorValues[p][:])
+	}
+
+	plane2Component := -1
+	if bmi.isDualPlane {
+		plane2Component = int(scb.plane2Component)
+	}
+	bias := 32
+	if rounding == RoundingRTZ {
+		bias = 0
+	}
+
+	wvals := scb.weights[:]
+	texelWeight := func(tix, planeOffset int) int {
+		if bmi.noDecimation {
+			return int(wvals[tix+planeOffset])
+		}
+		e := bmi.decimation[tix]
+		sum := 8
+		for i := range e.idx {
+			sum += int(wvals[int(e.idx[i])+planeOffset]) * int(e.w[i])
+		}
+		return sum >> 4
+	}
+
+	for tix := 0; tix < texelCount; tix++ {
+		part := 0
+		if partByTexel != nil {
+			part = int(partByTexel[tix])
+		}
+		w1 := texelWeight(tix, 0)
+		w2 := w1
+		if plane2Component >= 0 {
+			w2 = texelWeight(tix, weightsPlane2Offset)
+		}
+		for c := 0; c < 4; c++ {
+			w := w1
+			if c == plane2Component {
+				w = w2
+			}
+			v := (ep0[part][c]*(64-w) + ep1[part][c]*w + bias) >> 6
+			dst[tix*4+c] = rounding.unorm16ToUnorm8(v)
+		}
+	}
+}
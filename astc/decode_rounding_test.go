@@ -0,0 +1,41 @@
+package astc
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDecodeBlockToRGBA8Rounded_ReferenceMatchesDecode(t *testing.T) {
+	const w, h = 48, 48
+	pix := make([]byte, w*h*4)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			// Independent alpha and hard edges exercise dual-plane and partitioned modes.
+			v := uint8(x * 5)
+			if (x/5+y/7)%2 == 0 {
+				v = 255 - uint8(y*3)
+			}
+			copy(pix[(y*w+x)*4:], []byte{v, uint8(y * 5), uint8(x * y), uint8(x*16 + y%3)})
+		}
+	}
+
+	for _, bs := range [][2]int{{4, 4}, {6, 6}, {8, 5}, {12, 12}} {
+		for _, profile := range []Profile{ProfileLDR, ProfileLDRSRGB} {
+			data, err := EncodeRGBA8WithProfileAndQuality(pix, w, h, bs[0], bs[1], profile, EncodeThorough)
+			if err != nil {
+				t.Fatalf("%dx%d: encode: %v", bs[0], bs[1], err)
+			}
+			ctx := getDecodeContext(bs[0], bs[1], 1)
+			want := make([]byte, ctx.texelCount*4)
+			got := make([]byte, ctx.texelCount*4)
+			for off := HeaderSize; off < len(data); off += BlockBytes {
+				block := data[off : off+BlockBytes]
+				decodeBlockToRGBA8(profile, ctx, block, want)
+				decodeBlockToRGBA8Rounded(profile, ctx, block, got, RoundingReference)
+				if !bytes.Equal(got, want) {
+					t.Fatalf("%dx%d profile %v: block at %d decodes differently", bs[0], bs[1], profile, off)
+				}
+			}
+		}
+	}
+}
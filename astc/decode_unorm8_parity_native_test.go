@@ -12,6 +12,19 @@ import (
 )
 
 func TestCompress_UseDecodeUNORM8_U8SSE_CloseToNative(t *testing.T) {
+	checkU8SSECloseToNative(t, astc.ProfileLDR, astc.FlagUseDecodeUNORM8, native.FlagUseDecodeUNORM8)
+}
+
+// TestCompress_SRGB_U8SSE_CloseToNative cross-validates the sRGB profile, whose encoder always
+// evaluates errors on decode_unorm8 texels reconstructed from (v << 8) | 0x80 endpoints.
+func TestCompress_SRGB_U8SSE_CloseToNative(t *testing.T) {
+	checkU8SSECloseToNative(t, astc.ProfileLDRSRGB, 0, 0)
+}
+
+// checkU8SSECloseToNative compresses random texels with both implementations and checks that the
+// Go encoding's 8-bit squared error is within 1.6x of the native one.
+func checkU8SSECloseToNative(t *testing.T, profile astc.Profile, flags astc.Flags, nativeFlags native.Flags) {
+	t.Helper()
 	if !native.Enabled() {
 		t.Fatalf("native.Enabled() = false; want true")
 	}
@@ -30,7 +43,7 @@ func TestCompress_UseDecodeUNORM8_U8SSE_CloseToNative(t *testing.T) {
 	src := make([]byte, width*height*depth*4)
 	_, _ = rnd.Read(src)
 
-	cfgGo, err := astc.ConfigInit(astc.ProfileLDR, blockX, blockY, blockZ, quality, astc.FlagUseDecodeUNORM8)
+	cfgGo, err := astc.ConfigInit(profile, blockX, blockY, blockZ, quality, flags)
 	if err != nil {
 		t.Fatalf("astc.ConfigInit: %v", err)
 	}
@@ -40,7 +53,7 @@ func TestCompress_UseDecodeUNORM8_U8SSE_CloseToNative(t *testing.T) {
 	}
 	defer ctxGo.Close()
 
-	cfgN, err := native.ConfigInit(astc.ProfileLDR, blockX, blockY, blockZ, quality, native.FlagUseDecodeUNORM8)
+	cfgN, err := native.ConfigInit(profile, blockX, blockY, blockZ, quality, nativeFlags)
 	if err != nil {
 		t.Fatalf("native.ConfigInit: %v", err)
 	}
@@ -80,10 +93,10 @@ func TestCompress_UseDecodeUNORM8_U8SSE_CloseToNative(t *testing.T) {
 
 	decGo := make([]byte, len(src))
 	decN := make([]byte, len(src))
-	if err := astc.DecodeRGBA8VolumeFromParsedWithProfileInto(astc.ProfileLDR, hdr, outGo, decGo); err != nil {
+	if err := astc.DecodeRGBA8VolumeFromParsedWithProfileInto(profile, hdr, outGo, decGo); err != nil {
 		t.Fatalf("DecodeRGBA8VolumeFromParsedWithProfileInto(go): %v", err)
 	}
-	if err := astc.DecodeRGBA8VolumeFromParsedWithProfileInto(astc.ProfileLDR, hdr, outN, decN); err != nil {
+	if err := astc.DecodeRGBA8VolumeFromParsedWithProfileInto(profile, hdr, outN, decN); err != nil {
 		t.Fatalf("DecodeRGBA8VolumeFromParsedWithProfileInto(native): %v", err)
 	}
 
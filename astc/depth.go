@@ -0,0 +1,46 @@
+package astc
+
+import "errors"
+
+// depthChannelWeight is the channel weighting used for single-channel depth encoding. Depth is
+// replicated into RGB so that the HDR luminance endpoint formats can represent it, but only red is
+// read back, so the other channels carry no weight.
+var depthChannelWeight = [4]float32{1, 0, 0, 0}
+
+// EncodeDepthR32F encodes a single-channel float32 image (for example a depth or shadow map, or a
+// heightfield) into a .astc file using ProfileHDR.
+//
+// Each value is replicated into RGB with alpha fixed at 1 so blocks can use the HDR luminance
+// endpoint formats, and the error metric ignores the unused channels. HDR endpoints cannot store
+// negative values; negative and NaN inputs decode as 0. Use DecodeDepthR32F to read the values back.
+func EncodeDepthR32F(depth []float32, width, height int, blockX, blockY int, quality EncodeQuality) ([]byte, error) {
+	if width <= 0 || height <= 0 {
+		return nil, errors.New("astc: invalid image dimensions")
+	}
+	if len(depth) != width*height {
+		return nil, errors.New("astc: invalid R32F buffer length")
+	}
+
+	pix := make([]float32, width*height*4)
+	for i, v := range depth {
+		pix[i*4+0] = v
+		pix[i*4+1] = v
+		pix[i*4+2] = v
+		pix[i*4+3] = 1
+	}
+	return encodeRGBAF32(pix, width, height, blockX, blockY, ProfileHDR, quality, depthChannelWeight)
+}
+
+// DecodeDepthR32F decodes a 2D .astc file into a single-channel float32 image, returning the red
+// channel of each texel. It is the counterpart of EncodeDepthR32F.
+func DecodeDepthR32F(astcData []byte) (depth []float32, width, height int, err error) {
+	pix, width, height, err := DecodeRGBAF32WithProfile(astcData, ProfileHDR)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	depth = make([]float32, width*height)
+	for i := range depth {
+		depth[i] = pix[i*4]
+	}
+	return depth, width, height, nil
+}
@@ -0,0 +1,54 @@
+package astc_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/arm-software/astc-encoder/astc"
+)
+
+func TestEncodeDepthR32F_RoundTrip(t *testing.T) {
+	const (
+		w = 32
+		h = 24
+	)
+	depth := make([]float32, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			// A smooth slope with a step edge, typical of shadow-map depth.
+			v := 0.1 + 0.02*float64(x) + 0.01*float64(y)
+			if x > 20 {
+				v += 0.5
+			}
+			depth[y*w+x] = float32(v)
+		}
+	}
+
+	data, err := astc.EncodeDepthR32F(depth, w, h, 4, 4, astc.EncodeMedium)
+	if err != nil {
+		t.Fatalf("EncodeDepthR32F: %v", err)
+	}
+	got, gw, gh, err := astc.DecodeDepthR32F(data)
+	if err != nil {
+		t.Fatalf("DecodeDepthR32F: %v", err)
+	}
+	if gw != w || gh != h || len(got) != w*h {
+		t.Fatalf("unexpected decoded size %dx%d (%d values)", gw, gh, len(got))
+	}
+
+	var sum float64
+	for i := range depth {
+		d := float64(got[i] - depth[i])
+		sum += d * d
+		if rel := math.Abs(d) / float64(depth[i]); rel > 0.05 {
+			t.Fatalf("texel %d: got %v want %v", i, got[i], depth[i])
+		}
+	}
+	if rmse := math.Sqrt(sum / float64(len(depth))); rmse > 0.01 {
+		t.Fatalf("rmse too high: %v", rmse)
+	}
+
+	if _, err := astc.EncodeDepthR32F(depth[:10], w, h, 4, 4, astc.EncodeMedium); err == nil {
+		t.Fatalf("expected error for short buffer")
+	}
+}
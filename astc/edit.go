@@ -0,0 +1,139 @@
+package astc
+
+import (
+	"errors"
+	"image"
+	"runtime"
+	"sync/atomic"
+)
+
+// Edit decodes the 2D .astc file data, lets fn modify the decoded pixels, and returns a new file in
+// which only the blocks intersecting the dirty rectangles fn returns are re-encoded. Every other
+// block is copied from data unchanged, so untouched regions keep their exact encoding and the
+// encode cost scales with the edited area rather than the texture.
+//
+// LDR profiles decode to a TypeU8 image and HDR profiles to a TypeF32 image (see
+// DecodeRGBAF32WithProfile for the float values). fn may modify the pixels in place, but not the
+// image dimensions or data type. Rectangles are in pixels and clipped to the image; returning none
+// yields a copy of data. Dirty blocks are encoded like an Encoder created with the file's block
+// size and profile and otherwise default options would encode them, edge blocks included.
+//
+// data is not modified. An error from fn is returned as is.
+func Edit(data []byte, profile Profile, fn func(img *Image) (dirty []image.Rectangle, err error)) ([]byte, error) {
+	if fn == nil {
+		return nil, errors.New("astc: nil edit function")
+	}
+	h, blocks, err := ParseFile(data)
+	if err != nil {
+		return nil, err
+	}
+	if h.SizeZ != 1 || h.BlockZ != 1 {
+		return nil, errors.New("astc: Edit only supports 2D images")
+	}
+	width, height := int(h.SizeX), int(h.SizeY)
+	bx, by := int(h.BlockX), int(h.BlockY)
+	blocksX, blocksY, _, _, err := h.BlockCount()
+	if err != nil {
+		return nil, err
+	}
+	if err := checkDecodeLimits(h, 0, 0); err != nil {
+		return nil, err
+	}
+
+	hdr := profile == ProfileHDR || profile == ProfileHDRRGBLDRAlpha
+	img := &Image{DimX: width, DimY: height, DimZ: 1}
+	if hdr {
+		img.DataType = TypeF32
+		img.DataF32 = make([]float32, width*height*4)
+		err = DecodeRGBAF32VolumeFromParsedWithProfileInto(profile, h, blocks, img.DataF32)
+	} else {
+		img.DataType = TypeU8
+		img.DataU8 = make([]byte, width*height*4)
+		err = DecodeRGBA8VolumeFromParsedWithProfileInto(profile, h, blocks, img.DataU8)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rects, err := fn(img)
+	if err != nil {
+		return nil, err
+	}
+	if img.DimX != width || img.DimY != height || img.DimZ != 1 ||
+		(hdr && (img.DataType != TypeF32 || len(img.DataF32) != width*height*4)) ||
+		(!hdr && (img.DataType != TypeU8 || len(img.DataU8) != width*height*4)) {
+		return nil, errors.New("astc: edit function changed the image layout")
+	}
+
+	out := append([]byte(nil), data[:HeaderSize+len(blocks)]...)
+	dirtyBlock := make([]bool, blocksX*blocksY)
+	var dirty []int
+	bounds := image.Rect(0, 0, width, height)
+	for _, r := range rects {
+		r = r.Intersect(bounds)
+		if r.Empty() {
+			continue
+		}
+		for y := r.Min.Y / by; y <= (r.Max.Y-1)/by; y++ {
+			for x := r.Min.X / bx; x <= (r.Max.X-1)/bx; x++ {
+				if i := y*blocksX + x; !dirtyBlock[i] {
+					dirtyBlock[i] = true
+					dirty = append(dirty, i)
+				}
+			}
+		}
+	}
+	if len(dirty) == 0 {
+		return out, nil
+	}
+
+	enc, err := NewEncoder(WithBlockSize(bx, by), WithProfile(profile))
+	if err != nil {
+		return nil, err
+	}
+	var next atomic.Int64
+	err = runWorkers(min(runtime.GOMAXPROCS(0), len(dirty)), func(int) error {
+		ctx, err := ContextAlloc(&enc.cfg, 1)
+		if err != nil {
+			return err
+		}
+		defer ctx.Close()
+		sub := &Image{DimZ: 1, DataType: img.DataType}
+		subU8 := make([]byte, bx*by*4)
+		subF32 := make([]float32, bx*by*4)
+		for {
+			n := int(next.Add(1)) - 1
+			if n >= len(dirty) {
+				return nil
+			}
+			i := dirty[n]
+			x0, y0 := i%blocksX*bx, i/blocksX*by
+			sub.DimX, sub.DimY = min(bx, width-x0), min(by, height-y0)
+			rowLen := sub.DimX * 4
+			for y := range sub.DimY {
+				src := ((y0+y)*width + x0) * 4
+				if hdr {
+					copy(subF32[y*rowLen:], img.DataF32[src:src+rowLen])
+				} else {
+					copy(subU8[y*rowLen:], img.DataU8[src:src+rowLen])
+				}
+			}
+			if hdr {
+				sub.DataF32 = subF32[:sub.DimY*rowLen]
+			} else {
+				sub.DataU8 = subU8[:sub.DimY*rowLen]
+			}
+			block := out[HeaderSize+i*BlockBytes : HeaderSize+(i+1)*BlockBytes]
+			if err := ctx.CompressImage(sub, enc.opts.swizzle, block, 0); err != nil {
+				return err
+			}
+			if err := ctx.CompressReset(); err != nil {
+				return err
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
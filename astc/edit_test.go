@@ -0,0 +1,123 @@
+package astc_test
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"testing"
+
+	"github.com/arm-software/astc-encoder/astc"
+)
+
+func TestEdit(t *testing.T) {
+	const w, h = 45, 23
+	pix := make([]byte, w*h*4)
+	for i := range pix {
+		pix[i] = uint8(i*7 + i/13)
+	}
+	enc, err := astc.NewEncoder(astc.WithBlockSize(6, 6))
+	if err != nil {
+		t.Fatalf("NewEncoder: %v", err)
+	}
+	orig, err := enc.EncodeRGBA8(pix, w, h)
+	if err != nil {
+		t.Fatalf("EncodeRGBA8: %v", err)
+	}
+	decoded, _, _, err := astc.DecodeRGBA8(orig)
+	if err != nil {
+		t.Fatalf("DecodeRGBA8: %v", err)
+	}
+
+	// Stamp a rectangle reaching the right edge (partial blocks) and a single pixel.
+	stamp := image.Rect(20, 8, 45, 15)
+	dot := image.Pt(3, 20)
+	edited, err := astc.Edit(orig, astc.ProfileLDR, func(img *astc.Image) ([]image.Rectangle, error) {
+		if !bytes.Equal(img.DataU8, decoded) {
+			t.Fatalf("edit callback did not get the decoded image")
+		}
+		for y := stamp.Min.Y; y < stamp.Max.Y; y++ {
+			for x := stamp.Min.X; x < stamp.Max.X; x++ {
+				copy(img.DataU8[(y*w+x)*4:], []byte{255, 0, uint8(x * 5), 255})
+			}
+		}
+		copy(img.DataU8[(dot.Y*w+dot.X)*4:], []byte{0, 255, 0, 255})
+		return []image.Rectangle{stamp, image.Rect(dot.X, dot.Y, dot.X+1, dot.Y+1), image.Rect(100, 100, 120, 120)}, nil
+	})
+	if err != nil {
+		t.Fatalf("Edit: %v", err)
+	}
+
+	// Dirty blocks match an encode of the edited image; the others are the original blocks.
+	for y := stamp.Min.Y; y < stamp.Max.Y; y++ {
+		for x := stamp.Min.X; x < stamp.Max.X; x++ {
+			copy(decoded[(y*w+x)*4:], []byte{255, 0, uint8(x * 5), 255})
+		}
+	}
+	copy(decoded[(dot.Y*w+dot.X)*4:], []byte{0, 255, 0, 255})
+	full, err := enc.EncodeRGBA8(decoded, w, h)
+	if err != nil {
+		t.Fatalf("EncodeRGBA8: %v", err)
+	}
+	const blocksX = (w + 5) / 6
+	for i := 0; i*astc.BlockBytes < len(orig)-astc.HeaderSize; i++ {
+		bx, by := i%blocksX, i/blocksX
+		blockRect := image.Rect(bx*6, by*6, bx*6+6, by*6+6)
+		dirty := blockRect.Overlaps(stamp) || dot.In(blockRect)
+		off := astc.HeaderSize + i*astc.BlockBytes
+		got := edited[off : off+astc.BlockBytes]
+		want := orig[off : off+astc.BlockBytes]
+		if dirty {
+			want = full[off : off+astc.BlockBytes]
+		}
+		if !bytes.Equal(got, want) {
+			t.Fatalf("block (%d,%d) dirty=%v: got %x, want %x", bx, by, dirty, got, want)
+		}
+	}
+
+	// No dirty rectangles return a copy; callback errors are passed through.
+	same, err := astc.Edit(orig, astc.ProfileLDR, func(*astc.Image) ([]image.Rectangle, error) { return nil, nil })
+	if err != nil || !bytes.Equal(same, orig) || &same[0] == &orig[0] {
+		t.Fatalf("clean edit: err %v, equal %v", err, bytes.Equal(same, orig))
+	}
+	errStamp := errors.New("stamp failed")
+	if _, err := astc.Edit(orig, astc.ProfileLDR, func(*astc.Image) ([]image.Rectangle, error) { return nil, errStamp }); err != errStamp {
+		t.Fatalf("got %v, want the callback error", err)
+	}
+	if _, err := astc.Edit(orig, astc.ProfileLDR, func(img *astc.Image) ([]image.Rectangle, error) {
+		img.DimX--
+		return nil, nil
+	}); err == nil {
+		t.Fatalf("Edit accepted a resized image")
+	}
+}
+
+func TestEdit_HDR(t *testing.T) {
+	const w, h = 8, 8
+	pix := make([]float32, w*h*4)
+	for i := range pix {
+		pix[i] = float32(i%29) * 0.25
+	}
+	enc, err := astc.NewEncoder(astc.WithBlockSize(4, 4), astc.WithProfile(astc.ProfileHDR))
+	if err != nil {
+		t.Fatalf("NewEncoder: %v", err)
+	}
+	orig, err := enc.EncodeRGBAF32(pix, w, h)
+	if err != nil {
+		t.Fatalf("EncodeRGBAF32: %v", err)
+	}
+	edited, err := astc.Edit(orig, astc.ProfileHDR, func(img *astc.Image) ([]image.Rectangle, error) {
+		if img.DataType != astc.TypeF32 {
+			t.Fatalf("HDR edit got data type %v", img.DataType)
+		}
+		for i := 0; i < 4*4; i++ {
+			img.DataF32[i] = 12
+		}
+		return []image.Rectangle{image.Rect(0, 0, 4, 1)}, nil
+	})
+	if err != nil {
+		t.Fatalf("Edit: %v", err)
+	}
+	if bytes.Equal(edited[16:32], orig[16:32]) || !bytes.Equal(edited[32:], orig[32:]) {
+		t.Fatalf("HDR edit changed the wrong blocks")
+	}
+}
@@ -54,53 +54,11 @@ func validBlockModes(blockX, blockY, blockZ int) []blockModeDesc {
 	}
 	blockModeCacheMu.RUnlock()
 
-	var modes []blockModeDesc
-	for mode := 0; mode < (1 << 11); mode++ {
-		if blockZ == 1 {
-			xw, yw, dp, q, wb, ok := decodeBlockMode2D(mode)
-			if !ok || xw > blockX || yw > blockY {
-				continue
-			}
-			modes = append(modes, blockModeDesc{
-				mode:               mode,
-				xWeights:           xw,
-				yWeights:           yw,
-				zWeights:           1,
-				isDualPlane:        dp,
-				weightQuant:        q,
-				weightBits:         wb,
-				sampleTexelIndices: makeWeightGridSampleMap(blockX, blockY, blockZ, xw, yw, 1),
-			})
-		} else {
-			xw, yw, zw, dp, q, wb, ok := decodeBlockMode3D(mode)
-			if !ok || xw > blockX || yw > blockY || zw > blockZ {
-				continue
-			}
-			modes = append(modes, blockModeDesc{
-				mode:               mode,
-				xWeights:           xw,
-				yWeights:           yw,
-				zWeights:           zw,
-				isDualPlane:        dp,
-				weightQuant:        q,
-				weightBits:         wb,
-				sampleTexelIndices: makeWeightGridSampleMap(blockX, blockY, blockZ, xw, yw, zw),
-			})
-		}
-	}
-
-	// Sort by a crude "quality" heuristic to make quality presets deterministic.
-	sort.Slice(modes, func(i, j int) bool {
-		ai := modes[i].xWeights * modes[i].yWeights * modes[i].zWeights
-		aj := modes[j].xWeights * modes[j].yWeights * modes[j].zWeights
-		if ai != aj {
-			return ai > aj
-		}
-		if modes[i].weightQuant != modes[j].weightQuant {
-			return modes[i].weightQuant > modes[j].weightQuant
-		}
-		return modes[i].weightBits < modes[j].weightBits
-	})
+	order := blockModeOrder(blockX, blockY, blockZ)
+	modes := make([]blockModeDesc, len(order))
+	for i, mode := range order {
+		modes[i] = makeBlockModeDesc(mode, blockX, blockY, blockZ)
+	}
 
 	blockModeCacheMu.Lock()
 	// Another goroutine may have populated it; keep the first.
@@ -114,6 +72,122 @@ func validBlockModes(blockX, blockY, blockZ int) []blockModeDesc {
 	return modes
 }
 
+// blockModeOrder returns the block modes which fit the footprint in the order validBlockModes lists
+// them: sorted by a crude "quality" heuristic to make quality presets deterministic.
+func blockModeOrder(blockX, blockY, blockZ int) []int {
+	type entry struct {
+		mode, weights, bits int
+		quant               quantMethod
+	}
+	var entries []entry
+	for mode := 0; mode < (1 << 11); mode++ {
+		if !blockModeFits(mode, blockX, blockY, blockZ) {
+			continue
+		}
+		d := blockModeDesc{zWeights: 1}
+		if blockZ == 1 {
+			d.xWeights, d.yWeights, _, d.weightQuant, d.weightBits, _ = decodeBlockMode2D(mode)
+		} else {
+			d.xWeights, d.yWeights, d.zWeights, _, d.weightQuant, d.weightBits, _ = decodeBlockMode3D(mode)
+		}
+		entries = append(entries, entry{mode, d.xWeights * d.yWeights * d.zWeights, d.weightBits, d.weightQuant})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].weights != entries[j].weights {
+			return entries[i].weights > entries[j].weights
+		}
+		if entries[i].quant != entries[j].quant {
+			return entries[i].quant > entries[j].quant
+		}
+		return entries[i].bits < entries[j].bits
+	})
+	order := make([]int, len(entries))
+	for i, e := range entries {
+		order[i] = e.mode
+	}
+	return order
+}
+
+// blockModeFits reports whether mode is a valid block mode whose weight grid fits the footprint.
+func blockModeFits(mode, blockX, blockY, blockZ int) bool {
+	if blockZ == 1 {
+		xw, yw, _, _, _, ok := decodeBlockMode2D(mode)
+		return ok && xw <= blockX && yw <= blockY
+	}
+	xw, yw, zw, _, _, _, ok := decodeBlockMode3D(mode)
+	return ok && xw <= blockX && yw <= blockY && zw <= blockZ
+}
+
+// makeBlockModeDesc describes a block mode for which blockModeFits holds.
+func makeBlockModeDesc(mode, blockX, blockY, blockZ int) blockModeDesc {
+	d := blockModeDesc{mode: mode, zWeights: 1}
+	if blockZ == 1 {
+		d.xWeights, d.yWeights, d.isDualPlane, d.weightQuant, d.weightBits, _ = decodeBlockMode2D(mode)
+	} else {
+		d.xWeights, d.yWeights, d.zWeights, d.isDualPlane, d.weightQuant, d.weightBits, _ = decodeBlockMode3D(mode)
+	}
+	d.sampleTexelIndices = makeWeightGridSampleMap(blockX, blockY, blockZ, d.xWeights, d.yWeights, d.zWeights)
+	return d
+}
+
+type boundedBlockModeCacheKey struct {
+	block       blockModeCacheKey
+	lo, hi      int
+	disallowed  BlockModeMask
+	noDualPlane bool
+}
+
+// boundedBlockModeCacheCap bounds boundedBlockModeCache. The weight bounds give a few hundred keys
+// per footprint at most, but the block mode masks of Config.DisallowedBlockModes and
+// ForcedBlockModes are arbitrary, so a process creating contexts with ever new masks would
+// otherwise grow the cache without limit. A full cache is emptied before the next insertion.
+const boundedBlockModeCacheCap = 256
+
+var (
+	boundedBlockModeCacheMu sync.RWMutex
+	boundedBlockModeCache   = map[boundedBlockModeCacheKey][]blockModeDesc{}
+)
+
+// tunedBlockModes returns validBlockModes restricted to the weight quantization bounds and allowed
+// modes of tune, without dual-plane modes if tune forbids them, in the same order, so that the
+// block mode limit applies to the allowed modes.
+func tunedBlockModes(blockX, blockY, blockZ int, tune *encoderTuning) []blockModeDesc {
+	modes := validBlockModes(blockX, blockY, blockZ)
+	if tune.weightQuantMin == 0 && tune.weightQuantMax == 0 && tune.disallowedModes == nil && !tune.noDualPlane {
+		return modes
+	}
+
+	key := boundedBlockModeCacheKey{block: makeBlockModeCacheKey(blockX, blockY, blockZ), lo: tune.weightQuantMin, hi: tune.weightQuantMax, noDualPlane: tune.noDualPlane}
+	if tune.disallowedModes != nil {
+		key.disallowed = *tune.disallowedModes
+	}
+	boundedBlockModeCacheMu.RLock()
+	got, ok := boundedBlockModeCache[key]
+	boundedBlockModeCacheMu.RUnlock()
+	if ok {
+		return got
+	}
+
+	out := make([]blockModeDesc, 0, len(modes))
+	for _, m := range modes {
+		if quantInBounds(quantLevel(m.weightQuant), tune.weightQuantMin, tune.weightQuantMax) && !key.disallowed.Has(m.mode) && !(key.noDualPlane && m.isDualPlane) {
+			out = append(out, m)
+		}
+	}
+
+	boundedBlockModeCacheMu.Lock()
+	if got, ok := boundedBlockModeCache[key]; ok {
+		out = got
+	} else {
+		if len(boundedBlockModeCache) >= boundedBlockModeCacheCap {
+			clear(boundedBlockModeCache)
+		}
+		boundedBlockModeCache[key] = out
+	}
+	boundedBlockModeCacheMu.Unlock()
+	return out
+}
+
 func makeWeightGridSampleMap(blockX, blockY, blockZ, xWeights, yWeights, zWeights int) []uint16 {
 	weightsPerPlane := xWeights * yWeights * zWeights
 	out := make([]uint16, weightsPerPlane)
@@ -198,10 +272,12 @@ var (
 
 var weightQuantizeScrambledLUT [int(quant32) + 1][65]uint8
 
+// endpointExpandLDR and endpointExpandSRGB expand 8-bit endpoints to the 16-bit values the
+// decoder interpolates (upstream unpack_color_endpoints): v*257 for ProfileLDR and (v<<8)|0x80 for
+// ProfileLDRSRGB, alpha included.
 var (
 	endpointExpandLDR  [256]int32
 	endpointExpandSRGB [256]int32
-	normalXYZLUT       [256][256][3]float32
 )
 
 func init() {
@@ -252,26 +328,6 @@ func init() {
 			weightQuantizeScrambledLUT[q][u] = weightScrambleMap[q][best]
 		}
 	}
-
-	// Precompute normalized vectors for normal-map angular error evaluation (MAP_NORMAL mode).
-	for r := 0; r < 256; r++ {
-		x := float32(r)*(2.0/255.0) - 1.0
-		for a := 0; a < 256; a++ {
-			y := float32(a)*(2.0/255.0) - 1.0
-			z2 := 1.0 - x*x - y*y
-			if z2 < 0 {
-				z2 = 0
-			}
-			z := float32(math.Sqrt(float64(z2)))
-			n2 := x*x + y*y + z*z
-			if n2 > 0 {
-				invN := float32(1.0 / math.Sqrt(float64(n2)))
-				normalXYZLUT[r][a][0] = x * invN
-				normalXYZLUT[r][a][1] = y * invN
-				normalXYZLUT[r][a][2] = z * invN
-			}
-		}
-	}
 }
 
 func weightQuantizeScrambled(q quantMethod, u int) uint8 {
@@ -300,8 +356,9 @@ func blockErrorRGBA8(a, b []byte) uint64 {
 }
 
 func normalMapAngularError(origR, origA, decR, decA uint8) float64 {
-	ref := normalXYZLUT[origR][origA]
-	dec := normalXYZLUT[decR][decA]
+	lut := normalXYZLUT()
+	ref := lut[origR][origA]
+	dec := lut[decR][decA]
 	dot := float64(ref[0]*dec[0] + ref[1]*dec[1] + ref[2]*dec[2])
 	if dot > 1 {
 		dot = 1
@@ -725,11 +782,15 @@ func encodeBlockRGBA8LDR(profile Profile, blockX, blockY, blockZ int, texels []b
 		// Lower presets: still allow a little more partitioning headroom.
 		tune.maxPartitionCount++
 	}
+	candidates := tunedBlockModes(blockX, blockY, blockZ, &tune)
 	modeLimit := tune.modeLimit
-	if modeLimit <= 0 || modeLimit > len(modes) {
-		modeLimit = len(modes)
+	if modeLimit <= 0 || modeLimit > len(candidates) {
+		modeLimit = len(candidates)
+	}
+	modes = candidates[:modeLimit]
+	if tune.periodicModes > 0 && modeLimit < len(candidates) {
+		modes = periodicBlockModes(modes, candidates, texels, blockX, blockY, blockZ, tune.periodicModes)
 	}
-	modes = modes[:modeLimit]
 
 	// For higher presets we can use faster (approximate) weight projection to reduce division overhead.
 	// This does not affect the medium preset used by regression fixtures.
@@ -779,8 +840,46 @@ func encodeBlockRGBA8LDR(profile Profile, blockX, blockY, blockZ int, texels []b
 	}
 	alphaVary := alphaMin != alphaMax
 
+	// Higher presets store opaque and grayscale blocks with fewer endpoint channels, which frees
+	// bits for finer color quantization, and may switch to the delta form of the chosen format.
+	lumEndpoints := normalMap
+	tryDeltaEndpoints := false
+	if tune.extendedEndpointFormats && !rgbmMap {
+		tryDeltaEndpoints = true
+		if !normalMap {
+			gray := true
+			for t := 0; t < texelCount; t++ {
+				off := t * 4
+				if texels[off+0] != texels[off+1] || texels[off+0] != texels[off+2] {
+					gray = false
+					break
+				}
+			}
+			opaque := alphaMin == 255
+			switch {
+			case gray && opaque:
+				endpointFormat = fmtLuminance
+			case gray:
+				endpointFormat = fmtLuminanceAlpha
+			case opaque:
+				endpointFormat = fmtRGB
+			}
+			endpointStride = endpointFormatStride(endpointFormat)
+			lumEndpoints = gray
+		}
+	}
+
+	// Opaque blocks of a single chroma, such as shading gradients, may store each partition as a
+	// base color and a scale factor for the other endpoint: four color integers instead of six or
+	// eight.
+	rgbScale := tune.rgbScaleEndpoints && !normalMap && !rgbmMap && alphaMin == 255 &&
+		(endpointFormat == fmtRGB || endpointFormat == fmtRGBA) && blockFitsRGBScale(texels)
+
 	allowDualPlane := alphaVary
-	if allowDualPlane && quality >= EncodeThorough {
+	// Alpha with few distinct levels (cutout masks) is poorly served by shared weights even when
+	// it correlates with color, so it skips the correlation gate.
+	alphaLevelsGate := !normalMap && alphaLevelCount(texels, dualPlaneAlphaLevels+1) <= dualPlaneAlphaLevels
+	if allowDualPlane && quality >= EncodeThorough && !alphaLevelsGate {
 		thresh := tune.dualPlaneCorrelationThreshold
 		if normalMap && thresh < 0.99 {
 			thresh = 0.99
@@ -835,8 +934,16 @@ func encodeBlockRGBA8LDR(profile Profile, blockX, blockY, blockZ int, texels []b
 	candidates3Count := 0
 	candidates4Count := 0
 
+	if pt2 != nil && tuneOverride != nil && tune.lumaSplit && !normalMap && !alphaVary {
+		if idx, ok := lumaSplitPartition(pt2, texelLuma, partIndexLimit2); ok {
+			tune.addPartitionSeed(packPartitionSeed(2, idx))
+		}
+	}
 	if pt2 != nil {
 		want := tune.partitionCandidateLimit[2]
+		if tuneOverride != nil && tune.hasPartitionSeed(2) {
+			want = max(want, partIndexLimit2/2)
+		}
 		if want > partIndexLimit2 {
 			want = partIndexLimit2
 		}
@@ -845,11 +952,14 @@ func encodeBlockRGBA8LDR(profile Profile, blockX, blockY, blockZ int, texels []b
 		}
 		if want > 0 && partIndexLimit2 > 0 {
 			candidates2 = candidates2Arr[:want]
-			candidates2Count = selectBestPartitionIndices(candidates2, texels, pt2, 2, partIndexLimit2, alphaVary)
+			candidates2Count = partitionCandidatesWithSeeds(candidates2, want, tune.partitionSeeds, texels, pt2, 2, partIndexLimit2, alphaVary, channelWeight)
 		}
 	}
 	if pt3 != nil {
 		want := tune.partitionCandidateLimit[3]
+		if tuneOverride != nil && tune.hasPartitionSeed(3) {
+			want = max(want, partIndexLimit3/2)
+		}
 		if want > partIndexLimit3 {
 			want = partIndexLimit3
 		}
@@ -858,11 +968,14 @@ func encodeBlockRGBA8LDR(profile Profile, blockX, blockY, blockZ int, texels []b
 		}
 		if want > 0 && partIndexLimit3 > 0 {
 			candidates3 = candidates3Arr[:want]
-			candidates3Count = selectBestPartitionIndices(candidates3, texels, pt3, 3, partIndexLimit3, alphaVary)
+			candidates3Count = partitionCandidatesWithSeeds(candidates3, want, tune.partitionSeeds, texels, pt3, 3, partIndexLimit3, alphaVary, channelWeight)
 		}
 	}
 	if pt4 != nil {
 		want := tune.partitionCandidateLimit[4]
+		if tuneOverride != nil && tune.hasPartitionSeed(4) {
+			want = max(want, partIndexLimit4/2)
+		}
 		if want > partIndexLimit4 {
 			want = partIndexLimit4
 		}
@@ -871,7 +984,7 @@ func encodeBlockRGBA8LDR(profile Profile, blockX, blockY, blockZ int, texels []b
 		}
 		if want > 0 && partIndexLimit4 > 0 {
 			candidates4 = candidates4Arr[:want]
-			candidates4Count = selectBestPartitionIndices(candidates4, texels, pt4, 4, partIndexLimit4, alphaVary)
+			candidates4Count = partitionCandidatesWithSeeds(candidates4, want, tune.partitionSeeds, texels, pt4, 4, partIndexLimit4, alphaVary, channelWeight)
 		}
 	}
 
@@ -900,10 +1013,64 @@ func encodeBlockRGBA8LDR(profile Profile, blockX, blockY, blockZ int, texels []b
 	bestWeightLen := 0
 
 	var weightsUQArr [blockMaxWeights]uint8
+	var gridWeightsArr, gridWeights2Arr [blockMaxWeights]int
 	var endpointsArr [4]partitionEndpointsRGBA
+	var deltaEndpointsArr [4]partitionEndpointsRGBA
+	var seedColorsArr [4][2][4]uint8
+	bestEndpointFormat := endpointFormat
+	seedErrWeight := [4]float64{float64(channelWeight[0]), float64(channelWeight[1]), float64(channelWeight[2]), float64(channelWeight[3])}
 	var evalEp0 [4][4]int32
 	var evalEpd [4][4]int32
 
+	// Plain color data is scored by errKernel; the normal-map and RGBM metrics use the scalar loops.
+	plainError := !normalMap && !rgbmMap
+	var errKernel errorKernelRGBA8
+	if plainError {
+		errKernel.setTexels(texels[:texelCount*4])
+		errKernel.setChannelWeights([4]float64{wR, wG, wB, wA})
+		errKernel.setTexelWeights(tune.texelWeights)
+	}
+
+	// The partition indices each partition count searches, and the endpoint seeds of each, do not
+	// depend on the block mode; the seeds are computed on first use and shared by every mode.
+	var partIdxLists [blockMaxPartitions + 1][]int
+	var partIterCounts [blockMaxPartitions + 1]int
+	for _, partitionCount := range partitionCounts {
+		var candidates []int
+		partIndexLimit := 1
+		switch partitionCount {
+		case 2:
+			candidates = candidates2[:candidates2Count]
+			partIndexLimit = partIndexLimit2
+		case 3:
+			candidates = candidates3[:candidates3Count]
+			partIndexLimit = partIndexLimit3
+		case 4:
+			candidates = candidates4[:candidates4Count]
+			partIndexLimit = partIndexLimit4
+		}
+		partIterCounts[partitionCount] = partIndexLimit
+		if len(candidates) > 0 && !normalMap && (tuneOverride == nil || tune.hasPartitionSeed(partitionCount)) {
+			// Config tuning searches every index up to the limit, unless neighbor seeds narrow
+			// the search to the seeds and the scorer's best half of the indices.
+			partIdxLists[partitionCount] = candidates
+			partIterCounts[partitionCount] = len(candidates)
+		}
+	}
+
+	// Higher presets also try a percentile-trimmed endpoint seed for each partitioning.
+	seedCount := 1
+	if tune.endpointTrim > 0 && !normalMap {
+		seedCount = 2
+	}
+
+	var seedSlots [blockMaxPartitions + 1]int
+	for pc, n := range partIterCounts {
+		seedSlots[pc] = n * seedCount
+	}
+	var seedBuf [partitionSeedCacheInline]partitionSeed
+	seedCache := makePartitionSeedCache(seedBuf[:], &seedSlots)
+
 	for _, mode := range modes {
 		if mode.isDualPlane && !allowDualPlane {
 			continue
@@ -914,6 +1081,11 @@ func encodeBlockRGBA8LDR(profile Profile, blockX, blockY, blockZ int, texels []b
 		weightCountPerPlane := mode.xWeights * mode.yWeights * mode.zWeights
 		noDecimation := weightCountPerPlane == texelCount
 		sampleMap := mode.sampleTexelIndices
+		areaSampling := tune.areaWeightSampling && !noDecimation
+		var areaTab *areaSampleTable
+		if areaSampling {
+			areaTab = getAreaSampleTable(dec, blockX, blockY, blockZ, mode.xWeights, mode.yWeights, mode.zWeights)
+		}
 		realWeightCount := weightCountPerPlane
 		if mode.isDualPlane {
 			realWeightCount *= 2
@@ -942,35 +1114,30 @@ func encodeBlockRGBA8LDR(profile Profile, blockX, blockY, blockZ int, texels []b
 				continue
 			}
 
-			colorIntCount := partitionCount * endpointStride
-			qLevel := quantLevelForISE(colorIntCount, bitsAvailable)
-			if qLevel < int(quant6) {
+			format, stride, tryDelta := endpointFormat, endpointStride, tryDeltaEndpoints
+			qLevel := quantLevelForISE(partitionCount*stride, bitsAvailable)
+			// The scale format loses some precision on the second endpoint, so it is only used
+			// where the color integers it frees buy a finer quantization.
+			if rgbScale {
+				if q := quantLevelForISE(partitionCount*endpointFormatStride(fmtRGBScale), bitsAvailable); q > qLevel {
+					format, stride, tryDelta, qLevel = fmtRGBScale, endpointFormatStride(fmtRGBScale), false, q
+				}
+			}
+			if qLevel < int(quant6) || !tune.colorQuantAllowed(quantMethod(qLevel)) {
 				continue
 			}
 			colorQuant := quantMethod(qLevel)
 
 			var pt *partitionTable
-			var candidates []int
-			candidateCount := 0
-			partIndexLimit := 1
 			switch partitionCount {
 			case 1:
 				// no partition table
 			case 2:
 				pt = pt2
-				candidates = candidates2
-				candidateCount = candidates2Count
-				partIndexLimit = partIndexLimit2
 			case 3:
 				pt = pt3
-				candidates = candidates3
-				candidateCount = candidates3Count
-				partIndexLimit = partIndexLimit3
 			case 4:
 				pt = pt4
-				candidates = candidates4
-				candidateCount = candidates4Count
-				partIndexLimit = partIndexLimit4
 			default:
 				continue
 			}
@@ -980,28 +1147,18 @@ func encodeBlockRGBA8LDR(profile Profile, blockX, blockY, blockZ int, texels []b
 
 			endpoints := endpointsArr[:partitionCount]
 
-			var idxListArr [1]int
-			idxList := ([]int)(nil)
-			if partitionCount == 1 {
-				idxListArr[0] = 0
-				idxList = idxListArr[:]
-			} else if candidateCount > 0 && !normalMap && tuneOverride == nil {
-				idxList = candidates[:candidateCount]
-			}
+			idxList := partIdxLists[partitionCount]
+			iterCount := partIterCounts[partitionCount]
 
-			iterCount := partIndexLimit
-			if idxList != nil {
-				iterCount = len(idxList)
-			}
-
-			for i := 0; i < iterCount; i++ {
-				partitionIndex := i
+			for i := 0; i < iterCount*seedCount; i++ {
+				seed := i % seedCount
+				partitionIndex := i / seedCount
 				if idxList != nil {
-					partitionIndex = idxList[i]
+					partitionIndex = idxList[partitionIndex]
 				}
 
 				// Slices into scratch buffers. These buffers may swap when a new best candidate is found.
-				endpointPquant := currEndpointPquantBuf[:partitionCount*endpointStride]
+				endpointPquant := currEndpointPquantBuf[:partitionCount*stride]
 				weightPquant := currWeightPquantBuf[:realWeightCount]
 
 				var assign []uint8
@@ -1009,201 +1166,54 @@ func encodeBlockRGBA8LDR(profile Profile, blockX, blockY, blockZ int, texels []b
 					assign = pt.partitionsForIndex(partitionIndex)
 				}
 
-				// Endpoint selection in one pass for all partitions.
-				var count [4]uint16
-				var minL [4]int
-				var maxL [4]int
-				var minA [4]int
-				var maxA [4]int
-				var minIdx [4]int
-				var maxIdx [4]int
-				var sumX [4]float64
-				var sumY [4]float64
-				var sumXX [4]float64
-				var sumYY [4]float64
-				var sumXY [4]float64
-
-				if assign == nil {
-					// partitionCount == 1
-					count[0] = 0
-					minL[0] = math.MaxInt
-					maxL[0] = math.MinInt
-					minA[0] = math.MaxInt
-					maxA[0] = math.MinInt
-					minIdx[0] = 0
-					maxIdx[0] = 0
-
-					for t := 0; t < texelCount; t++ {
-						count[0]++
-						l := texelLuma[t]
-						ai := texelAlpha[t]
-						if normalMap {
-							off := t * 4
-							x := float64(texels[off+0])
-							y := float64(texels[off+3])
-							sumX[0] += x
-							sumY[0] += y
-							sumXX[0] += x * x
-							sumYY[0] += y * y
-							sumXY[0] += x * y
-						}
-						if l < minL[0] || (l == minL[0] && ai < minA[0]) {
-							minL[0] = l
-							minA[0] = ai
-							minIdx[0] = t
-						}
-						if l > maxL[0] || (l == maxL[0] && ai > maxA[0]) {
-							maxL[0] = l
-							maxA[0] = ai
-							maxIdx[0] = t
-						}
-					}
-				} else {
-					for p := 0; p < partitionCount; p++ {
-						count[p] = 0
-						minL[p] = math.MaxInt
-						maxL[p] = math.MinInt
-						minA[p] = math.MaxInt
-						maxA[p] = math.MinInt
-						minIdx[p] = 0
-						maxIdx[p] = 0
-					}
-
-					for t := 0; t < texelCount; t++ {
-						part := int(assign[t])
-						count[part]++
-
-						l := texelLuma[t]
-						ai := texelAlpha[t]
-						if normalMap {
-							off := t * 4
-							x := float64(texels[off+0])
-							y := float64(texels[off+3])
-							sumX[part] += x
-							sumY[part] += y
-							sumXX[part] += x * x
-							sumYY[part] += y * y
-							sumXY[part] += x * y
-						}
-						if l < minL[part] || (l == minL[part] && ai < minA[part]) {
-							minL[part] = l
-							minA[part] = ai
-							minIdx[part] = t
-						}
-						if l > maxL[part] || (l == maxL[part] && ai > maxA[part]) {
-							maxL[part] = l
-							maxA[part] = ai
-							maxIdx[part] = t
-						}
-					}
+				ps := seedCache.get(partitionCount, i)
+				if ps.state == partitionSeedUnset {
+					ps.computeRGBA8(texels, texelLuma, texelAlpha, assign, partitionCount, seed, normalMap, tune.constantPartitions, tune.endpointTrim)
 				}
-
-				if partitionCount != 1 {
-					degenerate := false
-					for p := 0; p < partitionCount; p++ {
-						if count[p] == 0 {
-							degenerate = true
-							break
-						}
-					}
-					if degenerate {
-						continue
-					}
-				}
-
-				if normalMap {
-					// Use a simple 2D PCA on (R, A) to pick endpoints for the L+A line. This better matches
-					// reference behavior for ASTCENC_FLG_MAP_NORMAL than luma-only endpoint selection.
-					var meanX [4]float64
-					var meanY [4]float64
-					var dirX [4]float64
-					var dirY [4]float64
-
-					for p := 0; p < partitionCount; p++ {
-						n := float64(count[p])
-						if n <= 0 {
-							continue
-						}
-
-						mx := sumX[p] / n
-						my := sumY[p] / n
-						meanX[p] = mx
-						meanY[p] = my
-
-						// Covariance matrix of centered data.
-						cov00 := sumXX[p]/n - mx*mx
-						cov11 := sumYY[p]/n - my*my
-						cov01 := sumXY[p]/n - mx*my
-
-						// Principal axis direction for 2x2 covariance.
-						dx, dy := 1.0, 0.0
-						if cov01 != 0 || cov00 != cov11 {
-							theta := 0.5 * math.Atan2(2*cov01, cov00-cov11)
-							dx = math.Cos(theta)
-							dy = math.Sin(theta)
-						} else if cov11 > cov00 {
-							dx, dy = 0.0, 1.0
-						}
-						dirX[p] = dx
-						dirY[p] = dy
-					}
-
-					minProj := [4]float64{math.Inf(1), math.Inf(1), math.Inf(1), math.Inf(1)}
-					maxProj := [4]float64{math.Inf(-1), math.Inf(-1), math.Inf(-1), math.Inf(-1)}
-					for t := 0; t < texelCount; t++ {
-						part := 0
-						if assign != nil {
-							part = int(assign[t])
-						}
-						off := t * 4
-						x := float64(texels[off+0]) - meanX[part]
-						y := float64(texels[off+3]) - meanY[part]
-						proj := x*dirX[part] + y*dirY[part]
-						if proj < minProj[part] {
-							minProj[part] = proj
-							minIdx[part] = t
-						}
-						if proj > maxProj[part] {
-							maxProj[part] = proj
-							maxIdx[part] = t
-						}
-					}
+				if ps.state == partitionSeedSkip {
+					continue
 				}
+				constPart := &ps.constPart
+				anyConstPart := ps.anyConstPart && !noDecimation
 
 				for p := 0; p < partitionCount; p++ {
-					off0 := minIdx[p] * 4
-					off1 := maxIdx[p] * 4
+					off0 := int(ps.minIdx[p]) * 4
+					off1 := int(ps.maxIdx[p]) * 4
 					var ep partitionEndpointsRGBA
-					if normalMap {
+					if lumEndpoints {
 						lum0 := texels[off0+0]
 						lum1 := texels[off1+0]
 						a0 := texels[off0+3]
 						a1 := texels[off1+3]
 						ep = quantizeEndpointsRGBABytes(colorQuant, lum0, lum0, lum0, a0, lum1, lum1, lum1, a1)
+						seedColorsArr[p] = [2][4]uint8{{lum0, lum0, lum0, a0}, {lum1, lum1, lum1, a1}}
+					} else if format == fmtRGBScale {
+						ep = quantizeEndpointsRGBScale(colorQuant, [4]uint8(texels[off0:off0+4]), [4]uint8(texels[off1:off1+4]))
+						seedColorsArr[p] = [2][4]uint8{[4]uint8(texels[off0 : off0+4]), [4]uint8(texels[off1 : off1+4])}
 					} else {
 						ep = quantizeEndpointsRGBABytes(
 							colorQuant,
 							texels[off0+0], texels[off0+1], texels[off0+2], texels[off0+3],
 							texels[off1+0], texels[off1+1], texels[off1+2], texels[off1+3],
 						)
+						seedColorsArr[p] = [2][4]uint8{
+							{texels[off0+0], texels[off0+1], texels[off0+2], texels[off0+3]},
+							{texels[off1+0], texels[off1+1], texels[off1+2], texels[off1+3]},
+						}
 					}
 					endpoints[p] = ep
-					base := p * endpointStride
-					pp := ep.pquant
-					if normalMap {
-						endpointPquant[base+0] = pp[0]
-						endpointPquant[base+1] = pp[1]
-						endpointPquant[base+2] = pp[6]
-						endpointPquant[base+3] = pp[7]
-					} else {
-						endpointPquant[base+0] = pp[0]
-						endpointPquant[base+1] = pp[1]
-						endpointPquant[base+2] = pp[2]
-						endpointPquant[base+3] = pp[3]
-						endpointPquant[base+4] = pp[4]
-						endpointPquant[base+5] = pp[5]
-						endpointPquant[base+6] = pp[6]
-						endpointPquant[base+7] = pp[7]
+					packEndpointPquant(format, &ep.pquant, endpointPquant[p*stride:])
+				}
+
+				candidateFormat := format
+				if tryDelta {
+					deltaEndpoints := deltaEndpointsArr[:partitionCount]
+					if selectDeltaEndpoints(colorQuant, format, seedColorsArr[:partitionCount], endpoints, &seedErrWeight, deltaEndpoints) {
+						candidateFormat = endpointDeltaFormat(format)
+						for p := 0; p < partitionCount; p++ {
+							endpoints[p] = deltaEndpoints[p]
+							packEndpointPquant(candidateFormat, &deltaEndpoints[p].pquant, endpointPquant[p*stride:])
+						}
 					}
 				}
 
@@ -1440,10 +1450,22 @@ func encodeBlockRGBA8LDR(profile Profile, blockX, blockY, blockZ int, texels []b
 						}
 					}
 
+					if areaSampling {
+						cp := constPartOrNil(constPart, anyConstPart)
+						areaSampleWeights(areaTab, assign, cp, sampleMap[:weightCountPerPlane], texelWeights, gridWeightsArr[:weightCountPerPlane])
+						areaSampleWeights(areaTab, assign, cp, sampleMap[:weightCountPerPlane], texelWeights2, gridWeights2Arr[:weightCountPerPlane])
+					} else if anyConstPart {
+						fillConstantPartitionWeights(dec, assign, constPart, sampleMap[:weightCountPerPlane], texelWeights)
+						fillConstantPartitionWeights(dec, assign, constPart, sampleMap[:weightCountPerPlane], texelWeights2)
+					}
 					for i := 0; i < weightCountPerPlane; i++ {
-						tix := int(sampleMap[i])
-						p1 := (*wQuantLUT)[texelWeights[tix]]
-						p2 := (*wQuantLUT)[texelWeights2[tix]]
+						g1, g2 := gridWeightsArr[i], gridWeights2Arr[i]
+						if !areaSampling {
+							tix := int(sampleMap[i])
+							g1, g2 = texelWeights[tix], texelWeights2[tix]
+						}
+						p1 := (*wQuantLUT)[g1]
+						p2 := (*wQuantLUT)[g2]
 						weightPquant[2*i] = p1
 						weightPquant[2*i+1] = p2
 						weightsUQ[i] = uqMap[p1]
@@ -1784,8 +1806,17 @@ func encodeBlockRGBA8LDR(profile Profile, blockX, blockY, blockZ int, texels []b
 						}
 					}
 
+					if areaSampling {
+						areaSampleWeights(areaTab, assign, constPartOrNil(constPart, anyConstPart), sampleMap[:weightCountPerPlane], texelWeights, gridWeightsArr[:weightCountPerPlane])
+					} else if anyConstPart {
+						fillConstantPartitionWeights(dec, assign, constPart, sampleMap[:weightCountPerPlane], texelWeights)
+					}
 					for i := 0; i < weightCountPerPlane; i++ {
-						p := (*wQuantLUT)[texelWeights[int(sampleMap[i])]]
+						g := gridWeightsArr[i]
+						if !areaSampling {
+							g = texelWeights[int(sampleMap[i])]
+						}
+						p := (*wQuantLUT)[g]
 						weightPquant[i] = p
 						weightsUQ[i] = uqMap[p]
 					}
@@ -1815,7 +1846,18 @@ func encodeBlockRGBA8LDR(profile Profile, blockX, blockY, blockZ int, texels []b
 				}
 
 				var errv float64
-				if !mode.isDualPlane {
+				if plainError {
+					errKernel.setPartitions(assign)
+					errv = errKernel.candidateError(&errorKernelCandidate{
+						weightsUQ:    &weightsUQArr,
+						dec:          dec,
+						noDecimation: noDecimation,
+						dualPlane:    mode.isDualPlane,
+						ep0:          &evalEp0,
+						epd:          &evalEpd,
+					}, useU8, bestErr)
+				} else if !mode.isDualPlane {
+					// FlagMapNormal and FlagMapRGBM compare decoded texels in their own error spaces.
 					if assign == nil {
 						e0 := evalEp0[0]
 						d := evalEpd[0]
@@ -1838,7 +1880,7 @@ func encodeBlockRGBA8LDR(profile Profile, blockX, blockY, blockZ int, texels []b
 									r8 := uint8(r16 >> 8)
 									a8 := uint8(a16 >> 8)
 									errv += normalMapAngularError(texels[off+0], texels[off+3], r8, a8)
-								} else if rgbmMap {
+								} else {
 									if a16 == 0 {
 										errv = math.Inf(1)
 										break
@@ -1875,18 +1917,6 @@ func encodeBlockRGBA8LDR(profile Profile, blockX, blockY, blockZ int, texels []b
 										errTex = 1e30
 									}
 									errv += errTex
-								} else {
-									srcR16 := u8ToU16ReplicatedI32(texels[off+0])
-									srcG16 := u8ToU16ReplicatedI32(texels[off+1])
-									srcB16 := u8ToU16ReplicatedI32(texels[off+2])
-									srcA16 := u8ToU16ReplicatedI32(texels[off+3])
-
-									dr := float64(srcR16 - r16)
-									dg := float64(srcG16 - g16)
-									db := float64(srcB16 - b16)
-									da := float64(srcA16 - a16)
-
-									errv += wR*dr*dr + wG*dg*dg + wB*db*db + wA*da*da
 								}
 
 								if errv >= bestErr {
@@ -1919,7 +1949,7 @@ func encodeBlockRGBA8LDR(profile Profile, blockX, blockY, blockZ int, texels []b
 									r8 := uint8(r16 >> 8)
 									a8 := uint8(a16 >> 8)
 									errv += normalMapAngularError(texels[off+0], texels[off+3], r8, a8)
-								} else if rgbmMap {
+								} else {
 									if a16 == 0 {
 										errv = math.Inf(1)
 										break
@@ -1956,18 +1986,6 @@ func encodeBlockRGBA8LDR(profile Profile, blockX, blockY, blockZ int, texels []b
 										errTex = 1e30
 									}
 									errv += errTex
-								} else {
-									srcR16 := u8ToU16ReplicatedI32(texels[off+0])
-									srcG16 := u8ToU16ReplicatedI32(texels[off+1])
-									srcB16 := u8ToU16ReplicatedI32(texels[off+2])
-									srcA16 := u8ToU16ReplicatedI32(texels[off+3])
-
-									dr := float64(srcR16 - r16)
-									dg := float64(srcG16 - g16)
-									db := float64(srcB16 - b16)
-									da := float64(srcA16 - a16)
-
-									errv += wR*dr*dr + wG*dg*dg + wB*db*db + wA*da*da
 								}
 
 								if errv >= bestErr {
@@ -1999,7 +2017,7 @@ func encodeBlockRGBA8LDR(profile Profile, blockX, blockY, blockZ int, texels []b
 									r8 := uint8(r16 >> 8)
 									a8 := uint8(a16 >> 8)
 									errv += normalMapAngularError(texels[off+0], texels[off+3], r8, a8)
-								} else if rgbmMap {
+								} else {
 									if a16 == 0 {
 										errv = math.Inf(1)
 										break
@@ -2036,18 +2054,6 @@ func encodeBlockRGBA8LDR(profile Profile, blockX, blockY, blockZ int, texels []b
 										errTex = 1e30
 									}
 									errv += errTex
-								} else {
-									srcR16 := u8ToU16ReplicatedI32(texels[off+0])
-									srcG16 := u8ToU16ReplicatedI32(texels[off+1])
-									srcB16 := u8ToU16ReplicatedI32(texels[off+2])
-									srcA16 := u8ToU16ReplicatedI32(texels[off+3])
-
-									dr := float64(srcR16 - r16)
-									dg := float64(srcG16 - g16)
-									db := float64(srcB16 - b16)
-									da := float64(srcA16 - a16)
-
-									errv += wR*dr*dr + wG*dg*dg + wB*db*db + wA*da*da
 								}
 
 								if errv >= bestErr {
@@ -2083,7 +2089,7 @@ func encodeBlockRGBA8LDR(profile Profile, blockX, blockY, blockZ int, texels []b
 									r8 := uint8(r16 >> 8)
 									a8 := uint8(a16 >> 8)
 									errv += normalMapAngularError(texels[off+0], texels[off+3], r8, a8)
-								} else if rgbmMap {
+								} else {
 									if a16 == 0 {
 										errv = math.Inf(1)
 										break
@@ -2120,18 +2126,6 @@ func encodeBlockRGBA8LDR(profile Profile, blockX, blockY, blockZ int, texels []b
 										errTex = 1e30
 									}
 									errv += errTex
-								} else {
-									srcR16 := u8ToU16ReplicatedI32(texels[off+0])
-									srcG16 := u8ToU16ReplicatedI32(texels[off+1])
-									srcB16 := u8ToU16ReplicatedI32(texels[off+2])
-									srcA16 := u8ToU16ReplicatedI32(texels[off+3])
-
-									dr := float64(srcR16 - r16)
-									dg := float64(srcG16 - g16)
-									db := float64(srcB16 - b16)
-									da := float64(srcA16 - a16)
-
-									errv += wR*dr*dr + wG*dg*dg + wB*db*db + wA*da*da
 								}
 
 								if errv >= bestErr {
@@ -2166,7 +2160,7 @@ func encodeBlockRGBA8LDR(profile Profile, blockX, blockY, blockZ int, texels []b
 									r8 := uint8(r16 >> 8)
 									a8 := uint8(a16 >> 8)
 									errv += normalMapAngularError(texels[off+0], texels[off+3], r8, a8)
-								} else if rgbmMap {
+								} else {
 									if a16 == 0 {
 										errv = math.Inf(1)
 										break
@@ -2203,18 +2197,6 @@ func encodeBlockRGBA8LDR(profile Profile, blockX, blockY, blockZ int, texels []b
 										errTex = 1e30
 									}
 									errv += errTex
-								} else {
-									srcR16 := u8ToU16ReplicatedI32(texels[off+0])
-									srcG16 := u8ToU16ReplicatedI32(texels[off+1])
-									srcB16 := u8ToU16ReplicatedI32(texels[off+2])
-									srcA16 := u8ToU16ReplicatedI32(texels[off+3])
-
-									dr := float64(srcR16 - r16)
-									dg := float64(srcG16 - g16)
-									db := float64(srcB16 - b16)
-									da := float64(srcA16 - a16)
-
-									errv += wR*dr*dr + wG*dg*dg + wB*db*db + wA*da*da
 								}
 
 								if errv >= bestErr {
@@ -2254,7 +2236,7 @@ func encodeBlockRGBA8LDR(profile Profile, blockX, blockY, blockZ int, texels []b
 									r8 := uint8(r16 >> 8)
 									a8 := uint8(a16 >> 8)
 									errv += normalMapAngularError(texels[off+0], texels[off+3], r8, a8)
-								} else if rgbmMap {
+								} else {
 									if a16 == 0 {
 										errv = math.Inf(1)
 										break
@@ -2291,18 +2273,6 @@ func encodeBlockRGBA8LDR(profile Profile, blockX, blockY, blockZ int, texels []b
 										errTex = 1e30
 									}
 									errv += errTex
-								} else {
-									srcR16 := u8ToU16ReplicatedI32(texels[off+0])
-									srcG16 := u8ToU16ReplicatedI32(texels[off+1])
-									srcB16 := u8ToU16ReplicatedI32(texels[off+2])
-									srcA16 := u8ToU16ReplicatedI32(texels[off+3])
-
-									dr := float64(srcR16 - r16)
-									dg := float64(srcG16 - g16)
-									db := float64(srcB16 - b16)
-									da := float64(srcA16 - a16)
-
-									errv += wR*dr*dr + wG*dg*dg + wB*db*db + wA*da*da
 								}
 
 								if errv >= bestErr {
@@ -2335,7 +2305,7 @@ func encodeBlockRGBA8LDR(profile Profile, blockX, blockY, blockZ int, texels []b
 									r8 := uint8(r16 >> 8)
 									a8 := uint8(a16 >> 8)
 									errv += normalMapAngularError(texels[off+0], texels[off+3], r8, a8)
-								} else if rgbmMap {
+								} else {
 									if a16 == 0 {
 										errv = math.Inf(1)
 										break
@@ -2372,18 +2342,6 @@ func encodeBlockRGBA8LDR(profile Profile, blockX, blockY, blockZ int, texels []b
 										errTex = 1e30
 									}
 									errv += errTex
-								} else {
-									srcR16 := u8ToU16ReplicatedI32(texels[off+0])
-									srcG16 := u8ToU16ReplicatedI32(texels[off+1])
-									srcB16 := u8ToU16ReplicatedI32(texels[off+2])
-									srcA16 := u8ToU16ReplicatedI32(texels[off+3])
-
-									dr := float64(srcR16 - r16)
-									dg := float64(srcG16 - g16)
-									db := float64(srcB16 - b16)
-									da := float64(srcA16 - a16)
-
-									errv += wR*dr*dr + wG*dg*dg + wB*db*db + wA*da*da
 								}
 
 								if errv >= bestErr {
@@ -2426,7 +2384,7 @@ func encodeBlockRGBA8LDR(profile Profile, blockX, blockY, blockZ int, texels []b
 									r8 := uint8(r16 >> 8)
 									a8 := uint8(a16 >> 8)
 									errv += normalMapAngularError(texels[off+0], texels[off+3], r8, a8)
-								} else if rgbmMap {
+								} else {
 									if a16 == 0 {
 										errv = math.Inf(1)
 										break
@@ -2463,18 +2421,6 @@ func encodeBlockRGBA8LDR(profile Profile, blockX, blockY, blockZ int, texels []b
 										errTex = 1e30
 									}
 									errv += errTex
-								} else {
-									srcR16 := u8ToU16ReplicatedI32(texels[off+0])
-									srcG16 := u8ToU16ReplicatedI32(texels[off+1])
-									srcB16 := u8ToU16ReplicatedI32(texels[off+2])
-									srcA16 := u8ToU16ReplicatedI32(texels[off+3])
-
-									dr := float64(srcR16 - r16)
-									dg := float64(srcG16 - g16)
-									db := float64(srcB16 - b16)
-									da := float64(srcA16 - a16)
-
-									errv += wR*dr*dr + wG*dg*dg + wB*db*db + wA*da*da
 								}
 
 								if errv >= bestErr {
@@ -2492,13 +2438,14 @@ func encodeBlockRGBA8LDR(profile Profile, blockX, blockY, blockZ int, texels []b
 					bestPartitionIndex = partitionIndex
 					bestPlane2Component = plane2Component
 					bestColorQuant = colorQuant
-					bestEndpointLen = partitionCount * endpointStride
+					bestEndpointFormat = candidateFormat
+					bestEndpointLen = partitionCount * stride
 					bestWeightLen = realWeightCount
 					currEndpointPquantBuf, bestEndpointPquantBuf = bestEndpointPquantBuf, currEndpointPquantBuf
 					currWeightPquantBuf, bestWeightPquantBuf = bestWeightPquantBuf, currWeightPquantBuf
 
 					if bestErr == 0 {
-						block, err := buildPhysicalBlock(bestMode, blockX, blockY, blockZ, bestPartitionCount, bestPartitionIndex, bestPlane2Component, endpointFormat, bestColorQuant, bestEndpointPquantBuf[:bestEndpointLen], bestWeightPquantBuf[:bestWeightLen])
+						block, err := buildPhysicalBlock(bestMode, blockX, blockY, blockZ, bestPartitionCount, bestPartitionIndex, bestPlane2Component, bestEndpointFormat, bestColorQuant, bestEndpointPquantBuf[:bestEndpointLen], bestWeightPquantBuf[:bestWeightLen])
 						if err != nil {
 							break
 						}
@@ -2514,14 +2461,92 @@ func encodeBlockRGBA8LDR(profile Profile, blockX, blockY, blockZ int, texels []b
 		r, g, b, a := avgBlockRGBA8(texels, blockX, blockY*blockZ, 0, 0, blockX, blockY*blockZ)
 		return EncodeConstBlockRGBA8(r, g, b, a), nil
 	}
-	block, err := buildPhysicalBlock(bestMode, blockX, blockY, blockZ, bestPartitionCount, bestPartitionIndex, bestPlane2Component, endpointFormat, bestColorQuant, bestEndpointPquantBuf[:bestEndpointLen], bestWeightPquantBuf[:bestWeightLen])
+	block, err := buildPhysicalBlock(bestMode, blockX, blockY, blockZ, bestPartitionCount, bestPartitionIndex, bestPlane2Component, bestEndpointFormat, bestColorQuant, bestEndpointPquantBuf[:bestEndpointLen], bestWeightPquantBuf[:bestWeightLen])
 	if err != nil {
 		r, g, b, a := avgBlockRGBA8(texels, blockX, blockY*blockZ, 0, 0, blockX, blockY*blockZ)
 		return EncodeConstBlockRGBA8(r, g, b, a), nil
 	}
+	if (tune.endpointRefinePasses > 0 || tune.stochasticIterations > 0) && !normalMap && !rgbmMap && (profile == ProfileLDR || profile == ProfileLDRSRGB) {
+		sb := stochasticBlock{
+			mode:            bestMode,
+			partitionCount:  bestPartitionCount,
+			partitionIndex:  bestPartitionIndex,
+			plane2Component: bestPlane2Component,
+			endpointFormat:  bestEndpointFormat,
+			colorQuant:      bestColorQuant,
+			endpointPquant:  bestEndpointPquantBuf[:bestEndpointLen],
+			weightPquant:    bestWeightPquantBuf[:bestWeightLen],
+		}
+		if tune.endpointRefinePasses > 0 {
+			block = refineEndpointsRGBA8(profile, blockX, blockY, blockZ, texels, channelWeight, tune.texelWeights, tune.endpointRefinePasses, &sb, block)
+		}
+		if tune.stochasticIterations > 0 {
+			block = stochasticRefineRGBA8(profile, blockX, blockY, blockZ, texels, channelWeight, tune.texelWeights, tune.stochasticIterations, tune.stochasticSeed, &sb, block)
+		}
+	}
 	return block, nil
 }
 
+// findConstantPartitions marks the partitions whose texels are all identical, returning true if
+// at least one, but not every, partition is constant.
+func findConstantPartitions(texels []byte, assign []uint8, partitionCount int, constPart *[4]bool) bool {
+	var first [4]int
+	for p := 0; p < partitionCount; p++ {
+		first[p] = -1
+		constPart[p] = true
+	}
+	for t := range assign {
+		p := assign[t]
+		if !constPart[p] {
+			continue
+		}
+		off := t * 4
+		if first[p] < 0 {
+			first[p] = off
+			continue
+		}
+		f := first[p]
+		if texels[off] != texels[f] || texels[off+1] != texels[f+1] || texels[off+2] != texels[f+2] || texels[off+3] != texels[f+3] {
+			constPart[p] = false
+		}
+	}
+
+	n := 0
+	for p := 0; p < partitionCount; p++ {
+		if constPart[p] {
+			n++
+		}
+	}
+	return n > 0 && n < partitionCount
+}
+
+// fillConstantPartitionWeights replaces the ideal weight of each grid sample texel in a constant
+// partition with the mean weight of the non-constant texels that grid point contributes to.
+// Constant partitions reconstruct exactly for any weight, so this lets the shared grid follow the
+// partitions that need it.
+func fillConstantPartitionWeights(dec []decimationEntry, assign []uint8, constPart *[4]bool, sampleMap []uint16, texelWeights []int) {
+	var sumArr, cntArr [blockMaxWeights]int
+	sum := sumArr[:len(sampleMap)]
+	cnt := cntArr[:len(sampleMap)]
+	for t, p := range assign {
+		if constPart[p] {
+			continue
+		}
+		e := &dec[t]
+		for k := 0; k < 4; k++ {
+			if w := int(e.w[k]); w != 0 {
+				sum[e.idx[k]] += w * texelWeights[t]
+				cnt[e.idx[k]] += w
+			}
+		}
+	}
+	for i, tix := range sampleMap {
+		if constPart[assign[tix]] && cnt[i] > 0 {
+			texelWeights[tix] = (sum[i] + cnt[i]/2) / cnt[i]
+		}
+	}
+}
+
 func minInt(a, b int) int {
 	if a < b {
 		return a
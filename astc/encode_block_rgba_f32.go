@@ -9,15 +9,15 @@ func isConstBlockRGBAF32(texels []float32) (r, g, b, a uint16, ok bool) {
 	if len(texels) < 4 {
 		return 0, 0, 0, 0, false
 	}
-	r0 := float32ToHalf(texels[0])
-	g0 := float32ToHalf(texels[1])
-	b0 := float32ToHalf(texels[2])
-	a0 := float32ToHalf(texels[3])
+	r0 := Float32ToHalf(texels[0])
+	g0 := Float32ToHalf(texels[1])
+	b0 := Float32ToHalf(texels[2])
+	a0 := Float32ToHalf(texels[3])
 	for i := 4; i < len(texels); i += 4 {
-		if float32ToHalf(texels[i+0]) != r0 ||
-			float32ToHalf(texels[i+1]) != g0 ||
-			float32ToHalf(texels[i+2]) != b0 ||
-			float32ToHalf(texels[i+3]) != a0 {
+		if Float32ToHalf(texels[i+0]) != r0 ||
+			Float32ToHalf(texels[i+1]) != g0 ||
+			Float32ToHalf(texels[i+2]) != b0 ||
+			Float32ToHalf(texels[i+3]) != a0 {
 			return 0, 0, 0, 0, false
 		}
 	}
@@ -132,10 +132,10 @@ func encodeBlockRGBAF32HDR(profile Profile, blockX, blockY, blockZ int, texels [
 		}
 		inv := 1.0 / float64(texelCount)
 		return EncodeConstBlockF16(
-			float32ToHalf(float32(sr*inv)),
-			float32ToHalf(float32(sg*inv)),
-			float32ToHalf(float32(sb*inv)),
-			float32ToHalf(float32(sa*inv)),
+			Float32ToHalf(float32(sr*inv)),
+			Float32ToHalf(float32(sg*inv)),
+			Float32ToHalf(float32(sb*inv)),
+			Float32ToHalf(float32(sa*inv)),
 		), nil
 	}
 
@@ -143,6 +143,7 @@ func encodeBlockRGBAF32HDR(profile Profile, blockX, blockY, blockZ int, texels [
 	if tuneOverride != nil {
 		tune = *tuneOverride
 	}
+	modes = tunedBlockModes(blockX, blockY, blockZ, &tune)
 	modeLimit := tune.modeLimit
 	if modeLimit <= 0 || modeLimit > len(modes) {
 		modeLimit = len(modes)
@@ -232,6 +233,14 @@ func encodeBlockRGBAF32HDR(profile Profile, blockX, blockY, blockZ int, texels [
 	wG := float64(channelWeight[1])
 	wB := float64(channelWeight[2])
 	wA := float64(channelWeight[3])
+	// texelW multiplies the error of each texel (see encoderTuning.texelWeights).
+	var texelW [blockMaxTexels]float64
+	for t := range texelCount {
+		texelW[t] = 1
+		if tune.texelWeights != nil {
+			texelW[t] = float64(tune.texelWeights[t])
+		}
+	}
 
 	bestErr := math.Inf(1)
 	var bestMode blockModeDesc
@@ -319,7 +328,7 @@ func encodeBlockRGBAF32HDR(profile Profile, blockX, blockY, blockZ int, texels [
 		}
 		if want > 0 && partIndexLimit2 > 0 {
 			candidates2 = candidates2Arr[:want]
-			candidates2Count = selectBestPartitionIndicesU16(candidates2, srcCodes, pt2, 2, partIndexLimit2, alphaVary)
+			candidates2Count = selectBestPartitionIndicesU16(candidates2, srcCodes, pt2, 2, partIndexLimit2, alphaVary, channelWeight)
 		}
 	}
 	if pt3 != nil {
@@ -332,7 +341,7 @@ func encodeBlockRGBAF32HDR(profile Profile, blockX, blockY, blockZ int, texels [
 		}
 		if want > 0 && partIndexLimit3 > 0 {
 			candidates3 = candidates3Arr[:want]
-			candidates3Count = selectBestPartitionIndicesU16(candidates3, srcCodes, pt3, 3, partIndexLimit3, alphaVary)
+			candidates3Count = selectBestPartitionIndicesU16(candidates3, srcCodes, pt3, 3, partIndexLimit3, alphaVary, channelWeight)
 		}
 	}
 	if pt4 != nil {
@@ -345,9 +354,36 @@ func encodeBlockRGBAF32HDR(profile Profile, blockX, blockY, blockZ int, texels [
 		}
 		if want > 0 && partIndexLimit4 > 0 {
 			candidates4 = candidates4Arr[:want]
-			candidates4Count = selectBestPartitionIndicesU16(candidates4, srcCodes, pt4, 4, partIndexLimit4, alphaVary)
+			candidates4Count = selectBestPartitionIndicesU16(candidates4, srcCodes, pt4, 4, partIndexLimit4, alphaVary, channelWeight)
+		}
+	}
+
+	// The partition indices each partition count searches, and the endpoint seeds of each, do not
+	// depend on the block mode; the seeds are computed on first use and shared by every mode.
+	var partIdxLists [blockMaxPartitions + 1][]int
+	var partIterCounts [blockMaxPartitions + 1]int
+	for _, partitionCount := range partitionCounts {
+		var candidates []int
+		partIndexLimit := 1
+		switch partitionCount {
+		case 2:
+			candidates = candidates2[:candidates2Count]
+			partIndexLimit = partIndexLimit2
+		case 3:
+			candidates = candidates3[:candidates3Count]
+			partIndexLimit = partIndexLimit3
+		case 4:
+			candidates = candidates4[:candidates4Count]
+			partIndexLimit = partIndexLimit4
+		}
+		partIterCounts[partitionCount] = partIndexLimit
+		if len(candidates) > 0 && tuneOverride == nil {
+			partIdxLists[partitionCount] = candidates
+			partIterCounts[partitionCount] = len(candidates)
 		}
 	}
+	var seedBuf [partitionSeedCacheInline]partitionSeed
+	seedCache := makePartitionSeedCache(seedBuf[:], &partIterCounts)
 
 	for _, mode := range modes {
 		if mode.isDualPlane && !allowDualPlane {
@@ -389,27 +425,15 @@ func encodeBlockRGBAF32HDR(profile Profile, blockX, blockY, blockZ int, texels [
 			}
 
 			var pt *partitionTable
-			var candidates []int
-			candidateCount := 0
-			partIndexLimit := 1
 			switch partitionCount {
 			case 1:
 				// none
 			case 2:
 				pt = pt2
-				candidates = candidates2
-				candidateCount = candidates2Count
-				partIndexLimit = partIndexLimit2
 			case 3:
 				pt = pt3
-				candidates = candidates3
-				candidateCount = candidates3Count
-				partIndexLimit = partIndexLimit3
 			case 4:
 				pt = pt4
-				candidates = candidates4
-				candidateCount = candidates4Count
-				partIndexLimit = partIndexLimit4
 			default:
 				continue
 			}
@@ -417,19 +441,8 @@ func encodeBlockRGBAF32HDR(profile Profile, blockX, blockY, blockZ int, texels [
 				continue
 			}
 
-			var idxListArr [1]int
-			idxList := ([]int)(nil)
-			if partitionCount == 1 {
-				idxListArr[0] = 0
-				idxList = idxListArr[:]
-			} else if candidateCount > 0 && tuneOverride == nil {
-				idxList = candidates[:candidateCount]
-			}
-
-			iterCount := partIndexLimit
-			if idxList != nil {
-				iterCount = len(idxList)
-			}
+			idxList := partIdxLists[partitionCount]
+			iterCount := partIterCounts[partitionCount]
 
 			for i := 0; i < iterCount; i++ {
 				partitionIndex := i
@@ -442,56 +455,12 @@ func encodeBlockRGBAF32HDR(profile Profile, blockX, blockY, blockZ int, texels [
 					assign = pt.partitionsForIndex(partitionIndex)
 				}
 
-				var minIdx [blockMaxPartitions]int
-				var maxIdx [blockMaxPartitions]int
-				var minL [blockMaxPartitions]float32
-				var maxL [blockMaxPartitions]float32
-				var minA [blockMaxPartitions]float32
-				var maxA [blockMaxPartitions]float32
-				var count [blockMaxPartitions]uint16
-
-				for p := 0; p < partitionCount; p++ {
-					minL[p] = float32(math.Inf(1))
-					maxL[p] = float32(math.Inf(-1))
-					minA[p] = float32(math.Inf(1))
-					maxA[p] = float32(math.Inf(-1))
-					minIdx[p] = 0
-					maxIdx[p] = 0
-					count[p] = 0
-				}
-
-				for t := 0; t < texelCount; t++ {
-					part := 0
-					if assign != nil {
-						part = int(assign[t])
-					}
-					count[part]++
-
-					l := texelLuma[t]
-					a := texelAlpha[t]
-					if l < minL[part] || (l == minL[part] && a < minA[part]) {
-						minL[part] = l
-						minA[part] = a
-						minIdx[part] = t
-					}
-					if l > maxL[part] || (l == maxL[part] && a > maxA[part]) {
-						maxL[part] = l
-						maxA[part] = a
-						maxIdx[part] = t
-					}
+				ps := seedCache.get(partitionCount, i)
+				if ps.state == partitionSeedUnset {
+					ps.computeF32(texelLuma, texelAlpha, assign, partitionCount)
 				}
-
-				if partitionCount != 1 {
-					degenerate := false
-					for p := 0; p < partitionCount; p++ {
-						if count[p] == 0 {
-							degenerate = true
-							break
-						}
-					}
-					if degenerate {
-						continue
-					}
+				if ps.state == partitionSeedSkip {
+					continue
 				}
 
 				var formatsArr [5]uint8
@@ -531,7 +500,7 @@ func encodeBlockRGBAF32HDR(profile Profile, blockX, blockY, blockZ int, texels [
 
 					colorIntCount := partitionCount * endpointStride
 					qLevel := quantLevelForISE(colorIntCount, bitsAvailable)
-					if qLevel < int(quant6) {
+					if qLevel < int(quant6) || !tune.colorQuantAllowed(quantMethod(qLevel)) {
 						continue
 					}
 					colorQuant := quantMethod(qLevel)
@@ -542,8 +511,8 @@ func encodeBlockRGBAF32HDR(profile Profile, blockX, blockY, blockZ int, texels [
 
 					formatOK := true
 					for p := 0; p < partitionCount; p++ {
-						e0Src := srcCodes[minIdx[p]]
-						e1Src := srcCodes[maxIdx[p]]
+						e0Src := srcCodes[ps.minIdx[p]]
+						e1Src := srcCodes[ps.maxIdx[p]]
 						color0 := [4]float32{
 							float32(e0Src[0]),
 							float32(e0Src[1]),
@@ -733,7 +702,7 @@ func encodeBlockRGBAF32HDR(profile Profile, blockX, blockY, blockZ int, texels [
 								dg := float64(int32(srcCodes[t][1]) - int32(gv))
 								db := float64(int32(srcCodes[t][2]) - int32(bv))
 								da := float64(int32(srcCodes[t][3]) - int32(av))
-								errv += wR*dr*dr + wG*dg*dg + wB*db*db + wA*da*da
+								errv += texelW[t] * (wR*dr*dr + wG*dg*dg + wB*db*db + wA*da*da)
 
 								if errv >= bestErr {
 									break
@@ -763,7 +732,7 @@ func encodeBlockRGBAF32HDR(profile Profile, blockX, blockY, blockZ int, texels [
 								dg := float64(int32(srcCodes[t][1]) - int32(gv))
 								db := float64(int32(srcCodes[t][2]) - int32(bv))
 								da := float64(int32(srcCodes[t][3]) - int32(av))
-								errv += wR*dr*dr + wG*dg*dg + wB*db*db + wA*da*da
+								errv += texelW[t] * (wR*dr*dr + wG*dg*dg + wB*db*db + wA*da*da)
 
 								if errv >= bestErr {
 									break
@@ -904,7 +873,7 @@ func encodeBlockRGBAF32HDR(profile Profile, blockX, blockY, blockZ int, texels [
 									dg := float64(int32(srcCodes[t][1]) - int32(gv))
 									db := float64(int32(srcCodes[t][2]) - int32(bv))
 									da := float64(int32(srcCodes[t][3]) - int32(av))
-									errv += wR*dr*dr + wG*dg*dg + wB*db*db + wA*da*da
+									errv += texelW[t] * (wR*dr*dr + wG*dg*dg + wB*db*db + wA*da*da)
 
 									if errv >= bestErr {
 										break
@@ -961,7 +930,7 @@ func encodeBlockRGBAF32HDR(profile Profile, blockX, blockY, blockZ int, texels [
 									dg := float64(int32(srcCodes[t][1]) - int32(gv))
 									db := float64(int32(srcCodes[t][2]) - int32(bv))
 									da := float64(int32(srcCodes[t][3]) - int32(av))
-									errv += wR*dr*dr + wG*dg*dg + wB*db*db + wA*da*da
+									errv += texelW[t] * (wR*dr*dr + wG*dg*dg + wB*db*db + wA*da*da)
 
 									if errv >= bestErr {
 										break
@@ -1014,9 +983,9 @@ func encodeBlockRGBAF32HDR(profile Profile, blockX, blockY, blockZ int, texels [
 	}
 	inv := 1.0 / float64(texelCount)
 	return EncodeConstBlockF16(
-		float32ToHalf(float32(sr*inv)),
-		float32ToHalf(float32(sg*inv)),
-		float32ToHalf(float32(sb*inv)),
-		float32ToHalf(float32(sa*inv)),
+		Float32ToHalf(float32(sr*inv)),
+		Float32ToHalf(float32(sg*inv)),
+		Float32ToHalf(float32(sb*inv)),
+		Float32ToHalf(float32(sa*inv)),
 	), nil
 }
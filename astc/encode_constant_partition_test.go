@@ -0,0 +1,66 @@
+package astc
+
+import "testing"
+
+func TestFindConstantPartitions(t *testing.T) {
+	texels := []byte{
+		1, 2, 3, 4, 9, 9, 9, 9,
+		1, 2, 3, 4, 8, 9, 9, 9,
+	}
+	var constPart [4]bool
+	if !findConstantPartitions(texels, []uint8{0, 1, 0, 1}, 2, &constPart) {
+		t.Fatalf("expected a constant partition")
+	}
+	if !constPart[0] || constPart[1] {
+		t.Fatalf("unexpected constant partitions: %v", constPart[:2])
+	}
+	if findConstantPartitions(texels, []uint8{0, 0, 1, 1}, 2, &constPart) {
+		t.Fatalf("no partition is constant")
+	}
+}
+
+func TestEncodeBlockRGBA8LDR_ConstantPartitionImproves(t *testing.T) {
+	const (
+		bx = 10
+		by = 10
+	)
+
+	// A UI-sprite-like block: a shaded disc over a flat background.
+	texels := make([]byte, bx*by*4)
+	for y := 0; y < by; y++ {
+		for x := 0; x < bx; x++ {
+			off := (y*bx + x) * 4
+			if (x-6)*(x-6)+(y-6)*(y-6) < 9 {
+				copy(texels[off:], []byte{uint8(90 + x*12), uint8(60 + y*15), uint8(200 - x*9), 255})
+			} else {
+				copy(texels[off:], []byte{20, 30, 40, 255})
+			}
+		}
+	}
+
+	ctx := getDecodeContext(bx, by, 1)
+	weights := [4]float32{1, 1, 1, 1}
+
+	blockError := func(tune *encoderTuning) uint64 {
+		block, err := encodeBlockRGBA8LDR(ProfileLDR, bx, by, 1, texels, EncodeThorough, weights, 0, 1, tune)
+		if err != nil {
+			t.Fatalf("encodeBlockRGBA8LDR: %v", err)
+		}
+		decoded := make([]byte, len(texels))
+		decodeBlockToRGBA8(ProfileLDR, ctx, block[:], decoded)
+		return blockErrorRGBA8(texels, decoded)
+	}
+
+	tune := encoderTuningFor(EncodeThorough, bx*by)
+	if !tune.constantPartitions {
+		t.Fatalf("expected thorough preset to enable constant-partition detection")
+	}
+	tune.partitionIndexLimit[3] = 0
+	tune.partitionIndexLimit[4] = 0
+	with := blockError(&tune)
+	tune.constantPartitions = false
+	without := blockError(&tune)
+	if with >= without {
+		t.Fatalf("constant-partition handling did not reduce error: got %d want < %d", with, without)
+	}
+}
@@ -2,6 +2,10 @@ package astc
 
 import "math"
 
+// dualPlaneAlphaLevels is the largest number of distinct alpha values for which LDR blocks always
+// consider dual-plane modes, regardless of the alpha/color correlation.
+const dualPlaneAlphaLevels = 4
+
 // alphaRGBAbsCorrelation returns |corr(alpha, luma)| for a block's RGBA8 texels, where
 // luma is the simple sum r+g+b.
 func alphaRGBAbsCorrelation(texels []byte) float64 {
@@ -43,3 +47,21 @@ func alphaRGBAbsCorrelation(texels []byte) float64 {
 	}
 	return corr
 }
+
+// alphaLevelCount returns the number of distinct alpha values of a block's RGBA8 texels, counting
+// no further than limit.
+func alphaLevelCount(texels []byte, limit int) int {
+	var seen [256 / 64]uint64
+	n := 0
+	for i := 3; i < len(texels); i += 4 {
+		a := texels[i]
+		if seen[a>>6]&(1<<(a&63)) != 0 {
+			continue
+		}
+		seen[a>>6] |= 1 << (a & 63)
+		if n++; n >= limit {
+			break
+		}
+	}
+	return n
+}
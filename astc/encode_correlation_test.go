@@ -0,0 +1,30 @@
+package astc
+
+import "testing"
+
+func TestAlphaLevelCount(t *testing.T) {
+	block := func(alpha func(i int) uint8) []byte {
+		texels := make([]byte, 36*4)
+		for i := 0; i < 36; i++ {
+			texels[i*4+3] = alpha(i)
+		}
+		return texels
+	}
+
+	for _, tc := range []struct {
+		name   string
+		texels []byte
+		limit  int
+		want   int
+	}{
+		{"constant", block(func(int) uint8 { return 255 }), 5, 1},
+		{"cutout", block(func(i int) uint8 { return uint8(255 * (i % 6 / 3)) }), 5, 2},
+		{"four levels", block(func(i int) uint8 { return uint8(85 * (i % 4)) }), 5, 4},
+		{"ramp stops at limit", block(func(i int) uint8 { return uint8(i * 7) }), 5, 5},
+		{"ramp", block(func(i int) uint8 { return uint8(i * 7) }), 256, 36},
+	} {
+		if got := alphaLevelCount(tc.texels, tc.limit); got != tc.want {
+			t.Errorf("%s: alphaLevelCount = %d, want %d", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package astc
+
+// diffusionCarry is the fraction of a block's residue carried into the next block. Carrying all
+// of it overshoots: on the testimage content it loses more PSNR than half does, and reduces the
+// regional error less.
+const diffusionCarry = 0.5
+
+// blockDiffusion carries the mean quantization residue of each encoded block into the target
+// colors of the next one, for Config.ExperimentalBlockErrorDiffusion.
+type blockDiffusion struct {
+	// carry is the residue added to every channel of the next block.
+	carry [4]float32
+	// target is the mean of the unclamped target texels of the current block.
+	target [4]float32
+
+	decoded []byte
+}
+
+// apply adds the carried residue to the RGBA8 block texels, clamping to 0..255, and records the
+// unclamped target mean.
+func (d *blockDiffusion) apply(texels []byte) {
+	var sum [4]float32
+	for i := 0; i < len(texels); i += 4 {
+		for ch := 0; ch < 4; ch++ {
+			v := float32(texels[i+ch])
+			sum[ch] += v
+			if d.carry[ch] != 0 {
+				texels[i+ch] = clampUnorm8(v + d.carry[ch])
+			}
+		}
+	}
+	n := float32(len(texels) / 4)
+	for ch := range sum {
+		d.target[ch] = sum[ch]/n + d.carry[ch]
+	}
+}
+
+// update sets the carry from the difference between the target mean and the mean of the decoded
+// block.
+func (d *blockDiffusion) update(profile Profile, ctx *decodeContext, block []byte) {
+	decodeBlockToRGBA8(profile, ctx, block, d.decoded)
+	var sum [4]float32
+	for i := 0; i < len(d.decoded); i += 4 {
+		for ch := 0; ch < 4; ch++ {
+			sum[ch] += float32(d.decoded[i+ch])
+		}
+	}
+	n := float32(len(d.decoded) / 4)
+	for ch := range sum {
+		d.carry[ch] = diffusionCarry * (d.target[ch] - sum[ch]/n)
+	}
+}
+
+func clampUnorm8(v float32) uint8 {
+	switch {
+	case v <= 0:
+		return 0
+	case v >= 255:
+		return 255
+	default:
+		return uint8(v + 0.5)
+	}
+}
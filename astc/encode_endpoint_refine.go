@@ -0,0 +1,86 @@
+package astc
+
+// Endpoint refinement: a greedy pass over the quantized endpoints of the best block found by the
+// candidate search. The search fits endpoints in unquantized space and rounds each one on its own,
+// ignoring how the rounded endpoints interact with each other and with the quantized weights;
+// stepping each endpoint value one quantization level up or down and keeping the steps which
+// lower the decoded error recovers part of that loss. Candidates are evaluated on the parsed
+// block, so a step costs one block interpolation rather than an encode and a decode.
+
+// refineEndpointsRGBA8 moves each endpoint value of sb one quantization level up or down, keeping
+// a move if it lowers the weighted error of the decoded block against texels, and repeats the
+// sweep until a sweep changes nothing or passes sweeps have run. The initial block is returned
+// unless a strictly better one is found; sb is left describing the returned block.
+func refineEndpointsRGBA8(profile Profile, blockX, blockY, blockZ int, texels []byte, channelWeight [4]float32, texelWeights []float32, passes int, sb *stochasticBlock, initial [BlockBytes]byte) [BlockBytes]byte {
+	ctx := getDecodeContext(blockX, blockY, blockZ)
+	scb := physicalToSymbolicWithCtx(initial[:], ctx)
+	vals := sb.endpointPquant
+	if scb.blockType != symBlockNonConst || len(vals) == 0 || len(vals)%sb.partitionCount != 0 {
+		return initial
+	}
+	stride := len(vals) / sb.partitionCount
+
+	var decodedArr [blockMaxTexels * 4]byte
+	decoded := decodedArr[:len(texels)]
+	evaluate := func() float64 {
+		decodeSymbolicToRGBA8(profile, ctx, &scb, decoded)
+		var err float64
+		for i := 0; i < len(texels); i += 4 {
+			w := 1.0
+			if texelWeights != nil {
+				w = float64(texelWeights[i/4])
+			}
+			for c := 0; c < 4; c++ {
+				d := float64(int(texels[i+c]) - int(decoded[i+c]))
+				err += w * float64(channelWeight[c]) * d * d
+			}
+		}
+		return err
+	}
+
+	table := colorScrambledPquantToUquantTables[int(sb.colorQuant)-int(quant6)]
+	bestErr := evaluate()
+	changed := false
+	for pass := 0; pass < passes && bestErr > 0; pass++ {
+		improved := false
+		for idx, v := range vals {
+			p, k := idx/stride, idx%stride
+			for _, up := range [2]bool{true, false} {
+				// Keep stepping while the error falls: a rounding error can span several levels.
+				moved := false
+				for {
+					next, ok := adjacentQuantLevel(table, v, up)
+					if !ok {
+						break
+					}
+					scb.colorValues[p][k] = table[next]
+					e := evaluate()
+					if e >= bestErr {
+						scb.colorValues[p][k] = table[v]
+						break
+					}
+					v, bestErr = next, e
+					vals[idx] = v
+					moved = true
+				}
+				if moved {
+					improved = true
+					break
+				}
+			}
+		}
+		if !improved {
+			break
+		}
+		changed = true
+	}
+	if !changed {
+		return initial
+	}
+
+	block, err := buildPhysicalBlock(sb.mode, blockX, blockY, blockZ, sb.partitionCount, sb.partitionIndex, sb.plane2Component, sb.endpointFormat, sb.colorQuant, sb.endpointPquant, sb.weightPquant)
+	if err != nil {
+		return initial
+	}
+	return block
+}
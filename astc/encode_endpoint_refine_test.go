@@ -0,0 +1,76 @@
+package astc
+
+import (
+	"image"
+	"image/draw"
+	"image/png"
+	"math"
+	"os"
+	"testing"
+)
+
+// loadPNGRGBA8 returns the top-left w x h texels of a PNG as RGBA8.
+func loadPNGRGBA8(t *testing.T, path string, w, h int) []byte {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	src, err := png.Decode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(dst, dst.Bounds(), src, src.Bounds().Min, draw.Src)
+	return dst.Pix
+}
+
+// configTuning returns the tuning a Context uses for an LDR block size and quality.
+func configTuning(t *testing.T, bx, by int, quality EncodeQuality) encoderTuning {
+	t.Helper()
+	cfg, err := ConfigInit(ProfileLDR, bx, by, 1, quality.Level(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return encoderTuningFromConfig(cfg)
+}
+
+// tunedImagePSNR encodes every block of a w x h RGBA8 image, whose dimensions are multiples of the
+// block size, with tune and returns the PSNR of the decoded image.
+func tunedImagePSNR(t *testing.T, pix []byte, w, h, bx, by int, quality EncodeQuality, tune encoderTuning) float64 {
+	t.Helper()
+	ctx := getDecodeContext(bx, by, 1)
+	texels := make([]byte, bx*by*4)
+	decoded := make([]byte, bx*by*4)
+	var sse uint64
+	for y0 := 0; y0 < h; y0 += by {
+		for x0 := 0; x0 < w; x0 += bx {
+			for y := range by {
+				copy(texels[y*bx*4:(y+1)*bx*4], pix[((y0+y)*w+x0)*4:])
+			}
+			block, err := encodeBlockRGBA8LDR(ProfileLDR, bx, by, 1, texels, quality, [4]float32{1, 1, 1, 1}, 0, 1, &tune)
+			if err != nil {
+				t.Fatalf("encodeBlockRGBA8LDR: %v", err)
+			}
+			decodeBlockToRGBA8(ProfileLDR, ctx, block[:], decoded)
+			sse += blockErrorRGBA8(texels, decoded)
+		}
+	}
+	mse := float64(sse) / float64(len(pix))
+	return 10 * math.Log10(255*255/mse)
+}
+
+func TestEndpointRefinement_ImprovesPSNR(t *testing.T) {
+	const w, h = 96, 80
+	pix := loadPNGRGBA8(t, "testdata/images/Small/LDR-RGB/ldr-rgb-05.png", w, h)
+	for _, bs := range [][2]int{{4, 4}, {8, 8}} {
+		tune := configTuning(t, bs[0], bs[1], EncodeMedium)
+		refined := tunedImagePSNR(t, pix, w, h, bs[0], bs[1], EncodeMedium, tune)
+		tune.endpointRefinePasses = 0
+		rounded := tunedImagePSNR(t, pix, w, h, bs[0], bs[1], EncodeMedium, tune)
+		if refined < rounded+0.15 {
+			t.Errorf("%dx%d: refined PSNR %.2f dB, want at least 0.15 dB above %.2f dB", bs[0], bs[1], refined, rounded)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package astc
+
+// endpointTrimMaxTexels bounds how many texels may be trimmed from each end of a partition's luma
+// range when seeding endpoints.
+const endpointTrimMaxTexels = 16
+
+// trimEndpointSeeds replaces the per-partition min/max luma seed texels with the texels at the
+// trim and (1-trim) luma percentiles, which makes endpoint seeding robust to isolated outlier
+// texels in noisy content. Ties are broken on alpha the same way as the min/max search.
+//
+// It reports whether any partition's seed texels changed.
+func trimEndpointSeeds(texelLuma, texelAlpha []int, assign []uint8, partitionCount int, count *[4]uint16, trim float32, minIdx, maxIdx *[4]int) bool {
+	if trim <= 0 {
+		return false
+	}
+
+	changed := false
+	for p := 0; p < partitionCount; p++ {
+		n := int(count[p])
+		k := int(float32(n)*trim + 0.5)
+		if k > (n-1)/2 {
+			k = (n - 1) / 2
+		}
+		if k > endpointTrimMaxTexels {
+			k = endpointTrimMaxTexels
+		}
+		if k <= 0 {
+			continue
+		}
+
+		// Keep the k+1 smallest and k+1 largest keys in sorted order.
+		var loKey, hiKey [endpointTrimMaxTexels + 1]int
+		var loIdx, hiIdx [endpointTrimMaxTexels + 1]int
+		loN, hiN := 0, 0
+		for t := range texelLuma {
+			if assign != nil && int(assign[t]) != p {
+				continue
+			}
+			key := texelLuma[t]*256 + texelAlpha[t]
+
+			if loN <= k || key < loKey[loN-1] {
+				j := loN
+				if j > k {
+					j = k
+				} else {
+					loN++
+				}
+				for j > 0 && loKey[j-1] > key {
+					loKey[j] = loKey[j-1]
+					loIdx[j] = loIdx[j-1]
+					j--
+				}
+				loKey[j] = key
+				loIdx[j] = t
+			}
+
+			if hiN <= k || key > hiKey[hiN-1] {
+				j := hiN
+				if j > k {
+					j = k
+				} else {
+					hiN++
+				}
+				for j > 0 && hiKey[j-1] < key {
+					hiKey[j] = hiKey[j-1]
+					hiIdx[j] = hiIdx[j-1]
+					j--
+				}
+				hiKey[j] = key
+				hiIdx[j] = t
+			}
+		}
+
+		if loIdx[k] != minIdx[p] || hiIdx[k] != maxIdx[p] {
+			minIdx[p] = loIdx[k]
+			maxIdx[p] = hiIdx[k]
+			changed = true
+		}
+	}
+	return changed
+}
@@ -0,0 +1,76 @@
+package astc
+
+import "testing"
+
+func TestTrimEndpointSeeds_SkipsOutliers(t *testing.T) {
+	const n = 20
+
+	texelLuma := make([]int, n)
+	texelAlpha := make([]int, n)
+	for i := 0; i < n; i++ {
+		texelLuma[i] = 300 + i
+		texelAlpha[i] = 255
+	}
+	texelLuma[7] = 0    // dark outlier
+	texelLuma[12] = 765 // bright outlier
+
+	count := [4]uint16{n}
+	minIdx := [4]int{7}
+	maxIdx := [4]int{12}
+	if !trimEndpointSeeds(texelLuma, texelAlpha, nil, 1, &count, 0.05, &minIdx, &maxIdx) {
+		t.Fatalf("expected trimmed seeds to differ from min/max")
+	}
+	if minIdx[0] != 0 || maxIdx[0] != n-1 {
+		t.Fatalf("unexpected trimmed seeds: min=%d max=%d", minIdx[0], maxIdx[0])
+	}
+
+	if trimEndpointSeeds(texelLuma, texelAlpha, nil, 1, &count, 0, &minIdx, &maxIdx) {
+		t.Fatalf("zero trim must not change seeds")
+	}
+}
+
+func TestEncodeBlockRGBA8LDR_TrimmedSeedsNeverWorse(t *testing.T) {
+	const (
+		bx = 6
+		by = 6
+	)
+
+	texels := make([]byte, bx*by*4)
+	seed := uint32(1)
+	for i := 0; i < bx*by; i++ {
+		seed = seed*1664525 + 1013904223
+		noise := int(seed>>27) - 16
+		v := clampI32(80+i*4+noise, 0, 255)
+		texels[i*4+0] = uint8(v)
+		texels[i*4+1] = uint8(clampI32(v+20, 0, 255))
+		texels[i*4+2] = uint8(clampI32(v-30, 0, 255))
+		texels[i*4+3] = 255
+	}
+	// Isolated outliers that dominate a plain min/max seed.
+	copy(texels[5*4:], []byte{255, 0, 255, 255})
+	copy(texels[30*4:], []byte{0, 255, 0, 255})
+
+	ctx := getDecodeContext(bx, by, 1)
+	weights := [4]float32{1, 1, 1, 1}
+
+	blockError := func(tune *encoderTuning) uint64 {
+		block, err := encodeBlockRGBA8LDR(ProfileLDR, bx, by, 1, texels, EncodeThorough, weights, 0, 1, tune)
+		if err != nil {
+			t.Fatalf("encodeBlockRGBA8LDR: %v", err)
+		}
+		decoded := make([]byte, len(texels))
+		decodeBlockToRGBA8(ProfileLDR, ctx, block[:], decoded)
+		return blockErrorRGBA8(texels, decoded)
+	}
+
+	tune := encoderTuningFor(EncodeThorough, bx*by)
+	if tune.endpointTrim <= 0 {
+		t.Fatalf("expected thorough preset to enable trimmed endpoint seeding")
+	}
+	withTrim := blockError(&tune)
+	tune.endpointTrim = 0
+	withoutTrim := blockError(&tune)
+	if withTrim > withoutTrim {
+		t.Fatalf("trimmed seeding increased error: got %d want <= %d", withTrim, withoutTrim)
+	}
+}
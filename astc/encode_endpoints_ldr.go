@@ -0,0 +1,339 @@
+package astc
+
+import "math"
+
+// LDR endpoint encodings beyond direct RGBA: reduced-channel formats for opaque and grayscale
+// blocks, the RGB scale format for opaque blocks of one chroma, and the base+offset (delta)
+// formats with optional blue-contraction.
+//
+// All encoders here return endpoints in partitionEndpointsRGBA form. The e0/e1 fields always hold
+// the values the decoder will reconstruct (computed by running the decoder's own unpack routine on
+// the quantized values), so the rest of the encoder can treat every format the same way. The
+// pquant field uses the RGBA slot layout r0,r1,g0,g1,b0,b1,a0,a1, except for fmtRGBScale, which
+// keeps its r,g,b,scale values in the first four slots; packEndpointPquant selects the slots each
+// format actually stores.
+
+// endpointFormatStride returns the number of color integers per partition for an LDR format.
+func endpointFormatStride(format uint8) int {
+	switch format {
+	case fmtLuminance, fmtLuminanceDelta:
+		return 2
+	case fmtLuminanceAlpha, fmtLuminanceAlphaDelta, fmtRGBScale:
+		return 4
+	case fmtRGB, fmtRGBDelta:
+		return 6
+	default:
+		return 8
+	}
+}
+
+// endpointDeltaFormat returns the base+offset counterpart of a direct LDR format.
+func endpointDeltaFormat(format uint8) uint8 {
+	switch format {
+	case fmtLuminance:
+		return fmtLuminanceDelta
+	case fmtLuminanceAlpha:
+		return fmtLuminanceAlphaDelta
+	case fmtRGB:
+		return fmtRGBDelta
+	default:
+		return fmtRGBADelta
+	}
+}
+
+// packEndpointPquant writes the scrambled pquant values stored by format into dst in ASTC
+// endpoint order.
+func packEndpointPquant(format uint8, pp *[8]uint8, dst []uint8) {
+	switch format {
+	case fmtLuminance, fmtLuminanceDelta:
+		dst[0] = pp[0]
+		dst[1] = pp[1]
+	case fmtLuminanceAlpha, fmtLuminanceAlphaDelta:
+		dst[0] = pp[0]
+		dst[1] = pp[1]
+		dst[2] = pp[6]
+		dst[3] = pp[7]
+	case fmtRGBScale:
+		copy(dst[:4], pp[:4])
+	case fmtRGB, fmtRGBDelta:
+		copy(dst[:6], pp[:6])
+	default:
+		copy(dst[:8], pp[:8])
+	}
+}
+
+// quantizeDeltaChannel encodes one channel of a bit-transfer-signed base+offset pair, as used by
+// fmtRGBDelta, fmtRGBADelta and fmtLuminanceAlphaDelta. The base keeps 8 bits (its top bit moves
+// into the offset value) and the offset is a signed 6-bit value.
+//
+// Ported from try_quantize_rgb_delta() in Source/astcenc_color_quantize.cpp.
+func quantizeDeltaChannel(q quantMethod, base, target int) (pBase, uBase, pOff, uOff uint8, ok bool) {
+	a := base << 1
+	pBase, uBase = colorQuantize(q, uint8(a&0xFF))
+	b := int(uBase) | (a & 0x100)
+
+	d := (target << 1) - b
+	if d > 63 || d < -64 {
+		return 0, 0, 0, 0, false
+	}
+	d &= 0x7F
+	d |= (b & 0x100) >> 1
+
+	pOff, uOff = colorQuantize(q, uint8(d))
+	if (d^int(uOff))&0xC0 != 0 {
+		// Quantization flipped the transferred base bit or the offset sign.
+		return 0, 0, 0, 0, false
+	}
+	return pBase, uBase, pOff, uOff, true
+}
+
+// quantizeEndpointsRGBScale encodes t0/t1 using fmtRGBScale: the brighter color becomes the base
+// and the other endpoint the base scaled by a factor of scale/256, fitted to the projection of the
+// darker color onto the quantized base. Alpha is 255.
+func quantizeEndpointsRGBScale(q quantMethod, t0, t1 [4]uint8) partitionEndpointsRGBA {
+	var out partitionEndpointsRGBA
+	if int(t0[0])+int(t0[1])+int(t0[2]) > int(t1[0])+int(t1[1])+int(t1[2]) {
+		t0, t1 = t1, t0
+	}
+	var base int4
+	var dot, norm int
+	for c := 0; c < 3; c++ {
+		p, u := colorQuantize(q, t1[c])
+		out.pquant[c] = p
+		base[c] = int(u)
+		dot += int(t0[c]) * base[c]
+		norm += base[c] * base[c]
+	}
+	scale := 0
+	if norm > 0 {
+		scale = min((dot*256+norm/2)/norm, 255)
+	}
+	p, u := colorQuantize(q, uint8(scale))
+	out.pquant[3] = p
+	o0, o1 := rgbScaleUnpack(base, int(u))
+	for c := 0; c < 4; c++ {
+		out.e0[c] = uint8(o0[c])
+		out.e1[c] = uint8(o1[c])
+	}
+	return out
+}
+
+// rgbScaleMaxDeviation bounds how far (in 8-bit units) a texel of a block may lie from the line
+// through black and the block's brightest color for blockFitsRGBScale to accept it.
+const rgbScaleMaxDeviation = 2
+
+// blockFitsRGBScale reports whether every texel of an opaque RGBA8 block is, within
+// rgbScaleMaxDeviation per channel, a scaled copy of its brightest texel, as fmtRGBScale
+// endpoints require of each partition. Shading gradients of a single material typically are.
+func blockFitsRGBScale(texels []byte) bool {
+	brightest, bestSum := 0, -1
+	for t := 0; t < len(texels); t += 4 {
+		if s := int(texels[t]) + int(texels[t+1]) + int(texels[t+2]); s > bestSum {
+			brightest, bestSum = t, s
+		}
+	}
+	if bestSum <= 0 {
+		return false
+	}
+	r, g, b := float64(texels[brightest]), float64(texels[brightest+1]), float64(texels[brightest+2])
+	norm := r*r + g*g + b*b
+	for t := 0; t < len(texels); t += 4 {
+		x, y, z := float64(texels[t]), float64(texels[t+1]), float64(texels[t+2])
+		f := (x*r + y*g + z*b) / norm
+		if math.Abs(x-f*r) > rgbScaleMaxDeviation || math.Abs(y-f*g) > rgbScaleMaxDeviation || math.Abs(z-f*b) > rgbScaleMaxDeviation {
+			return false
+		}
+	}
+	return true
+}
+
+// blueContract applies the inverse of the decoder's blue-contraction to an RGB color.
+func blueContract(c [4]uint8) (out int4, ok bool) {
+	out = int4{2*int(c[0]) - int(c[2]), 2*int(c[1]) - int(c[2]), int(c[2]), int(c[3])}
+	if out[0] < 0 || out[0] > 255 || out[1] < 0 || out[1] > 255 {
+		return out, false
+	}
+	return out, true
+}
+
+// quantizeEndpointsRGBADelta encodes e0/e1 using fmtRGBADelta (or fmtRGBDelta if rgbOnly).
+//
+// With contract set, the colors are stored blue-contracted and swapped, which trades blue
+// precision for extra red/green precision when those channels track blue closely.
+func quantizeEndpointsRGBADelta(q quantMethod, e0, e1 [4]uint8, contract bool, rgbOnly bool) (partitionEndpointsRGBA, bool) {
+	var out partitionEndpointsRGBA
+
+	base := int4{int(e0[0]), int(e0[1]), int(e0[2]), int(e0[3])}
+	target := int4{int(e1[0]), int(e1[1]), int(e1[2]), int(e1[3])}
+	if contract {
+		// The decoder swaps contracted endpoints, so the base holds the second color.
+		var ok0, ok1 bool
+		base, ok0 = blueContract(e1)
+		target, ok1 = blueContract(e0)
+		if !ok0 || !ok1 {
+			return out, false
+		}
+	}
+
+	channels := 4
+	if rgbOnly {
+		channels = 3
+	}
+	var u0, u1 int4
+	for c := 0; c < channels; c++ {
+		pb, ub, po, uo, ok := quantizeDeltaChannel(q, base[c], target[c])
+		if !ok {
+			return out, false
+		}
+		out.pquant[2*c] = pb
+		out.pquant[2*c+1] = po
+		u0[c] = int(ub)
+		u1[c] = int(uo)
+	}
+
+	// The decoder picks blue-contraction from the sign of the RGB offset sum; make sure it agrees.
+	offset, _ := bitTransferSigned(u1, u0)
+	if (haddRGB(offset) < 0) != contract {
+		return out, false
+	}
+
+	var o0, o1 int4
+	if rgbOnly {
+		o0, o1 = rgbDeltaUnpack(u0, u1)
+	} else {
+		o0, o1 = rgbaDeltaUnpack(u0, u1)
+	}
+	for c := 0; c < 4; c++ {
+		out.e0[c] = uint8(o0[c])
+		out.e1[c] = uint8(o1[c])
+	}
+	return out, true
+}
+
+// quantizeEndpointsLuminanceAlphaDelta encodes e0/e1 using fmtLuminanceAlphaDelta. Luminance is
+// taken from the red channel.
+func quantizeEndpointsLuminanceAlphaDelta(q quantMethod, e0, e1 [4]uint8) (partitionEndpointsRGBA, bool) {
+	var out partitionEndpointsRGBA
+
+	pl0, ul0, pl1, ul1, ok := quantizeDeltaChannel(q, int(e0[0]), int(e1[0]))
+	if !ok {
+		return out, false
+	}
+	pa0, ua0, pa1, ua1, ok := quantizeDeltaChannel(q, int(e0[3]), int(e1[3]))
+	if !ok {
+		return out, false
+	}
+	out.pquant[0] = pl0
+	out.pquant[1] = pl1
+	out.pquant[6] = pa0
+	out.pquant[7] = pa1
+
+	o0, o1 := luminanceAlphaDeltaUnpack([]uint8{ul0, ul1, ua0, ua1})
+	for c := 0; c < 4; c++ {
+		out.e0[c] = uint8(o0[c])
+		out.e1[c] = uint8(o1[c])
+	}
+	return out, true
+}
+
+// quantizeEndpointsLuminanceDelta encodes e0/e1 using fmtLuminanceDelta, which stores the base
+// luminance at full precision and a non-negative 6-bit offset. Luminance is taken from the red
+// channel.
+func quantizeEndpointsLuminanceDelta(q quantMethod, e0, e1 [4]uint8) (partitionEndpointsRGBA, bool) {
+	var out partitionEndpointsRGBA
+
+	l0 := int(e0[0])
+	l1 := int(e1[0])
+
+	p0, u0 := colorQuantize(q, uint8((l0<<2)&0xFF))
+	lb := (int(u0) >> 2) | (l0 & 0xC0)
+	d := l1 - lb
+	if d < 0 || d > 63 {
+		return out, false
+	}
+	v1 := (l0 & 0xC0) | d
+	p1, u1 := colorQuantize(q, uint8(v1))
+	if (int(u1)^v1)&0xC0 != 0 {
+		return out, false
+	}
+	out.pquant[0] = p0
+	out.pquant[1] = p1
+
+	o0, o1 := luminanceDeltaUnpack([]uint8{u0, u1})
+	for c := 0; c < 4; c++ {
+		out.e0[c] = uint8(o0[c])
+		out.e1[c] = uint8(o1[c])
+	}
+	return out, true
+}
+
+// quantizeEndpointsDeltaBest returns the most accurate delta encoding of the seed colors t0/t1 for
+// the delta counterpart of the direct format, trying both endpoint orders and (for RGB formats)
+// blue-contraction.
+func quantizeEndpointsDeltaBest(q quantMethod, format uint8, t0, t1 [4]uint8, w *[4]float64) (best partitionEndpointsRGBA, bestErr float64, ok bool) {
+	try := func(ep partitionEndpointsRGBA, valid bool) {
+		if !valid {
+			return
+		}
+		if err := endpointPairError(&ep, t0, t1, w); !ok || err < bestErr {
+			best, bestErr, ok = ep, err, true
+		}
+	}
+
+	for order := 0; order < 2; order++ {
+		a, b := t0, t1
+		if order == 1 {
+			a, b = t1, t0
+		}
+		switch format {
+		case fmtLuminance:
+			try(quantizeEndpointsLuminanceDelta(q, a, b))
+		case fmtLuminanceAlpha:
+			try(quantizeEndpointsLuminanceAlphaDelta(q, a, b))
+		case fmtRGB:
+			try(quantizeEndpointsRGBADelta(q, a, b, false, true))
+			try(quantizeEndpointsRGBADelta(q, a, b, true, true))
+		default:
+			try(quantizeEndpointsRGBADelta(q, a, b, false, false))
+			try(quantizeEndpointsRGBADelta(q, a, b, true, false))
+		}
+	}
+	return best, bestErr, ok
+}
+
+// endpointPairError returns the weighted squared distance between decoded endpoints and the seed
+// colors, independent of endpoint order.
+func endpointPairError(ep *partitionEndpointsRGBA, t0, t1 [4]uint8, w *[4]float64) float64 {
+	var same, swapped float64
+	for c := 0; c < 4; c++ {
+		d00 := float64(int(ep.e0[c]) - int(t0[c]))
+		d11 := float64(int(ep.e1[c]) - int(t1[c]))
+		d01 := float64(int(ep.e0[c]) - int(t1[c]))
+		d10 := float64(int(ep.e1[c]) - int(t0[c]))
+		same += w[c] * (d00*d00 + d11*d11)
+		swapped += w[c] * (d01*d01 + d10*d10)
+	}
+	if swapped < same {
+		return swapped
+	}
+	return same
+}
+
+// selectDeltaEndpoints compares the direct endpoints of every partition against the delta form of
+// the same format. Because the encoder emits blocks with matched endpoint formats, the delta form
+// is only chosen when every partition can use it and the summed seed error is lower.
+//
+// On success dst holds the delta endpoints for each partition.
+func selectDeltaEndpoints(q quantMethod, format uint8, seeds [][2][4]uint8, direct []partitionEndpointsRGBA, w *[4]float64, dst []partitionEndpointsRGBA) bool {
+	var directErr, deltaErr float64
+	for p := range seeds {
+		ep, err, ok := quantizeEndpointsDeltaBest(q, format, seeds[p][0], seeds[p][1], w)
+		if !ok {
+			return false
+		}
+		dst[p] = ep
+		deltaErr += err
+		directErr += endpointPairError(&direct[p], seeds[p][0], seeds[p][1], w)
+	}
+	return deltaErr < directErr
+}
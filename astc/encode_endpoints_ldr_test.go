@@ -0,0 +1,254 @@
+package astc
+
+import (
+	"math"
+	"testing"
+)
+
+func TestQuantizeEndpointsDelta_RoundTripsAtFullPrecision(t *testing.T) {
+	w := [4]float64{1, 1, 1, 1}
+	seed := uint32(7)
+	next := func() int {
+		seed = seed*1664525 + 1013904223
+		return int(seed >> 24)
+	}
+
+	for i := 0; i < 2000; i++ {
+		var e0, e1 [4]uint8
+		for c := 0; c < 4; c++ {
+			v := next()
+			e0[c] = uint8(v)
+			e1[c] = uint8(clampI32(v+next()%60-29, 0, 255))
+		}
+
+		for _, format := range []uint8{fmtRGBA, fmtRGB, fmtLuminanceAlpha} {
+			t0, t1 := e0, e1
+			if format == fmtRGB {
+				t0[3], t1[3] = 255, 255
+			}
+			if format == fmtLuminanceAlpha {
+				t0[1], t0[2] = t0[0], t0[0]
+				t1[1], t1[2] = t1[0], t1[0]
+			}
+			ep, err, ok := quantizeEndpointsDeltaBest(quant256, format, t0, t1, &w)
+			if !ok {
+				t.Fatalf("format %d: delta encoding failed for %v %v", format, t0, t1)
+			}
+			if err != 0 {
+				t.Fatalf("format %d: expected exact delta round-trip for %v %v, got %v %v", format, t0, t1, ep.e0, ep.e1)
+			}
+		}
+	}
+}
+
+func TestQuantizeEndpointsLuminanceDelta_Range(t *testing.T) {
+	ep, ok := quantizeEndpointsLuminanceDelta(quant256, [4]uint8{100, 100, 100, 255}, [4]uint8{163, 163, 163, 255})
+	if !ok {
+		t.Fatalf("expected offset of 63 to be encodable")
+	}
+	if ep.e0[0] != 100 || ep.e1[0] != 163 || ep.e1[3] != 255 {
+		t.Fatalf("unexpected decoded endpoints: %v %v", ep.e0, ep.e1)
+	}
+	if _, ok := quantizeEndpointsLuminanceDelta(quant256, [4]uint8{100, 100, 100, 255}, [4]uint8{164, 164, 164, 255}); ok {
+		t.Fatalf("expected offset of 64 to be rejected")
+	}
+	if _, ok := quantizeEndpointsLuminanceDelta(quant256, [4]uint8{100, 100, 100, 255}, [4]uint8{99, 99, 99, 255}); ok {
+		t.Fatalf("expected negative offset to be rejected")
+	}
+}
+
+func TestEncodeBlockRGBA8LDR_ExtendedEndpointFormats(t *testing.T) {
+	const (
+		bx = 8
+		by = 8
+	)
+
+	cases := []struct {
+		name    string
+		texel   func(x, y int) [4]uint8
+		formats []uint8
+	}{
+		{
+			name: "gray-opaque",
+			texel: func(x, y int) [4]uint8 {
+				v := uint8(90 + x*3 + y*2)
+				return [4]uint8{v, v, v, 255}
+			},
+			formats: []uint8{fmtLuminance, fmtLuminanceDelta},
+		},
+		{
+			name: "gray-alpha",
+			texel: func(x, y int) [4]uint8 {
+				v := uint8(40 + x*4)
+				return [4]uint8{v, v, v, uint8(200 - y*5)}
+			},
+			formats: []uint8{fmtLuminanceAlpha, fmtLuminanceAlphaDelta},
+		},
+		{
+			name: "color-opaque",
+			texel: func(x, y int) [4]uint8 {
+				return [4]uint8{uint8(120 + x*2), uint8(60 + y*3), uint8(30 + x + y), 255}
+			},
+			formats: []uint8{fmtRGB, fmtRGBDelta},
+		},
+		{
+			name: "color-alpha",
+			texel: func(x, y int) [4]uint8 {
+				return [4]uint8{uint8(120 + x*2), uint8(60 + y*3), uint8(30 + x + y), uint8(180 + x*3)}
+			},
+			formats: []uint8{fmtRGBA, fmtRGBADelta},
+		},
+	}
+
+	ctx := getDecodeContext(bx, by, 1)
+	weights := [4]float32{1, 1, 1, 1}
+	for _, tc := range cases {
+		texels := make([]byte, bx*by*4)
+		for y := 0; y < by; y++ {
+			for x := 0; x < bx; x++ {
+				c := tc.texel(x, y)
+				copy(texels[(y*bx+x)*4:], c[:])
+			}
+		}
+
+		blockError := func(tune *encoderTuning) (uint64, uint8) {
+			block, err := encodeBlockRGBA8LDR(ProfileLDR, bx, by, 1, texels, EncodeThorough, weights, 0, 1, tune)
+			if err != nil {
+				t.Fatalf("%s: encodeBlockRGBA8LDR: %v", tc.name, err)
+			}
+			scb := physicalToSymbolic(block[:], bx, by, 1)
+			if scb.blockType == symBlockError {
+				t.Fatalf("%s: encoder produced an error block", tc.name)
+			}
+			decoded := make([]byte, len(texels))
+			decodeBlockToRGBA8(ProfileLDR, ctx, block[:], decoded)
+			return blockErrorRGBA8(texels, decoded), scb.colorFormats[0]
+		}
+
+		tune := encoderTuningFor(EncodeThorough, bx*by)
+		extErr, format := blockError(&tune)
+		found := false
+		for _, f := range tc.formats {
+			if format == f {
+				found = true
+			}
+		}
+		if !found {
+			t.Fatalf("%s: unexpected endpoint format %d, want one of %v", tc.name, format, tc.formats)
+		}
+
+		tune.extendedEndpointFormats = false
+		baseErr, _ := blockError(&tune)
+		if extErr > baseErr {
+			t.Fatalf("%s: extended endpoint formats increased error: got %d want <= %d", tc.name, extErr, baseErr)
+		}
+	}
+}
+
+func TestQuantizeEndpointsRGBScale_RoundTrip(t *testing.T) {
+	seed := uint32(11)
+	next := func() int {
+		seed = seed*1664525 + 1013904223
+		return int(seed >> 24)
+	}
+	table := colorScrambledPquantToUquantTables[int(quant256)-int(quant6)]
+	for i := 0; i < 2000; i++ {
+		base := [4]uint8{uint8(next()), uint8(next()), uint8(next()), 255}
+		s := next()
+		var scaled [4]uint8
+		for c := 0; c < 3; c++ {
+			scaled[c] = uint8(int(base[c]) * s >> 8)
+		}
+		scaled[3] = 255
+
+		// Either endpoint order gives the brighter color as the base.
+		for _, pair := range [][2][4]uint8{{scaled, base}, {base, scaled}} {
+			ep := quantizeEndpointsRGBScale(quant256, pair[0], pair[1])
+			var packed [4]uint8
+			packEndpointPquant(fmtRGBScale, &ep.pquant, packed[:])
+			in := int4{int(table[packed[0]]), int(table[packed[1]]), int(table[packed[2]]), 0}
+			o0, o1 := rgbScaleUnpack(in, int(table[packed[3]]))
+			for c := 0; c < 4; c++ {
+				if int(ep.e0[c]) != o0[c] || int(ep.e1[c]) != o1[c] {
+					t.Fatalf("endpoints %v %v do not match the decoder's %v %v", ep.e0, ep.e1, o0, o1)
+				}
+			}
+			if ep.e1 != base {
+				t.Fatalf("base %v decoded as %v", base, ep.e1)
+			}
+			for c := 0; c < 3; c++ {
+				if d := int(ep.e0[c]) - int(scaled[c]); d < -1 || d > 1 {
+					t.Fatalf("scaled endpoint %v decoded as %v", scaled, ep.e0)
+				}
+			}
+		}
+	}
+}
+
+func TestEncodeBlockRGBA8LDR_RGBScaleEndpoints(t *testing.T) {
+	const bx, by = 8, 8
+	texels := make([]byte, bx*by*4)
+	for y := 0; y < by; y++ {
+		for x := 0; x < bx; x++ {
+			f := 0.4 + 0.05*float64(x) + 0.03*float64(y)
+			copy(texels[(y*bx+x)*4:], []byte{uint8(200*f + 0.5), uint8(140*f + 0.5), uint8(90*f + 0.5), 255})
+		}
+	}
+	if !blockFitsRGBScale(texels) {
+		t.Fatalf("shading block does not fit the RGB scale format")
+	}
+
+	ctx := getDecodeContext(bx, by, 1)
+	weights := [4]float32{1, 1, 1, 1}
+	blockError := func(tune *encoderTuning) (uint64, uint8) {
+		block, err := encodeBlockRGBA8LDR(ProfileLDR, bx, by, 1, texels, EncodeThorough, weights, 0, 1, tune)
+		if err != nil {
+			t.Fatalf("encodeBlockRGBA8LDR: %v", err)
+		}
+		scb := physicalToSymbolic(block[:], bx, by, 1)
+		if scb.blockType == symBlockError {
+			t.Fatalf("encoder produced an error block")
+		}
+		decoded := make([]byte, len(texels))
+		decodeBlockToRGBA8(ProfileLDR, ctx, block[:], decoded)
+		return blockErrorRGBA8(texels, decoded), scb.colorFormats[0]
+	}
+
+	tune := encoderTuningFor(EncodeThorough, bx*by)
+	scaleErr, format := blockError(&tune)
+	if format != fmtRGBScale {
+		t.Fatalf("endpoint format %d, want fmtRGBScale", format)
+	}
+	tune.rgbScaleEndpoints = false
+	baseErr, _ := blockError(&tune)
+	if scaleErr > baseErr {
+		t.Fatalf("RGB scale endpoints increased error: got %d want <= %d", scaleErr, baseErr)
+	}
+
+	// A color gradient between two chromas is not a scaled copy of one color.
+	for i := 0; i < len(texels); i += 4 {
+		texels[i+2] = uint8(i / 4)
+	}
+	if blockFitsRGBScale(texels) {
+		t.Fatalf("two-chroma block accepted for the RGB scale format")
+	}
+}
+
+func TestRGBScaleEndpoints_ImprovesShading(t *testing.T) {
+	// Opaque shading of one material: every texel is a scaled copy of one color.
+	const w, h = 64, 64
+	src := make([]byte, w*h*4)
+	for y := range h {
+		for x := range w {
+			f := 0.55 + 0.25*math.Sin(float64(x)*0.11)*math.Cos(float64(y)*0.07) + 0.2*math.Sin(float64(x+y)*0.03)
+			copy(src[(y*w+x)*4:], []byte{byte(math.Round(200 * f)), byte(math.Round(140 * f)), byte(math.Round(90 * f)), 255})
+		}
+	}
+	tune := configTuning(t, 8, 8, EncodeThorough)
+	scaled := tunedImagePSNR(t, src, w, h, 8, 8, EncodeThorough, tune)
+	tune.rgbScaleEndpoints = false
+	base := tunedImagePSNR(t, src, w, h, 8, 8, EncodeThorough, tune)
+	if scaled < base+1.5 {
+		t.Fatalf("RGB scale PSNR %.2f dB, want at least 1.5 dB above %.2f dB", scaled, base)
+	}
+}
@@ -0,0 +1,196 @@
+package astc
+
+// errorKernelRGBA8 evaluates the weighted squared error of LDR candidate encodings.
+//
+// It replaces the per-texel candidate loops of encodeBlockRGBA8LDR for plain color data (not
+// FlagMapNormal or FlagMapRGBM). The source texels are widened to UNORM16 once per block, in one
+// int32 row per channel, and each candidate is then scored over groups of four texels: the weights
+// of a group are infilled and its texels decoded and compared through fixed-size array views, so the
+// texel loop has no bounds checks and no data-dependent branches, and the early-out test runs once
+// per group. Results are bit-identical to the scalar loops; see candidateError.
+type errorKernelRGBA8 struct {
+	texelCount int
+
+	// src holds the source texels as UNORM16 (v*257), one row per channel.
+	src [4][blockMaxTexels]int32
+	// part holds the partition of each texel for the current candidate.
+	part [blockMaxTexels]uint8
+
+	// chw holds the channel error weights; intWeights reports that they are integers in 0..64,
+	// also held in chwInt.
+	chw        [4]float64
+	chwInt     [4]uint64
+	intWeights bool
+
+	// tw holds the per-texel error weights if texelWeighted is set.
+	tw            [blockMaxTexels]float64
+	texelWeighted bool
+}
+
+// errorKernelGroup is the number of texels processed per kernel iteration. blockMaxTexels and
+// weightsPlane2Offset are multiples of it, so the last group of a block never runs past the rows.
+const errorKernelGroup = 4
+
+// errorKernelCandidate describes the weights and endpoints of one candidate encoding.
+type errorKernelCandidate struct {
+	// weightsUQ holds the unquantized (0..64) weights of both planes, plane 2 starting at
+	// weightsPlane2Offset. dec infills them to texels unless noDecimation is set.
+	weightsUQ    *[blockMaxWeights]uint8
+	dec          []decimationEntry
+	noDecimation bool
+	// dualPlane selects plane 2 weights for alpha.
+	dualPlane bool
+
+	// ep0 and epd are the UNORM16 endpoint 0 and endpoint delta of each partition.
+	ep0, epd *[4][4]int32
+}
+
+// setTexels loads the RGBA8 source texels of a block.
+func (k *errorKernelRGBA8) setTexels(texels []byte) {
+	n := len(texels) / 4
+	k.texelCount = n
+	for t := 0; t < n; t++ {
+		px := texels[t*4 : t*4+4 : t*4+4]
+		k.src[0][t] = int32(px[0]) * 257
+		k.src[1][t] = int32(px[1]) * 257
+		k.src[2][t] = int32(px[2]) * 257
+		k.src[3][t] = int32(px[3]) * 257
+	}
+}
+
+// setPartitions loads the partition assignment of a candidate; nil means one partition.
+func (k *errorKernelRGBA8) setPartitions(assign []uint8) {
+	if assign == nil {
+		clear(k.part[:k.texelCount])
+		return
+	}
+	copy(k.part[:k.texelCount], assign)
+}
+
+// setChannelWeights sets the per-channel error weights. They must be float32 values.
+func (k *errorKernelRGBA8) setChannelWeights(chw [4]float64) {
+	k.chw = chw
+	k.intWeights = true
+	for c, w := range chw {
+		k.chwInt[c] = uint64(w)
+		if w < 0 || w > 64 || float64(k.chwInt[c]) != w {
+			k.intWeights = false
+		}
+	}
+}
+
+// setTexelWeights sets the per-texel error weights, which multiply the channel-weighted error of
+// each texel; nil weighs every texel equally.
+func (k *errorKernelRGBA8) setTexelWeights(w []float32) {
+	k.texelWeighted = w != nil
+	for t, v := range w {
+		k.tw[t] = float64(v)
+	}
+}
+
+// candidateError returns the channel-weighted squared error of candidate c over the texels, with
+// the loaded partition assignment. useU8 compares decode_unorm8 results instead of UNORM16. The sum
+// is abandoned once it reaches limit; the returned value is then only known to be >= limit.
+//
+// Because the channel weights are float32 values, w*d is exact in float64 and w*d*d equals w*(d*d)
+// with d*d computed in integer arithmetic (|d| <= 0xFFFF, so d*d fits a uint32). With small integer
+// weights (such as the default 1, 1, 1, 1) every term and partial sum is an integer below 2^53, so
+// the float64 sum is exact and is accumulated in a uint64 instead. Per-texel weights always use
+// the float64 sum.
+func (k *errorKernelRGBA8) candidateError(c *errorKernelCandidate, useU8 bool, limit float64) float64 {
+	n := k.texelCount
+	uq1 := c.weightsUQ
+	uq2 := (*[weightsPlane2Offset]uint8)(c.weightsUQ[weightsPlane2Offset:])
+	ep0, epd := c.ep0, c.epd
+	intWeights := k.intWeights
+	iR, iG, iB, iA := k.chwInt[0], k.chwInt[1], k.chwInt[2], k.chwInt[3]
+	fR, fG, fB, fA := k.chw[0], k.chw[1], k.chw[2], k.chw[3]
+
+	// Only one of the sums is used, so their total is exact.
+	var errInt uint64
+	var errFloat float64
+	for t := 0; t < n; t += errorKernelGroup {
+		m := min(errorKernelGroup, n-t)
+
+		var w1, w2 [errorKernelGroup]int32
+		if c.noDecimation {
+			// The weight grid covers every texel, so a group never runs past its plane.
+			g1 := (*[errorKernelGroup]uint8)(uq1[t:])
+			for i := range errorKernelGroup {
+				w1[i] = int32(g1[i])
+			}
+			if c.dualPlane {
+				g2 := (*[errorKernelGroup]uint8)(uq2[t:])
+				for i := range errorKernelGroup {
+					w2[i] = int32(g2[i])
+				}
+			}
+		} else {
+			dec := c.dec[t : t+m]
+			for i := range dec {
+				e := &dec[i]
+				sum := uint32(8)
+				sum += uint32(uq1[e.idx[0]&(blockMaxWeights-1)]) * uint32(e.w[0])
+				sum += uint32(uq1[e.idx[1]&(blockMaxWeights-1)]) * uint32(e.w[1])
+				sum += uint32(uq1[e.idx[2]&(blockMaxWeights-1)]) * uint32(e.w[2])
+				sum += uint32(uq1[e.idx[3]&(blockMaxWeights-1)]) * uint32(e.w[3])
+				w1[i] = int32(sum >> 4)
+			}
+			if c.dualPlane {
+				for i := range dec {
+					e := &dec[i]
+					sum := uint32(8)
+					sum += uint32(uq2[e.idx[0]&(weightsPlane2Offset-1)]) * uint32(e.w[0])
+					sum += uint32(uq2[e.idx[1]&(weightsPlane2Offset-1)]) * uint32(e.w[1])
+					sum += uint32(uq2[e.idx[2]&(weightsPlane2Offset-1)]) * uint32(e.w[2])
+					sum += uint32(uq2[e.idx[3]&(weightsPlane2Offset-1)]) * uint32(e.w[3])
+					w2[i] = int32(sum >> 4)
+				}
+			}
+		}
+		if !c.dualPlane {
+			w2 = w1
+		}
+
+		sr := (*[errorKernelGroup]int32)(k.src[0][t:])
+		sg := (*[errorKernelGroup]int32)(k.src[1][t:])
+		sb := (*[errorKernelGroup]int32)(k.src[2][t:])
+		sa := (*[errorKernelGroup]int32)(k.src[3][t:])
+		pp := (*[errorKernelGroup]uint8)(k.part[t:])
+		tw := (*[errorKernelGroup]float64)(k.tw[t:])
+		// i < m <= 4; the i&3 indexes let the compiler drop the bounds checks.
+		for i := range m {
+			e0 := &ep0[pp[i&3]&3]
+			d := &epd[pp[i&3]&3]
+
+			r := e0[0] + ((d[0]*w1[i&3] + 32) >> 6)
+			g := e0[1] + ((d[1]*w1[i&3] + 32) >> 6)
+			b := e0[2] + ((d[2]*w1[i&3] + 32) >> 6)
+			a := e0[3] + ((d[3]*w2[i&3] + 32) >> 6)
+			// The selections below are loop-invariant and always predicted; decoded values are in
+			// 0..0xFFFF, so decode_unorm8 rounding needs no clamp.
+			if useU8 {
+				r = (r >> 8) * 257
+				g = (g >> 8) * 257
+				b = (b >> 8) * 257
+				a = (a >> 8) * 257
+			}
+
+			dr := sr[i&3] - r
+			dg := sg[i&3] - g
+			db := sb[i&3] - b
+			da := sa[i&3] - a
+			if k.texelWeighted {
+				errFloat += tw[i&3] * (fR*float64(uint32(dr*dr)) + fG*float64(uint32(dg*dg)) + fB*float64(uint32(db*db)) + fA*float64(uint32(da*da)))
+			} else if intWeights {
+				errInt += iR*uint64(uint32(dr*dr)) + iG*uint64(uint32(dg*dg)) + iB*uint64(uint32(db*db)) + iA*uint64(uint32(da*da))
+			} else {
+				errFloat += fR*float64(uint32(dr*dr)) + fG*float64(uint32(dg*dg)) + fB*float64(uint32(db*db)) + fA*float64(uint32(da*da))
+			}
+		}
+		if float64(errInt)+errFloat >= limit {
+			break
+		}
+	}
+	return float64(errInt) + errFloat
+}
@@ -0,0 +1,230 @@
+package astc
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// benchBlockTexels returns a deterministic textured block: smooth gradients with noise and a
+// partial alpha edge, so the encoder evaluates partitioned and dual-plane candidates.
+func benchBlockTexels(bx, by int, seed int64) []byte {
+	rng := rand.New(rand.NewSource(seed))
+	texels := make([]byte, bx*by*4)
+	for y := 0; y < by; y++ {
+		for x := 0; x < bx; x++ {
+			off := (y*bx + x) * 4
+			texels[off+0] = uint8(x*200/bx + rng.Intn(24))
+			texels[off+1] = uint8(y*180/by + rng.Intn(24))
+			texels[off+2] = uint8(255 - (x+y)*100/(bx+by) - rng.Intn(24))
+			texels[off+3] = 255
+			if x+y > bx {
+				texels[off+3] = uint8(120 + rng.Intn(32))
+			}
+		}
+	}
+	return texels
+}
+
+func BenchmarkEncodeBlockRGBA8LDR(b *testing.B) {
+	weights := [4]float32{1, 1, 1, 1}
+	for _, bs := range [][2]int{{4, 4}, {6, 6}, {8, 8}, {12, 12}} {
+		for _, q := range []struct {
+			name    string
+			quality EncodeQuality
+		}{{"medium", EncodeMedium}, {"thorough", EncodeThorough}} {
+			bx, by := bs[0], bs[1]
+			b.Run(fmt.Sprintf("%dx%d/%s", bx, by, q.name), func(b *testing.B) {
+				var blocks [8][]byte
+				for i := range blocks {
+					blocks[i] = benchBlockTexels(bx, by, int64(i))
+				}
+				b.SetBytes(int64(bx * by * 4))
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					if _, err := encodeBlockRGBA8LDR(ProfileLDR, bx, by, 1, blocks[i%len(blocks)], q.quality, weights, 0, 1, nil); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+// candidateErrorScalar is the per-texel reference for errorKernelRGBA8.candidateError, written like
+// the loops it replaced.
+func candidateErrorScalar(texels []byte, assign []uint8, weightsUQ []uint8, dec []decimationEntry, noDecimation, dualPlane, useU8 bool, ep0, epd *[4][4]int32, chw [4]float64, limit float64) float64 {
+	wR, wG, wB, wA := chw[0], chw[1], chw[2], chw[3]
+	var errv float64
+	for t := 0; t < len(texels)/4; t++ {
+		var w1, w2 int32
+		if noDecimation {
+			w1 = int32(weightsUQ[t])
+			if dualPlane {
+				w2 = int32(weightsUQ[t+weightsPlane2Offset])
+			}
+		} else {
+			e := dec[t]
+			sum1 := uint32(8)
+			sum1 += uint32(weightsUQ[e.idx[0]]) * uint32(e.w[0])
+			sum1 += uint32(weightsUQ[e.idx[1]]) * uint32(e.w[1])
+			sum1 += uint32(weightsUQ[e.idx[2]]) * uint32(e.w[2])
+			sum1 += uint32(weightsUQ[e.idx[3]]) * uint32(e.w[3])
+			w1 = int32(sum1 >> 4)
+			if dualPlane {
+				sum2 := uint32(8)
+				sum2 += uint32(weightsUQ[int(e.idx[0])+weightsPlane2Offset]) * uint32(e.w[0])
+				sum2 += uint32(weightsUQ[int(e.idx[1])+weightsPlane2Offset]) * uint32(e.w[1])
+				sum2 += uint32(weightsUQ[int(e.idx[2])+weightsPlane2Offset]) * uint32(e.w[2])
+				sum2 += uint32(weightsUQ[int(e.idx[3])+weightsPlane2Offset]) * uint32(e.w[3])
+				w2 = int32(sum2 >> 4)
+			}
+		}
+		if !dualPlane {
+			w2 = w1
+		}
+
+		part := 0
+		if assign != nil {
+			part = int(assign[t])
+		}
+		e0 := ep0[part]
+		d := epd[part]
+		off := t * 4
+
+		r16 := e0[0] + ((d[0]*w1 + 32) >> 6)
+		g16 := e0[1] + ((d[1]*w1 + 32) >> 6)
+		b16 := e0[2] + ((d[2]*w1 + 32) >> 6)
+		a16 := e0[3] + ((d[3]*w2 + 32) >> 6)
+		if useU8 {
+			r16 = u16ToU8ReplicatedI32(r16)
+			g16 = u16ToU8ReplicatedI32(g16)
+			b16 = u16ToU8ReplicatedI32(b16)
+			a16 = u16ToU8ReplicatedI32(a16)
+		}
+
+		dr := float64(u8ToU16ReplicatedI32(texels[off+0]) - r16)
+		dg := float64(u8ToU16ReplicatedI32(texels[off+1]) - g16)
+		db := float64(u8ToU16ReplicatedI32(texels[off+2]) - b16)
+		da := float64(u8ToU16ReplicatedI32(texels[off+3]) - a16)
+		errv += wR*dr*dr + wG*dg*dg + wB*db*db + wA*da*da
+
+		if errv >= limit {
+			break
+		}
+	}
+	return errv
+}
+
+type errorKernelCase struct {
+	texels       []byte
+	assign       []uint8
+	weightsUQ    [blockMaxWeights]uint8
+	dec          []decimationEntry
+	noDecimation bool
+	dualPlane    bool
+	ep0, epd     [4][4]int32
+	chw          [4]float64
+}
+
+func (c *errorKernelCase) candidate() errorKernelCandidate {
+	return errorKernelCandidate{
+		weightsUQ:    &c.weightsUQ,
+		dec:          c.dec,
+		noDecimation: c.noDecimation,
+		dualPlane:    c.dualPlane,
+		ep0:          &c.ep0,
+		epd:          &c.epd,
+	}
+}
+
+func newErrorKernelCase(rng *rand.Rand, bx, by, wx, wy, partitions int, dualPlane bool) errorKernelCase {
+	c := errorKernelCase{
+		texels:       benchBlockTexels(bx, by, rng.Int63()),
+		dec:          getDecimationTable(bx, by, 1, wx, wy, 1),
+		noDecimation: wx == bx && wy == by,
+		dualPlane:    dualPlane,
+		chw:          [4]float64{1, 1, 1, 1},
+	}
+	if partitions > 1 {
+		c.assign = make([]uint8, bx*by)
+		for i := range c.assign {
+			c.assign[i] = uint8(rng.Intn(partitions))
+		}
+	}
+	for i := range c.weightsUQ {
+		c.weightsUQ[i] = uint8(rng.Intn(65))
+	}
+	for p := 0; p < 4; p++ {
+		for ch := 0; ch < 4; ch++ {
+			e0 := int32(rng.Intn(256)) * 257
+			e1 := int32(rng.Intn(256)) * 257
+			c.ep0[p][ch], c.epd[p][ch] = e0, e1-e0
+		}
+	}
+	return c
+}
+
+func TestErrorKernelRGBA8_MatchesScalar(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	shapes := [][4]int{{4, 4, 4, 4}, {5, 5, 3, 4}, {6, 6, 6, 6}, {8, 6, 5, 4}, {10, 5, 6, 3}, {12, 12, 6, 5}}
+	for _, s := range shapes {
+		for partitions := 1; partitions <= 4; partitions++ {
+			for _, dual := range []bool{false, true} {
+				if dual && s[2]*s[3] > weightsPlane2Offset {
+					continue
+				}
+				for _, useU8 := range []bool{false, true} {
+					c := newErrorKernelCase(rng, s[0], s[1], s[2], s[3], partitions, dual)
+					if useU8 {
+						// Fractional weights take the float64 path.
+						c.chw = [4]float64{float64(float32(0.3 * 2.25)), float64(float32(0.59 * 2.25)), 2, 1.25}
+					}
+					var k errorKernelRGBA8
+					k.setTexels(c.texels)
+					k.setChannelWeights(c.chw)
+					k.setPartitions(c.assign)
+					kc := c.candidate()
+
+					full := candidateErrorScalar(c.texels, c.assign, c.weightsUQ[:], c.dec, c.noDecimation, dual, useU8, &c.ep0, &c.epd, c.chw, math.Inf(1))
+					got := k.candidateError(&kc, useU8, math.Inf(1))
+					if got != full {
+						t.Fatalf("%dx%d w%dx%d p%d dual=%v u8=%v: kernel error %v, scalar %v", s[0], s[1], s[2], s[3], partitions, dual, useU8, got, full)
+					}
+
+					// An early-out must still reject the candidate against the same limit.
+					limit := full / 2
+					if got := k.candidateError(&kc, useU8, limit); got < limit {
+						t.Fatalf("%dx%d p%d: early-out returned %v below limit %v", s[0], s[1], partitions, got, limit)
+					}
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkCandidateErrorRGBA8(b *testing.B) {
+	for _, s := range [][4]int{{4, 4, 4, 4}, {6, 6, 6, 6}, {8, 8, 6, 5}, {12, 12, 8, 6}} {
+		c := newErrorKernelCase(rand.New(rand.NewSource(1)), s[0], s[1], s[2], s[3], 2, false)
+		texelCount := s[0] * s[1]
+		name := fmt.Sprintf("%dx%d_w%dx%d", s[0], s[1], s[2], s[3])
+		b.Run(name+"/scalar", func(b *testing.B) {
+			b.SetBytes(int64(texelCount * 4))
+			for i := 0; i < b.N; i++ {
+				candidateErrorScalar(c.texels, c.assign, c.weightsUQ[:], c.dec, c.noDecimation, false, false, &c.ep0, &c.epd, c.chw, math.Inf(1))
+			}
+		})
+		b.Run(name+"/kernel", func(b *testing.B) {
+			var k errorKernelRGBA8
+			k.setTexels(c.texels)
+			k.setChannelWeights(c.chw)
+			b.SetBytes(int64(texelCount * 4))
+			for i := 0; i < b.N; i++ {
+				k.setPartitions(c.assign)
+				kc := c.candidate()
+				k.candidateError(&kc, false, math.Inf(1))
+			}
+		})
+	}
+}
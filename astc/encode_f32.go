@@ -20,7 +20,14 @@ func EncodeRGBAF32(pix []float32, width, height int, blockX, blockY int) ([]byte
 // Supported profiles:
 //   - ProfileHDR
 //   - ProfileHDRRGBLDRAlpha
+//
+// Deprecated: Use NewEncoder with WithBlockSize, WithProfile and WithQuality, and
+// Encoder.EncodeRGBAF32.
 func EncodeRGBAF32WithProfileAndQuality(pix []float32, width, height int, blockX, blockY int, profile Profile, quality EncodeQuality) ([]byte, error) {
+	return encodeRGBAF32(pix, width, height, blockX, blockY, profile, quality, [4]float32{1, 1, 1, 1})
+}
+
+func encodeRGBAF32(pix []float32, width, height int, blockX, blockY int, profile Profile, quality EncodeQuality, channelWeight [4]float32) ([]byte, error) {
 	if width <= 0 || height <= 0 {
 		return nil, errors.New("astc: invalid image dimensions")
 	}
@@ -74,7 +81,7 @@ func EncodeRGBAF32WithProfileAndQuality(pix []float32, width, height int, blockX
 		for by := 0; by < blocksY; by++ {
 			for bx := 0; bx < blocksX; bx++ {
 				extractBlockRGBAF32(pix, width, height, bx*blockX, by*blockY, blockX, blockY, blockTexels)
-				block, err := encodeBlockRGBAF32HDR(profile, blockX, blockY, 1, blockTexels, quality, [4]float32{1, 1, 1, 1}, nil)
+				block, err := encodeBlockRGBAF32HDR(profile, blockX, blockY, 1, blockTexels, quality, channelWeight, nil)
 				if err != nil {
 					return nil, err
 				}
@@ -108,7 +115,7 @@ func EncodeRGBAF32WithProfileAndQuality(pix []float32, width, height int, blockX
 				bx := idx % blocksX
 				by := idx / blocksX
 				extractBlockRGBAF32(pix, width, height, bx*blockX, by*blockY, blockX, blockY, blockTexels)
-				block, err := encodeBlockRGBAF32HDR(profile, blockX, blockY, 1, blockTexels, quality, [4]float32{1, 1, 1, 1}, nil)
+				block, err := encodeBlockRGBAF32HDR(profile, blockX, blockY, 1, blockTexels, quality, channelWeight, nil)
 				if err != nil {
 					errOnce.Do(func() {
 						firstErr = err
@@ -141,6 +148,8 @@ func EncodeRGBAF32Volume(pix []float32, width, height, depth int, blockX, blockY
 // Supported profiles:
 //   - ProfileHDR
 //   - ProfileHDRRGBLDRAlpha
+//
+// Deprecated: Use NewEncoder with WithBlockSize3D, WithProfile and WithQuality, and Encoder.Encode.
 func EncodeRGBAF32VolumeWithProfileAndQuality(pix []float32, width, height, depth int, blockX, blockY, blockZ int, profile Profile, quality EncodeQuality) ([]byte, error) {
 	if width <= 0 || height <= 0 || depth <= 0 {
 		return nil, errors.New("astc: invalid image dimensions")
@@ -0,0 +1,61 @@
+package astc
+
+import "math"
+
+// importanceNeutral is the Image.Importance value which leaves a block's search effort and a
+// texel's error weight unchanged.
+const importanceNeutral = 128
+
+// blockImportance returns the mean Image.Importance of the texels of the block at (x0, y0, z0)
+// that lie inside the image, mapped to [-1, 1] with importanceNeutral at 0.
+func blockImportance(img *Image, x0, y0, z0, blockX, blockY, blockZ int) float32 {
+	xe, ye, ze := min(x0+blockX, img.DimX), min(y0+blockY, img.DimY), min(z0+blockZ, img.DimZ)
+	sum, n := 0, 0
+	for z := z0; z < ze; z++ {
+		for y := y0; y < ye; y++ {
+			row := img.Importance[(z*img.DimY+y)*img.DimX:]
+			for x := x0; x < xe; x++ {
+				sum += int(row[x])
+			}
+			n += xe - x0
+		}
+	}
+	if n == 0 {
+		return 0
+	}
+	mean := float32(sum) / float32(n)
+	return max(min((mean-importanceNeutral)/(255-importanceNeutral), 1), -1)
+}
+
+// importanceEffort returns the search effort factor (1/2 to 2) of a block with importance e in
+// [-1, 1].
+func importanceEffort(e float32) float32 {
+	return float32(math.Exp2(float64(e)))
+}
+
+// importanceTexelWeights multiplies w by the error weight of each texel of the block at
+// (x0, y0, z0): 4^e for the texel's Image.Importance mapped to e in [-1, 1]. Texels outside the image take the
+// weight of the nearest texel inside it, as they take its color.
+func importanceTexelWeights(img *Image, x0, y0, z0, blockX, blockY, blockZ int, w []float32) {
+	lut := &importanceWeights
+	t := 0
+	for z := z0; z < z0+blockZ; z++ {
+		zc := min(z, img.DimZ-1)
+		for y := y0; y < y0+blockY; y++ {
+			row := img.Importance[(zc*img.DimY+min(y, img.DimY-1))*img.DimX:]
+			for x := x0; x < x0+blockX; x++ {
+				w[t] *= lut[row[min(x, img.DimX-1)]]
+				t++
+			}
+		}
+	}
+}
+
+// importanceWeights maps Image.Importance values to texel error weights.
+var importanceWeights = func() (lut [256]float32) {
+	for v := range lut {
+		e := max(min((float64(v)-importanceNeutral)/(255-importanceNeutral), 1), -1)
+		lut[v] = float32(math.Exp2(2 * e))
+	}
+	return lut
+}()
@@ -0,0 +1,145 @@
+package astc_test
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/arm-software/astc-encoder/astc"
+	"github.com/arm-software/astc-encoder/astc/testimage"
+)
+
+func TestContext_CompressImage_ImportanceMap(t *testing.T) {
+	const w, h = 64, 32
+	src := make([]byte, w*h*4)
+	for i := range src {
+		src[i] = uint8(i*37 + i/11*29)
+	}
+	// The left half matters, the right half does not.
+	importance := make([]byte, w*h)
+	for i := range importance {
+		if i%w < w/2 {
+			importance[i] = 255
+		}
+	}
+
+	encode := func(importance []byte) []byte {
+		t.Helper()
+		cfg, err := astc.ConfigInit(astc.ProfileLDR, 8, 8, 1, 10, 0)
+		if err != nil {
+			t.Fatalf("ConfigInit: %v", err)
+		}
+		ctx, err := astc.ContextAlloc(&cfg, 1)
+		if err != nil {
+			t.Fatalf("ContextAlloc: %v", err)
+		}
+		blocks := make([]byte, blocksLenBytes(w, h, 1, 8, 8, 1))
+		img := astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeU8, DataU8: src, Importance: importance}
+		if err := ctx.CompressImage(&img, astc.SwizzleRGBA, blocks, 0); err != nil {
+			t.Fatalf("CompressImage: %v", err)
+		}
+		return blocks
+	}
+	halves := func(blocks []byte) (left, right float64) {
+		t.Helper()
+		hdr, err := astc.MarshalHeader(astc.Header{BlockX: 8, BlockY: 8, BlockZ: 1, SizeX: w, SizeY: h, SizeZ: 1})
+		if err != nil {
+			t.Fatalf("MarshalHeader: %v", err)
+		}
+		got, _, _, err := astc.DecodeRGBA8(append(hdr[:], blocks...))
+		if err != nil {
+			t.Fatalf("DecodeRGBA8: %v", err)
+		}
+		for i := range got {
+			d := float64(got[i]) - float64(src[i])
+			if i/4%w < w/2 {
+				left += d * d
+			} else {
+				right += d * d
+			}
+		}
+		return left, right
+	}
+
+	plain := encode(nil)
+	neutral := make([]byte, w*h)
+	for i := range neutral {
+		neutral[i] = 128
+	}
+	if !bytes.Equal(encode(neutral), plain) {
+		t.Fatalf("neutral importance map changed the encoding")
+	}
+	plainLeft, plainRight := halves(plain)
+	left, right := halves(encode(importance))
+	if left >= plainLeft || right <= plainRight {
+		t.Fatalf("importance map: squared error left %.0f right %.0f, without map left %.0f right %.0f", left, right, plainLeft, plainRight)
+	}
+
+	cfg, _ := astc.ConfigInit(astc.ProfileLDR, 8, 8, 1, 10, 0)
+	ctx, _ := astc.ContextAlloc(&cfg, 1)
+	img := astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeU8, DataU8: src, Importance: importance[1:]}
+	if err := ctx.CompressImage(&img, astc.SwizzleRGBA, plain, 0); astc.ErrorCodeOf(err) != astc.ErrBadParam {
+		t.Fatalf("short importance map: expected ErrBadParam, got %v", err)
+	}
+}
+
+// TestImportanceMap_RegionPSNR paints a region that is not aligned to the blocks, so every block
+// it touches also holds unimportant texels, and checks that the painted texels decode closer to
+// the source than without the map.
+func TestImportanceMap_RegionPSNR(t *testing.T) {
+	const w, h, bs = 64, 64, 8
+	src, err := testimage.RGBA8(testimage.KindPerlin, w, h, 1, testimage.Options{})
+	if err != nil {
+		t.Fatalf("testimage: %v", err)
+	}
+	inRegion := func(x, y int) bool { return x >= 13 && x < 45 && y >= 5 && y < 35 }
+	importance := make([]byte, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if inRegion(x, y) {
+				importance[y*w+x] = 255
+			}
+		}
+	}
+
+	regionPSNR := func(importance []byte) float64 {
+		t.Helper()
+		cfg, err := astc.ConfigInit(astc.ProfileLDR, bs, bs, 1, 60, 0)
+		if err != nil {
+			t.Fatalf("ConfigInit: %v", err)
+		}
+		ctx, err := astc.ContextAlloc(&cfg, 1)
+		if err != nil {
+			t.Fatalf("ContextAlloc: %v", err)
+		}
+		blocks := make([]byte, blocksLenBytes(w, h, 1, bs, bs, 1))
+		img := astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeU8, DataU8: src, Importance: importance}
+		if err := ctx.CompressImage(&img, astc.SwizzleRGBA, blocks, 0); err != nil {
+			t.Fatalf("CompressImage: %v", err)
+		}
+		hdr, err := astc.MarshalHeader(astc.Header{BlockX: bs, BlockY: bs, BlockZ: 1, SizeX: w, SizeY: h, SizeZ: 1})
+		if err != nil {
+			t.Fatalf("MarshalHeader: %v", err)
+		}
+		got, _, _, err := astc.DecodeRGBA8(append(hdr[:], blocks...))
+		if err != nil {
+			t.Fatalf("DecodeRGBA8: %v", err)
+		}
+		var sum float64
+		n := 0
+		for i := range got {
+			if !inRegion(i/4%w, i/4/w) {
+				continue
+			}
+			d := float64(got[i]) - float64(src[i])
+			sum += d * d
+			n++
+		}
+		return 10 * math.Log10(255*255/(sum/float64(n)))
+	}
+
+	plain, painted := regionPSNR(nil), regionPSNR(importance)
+	if painted < plain+0.75 {
+		t.Fatalf("painted region PSNR %.2f dB with the importance map, want above %.2f dB without it", painted, plain)
+	}
+}
@@ -55,3 +55,25 @@ func TestEncodeRGBA8Volume_Uses3DBlockModes(t *testing.T) {
 		t.Fatalf("unexpected zWeights=%d for 3D block mode=%d", zw, blockMode)
 	}
 }
+
+func TestTunedBlockModes_CacheBounded(t *testing.T) {
+	base := encoderTuningFor(EncodeMedium, 16)
+	for i := range 2 * boundedBlockModeCacheCap {
+		var mask BlockModeMask
+		mask.Set(i)
+		tune := base
+		tune.disallowedModes = &mask
+		modes := tunedBlockModes(4, 4, 1, &tune)
+		for _, m := range modes {
+			if m.mode == i {
+				t.Fatalf("mask %d: disallowed mode returned", i)
+			}
+		}
+	}
+	boundedBlockModeCacheMu.RLock()
+	n := len(boundedBlockModeCache)
+	boundedBlockModeCacheMu.RUnlock()
+	if n > boundedBlockModeCacheCap {
+		t.Fatalf("cache holds %d lists, want at most %d", n, boundedBlockModeCacheCap)
+	}
+}
@@ -0,0 +1,102 @@
+package astc
+
+// periodicModeCount is the number of aligned block modes the thorough and higher presets add for
+// periodic blocks.
+const periodicModeCount = 8
+
+// Repetition of a block's texels along one axis, as blockAxisRepetition reports it.
+const (
+	axisIrregular uint8 = iota
+	// Every texel equals its neighbor along the axis.
+	axisConstant
+	// The texels repeat with period 2 or 4 but are not constant.
+	axisPeriodic
+)
+
+// blockAxisRepetition classifies the texels of a block along axis (0 for x, 1 for y, 2 for z).
+// Periodic content, typical of tiling detail and noise maps, is only reproduced by a weight grid
+// with one point per texel on that axis: any coarser grid interpolates across the repetition and
+// blurs it away. Along a constant axis, on the other hand, two grid points are as exact as any
+// number and leave the most bits for weight precision.
+func blockAxisRepetition(texels []byte, blockX, blockY, blockZ, axis int) uint8 {
+	dims := [3]int{blockX, blockY, blockZ}
+	n := dims[axis]
+	if blockAxisRepeats(texels, dims, axis, 1) {
+		return axisConstant
+	}
+	for _, p := range [...]int{2, 4} {
+		if p < n-1 && blockAxisRepeats(texels, dims, axis, p) {
+			return axisPeriodic
+		}
+	}
+	return axisIrregular
+}
+
+// blockAxisRepeats reports whether every texel at coordinate c >= period along axis equals the
+// texel at c-period.
+func blockAxisRepeats(texels []byte, dims [3]int, axis, period int) bool {
+	strides := [3]int{1, dims[0], dims[0] * dims[1]}
+	off := period * strides[axis] * 4
+	for z := range dims[2] {
+		for y := range dims[1] {
+			for x := range dims[0] {
+				c := [3]int{x, y, z}
+				if c[axis] < period {
+					continue
+				}
+				t := ((z*dims[1]+y)*dims[0] + x) * 4
+				if texels[t] != texels[t-off] || texels[t+1] != texels[t+1-off] ||
+					texels[t+2] != texels[t+2-off] || texels[t+3] != texels[t+3-off] {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+// periodicBlockModes returns modes, a prefix of candidates, extended by up to extra of the
+// remaining candidates whose weight grid is aligned to the block's repetition: one point per
+// texel along every periodic axis and the fewest points along every constant one (see
+// blockAxisRepetition). modes is returned unchanged if the block is not periodic along any axis;
+// otherwise the result is a new slice, so modes may alias a shared cache.
+func periodicBlockModes(modes, candidates []blockModeDesc, texels []byte, blockX, blockY, blockZ, extra int) []blockModeDesc {
+	dims := [3]int{blockX, blockY, blockZ}
+	var rep [3]uint8
+	periodic := false
+	for axis := range rep {
+		rep[axis] = blockAxisRepetition(texels, blockX, blockY, blockZ, axis)
+		periodic = periodic || rep[axis] == axisPeriodic
+	}
+	if !periodic {
+		return modes
+	}
+
+	var out []blockModeDesc
+	for _, m := range candidates[len(modes):] {
+		aligned := true
+		for axis, w := range [3]int{m.xWeights, m.yWeights, m.zWeights} {
+			switch rep[axis] {
+			case axisPeriodic:
+				aligned = aligned && w == dims[axis]
+			case axisConstant:
+				aligned = aligned && w == min(2, dims[axis])
+			}
+		}
+		if !aligned {
+			continue
+		}
+		if out == nil {
+			out = make([]blockModeDesc, len(modes), len(modes)+extra)
+			copy(out, modes)
+		}
+		out = append(out, m)
+		if len(out) == len(modes)+extra {
+			break
+		}
+	}
+	if out == nil {
+		return modes
+	}
+	return out
+}
@@ -0,0 +1,176 @@
+package astc
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// SanitizeMode selects how CompressImage treats float input values that ASTC cannot represent:
+// NaN, infinities and negative values.
+type SanitizeMode uint8
+
+const (
+	// SanitizeNone passes the values to the encoder unchanged (matches upstream). NaN and negative
+	// values end up as zero and +Inf as the largest value, but they also disturb the endpoint
+	// search of their whole block.
+	SanitizeNone SanitizeMode = iota
+	// SanitizeClamp replaces NaN and negative values with 0 and +Inf with 65504, the largest FP16
+	// value.
+	SanitizeClamp
+	// SanitizeNeighborAverage replaces each invalid channel value with the mean of the valid
+	// values of the same channel among the texel's 8 neighbors in its slice, or 0 if there are
+	// none. Neighbors are read from the unmodified input, so the result does not depend on the
+	// scan order.
+	SanitizeNeighborAverage
+	// SanitizeError rejects images holding invalid values with an ErrBadParam error listing the
+	// coordinates of the first offending texels.
+	SanitizeError
+)
+
+// HDRInputPolicy is a set of flags selecting how the HDR profiles encode FP16 edge cases; see
+// Config.HDRInput.
+type HDRInputPolicy uint8
+
+const (
+	// HDRFlushSubnormals flushes input values whose magnitude is below 2^-14, the FP16 subnormal
+	// range, to zero. By default they are kept where representable: constant-color blocks store
+	// them exactly as FP16, and other blocks quantize them to LNS endpoints, which reach down to
+	// about 2^-26 (anything smaller becomes zero).
+	HDRFlushSubnormals HDRInputPolicy = 1 << iota
+	// HDRFlushNaN replaces NaN input values with zero. By default a block whose texels all hold
+	// the same FP16 value is encoded as a constant-color block storing that value exactly, NaN
+	// included (the ASTC specification leaves the decoded result of such blocks to the
+	// implementation), while NaN in any other block is encoded as zero but still disturbs the
+	// endpoint search of that block. Use Config.InputSanitize to repair or reject NaN instead.
+	HDRFlushNaN
+	// HDRAlphaUNORM makes ProfileHDR encode alpha with UNORM16 (LDR) endpoints, like
+	// ProfileHDRRGBLDRAlpha, instead of the default LNS (HDR) endpoints. It is the difference
+	// between upstream astcenc's -hdr and -hdra modes; decoding is unaffected. It has no effect on
+	// other profiles.
+	HDRAlphaUNORM
+
+	hdrInputAll = HDRFlushSubnormals | HDRFlushNaN | HDRAlphaUNORM
+)
+
+// applyHDRInputPolicy applies the subnormal and NaN flags of policy to the RGBA texels in place.
+func applyHDRInputPolicy(texels []float32, policy HDRInputPolicy) {
+	if policy&(HDRFlushSubnormals|HDRFlushNaN) == 0 {
+		return
+	}
+	for i, v := range texels {
+		switch {
+		case v != v:
+			if policy&HDRFlushNaN != 0 {
+				texels[i] = 0
+			}
+		case policy&HDRFlushSubnormals != 0 && v > -1.0/16384 && v < 1.0/16384:
+			texels[i] = 0
+		}
+	}
+}
+
+// maxReportedTexels is the number of texel coordinates listed by SanitizeError.
+const maxReportedTexels = 8
+
+// validInputF32 reports whether v is a finite, non-negative value.
+func validInputF32(v float32) bool {
+	return v >= 0 && v <= math.MaxFloat32
+}
+
+// validInputF16 reports whether the FP16 value h is finite and non-negative. Negative zero is
+// valid.
+func validInputF16(h uint16) bool {
+	return h&0x7C00 != 0x7C00 && (h&0x8000 == 0 || h&0x7FFF == 0)
+}
+
+// sanitizeInput applies mode to a TypeF16 or TypeF32 image. It returns nil when the image can be
+// encoded as is, and otherwise a copy of img with repaired pixel data; img is never modified.
+func sanitizeInput(img *Image, inType DataType, mode SanitizeMode) (*Image, error) {
+	if mode == SanitizeNone || inType == TypeU8 {
+		return nil, nil
+	}
+	texels := img.DimX * img.DimY * img.DimZ
+	valid := func(i int) bool {
+		if inType == TypeF16 {
+			return validInputF16(img.DataF16[i])
+		}
+		return validInputF32(img.DataF32[i])
+	}
+
+	bad := 0
+	var report []string
+	for t := 0; t < texels; t++ {
+		if valid(t*4) && valid(t*4+1) && valid(t*4+2) && valid(t*4+3) {
+			continue
+		}
+		bad++
+		if mode == SanitizeError && len(report) < maxReportedTexels {
+			x, y, z := t%img.DimX, t/img.DimX%img.DimY, t/(img.DimX*img.DimY)
+			report = append(report, fmt.Sprintf("(%d,%d,%d)", x, y, z))
+		}
+	}
+	if bad == 0 {
+		return nil, nil
+	}
+	if mode == SanitizeError {
+		if bad > len(report) {
+			report = append(report, "...")
+		}
+		return nil, newError(ErrBadParam, fmt.Sprintf("astc: %d texels hold NaN, infinite or negative values: %s", bad, strings.Join(report, " ")))
+	}
+
+	// repair returns the replacement of the invalid channel value at texel (x, y, z).
+	repair := func(x, y, z, ch int, v float32) float32 {
+		if mode == SanitizeClamp {
+			if v > 0 { // +Inf
+				return 65504
+			}
+			return 0
+		}
+		sum, n := float32(0), 0
+		for dy := -1; dy <= 1; dy++ {
+			for dx := -1; dx <= 1; dx++ {
+				nx, ny := x+dx, y+dy
+				if (dx == 0 && dy == 0) || nx < 0 || ny < 0 || nx >= img.DimX || ny >= img.DimY {
+					continue
+				}
+				i := ((z*img.DimY+ny)*img.DimX+nx)*4 + ch
+				if valid(i) {
+					if inType == TypeF16 {
+						sum += HalfToFloat32(img.DataF16[i])
+					} else {
+						sum += img.DataF32[i]
+					}
+					n++
+				}
+			}
+		}
+		if n == 0 {
+			return 0
+		}
+		return sum / float32(n)
+	}
+
+	out := *img
+	if inType == TypeF16 {
+		out.DataF16 = append([]uint16(nil), img.DataF16[:texels*4]...)
+	} else {
+		out.DataF32 = append([]float32(nil), img.DataF32[:texels*4]...)
+	}
+	for t := 0; t < texels; t++ {
+		x, y, z := t%img.DimX, t/img.DimX%img.DimY, t/(img.DimX*img.DimY)
+		for ch := 0; ch < 4; ch++ {
+			i := t*4 + ch
+			if valid(i) {
+				continue
+			}
+			if inType == TypeF16 {
+				out.DataF16[i] = Float32ToHalf(repair(x, y, z, ch, HalfToFloat32(img.DataF16[i])))
+			} else {
+				out.DataF32[i] = repair(x, y, z, ch, img.DataF32[i])
+			}
+		}
+	}
+	return &out, nil
+}
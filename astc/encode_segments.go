@@ -0,0 +1,51 @@
+package astc
+
+import "context"
+
+// SegmentBuffer holds the storage behind the segments returned by Encoder.EncodeSegments, so that
+// repeated encodes of same-sized images reuse it instead of allocating. The zero value is ready to
+// use. A SegmentBuffer must not be shared by concurrent calls.
+type SegmentBuffer struct {
+	header [HeaderSize]byte
+	blocks []byte
+	segs   [][]byte
+}
+
+// EncodeSegments encodes img like Encode, but returns the .astc file as a scatter list instead of
+// one contiguous slice: the 16-byte header, then one segment per row of blocks in storage order,
+// then a final segment holding the padding if WithOutputAlignment adds any.
+// Concatenating the segments gives exactly the bytes Encode returns. The segments alias buf and
+// stay valid until buf is passed to the next call, so a writer can hand them to writev (for
+// example as a net.Buffers) alongside its own framing without first copying the whole texture
+// into an output-sized buffer.
+func (e *Encoder) EncodeSegments(img *Image, buf *SegmentBuffer) ([][]byte, error) {
+	if buf == nil {
+		return nil, newError(ErrBadParam, "astc: nil segment buffer")
+	}
+	headerBytes, blocksX, total, err := encodeHeader(img, &e.cfg)
+	if err != nil {
+		return nil, err
+	}
+	size := total * BlockBytes
+	pad := e.paddingBytes(size)
+	if cap(buf.blocks) < size+pad {
+		buf.blocks = make([]byte, size+pad)
+	}
+	buf.blocks = buf.blocks[:size+pad]
+	if err := e.compressBlocks(context.Background(), img, &e.cfg, total, buf.blocks[:size]); err != nil {
+		return nil, err
+	}
+
+	buf.header = headerBytes
+	rowBytes := blocksX * BlockBytes
+	segs := append(buf.segs[:0], buf.header[:])
+	for off := 0; off < size; off += rowBytes {
+		segs = append(segs, buf.blocks[off:off+rowBytes:off+rowBytes])
+	}
+	if pad > 0 {
+		fillPadding(buf.blocks[size:])
+		segs = append(segs, buf.blocks[size:])
+	}
+	buf.segs = segs
+	return segs, nil
+}
@@ -0,0 +1,57 @@
+package astc_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/arm-software/astc-encoder/astc"
+)
+
+func TestEncodeSegments_MatchesEncode(t *testing.T) {
+	enc, err := astc.NewEncoder(astc.WithBlockSize(6, 6), astc.WithQuality(astc.EncodeFast))
+	if err != nil {
+		t.Fatalf("NewEncoder: %v", err)
+	}
+	var buf astc.SegmentBuffer
+	var prevBlocks *byte
+	for _, size := range [][2]int{{45, 23}, {45, 23}, {12, 6}} {
+		w, h := size[0], size[1]
+		pix := make([]byte, w*h*4)
+		for i := range pix {
+			pix[i] = uint8(i*13 + i/7 + w)
+		}
+		img := &astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeU8, DataU8: pix}
+		want, err := enc.Encode(img)
+		if err != nil {
+			t.Fatalf("Encode: %v", err)
+		}
+		segs, err := enc.EncodeSegments(img, &buf)
+		if err != nil {
+			t.Fatalf("EncodeSegments: %v", err)
+		}
+		blocksX, blocksY := (w+5)/6, (h+5)/6
+		if len(segs) != 1+blocksY {
+			t.Fatalf("%dx%d: got %d segments, want %d", w, h, len(segs), 1+blocksY)
+		}
+		if len(segs[0]) != astc.HeaderSize {
+			t.Fatalf("%dx%d: header segment is %d bytes", w, h, len(segs[0]))
+		}
+		for i, seg := range segs[1:] {
+			if len(seg) != blocksX*astc.BlockBytes {
+				t.Fatalf("%dx%d: row %d segment is %d bytes", w, h, i, len(seg))
+			}
+		}
+		if got := bytes.Join(segs, nil); !bytes.Equal(got, want) {
+			t.Fatalf("%dx%d: joined segments differ from Encode", w, h)
+		}
+		// Smaller or equal outputs reuse the buffer's storage.
+		if prevBlocks != nil && &segs[1][0] != prevBlocks {
+			t.Fatalf("%dx%d: segment storage was reallocated", w, h)
+		}
+		prevBlocks = &segs[1][0]
+	}
+
+	if _, err := enc.EncodeSegments(&astc.Image{DimX: 4, DimY: 4, DimZ: 1, DataType: astc.TypeU8, DataU8: make([]byte, 64)}, nil); err == nil {
+		t.Fatalf("EncodeSegments accepted a nil buffer")
+	}
+}
@@ -0,0 +1,75 @@
+//go:build astcenc_native && cgo
+
+package astc_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/arm-software/astc-encoder/astc"
+	"github.com/arm-software/astc-encoder/astc/native"
+	"github.com/arm-software/astc-encoder/astc/testimage"
+)
+
+// TestEncodeSRGB_MatchesNative checks ProfileLDRSRGB encodes against astcenc. The Go encoder
+// searches differently, so its blocks are not astcenc's; what must match bit for bit is the
+// reconstruction its errors are measured on. TestEncoderReconstruction_MatchesDecode ties that
+// model to the Go decoder, and here astcenc decodes every Go block to the same texels, so the
+// texels the encoder optimizes are the ones astcenc produces. The search itself is shared with
+// ProfileLDR, so relative to astcenc's own encoding of the same image, the sRGB error must not
+// fall further behind than the LDR error does.
+func TestEncodeSRGB_MatchesNative(t *testing.T) {
+	const w, h = 48, 40
+	// squaredErrors returns the 8-bit squared error of the Go and the astcenc encoding of src.
+	squaredErrors := func(src []byte, block int, profile astc.Profile, quality astc.EncodeQuality) (goErr, nativeErr uint64) {
+		t.Helper()
+		enc, err := astc.NewEncoder(astc.WithBlockSize(block, block), astc.WithProfile(profile), astc.WithQuality(quality))
+		if err != nil {
+			t.Fatalf("NewEncoder: %v", err)
+		}
+		data, err := enc.EncodeRGBA8(src, w, h)
+		if err != nil {
+			t.Fatalf("Encode: %v", err)
+		}
+		goPix, _, _, err := astc.DecodeRGBA8WithProfile(data, profile)
+		if err != nil {
+			t.Fatalf("astc.DecodeRGBA8WithProfile: %v", err)
+		}
+		nPix, _, _, err := native.DecodeRGBA8WithProfile(data, profile)
+		if err != nil {
+			t.Fatalf("native.DecodeRGBA8WithProfile: %v", err)
+		}
+		if !bytes.Equal(goPix, nPix) {
+			t.Fatalf("%v %dx%d %v: astcenc decodes the Go encoding differently", profile, block, block, quality)
+		}
+
+		nData, err := native.EncodeRGBA8WithProfileAndQuality(src, w, h, block, block, profile, quality)
+		if err != nil {
+			t.Fatalf("native encode: %v", err)
+		}
+		refPix, _, _, err := native.DecodeRGBA8WithProfile(nData, profile)
+		if err != nil {
+			t.Fatalf("native.DecodeRGBA8WithProfile: %v", err)
+		}
+		return sumSquaredDiffU8(src, nPix), sumSquaredDiffU8(src, refPix)
+	}
+
+	for _, kind := range []testimage.Kind{testimage.KindGradient, testimage.KindPerlin, testimage.KindText, testimage.KindAlphaCutout} {
+		src, err := testimage.RGBA8(kind, w, h, 1, testimage.Options{})
+		if err != nil {
+			t.Fatalf("testimage %v: %v", kind, err)
+		}
+		for _, block := range []int{4, 6, 8, 12} {
+			for _, quality := range []astc.EncodeQuality{astc.EncodeFast, astc.EncodeMedium, astc.EncodeThorough} {
+				srgbGo, srgbNative := squaredErrors(src, block, astc.ProfileLDRSRGB, quality)
+				ldrGo, ldrNative := squaredErrors(src, block, astc.ProfileLDR, quality)
+				// The offset keeps near-lossless encodes from dominating the ratios.
+				srgbRatio := float64(srgbGo+64) / float64(srgbNative+64)
+				ldrRatio := float64(ldrGo+64) / float64(ldrNative+64)
+				if srgbRatio > ldrRatio*1.3 {
+					t.Fatalf("%v %dx%d %v: sRGB squared error %d vs astcenc %d, LDR %d vs astcenc %d", kind, block, block, quality, srgbGo, srgbNative, ldrGo, ldrNative)
+				}
+			}
+		}
+	}
+}
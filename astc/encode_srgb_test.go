@@ -0,0 +1,42 @@
+package astc
+
+import "testing"
+
+// TestEncoderReconstruction_MatchesDecode checks the encoder's texel reconstruction model against
+// upstream's decode equations (unpack_color_endpoints and lerp_color_int) for every pair of 8-bit
+// endpoints and every weight: sRGB endpoints expand to (v << 8) | 0x80 in all four channels, LDR
+// endpoints to v * 257, and both profiles keep the top 8 bits of the interpolated value in
+// decode_unorm8 mode, which ProfileLDRSRGB always uses.
+func TestEncoderReconstruction_MatchesDecode(t *testing.T) {
+	for _, profile := range []Profile{ProfileLDR, ProfileLDRSRGB} {
+		expand := &endpointExpandLDR
+		if profile == ProfileLDRSRGB {
+			expand = &endpointExpandSRGB
+		}
+		for u := 0; u < 256; u++ {
+			v := uint8(u)
+			_, _, ep0, ep1 := unpackColorEndpoints(profile, fmtRGBA, []uint8{v, v, v, v, v, v, v, v})
+			for c := 0; c < 4; c++ {
+				if ep0[c] != int((*expand)[u]) || ep1[c] != int((*expand)[u]) {
+					t.Fatalf("profile %v: endpoint %d channel %d expands to %d/%d in the decoder, %d in the encoder", profile, u, c, ep0[c], ep1[c], (*expand)[u])
+				}
+			}
+		}
+
+		for u0 := 0; u0 < 256; u0++ {
+			for u1 := 0; u1 < 256; u1++ {
+				e0, e1 := (*expand)[u0], (*expand)[u1]
+				d := e1 - e0
+				for w := int32(0); w <= 64; w++ {
+					// Encoder: the form used by the candidate error loops and the error kernel.
+					enc := u16ToU8ReplicatedI32(e0 + ((d*w + 32) >> 6))
+					// Upstream: lerp_color_int followed by the decode_unorm8 replication.
+					ref := (((e0*(64-w) + e1*w + 32) >> 6) >> 8) * 257
+					if enc != ref {
+						t.Fatalf("profile %v: endpoints %d, %d weight %d reconstruct to %d, want %d", profile, u0, u1, w, enc, ref)
+					}
+				}
+			}
+		}
+	}
+}
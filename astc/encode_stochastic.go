@@ -0,0 +1,128 @@
+package astc
+
+import "math"
+
+// Stochastic refinement: a simulated-annealing pass over the quantized endpoints and weights of
+// the best block found by the candidate search. It is only enabled for the exhaustive preset via
+// Config.TuneStochasticIterations, and is seeded from the block coordinates so output does not
+// depend on thread scheduling.
+
+// stochasticBlock is the mutable encoding state of a block being refined.
+type stochasticBlock struct {
+	mode            blockModeDesc
+	partitionCount  int
+	partitionIndex  int
+	plane2Component int
+	endpointFormat  uint8
+	colorQuant      quantMethod
+	endpointPquant  []uint8
+	weightPquant    []uint8
+}
+
+// stochasticBlockSeed derives the refinement seed of a block from its coordinates.
+func stochasticBlockSeed(bx, by, bz int) uint64 {
+	h := uint64(bx)*0x9E3779B97F4A7C15 ^ uint64(by)*0xC2B2AE3D27D4EB4F ^ uint64(bz)*0x165667B19E3779F9
+	return splitmix64(&h)
+}
+
+// splitmix64 advances state and returns the next value of the sequence.
+func splitmix64(state *uint64) uint64 {
+	*state += 0x9E3779B97F4A7C15
+	z := *state
+	z = (z ^ (z >> 30)) * 0xBF58476D1CE4E5B9
+	z = (z ^ (z >> 27)) * 0x94D049BB133111EB
+	return z ^ (z >> 31)
+}
+
+// stochasticRefineRGBA8 perturbs one endpoint or weight value by one quantization level per
+// iteration, accepting worse states with a probability that decays linearly to zero, and returns
+// the best block seen. The initial block is returned unless a strictly better one is found.
+func stochasticRefineRGBA8(profile Profile, blockX, blockY, blockZ int, texels []byte, channelWeight [4]float32, texelWeights []float32, iterations int, seed uint64, sb *stochasticBlock, initial [BlockBytes]byte) [BlockBytes]byte {
+	ctx := getDecodeContext(blockX, blockY, blockZ)
+	decoded := make([]byte, len(texels))
+	evaluate := func(block []byte) float64 {
+		decodeBlockToRGBA8(profile, ctx, block, decoded)
+		var err float64
+		for i := 0; i < len(texels); i += 4 {
+			w := 1.0
+			if texelWeights != nil {
+				w = float64(texelWeights[i/4])
+			}
+			for c := 0; c < 4; c++ {
+				d := float64(int(texels[i+c]) - int(decoded[i+c]))
+				err += w * float64(channelWeight[c]) * d * d
+			}
+		}
+		return err
+	}
+
+	best := initial
+	bestErr := evaluate(initial[:])
+	if bestErr == 0 {
+		return best
+	}
+	curErr := bestErr
+	temp0 := bestErr * 0.02
+
+	colorTable := colorScrambledPquantToUquantTables[int(sb.colorQuant)-int(quant6)]
+	weightTable := weightUnscrambleAndUnquantMap[sb.mode.weightQuant][:quantLevel(sb.mode.weightQuant)]
+
+	state := seed
+	for it := 0; it < iterations; it++ {
+		r := splitmix64(&state)
+		up := r&1 != 0
+
+		// Pick a value to perturb: endpoints and weights with equal probability.
+		var vals []uint8
+		var table []uint8
+		if (r>>1)&1 == 0 {
+			vals, table = sb.endpointPquant, colorTable
+		} else {
+			vals, table = sb.weightPquant, weightTable
+		}
+		idx := int((r >> 2) % uint64(len(vals)))
+		old := vals[idx]
+		next, ok := adjacentQuantLevel(table, old, up)
+		if !ok {
+			continue
+		}
+
+		vals[idx] = next
+		block, err := buildPhysicalBlock(sb.mode, blockX, blockY, blockZ, sb.partitionCount, sb.partitionIndex, sb.plane2Component, sb.endpointFormat, sb.colorQuant, sb.endpointPquant, sb.weightPquant)
+		if err != nil {
+			vals[idx] = old
+			continue
+		}
+		e := evaluate(block[:])
+
+		temp := temp0 * (1 - float64(it)/float64(iterations))
+		accept := e <= curErr
+		if !accept && temp > 0 {
+			u := float64(splitmix64(&state)>>11) / (1 << 53)
+			accept = u < math.Exp(-(e-curErr)/temp)
+		}
+		if !accept {
+			vals[idx] = old
+			continue
+		}
+		curErr = e
+		if e < bestErr {
+			best, bestErr = block, e
+		}
+	}
+	return best
+}
+
+// adjacentQuantLevel returns the scrambled value whose unquantized value is the next one above
+// (up) or below the unquantized value of v in table.
+func adjacentQuantLevel(table []uint8, v uint8, up bool) (uint8, bool) {
+	cur := int(table[v])
+	bestIdx, bestVal := -1, 0
+	for i, u := range table {
+		u := int(u)
+		if up && u > cur && (bestIdx < 0 || u < bestVal) || !up && u < cur && (bestIdx < 0 || u > bestVal) {
+			bestIdx, bestVal = i, u
+		}
+	}
+	return uint8(bestIdx), bestIdx >= 0
+}
@@ -0,0 +1,60 @@
+package astc
+
+import "testing"
+
+func TestEncodeBlockRGBA8LDR_StochasticRefinement(t *testing.T) {
+	const (
+		bx = 6
+		by = 6
+	)
+
+	texels := make([]byte, bx*by*4)
+	for y := 0; y < by; y++ {
+		for x := 0; x < bx; x++ {
+			off := (y*bx + x) * 4
+			copy(texels[off:], []byte{uint8(17 + x*37), uint8(200 - y*29), uint8(x*y*7 + 3), uint8(255 - x*11)})
+		}
+	}
+
+	ctx := getDecodeContext(bx, by, 1)
+	weights := [4]float32{1, 1, 1, 1}
+
+	encode := func(tune *encoderTuning) ([BlockBytes]byte, uint64) {
+		block, err := encodeBlockRGBA8LDR(ProfileLDR, bx, by, 1, texels, EncodeExhaustive, weights, 0, 1, tune)
+		if err != nil {
+			t.Fatalf("encodeBlockRGBA8LDR: %v", err)
+		}
+		decoded := make([]byte, len(texels))
+		decodeBlockToRGBA8(ProfileLDR, ctx, block[:], decoded)
+		return block, blockErrorRGBA8(texels, decoded)
+	}
+
+	tune := encoderTuningFor(EncodeExhaustive, bx*by)
+	_, baseErr := encode(&tune)
+
+	tune.stochasticIterations = 256
+	tune.stochasticSeed = stochasticBlockSeed(3, 5, 0)
+	block, err := encode(&tune)
+	if err > baseErr {
+		t.Fatalf("stochastic refinement increased error: got %d want <= %d", err, baseErr)
+	}
+	again, _ := encode(&tune)
+	if again != block {
+		t.Fatalf("stochastic refinement is not deterministic for a fixed seed")
+	}
+}
+
+func TestStochasticBlockSeed_Distinct(t *testing.T) {
+	seen := map[uint64]bool{}
+	for z := 0; z < 4; z++ {
+		for y := 0; y < 16; y++ {
+			for x := 0; x < 16; x++ {
+				s := stochasticBlockSeed(x, y, z)
+				if seen[s] {
+					t.Fatalf("duplicate seed for block (%d,%d,%d)", x, y, z)
+				}
+				seen[s] = true
+			}
+		}
+	}
+}
@@ -6,13 +6,125 @@ type encoderTuning struct {
 	partitionIndexLimit           [blockMaxPartitions + 1]int
 	partitionCandidateLimit       [blockMaxPartitions + 1]int
 	dualPlaneCorrelationThreshold float32
+
+	// endpointTrim is the fraction of texels trimmed from each end of a partition's luma range
+	// when trying an additional percentile-based endpoint seed. Zero disables the extra seed.
+	endpointTrim float32
+
+	// extendedEndpointFormats enables the reduced-channel (RGB, L, LA) and base+offset delta LDR
+	// endpoint formats in addition to direct RGBA.
+	extendedEndpointFormats bool
+
+	// rgbScaleEndpoints stores opaque blocks whose colors are scaled copies of one color (see
+	// blockFitsRGBScale) with fmtRGBScale endpoints, four color integers per partition.
+	rgbScaleEndpoints bool
+
+	// constantPartitions detects partitions whose texels are all identical. Their endpoints encode
+	// the color exactly for any weight, so their texels are excluded from fitting the shared
+	// decimated weight grid.
+	constantPartitions bool
+
+	// stochasticIterations is the number of simulated-annealing steps applied to the best block,
+	// seeded with stochasticSeed. Zero disables the stage.
+	stochasticIterations int
+	stochasticSeed       uint64
+
+	// endpointRefinePasses bounds the greedy sweeps of refineEndpointsRGBA8 over the endpoints of
+	// the best block. Zero disables the stage.
+	endpointRefinePasses int
+
+	// Quantization bounds from Config, as level counts; zero leaves a bound open.
+	colorQuantMin, colorQuantMax   int
+	weightQuantMin, weightQuantMax int
+
+	// disallowedModes, if set, lists block modes the encoder must not emit, including every mode
+	// outside a non-empty Config.ForcedBlockModes.
+	disallowedModes *BlockModeMask
+
+	// noDualPlane forbids dual-plane block modes, and hardMaxPartitionCount, if nonzero, caps
+	// maxPartitionCount, for Config.DisableDualPlane and Config.MaxPartitionCountHard.
+	noDualPlane           bool
+	hardMaxPartitionCount int
+
+	// partitionSeeds are the partitionings chosen by the block's neighbors, packed by
+	// packPartitionSeed; zero entries are unused.
+	partitionSeeds [2]uint16
+
+	// lumaSplit seeds the 2-partition search of blocks with uniform alpha with the index found by
+	// lumaSplitPartition, which narrows a Config tuned search like a neighbor seed does.
+	lumaSplit bool
+
+	// periodicModes is the number of block modes with full-resolution weight grids added to the
+	// limited mode list of blocks whose texels repeat with period 2 or 4 (see periodicBlockModes).
+	periodicModes int
+
+	// areaWeightSampling derives decimated weight grids from the ideal weights of every texel each
+	// point is interpolated into rather than from the nearest texel (see areaSampleWeights).
+	areaWeightSampling bool
+
+	// texelWeights, if not nil, multiplies the error of each texel of the block (see
+	// Image.Importance), so the search favors the fidelity of the heavier texels.
+	texelWeights []float32
 }
 
+// addPartitionSeed puts seed first in partitionSeeds, keeping the first other seed.
+func (t *encoderTuning) addPartitionSeed(seed uint16) {
+	if t.partitionSeeds[0] != seed {
+		t.partitionSeeds[1] = t.partitionSeeds[0]
+		t.partitionSeeds[0] = seed
+	}
+}
+
+// hasPartitionSeed reports whether a neighbor seed has partitionCount partitions.
+func (t *encoderTuning) hasPartitionSeed(partitionCount int) bool {
+	for _, s := range t.partitionSeeds {
+		if s != 0 && int(s>>partitionIndexBits) == partitionCount {
+			return true
+		}
+	}
+	return false
+}
+
+// colorQuantAllowed reports whether a candidate encoding with color quantization q is within the
+// configured bounds.
+func (t *encoderTuning) colorQuantAllowed(q quantMethod) bool {
+	return quantInBounds(quantLevel(q), t.colorQuantMin, t.colorQuantMax)
+}
+
+func quantInBounds(levels, lo, hi int) bool {
+	return levels >= lo && (hi == 0 || levels <= hi)
+}
+
+// validQuantBounds reports whether lo and hi are zero or level counts of quantization methods in
+// first..last, with lo <= hi when both are set.
+func validQuantBounds(lo, hi uint32, first, last quantMethod) bool {
+	valid := func(levels uint32) bool {
+		if levels == 0 {
+			return true
+		}
+		for q := first; q <= last; q++ {
+			if quantLevel(q) == int(levels) {
+				return true
+			}
+		}
+		return false
+	}
+	return valid(lo) && valid(hi) && (hi == 0 || lo <= hi)
+}
+
+// endpointRefinePasses is the sweep bound of endpoint refinement at EncodeMedium and above; a
+// second sweep still finds a few moves, a third almost none.
+const endpointRefinePasses = 2
+
 func encoderTuningFromConfig(cfg Config) encoderTuning {
 	t := encoderTuning{
 		modeLimit:                     int(cfg.TuneBlockModeLimit),
 		maxPartitionCount:             int(cfg.TunePartitionCountLimit),
 		dualPlaneCorrelationThreshold: cfg.Tune2PlaneEarlyOutLimitCorrelation,
+		colorQuantMin:                 int(cfg.TuneColorQuantMin),
+		colorQuantMax:                 int(cfg.TuneColorQuantMax),
+		weightQuantMin:                int(cfg.TuneWeightQuantMin),
+		weightQuantMax:                int(cfg.TuneWeightQuantMax),
 	}
 	t.partitionIndexLimit[2] = int(cfg.Tune2PartitionIndexLimit)
 	t.partitionIndexLimit[3] = int(cfg.Tune3PartitionIndexLimit)
@@ -20,6 +132,34 @@ func encoderTuningFromConfig(cfg Config) encoderTuning {
 	t.partitionCandidateLimit[2] = int(cfg.Tune2PartitioningCandidateLimit)
 	t.partitionCandidateLimit[3] = int(cfg.Tune3PartitioningCandidateLimit)
 	t.partitionCandidateLimit[4] = int(cfg.Tune4PartitioningCandidateLimit)
+	if encodeQualityFromConfig(cfg) == EncodeExhaustive {
+		t.stochasticIterations = int(cfg.TuneStochasticIterations)
+	}
+	t.lumaSplit = encodeQualityFromConfig(cfg) == EncodeMedium
+	if encodeQualityFromConfig(cfg) >= EncodeThorough {
+		t.periodicModes = periodicModeCount
+		t.rgbScaleEndpoints = true
+	}
+	if encodeQualityFromConfig(cfg) >= EncodeMedium {
+		t.endpointRefinePasses = endpointRefinePasses
+	}
+	switch cfg.WeightSampling {
+	case WeightSamplingAuto:
+		t.areaWeightSampling = encodeQualityFromConfig(cfg) >= EncodeThorough
+	case WeightSamplingArea:
+		t.areaWeightSampling = true
+	}
+	if !cfg.ForcedBlockModes.IsZero() {
+		disallowed := cfg.DisallowedBlockModes.Union(cfg.ForcedBlockModes.complement())
+		t.disallowedModes = &disallowed
+	} else if !cfg.DisallowedBlockModes.IsZero() {
+		t.disallowedModes = &cfg.DisallowedBlockModes
+	}
+	t.noDualPlane = cfg.DisableDualPlane
+	t.hardMaxPartitionCount = int(cfg.MaxPartitionCountHard)
+	if t.hardMaxPartitionCount != 0 && t.maxPartitionCount > t.hardMaxPartitionCount {
+		t.maxPartitionCount = t.hardMaxPartitionCount
+	}
 	return t
 }
 
@@ -43,6 +183,7 @@ func encoderTuningFor(quality EncodeQuality, texelCount int) encoderTuning {
 		}
 		t.partitionIndexLimit[2] = 64
 		t.partitionCandidateLimit[2] = 2
+		t.endpointRefinePasses = endpointRefinePasses
 		return t
 	}
 
@@ -65,6 +206,13 @@ func encoderTuningFor(quality EncodeQuality, texelCount int) encoderTuning {
 		t.partitionCandidateLimit[3] = 2
 		t.partitionIndexLimit[4] = 30
 		t.partitionCandidateLimit[4] = 2
+		t.endpointTrim = 0.05
+		t.extendedEndpointFormats = true
+		t.rgbScaleEndpoints = true
+		t.constantPartitions = true
+		t.periodicModes = periodicModeCount
+		t.areaWeightSampling = true
+		t.endpointRefinePasses = endpointRefinePasses
 		if highBandwidth {
 			t.dualPlaneCorrelationThreshold = 0.97
 		} else if midBandwidth {
@@ -92,6 +240,13 @@ func encoderTuningFor(quality EncodeQuality, texelCount int) encoderTuning {
 			t.partitionCandidateLimit[3] = 6
 			t.partitionCandidateLimit[4] = 4
 		}
+		t.endpointTrim = 0.05
+		t.extendedEndpointFormats = true
+		t.rgbScaleEndpoints = true
+		t.constantPartitions = true
+		t.periodicModes = periodicModeCount
+		t.areaWeightSampling = true
+		t.endpointRefinePasses = endpointRefinePasses
 		if highBandwidth {
 			t.dualPlaneCorrelationThreshold = 0.98
 		} else if midBandwidth {
@@ -118,6 +273,13 @@ func encoderTuningFor(quality EncodeQuality, texelCount int) encoderTuning {
 		t.partitionCandidateLimit[2] = 8
 		t.partitionCandidateLimit[3] = 8
 		t.partitionCandidateLimit[4] = 8
+		t.endpointTrim = 0.05
+		t.extendedEndpointFormats = true
+		t.rgbScaleEndpoints = true
+		t.constantPartitions = true
+		t.periodicModes = periodicModeCount
+		t.areaWeightSampling = true
+		t.endpointRefinePasses = endpointRefinePasses
 		if highBandwidth {
 			t.dualPlaneCorrelationThreshold = 0.99
 		} else if midBandwidth {
@@ -0,0 +1,136 @@
+package astc_test
+
+import (
+	"testing"
+
+	"github.com/arm-software/astc-encoder/astc"
+)
+
+func TestContext_CompressImage_QuantBounds(t *testing.T) {
+	const w, h = 16, 16
+	pix := make([]byte, w*h*4)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			copy(pix[(y*w+x)*4:], []byte{uint8(x * 16), uint8(y * 16), uint8((x ^ y) * 16), uint8(255 - x*8)})
+		}
+	}
+	img := astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeU8, DataU8: pix}
+
+	// compress returns the infos of the non-constant blocks.
+	compress := func(set func(*astc.Config)) []astc.BlockInfo {
+		t.Helper()
+		_, all := compressBlockInfos(t, &img, astc.ProfileLDR, 60, set, nil)
+		var infos []astc.BlockInfo
+		for _, info := range all {
+			if !info.IsConstantBlock {
+				infos = append(infos, info)
+			}
+		}
+		if len(infos) == 0 {
+			t.Fatalf("expected non-constant blocks")
+		}
+		return infos
+	}
+
+	for _, info := range compress(func(c *astc.Config) { c.TuneWeightQuantMin = 12 }) {
+		if info.WeightLevelCount < 12 {
+			t.Fatalf("weight min 12: got %d weight levels", info.WeightLevelCount)
+		}
+	}
+	for _, info := range compress(func(c *astc.Config) { c.TuneWeightQuantMin, c.TuneWeightQuantMax = 4, 4 }) {
+		if info.WeightLevelCount != 4 {
+			t.Fatalf("weight forced to 4: got %d weight levels", info.WeightLevelCount)
+		}
+	}
+	for _, info := range compress(func(c *astc.Config) { c.TuneColorQuantMax = 32 }) {
+		if info.ColorLevelCount > 32 {
+			t.Fatalf("color max 32: got %d color levels", info.ColorLevelCount)
+		}
+	}
+
+	for _, bad := range []func(*astc.Config){
+		func(c *astc.Config) { c.TuneWeightQuantMin = 7 },
+		func(c *astc.Config) { c.TuneWeightQuantMax = 64 },
+		func(c *astc.Config) { c.TuneColorQuantMax = 4 },
+		func(c *astc.Config) { c.TuneColorQuantMin, c.TuneColorQuantMax = 64, 32 },
+	} {
+		cfg, err := astc.ConfigInit(astc.ProfileLDR, 4, 4, 1, 60, 0)
+		if err != nil {
+			t.Fatalf("ConfigInit: %v", err)
+		}
+		bad(&cfg)
+		if _, err := astc.ContextAlloc(&cfg, 1); astc.ErrorCodeOf(err) != astc.ErrBadParam {
+			t.Fatalf("expected ErrBadParam for %+v, got %v", cfg, err)
+		}
+	}
+}
+
+func TestContext_CompressImage_HardFeatureLimits(t *testing.T) {
+	const w, h = 32, 32
+	pix := make([]byte, w*h*4)
+	f32 := make([]float32, w*h*4)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			// Alpha varying independently of color favors dual plane on the left, hard edges favor
+			// partitioning on the right.
+			texel := []byte{uint8(x * 16), uint8(x * 8), uint8(255 - x*16), uint8(y*16 + x%3)}
+			if x >= w/2 {
+				v := uint8(x * 8)
+				if (x+2*y)/11%2 == 0 {
+					v = 255 - v/2
+				}
+				texel = []byte{v, uint8(y * 8), 255 - v, 255}
+			}
+			copy(pix[(y*w+x)*4:], texel)
+			for ch, c := range texel {
+				f32[(y*w+x)*4+ch] = float32(c) / 64
+			}
+		}
+	}
+
+	for _, tc := range []struct {
+		profile astc.Profile
+		img     astc.Image
+	}{
+		{astc.ProfileLDR, astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeU8, DataU8: pix}},
+		{astc.ProfileHDR, astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeF32, DataF32: f32}},
+	} {
+		compress := func(restrict bool, hint []byte) (dualPlane bool, maxPartitions uint32, out []byte) {
+			t.Helper()
+			var set func(*astc.Config)
+			if restrict {
+				set = func(c *astc.Config) { c.DisableDualPlane, c.MaxPartitionCountHard = true, 1 }
+			}
+			out, infos := compressBlockInfos(t, &tc.img, tc.profile, 98, set, hint)
+			for _, info := range infos {
+				dualPlane = dualPlane || info.IsDualPlaneBlock
+				maxPartitions = max(maxPartitions, info.PartitionCount)
+			}
+			return dualPlane, maxPartitions, out
+		}
+
+		dualPlane, partitions, plain := compress(false, nil)
+		if !dualPlane || partitions < 2 {
+			t.Fatalf("%v: test image does not exercise the limits (dual plane %v, %d partitions)", tc.profile, dualPlane, partitions)
+		}
+		if dualPlane, partitions, _ := compress(true, nil); dualPlane || partitions > 1 {
+			t.Fatalf("%v: restricted encode has dual plane %v, %d partitions", tc.profile, dualPlane, partitions)
+		}
+		if tc.profile != astc.ProfileLDR {
+			continue
+		}
+		// Hint blocks violating the limits are not reused.
+		if dualPlane, partitions, _ := compress(true, plain); dualPlane || partitions > 1 {
+			t.Fatalf("%v: restricted hinted encode has dual plane %v, %d partitions", tc.profile, dualPlane, partitions)
+		}
+	}
+
+	cfg, err := astc.ConfigInit(astc.ProfileLDR, 4, 4, 1, 60, 0)
+	if err != nil {
+		t.Fatalf("ConfigInit: %v", err)
+	}
+	cfg.MaxPartitionCountHard = 5
+	if _, err := astc.ContextAlloc(&cfg, 1); astc.ErrorCodeOf(err) != astc.ErrBadParam {
+		t.Fatalf("MaxPartitionCountHard 5: got %v, want ErrBadParam", err)
+	}
+}
@@ -0,0 +1,149 @@
+package astc
+
+import "math"
+
+// varianceReference is the luma standard deviation, in 8-bit units, at which a block's variance
+// scale is halved (with VariancePower 1). Noise of this amplitude masks most block artifacts.
+const varianceReference = 8
+
+// blockLumaStdDev returns the standard deviation of the source luma (R+2G+B)/4, in 8-bit units,
+// over the block at (x0, y0, z0) extended by ext texels in x and y and clipped to the image.
+func blockLumaStdDev(img *Image, inType DataType, x0, y0, z0, blockX, blockY, blockZ, ext int) float64 {
+	xs, xe := max(x0-ext, 0), min(x0+blockX+ext, img.DimX)
+	ys, ye := max(y0-ext, 0), min(y0+blockY+ext, img.DimY)
+	zs, ze := z0, min(z0+blockZ, img.DimZ)
+
+	var sum, sumSq float64
+	n := 0
+	for z := zs; z < ze; z++ {
+		for y := ys; y < ye; y++ {
+			row := (z*img.DimY + y) * img.DimX
+			for x := xs; x < xe; x++ {
+				l, ok := texelLuma(img, inType, row+x)
+				if !ok {
+					continue
+				}
+				sum += l
+				sumSq += l * l
+				n++
+			}
+		}
+	}
+	if n == 0 {
+		return 0
+	}
+	mean := sum / float64(n)
+	return math.Sqrt(max(sumSq/float64(n)-mean*mean, 0))
+}
+
+// texelLuma returns the source luma (R+2G+B)/4 of texel i in 8-bit units, or false if it is not
+// finite.
+func texelLuma(img *Image, inType DataType, i int) (float64, bool) {
+	off := i * 4
+	var l float64
+	switch inType {
+	case TypeU8:
+		r, g, b, _ := img.Layout.loadRGBA8(img.DataU8, i)
+		return float64(int(r)+2*int(g)+int(b)) * 0.25, true
+	case TypeF16:
+		p := img.DataF16[off : off+3 : off+3]
+		l = float64(HalfToFloat32(p[0])+2*HalfToFloat32(p[1])+HalfToFloat32(p[2])) * (255.0 / 4)
+	default:
+		p := img.DataF32[off : off+3 : off+3]
+		l = float64(p[0]+2*p[1]+p[2]) * (255.0 / 4)
+	}
+	return l, !math.IsNaN(l) && !math.IsInf(l, 0)
+}
+
+// computeInputVarianceWeights returns the variance error weight of each texel of img: the
+// variance scale of the luma standard deviation over the (2*radius-1)^2 texels centred on it,
+// clipped to the image, in its own z slice. Summed-area tables keep the cost independent of the
+// radius.
+func computeInputVarianceWeights(img *Image, inType DataType, radius int, power float32) []float32 {
+	w, h, d := img.DimX, img.DimY, img.DimZ
+	ext := radius - 1
+	weights := make([]float32, w*h*d)
+	// Tables of the luma sum, squared luma sum and finite texel count over [0, x) x [0, y).
+	stride := w + 1
+	sum := make([]float64, stride*(h+1))
+	sumSq := make([]float64, stride*(h+1))
+	count := make([]int32, stride*(h+1))
+	for z := 0; z < d; z++ {
+		for y := 0; y < h; y++ {
+			var rs, rsq float64
+			var rn int32
+			for x := 0; x < w; x++ {
+				if l, ok := texelLuma(img, inType, (z*h+y)*w+x); ok {
+					rs += l
+					rsq += l * l
+					rn++
+				}
+				i := (y+1)*stride + x + 1
+				sum[i] = sum[i-stride] + rs
+				sumSq[i] = sumSq[i-stride] + rsq
+				count[i] = count[i-stride] + rn
+			}
+		}
+		for y := 0; y < h; y++ {
+			ys, ye := max(y-ext, 0)*stride, min(y+ext+1, h)*stride
+			for x := 0; x < w; x++ {
+				xs, xe := max(x-ext, 0), min(x+ext+1, w)
+				n := count[ye+xe] - count[ye+xs] - count[ys+xe] + count[ys+xs]
+				sd := 0.0
+				if n != 0 {
+					mean := (sum[ye+xe] - sum[ye+xs] - sum[ys+xe] + sum[ys+xs]) / float64(n)
+					sq := (sumSq[ye+xe] - sumSq[ye+xs] - sumSq[ys+xe] + sumSq[ys+xs]) / float64(n)
+					sd = math.Sqrt(max(sq-mean*mean, 0))
+				}
+				weights[(z*h+y)*w+x] = varianceScale(sd, power)
+			}
+		}
+	}
+	return weights
+}
+
+// varianceTexelWeights multiplies w by the weights (from computeInputVarianceWeights) of the
+// texels of the block at (x0, y0, z0). Texels outside the image take the weight of the nearest
+// texel inside it, as they take its color.
+func varianceTexelWeights(img *Image, weights []float32, x0, y0, z0, blockX, blockY, blockZ int, w []float32) {
+	t := 0
+	for z := z0; z < z0+blockZ; z++ {
+		zc := min(z, img.DimZ-1)
+		for y := y0; y < y0+blockY; y++ {
+			row := weights[(zc*img.DimY+min(y, img.DimY-1))*img.DimX:]
+			for x := x0; x < x0+blockX; x++ {
+				w[t] *= row[min(x, img.DimX-1)]
+				t++
+			}
+		}
+	}
+}
+
+// varianceScale returns the variance scale of a block or texel neighborhood with luma standard
+// deviation sd: 1 where flat, falling towards 0 as it gets noisier.
+func varianceScale(sd float64, power float32) float32 {
+	return float32(math.Pow(varianceReference/(varianceReference+sd), float64(power)))
+}
+
+// withVarianceEffort returns t with its block mode and partition search limits scaled for a block
+// with variance scale s: by up to 1.5 for flat blocks and down to 0.5 for noisy ones.
+func (t encoderTuning) withVarianceEffort(s float32) encoderTuning {
+	return t.withEffortScale(0.5 + s)
+}
+
+// withEffortScale returns t with its block mode and partition search limits scaled by factor
+// (rounded, at least 1). Limits that are zero (unlimited or disabled) are kept.
+func (t encoderTuning) withEffortScale(factor float32) encoderTuning {
+	scale := func(v int) int {
+		if v <= 0 {
+			return v
+		}
+		return max(int(float32(v)*factor+0.5), 1)
+	}
+	t.modeLimit = scale(t.modeLimit)
+	for p := 2; p <= blockMaxPartitions; p++ {
+		t.partitionIndexLimit[p] = scale(t.partitionIndexLimit[p])
+		t.partitionCandidateLimit[p] = scale(t.partitionCandidateLimit[p])
+	}
+	return t
+}
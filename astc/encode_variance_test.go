@@ -0,0 +1,150 @@
+package astc
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBlockLumaStdDev(t *testing.T) {
+	const w, h = 8, 4
+	rgba := make([]byte, w*h*4)
+	rgb := make([]byte, w*h*3)
+	f32 := make([]float32, w*h*4)
+	for i := 0; i < w*h; i++ {
+		// Left half constant, right half alternating black and white columns.
+		v := uint8(100)
+		if x := i % w; x >= 4 {
+			v = uint8(255 * (x & 1))
+		}
+		copy(rgba[i*4:], []byte{v, v, v, 255})
+		copy(rgb[i*3:], []byte{v, v, v})
+		for c := 0; c < 3; c++ {
+			f32[i*4+c] = float32(v) / 255
+		}
+		f32[i*4+3] = 1
+	}
+
+	for _, tc := range []struct {
+		name   string
+		img    Image
+		inType DataType
+	}{
+		{"RGBA", Image{DimX: w, DimY: h, DimZ: 1, DataU8: rgba}, TypeU8},
+		{"RGB", Image{DimX: w, DimY: h, DimZ: 1, Layout: LayoutRGB, DataU8: rgb}, TypeU8},
+		{"F32", Image{DimX: w, DimY: h, DimZ: 1, DataType: TypeF32, DataF32: f32}, TypeF32},
+	} {
+		if sd := blockLumaStdDev(&tc.img, tc.inType, 0, 0, 0, 4, 4, 1, 0); sd > 1e-3 {
+			t.Fatalf("%s: flat block sd = %v, want 0", tc.name, sd)
+		}
+		if sd := blockLumaStdDev(&tc.img, tc.inType, 4, 0, 0, 4, 4, 1, 0); math.Abs(sd-127.5) > 1e-3 {
+			t.Fatalf("%s: striped block sd = %v, want 127.5", tc.name, sd)
+		}
+		// Extending the flat block by one texel reaches a white column.
+		if sd := blockLumaStdDev(&tc.img, tc.inType, 0, 0, 0, 4, 4, 1, 1); sd < 1 {
+			t.Fatalf("%s: extended flat block sd = %v, want > 1", tc.name, sd)
+		}
+	}
+}
+
+func TestVarianceScaleAndEffort(t *testing.T) {
+	if s := varianceScale(0, 1); s != 1 {
+		t.Fatalf("varianceScale(0) = %v, want 1", s)
+	}
+	if s := varianceScale(varianceReference, 1); math.Abs(float64(s)-0.5) > 1e-6 {
+		t.Fatalf("varianceScale(reference) = %v, want 0.5", s)
+	}
+	if a, b := varianceScale(20, 1), varianceScale(20, 2); !(b < a && a < 1) {
+		t.Fatalf("varianceScale(20): power 1 = %v, power 2 = %v", a, b)
+	}
+
+	base := encoderTuningFor(EncodeThorough, 16)
+	flat := base.withVarianceEffort(1)
+	noisy := base.withVarianceEffort(0)
+	if flat.modeLimit != 96 || noisy.modeLimit != 32 {
+		t.Fatalf("mode limits: flat %d, noisy %d; want 96, 32", flat.modeLimit, noisy.modeLimit)
+	}
+	if flat.partitionCandidateLimit[3] != 3 || noisy.partitionCandidateLimit[3] != 1 {
+		t.Fatalf("3-partition candidates: flat %d, noisy %d; want 3, 1", flat.partitionCandidateLimit[3], noisy.partitionCandidateLimit[3])
+	}
+	if got := encoderTuningFor(EncodeFastest, 16).withVarianceEffort(0); got.partitionIndexLimit[2] != 0 || got.modeLimit != 1 {
+		t.Fatalf("fastest: got %+v", got)
+	}
+}
+
+func TestComputeInputVarianceWeights(t *testing.T) {
+	const w, h, d = 9, 7, 2
+	pix := make([]byte, w*h*d*4)
+	for i := range pix {
+		pix[i] = uint8(i*37 + i/13*11)
+	}
+	img := Image{DimX: w, DimY: h, DimZ: d, DataU8: pix}
+	for _, radius := range []int{1, 2, 4} {
+		weights := computeInputVarianceWeights(&img, TypeU8, radius, 1.5)
+		for z := 0; z < d; z++ {
+			for y := 0; y < h; y++ {
+				for x := 0; x < w; x++ {
+					// The window centred on the texel is a one-texel block extended by radius-1.
+					want := varianceScale(blockLumaStdDev(&img, TypeU8, x, y, z, 1, 1, 1, radius-1), 1.5)
+					if got := weights[(z*h+y)*w+x]; math.Abs(float64(got-want)) > 1e-5 {
+						t.Fatalf("radius %d texel (%d, %d, %d): weight %v, want %v", radius, x, y, z, got, want)
+					}
+				}
+			}
+		}
+	}
+}
+
+// TestVarianceWeights_FavorFlatTexels checks that blocks straddling the edge between a gradient
+// and noise spend their precision on the gradient.
+func TestVarianceWeights_FavorFlatTexels(t *testing.T) {
+	const w, h, edge = 64, 32, 29
+	pix := make([]byte, w*h*4)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			v := uint8(60 + x + 2*y)
+			if x >= edge {
+				v = uint8((x*97 + y*57) * 31)
+			}
+			copy(pix[(y*w+x)*4:], []byte{v, 255 - v, v / 2, 255})
+		}
+	}
+
+	// flatError returns the squared error of the gradient texels in the blocks straddling the edge.
+	flatError := func(radius uint32) float64 {
+		t.Helper()
+		cfg, err := ConfigInit(ProfileLDR, 8, 8, 1, 60, 0)
+		if err != nil {
+			t.Fatalf("ConfigInit: %v", err)
+		}
+		cfg.VarianceRadius = radius
+		ctx, err := ContextAlloc(&cfg, 1)
+		if err != nil {
+			t.Fatalf("ContextAlloc: %v", err)
+		}
+		blocks := make([]byte, w/8*h/8*BlockBytes)
+		img := Image{DimX: w, DimY: h, DimZ: 1, DataU8: pix}
+		if err := ctx.CompressImage(&img, SwizzleRGBA, blocks, 0); err != nil {
+			t.Fatalf("CompressImage: %v", err)
+		}
+		got := make([]byte, len(pix))
+		out := Image{DimX: w, DimY: h, DimZ: 1, DataU8: got}
+		if err := ctx.DecompressImage(blocks, &out, SwizzleRGBA, 0); err != nil {
+			t.Fatalf("DecompressImage: %v", err)
+		}
+		var sum float64
+		for y := 0; y < h; y++ {
+			for x := edge / 8 * 8; x < edge; x++ {
+				for c := 0; c < 3; c++ {
+					d := float64(got[(y*w+x)*4+c]) - float64(pix[(y*w+x)*4+c])
+					sum += d * d
+				}
+			}
+		}
+		return sum
+	}
+
+	plain, weighted := flatError(0), flatError(3)
+	if weighted >= plain*0.5 {
+		t.Fatalf("gradient texels beside noise: squared error %.0f with variance weights, want below half of %.0f", weighted, plain)
+	}
+}
@@ -19,6 +19,8 @@ func EncodeRGBA8Volume(pix []byte, width, height, depth int, blockX, blockY, blo
 // `((z*height+y)*width + x) * 4`.
 //
 // Note: ASTC files do not store a profile. The profile controls encoder optimization behavior.
+//
+// Deprecated: Use NewEncoder with WithBlockSize3D, WithProfile and WithQuality, and Encoder.Encode.
 func EncodeRGBA8VolumeWithProfileAndQuality(pix []byte, width, height, depth int, blockX, blockY, blockZ int, profile Profile, quality EncodeQuality) ([]byte, error) {
 	if width <= 0 || height <= 0 || depth <= 0 {
 		return nil, errors.New("astc: invalid image dimensions")
@@ -163,3 +165,66 @@ func extractBlockRGBA8Volume(pix []byte, width, height, depth, x0, y0, z0, block
 		}
 	}
 }
+
+// extractBlockRGBA8VolumeLayout is extractBlockRGBA8Volume for pixel data in any PixelLayout,
+// converting texels to RGBA as they are copied.
+func extractBlockRGBA8VolumeLayout(pix []byte, layout PixelLayout, width, height, depth, x0, y0, z0, blockX, blockY, blockZ int, dst []byte) {
+	if layout == LayoutRGBA {
+		extractBlockRGBA8Volume(pix, width, height, depth, x0, y0, z0, blockX, blockY, blockZ, dst)
+		return
+	}
+
+	for bz := 0; bz < blockZ; bz++ {
+		z := min(z0+bz, depth-1)
+		for by := 0; by < blockY; by++ {
+			y := min(y0+by, height-1)
+			rowBase := (z*height + y) * width
+			for bx := 0; bx < blockX; bx++ {
+				x := min(x0+bx, width-1)
+				dstOff := ((bz*blockY+by)*blockX + bx) * 4
+				dst[dstOff+0], dst[dstOff+1], dst[dstOff+2], dst[dstOff+3] = layout.loadRGBA8(pix, rowBase+x)
+			}
+		}
+	}
+}
+
+// extractBlockRGBA8Layout is extractBlockRGBA8 for pixel data in any PixelLayout.
+func extractBlockRGBA8Layout(pix []byte, layout PixelLayout, width, height, x0, y0, blockX, blockY int, dst []byte) {
+	if layout == LayoutRGBA {
+		extractBlockRGBA8(pix, width, height, x0, y0, blockX, blockY, dst)
+		return
+	}
+	extractBlockRGBA8VolumeLayout(pix, layout, width, height, 1, x0, y0, 0, blockX, blockY, 1, dst)
+}
+
+func (l PixelLayout) bytesPerTexel() int {
+	switch l {
+	case LayoutRGB:
+		return 3
+	case LayoutLA:
+		return 2
+	default:
+		return 4
+	}
+}
+
+// loadRGBA8 returns texel i of pix in RGBA order.
+func (l PixelLayout) loadRGBA8(pix []byte, i int) (r, g, b, a uint8) {
+	switch l {
+	case LayoutBGRA:
+		off := i * 4
+		return pix[off+2], pix[off+1], pix[off+0], pix[off+3]
+	case LayoutRGBX:
+		off := i * 4
+		return pix[off+0], pix[off+1], pix[off+2], 255
+	case LayoutRGB:
+		off := i * 3
+		return pix[off+0], pix[off+1], pix[off+2], 255
+	case LayoutLA:
+		off := i * 2
+		return pix[off+0], pix[off+0], pix[off+0], pix[off+1]
+	default:
+		off := i * 4
+		return pix[off+0], pix[off+1], pix[off+2], pix[off+3]
+	}
+}
@@ -0,0 +1,158 @@
+package astc
+
+import "sync"
+
+// WeightSampling selects how the LDR encoder derives a decimated weight grid, one with fewer
+// points than the block has texels, from the ideal weight of each texel (Config.WeightSampling).
+type WeightSampling uint8
+
+const (
+	// WeightSamplingAuto, the zero value, uses WeightSamplingArea at EncodeThorough and above
+	// and WeightSamplingNearest below.
+	WeightSamplingAuto WeightSampling = iota
+	// WeightSamplingNearest gives each grid point the ideal weight of the texel nearest to it.
+	// It is the cheapest policy, but on grids whose spacing does not divide the footprint evenly,
+	// like 6x5 in a 6x6 block, the nearest texels lie to one side of most points and every weight
+	// is biased the same way.
+	WeightSamplingNearest
+	// WeightSamplingArea averages the ideal weights of every texel a grid point is interpolated
+	// into, weighted by its share of the texel, and refines the result with one least-squares
+	// step against the interpolated weights. On the test corpus it gains 0.4-0.85 dB at thorough
+	// over nearest sampling, at 1.7-2x the encoding time.
+	WeightSamplingArea
+
+	weightSamplingLast = WeightSamplingArea
+)
+
+// areaSampleTable lists the nonzero infill contributions of a decimated weight grid by texel, for
+// areaSampleWeights.
+type areaSampleTable struct {
+	taps []areaTap
+	// first[t] is the index of the first tap of texel t; first[texelCount] is len(taps).
+	first []uint16
+	// cnt and sq are the sums of the infill weights of each point and of their squares.
+	cnt, sq [blockMaxWeights]int32
+}
+
+type areaTap struct {
+	texel, point, w uint8
+}
+
+var areaSampleTables struct {
+	mu sync.RWMutex
+	m  map[decimationKey]*areaSampleTable
+}
+
+// getAreaSampleTable returns the areaSampleTable of a decimation table returned by
+// getDecimationTable with the same arguments.
+func getAreaSampleTable(dec []decimationEntry, blockX, blockY, blockZ, xWeights, yWeights, zWeights int) *areaSampleTable {
+	key := decimationKey{
+		bx: uint8(blockX), by: uint8(blockY), bz: uint8(blockZ),
+		wx: uint8(xWeights), wy: uint8(yWeights), wz: uint8(zWeights),
+	}
+	areaSampleTables.mu.RLock()
+	tab, ok := areaSampleTables.m[key]
+	areaSampleTables.mu.RUnlock()
+	if ok {
+		return tab
+	}
+
+	tab = &areaSampleTable{first: make([]uint16, len(dec)+1)}
+	for t := range dec {
+		tab.first[t] = uint16(len(tab.taps))
+		e := &dec[t]
+		for k := 0; k < 4; k++ {
+			if w := int32(e.w[k]); w != 0 {
+				tab.taps = append(tab.taps, areaTap{texel: uint8(t), point: e.idx[k], w: e.w[k]})
+				tab.cnt[e.idx[k]] += w
+				tab.sq[e.idx[k]] += w * w
+			}
+		}
+	}
+	tab.first[len(dec)] = uint16(len(tab.taps))
+
+	areaSampleTables.mu.Lock()
+	defer areaSampleTables.mu.Unlock()
+	if got, ok := areaSampleTables.m[key]; ok {
+		return got
+	}
+	if areaSampleTables.m == nil {
+		areaSampleTables.m = make(map[decimationKey]*areaSampleTable)
+	}
+	areaSampleTables.m[key] = tab
+	return tab
+}
+
+// areaSampleWeights computes the decimated weight grid of WeightSamplingArea from the ideal texel
+// weights (0..64): each point starts as the average of the texels it contributes to, weighted by
+// its infill weight, accumulated in integers. One Jacobi step of the least-squares fit then moves
+// each point against the residual of the texels it covers, by at most a quarter of the weight
+// range, which removes most of the blur the average adds.
+//
+// Texels of constant partitions (constPart, nil if there are none) are excluded, as
+// fillConstantPartitionWeights does; points that cover no other texel keep the ideal weight of
+// their nearest texel (sampleMap).
+func areaSampleWeights(tab *areaSampleTable, assign []uint8, constPart *[4]bool, sampleMap []uint16, texelWeights, grid []int) {
+	// The sums stay below 2^31 (64 * 16 * blockMaxTexels * 16), and 32-bit division is much
+	// cheaper than 64-bit.
+	var sum [blockMaxWeights]int32
+	cnt, sq := &tab.cnt, &tab.sq
+	var cntArr, sqArr [blockMaxWeights]int32
+	if constPart == nil {
+		for _, tp := range tab.taps {
+			sum[tp.point&(blockMaxWeights-1)] += int32(tp.w) * int32(texelWeights[tp.texel])
+		}
+	} else {
+		cnt, sq = &cntArr, &sqArr
+		for _, tp := range tab.taps {
+			if constPart[assign[tp.texel]&3] {
+				continue
+			}
+			p, w := tp.point&(blockMaxWeights-1), int32(tp.w)
+			sum[p] += w * int32(texelWeights[tp.texel])
+			cnt[p] += w
+			sq[p] += w * w
+		}
+	}
+	var g [blockMaxWeights]int32
+	for i := range grid {
+		if cnt[i] > 0 {
+			g[i] = (sum[i] + cnt[i]/2) / cnt[i]
+		} else {
+			g[i] = int32(texelWeights[sampleMap[i]])
+		}
+	}
+
+	// Refine: with infill weights w/16, the least-squares step of point i alone is
+	// -16 * sum(w * residual) / sum(w * w).
+	sum = [blockMaxWeights]int32{}
+	for t := range len(tab.first) - 1 {
+		if constPart != nil && constPart[assign[t]&3] {
+			continue
+		}
+		taps := tab.taps[tab.first[t]:tab.first[t+1]]
+		infill := int32(8)
+		for _, tp := range taps {
+			infill += int32(tp.w) * g[tp.point&(blockMaxWeights-1)]
+		}
+		r := infill>>4 - int32(texelWeights[t])
+		for _, tp := range taps {
+			sum[tp.point&(blockMaxWeights-1)] += int32(tp.w) * r
+		}
+	}
+	for i := range grid {
+		if sq[i] > 0 {
+			step := max(-16, min(16, -16*sum[i]/sq[i]))
+			g[i] = max(0, min(64, g[i]+step))
+		}
+		grid[i] = int(g[i])
+	}
+}
+
+// constPartOrNil returns constPart if any partition is constant, else nil.
+func constPartOrNil(constPart *[4]bool, any bool) *[4]bool {
+	if !any {
+		return nil
+	}
+	return constPart
+}
@@ -0,0 +1,85 @@
+package astc_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/arm-software/astc-encoder/astc"
+	"github.com/arm-software/astc-encoder/astc/testimage"
+)
+
+func weightSamplingPSNR(t *testing.T, pix []byte, w, h, bx, by int, quality astc.EncodeQuality, fn func(*astc.Config)) float64 {
+	t.Helper()
+	enc, err := astc.NewEncoder(astc.WithBlockSize(bx, by), astc.WithQuality(quality), astc.WithConfig(fn))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := enc.Encode(&astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeU8, DataU8: pix})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dec, _, _, err := astc.DecodeRGBA8(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return psnrU8(pix, dec, 4)
+}
+
+func TestWeightSampling_AreaBeatsNearest(t *testing.T) {
+	// Photographic content, where the bias of nearest sampling shows; synthetic test images are
+	// mostly smooth or flat within a block.
+	full, fw, _ := decodePNGToNRGBA(t, "testdata/images/Small/LDR-RGB/ldr-rgb-05.png")
+	const w, h = 96, 80
+	pix := make([]byte, w*h*4)
+	for y := range h {
+		copy(pix[y*w*4:(y+1)*w*4], full[y*fw*4:])
+	}
+	for _, bs := range [][2]int{{6, 5}, {8, 8}, {12, 12}} {
+		sampled := func(ws astc.WeightSampling) float64 {
+			return weightSamplingPSNR(t, pix, w, h, bs[0], bs[1], astc.EncodeMedium, func(c *astc.Config) { c.WeightSampling = ws })
+		}
+		nearest, area := sampled(astc.WeightSamplingNearest), sampled(astc.WeightSamplingArea)
+		if area < nearest+0.3 {
+			t.Errorf("%dx%d: area sampling %.2f dB, want at least 0.3 dB above nearest (%.2f dB)", bs[0], bs[1], area, nearest)
+		}
+	}
+}
+
+func TestWeightSampling_AutoPolicy(t *testing.T) {
+	const w, h = 48, 40
+	pix, err := testimage.RGBA8(testimage.KindPerlin, w, h, 1, testimage.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	encode := func(quality astc.EncodeQuality, fn func(*astc.Config)) string {
+		enc, err := astc.NewEncoder(astc.WithBlockSize(6, 5), astc.WithQuality(quality), astc.WithConfig(fn))
+		if err != nil {
+			t.Fatal(err)
+		}
+		out, err := enc.Encode(&astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeU8, DataU8: pix})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(out)
+	}
+	policy := func(ws astc.WeightSampling) func(*astc.Config) {
+		return func(c *astc.Config) { c.WeightSampling = ws }
+	}
+
+	// Auto is nearest below thorough and area from thorough on.
+	if encode(astc.EncodeMedium, policy(astc.WeightSamplingAuto)) != encode(astc.EncodeMedium, policy(astc.WeightSamplingNearest)) {
+		t.Error("medium: auto sampling differs from nearest")
+	}
+	if encode(astc.EncodeThorough, policy(astc.WeightSamplingAuto)) != encode(astc.EncodeThorough, policy(astc.WeightSamplingArea)) {
+		t.Error("thorough: auto sampling differs from area")
+	}
+
+	cfg, err := astc.ConfigInit(astc.ProfileLDR, 6, 5, 1, 60, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.WeightSampling = astc.WeightSamplingArea + 1
+	if _, err := astc.ContextAlloc(&cfg, 1); !errors.Is(err, astc.ErrBadParam) {
+		t.Fatalf("ContextAlloc with unknown weight sampling: %v, want ErrBadParam", err)
+	}
+}
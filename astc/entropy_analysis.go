@@ -0,0 +1,227 @@
+package astc
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+)
+
+// FieldEntropy is the order-0 entropy of one block field across a payload: the Shannon entropy of
+// the histogram of its values, ignoring any context.
+type FieldEntropy struct {
+	// Name identifies the field as in the CSV output: "block_mode", "partition", "endpoint_mode",
+	// "endpoint_values", "weights" or "constant_color".
+	Name string
+	// Symbols is the number of values observed and Distinct the number of different ones.
+	Symbols  int
+	Distinct int
+	// StoredBits is the number of bits the field occupies in the payload.
+	StoredBits int
+	// Entropy is the entropy in bits per symbol. For endpoint values and weights it is conditioned
+	// on the quantization level, which the block mode implies.
+	Entropy float64
+}
+
+// EntropyBits returns the entropy of the whole field, Entropy times Symbols: a lower bound on
+// what an order-0 coder needs to store its values.
+func (f FieldEntropy) EntropyBits() float64 {
+	return f.Entropy * float64(f.Symbols)
+}
+
+// EntropyAnalysis is the per-field entropy of a payload, as AnalyzeEntropy reports it.
+type EntropyAnalysis struct {
+	// Blocks counts the analyzed blocks; ErrorBlocks, which are not analyzed, are not included.
+	Blocks      int
+	ErrorBlocks int
+
+	// BlockMode holds one symbol per block: the 11-bit block mode (0x1FC or 0x3FC for constant
+	// blocks).
+	BlockMode FieldEntropy
+	// Partition holds one symbol per non-constant block: the partition count and index.
+	Partition FieldEntropy
+	// EndpointMode holds one symbol per non-constant block: the color endpoint modes of its
+	// partitions and, for dual-plane blocks, the plane 2 component.
+	EndpointMode FieldEntropy
+	// EndpointValues holds one symbol per color endpoint integer, by quantization level and value.
+	EndpointValues FieldEntropy
+	// Weights holds one symbol per weight, by quantization level and value.
+	Weights FieldEntropy
+	// ConstantColor holds four symbols per constant block: its 16-bit channel values. The
+	// void-extent coordinates are not analyzed.
+	ConstantColor FieldEntropy
+}
+
+// Fields returns the fields of a in the order of the CSV output.
+func (a *EntropyAnalysis) Fields() []FieldEntropy {
+	return []FieldEntropy{a.BlockMode, a.Partition, a.EndpointMode, a.EndpointValues, a.Weights, a.ConstantColor}
+}
+
+// AnalyzeEntropy estimates how compressible each field of the blocks of an encoded image is,
+// e.g. to judge where rate-distortion optimization would pay off or to design dictionaries for
+// supercompression: for the block mode, partitioning, endpoint modes, endpoint values, weights and
+// constant colors it reports the bits stored and the order-0 entropy of the values. Endpoint
+// values and weights are measured per quantization level (the entropy of the value given the
+// level), since a decoder knows the level before it reads them.
+//
+// The blocks slice is the block payload as returned by ParseFile.
+func AnalyzeEntropy(h Header, blocks []byte) (EntropyAnalysis, error) {
+	_, _, _, total, err := h.BlockCount()
+	if err != nil {
+		return EntropyAnalysis{}, err
+	}
+	if len(blocks) < total*BlockBytes {
+		return EntropyAnalysis{}, ioErrUnexpectedEOF("astc blocks", total*BlockBytes, len(blocks))
+	}
+	texelCount := int(h.BlockX) * int(h.BlockY) * int(h.BlockZ)
+	if texelCount <= 0 || texelCount > blockMaxTexels {
+		return EntropyAnalysis{}, errors.New("astc: invalid block dimensions")
+	}
+	ctx := getDecodeContext(int(h.BlockX), int(h.BlockY), int(h.BlockZ))
+
+	var out EntropyAnalysis
+	blockMode := entropyHistogram{dense: make([]int, blockModeCount)}
+	partition := entropyHistogram{dense: make([]int, (blockMaxPartitions+1)<<partitionIndexBits)}
+	endpointMode := entropyHistogram{sparse: map[uint32]int{}}
+	endpointValues := entropyHistogram{dense: make([]int, int(quant256+1)<<8), given: &entropyHistogram{dense: make([]int, quant256+1)}}
+	weights := entropyHistogram{dense: make([]int, int(quant32+1)<<8), given: &entropyHistogram{dense: make([]int, quant32+1)}}
+	constantColor := entropyHistogram{sparse: map[uint32]int{}}
+
+	for i := 0; i < total; i++ {
+		block := blocks[i*BlockBytes : (i+1)*BlockBytes]
+		scb := physicalToSymbolicWithCtx(block, ctx)
+		if scb.blockType == symBlockError {
+			out.ErrorBlocks++
+			continue
+		}
+		out.Blocks++
+		blockMode.add(readBits(11, 0, block))
+		bb := symbolicBlockBits(ctx, &scb)
+		out.BlockMode.StoredBits += 11
+		if bb.Constant {
+			for _, v := range scb.constantColor {
+				constantColor.add(uint32(v))
+			}
+			out.ConstantColor.StoredBits += 64
+			continue
+		}
+
+		bmi := ctx.blockMode(int(scb.blockMode))
+		partitionCount := int(scb.partitionCount)
+		partition.add(uint32(partitionCount)<<partitionIndexBits | uint32(scb.partitionIndex))
+		out.Partition.StoredBits += 2
+		if partitionCount > 1 {
+			out.Partition.StoredBits += partitionIndexBits
+		}
+
+		mode := uint32(scb.plane2Component + 1)
+		for p := 0; p < partitionCount; p++ {
+			mode = mode<<4 | uint32(scb.colorFormats[p])
+			for _, v := range scb.colorValues[p][:2*int(scb.colorFormats[p]>>2)+2] {
+				endpointValues.addGiven(uint32(scb.quantMode), uint32(v))
+			}
+		}
+		endpointMode.add(mode)
+		// The configuration bits left after the block mode and partition fields.
+		out.EndpointMode.StoredBits += bb.Config - 11 - 2
+		if partitionCount > 1 {
+			out.EndpointMode.StoredBits -= partitionIndexBits
+		}
+		out.EndpointValues.StoredBits += bb.Endpoint
+
+		for w := 0; w < int(bmi.weightCount); w++ {
+			weights.addGiven(uint32(bmi.weightQuant), uint32(scb.weights[w]))
+			if bmi.isDualPlane {
+				weights.addGiven(uint32(bmi.weightQuant), uint32(scb.weights[w+weightsPlane2Offset]))
+			}
+		}
+		out.Weights.StoredBits += bb.Weight
+	}
+
+	out.BlockMode = blockMode.field("block_mode", out.BlockMode.StoredBits)
+	out.Partition = partition.field("partition", out.Partition.StoredBits)
+	out.EndpointMode = endpointMode.field("endpoint_mode", out.EndpointMode.StoredBits)
+	out.EndpointValues = endpointValues.field("endpoint_values", out.EndpointValues.StoredBits)
+	out.Weights = weights.field("weights", out.Weights.StoredBits)
+	out.ConstantColor = constantColor.field("constant_color", out.ConstantColor.StoredBits)
+	return out, nil
+}
+
+// WriteCSV writes a as CSV: a header row, then one row per field with its name, symbol count,
+// distinct values, stored bits, entropy per symbol and total entropy bits.
+func (a *EntropyAnalysis) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"field", "symbols", "distinct", "stored_bits", "entropy_bits_per_symbol", "entropy_bits"}); err != nil {
+		return err
+	}
+	for _, f := range a.Fields() {
+		row := []string{
+			f.Name,
+			strconv.Itoa(f.Symbols),
+			strconv.Itoa(f.Distinct),
+			strconv.Itoa(f.StoredBits),
+			strconv.FormatFloat(f.Entropy, 'f', 4, 64),
+			strconv.FormatFloat(f.EntropyBits(), 'f', 1, 64),
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// entropyHistogram counts symbols in dense, indexed by value, or in sparse for fields whose values
+// are too wide for a table.
+type entropyHistogram struct {
+	dense  []int
+	sparse map[uint32]int
+	total  int
+
+	// given, if set, counts the condition of each symbol added with addGiven, and field reports
+	// the conditional entropy H(value | condition) = H(condition, value) - H(condition).
+	given *entropyHistogram
+}
+
+// addGiven counts the 8-bit value v under condition cond.
+func (h *entropyHistogram) addGiven(cond, v uint32) {
+	h.given.add(cond)
+	h.add(cond<<8 | v)
+}
+
+func (h *entropyHistogram) add(v uint32) {
+	if h.dense != nil {
+		h.dense[v]++
+	} else {
+		h.sparse[v]++
+	}
+	h.total++
+}
+
+func (h *entropyHistogram) field(name string, storedBits int) FieldEntropy {
+	f := FieldEntropy{Name: name, Symbols: h.total, StoredBits: storedBits}
+	count := func(n int) {
+		if n == 0 {
+			return
+		}
+		f.Distinct++
+		p := float64(n) / float64(h.total)
+		f.Entropy -= p * math.Log2(p)
+	}
+	for _, n := range h.dense {
+		count(n)
+	}
+	// Sum in a fixed order so the result does not depend on map iteration.
+	for _, v := range slices.Sorted(maps.Keys(h.sparse)) {
+		count(h.sparse[v])
+	}
+	if h.given != nil {
+		f.Entropy -= h.given.field("", 0).Entropy
+	}
+	// Avoid reporting -0 for single-valued fields, or rounding below zero for conditional ones.
+	f.Entropy = max(f.Entropy, 0)
+	return f
+}
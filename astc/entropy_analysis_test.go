@@ -0,0 +1,104 @@
+package astc_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"math"
+	"testing"
+
+	"github.com/arm-software/astc-encoder/astc"
+	"github.com/arm-software/astc-encoder/astc/testimage"
+)
+
+func TestAnalyzeEntropy(t *testing.T) {
+	const w, h = 96, 64
+	pix, err := testimage.RGBA8(testimage.KindAlphaCutout, w, h, 1, testimage.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A flat region so at least one block is constant.
+	for y := 0; y < 12; y++ {
+		for x := 0; x < 12; x++ {
+			copy(pix[(y*w+x)*4:], []byte{7, 7, 7, 255})
+		}
+	}
+	data, err := astc.EncodeRGBA8WithProfileAndQuality(pix, w, h, 6, 6, astc.ProfileLDR, astc.EncodeThorough)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hdr, blocks, err := astc.ParseFile(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := astc.AnalyzeEntropy(hdr, blocks)
+	if err != nil {
+		t.Fatalf("AnalyzeEntropy: %v", err)
+	}
+	bits, err := astc.AnalyzeBits(hdr, blocks)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	total := len(blocks) / astc.BlockBytes
+	if a.Blocks != total || a.ErrorBlocks != 0 || a.BlockMode.Symbols != total {
+		t.Fatalf("blocks %d, errors %d, block modes %d; want %d, 0, %d", a.Blocks, a.ErrorBlocks, a.BlockMode.Symbols, total, total)
+	}
+	consts := bits.ConstantBlocks
+	if consts == 0 {
+		t.Fatalf("expected at least one constant block")
+	}
+	if a.Partition.Symbols != total-consts || a.EndpointMode.Symbols != total-consts || a.ConstantColor.Symbols != 4*consts {
+		t.Fatalf("symbol counts %+v do not match %d blocks, %d constant", a, total, consts)
+	}
+
+	// The fields account for every configuration, endpoint and weight bit AnalyzeBits reports,
+	// except the void-extent coordinates of constant blocks.
+	if got, want := a.BlockMode.StoredBits+a.Partition.StoredBits+a.EndpointMode.StoredBits, bits.Config-53*consts; got != want {
+		t.Fatalf("configuration fields store %d bits, want %d", got, want)
+	}
+	if got, want := a.EndpointValues.StoredBits+a.ConstantColor.StoredBits, bits.Endpoint; got != want {
+		t.Fatalf("endpoint fields store %d bits, want %d", got, want)
+	}
+	if a.Weights.StoredBits != bits.Weight {
+		t.Fatalf("weights store %d bits, want %d", a.Weights.StoredBits, bits.Weight)
+	}
+
+	for _, f := range a.Fields() {
+		if f.Distinct < 1 || f.Distinct > f.Symbols {
+			t.Fatalf("%s: %d distinct of %d symbols", f.Name, f.Distinct, f.Symbols)
+		}
+		if f.Entropy < 0 || f.Entropy > math.Log2(float64(f.Distinct))+1e-9 {
+			t.Fatalf("%s: entropy %.4f outside [0, log2(%d)]", f.Name, f.Entropy, f.Distinct)
+		}
+		if f.EntropyBits() > float64(f.StoredBits) {
+			t.Fatalf("%s: entropy %.1f bits exceeds the %d stored bits", f.Name, f.EntropyBits(), f.StoredBits)
+		}
+	}
+	// In a payload of one repeated block, the fields with one symbol per block never vary.
+	flat := bytes.Repeat(blocks[len(blocks)-astc.BlockBytes:], total)
+	fa, err := astc.AnalyzeEntropy(hdr, flat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range []astc.FieldEntropy{fa.BlockMode, fa.Partition, fa.EndpointMode} {
+		if f.Entropy != 0 {
+			t.Fatalf("%s: entropy %v of a repeated block, want 0", f.Name, f.Entropy)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := a.WriteCSV(&buf); err != nil {
+		t.Fatalf("WriteCSV: %v", err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("reading CSV: %v", err)
+	}
+	if len(rows) != 7 || rows[0][0] != "field" || rows[5][0] != "weights" || len(rows[5]) != 6 {
+		t.Fatalf("unexpected CSV:\n%v", rows)
+	}
+
+	if _, err := astc.AnalyzeEntropy(hdr, blocks[:len(blocks)-1]); err == nil {
+		t.Fatalf("expected error for a truncated payload")
+	}
+}
@@ -84,6 +84,13 @@ func ErrorString(code ErrorCode) string {
 	}
 }
 
+// Error returns the error string of an *Error carrying only this code. It lets codes be used as
+// errors.Is targets: errors.Is(err, ErrBadBlockSize) reports whether err carries that code, for
+// errors from both this package and astc/native.
+func (c ErrorCode) Error() string {
+	return (&Error{Code: c}).Error()
+}
+
 // Error is a typed error that carries an upstream-equivalent error code.
 type Error struct {
 	Code ErrorCode
@@ -103,9 +110,21 @@ func (e *Error) Error() string {
 	return "astc: error"
 }
 
+// Is reports whether target is the ErrorCode of e, or an *Error with the same code.
+func (e *Error) Is(target error) bool {
+	switch t := target.(type) {
+	case ErrorCode:
+		return e.Code == t
+	case *Error:
+		return t != nil && e.Code == t.Code
+	}
+	return false
+}
+
 // ErrorCodeOf returns the astcenc-equivalent error code for err, or Success for nil.
 //
-// For non-*Error errors it returns ErrBadParam as a conservative fallback.
+// For errors carrying neither an *Error nor an ErrorCode it returns ErrBadParam as a conservative
+// fallback.
 func ErrorCodeOf(err error) ErrorCode {
 	if err == nil {
 		return Success
@@ -114,6 +133,10 @@ func ErrorCodeOf(err error) ErrorCode {
 	if errors.As(err, &e) {
 		return e.Code
 	}
+	var code ErrorCode
+	if errors.As(err, &code) {
+		return code
+	}
 	return ErrBadParam
 }
 
@@ -2,6 +2,7 @@ package astc_test
 
 import (
 	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/arm-software/astc-encoder/astc"
@@ -53,3 +54,20 @@ func TestErrorCodeOf(t *testing.T) {
 		t.Fatalf("ErrorCodeOf(non-astc): got %v want %v", got, astc.ErrBadParam)
 	}
 }
+
+func TestError_Is(t *testing.T) {
+	_, err := astc.ConfigInit(astc.ProfileLDR, 4, 4, 1, -1, 0)
+	wrapped := fmt.Errorf("loading preset: %w", err)
+	if !errors.Is(wrapped, astc.ErrBadQuality) {
+		t.Fatalf("errors.Is(%v, ErrBadQuality) = false, want true", wrapped)
+	}
+	if !errors.Is(wrapped, &astc.Error{Code: astc.ErrBadQuality}) {
+		t.Fatalf("errors.Is(%v, &Error{ErrBadQuality}) = false, want true", wrapped)
+	}
+	if errors.Is(wrapped, astc.ErrBadBlockSize) {
+		t.Fatalf("errors.Is(%v, ErrBadBlockSize) = true, want false", wrapped)
+	}
+	if got, want := astc.ErrBadBlockSize.Error(), "astc: ASTCENC_ERR_BAD_BLOCK_SIZE"; got != want {
+		t.Fatalf("ErrBadBlockSize.Error() = %q, want %q", got, want)
+	}
+}
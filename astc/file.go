@@ -0,0 +1,193 @@
+package astc
+
+import (
+	"errors"
+	"io"
+	"os"
+)
+
+// File is an .astc file opened for random access by OpenFile. Blocks are read on demand, so
+// regions of very large images can be decoded without loading the whole payload.
+//
+// A File is safe for concurrent use by multiple goroutines, except for Close.
+type File struct {
+	// Header is the parsed file header.
+	Header Header
+
+	f       *os.File
+	mapped  []byte // the whole file when memory-mapped, else nil
+	blocksX int
+	blocksY int
+	blocksZ int
+}
+
+// OpenFile opens an .astc file for random access. Where supported the file is memory-mapped;
+// otherwise blocks are read with positioned reads. The caller must Close the File.
+func OpenFile(path string) (*File, error) {
+	return openFile(path, true)
+}
+
+func openFile(path string, useMmap bool) (*File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	af, err := newFile(f, useMmap)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return af, nil
+}
+
+func newFile(f *os.File, useMmap bool) (*File, error) {
+	var hdr [HeaderSize]byte
+	if n, err := f.ReadAt(hdr[:], 0); n < HeaderSize {
+		if err == io.EOF {
+			return nil, ioErrUnexpectedEOF("astc header", HeaderSize, n)
+		}
+		return nil, err
+	}
+	h, err := ParseHeader(hdr[:])
+	if err != nil {
+		return nil, err
+	}
+	blocksX, blocksY, blocksZ, total, err := h.BlockCount()
+	if err != nil {
+		return nil, err
+	}
+	st, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	need := int64(HeaderSize) + int64(total)*BlockBytes
+	if st.Size() < need {
+		return nil, ioErrUnexpectedEOF("astc file", int(need), int(st.Size()))
+	}
+
+	af := &File{Header: h, f: f, blocksX: blocksX, blocksY: blocksY, blocksZ: blocksZ}
+	if useMmap {
+		if m, err := mmapFile(f, need); err == nil {
+			// The mapping stays valid after the descriptor is closed.
+			af.mapped = m
+			af.f = nil
+			f.Close()
+		}
+	}
+	return af, nil
+}
+
+// Close releases the mapping or file descriptor.
+func (f *File) Close() error {
+	if f.mapped != nil {
+		m := f.mapped
+		f.mapped = nil
+		return munmapFile(m)
+	}
+	if f.f != nil {
+		err := f.f.Close()
+		f.f = nil
+		return err
+	}
+	return nil
+}
+
+// Mapped reports whether the file is memory-mapped.
+func (f *File) Mapped() bool { return f.mapped != nil }
+
+// ReadBlocks copies len(dst)/BlockBytes consecutive blocks, starting at block index first in file
+// order, into dst.
+func (f *File) ReadBlocks(first int, dst []byte) error {
+	b, err := f.blocks(first, len(dst)/BlockBytes, dst)
+	if err != nil {
+		return err
+	}
+	copy(dst, b)
+	return nil
+}
+
+// blocks returns count blocks starting at index first, aliasing the mapping when possible and
+// otherwise reading into buf.
+func (f *File) blocks(first, count int, buf []byte) ([]byte, error) {
+	total := f.blocksX * f.blocksY * f.blocksZ
+	if first < 0 || count < 0 || first+count > total {
+		return nil, errors.New("astc: block range out of bounds")
+	}
+	off := int64(HeaderSize) + int64(first)*BlockBytes
+	n := count * BlockBytes
+	if f.mapped != nil {
+		return f.mapped[off : off+int64(n)], nil
+	}
+	if f.f == nil {
+		return nil, errors.New("astc: file is closed")
+	}
+	if len(buf) < n {
+		return nil, errors.New("astc: output buffer too small")
+	}
+	if _, err := f.f.ReadAt(buf[:n], off); err != nil {
+		return nil, err
+	}
+	return buf[:n], nil
+}
+
+// DecodeRegionRGBA8 decodes the texels in [x, x+width) x [y, y+height) x [z, z+depth) into dst,
+// laid out like DecodeRGBA8VolumeWithProfileInto for a width x height x depth image. Only the
+// blocks overlapping the region are read.
+//
+// Limitations:
+//   - Only LDR profiles (ProfileLDR, ProfileLDRSRGB).
+func (f *File) DecodeRegionRGBA8(profile Profile, x, y, z, width, height, depth int, dst []byte) error {
+	if profile != ProfileLDR && profile != ProfileLDRSRGB {
+		return errUnsupportedProfileRGBA8
+	}
+	h := f.Header
+	if width <= 0 || height <= 0 || depth <= 0 || x < 0 || y < 0 || z < 0 ||
+		x+width > int(h.SizeX) || y+height > int(h.SizeY) || z+depth > int(h.SizeZ) {
+		return errors.New("astc: region out of bounds")
+	}
+	if len(dst) < width*height*depth*4 {
+		return errors.New("astc: output buffer too small")
+	}
+
+	blockX, blockY, blockZ := int(h.BlockX), int(h.BlockY), int(h.BlockZ)
+	texelCount := blockX * blockY * blockZ
+	if texelCount <= 0 || texelCount > blockMaxTexels {
+		return errors.New("astc: invalid block dimensions")
+	}
+	ctx := getDecodeContext(blockX, blockY, blockZ)
+
+	var decodedBlock [blockMaxTexels * 4]byte
+	decoded := decodedBlock[:texelCount*4]
+
+	bx0, bx1 := x/blockX, (x+width-1)/blockX
+	var buf []byte
+	if f.mapped == nil {
+		buf = make([]byte, (bx1-bx0+1)*BlockBytes)
+	}
+	for bz := z / blockZ; bz <= (z+depth-1)/blockZ; bz++ {
+		for by := y / blockY; by <= (y+height-1)/blockY; by++ {
+			row, err := f.blocks((bz*f.blocksY+by)*f.blocksX+bx0, bx1-bx0+1, buf)
+			if err != nil {
+				return err
+			}
+			for bx := bx0; bx <= bx1; bx++ {
+				off := (bx - bx0) * BlockBytes
+				decodeBlockToRGBA8(profile, ctx, row[off:off+BlockBytes], decoded)
+
+				// Intersect the block with the region.
+				sx0, sx1 := max(bx*blockX, x), min((bx+1)*blockX, x+width)
+				sy0, sy1 := max(by*blockY, y), min((by+1)*blockY, y+height)
+				sz0, sz1 := max(bz*blockZ, z), min((bz+1)*blockZ, z+depth)
+				rowBytes := (sx1 - sx0) * 4
+				for tz := sz0; tz < sz1; tz++ {
+					for ty := sy0; ty < sy1; ty++ {
+						src := (((tz-bz*blockZ)*blockY+(ty-by*blockY))*blockX + (sx0 - bx*blockX)) * 4
+						d := (((tz-z)*height+(ty-y))*width + (sx0 - x)) * 4
+						copy(dst[d:d+rowBytes], decoded[src:src+rowBytes])
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
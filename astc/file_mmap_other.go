@@ -0,0 +1,18 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package astc
+
+import (
+	"errors"
+	"os"
+)
+
+// Memory mapping is not implemented on this platform; File falls back to positioned reads.
+
+func mmapFile(f *os.File, size int64) ([]byte, error) {
+	return nil, errors.New("astc: memory mapping not supported")
+}
+
+func munmapFile(b []byte) error {
+	return nil
+}
@@ -0,0 +1,83 @@
+package astc
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOpenFile_DecodeRegionRGBA8(t *testing.T) {
+	const w, h = 37, 29
+	pix := make([]byte, w*h*4)
+	for i := range pix {
+		pix[i] = uint8(i*7 + i/97)
+	}
+	data, err := EncodeRGBA8WithProfileAndQuality(pix, w, h, 6, 5, ProfileLDR, EncodeFast)
+	if err != nil {
+		t.Fatalf("EncodeRGBA8: %v", err)
+	}
+	full, _, _, err := DecodeRGBA8WithProfile(data, ProfileLDR)
+	if err != nil {
+		t.Fatalf("DecodeRGBA8WithProfile: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "img.astc")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	for _, useMmap := range []bool{true, false} {
+		f, err := openFile(path, useMmap)
+		if err != nil {
+			t.Fatalf("openFile: %v", err)
+		}
+		if f.Header.SizeX != w || f.Header.BlockY != 5 {
+			t.Fatalf("unexpected header: %v", f.Header)
+		}
+		if !useMmap && f.Mapped() {
+			t.Fatalf("fallback file reports mapped")
+		}
+
+		for _, r := range [][4]int{{0, 0, w, h}, {5, 3, 1, 1}, {11, 9, 14, 12}, {30, 20, 7, 9}} {
+			x, y, rw, rh := r[0], r[1], r[2], r[3]
+			got := make([]byte, rw*rh*4)
+			if err := f.DecodeRegionRGBA8(ProfileLDR, x, y, 0, rw, rh, 1, got); err != nil {
+				t.Fatalf("DecodeRegionRGBA8(%v): %v", r, err)
+			}
+			for ry := 0; ry < rh; ry++ {
+				want := full[((y+ry)*w+x)*4 : ((y+ry)*w+x+rw)*4]
+				if !bytes.Equal(got[ry*rw*4:(ry+1)*rw*4], want) {
+					t.Fatalf("mmap=%v region %v: row %d mismatch", useMmap, r, ry)
+				}
+			}
+		}
+
+		blk := make([]byte, 2*BlockBytes)
+		if err := f.ReadBlocks(3, blk); err != nil {
+			t.Fatalf("ReadBlocks: %v", err)
+		}
+		if !bytes.Equal(blk, data[HeaderSize+3*BlockBytes:HeaderSize+5*BlockBytes]) {
+			t.Fatalf("ReadBlocks mismatch")
+		}
+		if err := f.ReadBlocks(7*6-1, blk); err == nil {
+			t.Fatalf("expected error reading past the last block")
+		}
+		if err := f.DecodeRegionRGBA8(ProfileLDR, 30, 0, 0, 8, 1, 1, make([]byte, 32)); err == nil {
+			t.Fatalf("expected error for out-of-bounds region")
+		}
+		if err := f.DecodeRegionRGBA8(ProfileHDR, 0, 0, 0, 1, 1, 1, make([]byte, 4)); err == nil {
+			t.Fatalf("expected error for HDR profile")
+		}
+		if err := f.Close(); err != nil {
+			t.Fatalf("Close: %v", err)
+		}
+	}
+
+	short := filepath.Join(t.TempDir(), "short.astc")
+	if err := os.WriteFile(short, data[:len(data)-1], 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if _, err := OpenFile(short); err == nil {
+		t.Fatalf("expected error for truncated file")
+	}
+}
@@ -1,19 +1,48 @@
 package astc
 
-// Precomputed conversion tables for float32 output decoding.
+import "math"
+
+// Precomputed conversion tables for float32 output decoding and normal-map error evaluation.
+//
+// The float tables are used heavily by DecodeRGBAF32* hot paths. Computing these on the fly is
+// significantly slower than a table lookup.
 //
-// These are used heavily by DecodeRGBAF32* hot paths. Computing these on the fly is significantly
-// slower than a table lookup.
+// By default the tables (about 1.3 MB) are static and filled at package init. Building with the
+// astc_smalllut tag allocates and fills each one on first use instead, so programs that never
+// decode to float32 or score normal maps (typically WebAssembly asset viewers) pay neither the
+// memory nor the startup time. Hot paths fetch the tables through float32Tables and normalXYZLUT.
+
+type float32Table = [1 << 16]float32
 
-var (
-	unorm16ToFloat32Table [1 << 16]float32
-	lnsToFloat32Table     [1 << 16]float32
-)
+type normalXYZTable = [256][256][3]float32
 
-func init() {
+func fillFloat32Tables(unorm16, lns *float32Table) {
 	for i := 0; i < (1 << 16); i++ {
 		u := uint16(i)
-		unorm16ToFloat32Table[u] = halfToFloat32(unorm16ToSF16(u))
-		lnsToFloat32Table[u] = halfToFloat32(lnsToSF16(u))
+		unorm16[u] = HalfToFloat32(unorm16ToSF16(u))
+		lns[u] = HalfToFloat32(lnsToSF16(u))
+	}
+}
+
+// fillNormalXYZ precomputes normalized vectors for normal-map angular error evaluation
+// (MAP_NORMAL mode), indexed by the 8-bit X (red) and Y (alpha) components.
+func fillNormalXYZ(lut *normalXYZTable) {
+	for r := 0; r < 256; r++ {
+		x := float32(r)*(2.0/255.0) - 1.0
+		for a := 0; a < 256; a++ {
+			y := float32(a)*(2.0/255.0) - 1.0
+			z2 := 1.0 - x*x - y*y
+			if z2 < 0 {
+				z2 = 0
+			}
+			z := float32(math.Sqrt(float64(z2)))
+			n2 := x*x + y*y + z*z
+			if n2 > 0 {
+				invN := float32(1.0 / math.Sqrt(float64(n2)))
+				lut[r][a][0] = x * invN
+				lut[r][a][1] = y * invN
+				lut[r][a][2] = z * invN
+			}
+		}
 	}
 }
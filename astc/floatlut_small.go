@@ -0,0 +1,22 @@
+//go:build astc_smalllut
+
+package astc
+
+import "sync"
+
+var (
+	float32TablesOnce = sync.OnceValues(func() (*float32Table, *float32Table) {
+		unorm16, lns := new(float32Table), new(float32Table)
+		fillFloat32Tables(unorm16, lns)
+		return unorm16, lns
+	})
+	normalXYZOnce = sync.OnceValue(func() *normalXYZTable {
+		lut := new(normalXYZTable)
+		fillNormalXYZ(lut)
+		return lut
+	})
+)
+
+func float32Tables() (unorm16, lns *float32Table) { return float32TablesOnce() }
+
+func normalXYZLUT() *normalXYZTable { return normalXYZOnce() }
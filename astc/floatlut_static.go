@@ -0,0 +1,20 @@
+//go:build !astc_smalllut
+
+package astc
+
+var (
+	unorm16ToFloat32Table float32Table
+	lnsToFloat32Table     float32Table
+	normalXYZStatic       normalXYZTable
+)
+
+func init() {
+	fillFloat32Tables(&unorm16ToFloat32Table, &lnsToFloat32Table)
+	fillNormalXYZ(&normalXYZStatic)
+}
+
+func float32Tables() (unorm16, lns *float32Table) {
+	return &unorm16ToFloat32Table, &lnsToFloat32Table
+}
+
+func normalXYZLUT() *normalXYZTable { return &normalXYZStatic }
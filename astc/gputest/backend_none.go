@@ -0,0 +1,7 @@
+//go:build !gputest_vulkan || !cgo
+
+package gputest
+
+func openBackend() (Device, error) {
+	return nil, ErrNoBackend
+}
@@ -0,0 +1,390 @@
+//go:build gputest_vulkan && cgo
+
+package gputest
+
+/*
+#cgo LDFLAGS: -lvulkan
+#include <stdlib.h>
+#include <string.h>
+#include <vulkan/vulkan.h>
+
+typedef struct {
+	VkInstance instance;
+	VkPhysicalDevice phys;
+	VkDevice device;
+	VkQueue queue;
+	VkCommandPool pool;
+	char name[VK_MAX_PHYSICAL_DEVICE_NAME_SIZE];
+} gt_device;
+
+static void gt_close(gt_device* d) {
+	if (d->device) {
+		vkDeviceWaitIdle(d->device);
+		if (d->pool) {
+			vkDestroyCommandPool(d->device, d->pool, NULL);
+		}
+		vkDestroyDevice(d->device, NULL);
+	}
+	if (d->instance) {
+		vkDestroyInstance(d->instance, NULL);
+	}
+	memset(d, 0, sizeof(*d));
+}
+
+// gt_open creates a device on the first GPU with ASTC LDR support and a graphics queue (blits
+// need one).
+static VkResult gt_open(gt_device* d) {
+	memset(d, 0, sizeof(*d));
+
+	VkApplicationInfo app = {VK_STRUCTURE_TYPE_APPLICATION_INFO};
+	app.pApplicationName = "astc-gputest";
+	app.apiVersion = VK_API_VERSION_1_0;
+	VkInstanceCreateInfo ici = {VK_STRUCTURE_TYPE_INSTANCE_CREATE_INFO};
+	ici.pApplicationInfo = &app;
+#ifdef __APPLE__
+	// MoltenVK is a portability implementation.
+	const char* instExt[] = {"VK_KHR_portability_enumeration"};
+	ici.flags = 0x00000001; // VK_INSTANCE_CREATE_ENUMERATE_PORTABILITY_BIT_KHR
+	ici.enabledExtensionCount = 1;
+	ici.ppEnabledExtensionNames = instExt;
+#endif
+	VkResult r = vkCreateInstance(&ici, NULL, &d->instance);
+	if (r != VK_SUCCESS) {
+		return r;
+	}
+
+	uint32_t n = 0;
+	r = vkEnumeratePhysicalDevices(d->instance, &n, NULL);
+	if (r != VK_SUCCESS || n == 0) {
+		gt_close(d);
+		return VK_ERROR_INITIALIZATION_FAILED;
+	}
+	VkPhysicalDevice* devs = malloc(n * sizeof(*devs));
+	vkEnumeratePhysicalDevices(d->instance, &n, devs);
+
+	uint32_t family = 0;
+	for (uint32_t i = 0; i < n && !d->phys; i++) {
+		VkPhysicalDeviceFeatures f;
+		vkGetPhysicalDeviceFeatures(devs[i], &f);
+		if (!f.textureCompressionASTC_LDR) {
+			continue;
+		}
+		uint32_t qn = 0;
+		vkGetPhysicalDeviceQueueFamilyProperties(devs[i], &qn, NULL);
+		VkQueueFamilyProperties* qs = malloc(qn * sizeof(*qs));
+		vkGetPhysicalDeviceQueueFamilyProperties(devs[i], &qn, qs);
+		for (uint32_t q = 0; q < qn; q++) {
+			if (qs[q].queueFlags & VK_QUEUE_GRAPHICS_BIT) {
+				d->phys = devs[i];
+				family = q;
+				break;
+			}
+		}
+		free(qs);
+	}
+	free(devs);
+	if (!d->phys) {
+		gt_close(d);
+		return VK_ERROR_FEATURE_NOT_PRESENT;
+	}
+
+	VkPhysicalDeviceProperties props;
+	vkGetPhysicalDeviceProperties(d->phys, &props);
+	memcpy(d->name, props.deviceName, sizeof(d->name));
+
+	float prio = 1.0f;
+	VkDeviceQueueCreateInfo qci = {VK_STRUCTURE_TYPE_DEVICE_QUEUE_CREATE_INFO};
+	qci.queueFamilyIndex = family;
+	qci.queueCount = 1;
+	qci.pQueuePriorities = &prio;
+	VkPhysicalDeviceFeatures want;
+	memset(&want, 0, sizeof(want));
+	want.textureCompressionASTC_LDR = VK_TRUE;
+	VkDeviceCreateInfo dci = {VK_STRUCTURE_TYPE_DEVICE_CREATE_INFO};
+	dci.queueCreateInfoCount = 1;
+	dci.pQueueCreateInfos = &qci;
+	dci.pEnabledFeatures = &want;
+
+	// Portability implementations must have VK_KHR_portability_subset enabled.
+	const char* devExt[] = {"VK_KHR_portability_subset"};
+	uint32_t en = 0;
+	vkEnumerateDeviceExtensionProperties(d->phys, NULL, &en, NULL);
+	VkExtensionProperties* exts = malloc((en ? en : 1) * sizeof(*exts));
+	vkEnumerateDeviceExtensionProperties(d->phys, NULL, &en, exts);
+	for (uint32_t i = 0; i < en; i++) {
+		if (strcmp(exts[i].extensionName, devExt[0]) == 0) {
+			dci.enabledExtensionCount = 1;
+			dci.ppEnabledExtensionNames = devExt;
+		}
+	}
+	free(exts);
+
+	r = vkCreateDevice(d->phys, &dci, NULL, &d->device);
+	if (r != VK_SUCCESS) {
+		gt_close(d);
+		return r;
+	}
+	vkGetDeviceQueue(d->device, family, 0, &d->queue);
+
+	VkCommandPoolCreateInfo pci = {VK_STRUCTURE_TYPE_COMMAND_POOL_CREATE_INFO};
+	pci.queueFamilyIndex = family;
+	pci.flags = VK_COMMAND_POOL_CREATE_TRANSIENT_BIT;
+	r = vkCreateCommandPool(d->device, &pci, NULL, &d->pool);
+	if (r != VK_SUCCESS) {
+		gt_close(d);
+		return r;
+	}
+	return VK_SUCCESS;
+}
+
+static int gt_memtype(gt_device* d, uint32_t bits, VkMemoryPropertyFlags want) {
+	VkPhysicalDeviceMemoryProperties mp;
+	vkGetPhysicalDeviceMemoryProperties(d->phys, &mp);
+	for (uint32_t i = 0; i < mp.memoryTypeCount; i++) {
+		if ((bits & (1u << i)) && (mp.memoryTypes[i].propertyFlags & want) == want) {
+			return (int)i;
+		}
+	}
+	return -1;
+}
+
+static VkResult gt_alloc(gt_device* d, VkMemoryRequirements req, VkMemoryPropertyFlags want, VkDeviceMemory* mem) {
+	int type = gt_memtype(d, req.memoryTypeBits, want);
+	if (type < 0) {
+		type = gt_memtype(d, req.memoryTypeBits, 0);
+	}
+	if (type < 0) {
+		return VK_ERROR_OUT_OF_DEVICE_MEMORY;
+	}
+	VkMemoryAllocateInfo mai = {VK_STRUCTURE_TYPE_MEMORY_ALLOCATE_INFO};
+	mai.allocationSize = req.size;
+	mai.memoryTypeIndex = (uint32_t)type;
+	return vkAllocateMemory(d->device, &mai, NULL, mem);
+}
+
+static VkResult gt_image(gt_device* d, VkFormat format, uint32_t w, uint32_t h, VkImage* img, VkDeviceMemory* mem) {
+	VkImageCreateInfo ici = {VK_STRUCTURE_TYPE_IMAGE_CREATE_INFO};
+	ici.imageType = VK_IMAGE_TYPE_2D;
+	ici.format = format;
+	ici.extent.width = w;
+	ici.extent.height = h;
+	ici.extent.depth = 1;
+	ici.mipLevels = 1;
+	ici.arrayLayers = 1;
+	ici.samples = VK_SAMPLE_COUNT_1_BIT;
+	ici.tiling = VK_IMAGE_TILING_OPTIMAL;
+	ici.usage = VK_IMAGE_USAGE_TRANSFER_SRC_BIT | VK_IMAGE_USAGE_TRANSFER_DST_BIT;
+	ici.initialLayout = VK_IMAGE_LAYOUT_UNDEFINED;
+	VkResult r = vkCreateImage(d->device, &ici, NULL, img);
+	if (r != VK_SUCCESS) {
+		return r;
+	}
+	VkMemoryRequirements req;
+	vkGetImageMemoryRequirements(d->device, *img, &req);
+	r = gt_alloc(d, req, VK_MEMORY_PROPERTY_DEVICE_LOCAL_BIT, mem);
+	if (r != VK_SUCCESS) {
+		return r;
+	}
+	return vkBindImageMemory(d->device, *img, *mem, 0);
+}
+
+static void gt_barrier(VkCommandBuffer cb, VkImage img, VkImageLayout from, VkImageLayout to,
+	VkAccessFlags srcAccess, VkAccessFlags dstAccess) {
+	VkImageMemoryBarrier b = {VK_STRUCTURE_TYPE_IMAGE_MEMORY_BARRIER};
+	b.oldLayout = from;
+	b.newLayout = to;
+	b.srcAccessMask = srcAccess;
+	b.dstAccessMask = dstAccess;
+	b.srcQueueFamilyIndex = VK_QUEUE_FAMILY_IGNORED;
+	b.dstQueueFamilyIndex = VK_QUEUE_FAMILY_IGNORED;
+	b.image = img;
+	b.subresourceRange.aspectMask = VK_IMAGE_ASPECT_COLOR_BIT;
+	b.subresourceRange.levelCount = 1;
+	b.subresourceRange.layerCount = 1;
+	vkCmdPipelineBarrier(cb, VK_PIPELINE_STAGE_TRANSFER_BIT, VK_PIPELINE_STAGE_TRANSFER_BIT, 0,
+		0, NULL, 0, NULL, 1, &b);
+}
+
+// gt_decode uploads the blocks as an image of format src, blits it to an RGBA8 image of format
+// dst and reads the texels back into out (w*h*4 bytes).
+static VkResult gt_decode(gt_device* d, VkFormat src, VkFormat dst, const void* blocks, size_t blocksLen,
+	uint32_t w, uint32_t h, void* out) {
+	VkFormatProperties fp;
+	vkGetPhysicalDeviceFormatProperties(d->phys, src, &fp);
+	if (!(fp.optimalTilingFeatures & VK_FORMAT_FEATURE_BLIT_SRC_BIT)) {
+		return VK_ERROR_FORMAT_NOT_SUPPORTED;
+	}
+
+	VkResult r;
+	VkBuffer buf = VK_NULL_HANDLE;
+	VkDeviceMemory bufMem = VK_NULL_HANDLE, srcMem = VK_NULL_HANDLE, dstMem = VK_NULL_HANDLE;
+	VkImage srcImg = VK_NULL_HANDLE, dstImg = VK_NULL_HANDLE;
+	VkCommandBuffer cb = VK_NULL_HANDLE;
+	VkFence fence = VK_NULL_HANDLE;
+	void* mapped = NULL;
+	size_t outLen = (size_t)w * h * 4;
+
+	VkBufferCreateInfo bci = {VK_STRUCTURE_TYPE_BUFFER_CREATE_INFO};
+	bci.size = blocksLen > outLen ? blocksLen : outLen;
+	bci.usage = VK_BUFFER_USAGE_TRANSFER_SRC_BIT | VK_BUFFER_USAGE_TRANSFER_DST_BIT;
+	bci.sharingMode = VK_SHARING_MODE_EXCLUSIVE;
+	if ((r = vkCreateBuffer(d->device, &bci, NULL, &buf)) != VK_SUCCESS) goto done;
+	VkMemoryRequirements req;
+	vkGetBufferMemoryRequirements(d->device, buf, &req);
+	int type = gt_memtype(d, req.memoryTypeBits, VK_MEMORY_PROPERTY_HOST_VISIBLE_BIT | VK_MEMORY_PROPERTY_HOST_COHERENT_BIT);
+	if (type < 0) {
+		r = VK_ERROR_OUT_OF_HOST_MEMORY;
+		goto done;
+	}
+	VkMemoryAllocateInfo mai = {VK_STRUCTURE_TYPE_MEMORY_ALLOCATE_INFO};
+	mai.allocationSize = req.size;
+	mai.memoryTypeIndex = (uint32_t)type;
+	if ((r = vkAllocateMemory(d->device, &mai, NULL, &bufMem)) != VK_SUCCESS) goto done;
+	if ((r = vkBindBufferMemory(d->device, buf, bufMem, 0)) != VK_SUCCESS) goto done;
+	if ((r = vkMapMemory(d->device, bufMem, 0, VK_WHOLE_SIZE, 0, &mapped)) != VK_SUCCESS) goto done;
+	memcpy(mapped, blocks, blocksLen);
+
+	if ((r = gt_image(d, src, w, h, &srcImg, &srcMem)) != VK_SUCCESS) goto done;
+	if ((r = gt_image(d, dst, w, h, &dstImg, &dstMem)) != VK_SUCCESS) goto done;
+
+	VkCommandBufferAllocateInfo cbi = {VK_STRUCTURE_TYPE_COMMAND_BUFFER_ALLOCATE_INFO};
+	cbi.commandPool = d->pool;
+	cbi.level = VK_COMMAND_BUFFER_LEVEL_PRIMARY;
+	cbi.commandBufferCount = 1;
+	if ((r = vkAllocateCommandBuffers(d->device, &cbi, &cb)) != VK_SUCCESS) goto done;
+	VkCommandBufferBeginInfo begin = {VK_STRUCTURE_TYPE_COMMAND_BUFFER_BEGIN_INFO};
+	begin.flags = VK_COMMAND_BUFFER_USAGE_ONE_TIME_SUBMIT_BIT;
+	if ((r = vkBeginCommandBuffer(cb, &begin)) != VK_SUCCESS) goto done;
+
+	VkBufferImageCopy copy;
+	memset(&copy, 0, sizeof(copy));
+	copy.imageSubresource.aspectMask = VK_IMAGE_ASPECT_COLOR_BIT;
+	copy.imageSubresource.layerCount = 1;
+	copy.imageExtent.width = w;
+	copy.imageExtent.height = h;
+	copy.imageExtent.depth = 1;
+
+	gt_barrier(cb, srcImg, VK_IMAGE_LAYOUT_UNDEFINED, VK_IMAGE_LAYOUT_TRANSFER_DST_OPTIMAL, 0, VK_ACCESS_TRANSFER_WRITE_BIT);
+	vkCmdCopyBufferToImage(cb, buf, srcImg, VK_IMAGE_LAYOUT_TRANSFER_DST_OPTIMAL, 1, &copy);
+	gt_barrier(cb, srcImg, VK_IMAGE_LAYOUT_TRANSFER_DST_OPTIMAL, VK_IMAGE_LAYOUT_TRANSFER_SRC_OPTIMAL,
+		VK_ACCESS_TRANSFER_WRITE_BIT, VK_ACCESS_TRANSFER_READ_BIT);
+	gt_barrier(cb, dstImg, VK_IMAGE_LAYOUT_UNDEFINED, VK_IMAGE_LAYOUT_TRANSFER_DST_OPTIMAL, 0, VK_ACCESS_TRANSFER_WRITE_BIT);
+
+	// A blit between equal extents copies texels 1:1, converting the format.
+	VkImageBlit blit;
+	memset(&blit, 0, sizeof(blit));
+	blit.srcSubresource.aspectMask = VK_IMAGE_ASPECT_COLOR_BIT;
+	blit.srcSubresource.layerCount = 1;
+	blit.srcOffsets[1].x = (int32_t)w;
+	blit.srcOffsets[1].y = (int32_t)h;
+	blit.srcOffsets[1].z = 1;
+	blit.dstSubresource = blit.srcSubresource;
+	blit.dstOffsets[1] = blit.srcOffsets[1];
+	vkCmdBlitImage(cb, srcImg, VK_IMAGE_LAYOUT_TRANSFER_SRC_OPTIMAL, dstImg, VK_IMAGE_LAYOUT_TRANSFER_DST_OPTIMAL,
+		1, &blit, VK_FILTER_NEAREST);
+
+	gt_barrier(cb, dstImg, VK_IMAGE_LAYOUT_TRANSFER_DST_OPTIMAL, VK_IMAGE_LAYOUT_TRANSFER_SRC_OPTIMAL,
+		VK_ACCESS_TRANSFER_WRITE_BIT, VK_ACCESS_TRANSFER_READ_BIT);
+	vkCmdCopyImageToBuffer(cb, dstImg, VK_IMAGE_LAYOUT_TRANSFER_SRC_OPTIMAL, buf, 1, &copy);
+
+	VkBufferMemoryBarrier hb = {VK_STRUCTURE_TYPE_BUFFER_MEMORY_BARRIER};
+	hb.srcAccessMask = VK_ACCESS_TRANSFER_WRITE_BIT;
+	hb.dstAccessMask = VK_ACCESS_HOST_READ_BIT;
+	hb.srcQueueFamilyIndex = VK_QUEUE_FAMILY_IGNORED;
+	hb.dstQueueFamilyIndex = VK_QUEUE_FAMILY_IGNORED;
+	hb.buffer = buf;
+	hb.size = VK_WHOLE_SIZE;
+	vkCmdPipelineBarrier(cb, VK_PIPELINE_STAGE_TRANSFER_BIT, VK_PIPELINE_STAGE_HOST_BIT, 0, 0, NULL, 1, &hb, 0, NULL);
+	if ((r = vkEndCommandBuffer(cb)) != VK_SUCCESS) goto done;
+
+	VkFenceCreateInfo fci = {VK_STRUCTURE_TYPE_FENCE_CREATE_INFO};
+	if ((r = vkCreateFence(d->device, &fci, NULL, &fence)) != VK_SUCCESS) goto done;
+	VkSubmitInfo si = {VK_STRUCTURE_TYPE_SUBMIT_INFO};
+	si.commandBufferCount = 1;
+	si.pCommandBuffers = &cb;
+	if ((r = vkQueueSubmit(d->queue, 1, &si, fence)) != VK_SUCCESS) goto done;
+	if ((r = vkWaitForFences(d->device, 1, &fence, VK_TRUE, UINT64_MAX)) != VK_SUCCESS) goto done;
+	memcpy(out, mapped, outLen);
+
+done:
+	if (fence) vkDestroyFence(d->device, fence, NULL);
+	if (cb) vkFreeCommandBuffers(d->device, d->pool, 1, &cb);
+	if (dstImg) vkDestroyImage(d->device, dstImg, NULL);
+	if (dstMem) vkFreeMemory(d->device, dstMem, NULL);
+	if (srcImg) vkDestroyImage(d->device, srcImg, NULL);
+	if (srcMem) vkFreeMemory(d->device, srcMem, NULL);
+	if (buf) vkDestroyBuffer(d->device, buf, NULL);
+	if (bufMem) vkFreeMemory(d->device, bufMem, NULL);
+	return r;
+}
+*/
+import "C"
+
+import (
+	"fmt"
+	"unsafe"
+
+	"github.com/arm-software/astc-encoder/astc"
+)
+
+// vkFormatASTC4x4UNORM is VK_FORMAT_ASTC_4x4_UNORM_BLOCK. The 2D ASTC formats follow it in
+// Footprints2D order, each UNORM format followed by its SRGB variant.
+const vkFormatASTC4x4UNORM = 157
+
+type vulkanDevice struct {
+	d C.gt_device
+}
+
+func openBackend() (Device, error) {
+	dev := &vulkanDevice{}
+	if r := C.gt_open(&dev.d); r != C.VK_SUCCESS {
+		return nil, fmt.Errorf("astc/gputest: vulkan: no usable device (VkResult %d)", int(r))
+	}
+	return dev, nil
+}
+
+func (v *vulkanDevice) Name() string {
+	return "vulkan: " + C.GoString(&v.d.name[0])
+}
+
+func (v *vulkanDevice) DecodeRGBA8(blocks []byte, width, height, blockX, blockY int, srgb bool) ([]byte, error) {
+	idx := -1
+	for i, fp := range Footprints2D {
+		if fp == [2]int{blockX, blockY} {
+			idx = i
+		}
+	}
+	if idx < 0 || width <= 0 || height <= 0 {
+		return nil, ErrUnsupported
+	}
+	need := ((width + blockX - 1) / blockX) * ((height + blockY - 1) / blockY) * astc.BlockBytes
+	if len(blocks) < need {
+		return nil, fmt.Errorf("astc/gputest: block buffer too small")
+	}
+	src := C.VkFormat(vkFormatASTC4x4UNORM + 2*idx)
+	dst := C.VkFormat(C.VK_FORMAT_R8G8B8A8_UNORM)
+	if srgb {
+		src++
+		dst = C.VK_FORMAT_R8G8B8A8_SRGB
+	}
+
+	out := make([]byte, width*height*4)
+	cBlocks := C.CBytes(blocks[:need])
+	defer C.free(cBlocks)
+	cOut := C.malloc(C.size_t(len(out)))
+	defer C.free(cOut)
+	r := C.gt_decode(&v.d, src, dst, cBlocks, C.size_t(need), C.uint32_t(width), C.uint32_t(height), cOut)
+	if r == C.VK_ERROR_FORMAT_NOT_SUPPORTED {
+		return nil, ErrUnsupported
+	}
+	if r != C.VK_SUCCESS {
+		return nil, fmt.Errorf("astc/gputest: vulkan: decode failed (VkResult %d)", int(r))
+	}
+	copy(out, unsafe.Slice((*byte)(cOut), len(out)))
+	return out, nil
+}
+
+func (v *vulkanDevice) Close() error {
+	C.gt_close(&v.d)
+	return nil
+}
@@ -0,0 +1,40 @@
+//go:build gputest_vulkan && cgo
+
+package gputest_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/arm-software/astc-encoder/astc/gputest"
+)
+
+// TestDevice_MatchesCPU decodes the standard cases on the first Vulkan GPU and logs the
+// divergence report. It fails on differences larger than the one code expected from the FP16
+// decode path.
+func TestDevice_MatchesCPU(t *testing.T) {
+	dev, err := gputest.Open()
+	if err != nil {
+		t.Skipf("Open: %v", err)
+	}
+	defer dev.Close()
+
+	cases, err := gputest.StandardCases()
+	if err != nil {
+		t.Fatalf("StandardCases: %v", err)
+	}
+	report, err := gputest.Run(dev, cases)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	var sb strings.Builder
+	if err := report.WriteText(&sb); err != nil {
+		t.Fatalf("WriteText: %v", err)
+	}
+	t.Log("\n" + sb.String())
+	for _, res := range report.Results {
+		if res.MaxDiff > 1 {
+			t.Errorf("%s: max diff %d", res.Case, res.MaxDiff)
+		}
+	}
+}
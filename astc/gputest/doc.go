@@ -0,0 +1,21 @@
+// Package gputest compares the ASTC decoder of a GPU with the pure-Go decoder, for platform
+// bring-up teams certifying that the Go decoder models their hardware.
+//
+// A Device uploads a block payload to the GPU as an ASTC texture, reads back the decoded texels as
+// RGBA8, and Run compares them with astc.DecodeRGBA8VolumeFromParsedWithProfileInto, producing a
+// Report of the texels that diverge. StandardCases provides encoded test content and random block
+// payloads (including reserved and error encodings) for every 2D block size.
+//
+// GPU backends are optional and build-tagged, because they need cgo and the platform graphics
+// SDK:
+//
+//   - gputest_vulkan: Vulkan 1.0 through the system loader (libvulkan; on darwin the Vulkan SDK
+//     with MoltenVK, which also covers Metal devices). Build and run the harness with
+//     CGO_ENABLED=1 go test -tags gputest_vulkan -v ./astc/gputest
+//
+// Without a backend, Open returns ErrNoBackend. Run works with any Device implementation.
+//
+// GPUs decode LDR ASTC to FP16 before the conversion to RGBA8 done by the readback, so diffs of
+// one code are expected unless the device implements the decode_unorm8 rounding; the report lists
+// the largest difference per case for that reason.
+package gputest
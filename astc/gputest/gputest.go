@@ -0,0 +1,233 @@
+package gputest
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+
+	"github.com/arm-software/astc-encoder/astc"
+	"github.com/arm-software/astc-encoder/astc/testimage"
+)
+
+// ErrNoBackend is returned by Open when the package is built without a GPU backend.
+var ErrNoBackend = errors.New("astc/gputest: no GPU backend (build with -tags gputest_vulkan and CGO_ENABLED=1)")
+
+// ErrUnsupported is returned by Device.DecodeRGBA8 for block sizes or profiles the device cannot
+// decode. Run records such cases as skipped.
+var ErrUnsupported = errors.New("astc/gputest: format not supported by device")
+
+// Device decodes 2D ASTC block payloads on a GPU.
+type Device interface {
+	// Name describes the device, e.g. the driver-reported GPU name.
+	Name() string
+	// DecodeRGBA8 decodes the blocks of a width x height image into RGBA8 texels. srgb selects
+	// the sRGB ASTC formats; the texels are then returned sRGB-encoded.
+	DecodeRGBA8(blocks []byte, width, height, blockX, blockY int, srgb bool) ([]byte, error)
+	// Close releases the device.
+	Close() error
+}
+
+// Open opens the first GPU of the backend selected at build time.
+func Open() (Device, error) {
+	return openBackend()
+}
+
+// Case is one block payload to decode on the device and the CPU.
+type Case struct {
+	Name          string
+	Width, Height int
+	BlockX        int
+	BlockY        int
+	// Profile is ProfileLDR or ProfileLDRSRGB.
+	Profile astc.Profile
+	Blocks  []byte
+}
+
+// Divergence is a texel decoded differently by the device and the CPU.
+type Divergence struct {
+	X, Y int
+	GPU  [4]uint8
+	CPU  [4]uint8
+}
+
+// maxDivergences is the number of Divergence entries kept per case.
+const maxDivergences = 16
+
+// CaseResult is the comparison of one Case.
+type CaseResult struct {
+	Case string
+	// Skipped is the reason the device did not decode the case, or empty.
+	Skipped string
+	Texels  int
+	// Mismatched is the number of texels with any channel differing; MaxDiff is the largest
+	// channel difference.
+	Mismatched int
+	MaxDiff    int
+	// First lists up to 16 mismatched texels in scan order.
+	First []Divergence
+}
+
+// Report is the result of Run.
+type Report struct {
+	Device  string
+	Results []CaseResult
+}
+
+// OK reports whether every case that was decoded matched exactly.
+func (r *Report) OK() bool {
+	for _, c := range r.Results {
+		if c.Mismatched != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// WriteText writes a human-readable summary of the report, one line per case followed by its
+// first divergences.
+func (r *Report) WriteText(w io.Writer) error {
+	if _, err := fmt.Fprintf(w, "device: %s\n", r.Device); err != nil {
+		return err
+	}
+	for _, c := range r.Results {
+		var err error
+		switch {
+		case c.Skipped != "":
+			_, err = fmt.Fprintf(w, "%-28s skipped: %s\n", c.Case, c.Skipped)
+		case c.Mismatched == 0:
+			_, err = fmt.Fprintf(w, "%-28s ok (%d texels)\n", c.Case, c.Texels)
+		default:
+			_, err = fmt.Fprintf(w, "%-28s %d/%d texels differ, max diff %d\n", c.Case, c.Mismatched, c.Texels, c.MaxDiff)
+		}
+		if err != nil {
+			return err
+		}
+		for _, d := range c.First {
+			if _, err := fmt.Fprintf(w, "    (%d,%d) gpu=%v cpu=%v\n", d.X, d.Y, d.GPU, d.CPU); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Run decodes every case on dev and on the CPU and compares the texels. Cases the device reports
+// as ErrUnsupported are recorded as skipped; other device errors stop the run.
+func Run(dev Device, cases []Case) (*Report, error) {
+	if dev == nil {
+		return nil, errors.New("astc/gputest: nil device")
+	}
+	r := &Report{Device: dev.Name()}
+	for _, c := range cases {
+		res, err := runCase(dev, c)
+		if err != nil {
+			return nil, fmt.Errorf("astc/gputest: case %s: %w", c.Name, err)
+		}
+		r.Results = append(r.Results, res)
+	}
+	return r, nil
+}
+
+func runCase(dev Device, c Case) (CaseResult, error) {
+	res := CaseResult{Case: c.Name, Texels: c.Width * c.Height}
+	if c.Profile != astc.ProfileLDR && c.Profile != astc.ProfileLDRSRGB {
+		return res, errors.New("only LDR profiles are supported")
+	}
+	h := astc.Header{
+		BlockX: uint8(c.BlockX), BlockY: uint8(c.BlockY), BlockZ: 1,
+		SizeX: uint32(c.Width), SizeY: uint32(c.Height), SizeZ: 1,
+	}
+	cpu := make([]byte, c.Width*c.Height*4)
+	if err := astc.DecodeRGBA8VolumeFromParsedWithProfileInto(c.Profile, h, c.Blocks, cpu); err != nil {
+		return res, err
+	}
+	gpu, err := dev.DecodeRGBA8(c.Blocks, c.Width, c.Height, c.BlockX, c.BlockY, c.Profile == astc.ProfileLDRSRGB)
+	if errors.Is(err, ErrUnsupported) {
+		res.Skipped = err.Error()
+		return res, nil
+	}
+	if err != nil {
+		return res, err
+	}
+	if len(gpu) != len(cpu) {
+		return res, fmt.Errorf("device returned %d bytes, want %d", len(gpu), len(cpu))
+	}
+
+	for i := 0; i < len(cpu); i += 4 {
+		diff := 0
+		for ch := 0; ch < 4; ch++ {
+			diff = max(diff, absInt(int(gpu[i+ch])-int(cpu[i+ch])))
+		}
+		if diff == 0 {
+			continue
+		}
+		res.Mismatched++
+		res.MaxDiff = max(res.MaxDiff, diff)
+		if len(res.First) < maxDivergences {
+			t := i / 4
+			d := Divergence{X: t % c.Width, Y: t / c.Width}
+			copy(d.GPU[:], gpu[i:i+4])
+			copy(d.CPU[:], cpu[i:i+4])
+			res.First = append(res.First, d)
+		}
+	}
+	return res, nil
+}
+
+func absInt(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// Footprints2D lists the 2D ASTC block sizes, in the order of astc.Footprints2D.
+var Footprints2D = astc.Footprints2D()
+
+// StandardCases returns, for every 2D block size and both LDR profiles, gradient, noise and
+// alpha-cutout test images encoded with the pure-Go encoder, plus a payload of random 128-bit
+// blocks that exercises reserved encodings and the error color. The cases are deterministic.
+func StandardCases() ([]Case, error) {
+	const w, h = 64, 64
+	kinds := []testimage.Kind{testimage.KindGradient, testimage.KindPerlin, testimage.KindAlphaCutout}
+	var cases []Case
+	for _, fp := range Footprints2D {
+		bx, by := fp[0], fp[1]
+		for _, profile := range []astc.Profile{astc.ProfileLDR, astc.ProfileLDRSRGB} {
+			suffix := "ldr"
+			if profile == astc.ProfileLDRSRGB {
+				suffix = "srgb"
+			}
+			enc, err := astc.NewEncoder(astc.WithBlockSize(bx, by), astc.WithProfile(profile), astc.WithQuality(astc.EncodeFast))
+			if err != nil {
+				return nil, err
+			}
+			for _, k := range kinds {
+				pix, err := testimage.RGBA8(k, w, h, 1, testimage.Options{})
+				if err != nil {
+					return nil, err
+				}
+				file, err := enc.Encode(&astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeU8, DataU8: pix})
+				if err != nil {
+					return nil, err
+				}
+				cases = append(cases, Case{
+					Name:  fmt.Sprintf("%dx%d/%s/%s", bx, by, suffix, k),
+					Width: w, Height: h, BlockX: bx, BlockY: by, Profile: profile,
+					Blocks: file[astc.HeaderSize:],
+				})
+			}
+
+			rng := rand.New(rand.NewSource(int64(bx*100 + by*10 + int(profile))))
+			blocks := make([]byte, ((w+bx-1)/bx)*((h+by-1)/by)*astc.BlockBytes)
+			rng.Read(blocks)
+			cases = append(cases, Case{
+				Name:  fmt.Sprintf("%dx%d/%s/random", bx, by, suffix),
+				Width: w, Height: h, BlockX: bx, BlockY: by, Profile: profile,
+				Blocks: blocks,
+			})
+		}
+	}
+	return cases, nil
+}
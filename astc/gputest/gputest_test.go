@@ -0,0 +1,93 @@
+package gputest_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/arm-software/astc-encoder/astc"
+	"github.com/arm-software/astc-encoder/astc/gputest"
+)
+
+// cpuDevice decodes with the pure-Go decoder, corrupting one texel of every 8x8 case and refusing
+// 12x12.
+type cpuDevice struct{}
+
+func (cpuDevice) Name() string { return "cpu" }
+func (cpuDevice) Close() error { return nil }
+
+func (cpuDevice) DecodeRGBA8(blocks []byte, width, height, blockX, blockY int, srgb bool) ([]byte, error) {
+	if blockX == 12 && blockY == 12 {
+		return nil, gputest.ErrUnsupported
+	}
+	profile := astc.ProfileLDR
+	if srgb {
+		profile = astc.ProfileLDRSRGB
+	}
+	h := astc.Header{BlockX: uint8(blockX), BlockY: uint8(blockY), BlockZ: 1, SizeX: uint32(width), SizeY: uint32(height), SizeZ: 1}
+	out := make([]byte, width*height*4)
+	if err := astc.DecodeRGBA8VolumeFromParsedWithProfileInto(profile, h, blocks, out); err != nil {
+		return nil, err
+	}
+	if blockX == 8 && blockY == 8 {
+		i := (3*width + 5) * 4
+		out[i+1] ^= 0x04
+	}
+	return out, nil
+}
+
+func TestRun(t *testing.T) {
+	cases, err := gputest.StandardCases()
+	if err != nil {
+		t.Fatalf("StandardCases: %v", err)
+	}
+	if want := len(gputest.Footprints2D) * 2 * 4; len(cases) != want {
+		t.Fatalf("StandardCases returned %d cases, want %d", len(cases), want)
+	}
+
+	report, err := gputest.Run(cpuDevice{}, cases)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if report.OK() {
+		t.Fatalf("report is OK despite corrupted texels")
+	}
+	for _, res := range report.Results {
+		switch {
+		case strings.HasPrefix(res.Case, "12x12/"):
+			if res.Skipped == "" {
+				t.Fatalf("%s: not skipped", res.Case)
+			}
+		case strings.HasPrefix(res.Case, "8x8/"):
+			if res.Mismatched != 1 || res.MaxDiff != 4 || len(res.First) != 1 || res.First[0].X != 5 || res.First[0].Y != 3 {
+				t.Fatalf("%s: %+v", res.Case, res)
+			}
+		default:
+			if res.Mismatched != 0 || res.Skipped != "" {
+				t.Fatalf("%s: %+v", res.Case, res)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := report.WriteText(&buf); err != nil {
+		t.Fatalf("WriteText: %v", err)
+	}
+	for _, want := range []string{"device: cpu", "8x8/ldr/gradient", "1/4096 texels differ, max diff 4", "(5,3)", "skipped"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Fatalf("report lacks %q:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestOpen_NoBackend(t *testing.T) {
+	dev, err := gputest.Open()
+	if err == nil {
+		dev.Close()
+		t.Skip("built with a GPU backend")
+	}
+	if !errors.Is(err, gputest.ErrNoBackend) {
+		t.Skipf("no usable GPU: %v", err)
+	}
+}
@@ -0,0 +1,134 @@
+package astc
+
+import "math"
+
+// HalfToFloat32 converts an IEEE 754 binary16 value to float32. Every half value, including
+// subnormals and infinities, is exactly representable, so the conversion is exact. A NaN keeps its
+// sign and payload and is returned quiet, as x86 F16C and Arm FCVT do.
+func HalfToFloat32(h uint16) float32 {
+	sign := uint32(h>>15) & 0x1
+	exp := uint32(h>>10) & 0x1F
+	mant := uint32(h) & 0x3FF
+
+	switch exp {
+	case 0:
+		if mant == 0 {
+			return math.Float32frombits(sign << 31)
+		}
+		// Subnormal -> normalized float32.
+		// Scale mantissa into float32 and adjust exponent.
+		e := int32(-14)
+		for (mant & 0x400) == 0 {
+			mant <<= 1
+			e--
+		}
+		mant &= 0x3FF
+		exp32 := uint32(e + 127)
+		mant32 := mant << 13
+		return math.Float32frombits((sign << 31) | (exp32 << 23) | mant32)
+	case 0x1F:
+		if mant == 0 {
+			return math.Float32frombits((sign << 31) | 0x7F800000)
+		}
+		return math.Float32frombits((sign << 31) | 0x7FC00000 | (mant << 13))
+	default:
+		// Normal number.
+		exp32 := exp + (127 - 15)
+		mant32 := mant << 13
+		return math.Float32frombits((sign << 31) | (exp32 << 23) | mant32)
+	}
+}
+
+// Float32ToHalf converts f to IEEE 754 binary16, rounding to nearest with ties to even, the
+// rounding used by x86 F16C (VCVTPS2PH with the default MXCSR mode) and Arm FCVT. Values too large
+// for a half become infinity, values too small become a signed zero or a subnormal, and a NaN
+// becomes a quiet NaN keeping its sign and the top bits of its payload.
+func Float32ToHalf(f float32) uint16 {
+	bits := math.Float32bits(f)
+	sign := uint16((bits >> 16) & 0x8000)
+	exp := int32((bits >> 23) & 0xFF)
+	mant := bits & 0x7FFFFF
+
+	// Inf/NaN.
+	if exp == 0xFF {
+		if mant == 0 {
+			return sign | 0x7C00
+		}
+		return sign | 0x7E00 | uint16(mant>>13)
+	}
+
+	// Convert exponent bias from 127 to 15.
+	exp = exp - 127 + 15
+
+	// Subnormals/underflow.
+	if exp <= 0 {
+		if exp < -10 {
+			// Below half the smallest subnormal -> signed zero.
+			return sign
+		}
+		// Shift the significand, implicit leading 1 included, into subnormal position. Rounding
+		// up out of the largest subnormal yields the smallest normal, which is the right encoding.
+		return sign | uint16(roundShiftEven(mant|0x800000, uint32(14-exp)))
+	}
+
+	// Overflow -> inf.
+	if exp >= 0x1F {
+		return sign | 0x7C00
+	}
+
+	// Rounding carries out of the mantissa into the exponent, and out of the largest finite
+	// value into infinity, by plain addition on the packed value.
+	return sign | uint16(roundShiftEven(uint32(exp)<<23|mant, 13))
+}
+
+// roundShiftEven returns v >> shift rounded to nearest, ties to even.
+func roundShiftEven(v, shift uint32) uint32 {
+	q := v >> shift
+	rem := v & (1<<shift - 1)
+	half := uint32(1) << (shift - 1)
+	if rem > half || (rem == half && q&1 != 0) {
+		q++
+	}
+	return q
+}
+
+// ConvertF32ToF16Slice converts src to binary16 with Float32ToHalf and stores the results in dst.
+// Like copy, it converts min(len(dst), len(src)) values and returns that count. The loop works in
+// groups of four, one RGBA texel at a time, with a single bounds check per group.
+func ConvertF32ToF16Slice(dst []uint16, src []float32) int {
+	n := min(len(dst), len(src))
+	dst, src = dst[:n], src[:n]
+	i := 0
+	for ; i+4 <= n; i += 4 {
+		s := src[i : i+4 : i+4]
+		d := dst[i : i+4 : i+4]
+		d[0] = Float32ToHalf(s[0])
+		d[1] = Float32ToHalf(s[1])
+		d[2] = Float32ToHalf(s[2])
+		d[3] = Float32ToHalf(s[3])
+	}
+	for ; i < n; i++ {
+		dst[i] = Float32ToHalf(src[i])
+	}
+	return n
+}
+
+// ConvertF16ToF32Slice converts binary16 values in src to float32 with HalfToFloat32 and stores
+// the results in dst. Like copy, it converts min(len(dst), len(src)) values and returns that count.
+func ConvertF16ToF32Slice(dst []float32, src []uint16) int {
+	n := min(len(dst), len(src))
+	dst, src = dst[:n], src[:n]
+	i := 0
+	for ; i+4 <= n; i += 4 {
+		s := src[i : i+4 : i+4]
+		d := dst[i : i+4 : i+4]
+		d[0] = HalfToFloat32(s[0])
+		d[1] = HalfToFloat32(s[1])
+		d[2] = HalfToFloat32(s[2])
+		d[3] = HalfToFloat32(s[3])
+	}
+	for ; i < n; i++ {
+		dst[i] = HalfToFloat32(src[i])
+	}
+	return n
+}
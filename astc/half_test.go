@@ -0,0 +1,87 @@
+package astc_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/arm-software/astc-encoder/astc"
+)
+
+func TestHalfRoundTrip(t *testing.T) {
+	for h := 0; h <= 0xFFFF; h++ {
+		f := astc.HalfToFloat32(uint16(h))
+		got := astc.Float32ToHalf(f)
+		if h&0x7C00 == 0x7C00 && h&0x3FF != 0 {
+			// NaN: sign and payload survive, and the result is quiet.
+			if want := uint16(h) | 0x200; got != want {
+				t.Fatalf("NaN %#04x -> %#04x, want %#04x", h, got, want)
+			}
+			continue
+		}
+		if got != uint16(h) {
+			t.Fatalf("%#04x -> %g -> %#04x", h, f, got)
+		}
+	}
+}
+
+func TestFloat32ToHalf_RoundsToNearestEven(t *testing.T) {
+	// Check every pair of adjacent positive finite halves, and the gap between the largest finite
+	// half and infinity: the midpoint goes to the even neighbour, anything past it to the nearer.
+	for h := uint16(0); h < 0x7C00; h++ {
+		lo := float64(astc.HalfToFloat32(h))
+		var hi float64
+		if h+1 == 0x7C00 {
+			hi = 65536 // where the next half would be with an unbounded exponent
+		} else {
+			hi = float64(astc.HalfToFloat32(h + 1))
+		}
+		mid := float32((lo + hi) / 2)
+		even := h
+		if h&1 != 0 {
+			even = h + 1
+		}
+		for _, c := range []struct {
+			f    float32
+			want uint16
+		}{
+			{mid, even},
+			{math.Nextafter32(mid, 0), h},
+			{math.Nextafter32(mid, float32(math.Inf(1))), h + 1},
+		} {
+			if got := astc.Float32ToHalf(c.f); got != c.want {
+				t.Fatalf("Float32ToHalf(%g) = %#04x, want %#04x", c.f, got, c.want)
+			}
+			if got := astc.Float32ToHalf(-c.f); got != c.want|0x8000 {
+				t.Fatalf("Float32ToHalf(%g) = %#04x, want %#04x", -c.f, got, c.want|0x8000)
+			}
+		}
+	}
+	if got := astc.Float32ToHalf(1e-30); got != 0 {
+		t.Fatalf("Float32ToHalf(1e-30) = %#04x, want 0", got)
+	}
+	if got := astc.Float32ToHalf(float32(math.NaN())); got&0x7E00 != 0x7E00 {
+		t.Fatalf("Float32ToHalf(NaN) = %#04x, want a quiet NaN", got)
+	}
+}
+
+func TestConvertF32ToF16Slice(t *testing.T) {
+	src := []float32{0, 1, -2, 0.1, 65504, 70000, 1e-6, float32(math.Inf(-1)), 0.5, 3}
+	dst := make([]uint16, len(src)+2)
+	if n := astc.ConvertF32ToF16Slice(dst, src); n != len(src) {
+		t.Fatalf("converted %d values, want %d", n, len(src))
+	}
+	for i, f := range src {
+		if want := astc.Float32ToHalf(f); dst[i] != want {
+			t.Fatalf("dst[%d] = %#04x, want %#04x", i, dst[i], want)
+		}
+	}
+	back := make([]float32, 3)
+	if n := astc.ConvertF16ToF32Slice(back, dst); n != 3 {
+		t.Fatalf("converted %d values, want 3", n)
+	}
+	for i := range back {
+		if want := astc.HalfToFloat32(dst[i]); back[i] != want {
+			t.Fatalf("back[%d] = %g, want %g", i, back[i], want)
+		}
+	}
+}
@@ -32,15 +32,15 @@ func decodeConstBlockRGBAF32(t *testing.T, block []byte) [4]float32 {
 
 	var out [4]float32
 	if isU16 {
-		out[0] = halfToFloat32(unorm16ToSF16(r))
-		out[1] = halfToFloat32(unorm16ToSF16(g))
-		out[2] = halfToFloat32(unorm16ToSF16(b))
-		out[3] = halfToFloat32(unorm16ToSF16(a))
+		out[0] = astc.HalfToFloat32(unorm16ToSF16(r))
+		out[1] = astc.HalfToFloat32(unorm16ToSF16(g))
+		out[2] = astc.HalfToFloat32(unorm16ToSF16(b))
+		out[3] = astc.HalfToFloat32(unorm16ToSF16(a))
 	} else {
-		out[0] = halfToFloat32(r)
-		out[1] = halfToFloat32(g)
-		out[2] = halfToFloat32(b)
-		out[3] = halfToFloat32(a)
+		out[0] = astc.HalfToFloat32(r)
+		out[1] = astc.HalfToFloat32(g)
+		out[2] = astc.HalfToFloat32(b)
+		out[3] = astc.HalfToFloat32(a)
 	}
 	return out
 }
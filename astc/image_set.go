@@ -0,0 +1,245 @@
+package astc
+
+import (
+	"math"
+	"math/bits"
+)
+
+// ImageSet describes the subresources of a texture (mip levels, array layers and cubemap faces)
+// stored in one backing buffer, like a KTX2 file or a GPU upload buffer. Subresources are stored
+// level by level from the base level; within a level, layer by layer; within a layer, face by
+// face. Each subresource is a tightly packed RGBA image of its level's dimensions.
+//
+// Image returns a view of one subresource for CompressImage and DecompressImage, and
+// Context.CompressSubresource and Context.DecompressSubresource map subresources to their blocks
+// in a payload holding the blocks of every subresource in the same order.
+type ImageSet struct {
+	// DimX, DimY and DimZ are the base level dimensions. Level n has dimensions max(1, dim>>n).
+	DimX int
+	DimY int
+	DimZ int
+
+	Levels int
+	Layers int
+	// Faces is 1, or CubeFaceCount for cubemaps (square 2D faces).
+	Faces int
+
+	DataType DataType
+	DataU8   []byte
+	DataF16  []uint16
+	DataF32  []float32
+}
+
+// Subresource selects one image of an ImageSet.
+type Subresource struct {
+	Level int
+	Layer int
+	Face  CubeFace
+}
+
+// MaxMipLevels returns the number of levels of a full mip chain for the given base dimensions.
+func MaxMipLevels(dimX, dimY, dimZ int) int {
+	return bits.Len(uint(max(dimX, dimY, dimZ, 1)))
+}
+
+// NewImageSet allocates an ImageSet with a zeroed backing buffer. Levels 0 selects a full mip
+// chain.
+func NewImageSet(dataType DataType, dimX, dimY, dimZ, levels, layers, faces int) (*ImageSet, error) {
+	if levels == 0 {
+		levels = MaxMipLevels(dimX, dimY, dimZ)
+	}
+	s := &ImageSet{DimX: dimX, DimY: dimY, DimZ: dimZ, Levels: levels, Layers: layers, Faces: faces, DataType: dataType}
+	if err := s.validateLayout(); err != nil {
+		return nil, err
+	}
+	n, _ := s.texelCount()
+	n *= 4
+	switch dataType {
+	case TypeU8:
+		s.DataU8 = make([]byte, n)
+	case TypeF16:
+		s.DataF16 = make([]uint16, n)
+	case TypeF32:
+		s.DataF32 = make([]float32, n)
+	default:
+		return nil, newError(ErrBadParam, "astc: unknown image data type")
+	}
+	return s, nil
+}
+
+// LevelDims returns the dimensions of the images of a mip level.
+func (s *ImageSet) LevelDims(level int) (dimX, dimY, dimZ int) {
+	return max(s.DimX>>level, 1), max(s.DimY>>level, 1), max(s.DimZ>>level, 1)
+}
+
+func (s *ImageSet) validateLayout() error {
+	if s.DimX <= 0 || s.DimY <= 0 || s.DimZ <= 0 || s.Layers <= 0 {
+		return newError(ErrBadParam, "astc: invalid image set dimensions")
+	}
+	if s.Levels <= 0 || s.Levels > MaxMipLevels(s.DimX, s.DimY, s.DimZ) {
+		return newError(ErrBadParam, "astc: invalid image set level count")
+	}
+	switch s.Faces {
+	case 1:
+	case CubeFaceCount:
+		if s.DimX != s.DimY || s.DimZ != 1 {
+			return newError(ErrBadParam, "astc: cubemap faces must be square and 2D")
+		}
+	default:
+		return newError(ErrBadParam, "astc: invalid image set face count")
+	}
+	if _, ok := s.texelCount(); !ok {
+		return newError(ErrBadParam, "astc: image set too large")
+	}
+	return nil
+}
+
+// texelCount returns the number of texels of all subresources, or false if that overflows the
+// sizes derived from it: four channels per texel, or a block per texel. The layout dimensions must
+// be positive.
+func (s *ImageSet) texelCount() (int, bool) {
+	const limit = math.MaxInt / BlockBytes
+	n := 0
+	for level := 0; level < s.Levels; level++ {
+		x, y, z := s.LevelDims(level)
+		texels := x
+		for _, d := range []int{y, z, s.Layers, s.Faces} {
+			if texels > limit/d {
+				return 0, false
+			}
+			texels *= d
+		}
+		if n > limit-texels {
+			return 0, false
+		}
+		n += texels
+	}
+	return n, true
+}
+
+// locate returns the texel offset of sub and its dimensions.
+func (s *ImageSet) locate(sub Subresource) (offset, dimX, dimY, dimZ int, err error) {
+	if err := s.validateLayout(); err != nil {
+		return 0, 0, 0, 0, err
+	}
+	if sub.Level < 0 || sub.Level >= s.Levels || sub.Layer < 0 || sub.Layer >= s.Layers || int(sub.Face) >= s.Faces {
+		return 0, 0, 0, 0, newError(ErrBadParam, "astc: subresource out of range")
+	}
+	for level := 0; level < sub.Level; level++ {
+		x, y, z := s.LevelDims(level)
+		offset += x * y * z * s.Layers * s.Faces
+	}
+	dimX, dimY, dimZ = s.LevelDims(sub.Level)
+	offset += (sub.Layer*s.Faces + int(sub.Face)) * dimX * dimY * dimZ
+	return offset, dimX, dimY, dimZ, nil
+}
+
+// Image returns a view of subresource sub: an Image whose pixel data aliases the backing buffer.
+func (s *ImageSet) Image(sub Subresource) (*Image, error) {
+	offset, x, y, z, err := s.locate(sub)
+	if err != nil {
+		return nil, err
+	}
+	if n, _ := s.texelCount(); n*4 != s.dataLen() {
+		return nil, newError(ErrBadParam, "astc: invalid image set buffer length")
+	}
+	lo, hi := offset*4, (offset+x*y*z)*4
+	img := &Image{DimX: x, DimY: y, DimZ: z, DataType: s.DataType}
+	switch s.DataType {
+	case TypeU8:
+		img.DataU8 = s.DataU8[lo:hi:hi]
+	case TypeF16:
+		img.DataF16 = s.DataF16[lo:hi:hi]
+	case TypeF32:
+		img.DataF32 = s.DataF32[lo:hi:hi]
+	}
+	return img, nil
+}
+
+func (s *ImageSet) dataLen() int {
+	switch s.DataType {
+	case TypeU8:
+		return len(s.DataU8)
+	case TypeF16:
+		return len(s.DataF16)
+	case TypeF32:
+		return len(s.DataF32)
+	default:
+		return -1
+	}
+}
+
+// BlockRange returns the byte range of the blocks of subresource sub in a payload holding the
+// blocks of every subresource of s, in ImageSet order, for the given block footprint.
+func (s *ImageSet) BlockRange(sub Subresource, blockX, blockY, blockZ int) (offset, length int, err error) {
+	if err := validateBlockSize(blockX, blockY, blockZ); err != nil {
+		return 0, 0, err
+	}
+	if _, _, _, _, err := s.locate(sub); err != nil {
+		return 0, 0, err
+	}
+	blocks := func(level int) int {
+		x, y, z := s.LevelDims(level)
+		return ((x + blockX - 1) / blockX) * ((y + blockY - 1) / blockY) * ((z + blockZ - 1) / blockZ)
+	}
+	for level := 0; level < sub.Level; level++ {
+		offset += blocks(level) * s.Layers * s.Faces
+	}
+	n := blocks(sub.Level)
+	offset += (sub.Layer*s.Faces + int(sub.Face)) * n
+	return offset * BlockBytes, n * BlockBytes, nil
+}
+
+// CompressedSize returns the size in bytes of a payload holding the blocks of every subresource
+// of s for the given block footprint.
+func (s *ImageSet) CompressedSize(blockX, blockY, blockZ int) (int, error) {
+	last := Subresource{Level: s.Levels - 1, Layer: s.Layers - 1, Face: CubeFace(s.Faces - 1)}
+	offset, length, err := s.BlockRange(last, blockX, blockY, blockZ)
+	return offset + length, err
+}
+
+// CompressSubresource compresses subresource sub of set into its blocks in out, a payload laid out
+// as described by ImageSet.BlockRange. Threads cooperate as with CompressImage.
+func (c *Context) CompressSubresource(set *ImageSet, sub Subresource, swizzle Swizzle, out []byte, threadIndex int) error {
+	if c == nil {
+		return newError(ErrBadContext, "astc: nil context")
+	}
+	img, blocks, err := c.subresource(set, sub, out)
+	if err != nil {
+		return err
+	}
+	return c.CompressImage(img, swizzle, blocks, threadIndex)
+}
+
+// DecompressSubresource decompresses the blocks of subresource sub in data, a payload laid out as
+// described by ImageSet.BlockRange, into that subresource of set. Threads cooperate as with
+// DecompressImage.
+func (c *Context) DecompressSubresource(data []byte, set *ImageSet, sub Subresource, swizzle Swizzle, threadIndex int) error {
+	if c == nil {
+		return newError(ErrBadContext, "astc: nil context")
+	}
+	img, blocks, err := c.subresource(set, sub, data)
+	if err != nil {
+		return err
+	}
+	return c.DecompressImage(blocks, img, swizzle, threadIndex)
+}
+
+// subresource returns the view of sub and its blocks in payload.
+func (c *Context) subresource(set *ImageSet, sub Subresource, payload []byte) (*Image, []byte, error) {
+	if set == nil {
+		return nil, nil, newError(ErrBadParam, "astc: nil image set")
+	}
+	img, err := set.Image(sub)
+	if err != nil {
+		return nil, nil, err
+	}
+	offset, length, err := set.BlockRange(sub, c.blockX, c.blockY, c.blockZ)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(payload) < offset+length {
+		return nil, nil, newError(ErrOutOfMem, "astc: block buffer too small")
+	}
+	return img, payload[offset : offset+length : offset+length], nil
+}
@@ -0,0 +1,122 @@
+package astc_test
+
+import (
+	"bytes"
+	"testing"
+
+	astc "github.com/arm-software/astc-encoder/astc"
+)
+
+func TestImageSet(t *testing.T) {
+	if got := astc.MaxMipLevels(20, 12, 1); got != 5 {
+		t.Fatalf("MaxMipLevels=%d, want 5", got)
+	}
+	if _, err := astc.NewImageSet(astc.TypeU8, 16, 8, 1, 0, 1, astc.CubeFaceCount); err == nil {
+		t.Fatalf("expected error for non-square cubemap")
+	}
+	if _, err := astc.NewImageSet(astc.TypeU8, 16, 16, 1, 6, 1, 1); err == nil {
+		t.Fatalf("expected error for too many levels")
+	}
+	if _, err := astc.NewImageSet(astc.TypeU8, 1<<20, 1<<20, 1<<20, 1, 1, 1); astc.ErrorCodeOf(err) != astc.ErrBadParam {
+		t.Fatalf("overflowing texel count: got %v, want ErrBadParam", err)
+	}
+	huge := &astc.ImageSet{DimX: 1 << 20, DimY: 1 << 20, DimZ: 1 << 20, Levels: 1, Layers: 1, Faces: 1, DataType: astc.TypeU8}
+	if _, err := huge.Image(astc.Subresource{}); astc.ErrorCodeOf(err) != astc.ErrBadParam {
+		t.Fatalf("Image of an overflowing set: got %v, want ErrBadParam", err)
+	}
+	if _, err := huge.CompressedSize(4, 4, 4); astc.ErrorCodeOf(err) != astc.ErrBadParam {
+		t.Fatalf("CompressedSize of an overflowing set: got %v, want ErrBadParam", err)
+	}
+
+	const bs = 4
+	set, err := astc.NewImageSet(astc.TypeU8, 16, 16, 1, 0, 2, astc.CubeFaceCount)
+	if err != nil {
+		t.Fatalf("NewImageSet: %v", err)
+	}
+	if set.Levels != 5 || len(set.DataU8) != 2*6*4*(256+64+16+4+1) {
+		t.Fatalf("levels=%d len=%d", set.Levels, len(set.DataU8))
+	}
+	for i := range set.DataU8 {
+		set.DataU8[i] = byte(i*7 + i/13)
+	}
+	size, err := set.CompressedSize(bs, bs, 1)
+	if err != nil {
+		t.Fatalf("CompressedSize: %v", err)
+	}
+	if want := 2 * 6 * 16 * (16 + 4 + 1 + 1 + 1); size != want {
+		t.Fatalf("CompressedSize=%d, want %d", size, want)
+	}
+
+	cfg, err := astc.ConfigInit(astc.ProfileLDR, bs, bs, 1, 0, 0)
+	if err != nil {
+		t.Fatalf("ConfigInit: %v", err)
+	}
+	ctx, err := astc.ContextAlloc(&cfg, 1)
+	if err != nil {
+		t.Fatalf("ContextAlloc: %v", err)
+	}
+	payload := make([]byte, size)
+	subs := []astc.Subresource{
+		{Level: 0, Layer: 0, Face: astc.CubeFacePosX},
+		{Level: 1, Layer: 1, Face: astc.CubeFaceNegZ},
+		{Level: 4, Layer: 1, Face: astc.CubeFacePosY},
+	}
+	for _, sub := range subs {
+		if err := ctx.CompressSubresource(set, sub, astc.SwizzleRGBA, payload, 0); err != nil {
+			t.Fatalf("CompressSubresource(%+v): %v", sub, err)
+		}
+		if err := ctx.CompressReset(); err != nil {
+			t.Fatalf("CompressReset: %v", err)
+		}
+
+		// The blocks must match compressing a standalone copy of the subresource.
+		view, err := set.Image(sub)
+		if err != nil {
+			t.Fatalf("Image(%+v): %v", sub, err)
+		}
+		standalone := &astc.Image{DimX: view.DimX, DimY: view.DimY, DimZ: 1, DataType: astc.TypeU8, DataU8: append([]byte(nil), view.DataU8...)}
+		want := make([]byte, blocksLenBytes(view.DimX, view.DimY, 1, bs, bs, 1))
+		if err := ctx.CompressImage(standalone, astc.SwizzleRGBA, want, 0); err != nil {
+			t.Fatalf("CompressImage: %v", err)
+		}
+		if err := ctx.CompressReset(); err != nil {
+			t.Fatalf("CompressReset: %v", err)
+		}
+		off, n, err := set.BlockRange(sub, bs, bs, 1)
+		if err != nil {
+			t.Fatalf("BlockRange: %v", err)
+		}
+		if !bytes.Equal(payload[off:off+n], want) {
+			t.Fatalf("subresource %+v blocks differ from standalone compression", sub)
+		}
+
+		out, err := astc.NewImageSet(astc.TypeU8, 16, 16, 1, 0, 2, astc.CubeFaceCount)
+		if err != nil {
+			t.Fatalf("NewImageSet: %v", err)
+		}
+		if err := ctx.DecompressSubresource(payload, out, sub, astc.SwizzleRGBA, 0); err != nil {
+			t.Fatalf("DecompressSubresource(%+v): %v", sub, err)
+		}
+		if err := ctx.DecompressReset(); err != nil {
+			t.Fatalf("DecompressReset: %v", err)
+		}
+		got, _ := out.Image(sub)
+		dec := &astc.Image{DimX: view.DimX, DimY: view.DimY, DimZ: 1, DataType: astc.TypeU8, DataU8: make([]byte, len(view.DataU8))}
+		if err := ctx.DecompressImage(want, dec, astc.SwizzleRGBA, 0); err != nil {
+			t.Fatalf("DecompressImage: %v", err)
+		}
+		if err := ctx.DecompressReset(); err != nil {
+			t.Fatalf("DecompressReset: %v", err)
+		}
+		if !bytes.Equal(got.DataU8, dec.DataU8) {
+			t.Fatalf("subresource %+v decoded texels differ", sub)
+		}
+	}
+
+	if _, err := set.Image(astc.Subresource{Level: 5}); err == nil {
+		t.Fatalf("expected error for out-of-range level")
+	}
+	if err := ctx.CompressSubresource(set, subs[0], astc.SwizzleRGBA, payload[:16], 0); err == nil {
+		t.Fatalf("expected error for short payload")
+	}
+}
@@ -0,0 +1,8 @@
+// Package imagefile converts between image files and compressed texture files in one call, for
+// tools which do not manage pixel buffers themselves.
+//
+// CompressFile decodes a PNG or JPEG (or any format registered with the image package) and encodes
+// it to a .astc file, a .ktx2 file or a registered FileContainer; DecompressFile reverses it,
+// writing a PNG or JPEG. The image decoders and encoders, and the KTX2 container, live here rather
+// than in package astc, so programs which only encode pixel buffers do not link them.
+package imagefile
@@ -0,0 +1,268 @@
+package imagefile
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/arm-software/astc-encoder/astc"
+	"github.com/arm-software/astc-encoder/astc/ktx2"
+)
+
+// Report describes one CompressFile or DecompressFile run.
+type Report struct {
+	// Width, Height and Depth are the image dimensions.
+	Width, Height, Depth int
+	// InputFormat and OutputFormat name the file formats: "png", "jpeg" (or another format
+	// registered with the image package), "astc", or the name of a registered FileContainer.
+	InputFormat  string
+	OutputFormat string
+	// InputBytes and OutputBytes are the sizes of the source and the written file.
+	InputBytes  int64
+	OutputBytes int64
+	// Elapsed is the wall time of the run, including reading and writing the files.
+	Elapsed time.Duration
+}
+
+// FileContainer is a file format that stores a .astc file's blocks, registered with
+// RegisterFileContainer so CompressFile can write it and DecompressFile read it.
+type FileContainer struct {
+	// Name identifies the format in a Report, e.g. "ktx2".
+	Name string
+	// Magic is the prefix DecompressFile recognizes the format by.
+	Magic []byte
+	// Wrap converts a .astc file encoded with profile to the container format.
+	Wrap func(astcData []byte, profile astc.Profile) ([]byte, error)
+	// Unwrap converts a file of the container format back to a .astc file.
+	Unwrap func(data []byte) ([]byte, error)
+}
+
+// astcMagic is the magic number at the start of a .astc file.
+var astcMagic = []byte{0x13, 0xAB, 0xA1, 0x5C}
+
+// ktx2Magic is the identifier at the start of a KTX 2.0 file.
+var ktx2Magic = []byte{0xAB, 'K', 'T', 'X', ' ', '2', '0', 0xBB, '\r', '\n', 0x1A, '\n'}
+
+var (
+	fileContainersMu sync.RWMutex
+	fileContainers   = map[string]FileContainer{
+		".ktx2": {
+			Name:  "ktx2",
+			Magic: ktx2Magic,
+			Wrap: func(astcData []byte, profile astc.Profile) ([]byte, error) {
+				return ktx2.FromASTC(astcData, profile, ktx2.Options{})
+			},
+			Unwrap: ktx2.ToASTC,
+		},
+	}
+)
+
+// RegisterFileContainer makes CompressFile write c for destination paths with extension ext (e.g.
+// ".ktx2", matched without regard to case) and DecompressFile read files starting with c.Magic.
+// ".ktx2" is registered from the start, writing single-level KTX2 files with astc/ktx2.
+// Registering an extension again replaces the previous container.
+func RegisterFileContainer(ext string, c FileContainer) {
+	fileContainersMu.Lock()
+	defer fileContainersMu.Unlock()
+	fileContainers[strings.ToLower(ext)] = c
+}
+
+// CompressFile encodes the image at srcPath with cfg using workers goroutines (<= 0 uses
+// GOMAXPROCS) and writes it to dstPath, as a .astc file or, if the extension of dstPath belongs to
+// a registered FileContainer, in that container.
+//
+// The source format is sniffed from its content with image.Decode: PNG and JPEG are always
+// available, other formats once their decoder is registered with the image package. Only LDR
+// profiles are supported: the image package has no OpenEXR or other HDR decoder, so HDR profiles
+// fail with ErrBadProfile rather than encoding the source as 0..1 values. The Config is validated
+// like astc.ContextAlloc does; dstPath is written only if encoding succeeds.
+func CompressFile(srcPath, dstPath string, cfg astc.Config, workers int) (Report, error) {
+	start := time.Now()
+	var r Report
+	if cfg.Profile == astc.ProfileHDR || cfg.Profile == astc.ProfileHDRRGBLDRAlpha {
+		return r, &astc.Error{Code: astc.ErrBadProfile, Msg: "astc/imagefile: HDR profiles need an HDR source, which CompressFile cannot read"}
+	}
+	container, outFormat, err := fileOutputContainer(dstPath)
+	if err != nil {
+		return r, err
+	}
+	e, err := astc.NewEncoder(
+		astc.WithProfile(cfg.Profile),
+		astc.WithBlockSize3D(int(cfg.BlockX), int(cfg.BlockY), int(cfg.BlockZ)),
+		astc.WithWorkers(workers),
+		astc.WithConfig(func(c *astc.Config) { *c = cfg }),
+	)
+	if err != nil {
+		return r, err
+	}
+
+	data, err := os.ReadFile(srcPath)
+	if err != nil {
+		return r, err
+	}
+	src, inFormat, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return r, fmt.Errorf("astc/imagefile: decoding %s: %w", srcPath, err)
+	}
+	img := imageFromSource(src)
+	out, err := e.Encode(img)
+	if err != nil {
+		return r, err
+	}
+	if container.Wrap != nil {
+		if out, err = container.Wrap(out, cfg.Profile); err != nil {
+			return r, err
+		}
+	}
+	if err := os.WriteFile(dstPath, out, 0o644); err != nil {
+		return r, err
+	}
+
+	r = Report{
+		Width:        img.DimX,
+		Height:       img.DimY,
+		Depth:        1,
+		InputFormat:  inFormat,
+		OutputFormat: outFormat,
+		InputBytes:   int64(len(data)),
+		OutputBytes:  int64(len(out)),
+		Elapsed:      time.Since(start),
+	}
+	return r, nil
+}
+
+// DecompressFile decodes the .astc file or registered FileContainer at srcPath, recognized by its
+// magic, with profile using workers goroutines (<= 0 uses GOMAXPROCS) and writes the image to
+// dstPath as PNG or, for a ".jpg" or ".jpeg" extension, as JPEG. LDR profiles write 8 bits per
+// channel; HDR profiles write 16-bit PNGs of the values clamped to 0..1. Only 2D images are
+// supported.
+func DecompressFile(srcPath, dstPath string, profile astc.Profile, workers int) (Report, error) {
+	start := time.Now()
+	var r Report
+	outFormat := strings.ToLower(filepath.Ext(dstPath))
+	switch outFormat {
+	case ".png":
+		outFormat = "png"
+	case ".jpg", ".jpeg":
+		outFormat = "jpeg"
+	default:
+		return r, &astc.Error{Code: astc.ErrBadParam, Msg: fmt.Sprintf("astc/imagefile: unsupported output extension %q", filepath.Ext(dstPath))}
+	}
+
+	data, err := os.ReadFile(srcPath)
+	if err != nil {
+		return r, err
+	}
+	astcData, inFormat, err := unwrapFileContainer(data)
+	if err != nil {
+		return r, err
+	}
+	h, err := astc.ParseHeader(astcData)
+	if err != nil {
+		return r, err
+	}
+	if h.SizeZ != 1 {
+		return r, errors.New("astc/imagefile: DecompressFile supports only 2D images")
+	}
+	dec, err := astc.NewDecoder(astc.WithProfile(profile), astc.WithWorkers(workers))
+	if err != nil {
+		return r, err
+	}
+
+	var dst image.Image
+	if profile == astc.ProfileHDR || profile == astc.ProfileHDRRGBLDRAlpha {
+		pix, width, height, _, err := dec.DecodeRGBAF32(astcData)
+		if err != nil {
+			return r, err
+		}
+		img := image.NewNRGBA64(image.Rect(0, 0, width, height))
+		for i, v := range pix {
+			u := uint16(min(max(v, 0), 1)*65535 + 0.5)
+			img.Pix[i*2] = byte(u >> 8)
+			img.Pix[i*2+1] = byte(u)
+		}
+		dst = img
+	} else {
+		pix, width, height, _, err := dec.DecodeRGBA8(astcData)
+		if err != nil {
+			return r, err
+		}
+		dst = &image.NRGBA{Pix: pix, Stride: width * 4, Rect: image.Rect(0, 0, width, height)}
+	}
+
+	var buf bytes.Buffer
+	if outFormat == "png" {
+		err = png.Encode(&buf, dst)
+	} else {
+		err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 95})
+	}
+	if err != nil {
+		return r, err
+	}
+	if err := os.WriteFile(dstPath, buf.Bytes(), 0o644); err != nil {
+		return r, err
+	}
+
+	r = Report{
+		Width:        int(h.SizeX),
+		Height:       int(h.SizeY),
+		Depth:        1,
+		InputFormat:  inFormat,
+		OutputFormat: outFormat,
+		InputBytes:   int64(len(data)),
+		OutputBytes:  int64(buf.Len()),
+		Elapsed:      time.Since(start),
+	}
+	return r, nil
+}
+
+// fileOutputContainer returns the container CompressFile writes for dstPath (the zero
+// FileContainer for .astc files) and the name of the format.
+func fileOutputContainer(dstPath string) (FileContainer, string, error) {
+	ext := strings.ToLower(filepath.Ext(dstPath))
+	if ext == ".astc" {
+		return FileContainer{}, "astc", nil
+	}
+	fileContainersMu.RLock()
+	c, ok := fileContainers[ext]
+	fileContainersMu.RUnlock()
+	if !ok {
+		return FileContainer{}, "", &astc.Error{Code: astc.ErrBadParam, Msg: fmt.Sprintf("astc/imagefile: unsupported output extension %q", filepath.Ext(dstPath))}
+	}
+	return c, c.Name, nil
+}
+
+// unwrapFileContainer returns the .astc file in data, unwrapping it from the registered container
+// whose magic it starts with, and the name of its format.
+func unwrapFileContainer(data []byte) ([]byte, string, error) {
+	if bytes.HasPrefix(data, astcMagic) {
+		return data, "astc", nil
+	}
+	fileContainersMu.RLock()
+	defer fileContainersMu.RUnlock()
+	for _, c := range fileContainers {
+		if len(c.Magic) > 0 && bytes.HasPrefix(data, c.Magic) {
+			astcData, err := c.Unwrap(data)
+			return astcData, c.Name, err
+		}
+	}
+	return nil, "", errors.New("astc/imagefile: unrecognized compressed file format")
+}
+
+// imageFromSource converts a decoded source image to an RGBA8 Image.
+func imageFromSource(src image.Image) *astc.Image {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	pix := image.NewNRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(pix, pix.Bounds(), src, b.Min, draw.Src)
+	return &astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeU8, DataU8: pix.Pix}
+}
@@ -0,0 +1,181 @@
+package imagefile_test
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/arm-software/astc-encoder/astc"
+	"github.com/arm-software/astc-encoder/astc/imagefile"
+	"github.com/arm-software/astc-encoder/astc/ktx2"
+	"github.com/arm-software/astc-encoder/astc/testimage"
+)
+
+func writeTestPNG(t *testing.T, path string, w, h int) []byte {
+	t.Helper()
+	pix, err := testimage.RGBA8(testimage.KindGradient, w, h, 1, testimage.Options{})
+	if err != nil {
+		t.Fatalf("RGBA8: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, &image.NRGBA{Pix: pix, Stride: w * 4, Rect: image.Rect(0, 0, w, h)}); err != nil {
+		t.Fatalf("png.Encode: %v", err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	return pix
+}
+
+func TestCompressFile_RoundTrip(t *testing.T) {
+	const w, h = 37, 21
+	dir := t.TempDir()
+	src := filepath.Join(dir, "in.png")
+	pix := writeTestPNG(t, src, w, h)
+
+	cfg, err := astc.ConfigInit(astc.ProfileLDR, 6, 6, 1, astc.EncodeMedium.Level(), 0)
+	if err != nil {
+		t.Fatalf("ConfigInit: %v", err)
+	}
+	dst := filepath.Join(dir, "out.astc")
+	rep, err := imagefile.CompressFile(src, dst, cfg, 2)
+	if err != nil {
+		t.Fatalf("CompressFile: %v", err)
+	}
+	if rep.Width != w || rep.Height != h || rep.Depth != 1 || rep.InputFormat != "png" || rep.OutputFormat != "astc" {
+		t.Fatalf("report = %+v", rep)
+	}
+	astcData, err := os.ReadFile(dst)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if rep.OutputBytes != int64(len(astcData)) {
+		t.Fatalf("OutputBytes = %d, file has %d", rep.OutputBytes, len(astcData))
+	}
+
+	// The file matches what an Encoder with the same Config produces.
+	enc, err := astc.NewEncoder(astc.WithBlockSize(6, 6), astc.WithConfig(func(c *astc.Config) { *c = cfg }))
+	if err != nil {
+		t.Fatalf("NewEncoder: %v", err)
+	}
+	want, err := enc.EncodeRGBA8(pix, w, h)
+	if err != nil {
+		t.Fatalf("EncodeRGBA8: %v", err)
+	}
+	if !bytes.Equal(astcData, want) {
+		t.Fatal("CompressFile output differs from Encoder output")
+	}
+
+	out := filepath.Join(dir, "out.png")
+	rep, err = imagefile.DecompressFile(dst, out, astc.ProfileLDR, 0)
+	if err != nil {
+		t.Fatalf("DecompressFile: %v", err)
+	}
+	if rep.InputFormat != "astc" || rep.OutputFormat != "png" || rep.Width != w || rep.Height != h {
+		t.Fatalf("report = %+v", rep)
+	}
+	f, err := os.Open(out)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil {
+		t.Fatalf("png.Decode: %v", err)
+	}
+	decoded, _, _, err := astc.DecodeRGBA8WithProfile(astcData, astc.ProfileLDR)
+	if err != nil {
+		t.Fatalf("DecodeRGBA8WithProfile: %v", err)
+	}
+	if nrgba, ok := img.(*image.NRGBA); !ok || !bytes.Equal(nrgba.Pix, decoded) {
+		t.Fatal("decompressed PNG differs from DecodeRGBA8WithProfile")
+	}
+}
+
+func TestCompressFile_Rejects(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "in.png")
+	writeTestPNG(t, src, 8, 8)
+	cfg, err := astc.ConfigInit(astc.ProfileLDR, 4, 4, 1, astc.EncodeFast.Level(), 0)
+	if err != nil {
+		t.Fatalf("ConfigInit: %v", err)
+	}
+
+	if _, err := imagefile.CompressFile(src, filepath.Join(dir, "out.dds"), cfg, 1); err == nil {
+		t.Fatal("expected an error for an unknown output extension")
+	}
+	bad := filepath.Join(dir, "bad.png")
+	if err := os.WriteFile(bad, []byte("not an image"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	dst := filepath.Join(dir, "bad.astc")
+	if _, err := imagefile.CompressFile(bad, dst, cfg, 1); err == nil {
+		t.Fatal("expected an error for an undecodable source")
+	}
+	if _, err := os.Stat(dst); !os.IsNotExist(err) {
+		t.Fatalf("destination written after a failure: %v", err)
+	}
+	hdr, err := astc.ConfigInit(astc.ProfileHDR, 4, 4, 1, astc.EncodeFast.Level(), 0)
+	if err != nil {
+		t.Fatalf("ConfigInit: %v", err)
+	}
+	if _, err := imagefile.CompressFile(src, dst, hdr, 1); !errors.Is(err, astc.ErrBadProfile) {
+		t.Fatalf("HDR profile: got %v, want ErrBadProfile", err)
+	}
+	if _, err := imagefile.DecompressFile(src, filepath.Join(dir, "out.png"), astc.ProfileLDR, 1); err == nil {
+		t.Fatal("expected an error for a source that is not compressed")
+	}
+}
+
+func TestCompressFile_KTX2(t *testing.T) {
+	const w, h = 24, 16
+	dir := t.TempDir()
+	pix, err := testimage.RGBA8(testimage.KindText, w, h, 1, testimage.Options{})
+	if err != nil {
+		t.Fatalf("RGBA8: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, &image.NRGBA{Pix: pix, Stride: w * 4, Rect: image.Rect(0, 0, w, h)}); err != nil {
+		t.Fatalf("png.Encode: %v", err)
+	}
+	src := filepath.Join(dir, "in.png")
+	if err := os.WriteFile(src, buf.Bytes(), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	cfg, err := astc.ConfigInit(astc.ProfileLDRSRGB, 4, 4, 1, astc.EncodeFast.Level(), 0)
+	if err != nil {
+		t.Fatalf("ConfigInit: %v", err)
+	}
+
+	dst := filepath.Join(dir, "out.KTX2")
+	rep, err := imagefile.CompressFile(src, dst, cfg, 1)
+	if err != nil {
+		t.Fatalf("CompressFile: %v", err)
+	}
+	if rep.OutputFormat != "ktx2" {
+		t.Fatalf("OutputFormat = %q, want ktx2", rep.OutputFormat)
+	}
+	data, err := os.ReadFile(dst)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	tex, err := ktx2.Unmarshal(data)
+	if err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if tex.Profile != astc.ProfileLDRSRGB || tex.Width != w || tex.Height != h {
+		t.Fatalf("texture = %dx%d profile %v", tex.Width, tex.Height, tex.Profile)
+	}
+
+	rep, err = imagefile.DecompressFile(dst, filepath.Join(dir, "out.png"), astc.ProfileLDRSRGB, 1)
+	if err != nil {
+		t.Fatalf("DecompressFile: %v", err)
+	}
+	if rep.InputFormat != "ktx2" || rep.Width != w || rep.Height != h {
+		t.Fatalf("report = %+v", rep)
+	}
+}
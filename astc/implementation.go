@@ -0,0 +1,152 @@
+package astc
+
+import (
+	"errors"
+	"os"
+	"sync"
+	"time"
+)
+
+// Implementation routes one-shot encodes and decodes to a codec implementation. The pure-Go
+// implementation is always available; other packages register theirs with RegisterImplementation
+// (importing astc/native in a build with the astcenc_native tag registers "native").
+type Implementation interface {
+	// Name identifies the implementation, e.g. "go" or "native".
+	Name() string
+	EncodeRGBA8VolumeWithProfileAndQuality(pix []byte, width, height, depth int, blockX, blockY, blockZ int, profile Profile, quality EncodeQuality) ([]byte, error)
+	DecodeRGBA8VolumeWithProfile(astcData []byte, profile Profile) (pix []byte, width, height, depth int, err error)
+}
+
+// ImplementationEnv is the environment variable which overrides the choice of AutoImplementation
+// and SelectImplementation: when it names a registered implementation, that one is used without
+// benchmarking. Other values are ignored.
+const ImplementationEnv = "ASTC_IMPLEMENTATION"
+
+type goImplementation struct{}
+
+func (goImplementation) Name() string { return "go" }
+
+func (goImplementation) EncodeRGBA8VolumeWithProfileAndQuality(pix []byte, width, height, depth int, blockX, blockY, blockZ int, profile Profile, quality EncodeQuality) ([]byte, error) {
+	return EncodeRGBA8VolumeWithProfileAndQuality(pix, width, height, depth, blockX, blockY, blockZ, profile, quality)
+}
+
+func (goImplementation) DecodeRGBA8VolumeWithProfile(astcData []byte, profile Profile) ([]byte, int, int, int, error) {
+	return DecodeRGBA8VolumeWithProfile(astcData, profile)
+}
+
+var (
+	implMu       sync.Mutex
+	implList     = []Implementation{goImplementation{}}
+	implSelected Implementation
+)
+
+// GoImplementation returns the pure-Go implementation.
+func GoImplementation() Implementation {
+	return goImplementation{}
+}
+
+// RegisterImplementation makes impl available to AutoImplementation and SelectImplementation. It
+// panics if an implementation with the same name is already registered.
+func RegisterImplementation(impl Implementation) {
+	implMu.Lock()
+	defer implMu.Unlock()
+	for _, have := range implList {
+		if have.Name() == impl.Name() {
+			panic("astc: implementation " + impl.Name() + " registered twice")
+		}
+	}
+	implList = append(implList, impl)
+}
+
+// Implementations returns the registered implementations, starting with the pure-Go one.
+func Implementations() []Implementation {
+	implMu.Lock()
+	defer implMu.Unlock()
+	return append([]Implementation(nil), implList...)
+}
+
+// AutoImplementation returns the implementation chosen by the first call to SelectImplementation,
+// making that call if needed. Later calls return the same implementation without benchmarking.
+func AutoImplementation() Implementation {
+	implMu.Lock()
+	impl := implSelected
+	implMu.Unlock()
+	if impl != nil {
+		return impl
+	}
+	return SelectImplementation()
+}
+
+// SelectImplementation chooses the implementation used by AutoImplementation: the one named by
+// ImplementationEnv if set, otherwise the registered implementation which encodes and decodes a
+// small sample image fastest on this machine. Implementations which fail on the sample are skipped;
+// the pure-Go implementation is the fallback. The benchmark takes a few milliseconds; call it again
+// to re-run it, e.g. after registering an implementation.
+func SelectImplementation() Implementation {
+	impls := Implementations()
+
+	var best Implementation
+	if name := os.Getenv(ImplementationEnv); name != "" {
+		for _, impl := range impls {
+			if impl.Name() == name {
+				best = impl
+			}
+		}
+	}
+	if best == nil {
+		bestTime := time.Duration(-1)
+		for _, impl := range impls {
+			d, err := benchmarkImplementation(impl)
+			if err != nil {
+				continue
+			}
+			if bestTime < 0 || d < bestTime {
+				best, bestTime = impl, d
+			}
+		}
+	}
+	if best == nil {
+		best = goImplementation{}
+	}
+
+	implMu.Lock()
+	implSelected = best
+	implMu.Unlock()
+	return best
+}
+
+// benchmarkImplementation returns the fastest of a few encode/decode round trips of a small
+// sample image through impl.
+func benchmarkImplementation(impl Implementation) (time.Duration, error) {
+	const size, blockSize, runs = 24, 6, 3
+	pix := make([]byte, size*size*4)
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			off := (y*size + x) * 4
+			pix[off+0] = uint8(x * 10)
+			pix[off+1] = uint8(y * 10)
+			pix[off+2] = uint8((x ^ y) * 8)
+			pix[off+3] = 255
+		}
+	}
+
+	best := time.Duration(-1)
+	for range runs {
+		start := time.Now()
+		data, err := impl.EncodeRGBA8VolumeWithProfileAndQuality(pix, size, size, 1, blockSize, blockSize, 1, ProfileLDR, EncodeMedium)
+		if err != nil {
+			return 0, err
+		}
+		out, w, h, d, err := impl.DecodeRGBA8VolumeWithProfile(data, ProfileLDR)
+		if err != nil {
+			return 0, err
+		}
+		if w != size || h != size || d != 1 || len(out) != len(pix) {
+			return 0, errors.New("astc: implementation returned the wrong image size")
+		}
+		if elapsed := time.Since(start); best < 0 || elapsed < best {
+			best = elapsed
+		}
+	}
+	return best, nil
+}
@@ -0,0 +1,78 @@
+package astc_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/arm-software/astc-encoder/astc"
+)
+
+// failingImplementation fails every call, so benchmark-driven selection must skip it.
+type failingImplementation struct{}
+
+func (failingImplementation) Name() string { return "test-failing" }
+
+func (failingImplementation) EncodeRGBA8VolumeWithProfileAndQuality([]byte, int, int, int, int, int, int, astc.Profile, astc.EncodeQuality) ([]byte, error) {
+	return nil, errors.New("unavailable")
+}
+
+func (failingImplementation) DecodeRGBA8VolumeWithProfile([]byte, astc.Profile) ([]byte, int, int, int, error) {
+	return nil, 0, 0, 0, errors.New("unavailable")
+}
+
+func TestImplementation_Go(t *testing.T) {
+	const w, h = 12, 8
+	pix := make([]byte, w*h*4)
+	for i := range pix {
+		pix[i] = uint8(i * 7)
+	}
+	impl := astc.GoImplementation()
+	if impl.Name() != "go" {
+		t.Fatalf("Name() = %q, want go", impl.Name())
+	}
+	data, err := impl.EncodeRGBA8VolumeWithProfileAndQuality(pix, w, h, 1, 4, 4, 1, astc.ProfileLDR, astc.EncodeFast)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	want, err := astc.EncodeRGBA8VolumeWithProfileAndQuality(pix, w, h, 1, 4, 4, 1, astc.ProfileLDR, astc.EncodeFast)
+	if err != nil || !bytes.Equal(data, want) {
+		t.Fatalf("GoImplementation encode differs from EncodeRGBA8VolumeWithProfileAndQuality (%v)", err)
+	}
+	out, gw, gh, gd, err := impl.DecodeRGBA8VolumeWithProfile(data, astc.ProfileLDR)
+	if err != nil || gw != w || gh != h || gd != 1 || len(out) != len(pix) {
+		t.Fatalf("decode: %dx%dx%d, %d bytes, %v", gw, gh, gd, len(out), err)
+	}
+}
+
+func TestSelectImplementation(t *testing.T) {
+	astc.RegisterImplementation(failingImplementation{})
+	defer func() {
+		if recover() == nil {
+			t.Errorf("registering a duplicate name did not panic")
+		}
+	}()
+
+	t.Setenv(astc.ImplementationEnv, "")
+	if got := astc.SelectImplementation(); got.Name() == "test-failing" {
+		t.Fatalf("SelectImplementation chose an implementation which fails")
+	}
+	if got, again := astc.AutoImplementation(), astc.AutoImplementation(); got.Name() != again.Name() {
+		t.Fatalf("AutoImplementation changed from %q to %q", got.Name(), again.Name())
+	}
+
+	// The environment override is honored without benchmarking, and unknown names are ignored.
+	t.Setenv(astc.ImplementationEnv, "test-failing")
+	if got := astc.SelectImplementation(); got.Name() != "test-failing" {
+		t.Fatalf("override: got %q", got.Name())
+	}
+	if got := astc.AutoImplementation(); got.Name() != "test-failing" {
+		t.Fatalf("AutoImplementation after override: got %q", got.Name())
+	}
+	t.Setenv(astc.ImplementationEnv, "no-such-implementation")
+	if got := astc.SelectImplementation(); got.Name() == "test-failing" {
+		t.Fatalf("unknown override: got %q", got.Name())
+	}
+
+	astc.RegisterImplementation(astc.GoImplementation())
+}
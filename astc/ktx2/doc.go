@@ -0,0 +1,12 @@
+// Package ktx2 writes and reads ASTC textures as KTX 2.0 files, optionally with Zstandard
+// supercompression, so an encoded texture becomes an engine-ready artifact in one call.
+//
+// A Texture holds the block payload of every mip level (layer by layer, face by face within a
+// level, the order of astc.ImageSet). Marshal writes it with the matching VkFormat (UNORM, SRGB or,
+// for HDR profiles, the SFLOAT formats of VK_EXT_texture_compression_astc_hdr), the ASTC data
+// format descriptor and a KTXwriter entry; Unmarshal reverses it, inflating Zstandard levels.
+// FromASTC and ToASTC convert a single .astc file directly; imagefile.CompressFile and
+// imagefile.DecompressFile use them for ".ktx2" paths.
+//
+// Only 2D block footprints are supported, since Vulkan has no 3D ASTC formats in core.
+package ktx2
@@ -0,0 +1,524 @@
+package ktx2
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/arm-software/astc-encoder/astc"
+)
+
+// Supercompression is a KTX2 supercompression scheme.
+type Supercompression uint32
+
+const (
+	// SupercompressionNone stores the blocks as is, each level aligned to 16 bytes.
+	SupercompressionNone Supercompression = 0
+	// SupercompressionZstd stores each level as one Zstandard frame.
+	SupercompressionZstd Supercompression = 2
+)
+
+// DefaultWriter is the KTXwriter value written when Options.Writer is empty.
+const DefaultWriter = "go-astc-encoder"
+
+// Texture is an ASTC texture with all of its subresources.
+type Texture struct {
+	// BlockX and BlockY are the 2D block footprint.
+	BlockX, BlockY int
+	// Width and Height are the base level dimensions in texels.
+	Width, Height int
+	// Layers is 1 for a non-array texture; larger values make an array texture.
+	Layers int
+	// Faces is 1, or 6 for cubemaps (square faces in +X, -X, +Y, -Y, +Z, -Z order).
+	Faces int
+	// Profile selects the VkFormat: ProfileLDR maps to UNORM, ProfileLDRSRGB to SRGB and the HDR
+	// profiles to SFLOAT. Unmarshal reports SFLOAT formats as ProfileHDR.
+	Profile astc.Profile
+	// Levels holds the blocks of each mip level from the base level, layer by layer and face by
+	// face within a level. Level n has dimensions max(1, Width>>n) x max(1, Height>>n).
+	Levels [][]byte
+}
+
+// Options configures Marshal.
+type Options struct {
+	Supercompression Supercompression
+	// ZstdLevel is the Zstandard compression level (1-22); 0 selects 3, the zstd default.
+	ZstdLevel int
+	// Writer is stored as the KTXwriter metadata; empty selects DefaultWriter.
+	Writer string
+}
+
+var identifier = [12]byte{0xAB, 'K', 'T', 'X', ' ', '2', '0', 0xBB, '\r', '\n', 0x1A, '\n'}
+
+const (
+	headerSize     = 80
+	levelIndexSize = 24
+	dfdSize        = 44
+
+	// VkFormat values. The LDR formats are ordered as footprints, each UNORM format followed by
+	// its SRGB variant; the SFLOAT formats come from VK_EXT_texture_compression_astc_hdr.
+	vkFormatASTC4x4UNORM  = 157
+	vkFormatASTC4x4SFLOAT = 1000066000
+
+	// Khronos data format descriptor values.
+	dfModelASTC       = 162
+	dfPrimariesBT709  = 1
+	dfTransferLinear  = 1
+	dfTransferSRGB    = 2
+	dfSampleFloat     = 0x80
+	dfSampleSigned    = 0x40
+	dfVersion         = 2
+	dfBasicBlockBytes = 24 + 16
+)
+
+// footprints orders the 2D footprints as the VkFormat values are.
+var footprints = astc.Footprints2D()
+
+func vkFormat(blockX, blockY int, profile astc.Profile) (uint32, error) {
+	for i, fp := range footprints {
+		if fp[0] != blockX || fp[1] != blockY {
+			continue
+		}
+		switch profile {
+		case astc.ProfileLDR:
+			return vkFormatASTC4x4UNORM + 2*uint32(i), nil
+		case astc.ProfileLDRSRGB:
+			return vkFormatASTC4x4UNORM + 2*uint32(i) + 1, nil
+		case astc.ProfileHDR, astc.ProfileHDRRGBLDRAlpha:
+			return vkFormatASTC4x4SFLOAT + uint32(i), nil
+		}
+		return 0, fmt.Errorf("astc/ktx2: unknown profile %d", profile)
+	}
+	return 0, fmt.Errorf("astc/ktx2: unsupported block footprint %dx%d", blockX, blockY)
+}
+
+func parseVkFormat(format uint32) (blockX, blockY int, profile astc.Profile, err error) {
+	switch {
+	case format >= vkFormatASTC4x4UNORM && format < vkFormatASTC4x4UNORM+2*uint32(len(footprints)):
+		i := (format - vkFormatASTC4x4UNORM) / 2
+		profile = astc.ProfileLDR
+		if (format-vkFormatASTC4x4UNORM)%2 == 1 {
+			profile = astc.ProfileLDRSRGB
+		}
+		return footprints[i][0], footprints[i][1], profile, nil
+	case format >= vkFormatASTC4x4SFLOAT && format < vkFormatASTC4x4SFLOAT+uint32(len(footprints)):
+		i := format - vkFormatASTC4x4SFLOAT
+		return footprints[i][0], footprints[i][1], astc.ProfileHDR, nil
+	}
+	return 0, 0, 0, fmt.Errorf("astc/ktx2: VkFormat %d is not a 2D ASTC format", format)
+}
+
+// LevelSize returns the size in bytes of the blocks of a mip level.
+func (t *Texture) LevelSize(level int) (int, error) {
+	n, err := astc.EncodedSize(max(t.Width>>level, 1), max(t.Height>>level, 1), 1, t.BlockX, t.BlockY, 1)
+	if err != nil {
+		return 0, err
+	}
+	if t.Layers <= 0 || t.Faces <= 0 || n > math.MaxInt/t.Layers/t.Faces {
+		return 0, errors.New("astc/ktx2: invalid texture dimensions")
+	}
+	return n * t.Layers * t.Faces, nil
+}
+
+func (t *Texture) validate() error {
+	if _, err := vkFormat(t.BlockX, t.BlockY, t.Profile); err != nil {
+		return err
+	}
+	if t.Width <= 0 || t.Height <= 0 || t.Layers <= 0 {
+		return errors.New("astc/ktx2: invalid texture dimensions")
+	}
+	switch t.Faces {
+	case 1:
+	case astc.CubeFaceCount:
+		if t.Width != t.Height {
+			return errors.New("astc/ktx2: cubemap faces must be square")
+		}
+	default:
+		return errors.New("astc/ktx2: face count must be 1 or 6")
+	}
+	if len(t.Levels) == 0 || len(t.Levels) > astc.MaxMipLevels(t.Width, t.Height, 1) {
+		return errors.New("astc/ktx2: invalid level count")
+	}
+	for i, level := range t.Levels {
+		n, err := t.LevelSize(i)
+		if err != nil {
+			return err
+		}
+		if len(level) != n {
+			return fmt.Errorf("astc/ktx2: level %d holds %d bytes, want %d", i, len(level), n)
+		}
+	}
+	return nil
+}
+
+// Marshal encodes t as a KTX2 file.
+func Marshal(t *Texture, opts Options) ([]byte, error) {
+	if err := t.validate(); err != nil {
+		return nil, err
+	}
+	format, _ := vkFormat(t.BlockX, t.BlockY, t.Profile)
+
+	levels := t.Levels
+	switch opts.Supercompression {
+	case SupercompressionNone:
+	case SupercompressionZstd:
+		if opts.ZstdLevel < 0 || opts.ZstdLevel > 22 {
+			return nil, errors.New("astc/ktx2: zstd level must be 0-22")
+		}
+		zlevel := opts.ZstdLevel
+		if zlevel == 0 {
+			zlevel = 3
+		}
+		enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(zlevel)), zstd.WithEncoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+		defer enc.Close()
+		levels = make([][]byte, len(t.Levels))
+		for i, level := range t.Levels {
+			levels[i] = enc.EncodeAll(level, nil)
+		}
+	default:
+		return nil, fmt.Errorf("astc/ktx2: unsupported supercompression scheme %d", opts.Supercompression)
+	}
+
+	writer := opts.Writer
+	if writer == "" {
+		writer = DefaultWriter
+	}
+	kvd := keyValue("KTXwriter", writer)
+
+	dfdOffset := headerSize + levelIndexSize*len(levels)
+	kvdOffset := dfdOffset + dfdSize
+	dataOffset := kvdOffset + len(kvd)
+	align := 16 // lcm(block size, 4); supercompressed levels are unaligned
+	if opts.Supercompression != SupercompressionNone {
+		align = 1
+	}
+
+	// Level data is stored from the smallest level to the base level.
+	offsets := make([]int, len(levels))
+	end := dataOffset
+	for i := len(levels) - 1; i >= 0; i-- {
+		end = (end + align - 1) / align * align
+		offsets[i] = end
+		end += len(levels[i])
+	}
+
+	out := make([]byte, end)
+	copy(out, identifier[:])
+	le := binary.LittleEndian
+	layers := t.Layers
+	if layers == 1 {
+		layers = 0
+	}
+	for i, v := range []uint32{
+		format, 1, uint32(t.Width), uint32(t.Height), 0, uint32(layers), uint32(t.Faces),
+		uint32(len(levels)), uint32(opts.Supercompression),
+		uint32(dfdOffset), dfdSize, uint32(kvdOffset), uint32(len(kvd)),
+	} {
+		le.PutUint32(out[12+4*i:], v)
+	}
+	// sgdByteOffset and sgdByteLength stay zero: only BasisLZ uses global data.
+	for i, level := range levels {
+		idx := out[headerSize+levelIndexSize*i:]
+		le.PutUint64(idx[0:], uint64(offsets[i]))
+		le.PutUint64(idx[8:], uint64(len(level)))
+		le.PutUint64(idx[16:], uint64(len(t.Levels[i])))
+		copy(out[offsets[i]:], level)
+	}
+	putDFD(out[dfdOffset:], t, opts.Supercompression)
+	copy(out[kvdOffset:], kvd)
+	return out, nil
+}
+
+// putDFD writes the data format descriptor: one basic descriptor block with the single 128-bit
+// ASTC sample.
+func putDFD(b []byte, t *Texture, sc Supercompression) {
+	le := binary.LittleEndian
+	transfer := uint32(dfTransferLinear)
+	if t.Profile == astc.ProfileLDRSRGB {
+		transfer = dfTransferSRGB
+	}
+	// Supercompressed data is unsized: bytesPlane0 is zero.
+	bytesPlane0 := uint32(astc.BlockBytes)
+	if sc != SupercompressionNone {
+		bytesPlane0 = 0
+	}
+	channelType, lower, upper := uint32(0), uint32(0), uint32(0xFFFFFFFF)
+	if t.Profile == astc.ProfileHDR || t.Profile == astc.ProfileHDRRGBLDRAlpha {
+		channelType, lower, upper = dfSampleFloat|dfSampleSigned, 0xBF800000, 0x7F800000
+	}
+	for i, v := range []uint32{
+		dfdSize,
+		0, // vendorId KHR, descriptorType basic
+		dfVersion | dfBasicBlockBytes<<16,
+		dfModelASTC | dfPrimariesBT709<<8 | transfer<<16, // straight alpha
+		uint32(t.BlockX-1) | uint32(t.BlockY-1)<<8,
+		bytesPlane0,
+		0,
+		127<<16 | channelType<<24, // bitOffset 0, bitLength 128-1, channel ASTC_DATA
+		0,
+		lower,
+		upper,
+	} {
+		le.PutUint32(b[4*i:], v)
+	}
+}
+
+// keyValue encodes one key/value entry, padded to 4 bytes.
+func keyValue(key, value string) []byte {
+	n := len(key) + 1 + len(value) + 1
+	b := make([]byte, 4+(n+3)/4*4)
+	binary.LittleEndian.PutUint32(b, uint32(n))
+	copy(b[4:], key)
+	copy(b[4+len(key)+1:], value)
+	return b
+}
+
+// Unmarshal decodes a KTX2 file holding a 2D ASTC texture, inflating Zstandard supercompressed
+// levels. The returned levels do not alias data. Textures whose base level exceeds
+// astc.DefaultMaxPixels texels or astc.DefaultMaxBlocks blocks across all layers and faces are
+// rejected with a *astc.DecodeLimitError before anything is allocated.
+func Unmarshal(data []byte) (*Texture, error) {
+	if len(data) < headerSize || !bytes.Equal(data[:12], identifier[:]) {
+		return nil, errors.New("astc/ktx2: not a KTX2 file")
+	}
+	le := binary.LittleEndian
+	u32 := func(off int) uint32 { return le.Uint32(data[off:]) }
+
+	blockX, blockY, profile, err := parseVkFormat(u32(12))
+	if err != nil {
+		return nil, err
+	}
+	if u32(28) > 1 {
+		return nil, errors.New("astc/ktx2: 3D textures are not supported")
+	}
+	t := &Texture{
+		BlockX:  blockX,
+		BlockY:  blockY,
+		Width:   int(u32(20)),
+		Height:  int(u32(24)),
+		Layers:  max(int(u32(32)), 1),
+		Faces:   int(u32(36)),
+		Profile: profile,
+	}
+	levelCount := int(u32(40))
+	sc := Supercompression(u32(44))
+	if sc != SupercompressionNone && sc != SupercompressionZstd {
+		return nil, fmt.Errorf("astc/ktx2: unsupported supercompression scheme %d", sc)
+	}
+	if t.Width > astc.MaxImageDim || t.Height > astc.MaxImageDim || t.Layers > astc.MaxImageDim {
+		return nil, errors.New("astc/ktx2: invalid texture dimensions")
+	}
+	if t.Faces != 1 && t.Faces != astc.CubeFaceCount {
+		return nil, errors.New("astc/ktx2: face count must be 1 or 6")
+	}
+	if levelCount == 0 || levelCount > astc.MaxMipLevels(t.Width, t.Height, 1) {
+		return nil, errors.New("astc/ktx2: invalid level count")
+	}
+	if len(data) < headerSize+levelIndexSize*levelCount {
+		return nil, errors.New("astc/ktx2: truncated level index")
+	}
+	if err := checkDFD(data, blockX, blockY); err != nil {
+		return nil, err
+	}
+
+	// The header is untrusted: check it against the decode ceilings before anything is sized
+	// from it.
+	maxSize, err := t.LevelSize(0)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkLimits(t, maxSize); err != nil {
+		return nil, err
+	}
+
+	var dec *zstd.Decoder
+	if sc == SupercompressionZstd {
+		// Bound decoder memory by the largest level (the base level), leaving room for the zstd
+		// window of small levels.
+		if dec, err = zstd.NewReader(nil, zstd.WithDecoderConcurrency(1), zstd.WithDecoderMaxMemory(uint64(max(maxSize, 1<<20)))); err != nil {
+			return nil, err
+		}
+		defer dec.Close()
+	}
+
+	t.Levels = make([][]byte, levelCount)
+	for i := range t.Levels {
+		want, err := t.LevelSize(i)
+		if err != nil {
+			return nil, err
+		}
+		idx := data[headerSize+levelIndexSize*i:]
+		off, length, uncompressed := le.Uint64(idx[0:]), le.Uint64(idx[8:]), le.Uint64(idx[16:])
+		if off > uint64(len(data)) || length > uint64(len(data))-off {
+			return nil, fmt.Errorf("astc/ktx2: level %d outside file", i)
+		}
+		if uncompressed != uint64(want) {
+			return nil, fmt.Errorf("astc/ktx2: level %d holds %d bytes, want %d", i, uncompressed, want)
+		}
+		src := data[off : off+length]
+		switch sc {
+		case SupercompressionNone:
+			if length != uncompressed {
+				return nil, fmt.Errorf("astc/ktx2: level %d holds %d bytes, want %d", i, length, want)
+			}
+			t.Levels[i] = bytes.Clone(src)
+		case SupercompressionZstd:
+			// DecodeAll allocates from the declared content size of the frame.
+			var fh zstd.Header
+			if err := fh.Decode(src); err != nil {
+				return nil, fmt.Errorf("astc/ktx2: level %d: %w", i, err)
+			}
+			if fh.HasFCS && fh.FrameContentSize != uncompressed {
+				return nil, fmt.Errorf("astc/ktx2: level %d declares %d bytes, want %d", i, fh.FrameContentSize, want)
+			}
+			level, err := dec.DecodeAll(src, make([]byte, 0, want))
+			if err != nil {
+				return nil, fmt.Errorf("astc/ktx2: level %d: %w", i, err)
+			}
+			if len(level) != want {
+				return nil, fmt.Errorf("astc/ktx2: level %d inflates to %d bytes, want %d", i, len(level), want)
+			}
+			t.Levels[i] = level
+		}
+	}
+	if err := t.validate(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// checkLimits returns a *astc.DecodeLimitError if the texture, with base level size baseSize,
+// exceeds astc.DefaultMaxPixels or astc.DefaultMaxBlocks across its layers and faces.
+func checkLimits(t *Texture, baseSize int) error {
+	images := int64(t.Layers) * int64(t.Faces)
+	pixels := int64(t.Width) * int64(t.Height)
+	if pixels > math.MaxInt64/images {
+		pixels = math.MaxInt64
+	} else {
+		pixels *= images
+	}
+	blocks := int64(baseSize / astc.BlockBytes)
+	if pixels > astc.DefaultMaxPixels || blocks > astc.DefaultMaxBlocks {
+		h := astc.Header{BlockX: uint8(t.BlockX), BlockY: uint8(t.BlockY), BlockZ: 1, SizeX: uint32(t.Width), SizeY: uint32(t.Height), SizeZ: 1}
+		return &astc.DecodeLimitError{Header: h, Pixels: pixels, Blocks: blocks, MaxPixels: astc.DefaultMaxPixels, MaxBlocks: astc.DefaultMaxBlocks}
+	}
+	return nil
+}
+
+func checkDFD(data []byte, blockX, blockY int) error {
+	le := binary.LittleEndian
+	off, length := le.Uint32(data[48:]), le.Uint32(data[52:])
+	if length < dfdSize || uint64(off)+uint64(length) > uint64(len(data)) {
+		return errors.New("astc/ktx2: missing data format descriptor")
+	}
+	dfd := data[off : off+length]
+	if le.Uint32(dfd[12:])&0xFF != dfModelASTC {
+		return errors.New("astc/ktx2: data format descriptor is not ASTC")
+	}
+	dims := le.Uint32(dfd[16:])
+	if int(dims&0xFF)+1 != blockX || int(dims>>8&0xFF)+1 != blockY || dims>>16&0xFF != 0 {
+		return errors.New("astc/ktx2: data format descriptor block size does not match VkFormat")
+	}
+	return nil
+}
+
+// FromASTC converts a 2D .astc file to a single-level KTX2 file. The .astc header does not record
+// the color profile, so it is passed explicitly.
+func FromASTC(astcData []byte, profile astc.Profile, opts Options) ([]byte, error) {
+	h, blocks, err := astc.ParseFile(astcData)
+	if err != nil {
+		return nil, err
+	}
+	if h.BlockZ != 1 || h.SizeZ != 1 {
+		return nil, errors.New("astc/ktx2: only 2D images are supported")
+	}
+	t := &Texture{
+		BlockX:  int(h.BlockX),
+		BlockY:  int(h.BlockY),
+		Width:   int(h.SizeX),
+		Height:  int(h.SizeY),
+		Layers:  1,
+		Faces:   1,
+		Profile: profile,
+		Levels:  [][]byte{blocks},
+	}
+	return Marshal(t, opts)
+}
+
+// ToASTC converts the base level of the first layer and face of a KTX2 file to a .astc file.
+func ToASTC(ktx2Data []byte) ([]byte, error) {
+	t, err := Unmarshal(ktx2Data)
+	if err != nil {
+		return nil, err
+	}
+	return t.ASTC(0, 0, 0)
+}
+
+// ASTC returns one subresource of t as a .astc file.
+func (t *Texture) ASTC(level, layer int, face astc.CubeFace) ([]byte, error) {
+	if level < 0 || level >= len(t.Levels) || layer < 0 || layer >= t.Layers || int(face) < 0 || int(face) >= t.Faces {
+		return nil, errors.New("astc/ktx2: subresource out of range")
+	}
+	w, h := max(t.Width>>level, 1), max(t.Height>>level, 1)
+	n, err := astc.EncodedSize(w, h, 1, t.BlockX, t.BlockY, 1)
+	if err != nil {
+		return nil, err
+	}
+	hdr, err := astc.MarshalHeader(astc.Header{
+		BlockX: uint8(t.BlockX), BlockY: uint8(t.BlockY), BlockZ: 1,
+		SizeX: uint32(w), SizeY: uint32(h), SizeZ: 1,
+	})
+	if err != nil {
+		return nil, err
+	}
+	off := (layer*t.Faces + int(face)) * n
+	if off+n > len(t.Levels[level]) {
+		return nil, fmt.Errorf("astc/ktx2: level %d holds %d bytes, subresource needs %d", level, len(t.Levels[level]), off+n)
+	}
+	out := make([]byte, 0, astc.HeaderSize+n)
+	out = append(out, hdr[:]...)
+	return append(out, t.Levels[level][off:off+n]...), nil
+}
+
+// FromImageSet builds a Texture from the blocks of every subresource of set, as produced by
+// Context.CompressSubresource into a buffer of set.CompressedSize bytes. The set must be 2D.
+func FromImageSet(set *astc.ImageSet, blocks []byte, blockX, blockY int, profile astc.Profile) (*Texture, error) {
+	if set.DimZ != 1 {
+		return nil, errors.New("astc/ktx2: only 2D image sets are supported")
+	}
+	t := &Texture{
+		BlockX:  blockX,
+		BlockY:  blockY,
+		Width:   set.DimX,
+		Height:  set.DimY,
+		Layers:  set.Layers,
+		Faces:   set.Faces,
+		Profile: profile,
+		Levels:  make([][]byte, set.Levels),
+	}
+	for level := range t.Levels {
+		first, _, err := set.BlockRange(astc.Subresource{Level: level}, blockX, blockY, 1)
+		if err != nil {
+			return nil, err
+		}
+		n, err := t.LevelSize(level)
+		if err != nil {
+			return nil, err
+		}
+		if first+n > len(blocks) {
+			return nil, errors.New("astc/ktx2: block buffer too small")
+		}
+		t.Levels[level] = blocks[first : first+n]
+	}
+	if err := t.validate(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
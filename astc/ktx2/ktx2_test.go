@@ -0,0 +1,202 @@
+package ktx2_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"github.com/arm-software/astc-encoder/astc"
+	"github.com/arm-software/astc-encoder/astc/ktx2"
+	"github.com/arm-software/astc-encoder/astc/testimage"
+)
+
+func TestFromASTC_RoundTrip(t *testing.T) {
+	const w, h = 40, 30
+	pix, err := testimage.RGBA8(testimage.KindText, w, h, 1, testimage.Options{})
+	if err != nil {
+		t.Fatalf("RGBA8: %v", err)
+	}
+	astcData, err := astc.EncodeRGBA8WithProfileAndQuality(pix, w, h, 6, 6, astc.ProfileLDRSRGB, astc.EncodeFast)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+
+	for _, sc := range []ktx2.Supercompression{ktx2.SupercompressionNone, ktx2.SupercompressionZstd} {
+		data, err := ktx2.FromASTC(astcData, astc.ProfileLDRSRGB, ktx2.Options{Supercompression: sc})
+		if err != nil {
+			t.Fatalf("scheme %d: FromASTC: %v", sc, err)
+		}
+		le := binary.LittleEndian
+		if got := le.Uint32(data[12:]); got != 166 { // VK_FORMAT_ASTC_6x6_SRGB_BLOCK
+			t.Fatalf("scheme %d: vkFormat = %d, want 166", sc, got)
+		}
+		if got := le.Uint32(data[44:]); got != uint32(sc) {
+			t.Fatalf("scheme %d: supercompressionScheme = %d", sc, got)
+		}
+		dfd := data[le.Uint32(data[48:]):]
+		if model, transfer := dfd[12], dfd[14]; model != 162 || transfer != 2 {
+			t.Fatalf("scheme %d: DFD color model %d transfer %d, want 162 (ASTC) and 2 (sRGB)", sc, model, transfer)
+		}
+		if dfd[16] != 5 || dfd[17] != 5 {
+			t.Fatalf("scheme %d: DFD block dimensions %d %d, want 5 5", sc, dfd[16], dfd[17])
+		}
+		wantPlane := byte(16)
+		if sc != ktx2.SupercompressionNone {
+			wantPlane = 0
+		}
+		if dfd[20] != wantPlane {
+			t.Fatalf("scheme %d: bytesPlane0 = %d, want %d", sc, dfd[20], wantPlane)
+		}
+		if !bytes.Contains(data, []byte("KTXwriter\x00"+ktx2.DefaultWriter+"\x00")) {
+			t.Fatalf("scheme %d: missing KTXwriter", sc)
+		}
+		if sc == ktx2.SupercompressionNone && le.Uint64(data[80:])%16 != 0 {
+			t.Fatalf("uncompressed level is not 16-byte aligned")
+		}
+
+		back, err := ktx2.ToASTC(data)
+		if err != nil {
+			t.Fatalf("scheme %d: ToASTC: %v", sc, err)
+		}
+		if !bytes.Equal(back, astcData) {
+			t.Fatalf("scheme %d: round trip changed the .astc file", sc)
+		}
+	}
+}
+
+func TestMarshal_ZstdCompresses(t *testing.T) {
+	astcData, err := astc.EncodeRGBA8WithProfileAndQuality(make([]byte, 256*256*4), 256, 256, 4, 4, astc.ProfileLDR, astc.EncodeFast)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	plain, err := ktx2.FromASTC(astcData, astc.ProfileLDR, ktx2.Options{})
+	if err != nil {
+		t.Fatalf("FromASTC: %v", err)
+	}
+	packed, err := ktx2.FromASTC(astcData, astc.ProfileLDR, ktx2.Options{Supercompression: ktx2.SupercompressionZstd, ZstdLevel: 19})
+	if err != nil {
+		t.Fatalf("FromASTC zstd: %v", err)
+	}
+	if len(packed)*10 > len(plain) {
+		t.Fatalf("zstd file is %d bytes, uncompressed %d", len(packed), len(plain))
+	}
+}
+
+func TestImageSet_RoundTrip(t *testing.T) {
+	set, err := astc.NewImageSet(astc.TypeU8, 20, 20, 1, 0, 2, astc.CubeFaceCount)
+	if err != nil {
+		t.Fatalf("NewImageSet: %v", err)
+	}
+	size, err := set.CompressedSize(5, 4, 1)
+	if err != nil {
+		t.Fatalf("CompressedSize: %v", err)
+	}
+	blocks := make([]byte, size)
+	for i := range blocks {
+		blocks[i] = uint8(i*31 + i>>8)
+	}
+
+	tex, err := ktx2.FromImageSet(set, blocks, 5, 4, astc.ProfileHDR)
+	if err != nil {
+		t.Fatalf("FromImageSet: %v", err)
+	}
+	for _, sc := range []ktx2.Supercompression{ktx2.SupercompressionNone, ktx2.SupercompressionZstd} {
+		data, err := ktx2.Marshal(tex, ktx2.Options{Supercompression: sc, Writer: "test"})
+		if err != nil {
+			t.Fatalf("scheme %d: Marshal: %v", sc, err)
+		}
+		le := binary.LittleEndian
+		if got := le.Uint32(data[12:]); got != 1000066001 { // VK_FORMAT_ASTC_5x4_SFLOAT_BLOCK
+			t.Fatalf("scheme %d: vkFormat = %d", sc, got)
+		}
+		if layers, faces, levels := le.Uint32(data[32:]), le.Uint32(data[36:]), le.Uint32(data[40:]); layers != 2 || faces != 6 || levels != 5 {
+			t.Fatalf("scheme %d: layers %d faces %d levels %d, want 2 6 5", sc, layers, faces, levels)
+		}
+
+		got, err := ktx2.Unmarshal(data)
+		if err != nil {
+			t.Fatalf("scheme %d: Unmarshal: %v", sc, err)
+		}
+		if got.Width != 20 || got.Height != 20 || got.Layers != 2 || got.Faces != 6 || got.Profile != astc.ProfileHDR || len(got.Levels) != set.Levels {
+			t.Fatalf("scheme %d: unexpected texture %+v", sc, got)
+		}
+		for level := 0; level < set.Levels; level++ {
+			for layer := 0; layer < 2; layer++ {
+				for face := astc.CubeFace(0); face < astc.CubeFaceCount; face++ {
+					sub := astc.Subresource{Level: level, Layer: layer, Face: face}
+					off, n, err := set.BlockRange(sub, 5, 4, 1)
+					if err != nil {
+						t.Fatalf("BlockRange: %v", err)
+					}
+					file, err := got.ASTC(level, layer, face)
+					if err != nil {
+						t.Fatalf("ASTC(%+v): %v", sub, err)
+					}
+					if !bytes.Equal(file[astc.HeaderSize:], blocks[off:off+n]) {
+						t.Fatalf("scheme %d: subresource %+v differs", sc, sub)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestUnmarshal_Rejects(t *testing.T) {
+	astcData, err := astc.EncodeRGBA8WithProfileAndQuality(make([]byte, 8*8*4), 8, 8, 4, 4, astc.ProfileLDR, astc.EncodeFast)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	good, err := ktx2.FromASTC(astcData, astc.ProfileLDR, ktx2.Options{Supercompression: ktx2.SupercompressionZstd})
+	if err != nil {
+		t.Fatalf("FromASTC: %v", err)
+	}
+	if _, err := ktx2.Unmarshal(good); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+
+	mutate := func(f func(b []byte) []byte) []byte { return f(bytes.Clone(good)) }
+	le := binary.LittleEndian
+	cases := map[string][]byte{
+		"identifier":  mutate(func(b []byte) []byte { b[1] = 'X'; return b }),
+		"truncated":   good[:len(good)-4],
+		"vkFormat":    mutate(func(b []byte) []byte { le.PutUint32(b[12:], 37); return b }),
+		"basisLZ":     mutate(func(b []byte) []byte { le.PutUint32(b[44:], 1); return b }),
+		"faces":       mutate(func(b []byte) []byte { le.PutUint32(b[36:], 2); return b }),
+		"levels":      mutate(func(b []byte) []byte { le.PutUint32(b[40:], 9); return b }),
+		"uncompSize":  mutate(func(b []byte) []byte { le.PutUint64(b[96:], 32); return b }),
+		"dfd model":   mutate(func(b []byte) []byte { b[le.Uint32(b[48:])+12] = 128; return b }),
+		"zstd stream": mutate(func(b []byte) []byte { b[le.Uint64(b[80:])+6] ^= 0xFF; return b }),
+		// Turn the window descriptor into a single-segment content size of 32 bytes.
+		"zstd size": mutate(func(b []byte) []byte { off := le.Uint64(b[80:]); b[off+4] |= 0x20; b[off+5] = 32; return b }),
+	}
+	for name, data := range cases {
+		if _, err := ktx2.Unmarshal(data); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+
+	// A forged header claiming a texture far beyond the decode ceilings is rejected before the
+	// zstd decoder sizes anything from it.
+	huge := mutate(func(b []byte) []byte {
+		le.PutUint32(b[20:], 1<<20)
+		le.PutUint32(b[24:], 1<<20)
+		le.PutUint64(b[96:], 1<<36)
+		return b
+	})
+	var tooLarge *astc.DecodeLimitError
+	if _, err := ktx2.Unmarshal(huge); !errors.As(err, &tooLarge) {
+		t.Fatalf("huge texture: got %v, want *astc.DecodeLimitError", err)
+	}
+
+	if _, err := ktx2.Marshal(&ktx2.Texture{BlockX: 6, BlockY: 4, Width: 8, Height: 8, Layers: 1, Faces: 1, Levels: [][]byte{make([]byte, 64)}}, ktx2.Options{}); err == nil {
+		t.Fatalf("Marshal accepted a 6x4 footprint")
+	}
+	if _, err := ktx2.Marshal(&ktx2.Texture{BlockX: 4, BlockY: 4, Width: 8, Height: 8, Layers: 1, Faces: 1, Levels: [][]byte{make([]byte, 48)}}, ktx2.Options{}); err == nil {
+		t.Fatalf("Marshal accepted a short level")
+	}
+	short := &ktx2.Texture{BlockX: 4, BlockY: 4, Width: 8, Height: 8, Layers: 2, Faces: 1, Levels: [][]byte{make([]byte, 64)}}
+	if _, err := short.ASTC(0, 1, 0); err == nil {
+		t.Fatalf("ASTC accepted a level too short for the layer")
+	}
+}
@@ -0,0 +1,43 @@
+package astc
+
+// EncodeLA8 encodes a two-channel LA8 pixel buffer (2 bytes per texel, see LayoutLA) into a .astc
+// file.
+func EncodeLA8(pix []byte, width, height int, blockX, blockY int) ([]byte, error) {
+	return EncodeLA8WithProfileAndQuality(pix, width, height, blockX, blockY, ProfileLDR, EncodeMedium)
+}
+
+// EncodeLA8WithProfileAndQuality encodes a two-channel LA8 pixel buffer into a .astc file. The
+// texels are expanded to L, L, L, A while blocks are extracted, with no full-image copy. Any
+// two-channel data (e.g. RG) can be passed with its first channel in L and its second in A; decode
+// it with DecodeLA8WithProfile, or sample the R and A channels of the decoded texture.
+//
+// Deprecated: Use NewEncoder with WithBlockSize, WithProfile and WithQuality, and Encoder.Encode of
+// an Image with Layout LayoutLA.
+func EncodeLA8WithProfileAndQuality(pix []byte, width, height int, blockX, blockY int, profile Profile, quality EncodeQuality) ([]byte, error) {
+	return encode2DLayout(pix, LayoutLA, width, height, blockX, blockY, profile, quality)
+}
+
+// DecodeLA8 decodes a .astc file into a two-channel LA8 pixel buffer.
+func DecodeLA8(astcData []byte) (pix []byte, width, height int, err error) {
+	return DecodeLA8WithProfile(astcData, ProfileLDR)
+}
+
+// DecodeLA8WithProfile decodes a .astc file into a two-channel LA8 pixel buffer holding the R and
+// A channels of each decoded texel.
+//
+// Limitations:
+//   - Only 2D images (SizeZ==1, BlockZ==1).
+//   - Only LDR profiles (ProfileLDR, ProfileLDRSRGB).
+func DecodeLA8WithProfile(astcData []byte, profile Profile) (pix []byte, width, height int, err error) {
+	rgba, width, height, err := DecodeRGBA8WithProfile(astcData, profile)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	// Compact in place: texel i moves from offset 4i to 2i, which never overtakes the reads.
+	n := width * height
+	for i := 0; i < n; i++ {
+		rgba[i*2+0] = rgba[i*4+0]
+		rgba[i*2+1] = rgba[i*4+3]
+	}
+	return rgba[: n*2 : n*2], width, height, nil
+}
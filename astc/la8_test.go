@@ -0,0 +1,57 @@
+package astc_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/arm-software/astc-encoder/astc"
+)
+
+func TestEncodeDecodeLA8(t *testing.T) {
+	const w, h = 21, 13
+	la := make([]byte, w*h*2)
+	rgba := make([]byte, w*h*4)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			// A distance-field-like ramp in L and an unrelated mask in A.
+			i := y*w + x
+			l := uint8(min(255, 12*(x+y)))
+			a := uint8(255 * ((x / 4) % 2))
+			copy(la[i*2:], []byte{l, a})
+			copy(rgba[i*4:], []byte{l, l, l, a})
+		}
+	}
+
+	data, err := astc.EncodeLA8WithProfileAndQuality(la, w, h, 6, 6, astc.ProfileLDR, astc.EncodeThorough)
+	if err != nil {
+		t.Fatalf("EncodeLA8WithProfileAndQuality: %v", err)
+	}
+	want, err := astc.EncodeRGBA8WithProfileAndQuality(rgba, w, h, 6, 6, astc.ProfileLDR, astc.EncodeThorough)
+	if err != nil {
+		t.Fatalf("EncodeRGBA8WithProfileAndQuality: %v", err)
+	}
+	if !bytes.Equal(data, want) {
+		t.Fatalf("LA8 encode differs from the equivalent RGBA8 encode")
+	}
+
+	got, gw, gh, err := astc.DecodeLA8(data)
+	if err != nil {
+		t.Fatalf("DecodeLA8: %v", err)
+	}
+	full, _, _, err := astc.DecodeRGBA8(data)
+	if err != nil {
+		t.Fatalf("DecodeRGBA8: %v", err)
+	}
+	if gw != w || gh != h || len(got) != w*h*2 {
+		t.Fatalf("DecodeLA8: %dx%d, %d bytes", gw, gh, len(got))
+	}
+	for i := 0; i < w*h; i++ {
+		if got[i*2] != full[i*4] || got[i*2+1] != full[i*4+3] {
+			t.Fatalf("texel %d: got %v, want R=%d A=%d", i, got[i*2:i*2+2], full[i*4], full[i*4+3])
+		}
+	}
+
+	if _, err := astc.EncodeLA8(rgba, w, h, 6, 6); err == nil {
+		t.Fatalf("expected error for a 4-byte-per-texel buffer")
+	}
+}
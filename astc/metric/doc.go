@@ -0,0 +1,14 @@
+// Package metric computes whole-image perceptual quality metrics of decoded outputs against their
+// sources, so quality gates and encoder comparisons can use more than PSNR without shelling out
+// to external tools.
+//
+//   - SSIM is the structural similarity index of Wang et al. (2004) with the usual 11x11 Gaussian
+//     window (sigma 1.5), computed per RGB channel and averaged. It follows the conventions of
+//     scikit-image's structural_similarity with gaussian_weights=True, use_sample_covariance=False and
+//     data_range=255.
+//   - FLIP is the LDR variant of NVIDIA's FLIP difference evaluator (Andersson et al. 2020), which
+//     models how differences are perceived when flipping between two images on a display.
+//
+// Images are tightly packed 8-bit RGBA buffers such as the astc package's RGBA8 decoders return,
+// with RGB holding sRGB-encoded values; alpha is ignored.
+package metric
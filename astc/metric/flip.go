@@ -0,0 +1,262 @@
+package metric
+
+import (
+	"errors"
+	"math"
+)
+
+// DefaultPixelsPerDegree is the FLIP observer setup of its authors' reference implementation: a
+// 0.7 m wide 3840-pixel display viewed from 0.7 m.
+const DefaultPixelsPerDegree = 67.0206
+
+// FLIP constants from the reference implementation.
+const (
+	flipQc = 0.7   // Color difference exponent.
+	flipPc = 0.4   // Color difference redistribution cut-off, relative to its maximum.
+	flipPt = 0.95  // Redistributed value at the cut-off.
+	flipQf = 0.5   // Feature difference exponent.
+	flipW  = 0.082 // Feature detector width, in degrees.
+)
+
+// FLIP returns the mean LDR-FLIP error of test against ref, both width x height RGBA8 images:
+// 0 for identical images, up to 1 for the most perceptible differences. ppd is the number of
+// pixels per degree of visual angle of the viewing setup; 0 selects DefaultPixelsPerDegree.
+func FLIP(ref, test []byte, width, height int, ppd float64) (float64, error) {
+	errMap, err := FLIPMap(ref, test, width, height, ppd)
+	if err != nil {
+		return 0, err
+	}
+	sum := 0.0
+	for _, v := range errMap {
+		sum += float64(v)
+	}
+	return sum / float64(len(errMap)), nil
+}
+
+// FLIPMap returns the per-pixel LDR-FLIP error of test against ref in row-major order; FLIP is its
+// mean.
+func FLIPMap(ref, test []byte, width, height int, ppd float64) ([]float32, error) {
+	if err := checkImages(ref, test, width, height); err != nil {
+		return nil, err
+	}
+	if ppd == 0 {
+		ppd = DefaultPixelsPerDegree
+	}
+	if !(ppd > 0) || math.IsInf(ppd, 0) {
+		return nil, errors.New("astc/metric: invalid pixels per degree")
+	}
+
+	n := width * height
+	refYCC, testYCC := toYCxCz(ref, n), toYCxCz(test, n)
+
+	// Color pipeline: filter with the contrast sensitivity functions in YCxCz, then compare the
+	// Hunt-adjusted CIELAB values with the HyAB distance.
+	refLab := filteredHuntLab(refYCC, width, height, ppd)
+	testLab := filteredHuntLab(testYCC, width, height, ppd)
+	cmax := math.Pow(hyab(huntLab(linearToLab([3]float64{0, 1, 0})), huntLab(linearToLab([3]float64{0, 0, 1}))), flipQc)
+
+	// Feature pipeline: edges and points of the normalized achromatic channel.
+	refEdges, refPoints := features(refYCC[0], width, height, ppd)
+	testEdges, testPoints := features(testYCC[0], width, height, ppd)
+
+	out := make([]float32, n)
+	for i := range out {
+		dc := math.Pow(hyab(refLab[i], testLab[i]), flipQc)
+		if dc < flipPc*cmax {
+			dc *= flipPt / (flipPc * cmax)
+		} else {
+			dc = flipPt + (dc-flipPc*cmax)/(cmax-flipPc*cmax)*(1-flipPt)
+		}
+		df := math.Pow(max(math.Abs(refEdges[i]-testEdges[i]), math.Abs(refPoints[i]-testPoints[i]))/math.Sqrt2, flipQf)
+		out[i] = float32(math.Pow(dc, 1-df))
+	}
+	return out, nil
+}
+
+// whiteXYZ is the XYZ of linear RGB white, the reference illuminant of the FLIP color spaces.
+var whiteXYZ = linearToXYZ([3]float64{1, 1, 1})
+
+func srgbToLinear(v byte) float64 {
+	c := float64(v) / 255
+	if c <= 0.04045 {
+		return c / 12.92
+	}
+	return math.Pow((c+0.055)/1.055, 2.4)
+}
+
+// linearToXYZ and xyzToLinear use the matrices of the reference implementation: the exact
+// sRGB-primaries matrix and its published inverse.
+func linearToXYZ(c [3]float64) [3]float64 {
+	return [3]float64{
+		(10135552*c[0] + 8788810*c[1] + 4435075*c[2]) / 24577794,
+		(2613072*c[0] + 8788810*c[1] + 887015*c[2]) / 12288897,
+		(1425312*c[0] + 8788810*c[1] + 70074185*c[2]) / 73733382,
+	}
+}
+
+func xyzToLinear(c [3]float64) [3]float64 {
+	return [3]float64{
+		3.241003275*c[0] - 1.537398934*c[1] - 0.498615861*c[2],
+		-0.969224334*c[0] + 1.875930071*c[1] + 0.041554224*c[2],
+		0.055639423*c[0] - 0.204011202*c[1] + 1.057148933*c[2],
+	}
+}
+
+// toYCxCz converts the RGB of n sRGB RGBA8 pixels to three YCxCz planes.
+func toYCxCz(pix []byte, n int) [3][]float64 {
+	var out [3][]float64
+	for c := range out {
+		out[c] = make([]float64, n)
+	}
+	for i := range n {
+		xyz := linearToXYZ([3]float64{srgbToLinear(pix[i*4]), srgbToLinear(pix[i*4+1]), srgbToLinear(pix[i*4+2])})
+		x, y, z := xyz[0]/whiteXYZ[0], xyz[1]/whiteXYZ[1], xyz[2]/whiteXYZ[2]
+		out[0][i] = 116*y - 16
+		out[1][i] = 500 * (x - y)
+		out[2][i] = 200 * (y - z)
+	}
+	return out
+}
+
+// csfParams are the a1, b1, a2, b2 parameters of the contrast sensitivity functions of the
+// achromatic, red-green and blue-yellow channels.
+var csfParams = [3][4]float64{
+	{1, 0.0047, 0, 1e-5},
+	{1, 0.0053, 0, 1e-5},
+	{34.1, 0.04, 13.5, 0.025},
+}
+
+// filteredHuntLab filters the YCxCz planes with the contrast sensitivity functions and returns the
+// Hunt-adjusted CIELAB color of every pixel.
+func filteredHuntLab(ycc [3][]float64, width, height int, ppd float64) [][3]float64 {
+	n := width * height
+	radius := int(math.Ceil(3 * math.Sqrt(0.04/(2*math.Pi*math.Pi)) * ppd))
+	tmp := make([]float64, n)
+	var filtered [3][]float64
+	for c, p := range csfParams {
+		// Each CSF is a sum of two 2D Gaussians, each separable; the sum is normalized as a whole.
+		var parts [2][]float64
+		var weights [2]float64
+		total := 0.0
+		for k := range parts {
+			a, b := p[2*k], p[2*k+1]
+			if a == 0 {
+				continue
+			}
+			h := make([]float64, 2*radius+1)
+			s := 0.0
+			for i := range h {
+				x := float64(i-radius) / ppd
+				h[i] = math.Exp(-math.Pi * math.Pi * x * x / b)
+				s += h[i]
+			}
+			weights[k] = a * math.Sqrt(math.Pi/b) * s * s
+			total += weights[k]
+			for i := range h {
+				h[i] /= s
+			}
+			parts[k] = append([]float64(nil), ycc[c]...)
+			convolveSeparable(parts[k], tmp, width, height, h, h)
+		}
+		filtered[c] = make([]float64, n)
+		for k, part := range parts {
+			for i, v := range part {
+				filtered[c][i] += v * weights[k] / total
+			}
+		}
+	}
+
+	out := make([][3]float64, n)
+	for i := range out {
+		yn := (filtered[0][i] + 16) / 116
+		xyz := [3]float64{(filtered[1][i]/500 + yn) * whiteXYZ[0], yn * whiteXYZ[1], (yn - filtered[2][i]/200) * whiteXYZ[2]}
+		rgb := xyzToLinear(xyz)
+		for c := range rgb {
+			rgb[c] = min(max(rgb[c], 0), 1)
+		}
+		out[i] = huntLab(linearToLab(rgb))
+	}
+	return out
+}
+
+func linearToLab(rgb [3]float64) [3]float64 {
+	xyz := linearToXYZ(rgb)
+	const delta = 6.0 / 29
+	var f [3]float64
+	for c := range f {
+		t := xyz[c] / whiteXYZ[c]
+		if t > delta*delta*delta {
+			f[c] = math.Cbrt(t)
+		} else {
+			f[c] = t/(3*delta*delta) + 4.0/29
+		}
+	}
+	return [3]float64{116*f[1] - 16, 500 * (f[0] - f[1]), 200 * (f[1] - f[2])}
+}
+
+func huntLab(lab [3]float64) [3]float64 {
+	return [3]float64{lab[0], 0.01 * lab[0] * lab[1], 0.01 * lab[0] * lab[2]}
+}
+
+// hyab is the HyAB color distance: city-block in lightness, Euclidean in chroma.
+func hyab(a, b [3]float64) float64 {
+	return math.Abs(a[0]-b[0]) + math.Hypot(a[1]-b[1], a[2]-b[2])
+}
+
+// features returns the edge and point detector magnitudes of the YCxCz achromatic plane y,
+// normalized to luminance relative to white.
+func features(y []float64, width, height int, ppd float64) (edges, points []float64) {
+	sigma := 0.5 * flipW * ppd
+	radius := int(math.Ceil(3 * sigma))
+	g := make([]float64, 2*radius+1)
+	d1 := make([]float64, 2*radius+1)
+	d2 := make([]float64, 2*radius+1)
+	sumG := 0.0
+	for i := range g {
+		x := float64(i - radius)
+		g[i] = math.Exp(-x * x / (2 * sigma * sigma))
+		d1[i] = -x * g[i]
+		d2[i] = (x*x/(sigma*sigma) - 1) * g[i]
+		sumG += g[i]
+	}
+	// The 2D detectors are d(x)g(y); their positive and negative lobes are each normalized to
+	// sum to 1 and -1, which scales d's lobes and g separately.
+	for _, d := range [][]float64{d1, d2} {
+		pos, neg := 0.0, 0.0
+		for _, v := range d {
+			if v > 0 {
+				pos += v
+			} else {
+				neg -= v
+			}
+		}
+		for i, v := range d {
+			if v > 0 {
+				d[i] = v / pos
+			} else {
+				d[i] = v / neg
+			}
+		}
+	}
+	for i := range g {
+		g[i] /= sumG
+	}
+
+	n := width * height
+	norm := make([]float64, n)
+	for i, v := range y {
+		norm[i] = (v + 16) / 116
+	}
+	tmp := make([]float64, n)
+	magnitude := func(d []float64) []float64 {
+		gx := append([]float64(nil), norm...)
+		gy := append([]float64(nil), norm...)
+		convolveSeparable(gx, tmp, width, height, d, g)
+		convolveSeparable(gy, tmp, width, height, g, d)
+		for i := range gx {
+			gx[i] = math.Hypot(gx[i], gy[i])
+		}
+		return gx
+	}
+	return magnitude(d1), magnitude(d2)
+}
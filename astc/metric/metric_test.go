@@ -0,0 +1,143 @@
+package metric_test
+
+import (
+	"image"
+	"image/draw"
+	"image/png"
+	"math"
+	"os"
+	"testing"
+
+	"github.com/arm-software/astc-encoder/astc"
+	"github.com/arm-software/astc-encoder/astc/metric"
+	"github.com/arm-software/astc-encoder/astc/testimage"
+)
+
+func decodeAt(t *testing.T, src []byte, w, h, block int) []byte {
+	t.Helper()
+	enc, err := astc.NewEncoder(astc.WithBlockSize(block, block))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := enc.EncodeRGBA8(src, w, h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pix, _, _, err := astc.DecodeRGBA8WithProfile(data, astc.ProfileLDR)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pix
+}
+
+func TestMetrics(t *testing.T) {
+	const w, h = 64, 48
+	src, err := testimage.RGBA8(testimage.KindPerlin, w, h, 1, testimage.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fine, coarse := decodeAt(t, src, w, h, 4), decodeAt(t, src, w, h, 12)
+
+	ssim := func(test []byte) float64 {
+		t.Helper()
+		v, err := metric.SSIM(src, test, w, h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+	flip := func(test []byte) float64 {
+		t.Helper()
+		v, err := metric.FLIP(src, test, w, h, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+
+	if v := ssim(src); v < 1-1e-12 || v > 1+1e-12 {
+		t.Fatalf("SSIM of identical images = %v", v)
+	}
+	if v := flip(src); v != 0 {
+		t.Fatalf("FLIP of identical images = %v", v)
+	}
+	sFine, sCoarse := ssim(fine), ssim(coarse)
+	fFine, fCoarse := flip(fine), flip(coarse)
+	t.Logf("4x4: SSIM %.4f FLIP %.4f; 12x12: SSIM %.4f FLIP %.4f", sFine, fFine, sCoarse, fCoarse)
+	if !(sCoarse < sFine && sFine < 1) {
+		t.Fatalf("SSIM does not rank the encodings: 4x4 %v, 12x12 %v", sFine, sCoarse)
+	}
+	if !(fCoarse > fFine && fFine > 0) {
+		t.Fatalf("FLIP does not rank the encodings: 4x4 %v, 12x12 %v", fFine, fCoarse)
+	}
+
+	// Black against white is close to the largest FLIP error.
+	black, white := make([]byte, w*h*4), make([]byte, w*h*4)
+	for i := range white {
+		white[i] = 255
+	}
+	if v, err := metric.FLIP(black, white, w, h, 0); err != nil || v < 0.9 || v > 1 {
+		t.Fatalf("FLIP(black, white) = %v, %v", v, err)
+	}
+	errMap, err := metric.FLIPMap(src, coarse, w, h, metric.DefaultPixelsPerDegree)
+	if err != nil || len(errMap) != w*h {
+		t.Fatalf("FLIPMap: %d values, %v", len(errMap), err)
+	}
+
+	if _, err := metric.SSIM(src[:8*8*4], src[:8*8*4], 8, 8); err == nil {
+		t.Fatal("SSIM: expected error for an image smaller than the window")
+	}
+	if _, err := metric.FLIP(src, src[4:], w, h, 0); err == nil {
+		t.Fatal("FLIP: expected error for a short buffer")
+	}
+	if _, err := metric.FLIP(src, src, w, h, -1); err == nil {
+		t.Fatal("FLIP: expected error for negative pixels per degree")
+	}
+}
+
+func readRGBA8(t *testing.T, path string) ([]byte, int, int) {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil {
+		t.Fatalf("%s: %v", path, err)
+	}
+	b := img.Bounds()
+	rgba := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, b.Min, draw.Src)
+	return rgba.Pix, b.Dx(), b.Dy()
+}
+
+// TestMetrics_ReferenceValues compares both metrics of a fixture pair with fixed reference values.
+// testdata/reference.py writes the pair and prints the values: from scikit-image's
+// structural_similarity and NVIDIA's flip_evaluator when they are installed, and from its own
+// dependency-free transcription of both, with their full 2D kernels, otherwise. The values below
+// come from the transcription. The images are constant within 12 pixels of their edges, so the
+// border handling of the implementations does not matter.
+func TestMetrics_ReferenceValues(t *testing.T) {
+	const wantSSIM, wantFLIP = 0.872591, 0.057886
+	ref, w, h := readRGBA8(t, "testdata/metric_ref.png")
+	test, tw, th := readRGBA8(t, "testdata/metric_test.png")
+	if tw != w || th != h {
+		t.Fatalf("fixture sizes differ: %dx%d and %dx%d", w, h, tw, th)
+	}
+
+	ssim, err := metric.SSIM(ref, test, w, h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	flip, err := metric.FLIP(ref, test, w, h, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(ssim-wantSSIM) > 1e-5 {
+		t.Errorf("SSIM = %.6f, want %.6f", ssim, wantSSIM)
+	}
+	if math.Abs(flip-wantFLIP) > 1e-5 {
+		t.Errorf("FLIP = %.6f, want %.6f", flip, wantFLIP)
+	}
+}
@@ -0,0 +1,104 @@
+package metric
+
+import (
+	"errors"
+	"math"
+)
+
+const (
+	ssimRadius = 5
+	ssimSigma  = 1.5
+	ssimC1     = (0.01 * 255) * (0.01 * 255)
+	ssimC2     = (0.03 * 255) * (0.03 * 255)
+)
+
+// SSIM returns the mean structural similarity of the RGB channels of test against ref, both
+// width x height RGBA8 images: 1 for identical images, lower for less similar ones. The mean is
+// taken over the pixels whose 11x11 window lies inside the image, so both dimensions must be at
+// least 11.
+func SSIM(ref, test []byte, width, height int) (float64, error) {
+	if err := checkImages(ref, test, width, height); err != nil {
+		return 0, err
+	}
+	if width < 2*ssimRadius+1 || height < 2*ssimRadius+1 {
+		return 0, errors.New("astc/metric: SSIM needs images of at least 11x11 pixels")
+	}
+	kernel := gaussianKernel(ssimRadius, ssimSigma)
+
+	n := width * height
+	planes := [5][]float64{}
+	for i := range planes {
+		planes[i] = make([]float64, n)
+	}
+	tmp := make([]float64, n)
+	sum := 0.0
+	for c := range 3 {
+		for i := range n {
+			a, b := float64(ref[i*4+c]), float64(test[i*4+c])
+			planes[0][i], planes[1][i] = a, b
+			planes[2][i], planes[3][i], planes[4][i] = a*a, b*b, a*b
+		}
+		for _, p := range planes {
+			convolveSeparable(p, tmp, width, height, kernel, kernel)
+		}
+		for py := ssimRadius; py < height-ssimRadius; py++ {
+			for px := ssimRadius; px < width-ssimRadius; px++ {
+				i := py*width + px
+				mx, my := planes[0][i], planes[1][i]
+				vx, vy, cxy := planes[2][i]-mx*mx, planes[3][i]-my*my, planes[4][i]-mx*my
+				sum += (2*mx*my + ssimC1) * (2*cxy + ssimC2) / ((mx*mx + my*my + ssimC1) * (vx + vy + ssimC2))
+			}
+		}
+	}
+	return sum / float64(3*(width-2*ssimRadius)*(height-2*ssimRadius)), nil
+}
+
+func checkImages(ref, test []byte, width, height int) error {
+	if width <= 0 || height <= 0 {
+		return errors.New("astc/metric: invalid image dimensions")
+	}
+	if len(ref) != width*height*4 || len(test) != width*height*4 {
+		return errors.New("astc/metric: image buffers must hold width*height RGBA8 pixels")
+	}
+	return nil
+}
+
+// gaussianKernel returns the normalized 1D Gaussian of the given radius and standard deviation.
+func gaussianKernel(radius int, sigma float64) []float64 {
+	k := make([]float64, 2*radius+1)
+	sum := 0.0
+	for i := range k {
+		x := float64(i - radius)
+		k[i] = math.Exp(-x * x / (2 * sigma * sigma))
+		sum += k[i]
+	}
+	for i := range k {
+		k[i] /= sum
+	}
+	return k
+}
+
+// convolveSeparable convolves the width x height plane p in place with kx along rows and ky along
+// columns, clamping coordinates to the image edge; tmp is scratch space of the same size.
+func convolveSeparable(p, tmp []float64, width, height int, kx, ky []float64) {
+	rx, ry := len(kx)/2, len(ky)/2
+	for y := range height {
+		row := p[y*width : (y+1)*width]
+		for x := range width {
+			s := 0.0
+			for k, w := range kx {
+				s += w * row[min(max(x+k-rx, 0), width-1)]
+			}
+			tmp[y*width+x] = s
+		}
+	}
+	for y := range height {
+		for x := range width {
+			s := 0.0
+			for k, w := range ky {
+				s += w * tmp[min(max(y+k-ry, 0), height-1)*width+x]
+			}
+			p[y*width+x] = s
+		}
+	}
+}
@@ -0,0 +1,254 @@
+#!/usr/bin/env python3
+"""Writes the metric test fixture pair and prints its SSIM and LDR-FLIP reference values.
+
+The values are computed twice when the reference tools are installed: by scikit-image's
+structural_similarity (gaussian_weights=True, sigma=1.5, use_sample_covariance=False,
+data_range=255) and NVIDIA's flip_evaluator (LDR, default 67.0206 pixels per degree), and by the
+dependency-free transcription of both below, which uses their full 2D kernels rather than the
+separable filters of the Go package. Both images are constant within 12 pixels of their edges,
+wider than every filter radius, so the border handling of the implementations does not matter.
+
+Usage: python3 reference.py  (run from this directory)
+"""
+
+import math
+import struct
+import zlib
+
+W = H = 48
+BORDER = 12
+
+
+def fixture():
+    ref, test = [], []
+    for y in range(H):
+        for x in range(W):
+            if BORDER <= x < W - BORDER and BORDER <= y < H - BORDER:
+                u, v = x - BORDER, y - BORDER
+                r = 40 + u * 8
+                g = 200 - v * 6
+                b = 180 if (u // 4 + v // 4) % 2 else 60
+                ref.append((r, g, b, 255))
+                # A coarse red quantization, a green offset on the right half and a checker
+                # shifted by one pixel.
+                tb = 180 if ((u + 1) // 4 + v // 4) % 2 else 60
+                test.append(((r // 24) * 24 + 12, min(g + 10, 255) if u >= 12 else g, tb, 255))
+            else:
+                ref.append((90, 90, 90, 255))
+                test.append((90, 90, 90, 255))
+    return ref, test
+
+
+def write_png(path, pix):
+    raw = b"".join(b"\x00" + bytes(c for p in pix[y * W:(y + 1) * W] for c in p) for y in range(H))
+
+    def chunk(kind, data):
+        body = kind + data
+        return struct.pack(">I", len(data)) + body + struct.pack(">I", zlib.crc32(body))
+
+    with open(path, "wb") as f:
+        f.write(b"\x89PNG\r\n\x1a\n")
+        f.write(chunk(b"IHDR", struct.pack(">IIBBBBB", W, H, 8, 6, 0, 0, 0)))
+        f.write(chunk(b"IDAT", zlib.compress(raw, 9)))
+        f.write(chunk(b"IEND", b""))
+
+
+def convolve(plane, kernel):
+    """2D filtering of a row-major W x H plane with a square kernel, mirroring at the edges."""
+    r = len(kernel) // 2
+
+    def mirror(i, n):
+        while i < 0 or i >= n:
+            i = -i - 1 if i < 0 else 2 * n - i - 1
+        return i
+
+    out = []
+    for y in range(H):
+        for x in range(W):
+            s = 0.0
+            for ky in range(-r, r + 1):
+                row = mirror(y - ky, H) * W
+                krow = kernel[ky + r]
+                for kx in range(-r, r + 1):
+                    s += krow[kx + r] * plane[row + mirror(x - kx, W)]
+            out.append(s)
+    return out
+
+
+def ssim(ref, test):
+    sigma, r = 1.5, int(3.5 * 1.5 + 0.5)
+    g = [math.exp(-0.5 * (i - r) ** 2 / sigma ** 2) for i in range(2 * r + 1)]
+    s = sum(g)
+    kernel = [[a * b / (s * s) for b in g] for a in g]
+    c1, c2 = (0.01 * 255) ** 2, (0.03 * 255) ** 2
+    total = 0.0
+    for c in range(3):
+        x = [float(p[c]) for p in ref]
+        y = [float(p[c]) for p in test]
+        ux, uy = convolve(x, kernel), convolve(y, kernel)
+        uxx = convolve([a * a for a in x], kernel)
+        uyy = convolve([a * a for a in y], kernel)
+        uxy = convolve([a * b for a, b in zip(x, y)], kernel)
+        acc = 0.0
+        for py in range(r, H - r):
+            for px in range(r, W - r):
+                i = py * W + px
+                vx, vy, vxy = uxx[i] - ux[i] ** 2, uyy[i] - uy[i] ** 2, uxy[i] - ux[i] * uy[i]
+                acc += ((2 * ux[i] * uy[i] + c1) * (2 * vxy + c2)) / ((ux[i] ** 2 + uy[i] ** 2 + c1) * (vx + vy + c2))
+        total += acc / ((W - 2 * r) * (H - 2 * r))
+    return total / 3
+
+
+# FLIP's color_space_transform matrices.
+LIN2XYZ = [
+    [10135552 / 24577794, 8788810 / 24577794, 4435075 / 24577794],
+    [2613072 / 12288897, 8788810 / 12288897, 887015 / 12288897],
+    [1425312 / 73733382, 8788810 / 73733382, 70074185 / 73733382],
+]
+XYZ2LIN = [
+    [3.241003275, -1.537398934, -0.498615861],
+    [-0.969224334, 1.875930071, 0.041554224],
+    [0.055639423, -0.204011202, 1.057148933],
+]
+
+
+def mat(m, v):
+    return [sum(m[i][j] * v[j] for j in range(3)) for i in range(3)]
+
+
+WHITE = mat(LIN2XYZ, [1.0, 1.0, 1.0])
+
+
+def srgb2lin(v):
+    v /= 255
+    return v / 12.92 if v <= 0.04045 else ((v + 0.055) / 1.055) ** 2.4
+
+
+def lin2lab(rgb):
+    xyz = mat(LIN2XYZ, rgb)
+    d = 6 / 29
+
+    def f(t):
+        return t ** (1 / 3) if t > d ** 3 else t / (3 * d * d) + 4 / 29
+
+    fx, fy, fz = (f(xyz[i] / WHITE[i]) for i in range(3))
+    return [116 * fy - 16, 500 * (fx - fy), 200 * (fy - fz)]
+
+
+def hunt(lab):
+    return [lab[0], 0.01 * lab[0] * lab[1], 0.01 * lab[0] * lab[2]]
+
+
+def hyab(a, b):
+    return abs(a[0] - b[0]) + math.hypot(a[1] - b[1], a[2] - b[2])
+
+
+def flip(ref, test, ppd=67.0206):
+    qc, pc, pt, qf, w = 0.7, 0.4, 0.95, 0.5, 0.082
+
+    def ycxcz(pix):
+        planes = [[], [], []]
+        for p in pix:
+            xyz = mat(LIN2XYZ, [srgb2lin(p[0]), srgb2lin(p[1]), srgb2lin(p[2])])
+            x, y, z = (xyz[i] / WHITE[i] for i in range(3))
+            planes[0].append(116 * y - 16)
+            planes[1].append(500 * (x - y))
+            planes[2].append(200 * (y - z))
+        return planes
+
+    params = [(1, 0.0047, 0, 1e-5), (1, 0.0053, 0, 1e-5), (34.1, 0.04, 13.5, 0.025)]
+    r = int(math.ceil(3 * math.sqrt(0.04 / (2 * math.pi ** 2)) * ppd))
+    csf = []
+    for a1, b1, a2, b2 in params:
+        k = []
+        for j in range(-r, r + 1):
+            row = []
+            for i in range(-r, r + 1):
+                d2 = (i / ppd) ** 2 + (j / ppd) ** 2
+                v = a1 * math.sqrt(math.pi / b1) * math.exp(-math.pi ** 2 * d2 / b1)
+                if a2:
+                    v += a2 * math.sqrt(math.pi / b2) * math.exp(-math.pi ** 2 * d2 / b2)
+                row.append(v)
+            k.append(row)
+        s = sum(map(sum, k))
+        csf.append([[v / s for v in row] for row in k])
+
+    def filtered_lab(planes):
+        f = [convolve(planes[c], csf[c]) for c in range(3)]
+        out = []
+        for i in range(W * H):
+            yn = (f[0][i] + 16) / 116
+            xyz = [(f[1][i] / 500 + yn) * WHITE[0], yn * WHITE[1], (yn - f[2][i] / 200) * WHITE[2]]
+            rgb = [min(max(v, 0.0), 1.0) for v in mat(XYZ2LIN, xyz)]
+            out.append(hunt(lin2lab(rgb)))
+        return out
+
+    sd = 0.5 * w * ppd
+    fr = int(math.ceil(3 * sd))
+
+    def detector(edge):
+        k = []
+        for j in range(-fr, fr + 1):
+            row = []
+            for i in range(-fr, fr + 1):
+                g = math.exp(-(i * i + j * j) / (2 * sd * sd))
+                row.append(-i * g if edge else (i * i / (sd * sd) - 1) * g)
+            k.append(row)
+        pos = sum(v for row in k for v in row if v > 0)
+        neg = -sum(v for row in k for v in row if v < 0)
+        return [[v / pos if v > 0 else v / neg for v in row] for row in k]
+
+    def features(planes):
+        y = [(v + 16) / 116 for v in planes[0]]
+        out = []
+        for edge in (True, False):
+            kx = detector(edge)
+            ky = [list(col) for col in zip(*kx)]
+            gx, gy = convolve(y, kx), convolve(y, ky)
+            out.append([math.hypot(a, b) for a, b in zip(gx, gy)])
+        return out
+
+    ref_ycc, test_ycc = ycxcz(ref), ycxcz(test)
+    ref_lab, test_lab = filtered_lab(ref_ycc), filtered_lab(test_ycc)
+    ref_edges, ref_points = features(ref_ycc)
+    test_edges, test_points = features(test_ycc)
+    cmax = hyab(hunt(lin2lab([0, 1, 0])), hunt(lin2lab([0, 0, 1]))) ** qc
+    total = 0.0
+    for i in range(W * H):
+        dc = hyab(ref_lab[i], test_lab[i]) ** qc
+        if dc < pc * cmax:
+            dc *= pt / (pc * cmax)
+        else:
+            dc = pt + (dc - pc * cmax) / (cmax - pc * cmax) * (1 - pt)
+        df = (max(abs(ref_edges[i] - test_edges[i]), abs(ref_points[i] - test_points[i])) / math.sqrt(2)) ** qf
+        total += dc ** (1 - df)
+    return total / (W * H)
+
+
+def main():
+    ref, test = fixture()
+    write_png("metric_ref.png", ref)
+    write_png("metric_test.png", test)
+    print("transcription: SSIM %.6f FLIP %.6f" % (ssim(ref, test), flip(ref, test)))
+    try:
+        import numpy as np
+        from skimage.metrics import structural_similarity
+
+        a = np.array([p[:3] for p in ref], dtype=np.float64).reshape(H, W, 3)
+        b = np.array([p[:3] for p in test], dtype=np.float64).reshape(H, W, 3)
+        v = structural_similarity(a, b, gaussian_weights=True, sigma=1.5, use_sample_covariance=False,
+                                  data_range=255, channel_axis=2)
+        print("scikit-image: SSIM %.6f" % v)
+    except ImportError:
+        print("scikit-image: not installed")
+    try:
+        import flip_evaluator
+
+        _, v, _ = flip_evaluator.evaluate("metric_ref.png", "metric_test.png", "LDR")
+        print("flip_evaluator: FLIP %.6f" % v)
+    except ImportError:
+        print("flip_evaluator: not installed")
+
+
+if __name__ == "__main__":
+    main()
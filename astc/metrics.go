@@ -0,0 +1,76 @@
+package astc
+
+import "time"
+
+// Metrics receives operational telemetry from a Context (see Config.Metrics) or a native encoder
+// or decoder, for production monitoring of encode farms. Implementations back the two methods
+// with a metrics system such as Prometheus: Count with a counter per name and Observe with a
+// histogram per name. Both may be called concurrently from the threads of one image and must not
+// block for long, since they are called while the image is being processed.
+type Metrics interface {
+	// Count adds delta (always positive) to the counter name.
+	Count(name string, delta int64)
+	// Observe records one sample of the histogram name.
+	Observe(name string, value float64)
+}
+
+// Metric names reported to Metrics. Counters end in _total; the other names are histograms with
+// one sample per completed image.
+const (
+	// MetricBlocksEncoded counts blocks written by CompressImage.
+	MetricBlocksEncoded = "astc_blocks_encoded_total"
+	// MetricBlocksDecoded counts blocks read by DecompressImage.
+	MetricBlocksDecoded = "astc_blocks_decoded_total"
+	// MetricErrors counts images whose compression or decompression failed, once however many
+	// threads worked on them, and calls rejected before starting an image (by thread 0 only, so
+	// an image rejected on every thread also counts once). Cancelled compressions and threads
+	// arriving after an image was finished are not errors.
+	MetricErrors = "astc_errors_total"
+	// MetricHintHits counts blocks CompressImageWithHint reused from the previous blocks without
+	// searching.
+	MetricHintHits = "astc_hint_hits_total"
+	// MetricContextReuses counts images started on a Context after its first, i.e. the context
+	// allocations saved by reusing it.
+	MetricContextReuses = "astc_context_reuses_total"
+
+	// MetricEncodeSeconds and MetricDecodeSeconds are the wall time of each completed image, from
+	// the first thread joining it to the last thread leaving it.
+	MetricEncodeSeconds = "astc_encode_seconds"
+	MetricDecodeSeconds = "astc_decode_seconds"
+	// MetricEncodeBlocksPerSecond and MetricDecodeBlocksPerSecond are the throughput of each
+	// completed image, over all its threads.
+	MetricEncodeBlocksPerSecond = "astc_encode_blocks_per_second"
+	MetricDecodeBlocksPerSecond = "astc_decode_blocks_per_second"
+)
+
+// reportImageMetrics reports an operation on one image of blocks blocks: the block counter and,
+// if the image was completed, its duration and throughput histograms.
+func reportImageMetrics(m Metrics, encode bool, blocks int, elapsed time.Duration, completed bool) {
+	blocksName, secondsName, rateName := MetricBlocksDecoded, MetricDecodeSeconds, MetricDecodeBlocksPerSecond
+	if encode {
+		blocksName, secondsName, rateName = MetricBlocksEncoded, MetricEncodeSeconds, MetricEncodeBlocksPerSecond
+	}
+	if blocks > 0 {
+		m.Count(blocksName, int64(blocks))
+	}
+	if !completed {
+		return
+	}
+	seconds := elapsed.Seconds()
+	m.Observe(secondsName, seconds)
+	if seconds > 0 {
+		m.Observe(rateName, float64(blocks)/seconds)
+	}
+}
+
+// countContextReuse counts an image starting on c for MetricContextReuses.
+func (c *Context) countContextReuse() {
+	if c.images.Add(1) > 1 && c.cfg.Metrics != nil {
+		c.cfg.Metrics.Count(MetricContextReuses, 1)
+	}
+}
+
+// elapsed returns the time since the operation started.
+func (s *opState) elapsed() time.Duration {
+	return time.Since(progressEpoch) - time.Duration(s.startNanos.Load())
+}
@@ -0,0 +1,129 @@
+package astc_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/arm-software/astc-encoder/astc"
+)
+
+// recordingMetrics is an in-memory astc.Metrics.
+type recordingMetrics struct {
+	mu       sync.Mutex
+	counters map[string]int64
+	samples  map[string][]float64
+}
+
+func newRecordingMetrics() *recordingMetrics {
+	return &recordingMetrics{counters: map[string]int64{}, samples: map[string][]float64{}}
+}
+
+func (r *recordingMetrics) Count(name string, delta int64) {
+	r.mu.Lock()
+	r.counters[name] += delta
+	r.mu.Unlock()
+}
+
+func (r *recordingMetrics) Observe(name string, value float64) {
+	r.mu.Lock()
+	r.samples[name] = append(r.samples[name], value)
+	r.mu.Unlock()
+}
+
+func TestContext_Metrics(t *testing.T) {
+	const w, h = 24, 16 // 6x4 blocks
+	const blocks = 24
+	pix := make([]byte, w*h*4)
+	for i := range pix {
+		pix[i] = uint8(i * 7)
+	}
+
+	m := newRecordingMetrics()
+	cfg, err := astc.ConfigInit(astc.ProfileLDR, 4, 4, 1, 10, 0)
+	if err != nil {
+		t.Fatalf("ConfigInit: %v", err)
+	}
+	cfg.Metrics = m
+	ctx, err := astc.ContextAlloc(&cfg, 1)
+	if err != nil {
+		t.Fatalf("ContextAlloc: %v", err)
+	}
+	img := astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeU8, DataU8: pix}
+	out := make([]byte, blocks*astc.BlockBytes)
+	if err := ctx.CompressImage(&img, astc.SwizzleRGBA, out, 0); err != nil {
+		t.Fatalf("CompressImage: %v", err)
+	}
+	// Reusing every block of the previous encode.
+	if err := ctx.CompressImageWithHint(&img, append([]byte(nil), out...), 1e9, astc.SwizzleRGBA, out, 0); err != nil {
+		t.Fatalf("CompressImageWithHint: %v", err)
+	}
+	dec := astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeU8, DataU8: make([]byte, w*h*4)}
+	if err := ctx.DecompressImage(out, &dec, astc.SwizzleRGBA, 0); err != nil {
+		t.Fatalf("DecompressImage: %v", err)
+	}
+	if err := ctx.CompressImage(&img, astc.SwizzleRGBA, out[:1], 0); err == nil {
+		t.Fatalf("CompressImage into a short buffer succeeded")
+	}
+
+	for name, want := range map[string]int64{
+		astc.MetricBlocksEncoded: 2 * blocks,
+		astc.MetricBlocksDecoded: blocks,
+		astc.MetricHintHits:      blocks,
+		astc.MetricContextReuses: 2,
+		astc.MetricErrors:        1,
+	} {
+		if got := m.counters[name]; got != want {
+			t.Errorf("%s = %d, want %d", name, got, want)
+		}
+	}
+	for name, want := range map[string]int{
+		astc.MetricEncodeSeconds:         2,
+		astc.MetricEncodeBlocksPerSecond: 2,
+		astc.MetricDecodeSeconds:         1,
+	} {
+		if got := len(m.samples[name]); got != want {
+			t.Errorf("%s has %d samples, want %d", name, got, want)
+		}
+	}
+	for _, s := range m.samples[astc.MetricEncodeSeconds] {
+		if !(s >= 0) {
+			t.Errorf("%s sample %g, want >= 0", astc.MetricEncodeSeconds, s)
+		}
+	}
+}
+
+func TestEncoder_Metrics_MultiWorker(t *testing.T) {
+	const w, h = 24, 16
+	pix := make([]byte, w*h*4)
+	for i := range pix {
+		pix[i] = uint8(i * 7)
+	}
+
+	// Workers which join after the image is finished fail to start; that is not an error.
+	m := newRecordingMetrics()
+	withMetrics := astc.WithConfig(func(c *astc.Config) { c.Metrics = m })
+	enc, err := astc.NewEncoder(astc.WithQuality(astc.EncodeFastest), astc.WithWorkers(16), withMetrics)
+	if err != nil {
+		t.Fatalf("NewEncoder: %v", err)
+	}
+	dec, err := astc.NewDecoder(astc.WithWorkers(16), withMetrics)
+	if err != nil {
+		t.Fatalf("NewDecoder: %v", err)
+	}
+	const calls = 50
+	for i := 0; i < calls; i++ {
+		data, err := enc.EncodeRGBA8(pix, w, h)
+		if err != nil {
+			t.Fatalf("EncodeRGBA8: %v", err)
+		}
+		if _, _, _, _, err := dec.DecodeRGBA8(data); err != nil {
+			t.Fatalf("DecodeRGBA8: %v", err)
+		}
+	}
+	if got := m.counters[astc.MetricErrors]; got != 0 {
+		t.Fatalf("%s = %d after %d successful calls, want 0", astc.MetricErrors, got, 2*calls)
+	}
+	if got, want := len(m.samples[astc.MetricEncodeSeconds]), calls; got != want {
+		t.Fatalf("%s has %d samples, want %d", astc.MetricEncodeSeconds, got, want)
+	}
+}
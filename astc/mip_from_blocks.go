@@ -0,0 +1,403 @@
+package astc
+
+import "errors"
+
+// NextMipRGBA8FromParsed returns the mip level below the 2D image of a parsed .astc file (h and
+// blocks as ParseFile returns them) without decoding it to full resolution: max(1, SizeX/2) x
+// max(1, SizeY/2) RGBA8 texels, each the average of a 2x2 group of source texels (for odd sizes
+// the last column or row is dropped). It is meant for regenerating the mips of a texture painted
+// at runtime.
+//
+// Groups are reconstructed at weight grid resolution: the average of a group's texel weights is
+// filtered directly from its block's weight grid, and the group's color is the endpoint
+// interpolation at that weight, one color per output texel instead of four. Groups across a
+// partition edge, and groups straddling two blocks (which only odd footprints have), average the
+// colors of their texels instead. The result is within a few code values of the box-filtered
+// decode and exact for constant blocks; like a box filter of the decoded texels, ProfileLDRSRGB
+// data is averaged in sRGB space. Block parsing, which a full decode shares, dominates the cost,
+// so the time saved over decoding and filtering is modest; the memory saved is the
+// full-resolution image. Only the LDR profiles and 2D footprints are supported.
+func NextMipRGBA8FromParsed(profile Profile, h Header, blocks []byte) (pix []byte, width, height int, err error) {
+	if profile != ProfileLDR && profile != ProfileLDRSRGB {
+		return nil, 0, 0, errors.New("astc: mip generation from blocks supports only LDR profiles")
+	}
+	h = h.Normalize()
+	if err := h.Validate(); err != nil {
+		return nil, 0, 0, err
+	}
+	if h.BlockZ != 1 || h.SizeZ != 1 {
+		return nil, 0, 0, errors.New("astc: mip generation from blocks supports only 2D images")
+	}
+	blocksX, _, _, total, err := h.BlockCount()
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if len(blocks) < total*BlockBytes {
+		return nil, 0, 0, ioErrUnexpectedEOF("astc blocks", total*BlockBytes, len(blocks))
+	}
+
+	srcW, srcH := int(h.SizeX), int(h.SizeY)
+	width, height = max(1, srcW/2), max(1, srcH/2)
+	pix = make([]byte, width*height*4)
+	ctx := getDecodeContext(int(h.BlockX), int(h.BlockY), 1)
+	m := mipFromBlocks{
+		profile: profile,
+		ctx:     ctx,
+		blocks:  blocks,
+		blocksX: blocksX,
+	}
+	m.rows[0].row, m.rows[1].row = -1, -1
+	m.rows[0].blocks = make([]mipBlock, blocksX)
+	m.rows[1].blocks = make([]mipBlock, blocksX)
+	cols := mipGroups(width, srcW, ctx.blockX)
+
+	for oy, rg := range mipGroups(height, srcH, ctx.blockY) {
+		row0, row1 := m.row(rg.b0), m.row(rg.b1)
+		out := pix[oy*width*4 : (oy+1)*width*4]
+		for ox := 0; ox < width; {
+			cg := cols[ox]
+			if rg.b0 == rg.b1 && cg.b0 == cg.b1 {
+				// The run of groups within this block.
+				end := ox + 1
+				for end < width && cols[end].b0 == cg.b0 && cols[end].b1 == cg.b0 {
+					end++
+				}
+				row0[cg.b0].groupRun(&m, cols[ox:end], rg, out[ox*4:end*4])
+				ox = end
+				continue
+			}
+			var sum [4]int
+			row0[cg.b0].texelColor(ctx, cg.l0, rg.l0, &sum)
+			row0[cg.b1].texelColor(ctx, cg.l1, rg.l0, &sum)
+			row1[cg.b0].texelColor(ctx, cg.l0, rg.l1, &sum)
+			row1[cg.b1].texelColor(ctx, cg.l1, rg.l1, &sum)
+			putMipTexel(out[ox*4:ox*4+4], &sum)
+			ox++
+		}
+	}
+	return pix, width, height, nil
+}
+
+func putMipTexel(o []byte, sum *[4]int) {
+	o[0] = uint8((sum[0] + 2) >> 2)
+	o[1] = uint8((sum[1] + 2) >> 2)
+	o[2] = uint8((sum[2] + 2) >> 2)
+	o[3] = uint8((sum[3] + 2) >> 2)
+}
+
+// mipGroup locates the two source texels of an output texel along one axis: in block b0 at
+// coordinate l0 and in block b1 at l1.
+type mipGroup struct {
+	b0, l0, b1, l1 int
+}
+
+// mipGroups returns the mipGroup of each of the n output texels along an axis of srcN texels
+// split into blocks of size blockN.
+func mipGroups(n, srcN, blockN int) []mipGroup {
+	g := make([]mipGroup, n)
+	for i := range g {
+		t0, t1 := min(2*i, srcN-1), min(2*i+1, srcN-1)
+		g[i] = mipGroup{t0 / blockN, t0 % blockN, t1 / blockN, t1 % blockN}
+	}
+	return g
+}
+
+// mipFromBlocks holds the decoded block rows NextMipRGBA8FromParsed reads. A row of output texels
+// needs at most two block rows, so the two most recent are kept.
+type mipFromBlocks struct {
+	profile Profile
+	ctx     *decodeContext
+	blocks  []byte
+	blocksX int
+	// axisTaps caches, per block axis and grid size, the mipTaps of every pair of texels (see
+	// taps).
+	axisTaps [2][13][]mipTaps // 2D weight grids have at most 12 points per axis.
+	rows     [2]struct {
+		row    int
+		blocks []mipBlock
+	}
+}
+
+// row returns the decoded blocks of block row by, decoding it over the older cached row if needed.
+func (m *mipFromBlocks) row(by int) []mipBlock {
+	for i := range m.rows {
+		if m.rows[i].row == by {
+			return m.rows[i].blocks
+		}
+	}
+	r := &m.rows[0]
+	if m.rows[1].row < r.row {
+		r = &m.rows[1]
+	}
+	r.row = by
+	for bx := range r.blocks {
+		i := by*m.blocksX + bx
+		r.blocks[bx].decode(m.profile, m.ctx, m.blocks[i*BlockBytes:(i+1)*BlockBytes])
+	}
+	return r.blocks
+}
+
+// mipBlock is a block decoded to the weight grid and endpoints, the state NextMipRGBA8FromParsed
+// reconstructs texels from.
+type mipBlock struct {
+	// bmi is nil for constant and error blocks, whose color is e0 >> 8.
+	bmi     *blockModeInfo
+	parts   []uint8 // Partition of each texel; nil for one partition.
+	planes  int     // Number of weight planes.
+	plane2  int     // Channel of the second weight plane, or -1.
+	e0, d   [blockMaxPartitions][4]int32
+	weights [blockMaxWeights]uint8
+}
+
+func (b *mipBlock) decode(profile Profile, ctx *decodeContext, block []byte) {
+	scb := physicalToSymbolicWithCtx(block, ctx)
+	b.bmi, b.parts, b.planes, b.plane2 = nil, nil, 1, -1
+	b.d[0] = [4]int32{}
+	color := [4]int{0xFF, 0x00, 0xFF, 0xFF}
+	switch scb.blockType {
+	case symBlockConstU16:
+		for c, v := range scb.constantColor {
+			color[c] = int(v >> 8)
+		}
+	case symBlockNonConst:
+		bmi := ctx.blockMode(int(scb.blockMode))
+		if !bmi.ok || (bmi.isDualPlane && scb.plane2Component > 3) {
+			break
+		}
+		b.bmi = bmi
+		if bmi.isDualPlane {
+			b.planes, b.plane2 = 2, int(scb.plane2Component)
+		}
+		partitionCount := int(scb.partitionCount)
+		if partitionCount > 1 {
+			b.parts = ctx.partitionTables[partitionCount].partitionsForIndex(int(scb.partitionIndex))
+		}
+		for p := range partitionCount {
+			_, _, e0, e1 := unpackColorEndpoints(profile, scb.colorFormats[p], scb.colorValues[p][:])
+			for c := range 4 {
+				b.e0[p][c] = int32(e0[c])
+				b.d[p][c] = int32(e1[c] - e0[c])
+			}
+		}
+		b.weights = scb.weights
+		return
+	}
+	for c := range 4 {
+		b.e0[0][c] = int32(color[c] << 8)
+	}
+}
+
+// groupRun writes to out the output texels of the groups cols x rg, which all lie in b.
+func (b *mipBlock) groupRun(m *mipFromBlocks, cols []mipGroup, rg mipGroup, out []byte) {
+	if b.bmi == nil {
+		for i := 0; i < len(out); i += 4 {
+			for c := range 4 {
+				out[i+c] = uint8(b.e0[0][c] >> 8)
+			}
+		}
+		return
+	}
+	if b.planes != 1 {
+		for i, cg := range cols {
+			var sum [4]int
+			b.groupColor(m, cg.l0, cg.l1, rg.l0, rg.l1, &sum)
+			putMipTexel(out[i*4:i*4+4], &sum)
+		}
+		return
+	}
+	// The common case, one weight plane: when all four texels of a group are in one partition
+	// they share the color, which is then the output texel.
+	blockX := m.ctx.blockX
+	r0, r1 := rg.l0*blockX, rg.l1*blockX
+	ty := m.taps(1, int(b.bmi.yWeights), rg.l0, rg.l1)
+	for i, cg := range cols {
+		o := out[i*4 : i*4+4]
+		t00, t01, t10, t11 := r0+cg.l0, r0+cg.l1, r1+cg.l0, r1+cg.l1
+		p := 0
+		if b.parts != nil {
+			p = int(b.parts[t00])
+			if int(b.parts[t01]) != p || int(b.parts[t10]) != p || int(b.parts[t11]) != p {
+				var sum [4]int
+				for _, t := range [4]int{t00, t01, t10, t11} {
+					b.addColor(int(b.parts[t]), [2]int{b.texelWeight(0, t)}, 1, &sum)
+				}
+				putMipTexel(o, &sum)
+				continue
+			}
+		}
+		var w int32
+		if b.bmi.noDecimation {
+			w = int32(int(b.weights[t00])+int(b.weights[t01])+int(b.weights[t10])+int(b.weights[t11])+2) >> 2
+		} else {
+			w = int32(b.gridWeight(0, m.taps(0, int(b.bmi.xWeights), cg.l0, cg.l1), ty))
+		}
+		e0, d := &b.e0[p], &b.d[p]
+		o[0] = uint8((e0[0] + ((d[0]*w + 32) >> 6)) >> 8)
+		o[1] = uint8((e0[1] + ((d[1]*w + 32) >> 6)) >> 8)
+		o[2] = uint8((e0[2] + ((d[2]*w + 32) >> 6)) >> 8)
+		o[3] = uint8((e0[3] + ((d[3]*w + 32) >> 6)) >> 8)
+	}
+}
+
+// groupColor adds to sum the colors of the texels x0..x1 x y0..y1 (two texels per axis, or one
+// repeated) of b. Groups within one partition are reconstructed with their average weight; groups
+// across a partition edge texel by texel, since there the texel weights correlate with the
+// partition and the average weight would mix the wrong colors.
+func (b *mipBlock) groupColor(m *mipFromBlocks, x0, x1, y0, y1 int, sum *[4]int) {
+	ctx := m.ctx
+	if b.bmi == nil {
+		for c := range 4 {
+			sum[c] += 4 * int(b.e0[0][c]>>8)
+		}
+		return
+	}
+	part := 0
+	if b.parts != nil {
+		part = int(b.parts[y0*ctx.blockX+x0])
+		if int(b.parts[y0*ctx.blockX+x1]) != part || int(b.parts[y1*ctx.blockX+x0]) != part || int(b.parts[y1*ctx.blockX+x1]) != part {
+			for _, t := range [4][2]int{{x0, y0}, {x1, y0}, {x0, y1}, {x1, y1}} {
+				b.texelColor(ctx, t[0], t[1], sum)
+			}
+			return
+		}
+	}
+	var w [2]int
+	if b.bmi.noDecimation {
+		// The grid is the texel grid: average the texel weights.
+		for pl := range b.planes {
+			ws := b.weights[pl*weightsPlane2Offset:]
+			w[pl] = (int(ws[y0*ctx.blockX+x0]) + int(ws[y0*ctx.blockX+x1]) + int(ws[y1*ctx.blockX+x0]) + int(ws[y1*ctx.blockX+x1]) + 2) >> 2
+		}
+	} else {
+		tx := m.taps(0, int(b.bmi.xWeights), x0, x1)
+		ty := m.taps(1, int(b.bmi.yWeights), y0, y1)
+		for pl := range b.planes {
+			w[pl] = b.gridWeight(pl, tx, ty)
+		}
+	}
+	b.addColor(part, w, 4, sum)
+}
+
+// texelColor adds to sum the color of texel (x, y) of b.
+func (b *mipBlock) texelColor(ctx *decodeContext, x, y int, sum *[4]int) {
+	if b.bmi == nil {
+		for c := range 4 {
+			sum[c] += int(b.e0[0][c] >> 8)
+		}
+		return
+	}
+	t := y*ctx.blockX + x
+	var w [2]int
+	for pl := range b.planes {
+		w[pl] = b.texelWeight(pl, t)
+	}
+	part := 0
+	if b.parts != nil {
+		part = int(b.parts[t])
+	}
+	b.addColor(part, w, 1, sum)
+}
+
+// texelWeight returns the weight of plane pl at texel t, infilled from the grid.
+func (b *mipBlock) texelWeight(pl, t int) int {
+	ws := b.weights[pl*weightsPlane2Offset:]
+	if b.bmi.noDecimation {
+		return int(ws[t])
+	}
+	e := &b.bmi.decimation[t]
+	return (8 + int(ws[e.idx[0]])*int(e.w[0]) + int(ws[e.idx[1]])*int(e.w[1]) +
+		int(ws[e.idx[2]])*int(e.w[2]) + int(ws[e.idx[3]])*int(e.w[3])) >> 4
+}
+
+// addColor adds n times the color of partition p at the plane weights w to sum.
+func (b *mipBlock) addColor(p int, w [2]int, n int, sum *[4]int) {
+	if b.plane2 < 0 {
+		e0, d, w := &b.e0[p], &b.d[p], int32(w[0])
+		sum[0] += n * int((e0[0]+((d[0]*w+32)>>6))>>8)
+		sum[1] += n * int((e0[1]+((d[1]*w+32)>>6))>>8)
+		sum[2] += n * int((e0[2]+((d[2]*w+32)>>6))>>8)
+		sum[3] += n * int((e0[3]+((d[3]*w+32)>>6))>>8)
+		return
+	}
+	for c := range 4 {
+		wc := int32(w[0])
+		if c == b.plane2 {
+			wc = int32(w[1])
+		}
+		sum[c] += n * int((b.e0[p][c]+((b.d[p][c]*wc+32)>>6))>>8)
+	}
+}
+
+// gridWeight returns the weight of plane pl filtered with the grid taps tx and ty.
+func (b *mipBlock) gridWeight(pl int, tx, ty *mipTaps) int {
+	gridX := int(b.bmi.xWeights)
+	ws := b.weights[pl*weightsPlane2Offset:]
+	s := 512
+	for j, fy := range ty.w {
+		if fy == 0 {
+			continue
+		}
+		row := ws[(int(ty.i)+j)*gridX+int(tx.i):]
+		r := int(row[0]) * int(tx.w[0])
+		if tx.w[1] != 0 {
+			r += int(row[1]) * int(tx.w[1])
+			if tx.w[2] != 0 {
+				r += int(row[2]) * int(tx.w[2])
+			}
+		}
+		s += r * int(fy)
+	}
+	return s >> 10
+}
+
+// mipTaps filters the weight grid along one block axis: the weights, in 1/32, of the grid points
+// i, i+1 and i+2 in the average of the weights of two texels.
+type mipTaps struct {
+	i int8
+	w [3]int8
+}
+
+// taps returns the mipTaps of the texels x0 and x1 (equal, or x1 = x0+1) along axis (0 for x, 1
+// for y) for a grid of grid points, as the weight infill positions them.
+func (m *mipFromBlocks) taps(axis, grid, x0, x1 int) *mipTaps {
+	t := m.axisTaps[axis][grid]
+	if t == nil {
+		n := m.ctx.blockX
+		if axis == 1 {
+			n = m.ctx.blockY
+		}
+		scale := mipAxisScale(n)
+		t = make([]mipTaps, 2*n)
+		for x := range n {
+			for dx := range min(2, n-x) {
+				p0, p1 := mipGridPos(scale, grid, x), mipGridPos(scale, grid, x+dx)
+				e := &t[2*x+dx]
+				e.i = int8(p0 >> 4)
+				for _, p := range [2]int{p0, p1} {
+					k := p>>4 - int(e.i)
+					e.w[k] += int8(16 - p&15)
+					if p&15 != 0 {
+						e.w[k+1] += int8(p & 15)
+					}
+				}
+			}
+		}
+		m.axisTaps[axis][grid] = t
+	}
+	return &t[2*x0+x1-x0]
+}
+
+// mipAxisScale returns the texel to grid position scale of a block axis of size n, as the weight
+// infill computes it.
+func mipAxisScale(n int) int {
+	if n <= 1 {
+		return 0
+	}
+	return (1024 + n/2) / (n - 1)
+}
+
+// mipGridPos returns the weight grid position of texel x of a block axis with texel to grid scale
+// (see mipAxisScale) and grid points, in 1/16 grid steps.
+func mipGridPos(scale, grid, x int) int {
+	return (scale*x*(grid-1) + 32) >> 6
+}
@@ -0,0 +1,73 @@
+package astc_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/arm-software/astc-encoder/astc"
+	"github.com/arm-software/astc-encoder/astc/testimage"
+)
+
+// boxDownsampleRGBA8 is the reference NextMipRGBA8FromParsed approximates: the 2x2 box filter of
+// the decoded image.
+func boxDownsampleRGBA8(pix []byte, w, h int) []byte {
+	ow, oh := max(1, w/2), max(1, h/2)
+	out := make([]byte, ow*oh*4)
+	for y := range oh {
+		for x := range ow {
+			for c := range 4 {
+				sum := 0
+				for _, t := range [4][2]int{{2 * x, 2 * y}, {2*x + 1, 2 * y}, {2 * x, 2*y + 1}, {2*x + 1, 2*y + 1}} {
+					sx, sy := min(t[0], w-1), min(t[1], h-1)
+					sum += int(pix[(sy*w+sx)*4+c])
+				}
+				out[(y*ow+x)*4+c] = uint8((sum + 2) >> 2)
+			}
+		}
+	}
+	return out
+}
+
+func TestNextMipRGBA8FromParsed_MatchesBoxFilter(t *testing.T) {
+	const w, h = 75, 49
+	for _, kind := range []testimage.Kind{testimage.KindGradient, testimage.KindPerlin, testimage.KindText} {
+		pix, err := testimage.RGBA8(kind, w, h, 1, testimage.Options{})
+		if err != nil {
+			t.Fatalf("RGBA8: %v", err)
+		}
+		for _, bs := range [][2]int{{4, 4}, {5, 5}, {6, 6}, {8, 5}, {8, 8}, {12, 12}} {
+			for _, profile := range []astc.Profile{astc.ProfileLDR, astc.ProfileLDRSRGB} {
+				t.Run(fmt.Sprintf("%v/%dx%d/%v", kind, bs[0], bs[1], profile), func(t *testing.T) {
+					data, err := astc.EncodeRGBA8WithProfileAndQuality(pix, w, h, bs[0], bs[1], profile, astc.EncodeFast)
+					if err != nil {
+						t.Fatalf("encode: %v", err)
+					}
+					hdr, blocks, err := astc.ParseFile(data)
+					if err != nil {
+						t.Fatalf("ParseFile: %v", err)
+					}
+					got, mw, mh, err := astc.NextMipRGBA8FromParsed(profile, hdr, blocks)
+					if err != nil {
+						t.Fatalf("NextMipRGBA8FromParsed: %v", err)
+					}
+					if mw != w/2 || mh != h/2 {
+						t.Fatalf("size = %dx%d, want %dx%d", mw, mh, w/2, h/2)
+					}
+					decoded, _, _, err := astc.DecodeRGBA8WithProfile(data, profile)
+					if err != nil {
+						t.Fatalf("decode: %v", err)
+					}
+					want := boxDownsampleRGBA8(decoded, w, h)
+					maxDiff := 0
+					for i := range want {
+						maxDiff = max(maxDiff, int(got[i])-int(want[i]), int(want[i])-int(got[i]))
+					}
+					psnr := psnrU8(got, want, 4)
+					if psnr < 40 || maxDiff > 24 {
+						t.Fatalf("PSNR against the box-filtered decode = %.2f dB, max difference %d", psnr, maxDiff)
+					}
+				})
+			}
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package astc
+
+import "math"
+
+// MipQualityCurve lowers the encoder search effort for the smaller levels of a mip chain, which are
+// minified and filtered when sampled, so their compression error is less visible than the base
+// level's. The zero value encodes every level like the base.
+//
+// The curve scales search limits only: this encoder has no PSNR early out (Config.TuneDBLimit is
+// kept for upstream compatibility but does not end the search), so relaxing the dB target would
+// have no effect.
+type MipQualityCurve struct {
+	// EffortDecay scales the block mode, partition index and candidate search limits by
+	// EffortDecay^level, e.g. 0.5 halves them at each level. It must be in [0, 1]; 0 and 1 keep
+	// the limits.
+	EffortDecay float32
+	// MinEffort is the lowest scale EffortDecay may reach, in [0, 1]; zero means 0.125.
+	MinEffort float32
+}
+
+// DefaultMipQualityCurve halves the search effort per level, down to an eighth.
+var DefaultMipQualityCurve = MipQualityCurve{EffortDecay: 0.5}
+
+func (m MipQualityCurve) valid() bool {
+	return m.EffortDecay >= 0 && m.EffortDecay <= 1 && m.MinEffort >= 0 && m.MinEffort <= 1
+}
+
+// Apply returns cfg adjusted for mip level (0 is the base level, which is returned unchanged). It
+// can be used to encode mip chains with the Context API.
+func (m MipQualityCurve) Apply(cfg Config, level int) Config {
+	if level <= 0 || !m.valid() || m.EffortDecay == 0 || m.EffortDecay == 1 {
+		return cfg
+	}
+	floor := m.MinEffort
+	if floor == 0 {
+		floor = 0.125
+	}
+	factor := max(float32(math.Pow(float64(m.EffortDecay), float64(level))), floor)
+	scale := func(v *uint32) {
+		*v = max(uint32(float32(*v)*factor+0.5), 1)
+	}
+	scale(&cfg.TuneBlockModeLimit)
+	scale(&cfg.Tune2PartitionIndexLimit)
+	scale(&cfg.Tune3PartitionIndexLimit)
+	scale(&cfg.Tune4PartitionIndexLimit)
+	scale(&cfg.TuneCandidateLimit)
+	scale(&cfg.Tune2PartitioningCandidateLimit)
+	scale(&cfg.Tune3PartitioningCandidateLimit)
+	scale(&cfg.Tune4PartitioningCandidateLimit)
+	return cfg
+}
@@ -19,10 +19,10 @@ import (
 // ConfigInit populates a config using upstream astcenc_config_init defaults.
 func ConfigInit(profile astc.Profile, blockX, blockY, blockZ int, quality float32, flags Flags) (Config, error) {
 	if blockX <= 0 || blockY <= 0 || blockZ <= 0 || blockX > 255 || blockY > 255 || blockZ > 255 {
-		return Config{}, errors.New("astc/native: invalid block dimensions")
+		return Config{}, newError(astc.ErrBadBlockSize, "astc/native: invalid block dimensions")
 	}
 	if blockX*blockY*blockZ > 216 {
-		return Config{}, errors.New("astc/native: invalid block dimensions")
+		return Config{}, newError(astc.ErrBadBlockSize, "astc/native: invalid block dimensions")
 	}
 
 	cProf, err := profileToC(profile)
@@ -93,7 +93,7 @@ func (c *Context) Close() error {
 
 func (c *Context) CompressImage(img *Image, swizzle Swizzle, out []byte, threadIndex int) error {
 	if c == nil || c.ctx == nil {
-		return errors.New("astc/native: nil context")
+		return newError(astc.ErrBadContext, "astc/native: nil context")
 	}
 	if img == nil {
 		return errors.New("astc/native: nil image")
@@ -101,7 +101,7 @@ func (c *Context) CompressImage(img *Image, swizzle Swizzle, out []byte, threadI
 
 	blockX, blockY, blockZ := int(c.cfg.BlockX), int(c.cfg.BlockY), int(c.cfg.BlockZ)
 	if blockX <= 0 || blockY <= 0 || blockZ <= 0 {
-		return errors.New("astc/native: invalid context block dimensions")
+		return newError(astc.ErrBadBlockSize, "astc/native: invalid context block dimensions")
 	}
 
 	blocksX := (img.DimX + blockX - 1) / blockX
@@ -150,7 +150,7 @@ func (c *Context) CompressImage(img *Image, swizzle Swizzle, out []byte, threadI
 
 func (c *Context) CompressReset() error {
 	if c == nil || c.ctx == nil {
-		return errors.New("astc/native: nil context")
+		return newError(astc.ErrBadContext, "astc/native: nil context")
 	}
 	code := C.astc_native_compress_reset(c.ctx)
 	return errFromCode(int(code), "astcenc_compress_reset")
@@ -158,7 +158,7 @@ func (c *Context) CompressReset() error {
 
 func (c *Context) CompressCancel() error {
 	if c == nil || c.ctx == nil {
-		return errors.New("astc/native: nil context")
+		return newError(astc.ErrBadContext, "astc/native: nil context")
 	}
 	code := C.astc_native_compress_cancel(c.ctx)
 	return errFromCode(int(code), "astcenc_compress_cancel")
@@ -166,7 +166,7 @@ func (c *Context) CompressCancel() error {
 
 func (c *Context) DecompressImage(data []byte, imgOut *Image, swizzle Swizzle, threadIndex int) error {
 	if c == nil || c.ctx == nil {
-		return errors.New("astc/native: nil context")
+		return newError(astc.ErrBadContext, "astc/native: nil context")
 	}
 	if imgOut == nil {
 		return errors.New("astc/native: nil output image")
@@ -177,7 +177,7 @@ func (c *Context) DecompressImage(data []byte, imgOut *Image, swizzle Swizzle, t
 
 	blockX, blockY, blockZ := int(c.cfg.BlockX), int(c.cfg.BlockY), int(c.cfg.BlockZ)
 	if blockX <= 0 || blockY <= 0 || blockZ <= 0 {
-		return errors.New("astc/native: invalid context block dimensions")
+		return newError(astc.ErrBadBlockSize, "astc/native: invalid context block dimensions")
 	}
 
 	blocksX := (imgOut.DimX + blockX - 1) / blockX
@@ -195,6 +195,9 @@ func (c *Context) DecompressImage(data []byte, imgOut *Image, swizzle Swizzle, t
 	if err != nil {
 		return err
 	}
+	if overlaps(data[:needBlocks], imgOut.DataU8) || overlaps(data[:needBlocks], imgOut.DataF16) || overlaps(data[:needBlocks], imgOut.DataF32) {
+		return newError(astc.ErrBadParam, "astc/native: output image overlaps the block data")
+	}
 
 	var cSwz C.astc_native_swizzle = swizzleToC(swizzle)
 	code := C.astc_native_decompress_image_ex(
@@ -218,7 +221,7 @@ func (c *Context) DecompressImage(data []byte, imgOut *Image, swizzle Swizzle, t
 
 func (c *Context) DecompressReset() error {
 	if c == nil || c.ctx == nil {
-		return errors.New("astc/native: nil context")
+		return newError(astc.ErrBadContext, "astc/native: nil context")
 	}
 	code := C.astc_native_decompress_reset(c.ctx)
 	return errFromCode(int(code), "astcenc_decompress_reset")
@@ -226,7 +229,7 @@ func (c *Context) DecompressReset() error {
 
 func (c *Context) GetBlockInfo(block [astc.BlockBytes]byte) (BlockInfo, error) {
 	if c == nil || c.ctx == nil {
-		return BlockInfo{}, errors.New("astc/native: nil context")
+		return BlockInfo{}, newError(astc.ErrBadContext, "astc/native: nil context")
 	}
 	var info C.astc_native_block_info
 	code := C.astc_native_get_block_info(c.ctx, (*C.uint8_t)(unsafe.Pointer(&block[0])), &info)
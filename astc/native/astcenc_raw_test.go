@@ -3,6 +3,7 @@
 package native_test
 
 import (
+	"errors"
 	"sync/atomic"
 	"testing"
 
@@ -35,6 +36,31 @@ func TestRawConfigInit_ContextAlloc(t *testing.T) {
 	}
 }
 
+func TestRawConfigInit_ErrorCodesMatchPureGo(t *testing.T) {
+	cases := []struct {
+		name           string
+		blockX, blockY int
+		quality        float32
+		want           astc.ErrorCode
+	}{
+		{"bad footprint", 5, 3, 60, astc.ErrBadBlockSize},
+		{"oversized block", 16, 16, 60, astc.ErrBadBlockSize},
+		{"bad quality", 4, 4, -1, astc.ErrBadQuality},
+	}
+	for _, c := range cases {
+		_, nativeErr := native.ConfigInit(astc.ProfileLDR, c.blockX, c.blockY, 1, c.quality, 0)
+		_, goErr := astc.ConfigInit(astc.ProfileLDR, c.blockX, c.blockY, 1, c.quality, 0)
+		for _, err := range []error{nativeErr, goErr} {
+			if got := astc.ErrorCodeOf(err); got != c.want {
+				t.Fatalf("%s: ErrorCodeOf(%v) = %v, want %v", c.name, err, got, c.want)
+			}
+			if !errors.Is(err, c.want) || errors.Is(err, astc.ErrOutOfMem) {
+				t.Fatalf("%s: errors.Is(%v, %v) mismatch", c.name, err, c.want)
+			}
+		}
+	}
+}
+
 func TestRawCompress_Decompress_RGBA8_MatchesHighLevel(t *testing.T) {
 	const (
 		w      = 8
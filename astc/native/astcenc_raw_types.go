@@ -146,7 +146,9 @@ type BlockInfo struct {
 //
 // It mirrors the upstream usage model: for multi-threading, the caller is
 // responsible for spawning N workers and calling CompressImage/DecompressImage
-// once per thread with a unique thread index.
+// once per thread with a unique thread index. Unlike astc.Context, DecompressImage
+// rejects an output image that overlaps the block data with ErrBadParam, since its
+// threads cannot share one private copy of the blocks.
 type Context struct {
 	ctx unsafe.Pointer
 
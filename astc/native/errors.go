@@ -0,0 +1,16 @@
+package native
+
+import (
+	"errors"
+
+	"github.com/arm-software/astc-encoder/astc"
+)
+
+// ErrNotEnabled is returned, wrapped with build guidance, by every operation of a build without
+// the native implementation. Test for it with errors.Is; Available reports the reason up front.
+var ErrNotEnabled = errors.New("astc/native: not enabled in this build")
+
+// newError returns an *astc.Error, so native failures carry the same codes as the pure-Go API.
+func newError(code astc.ErrorCode, msg string) error {
+	return &astc.Error{Code: code, Msg: msg}
+}
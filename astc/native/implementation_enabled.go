@@ -0,0 +1,22 @@
+//go:build astcenc_native && cgo
+
+package native
+
+import "github.com/arm-software/astc-encoder/astc"
+
+func init() {
+	astc.RegisterImplementation(implementation{})
+}
+
+// implementation routes astc.AutoImplementation one-shot calls to the native codec.
+type implementation struct{}
+
+func (implementation) Name() string { return "native" }
+
+func (implementation) EncodeRGBA8VolumeWithProfileAndQuality(pix []byte, width, height, depth int, blockX, blockY, blockZ int, profile astc.Profile, quality astc.EncodeQuality) ([]byte, error) {
+	return EncodeRGBA8VolumeWithProfileAndQuality(pix, width, height, depth, blockX, blockY, blockZ, profile, quality)
+}
+
+func (implementation) DecodeRGBA8VolumeWithProfile(astcData []byte, profile astc.Profile) ([]byte, int, int, int, error) {
+	return DecodeRGBA8VolumeWithProfile(astcData, profile)
+}
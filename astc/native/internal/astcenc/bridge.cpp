@@ -12,6 +12,11 @@
 #endif
 
 #include "astcenc.h"
+#include "astcenc_mathlib.h"
+
+// Upstream does not expose its version through the library API; upstream_version.h records the
+// version of the vendored sources.
+#include "upstream_version.h"
 
 // Go callback bridge for astcenc_config::progress_callback.
 //
@@ -37,6 +42,32 @@ extern "C" const char* astc_native_error_string(int err)
 	return astcenc_get_error_string(static_cast<astcenc_error>(err));
 }
 
+extern "C" const char* astc_native_version(void)
+{
+	return ASTC_NATIVE_UPSTREAM_VERSION;
+}
+
+extern "C" const char* astc_native_isa(void)
+{
+#if ASTCENC_AVX >= 2
+	return "avx2";
+#elif ASTCENC_SSE >= 42
+	return "sse4.2";
+#elif ASTCENC_SSE >= 41
+	return "sse4.1";
+#elif ASTCENC_SSE >= 20
+	return "sse2";
+#elif ASTCENC_SVE == 8
+	return "sve_256";
+#elif ASTCENC_SVE == 4
+	return "sve_128";
+#elif ASTCENC_NEON
+	return "neon";
+#else
+	return "none";
+#endif
+}
+
 extern "C" int astc_native_context_create(
 	int profile,
 	unsigned int block_x,
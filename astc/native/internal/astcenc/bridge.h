@@ -13,6 +13,12 @@ extern "C" {
 
 const char* astc_native_error_string(int err);
 
+// Version of the vendored upstream astcenc sources.
+const char* astc_native_version(void);
+
+// SIMD instruction set the native core was compiled for (e.g. "avx2", "sse4.1", "neon", "none").
+const char* astc_native_isa(void);
+
 int astc_native_context_create(
 	int profile,
 	unsigned int block_x,
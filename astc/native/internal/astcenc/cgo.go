@@ -28,6 +28,11 @@ func ErrorString(code int) string {
 	return C.GoString(s)
 }
 
+// Version returns the vendored upstream version and the SIMD ISA the native core was built for.
+func Version() (version, isa string) {
+	return C.GoString(C.astc_native_version()), C.GoString(C.astc_native_isa())
+}
+
 func Realloc(p unsafe.Pointer, size int) unsafe.Pointer {
 	if size <= 0 {
 		return nil
@@ -134,6 +139,23 @@ func DecompressImageRGBAF32(ctx, data unsafe.Pointer, dataLen int, width, height
 	))
 }
 
+// DecompressImageRGBAF16 decodes into tightly packed RGBA half-floats with the identity swizzle.
+func DecompressImageRGBAF16(ctx, data unsafe.Pointer, dataLen int, width, height, depth int, outRGBA unsafe.Pointer, outLen int, threadIndex int) int {
+	return int(C.astc_native_decompress_image_ex(
+		ctx,
+		data,
+		C.size_t(dataLen),
+		C.int(1), // ASTCENC_TYPE_F16
+		C.uint(width),
+		C.uint(height),
+		C.uint(depth),
+		outRGBA,
+		C.size_t(outLen),
+		nil,
+		C.uint(threadIndex),
+	))
+}
+
 func DecompressReset(ctx unsafe.Pointer) int {
 	return int(C.astc_native_decompress_reset(ctx))
 }
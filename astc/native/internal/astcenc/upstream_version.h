@@ -0,0 +1,9 @@
+#ifndef ASTC_NATIVE_UPSTREAM_VERSION_H_INCLUDED
+#define ASTC_NATIVE_UPSTREAM_VERSION_H_INCLUDED
+
+// The version of the astcenc sources vendored in upstream/, which do not record it themselves.
+// Update it together with the sources when re-vendoring; native.Version reports it, and
+// TestVersion checks that the build picked it up.
+#define ASTC_NATIVE_UPSTREAM_VERSION "5.3.0"
+
+#endif
@@ -0,0 +1,67 @@
+//go:build astcenc_native && cgo
+
+package native
+
+import (
+	"time"
+
+	"github.com/arm-software/astc-encoder/astc"
+)
+
+// nativeMetrics reports the telemetry of a native encoder or decoder to an astc.Metrics, under
+// the same names as an astc.Context.
+type nativeMetrics struct {
+	m      astc.Metrics
+	images int
+}
+
+// report reports an image call which started at start and covers blocks blocks, ending with *err.
+// Native calls either complete the image or fail, so failed calls count no blocks.
+func (n *nativeMetrics) report(encode bool, blocks int, start time.Time, err *error) {
+	if n.m == nil {
+		return
+	}
+	n.images++
+	if n.images > 1 {
+		n.m.Count(astc.MetricContextReuses, 1)
+	}
+	if *err != nil {
+		n.m.Count(astc.MetricErrors, 1)
+		return
+	}
+	blocksName, secondsName, rateName := astc.MetricBlocksDecoded, astc.MetricDecodeSeconds, astc.MetricDecodeBlocksPerSecond
+	if encode {
+		blocksName, secondsName, rateName = astc.MetricBlocksEncoded, astc.MetricEncodeSeconds, astc.MetricEncodeBlocksPerSecond
+	}
+	n.m.Count(blocksName, int64(blocks))
+	seconds := time.Since(start).Seconds()
+	n.m.Observe(secondsName, seconds)
+	if seconds > 0 {
+		n.m.Observe(rateName, float64(blocks)/seconds)
+	}
+}
+
+// blockCount returns the number of blocks of an image, or 0 for invalid dimensions.
+func blockCount(width, height, depth, blockX, blockY, blockZ int) int {
+	if width <= 0 || height <= 0 || depth <= 0 {
+		return 0
+	}
+	return ((width + blockX - 1) / blockX) * ((height + blockY - 1) / blockY) * ((depth + blockZ - 1) / blockZ)
+}
+
+// SetMetrics makes the encoder report its block counts, errors, reuses and per-image timings to
+// m under the astc.Metric* names, like astc.Config.Metrics; nil disables reporting. CompressInto
+// calls are not reported. SetMetrics must not be called while the encoder is in use.
+func (e *Encoder) SetMetrics(m astc.Metrics) { e.metrics.m = m }
+
+// SetMetrics makes the encoder report its block counts, errors, reuses and per-image timings to
+// m under the astc.Metric* names, like astc.Config.Metrics; nil disables reporting.
+func (e *EncoderF16) SetMetrics(m astc.Metrics) { e.metrics.m = m }
+
+// SetMetrics makes the encoder report its block counts, errors, reuses and per-image timings to
+// m under the astc.Metric* names, like astc.Config.Metrics; nil disables reporting.
+func (e *EncoderF32) SetMetrics(m astc.Metrics) { e.metrics.m = m }
+
+// SetMetrics makes the decoder report its block counts, errors, reuses and per-image timings to
+// m under the astc.Metric* names, like astc.Config.Metrics; nil disables reporting.
+func (d *Decoder) SetMetrics(m astc.Metrics) { d.metrics.m = m }
@@ -3,22 +3,37 @@
 package native
 
 import (
-	"errors"
+	"fmt"
 
 	"github.com/arm-software/astc-encoder/astc"
 )
 
-var errDisabled = errors.New("astc/native: disabled (build with -tags astcenc_native and CGO_ENABLED=1)")
+const disabledReason = "built without the astcenc_native tag (build with -tags astcenc_native and CGO_ENABLED=1)"
+
+var errDisabled = fmt.Errorf("%w: %s", ErrNotEnabled, disabledReason)
 
 // Enabled reports whether the CGO native implementation is available in this build.
 func Enabled() bool { return false }
 
+// Available reports whether the CGO native implementation is available in this build and, if it
+// is not, why and how to enable it.
+func Available() (ok bool, reason string) { return false, disabledReason }
+
+// Version reports the upstream astcenc version compiled into the native core (e.g. "5.3.0") and
+// the SIMD instruction set it was built for (e.g. "avx2", "sse4.1", "neon" or "none"). Both are
+// empty when the native implementation is not available in this build.
+func Version() (version, isa string) { return "", "" }
+
 type Encoder struct{}
 
 func NewEncoder(blockX, blockY, blockZ int, profile astc.Profile, quality astc.EncodeQuality, threadCount int) (*Encoder, error) {
 	return nil, errDisabled
 }
 
+func NewEncoderWithQualityLevel(blockX, blockY, blockZ int, profile astc.Profile, quality float32, threadCount int) (*Encoder, error) {
+	return nil, errDisabled
+}
+
 func (e *Encoder) Close() error { return errDisabled }
 
 func (e *Encoder) EncodeRGBA8(pix []byte, width, height int) ([]byte, error) {
@@ -29,12 +44,24 @@ func (e *Encoder) EncodeRGBA8Volume(pix []byte, width, height, depth int) ([]byt
 	return nil, errDisabled
 }
 
+func (e *Encoder) CompressInto(img *astc.Image, out []byte, threadIndex int) error {
+	return errDisabled
+}
+
+func (e *Encoder) CompressReset() error { return errDisabled }
+
+func (e *Encoder) SetMetrics(m astc.Metrics) {}
+
 type EncoderF16 struct{}
 
 func NewEncoderF16(blockX, blockY, blockZ int, profile astc.Profile, quality astc.EncodeQuality, threadCount int) (*EncoderF16, error) {
 	return nil, errDisabled
 }
 
+func NewEncoderF16WithQualityLevel(blockX, blockY, blockZ int, profile astc.Profile, quality float32, threadCount int) (*EncoderF16, error) {
+	return nil, errDisabled
+}
+
 func (e *EncoderF16) Close() error { return errDisabled }
 
 func (e *EncoderF16) EncodeRGBAF16(pix []uint16, width, height int) ([]byte, error) {
@@ -45,12 +72,18 @@ func (e *EncoderF16) EncodeRGBAF16Volume(pix []uint16, width, height, depth int)
 	return nil, errDisabled
 }
 
+func (e *EncoderF16) SetMetrics(m astc.Metrics) {}
+
 type EncoderF32 struct{}
 
 func NewEncoderF32(blockX, blockY, blockZ int, profile astc.Profile, quality astc.EncodeQuality, threadCount int) (*EncoderF32, error) {
 	return nil, errDisabled
 }
 
+func NewEncoderF32WithQualityLevel(blockX, blockY, blockZ int, profile astc.Profile, quality float32, threadCount int) (*EncoderF32, error) {
+	return nil, errDisabled
+}
+
 func (e *EncoderF32) Close() error { return errDisabled }
 
 func (e *EncoderF32) EncodeRGBAF32(pix []float32, width, height int) ([]byte, error) {
@@ -61,6 +94,8 @@ func (e *EncoderF32) EncodeRGBAF32Volume(pix []float32, width, height, depth int
 	return nil, errDisabled
 }
 
+func (e *EncoderF32) SetMetrics(m astc.Metrics) {}
+
 type Decoder struct{}
 
 func NewDecoder(blockX, blockY, blockZ int, profile astc.Profile, threadCount int) (*Decoder, error) {
@@ -77,6 +112,12 @@ func (d *Decoder) DecodeRGBAF32VolumeInto(width, height, depth int, blocks []byt
 	return errDisabled
 }
 
+func (d *Decoder) DecodeRGBAF16VolumeInto(width, height, depth int, blocks []byte, dst []uint16) error {
+	return errDisabled
+}
+
+func (d *Decoder) SetMetrics(m astc.Metrics) {}
+
 func EncodeRGBA8(pix []byte, width, height int, blockX, blockY int) ([]byte, error) {
 	return nil, errDisabled
 }
@@ -145,6 +186,14 @@ func DecodeRGBA8VolumeFromParsedWithProfileInto(profile astc.Profile, h astc.Hea
 	return errDisabled
 }
 
+func DecodeRGBA8FromBlocksInto(blocks []byte, width, height, depth, blockX, blockY, blockZ int, profile astc.Profile, dst []byte) error {
+	return errDisabled
+}
+
+func DecodeBlocksRGBA8Into(blocks []byte, width, height, depth, blockX, blockY, blockZ int, profile astc.Profile, dst []byte) error {
+	return errDisabled
+}
+
 func DecodeRGBAF32VolumeWithProfile(astcData []byte, profile astc.Profile) (pix []float32, width, height, depth int, err error) {
 	return nil, 0, 0, 0, errDisabled
 }
@@ -153,6 +202,10 @@ func DecodeRGBAF32VolumeWithProfileInto(astcData []byte, profile astc.Profile, d
 	return 0, 0, 0, errDisabled
 }
 
+func DecodeRGBAF16VolumeWithProfileInto(astcData []byte, profile astc.Profile, dst []uint16) (width, height, depth int, err error) {
+	return 0, 0, 0, errDisabled
+}
+
 func DecodeRGBAF32VolumeFromParsedWithProfileInto(profile astc.Profile, h astc.Header, blocks []byte, dst []float32) error {
 	return errDisabled
 }
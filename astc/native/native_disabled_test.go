@@ -0,0 +1,34 @@
+//go:build !astcenc_native || !cgo
+
+package native_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/arm-software/astc-encoder/astc"
+	"github.com/arm-software/astc-encoder/astc/native"
+)
+
+func TestNotEnabled(t *testing.T) {
+	ok, reason := native.Available()
+	if ok || native.Enabled() || reason == "" {
+		t.Fatalf("Available() = %v, %q; Enabled() = %v", ok, reason, native.Enabled())
+	}
+
+	_, encErr := native.NewEncoder(4, 4, 1, astc.ProfileLDR, astc.EncodeMedium, 0)
+	_, decErr := native.NewDecoder(4, 4, 1, astc.ProfileLDR, 0)
+	_, cfgErr := native.ConfigInit(astc.ProfileLDR, 4, 4, 1, 60, 0)
+	var ctx *native.Context
+	for _, err := range []error{
+		encErr,
+		decErr,
+		cfgErr,
+		ctx.CompressReset(),
+		native.DecodeBlocksRGBA8Into(nil, 4, 4, 1, 4, 4, 1, astc.ProfileLDR, nil),
+	} {
+		if !errors.Is(err, native.ErrNotEnabled) {
+			t.Fatalf("got %v, want an error wrapping ErrNotEnabled", err)
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"runtime"
 	"sync"
+	"time"
 	"unsafe"
 
 	"github.com/arm-software/astc-encoder/astc"
@@ -21,24 +22,9 @@ const (
 
 func Enabled() bool { return true }
 
-func qualityToFloat(q astc.EncodeQuality) float32 {
-	switch q {
-	case astc.EncodeFastest:
-		return 0
-	case astc.EncodeFast:
-		return 10
-	case astc.EncodeMedium:
-		return 60
-	case astc.EncodeThorough:
-		return 98
-	case astc.EncodeVeryThorough:
-		return 99
-	case astc.EncodeExhaustive:
-		return 100
-	default:
-		return 60
-	}
-}
+func Available() (ok bool, reason string) { return true, "" }
+
+func Version() (version, isa string) { return nativecgo.Version() }
 
 func profileToC(p astc.Profile) (int, error) {
 	switch p {
@@ -51,23 +37,25 @@ func profileToC(p astc.Profile) (int, error) {
 	case astc.ProfileHDR:
 		return 3, nil // ASTCENC_PRF_HDR
 	default:
-		return 0, errors.New("astc/native: unknown profile")
+		return 0, newError(astc.ErrBadProfile, "astc/native: unknown profile")
 	}
 }
 
+// errFromCode converts an astcenc_error returned by op into an *astc.Error with the same code.
 func errFromCode(code int, op string) error {
 	if code == 0 {
 		return nil
 	}
 	if msg := nativecgo.ErrorString(code); msg != "" {
-		return fmt.Errorf("astc/native: %s: %s", op, msg)
+		return newError(astc.ErrorCode(code), fmt.Sprintf("astc/native: %s: %s", op, msg))
 	}
-	return fmt.Errorf("astc/native: %s: error %d", op, code)
+	return newError(astc.ErrorCode(code), fmt.Sprintf("astc/native: %s: error %d", op, code))
 }
 
 // Encoder wraps a reusable native astcenc compression context.
 //
-// Encoder is not safe for concurrent use.
+// Encoder is not safe for concurrent use, except for concurrent CompressInto calls with distinct
+// thread indices.
 type Encoder struct {
 	ctx unsafe.Pointer
 	img unsafe.Pointer
@@ -75,21 +63,41 @@ type Encoder struct {
 	inBuf unsafe.Pointer
 	inCap int
 
+	// CompressInto state: the first call for an image stages its texels into inBuf.
+	stageMu sync.Mutex
+	staged  bool
+
 	blockX int
 	blockY int
 	blockZ int
 
 	profile     astc.Profile
-	quality     astc.EncodeQuality
+	quality     float32
 	threadCount int
+
+	metrics nativeMetrics
 }
 
 func NewEncoder(blockX, blockY, blockZ int, profile astc.Profile, quality astc.EncodeQuality, threadCount int) (*Encoder, error) {
+	return newEncoder(blockX, blockY, blockZ, profile, quality.Level(), threadCount)
+}
+
+// NewEncoderWithQualityLevel is NewEncoder with a quality level from 0 (fastest) to 100
+// (exhaustive) instead of a preset; astcenc interpolates its search tuning between the
+// neighboring presets.
+func NewEncoderWithQualityLevel(blockX, blockY, blockZ int, profile astc.Profile, quality float32, threadCount int) (*Encoder, error) {
+	return newEncoder(blockX, blockY, blockZ, profile, quality, threadCount)
+}
+
+func newEncoder(blockX, blockY, blockZ int, profile astc.Profile, quality float32, threadCount int) (*Encoder, error) {
+	if !(quality >= 0 && quality <= 100) {
+		return nil, newError(astc.ErrBadQuality, "astc/native: invalid quality")
+	}
 	if blockX <= 0 || blockY <= 0 || blockZ <= 0 || blockX > 255 || blockY > 255 || blockZ > 255 {
-		return nil, errors.New("astc/native: invalid block dimensions")
+		return nil, newError(astc.ErrBadBlockSize, "astc/native: invalid block dimensions")
 	}
 	if blockX*blockY*blockZ > 216 {
-		return nil, errors.New("astc/native: invalid block dimensions")
+		return nil, newError(astc.ErrBadBlockSize, "astc/native: invalid block dimensions")
 	}
 	if threadCount <= 0 {
 		threadCount = runtime.GOMAXPROCS(0)
@@ -103,7 +111,7 @@ func NewEncoder(blockX, blockY, blockZ int, profile astc.Profile, quality astc.E
 		return nil, err
 	}
 
-	ctx, code := nativecgo.ContextCreate(cProf, blockX, blockY, blockZ, qualityToFloat(quality), 0, threadCount)
+	ctx, code := nativecgo.ContextCreate(cProf, blockX, blockY, blockZ, quality, 0, threadCount)
 	if err := errFromCode(code, "astcenc_context_alloc"); err != nil {
 		return nil, err
 	}
@@ -152,7 +160,7 @@ func (e *Encoder) ensureInCap(n int) error {
 	}
 	p := nativecgo.Realloc(e.inBuf, n)
 	if p == nil {
-		return errors.New("astc/native: out of memory")
+		return newError(astc.ErrOutOfMem, "astc/native: out of memory")
 	}
 	e.inBuf = p
 	e.inCap = n
@@ -166,7 +174,8 @@ func (e *Encoder) EncodeRGBA8(pix []byte, width, height int) ([]byte, error) {
 	return e.EncodeRGBA8Volume(pix, width, height, 1)
 }
 
-func (e *Encoder) EncodeRGBA8Volume(pix []byte, width, height, depth int) ([]byte, error) {
+func (e *Encoder) EncodeRGBA8Volume(pix []byte, width, height, depth int) (_ []byte, err error) {
+	defer e.metrics.report(true, blockCount(width, height, depth, e.blockX, e.blockY, e.blockZ), time.Now(), &err)
 	if width <= 0 || height <= 0 || depth <= 0 {
 		return nil, errors.New("astc/native: invalid image dimensions")
 	}
@@ -261,6 +270,83 @@ func (e *Encoder) EncodeRGBA8Volume(pix []byte, width, height, depth int) ([]byt
 	return out, nil
 }
 
+// CompressInto compresses a TypeU8 RGBA image into out (block payloads only, no .astc header)
+// using the encoder thread slot threadIndex, for callers that run the encoder's threads on their own
+// scheduler instead of the goroutines started by EncodeRGBA8.
+//
+// Each slot 0..threadCount-1 may call CompressInto concurrently with the same img and out; the
+// image is staged once by whichever call arrives first and the calls share the blocks between
+// them. After all calls have returned, CompressReset must be called before the next image.
+// Encoders created with a threadCount of 1 reset implicitly.
+func (e *Encoder) CompressInto(img *astc.Image, out []byte, threadIndex int) error {
+	if img == nil {
+		return errors.New("astc/native: nil image")
+	}
+	if img.DataType != astc.TypeU8 || img.Layout != astc.LayoutRGBA {
+		return errors.New("astc/native: CompressInto requires an RGBA TypeU8 image")
+	}
+	if threadIndex < 0 || threadIndex >= e.threadCount {
+		return errors.New("astc/native: invalid thread index")
+	}
+	width, height, depth := img.DimX, img.DimY, img.DimZ
+	if width <= 0 || height <= 0 || depth <= 0 {
+		return errors.New("astc/native: invalid image dimensions")
+	}
+	if len(img.DataU8) != width*height*depth*4 {
+		return errors.New("astc/native: invalid RGBA8 buffer length")
+	}
+	h := astc.Header{
+		BlockX: uint8(e.blockX),
+		BlockY: uint8(e.blockY),
+		BlockZ: uint8(e.blockZ),
+		SizeX:  uint32(width),
+		SizeY:  uint32(height),
+		SizeZ:  uint32(depth),
+	}
+	_, _, _, total, err := h.BlockCount()
+	if err != nil {
+		return err
+	}
+	if len(out) < total*astc.BlockBytes {
+		return errors.New("astc/native: output buffer too small")
+	}
+
+	e.stageMu.Lock()
+	if !e.staged {
+		if err := e.ensureInCap(len(img.DataU8)); err != nil {
+			e.stageMu.Unlock()
+			return err
+		}
+		copy(unsafe.Slice((*byte)(e.inBuf), len(img.DataU8)), img.DataU8)
+		code := nativecgo.ImageInitU8(e.img, width, height, depth, e.inBuf)
+		if err := errFromCode(code, "astcenc_image_init"); err != nil {
+			e.stageMu.Unlock()
+			return err
+		}
+		e.staged = true
+	}
+	e.stageMu.Unlock()
+
+	code := nativecgo.CompressImage(e.ctx, e.img, unsafe.Pointer(&out[0]), total*astc.BlockBytes, threadIndex)
+	if e.threadCount == 1 {
+		resetErr := e.CompressReset()
+		if err := errFromCode(code, "astcenc_compress_image"); err != nil {
+			return err
+		}
+		return resetErr
+	}
+	return errFromCode(code, "astcenc_compress_image")
+}
+
+// CompressReset prepares the encoder for the next CompressInto image. It must not be called while
+// CompressInto calls are running.
+func (e *Encoder) CompressReset() error {
+	e.stageMu.Lock()
+	e.staged = false
+	e.stageMu.Unlock()
+	return errFromCode(nativecgo.CompressReset(e.ctx), "astcenc_compress_reset")
+}
+
 // EncoderF16 wraps a reusable native astcenc compression context for RGBA float16 (IEEE binary16)
 // input.
 //
@@ -277,16 +363,32 @@ type EncoderF16 struct {
 	blockZ int
 
 	profile     astc.Profile
-	quality     astc.EncodeQuality
+	quality     float32
 	threadCount int
+
+	metrics nativeMetrics
 }
 
 func NewEncoderF16(blockX, blockY, blockZ int, profile astc.Profile, quality astc.EncodeQuality, threadCount int) (*EncoderF16, error) {
+	return newEncoderF16(blockX, blockY, blockZ, profile, quality.Level(), threadCount)
+}
+
+// NewEncoderF16WithQualityLevel is NewEncoderF16 with a quality level from 0 (fastest) to 100
+// (exhaustive) instead of a preset; astcenc interpolates its search tuning between the
+// neighboring presets.
+func NewEncoderF16WithQualityLevel(blockX, blockY, blockZ int, profile astc.Profile, quality float32, threadCount int) (*EncoderF16, error) {
+	return newEncoderF16(blockX, blockY, blockZ, profile, quality, threadCount)
+}
+
+func newEncoderF16(blockX, blockY, blockZ int, profile astc.Profile, quality float32, threadCount int) (*EncoderF16, error) {
+	if !(quality >= 0 && quality <= 100) {
+		return nil, newError(astc.ErrBadQuality, "astc/native: invalid quality")
+	}
 	if blockX <= 0 || blockY <= 0 || blockZ <= 0 || blockX > 255 || blockY > 255 || blockZ > 255 {
-		return nil, errors.New("astc/native: invalid block dimensions")
+		return nil, newError(astc.ErrBadBlockSize, "astc/native: invalid block dimensions")
 	}
 	if blockX*blockY*blockZ > 216 {
-		return nil, errors.New("astc/native: invalid block dimensions")
+		return nil, newError(astc.ErrBadBlockSize, "astc/native: invalid block dimensions")
 	}
 	if threadCount <= 0 {
 		threadCount = runtime.GOMAXPROCS(0)
@@ -300,7 +402,7 @@ func NewEncoderF16(blockX, blockY, blockZ int, profile astc.Profile, quality ast
 		return nil, err
 	}
 
-	ctx, code := nativecgo.ContextCreate(cProf, blockX, blockY, blockZ, qualityToFloat(quality), 0, threadCount)
+	ctx, code := nativecgo.ContextCreate(cProf, blockX, blockY, blockZ, quality, 0, threadCount)
 	if err := errFromCode(code, "astcenc_context_alloc"); err != nil {
 		return nil, err
 	}
@@ -349,7 +451,7 @@ func (e *EncoderF16) ensureInCap(n int) error {
 	}
 	p := nativecgo.Realloc(e.inBuf, n)
 	if p == nil {
-		return errors.New("astc/native: out of memory")
+		return newError(astc.ErrOutOfMem, "astc/native: out of memory")
 	}
 	e.inBuf = p
 	e.inCap = n
@@ -363,7 +465,8 @@ func (e *EncoderF16) EncodeRGBAF16(pix []uint16, width, height int) ([]byte, err
 	return e.EncodeRGBAF16Volume(pix, width, height, 1)
 }
 
-func (e *EncoderF16) EncodeRGBAF16Volume(pix []uint16, width, height, depth int) ([]byte, error) {
+func (e *EncoderF16) EncodeRGBAF16Volume(pix []uint16, width, height, depth int) (_ []byte, err error) {
+	defer e.metrics.report(true, blockCount(width, height, depth, e.blockX, e.blockY, e.blockZ), time.Now(), &err)
 	if width <= 0 || height <= 0 || depth <= 0 {
 		return nil, errors.New("astc/native: invalid image dimensions")
 	}
@@ -474,16 +577,32 @@ type EncoderF32 struct {
 	blockZ int
 
 	profile     astc.Profile
-	quality     astc.EncodeQuality
+	quality     float32
 	threadCount int
+
+	metrics nativeMetrics
 }
 
 func NewEncoderF32(blockX, blockY, blockZ int, profile astc.Profile, quality astc.EncodeQuality, threadCount int) (*EncoderF32, error) {
+	return newEncoderF32(blockX, blockY, blockZ, profile, quality.Level(), threadCount)
+}
+
+// NewEncoderF32WithQualityLevel is NewEncoderF32 with a quality level from 0 (fastest) to 100
+// (exhaustive) instead of a preset; astcenc interpolates its search tuning between the
+// neighboring presets.
+func NewEncoderF32WithQualityLevel(blockX, blockY, blockZ int, profile astc.Profile, quality float32, threadCount int) (*EncoderF32, error) {
+	return newEncoderF32(blockX, blockY, blockZ, profile, quality, threadCount)
+}
+
+func newEncoderF32(blockX, blockY, blockZ int, profile astc.Profile, quality float32, threadCount int) (*EncoderF32, error) {
+	if !(quality >= 0 && quality <= 100) {
+		return nil, newError(astc.ErrBadQuality, "astc/native: invalid quality")
+	}
 	if blockX <= 0 || blockY <= 0 || blockZ <= 0 || blockX > 255 || blockY > 255 || blockZ > 255 {
-		return nil, errors.New("astc/native: invalid block dimensions")
+		return nil, newError(astc.ErrBadBlockSize, "astc/native: invalid block dimensions")
 	}
 	if blockX*blockY*blockZ > 216 {
-		return nil, errors.New("astc/native: invalid block dimensions")
+		return nil, newError(astc.ErrBadBlockSize, "astc/native: invalid block dimensions")
 	}
 	if threadCount <= 0 {
 		threadCount = runtime.GOMAXPROCS(0)
@@ -497,7 +616,7 @@ func NewEncoderF32(blockX, blockY, blockZ int, profile astc.Profile, quality ast
 		return nil, err
 	}
 
-	ctx, code := nativecgo.ContextCreate(cProf, blockX, blockY, blockZ, qualityToFloat(quality), 0, threadCount)
+	ctx, code := nativecgo.ContextCreate(cProf, blockX, blockY, blockZ, quality, 0, threadCount)
 	if err := errFromCode(code, "astcenc_context_alloc"); err != nil {
 		return nil, err
 	}
@@ -546,7 +665,7 @@ func (e *EncoderF32) ensureInCap(n int) error {
 	}
 	p := nativecgo.Realloc(e.inBuf, n)
 	if p == nil {
-		return errors.New("astc/native: out of memory")
+		return newError(astc.ErrOutOfMem, "astc/native: out of memory")
 	}
 	e.inBuf = p
 	e.inCap = n
@@ -560,7 +679,8 @@ func (e *EncoderF32) EncodeRGBAF32(pix []float32, width, height int) ([]byte, er
 	return e.EncodeRGBAF32Volume(pix, width, height, 1)
 }
 
-func (e *EncoderF32) EncodeRGBAF32Volume(pix []float32, width, height, depth int) ([]byte, error) {
+func (e *EncoderF32) EncodeRGBAF32Volume(pix []float32, width, height, depth int) (_ []byte, err error) {
+	defer e.metrics.report(true, blockCount(width, height, depth, e.blockX, e.blockY, e.blockZ), time.Now(), &err)
 	if width <= 0 || height <= 0 || depth <= 0 {
 		return nil, errors.New("astc/native: invalid image dimensions")
 	}
@@ -668,14 +788,16 @@ type Decoder struct {
 
 	profile     astc.Profile
 	threadCount int
+
+	metrics nativeMetrics
 }
 
 func NewDecoder(blockX, blockY, blockZ int, profile astc.Profile, threadCount int) (*Decoder, error) {
 	if blockX <= 0 || blockY <= 0 || blockZ <= 0 || blockX > 255 || blockY > 255 || blockZ > 255 {
-		return nil, errors.New("astc/native: invalid block dimensions")
+		return nil, newError(astc.ErrBadBlockSize, "astc/native: invalid block dimensions")
 	}
 	if blockX*blockY*blockZ > 216 {
-		return nil, errors.New("astc/native: invalid block dimensions")
+		return nil, newError(astc.ErrBadBlockSize, "astc/native: invalid block dimensions")
 	}
 	if threadCount <= 0 {
 		threadCount = runtime.GOMAXPROCS(0)
@@ -712,7 +834,8 @@ func (d *Decoder) Close() error {
 	return nil
 }
 
-func (d *Decoder) DecodeRGBA8VolumeInto(width, height, depth int, blocks []byte, dst []byte) error {
+func (d *Decoder) DecodeRGBA8VolumeInto(width, height, depth int, blocks []byte, dst []byte) (err error) {
+	defer d.metrics.report(false, blockCount(width, height, depth, d.blockX, d.blockY, d.blockZ), time.Now(), &err)
 	if width <= 0 || height <= 0 || depth <= 0 {
 		return errors.New("astc/native: invalid image dimensions")
 	}
@@ -725,6 +848,7 @@ func (d *Decoder) DecodeRGBA8VolumeInto(width, height, depth int, blocks []byte,
 	if len(blocks) < needBlocks {
 		return errors.New("astc/native: block buffer too small")
 	}
+	blocks = detachBlocks(blocks[:needBlocks], dst)
 
 	workers := d.threadCount
 	if workers < 1 {
@@ -781,7 +905,8 @@ func (d *Decoder) DecodeRGBA8VolumeInto(width, height, depth int, blocks []byte,
 	return nil
 }
 
-func (d *Decoder) DecodeRGBAF32VolumeInto(width, height, depth int, blocks []byte, dst []float32) error {
+func (d *Decoder) DecodeRGBAF32VolumeInto(width, height, depth int, blocks []byte, dst []float32) (err error) {
+	defer d.metrics.report(false, blockCount(width, height, depth, d.blockX, d.blockY, d.blockZ), time.Now(), &err)
 	if width <= 0 || height <= 0 || depth <= 0 {
 		return errors.New("astc/native: invalid image dimensions")
 	}
@@ -794,6 +919,7 @@ func (d *Decoder) DecodeRGBAF32VolumeInto(width, height, depth int, blocks []byt
 	if len(blocks) < needBlocks {
 		return errors.New("astc/native: block buffer too small")
 	}
+	blocks = detachBlocks(blocks[:needBlocks], dst)
 
 	workers := d.threadCount
 	if workers < 1 {
@@ -850,6 +976,78 @@ func (d *Decoder) DecodeRGBAF32VolumeInto(width, height, depth int, blocks []byt
 	return nil
 }
 
+// DecodeRGBAF16VolumeInto decodes into RGBA IEEE 754 half-floats, like DecodeRGBAF32VolumeInto.
+func (d *Decoder) DecodeRGBAF16VolumeInto(width, height, depth int, blocks []byte, dst []uint16) (err error) {
+	defer d.metrics.report(false, blockCount(width, height, depth, d.blockX, d.blockY, d.blockZ), time.Now(), &err)
+	if width <= 0 || height <= 0 || depth <= 0 {
+		return errors.New("astc/native: invalid image dimensions")
+	}
+	if len(dst) < width*height*depth*4 {
+		return errors.New("astc/native: output buffer too small")
+	}
+
+	totalBlocks := ((width + d.blockX - 1) / d.blockX) * ((height + d.blockY - 1) / d.blockY) * ((depth + d.blockZ - 1) / d.blockZ)
+	needBlocks := totalBlocks * astc.BlockBytes
+	if len(blocks) < needBlocks {
+		return errors.New("astc/native: block buffer too small")
+	}
+	blocks = detachBlocks(blocks[:needBlocks], dst)
+
+	workers := d.threadCount
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > totalBlocks {
+		workers = totalBlocks
+	}
+
+	dataPtr := unsafe.Pointer(&blocks[0])
+	dataLen := needBlocks
+	outPtr := unsafe.Pointer(&dst[0])
+	outLen := width * height * depth * 4 * 2 // float16 bytes
+
+	if workers == 1 || totalBlocks < defaultSmallBlockHint {
+		code := nativecgo.DecompressImageRGBAF16(d.ctx, dataPtr, dataLen, width, height, depth, outPtr, outLen, 0)
+		resetCode := nativecgo.DecompressReset(d.ctx)
+		if err := errFromCode(code, "astcenc_decompress_image"); err != nil {
+			_ = errFromCode(resetCode, "astcenc_decompress_reset")
+			return err
+		}
+		if err := errFromCode(resetCode, "astcenc_decompress_reset"); err != nil {
+			return err
+		}
+		return nil
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	var firstErr error
+	var once sync.Once
+	for i := 0; i < workers; i++ {
+		threadIndex := i
+		go func() {
+			defer wg.Done()
+			code := nativecgo.DecompressImageRGBAF16(d.ctx, dataPtr, dataLen, width, height, depth, outPtr, outLen, threadIndex)
+			if code != 0 {
+				once.Do(func() {
+					firstErr = errFromCode(code, "astcenc_decompress_image")
+				})
+			}
+		}()
+	}
+	wg.Wait()
+
+	resetCode := nativecgo.DecompressReset(d.ctx)
+	if firstErr != nil {
+		_ = errFromCode(resetCode, "astcenc_decompress_reset")
+		return firstErr
+	}
+	if err := errFromCode(resetCode, "astcenc_decompress_reset"); err != nil {
+		return err
+	}
+	return nil
+}
+
 func EncodeRGBA8(pix []byte, width, height int, blockX, blockY int) ([]byte, error) {
 	return EncodeRGBA8WithProfileAndQuality(pix, width, height, blockX, blockY, astc.ProfileLDR, astc.EncodeMedium)
 }
@@ -955,6 +1153,9 @@ func DecodeRGBA8VolumeWithProfile(astcData []byte, profile astc.Profile) (pix []
 	if width <= 0 || height <= 0 || depth <= 0 {
 		return nil, 0, 0, 0, errors.New("astc/native: invalid image dimensions")
 	}
+	if err := (astc.DecodeOptions{}).CheckLimits(h); err != nil {
+		return nil, 0, 0, 0, err
+	}
 
 	pix = make([]byte, width*height*depth*4)
 	if err := DecodeRGBA8VolumeFromParsedWithProfileInto(profile, h, blocks, pix); err != nil {
@@ -1020,6 +1221,41 @@ func DecodeRGBA8VolumeFromParsedWithProfileInto(profile astc.Profile, h astc.Hea
 	return dec.DecodeRGBA8VolumeInto(width, height, depth, blocks[:total*astc.BlockBytes], dst)
 }
 
+// DecodeRGBA8FromBlocksInto decodes a headerless block payload of a width x height x depth image
+// with the given block size into dst, for archives that store the image dimensions elsewhere. dst
+// may overlap blocks; the blocks are then copied before decoding.
+func DecodeRGBA8FromBlocksInto(blocks []byte, width, height, depth, blockX, blockY, blockZ int, profile astc.Profile, dst []byte) error {
+	h, err := blocksHeader(width, height, depth, blockX, blockY, blockZ)
+	if err != nil {
+		return err
+	}
+	return DecodeRGBA8VolumeFromParsedWithProfileInto(profile, h, blocks, dst)
+}
+
+// DecodeBlocksRGBA8Into is DecodeRGBA8FromBlocksInto, under the name it had before the pure-Go
+// package gained it.
+func DecodeBlocksRGBA8Into(blocks []byte, width, height, depth, blockX, blockY, blockZ int, profile astc.Profile, dst []byte) error {
+	return DecodeRGBA8FromBlocksInto(blocks, width, height, depth, blockX, blockY, blockZ, profile, dst)
+}
+
+// blocksHeader returns the header describing a headerless block payload.
+func blocksHeader(width, height, depth, blockX, blockY, blockZ int) (astc.Header, error) {
+	if width <= 0 || height <= 0 || depth <= 0 || width > astc.MaxImageDim || height > astc.MaxImageDim || depth > astc.MaxImageDim {
+		return astc.Header{}, errors.New("astc/native: invalid image dimensions")
+	}
+	if blockX <= 0 || blockY <= 0 || blockZ <= 0 || blockX > 255 || blockY > 255 || blockZ > 255 {
+		return astc.Header{}, errors.New("astc/native: invalid block size")
+	}
+	h := astc.Header{
+		BlockX: uint8(blockX), BlockY: uint8(blockY), BlockZ: uint8(blockZ),
+		SizeX: uint32(width), SizeY: uint32(height), SizeZ: uint32(depth),
+	}
+	if err := h.Validate(); err != nil {
+		return astc.Header{}, err
+	}
+	return h, nil
+}
+
 func DecodeRGBAF32VolumeWithProfile(astcData []byte, profile astc.Profile) (pix []float32, width, height, depth int, err error) {
 	h, blocks, err := astc.ParseFile(astcData)
 	if err != nil {
@@ -1032,6 +1268,9 @@ func DecodeRGBAF32VolumeWithProfile(astcData []byte, profile astc.Profile) (pix
 	if width <= 0 || height <= 0 || depth <= 0 {
 		return nil, 0, 0, 0, errors.New("astc/native: invalid image dimensions")
 	}
+	if err := (astc.DecodeOptions{}).CheckLimits(h); err != nil {
+		return nil, 0, 0, 0, err
+	}
 
 	pix = make([]float32, width*height*depth*4)
 	if err := DecodeRGBAF32VolumeFromParsedWithProfileInto(profile, h, blocks, pix); err != nil {
@@ -1061,6 +1300,39 @@ func DecodeRGBAF32VolumeWithProfileInto(astcData []byte, profile astc.Profile, d
 	return width, height, depth, nil
 }
 
+// DecodeRGBAF16VolumeWithProfileInto decodes a .astc file into caller-provided RGBA IEEE 754
+// half-floats, like astc.DecodeRGBAF16VolumeWithProfileInto.
+func DecodeRGBAF16VolumeWithProfileInto(astcData []byte, profile astc.Profile, dst []uint16) (width, height, depth int, err error) {
+	h, blocks, err := astc.ParseFile(astcData)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	_, _, _, total, err := h.BlockCount()
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	if len(blocks) < total*astc.BlockBytes {
+		return 0, 0, 0, errors.New("astc/native: block buffer too small")
+	}
+
+	width = int(h.SizeX)
+	height = int(h.SizeY)
+	depth = int(h.SizeZ)
+	if len(dst) < width*height*depth*4 {
+		return 0, 0, 0, errors.New("astc/native: output buffer too small")
+	}
+
+	dec, err := NewDecoder(int(h.BlockX), int(h.BlockY), int(h.BlockZ), profile, 0)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	defer dec.Close()
+	if err := dec.DecodeRGBAF16VolumeInto(width, height, depth, blocks[:total*astc.BlockBytes], dst[:width*height*depth*4]); err != nil {
+		return 0, 0, 0, err
+	}
+	return width, height, depth, nil
+}
+
 func DecodeRGBAF32VolumeFromParsedWithProfileInto(profile astc.Profile, h astc.Header, blocks []byte, dst []float32) error {
 	blocksX, blocksY, blocksZ, total, err := h.BlockCount()
 	if err != nil {
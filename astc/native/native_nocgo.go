@@ -3,21 +3,31 @@
 package native
 
 import (
-	"errors"
+	"fmt"
 
 	"github.com/arm-software/astc-encoder/astc"
 )
 
-var errNoCGO = errors.New("astc/native: astcenc_native set but CGO is disabled (set CGO_ENABLED=1)")
+const noCGOReason = "astcenc_native set but CGO is disabled (set CGO_ENABLED=1)"
+
+var errNoCGO = fmt.Errorf("%w: %s", ErrNotEnabled, noCGOReason)
 
 func Enabled() bool { return false }
 
+func Available() (ok bool, reason string) { return false, noCGOReason }
+
+func Version() (version, isa string) { return "", "" }
+
 type Encoder struct{}
 
 func NewEncoder(blockX, blockY, blockZ int, profile astc.Profile, quality astc.EncodeQuality, threadCount int) (*Encoder, error) {
 	return nil, errNoCGO
 }
 
+func NewEncoderWithQualityLevel(blockX, blockY, blockZ int, profile astc.Profile, quality float32, threadCount int) (*Encoder, error) {
+	return nil, errNoCGO
+}
+
 func (e *Encoder) Close() error { return errNoCGO }
 
 func (e *Encoder) EncodeRGBA8(pix []byte, width, height int) ([]byte, error) { return nil, errNoCGO }
@@ -26,12 +36,22 @@ func (e *Encoder) EncodeRGBA8Volume(pix []byte, width, height, depth int) ([]byt
 	return nil, errNoCGO
 }
 
+func (e *Encoder) CompressInto(img *astc.Image, out []byte, threadIndex int) error { return errNoCGO }
+
+func (e *Encoder) CompressReset() error { return errNoCGO }
+
+func (e *Encoder) SetMetrics(m astc.Metrics) {}
+
 type EncoderF16 struct{}
 
 func NewEncoderF16(blockX, blockY, blockZ int, profile astc.Profile, quality astc.EncodeQuality, threadCount int) (*EncoderF16, error) {
 	return nil, errNoCGO
 }
 
+func NewEncoderF16WithQualityLevel(blockX, blockY, blockZ int, profile astc.Profile, quality float32, threadCount int) (*EncoderF16, error) {
+	return nil, errNoCGO
+}
+
 func (e *EncoderF16) Close() error { return errNoCGO }
 
 func (e *EncoderF16) EncodeRGBAF16(pix []uint16, width, height int) ([]byte, error) {
@@ -42,12 +62,18 @@ func (e *EncoderF16) EncodeRGBAF16Volume(pix []uint16, width, height, depth int)
 	return nil, errNoCGO
 }
 
+func (e *EncoderF16) SetMetrics(m astc.Metrics) {}
+
 type EncoderF32 struct{}
 
 func NewEncoderF32(blockX, blockY, blockZ int, profile astc.Profile, quality astc.EncodeQuality, threadCount int) (*EncoderF32, error) {
 	return nil, errNoCGO
 }
 
+func NewEncoderF32WithQualityLevel(blockX, blockY, blockZ int, profile astc.Profile, quality float32, threadCount int) (*EncoderF32, error) {
+	return nil, errNoCGO
+}
+
 func (e *EncoderF32) Close() error { return errNoCGO }
 
 func (e *EncoderF32) EncodeRGBAF32(pix []float32, width, height int) ([]byte, error) {
@@ -58,6 +84,8 @@ func (e *EncoderF32) EncodeRGBAF32Volume(pix []float32, width, height, depth int
 	return nil, errNoCGO
 }
 
+func (e *EncoderF32) SetMetrics(m astc.Metrics) {}
+
 type Decoder struct{}
 
 func NewDecoder(blockX, blockY, blockZ int, profile astc.Profile, threadCount int) (*Decoder, error) {
@@ -74,6 +102,12 @@ func (d *Decoder) DecodeRGBAF32VolumeInto(width, height, depth int, blocks []byt
 	return errNoCGO
 }
 
+func (d *Decoder) DecodeRGBAF16VolumeInto(width, height, depth int, blocks []byte, dst []uint16) error {
+	return errNoCGO
+}
+
+func (d *Decoder) SetMetrics(m astc.Metrics) {}
+
 func EncodeRGBA8(pix []byte, width, height int, blockX, blockY int) ([]byte, error) {
 	return nil, errNoCGO
 }
@@ -142,6 +176,14 @@ func DecodeRGBA8VolumeFromParsedWithProfileInto(profile astc.Profile, h astc.Hea
 	return errNoCGO
 }
 
+func DecodeRGBA8FromBlocksInto(blocks []byte, width, height, depth, blockX, blockY, blockZ int, profile astc.Profile, dst []byte) error {
+	return errNoCGO
+}
+
+func DecodeBlocksRGBA8Into(blocks []byte, width, height, depth, blockX, blockY, blockZ int, profile astc.Profile, dst []byte) error {
+	return errNoCGO
+}
+
 func DecodeRGBAF32VolumeWithProfile(astcData []byte, profile astc.Profile) (pix []float32, width, height, depth int, err error) {
 	return nil, 0, 0, 0, errNoCGO
 }
@@ -150,6 +192,10 @@ func DecodeRGBAF32VolumeWithProfileInto(astcData []byte, profile astc.Profile, d
 	return 0, 0, 0, errNoCGO
 }
 
+func DecodeRGBAF16VolumeWithProfileInto(astcData []byte, profile astc.Profile, dst []uint16) (width, height, depth int, err error) {
+	return 0, 0, 0, errNoCGO
+}
+
 func DecodeRGBAF32VolumeFromParsedWithProfileInto(profile astc.Profile, h astc.Header, blocks []byte, dst []float32) error {
 	return errNoCGO
 }
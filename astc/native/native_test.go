@@ -6,7 +6,9 @@ import (
 	"bytes"
 	"math"
 	"os"
+	"regexp"
 	"testing"
+	"unsafe"
 
 	"github.com/arm-software/astc-encoder/astc"
 	"github.com/arm-software/astc-encoder/astc/native"
@@ -16,6 +18,42 @@ func TestEnabled(t *testing.T) {
 	if !native.Enabled() {
 		t.Fatalf("native.Enabled() = false; want true")
 	}
+	if ok, reason := native.Available(); !ok || reason != "" {
+		t.Fatalf("native.Available() = %v, %q; want true, \"\"", ok, reason)
+	}
+}
+
+func TestVersion(t *testing.T) {
+	version, isa := native.Version()
+	if version == "" || isa == "" {
+		t.Fatalf("native.Version() = %q, %q; want non-empty", version, isa)
+	}
+
+	// The version comes from the header recorded with the vendored sources, not a build override.
+	hdr, err := os.ReadFile("internal/astcenc/upstream_version.h")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := regexp.MustCompile(`#define ASTC_NATIVE_UPSTREAM_VERSION "(\d+\.\d+\.\d+)"`).FindSubmatch(hdr)
+	if m == nil {
+		t.Fatalf("upstream_version.h does not define ASTC_NATIVE_UPSTREAM_VERSION as a x.y.z string")
+	}
+	if version != string(m[1]) {
+		t.Fatalf("native.Version() = %q, upstream_version.h records %q", version, m[1])
+	}
+}
+
+func TestImplementationRegistered(t *testing.T) {
+	for _, impl := range astc.Implementations() {
+		if impl.Name() == "native" {
+			t.Setenv(astc.ImplementationEnv, "native")
+			if got := astc.SelectImplementation().Name(); got != "native" {
+				t.Fatalf("SelectImplementation with %s=native: got %q", astc.ImplementationEnv, got)
+			}
+			return
+		}
+	}
+	t.Fatalf("native implementation not registered")
 }
 
 func TestDecodeRGBA8_MatchesPureGo_TilesLDR(t *testing.T) {
@@ -72,6 +110,85 @@ func TestEncodeRGBA8_RoundTripConst2D(t *testing.T) {
 	}
 }
 
+func TestEncodeRGBA8_QualityLevel(t *testing.T) {
+	const w, h = 24, 16
+	src := make([]byte, w*h*4)
+	for i := range src {
+		src[i] = uint8(i*13 + i/7)
+	}
+
+	medium, err := native.EncodeRGBA8WithProfileAndQuality(src, w, h, 6, 6, astc.ProfileLDR, astc.EncodeMedium)
+	if err != nil {
+		t.Fatalf("native.EncodeRGBA8WithProfileAndQuality: %v", err)
+	}
+	encodeLevel := func(quality float32) ([]byte, error) {
+		enc, err := native.NewEncoderWithQualityLevel(6, 6, 1, astc.ProfileLDR, quality, 0)
+		if err != nil {
+			return nil, err
+		}
+		defer enc.Close()
+		return enc.EncodeRGBA8(src, w, h)
+	}
+	level, err := encodeLevel(60)
+	if err != nil {
+		t.Fatalf("quality level 60: %v", err)
+	}
+	if !bytes.Equal(level, medium) {
+		t.Fatalf("quality level 60 differs from EncodeMedium")
+	}
+	if _, err := encodeLevel(75); err != nil {
+		t.Fatalf("quality level 75: %v", err)
+	}
+
+	for _, q := range []float32{-1, 101, float32(math.NaN())} {
+		_, err := native.NewEncoderF32WithQualityLevel(6, 6, 1, astc.ProfileHDR, q, 1)
+		if astc.ErrorCodeOf(err) != astc.ErrBadQuality {
+			t.Fatalf("NewEncoderF32WithQualityLevel(%v): %v, want ErrBadQuality", q, err)
+		}
+	}
+}
+
+func TestDecodeBlocksRGBA8Into(t *testing.T) {
+	const w, h, d = 20, 12, 3
+	src := make([]byte, w*h*d*4)
+	for i := range src {
+		src[i] = uint8(i*29 + i/5)
+	}
+	file, err := native.EncodeRGBA8VolumeWithProfileAndQuality(src, w, h, d, 4, 4, 4, astc.ProfileLDR, astc.EncodeFast)
+	if err != nil {
+		t.Fatalf("native.EncodeRGBA8VolumeWithProfileAndQuality: %v", err)
+	}
+	want, _, _, _, err := native.DecodeRGBA8VolumeWithProfile(file, astc.ProfileLDR)
+	if err != nil {
+		t.Fatalf("native.DecodeRGBA8VolumeWithProfile: %v", err)
+	}
+
+	got := make([]byte, w*h*d*4)
+	if err := native.DecodeBlocksRGBA8Into(file[astc.HeaderSize:], w, h, d, 4, 4, 4, astc.ProfileLDR, got); err != nil {
+		t.Fatalf("native.DecodeBlocksRGBA8Into: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("headerless decode differs from file decode")
+	}
+	goGot := make([]byte, len(got))
+	if err := astc.DecodeRGBA8FromBlocksInto(file[astc.HeaderSize:], w, h, d, 4, 4, 4, astc.ProfileLDR, goGot); err != nil {
+		t.Fatalf("astc.DecodeRGBA8FromBlocksInto: %v", err)
+	}
+	if !bytes.Equal(goGot, got) {
+		t.Fatalf("pure-Go headerless decode differs from native")
+	}
+
+	if err := native.DecodeBlocksRGBA8Into(file[astc.HeaderSize:len(file)-1], w, h, d, 4, 4, 4, astc.ProfileLDR, got); err == nil {
+		t.Fatalf("expected error for short block buffer")
+	}
+	if err := native.DecodeBlocksRGBA8Into(file[astc.HeaderSize:], w, h, d, 4, 4, 4, astc.ProfileLDR, got[:len(got)-1]); err == nil {
+		t.Fatalf("expected error for short output buffer")
+	}
+	if err := native.DecodeBlocksRGBA8Into(file[astc.HeaderSize:], w, h, d, 4, 7, 4, astc.ProfileLDR, got); err == nil {
+		t.Fatalf("expected error for invalid block size")
+	}
+}
+
 func TestEncodeRGBA8_RoundTripConst3D(t *testing.T) {
 	const (
 		w = 4
@@ -160,6 +277,36 @@ func TestEncodeRGBAF32_MatchesPureGoDecode_HDR2D(t *testing.T) {
 	}
 }
 
+func TestDecodeRGBAF16VolumeWithProfileInto_MatchesPureGo(t *testing.T) {
+	const w, h, d = 13, 7, 5
+	src := make([]float32, w*h*d*4)
+	for i := range src {
+		src[i] = float32(i%97) * 0.37
+	}
+	astcData, err := native.EncodeRGBAF32VolumeWithProfileAndQuality(src, w, h, d, 4, 4, 4, astc.ProfileHDR, astc.EncodeFast)
+	if err != nil {
+		t.Fatalf("native.EncodeRGBAF32VolumeWithProfileAndQuality: %v", err)
+	}
+
+	want := make([]uint16, w*h*d*4)
+	if _, _, _, err := astc.DecodeRGBAF16VolumeWithProfileInto(astcData, astc.ProfileHDR, want); err != nil {
+		t.Fatalf("astc.DecodeRGBAF16VolumeWithProfileInto: %v", err)
+	}
+	got := make([]uint16, w*h*d*4)
+	nW, nH, nD, err := native.DecodeRGBAF16VolumeWithProfileInto(astcData, astc.ProfileHDR, got)
+	if err != nil {
+		t.Fatalf("native.DecodeRGBAF16VolumeWithProfileInto: %v", err)
+	}
+	if nW != w || nH != h || nD != d {
+		t.Fatalf("dimensions = %dx%dx%d, want %dx%dx%d", nW, nH, nD, w, h, d)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("half mismatch at %d: got %04x want %04x", i, got[i], want[i])
+		}
+	}
+}
+
 func TestEncodeRGBAF32_MatchesPureGoDecode_HDRVolume(t *testing.T) {
 	const (
 		w      = 5
@@ -217,3 +364,186 @@ func TestEncodeRGBAF32_MatchesPureGoDecode_HDRVolume(t *testing.T) {
 		}
 	}
 }
+
+func TestEncoder_CompressInto_ExternalWorkers(t *testing.T) {
+	const (
+		w       = 64
+		h       = 48
+		workers = 4
+	)
+	src := make([]byte, w*h*4)
+	for i := range src {
+		src[i] = uint8(i*13 + i/251)
+	}
+	img := astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeU8, DataU8: src}
+
+	ref, err := native.NewEncoder(6, 6, 1, astc.ProfileLDR, astc.EncodeMedium, 1)
+	if err != nil {
+		t.Fatalf("native.NewEncoder: %v", err)
+	}
+	defer ref.Close()
+	want, err := ref.EncodeRGBA8(src, w, h)
+	if err != nil {
+		t.Fatalf("EncodeRGBA8: %v", err)
+	}
+	want = want[astc.HeaderSize:]
+
+	// Single-threaded encoders reset implicitly between images.
+	for i := 0; i < 2; i++ {
+		got := make([]byte, len(want))
+		if err := ref.CompressInto(&img, got, 0); err != nil {
+			t.Fatalf("CompressInto: %v", err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Fatalf("single-threaded CompressInto output differs from EncodeRGBA8")
+		}
+	}
+
+	enc, err := native.NewEncoder(6, 6, 1, astc.ProfileLDR, astc.EncodeMedium, workers)
+	if err != nil {
+		t.Fatalf("native.NewEncoder: %v", err)
+	}
+	defer enc.Close()
+	for round := 0; round < 2; round++ {
+		got := make([]byte, len(want))
+		errs := make(chan error, workers)
+		for i := 0; i < workers; i++ {
+			go func(threadIndex int) { errs <- enc.CompressInto(&img, got, threadIndex) }(i)
+		}
+		for i := 0; i < workers; i++ {
+			if err := <-errs; err != nil {
+				t.Fatalf("CompressInto: %v", err)
+			}
+		}
+		if err := enc.CompressReset(); err != nil {
+			t.Fatalf("CompressReset: %v", err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Fatalf("round %d: multi-threaded CompressInto output differs from EncodeRGBA8", round)
+		}
+	}
+
+	if err := enc.CompressInto(&img, make([]byte, len(want)), workers); err == nil {
+		t.Fatalf("expected error for out-of-range thread index")
+	}
+	if err := enc.CompressInto(&img, make([]byte, len(want)-1), 0); err == nil {
+		t.Fatalf("expected error for short output")
+	}
+}
+
+// countingMetrics is an astc.Metrics recording counter totals and histogram sample counts.
+type countingMetrics struct {
+	counters map[string]int64
+	samples  map[string]int
+}
+
+func (c *countingMetrics) Count(name string, delta int64)     { c.counters[name] += delta }
+func (c *countingMetrics) Observe(name string, value float64) { c.samples[name]++ }
+
+func TestEncoderDecoder_SetMetrics(t *testing.T) {
+	const w, h = 20, 12 // 5x3 blocks of 4x4
+	src := make([]byte, w*h*4)
+	for i := range src {
+		src[i] = uint8(i * 5)
+	}
+	m := &countingMetrics{counters: map[string]int64{}, samples: map[string]int{}}
+
+	enc, err := native.NewEncoder(4, 4, 1, astc.ProfileLDR, astc.EncodeFast, 1)
+	if err != nil {
+		t.Fatalf("native.NewEncoder: %v", err)
+	}
+	defer enc.Close()
+	enc.SetMetrics(m)
+	out, err := enc.EncodeRGBA8(src, w, h)
+	if err != nil {
+		t.Fatalf("EncodeRGBA8: %v", err)
+	}
+	if _, err := enc.EncodeRGBA8(src[:4], w, h); err == nil {
+		t.Fatalf("expected error for short input")
+	}
+
+	dec, err := native.NewDecoder(4, 4, 1, astc.ProfileLDR, 1)
+	if err != nil {
+		t.Fatalf("native.NewDecoder: %v", err)
+	}
+	defer dec.Close()
+	dec.SetMetrics(m)
+	if err := dec.DecodeRGBA8VolumeInto(w, h, 1, out[astc.HeaderSize:], make([]byte, w*h*4)); err != nil {
+		t.Fatalf("DecodeRGBA8VolumeInto: %v", err)
+	}
+
+	for name, want := range map[string]int64{
+		astc.MetricBlocksEncoded: 15,
+		astc.MetricBlocksDecoded: 15,
+		astc.MetricErrors:        1,
+		astc.MetricContextReuses: 1,
+	} {
+		if got := m.counters[name]; got != want {
+			t.Errorf("%s = %d, want %d", name, got, want)
+		}
+	}
+	if m.samples[astc.MetricEncodeSeconds] != 1 || m.samples[astc.MetricDecodeSeconds] != 1 {
+		t.Errorf("timing samples %v, want one encode and one decode", m.samples)
+	}
+}
+
+func TestDecode_OverlappingOutput(t *testing.T) {
+	const w, h = 64, 48
+	pix := make([]byte, w*h*4)
+	for i := range pix {
+		pix[i] = uint8(i*13 + i/7)
+	}
+	data, err := native.EncodeRGBA8VolumeWithProfileAndQuality(pix, w, h, 1, 4, 4, 1, astc.ProfileLDR, astc.EncodeFast)
+	if err != nil {
+		t.Fatalf("native.EncodeRGBA8VolumeWithProfileAndQuality: %v", err)
+	}
+	want, _, _, _, err := native.DecodeRGBA8VolumeWithProfile(data, astc.ProfileLDR)
+	if err != nil {
+		t.Fatalf("native.DecodeRGBA8VolumeWithProfile: %v", err)
+	}
+	blocks := data[astc.HeaderSize:]
+
+	// The payload is placed at the start, in the middle and at the end of the output buffer.
+	for _, off := range []int{0, len(want)/2 - len(blocks)/2, len(want) - len(blocks)} {
+		arena := make([]byte, len(want))
+		copy(arena[off:], blocks)
+		if err := native.DecodeRGBA8FromBlocksInto(arena[off:off+len(blocks)], w, h, 1, 4, 4, 1, astc.ProfileLDR, arena); err != nil {
+			t.Fatalf("DecodeRGBA8FromBlocksInto (offset %d): %v", off, err)
+		}
+		if !bytes.Equal(arena, want) {
+			t.Fatalf("DecodeRGBA8FromBlocksInto (offset %d): output differs", off)
+		}
+
+		wantF16 := make([]uint16, w*h*4)
+		if _, _, _, err := native.DecodeRGBAF16VolumeWithProfileInto(data, astc.ProfileLDR, wantF16); err != nil {
+			t.Fatalf("DecodeRGBAF16VolumeWithProfileInto: %v", err)
+		}
+		halves := make([]uint16, w*h*4)
+		arena = unsafe.Slice((*byte)(unsafe.Pointer(&halves[0])), len(halves)*2)
+		copy(arena[off:], data)
+		if _, _, _, err := native.DecodeRGBAF16VolumeWithProfileInto(arena[off:off+len(data)], astc.ProfileLDR, halves); err != nil {
+			t.Fatalf("DecodeRGBAF16VolumeWithProfileInto (offset %d): %v", off, err)
+		}
+		for i := range halves {
+			if halves[i] != wantF16[i] {
+				t.Fatalf("DecodeRGBAF16VolumeWithProfileInto (offset %d): half %d = %04x, want %04x", off, i, halves[i], wantF16[i])
+			}
+		}
+	}
+
+	cfg, err := native.ConfigInit(astc.ProfileLDR, 4, 4, 1, 60, 0)
+	if err != nil {
+		t.Fatalf("ConfigInit: %v", err)
+	}
+	ctx, err := native.ContextAlloc(&cfg, 1)
+	if err != nil {
+		t.Fatalf("ContextAlloc: %v", err)
+	}
+	defer ctx.Close()
+	arena := make([]byte, len(want))
+	copy(arena, blocks)
+	img := &native.Image{DimX: w, DimY: h, DimZ: 1, DataType: native.TypeU8, DataU8: arena}
+	if err := ctx.DecompressImage(arena[:len(blocks)], img, native.SwizzleRGBA, 0); astc.ErrorCodeOf(err) != astc.ErrBadParam {
+		t.Fatalf("Context.DecompressImage into overlapping image: got %v, want ErrBadParam", err)
+	}
+}
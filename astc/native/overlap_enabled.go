@@ -0,0 +1,32 @@
+//go:build astcenc_native && cgo
+
+package native
+
+import "unsafe"
+
+// The native decoder writes texels while other threads still read blocks, so decoding into memory
+// shared with the block payload would overwrite blocks before they are read. The Decoder methods,
+// and the package functions built on them, therefore take a private copy of the payload when it
+// overlaps the destination, like the pure-Go decoders (see astc.DecodeRGBA8VolumeWithProfileInto).
+// Context.DecompressImage is called once per thread, which cannot agree on one copy, so it rejects
+// overlapping buffers instead.
+
+// overlaps reports whether blocks and s share any memory.
+func overlaps[T any](blocks []byte, s []T) bool {
+	if len(blocks) == 0 || len(s) == 0 {
+		return false
+	}
+	b0 := uintptr(unsafe.Pointer(unsafe.SliceData(blocks)))
+	b1 := b0 + uintptr(len(blocks))
+	s0 := uintptr(unsafe.Pointer(unsafe.SliceData(s)))
+	s1 := s0 + uintptr(len(s))*unsafe.Sizeof(s[0])
+	return b0 < s1 && s0 < b1
+}
+
+// detachBlocks returns blocks, or a copy of them when they overlap dst.
+func detachBlocks[T any](blocks []byte, dst []T) []byte {
+	if !overlaps(blocks, dst) {
+		return blocks
+	}
+	return append([]byte(nil), blocks...)
+}
@@ -0,0 +1,90 @@
+package astc_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/arm-software/astc-encoder/astc"
+	"github.com/arm-software/astc-encoder/astc/testimage"
+)
+
+func TestBlockOracleError(t *testing.T) {
+	const w, h, bs = 36, 36, 6
+	for _, kind := range []testimage.Kind{testimage.KindPerlin, testimage.KindAlphaCutout} {
+		src, err := testimage.RGBA8(kind, w, h, 1, testimage.Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		cfg, err := astc.ConfigInit(astc.ProfileLDR, bs, bs, 1, 98, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var log bytes.Buffer
+		cfg.DecisionLog = &log
+		ctx, err := astc.ContextAlloc(&cfg, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		blocks := make([]byte, blocksLenBytes(w, h, 1, bs, bs, 1))
+		img := astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeU8, DataU8: src}
+		if err := ctx.CompressImage(&img, astc.SwizzleRGBA, blocks, 0); err != nil {
+			t.Fatal(err)
+		}
+		var decisions astc.DecisionLog
+		if err := json.Unmarshal(log.Bytes(), &decisions); err != nil {
+			t.Fatal(err)
+		}
+
+		var sumOracle, sumActual float64
+		for _, d := range decisions.Blocks {
+			if d.Constant {
+				continue
+			}
+			bx, by := d.Index%decisions.BlocksX*bs, d.Index/decisions.BlocksX*bs
+			texels := make([]float32, bs*bs*4)
+			for y := range bs {
+				for x := range bs * 4 {
+					texels[y*bs*4+x] = float32(src[((by+y)*w+bx)*4+x])
+				}
+			}
+			block := astc.OracleBlock{BlockX: bs, BlockY: bs, BlockZ: 1, Texels: texels}
+			mode := astc.OracleMode{BlockMode: d.Mode, PartitionCount: d.Partitions, Plane2Component: d.Plane2Component}
+			got, err := astc.BlockOracleError(block, mode, d.PartitionIndex)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got > d.Error*1.0001+1e-6 {
+				t.Errorf("%v block %d: oracle error %g above the encoded error %g", kind, d.Index, got, d.Error)
+			}
+			sumOracle += got
+			sumActual += d.Error
+		}
+		t.Logf("%v: oracle %.0f, encoded %.0f", kind, sumOracle, sumActual)
+	}
+
+	// A block on a line through RGBA space is exact with any mode that has a full weight grid.
+	texels := make([]float32, 4*4*4)
+	for i := range 16 {
+		texels[i*4], texels[i*4+1], texels[i*4+2], texels[i*4+3] = float32(i), float32(2*i), 40-float32(i), 255
+	}
+	block := astc.OracleBlock{BlockX: 4, BlockY: 4, BlockZ: 1, Texels: texels}
+	full := astc.OracleMode{BlockMode: 0x42, PartitionCount: 1} // 4x4 weights, 2 levels
+	if got, err := astc.BlockOracleError(block, full, 0); err != nil || got > 1e-6 {
+		t.Fatalf("line block: error %g, %v", got, err)
+	}
+	for _, tc := range []struct {
+		block astc.OracleBlock
+		mode  astc.OracleMode
+		index int
+	}{
+		{astc.OracleBlock{BlockX: 4, BlockY: 4, BlockZ: 1, Texels: texels[:60]}, full, 0},
+		{block, astc.OracleMode{BlockMode: 0}, 0},
+		{block, astc.OracleMode{BlockMode: 0x42, PartitionCount: 5}, 0},
+		{block, full, 1024},
+	} {
+		if _, err := astc.BlockOracleError(tc.block, tc.mode, tc.index); err == nil {
+			t.Fatalf("%+v index %d: expected error", tc.mode, tc.index)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package astc
+
+import "unsafe"
+
+// Decoding into memory that shares storage with the block payload is supported by every decode
+// function, here and in astc/native: callers commonly carve both from one arena and decode in
+// place. Texels are written in block order and each block expands to at least 64 bytes of output,
+// so writing the output would overwrite blocks which are still to be read. Decoders therefore take
+// a private copy of the payload first when it overlaps the destination; disjoint buffers are
+// decoded without copying. The one exception is native.Context.DecompressImage, whose threads
+// call it separately, which rejects overlapping buffers.
+
+// overlaps reports whether blocks and s share any memory.
+func overlaps[T any](blocks []byte, s []T) bool {
+	if len(blocks) == 0 || len(s) == 0 {
+		return false
+	}
+	b0 := uintptr(unsafe.Pointer(unsafe.SliceData(blocks)))
+	b1 := b0 + uintptr(len(blocks))
+	s0 := uintptr(unsafe.Pointer(unsafe.SliceData(s)))
+	s1 := s0 + uintptr(len(s))*unsafe.Sizeof(s[0])
+	return b0 < s1 && s0 < b1
+}
+
+// detachBlocks returns blocks, or a copy of them when they overlap dst.
+func detachBlocks[T any](blocks []byte, dst []T) []byte {
+	if !overlaps(blocks, dst) {
+		return blocks
+	}
+	return append([]byte(nil), blocks...)
+}
+
+// detachBlocksFromImage is detachBlocks for the pixel buffers of img.
+func detachBlocksFromImage(blocks []byte, img *Image) []byte {
+	if overlaps(blocks, img.DataU8) || overlaps(blocks, img.DataF16) || overlaps(blocks, img.DataF32) {
+		return append([]byte(nil), blocks...)
+	}
+	return blocks
+}
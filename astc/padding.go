@@ -0,0 +1,42 @@
+package astc
+
+// paddingBlock is the block WithOutputAlignment pads with: a transparent black constant-color
+// block, which decodes legally in every profile.
+var paddingBlock = EncodeConstBlockRGBA8(0, 0, 0, 0)
+
+// PaddingBlock returns the constant-color block (transparent black) that WithOutputAlignment pads
+// the block region with.
+func PaddingBlock() [BlockBytes]byte {
+	return paddingBlock
+}
+
+// fillPadding fills dst, a multiple of BlockBytes long, with padding blocks.
+func fillPadding(dst []byte) {
+	for i := 0; i < len(dst); i += BlockBytes {
+		copy(dst[i:], paddingBlock[:])
+	}
+}
+
+// isPadding reports whether data is a whole number of padding blocks.
+func isPadding(data []byte) bool {
+	if len(data)%BlockBytes != 0 {
+		return false
+	}
+	for i := 0; i < len(data); i += BlockBytes {
+		if [BlockBytes]byte(data[i:i+BlockBytes]) != paddingBlock {
+			return false
+		}
+	}
+	return true
+}
+
+// BlockRegionSize returns the logical size of the block region of the .astc file data, as implied
+// by its header, and its padded size: every byte after the header, including padding added by
+// WithOutputAlignment (or zero bytes) that ParseFile strips.
+func BlockRegionSize(data []byte) (logical, padded int, err error) {
+	_, blocks, err := ParseFile(data)
+	if err != nil {
+		return 0, 0, err
+	}
+	return len(blocks), len(data) - HeaderSize, nil
+}
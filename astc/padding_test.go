@@ -0,0 +1,76 @@
+package astc_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/arm-software/astc-encoder/astc"
+)
+
+func TestEncoder_OutputAlignment(t *testing.T) {
+	const w, h = 20, 12
+	pix := make([]byte, w*h*4)
+	for i := range pix {
+		pix[i] = byte(i * 7)
+	}
+	img := &astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeU8, DataU8: pix}
+
+	plain, err := astc.NewEncoder(astc.WithBlockSize(4, 4), astc.WithQuality(astc.EncodeFastest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := plain.Encode(img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const align = 256
+	enc, err := astc.NewEncoder(astc.WithBlockSize(4, 4), astc.WithQuality(astc.EncodeFastest), astc.WithOutputAlignment(align))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := enc.Encode(img)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logical := len(want) - astc.HeaderSize
+	if (len(got)-astc.HeaderSize)%align != 0 || !bytes.Equal(got[:len(want)], want) {
+		t.Fatalf("padded output: len %d, want %d bytes plus padding to %d", len(got), len(want), align)
+	}
+	pad := astc.PaddingBlock()
+	for off := len(want); off < len(got); off += astc.BlockBytes {
+		if !bytes.Equal(got[off:off+astc.BlockBytes], pad[:]) {
+			t.Fatalf("byte %d: not a padding block", off)
+		}
+	}
+	if l, p, err := astc.BlockRegionSize(got); err != nil || l != logical || p != len(got)-astc.HeaderSize {
+		t.Fatalf("BlockRegionSize = %d, %d, %v; want %d, %d", l, p, err, logical, len(got)-astc.HeaderSize)
+	}
+	if _, blocks, err := astc.ParseFile(got); err != nil || !bytes.Equal(blocks, want[astc.HeaderSize:]) {
+		t.Fatalf("ParseFile of padded output: %v", err)
+	}
+
+	// Padding followed by anything else is still rejected as concatenated data.
+	bad := append(append([]byte(nil), got...), want[astc.HeaderSize:astc.HeaderSize+astc.BlockBytes]...)
+	if _, _, err := astc.ParseFile(bad); err == nil {
+		t.Fatal("expected error for data after padding")
+	}
+
+	var buf astc.SegmentBuffer
+	segs, err := enc.EncodeSegments(img, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if joined := bytes.Join(segs, nil); !bytes.Equal(joined, got) {
+		t.Fatal("EncodeSegments output differs from Encode")
+	}
+
+	// An already aligned block region gets no padding.
+	aligned, err := astc.NewEncoder(astc.WithBlockSize(4, 4), astc.WithQuality(astc.EncodeFastest), astc.WithOutputAlignment(logical))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out, err := aligned.Encode(img); err != nil || len(out) != len(want) {
+		t.Fatalf("aligned encode: len %d, err %v; want %d", len(out), err, len(want))
+	}
+}
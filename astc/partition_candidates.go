@@ -1,14 +1,19 @@
 package astc
 
-import "sort"
+import (
+	"math/bits"
+	"slices"
+	"sort"
+)
 
 // selectBestPartitionIndices picks a small set of promising partition seeds to try.
 //
 // It ranks seeds by their total within-partition SSE in RGB (and A if includeAlpha is true),
-// and returns a deterministic list sorted by partition index.
+// each channel scaled by channelWeight, and returns a deterministic list sorted by partition
+// index.
 //
 // The dst slice is used as output storage; the returned value is the number of entries written.
-func selectBestPartitionIndices(dst []int, texels []byte, pt *partitionTable, partitionCount int, searchLimit int, includeAlpha bool) int {
+func selectBestPartitionIndices(dst []int, texels []byte, pt *partitionTable, partitionCount int, searchLimit int, includeAlpha bool, channelWeight [4]float32) int {
 	if pt == nil || len(dst) == 0 || searchLimit <= 0 || partitionCount < 2 || partitionCount > 4 {
 		return 0
 	}
@@ -21,17 +26,18 @@ func selectBestPartitionIndices(dst []int, texels []byte, pt *partitionTable, pa
 	if limit > (1 << partitionIndexBits) {
 		limit = 1 << partitionIndexBits
 	}
+	wR, wG, wB, wA := partitionScoreWeights(channelWeight, includeAlpha)
 
 	// Keep the best N candidates in-place in dst, tracking their scores separately.
 	// N is small (<=128), and limit is at most 1024, so O(N*limit) selection is fine.
-	var scoresArr [128]uint64
+	var scoresArr [128]float64
 	scores := scoresArr[:len(dst)]
 
 	bestCount := 0
 	for pidx := 0; pidx < limit; pidx++ {
 		assign := pt.partitionsForIndex(pidx)
 
-		var score uint64
+		var score float64
 		switch partitionCount {
 		case 2:
 			var count0, count1 uint32
@@ -78,25 +84,25 @@ func selectBestPartitionIndices(dst []int, texels []byte, pt *partitionTable, pa
 			n1 := uint64(count1)
 
 			s := uint64(sum0r)
-			score += sq0r - (s*s)/n0
+			score += wR * float64(sq0r-(s*s)/n0)
 			s = uint64(sum0g)
-			score += sq0g - (s*s)/n0
+			score += wG * float64(sq0g-(s*s)/n0)
 			s = uint64(sum0b)
-			score += sq0b - (s*s)/n0
+			score += wB * float64(sq0b-(s*s)/n0)
 			if includeAlpha {
 				s = uint64(sum0a)
-				score += sq0a - (s*s)/n0
+				score += wA * float64(sq0a-(s*s)/n0)
 			}
 
 			s = uint64(sum1r)
-			score += sq1r - (s*s)/n1
+			score += wR * float64(sq1r-(s*s)/n1)
 			s = uint64(sum1g)
-			score += sq1g - (s*s)/n1
+			score += wG * float64(sq1g-(s*s)/n1)
 			s = uint64(sum1b)
-			score += sq1b - (s*s)/n1
+			score += wB * float64(sq1b-(s*s)/n1)
 			if includeAlpha {
 				s = uint64(sum1a)
-				score += sq1a - (s*s)/n1
+				score += wA * float64(sq1a-(s*s)/n1)
 			}
 
 		case 3:
@@ -158,36 +164,36 @@ func selectBestPartitionIndices(dst []int, texels []byte, pt *partitionTable, pa
 			n2 := uint64(count2)
 
 			s := uint64(sum0r)
-			score += sq0r - (s*s)/n0
+			score += wR * float64(sq0r-(s*s)/n0)
 			s = uint64(sum0g)
-			score += sq0g - (s*s)/n0
+			score += wG * float64(sq0g-(s*s)/n0)
 			s = uint64(sum0b)
-			score += sq0b - (s*s)/n0
+			score += wB * float64(sq0b-(s*s)/n0)
 			if includeAlpha {
 				s = uint64(sum0a)
-				score += sq0a - (s*s)/n0
+				score += wA * float64(sq0a-(s*s)/n0)
 			}
 
 			s = uint64(sum1r)
-			score += sq1r - (s*s)/n1
+			score += wR * float64(sq1r-(s*s)/n1)
 			s = uint64(sum1g)
-			score += sq1g - (s*s)/n1
+			score += wG * float64(sq1g-(s*s)/n1)
 			s = uint64(sum1b)
-			score += sq1b - (s*s)/n1
+			score += wB * float64(sq1b-(s*s)/n1)
 			if includeAlpha {
 				s = uint64(sum1a)
-				score += sq1a - (s*s)/n1
+				score += wA * float64(sq1a-(s*s)/n1)
 			}
 
 			s = uint64(sum2r)
-			score += sq2r - (s*s)/n2
+			score += wR * float64(sq2r-(s*s)/n2)
 			s = uint64(sum2g)
-			score += sq2g - (s*s)/n2
+			score += wG * float64(sq2g-(s*s)/n2)
 			s = uint64(sum2b)
-			score += sq2b - (s*s)/n2
+			score += wB * float64(sq2b-(s*s)/n2)
 			if includeAlpha {
 				s = uint64(sum2a)
-				score += sq2a - (s*s)/n2
+				score += wA * float64(sq2a-(s*s)/n2)
 			}
 
 		case 4:
@@ -262,47 +268,47 @@ func selectBestPartitionIndices(dst []int, texels []byte, pt *partitionTable, pa
 			n3 := uint64(count3)
 
 			s := uint64(sum0r)
-			score += sq0r - (s*s)/n0
+			score += wR * float64(sq0r-(s*s)/n0)
 			s = uint64(sum0g)
-			score += sq0g - (s*s)/n0
+			score += wG * float64(sq0g-(s*s)/n0)
 			s = uint64(sum0b)
-			score += sq0b - (s*s)/n0
+			score += wB * float64(sq0b-(s*s)/n0)
 			if includeAlpha {
 				s = uint64(sum0a)
-				score += sq0a - (s*s)/n0
+				score += wA * float64(sq0a-(s*s)/n0)
 			}
 
 			s = uint64(sum1r)
-			score += sq1r - (s*s)/n1
+			score += wR * float64(sq1r-(s*s)/n1)
 			s = uint64(sum1g)
-			score += sq1g - (s*s)/n1
+			score += wG * float64(sq1g-(s*s)/n1)
 			s = uint64(sum1b)
-			score += sq1b - (s*s)/n1
+			score += wB * float64(sq1b-(s*s)/n1)
 			if includeAlpha {
 				s = uint64(sum1a)
-				score += sq1a - (s*s)/n1
+				score += wA * float64(sq1a-(s*s)/n1)
 			}
 
 			s = uint64(sum2r)
-			score += sq2r - (s*s)/n2
+			score += wR * float64(sq2r-(s*s)/n2)
 			s = uint64(sum2g)
-			score += sq2g - (s*s)/n2
+			score += wG * float64(sq2g-(s*s)/n2)
 			s = uint64(sum2b)
-			score += sq2b - (s*s)/n2
+			score += wB * float64(sq2b-(s*s)/n2)
 			if includeAlpha {
 				s = uint64(sum2a)
-				score += sq2a - (s*s)/n2
+				score += wA * float64(sq2a-(s*s)/n2)
 			}
 
 			s = uint64(sum3r)
-			score += sq3r - (s*s)/n3
+			score += wR * float64(sq3r-(s*s)/n3)
 			s = uint64(sum3g)
-			score += sq3g - (s*s)/n3
+			score += wG * float64(sq3g-(s*s)/n3)
 			s = uint64(sum3b)
-			score += sq3b - (s*s)/n3
+			score += wB * float64(sq3b-(s*s)/n3)
 			if includeAlpha {
 				s = uint64(sum3a)
-				score += sq3a - (s*s)/n3
+				score += wA * float64(sq3a-(s*s)/n3)
 			}
 		}
 
@@ -342,6 +348,114 @@ func selectBestPartitionIndices(dst []int, texels []byte, pt *partitionTable, pa
 }
 
 // selectBestPartitionIndices2 is a specialized wrapper for the most common encoder case.
-func selectBestPartitionIndices2(dst []int, texels []byte, pt *partitionTable, searchLimit int, includeAlpha bool) int {
-	return selectBestPartitionIndices(dst, texels, pt, 2, searchLimit, includeAlpha)
+func selectBestPartitionIndices2(dst []int, texels []byte, pt *partitionTable, searchLimit int, includeAlpha bool, channelWeight [4]float32) int {
+	return selectBestPartitionIndices(dst, texels, pt, 2, searchLimit, includeAlpha, channelWeight)
+}
+
+// partitionScoreWeights returns the per-channel weights of the partition scorers. Uniform weights
+// over the scored channels rank partitions like unit weights, so they are replaced by ones, which
+// keeps the scores exact integers and the ranking independent of the weight scale.
+func partitionScoreWeights(channelWeight [4]float32, includeAlpha bool) (wR, wG, wB, wA float64) {
+	w := channelWeight
+	if w[0] == w[1] && w[1] == w[2] && (!includeAlpha || w[3] == w[0]) {
+		return 1, 1, 1, 1
+	}
+	return float64(w[0]), float64(w[1]), float64(w[2]), float64(w[3])
+}
+
+// lumaSplitMinRange is the smallest luma range (as a sum of R, G and B) for which
+// lumaSplitPartition tests a split; flatter blocks split on noise.
+const lumaSplitMinRange = 24
+
+// lumaSplitPartition is a cheap pre-test for blocks made of two luma levels. It thresholds the texel
+// lumas at the midpoint of their range and returns the 2-partition index below limit whose pattern
+// matches the split best, in either partition order, if at most one texel in 16 disagrees. Each
+// index costs a few popcounts, against a pass over the texels for selectBestPartitionIndices.
+func lumaSplitPartition(pt *partitionTable, luma []int, limit int) (int, bool) {
+	if pt == nil || pt.masks == nil || len(luma) < pt.texelCount {
+		return 0, false
+	}
+	texelCount := pt.texelCount
+	lo, hi := luma[0], luma[0]
+	for _, l := range luma[:texelCount] {
+		lo = min(lo, l)
+		hi = max(hi, l)
+	}
+	if hi-lo < lumaSplitMinRange {
+		return 0, false
+	}
+	threshold := (lo + hi) / 2
+	var split texelMask
+	for t, l := range luma[:texelCount] {
+		if l > threshold {
+			split[t/64] |= 1 << (t % 64)
+		}
+	}
+
+	limit = min(limit, len(pt.masks))
+	best, bestDiff := 0, texelCount/16+1
+	for pidx := range limit {
+		m := &pt.masks[pidx]
+		diff := 0
+		for i := range split {
+			diff += bits.OnesCount64(split[i] ^ m[i])
+		}
+		diff = min(diff, texelCount-diff)
+		if diff < bestDiff {
+			best, bestDiff = pidx, diff
+		}
+	}
+	return best, bestDiff <= texelCount/16
+}
+
+// packPartitionSeed packs a partitioning into an encoderTuning.partitionSeeds entry.
+func packPartitionSeed(partitionCount, partitionIndex int) uint16 {
+	return uint16(partitionCount<<partitionIndexBits | partitionIndex)
+}
+
+// blockPartitionSeed returns the packed partitioning of an encoded block, or 0 if the block has a
+// single partition or is not a valid partitioned block.
+func blockPartitionSeed(block []byte, ctx *decodeContext) uint16 {
+	scb := physicalToSymbolicWithCtx(block, ctx)
+	if scb.blockType != symBlockNonConst || scb.partitionCount < 2 {
+		return 0
+	}
+	return packPartitionSeed(int(scb.partitionCount), int(scb.partitionIndex)&((1<<partitionIndexBits)-1))
+}
+
+// partitionCandidatesWithSeeds selects up to want partition candidates into dst: the partition
+// indices of the seeds with partitionCount partitions, followed by the best-scoring indices of
+// selectBestPartitionIndices. It returns the number of candidates.
+func partitionCandidatesWithSeeds(dst []int, want int, seeds [2]uint16, texels []byte, pt *partitionTable, partitionCount int, searchLimit int, includeAlpha bool, channelWeight [4]float32) int {
+	n := 0
+	for _, s := range seeds {
+		if int(s>>partitionIndexBits) != partitionCount || n >= want {
+			continue
+		}
+		idx := int(s) & ((1 << partitionIndexBits) - 1)
+		if n == 1 && dst[0] == idx {
+			continue
+		}
+		dst[n] = idx
+		n++
+	}
+	if n == 0 {
+		return selectBestPartitionIndices(dst[:want], texels, pt, partitionCount, searchLimit, includeAlpha, channelWeight)
+	}
+
+	var scoredArr [128]int
+	scored := scoredArr[:want-n]
+	if len(scored) == 0 {
+		return n
+	}
+	count := selectBestPartitionIndices(scored, texels, pt, partitionCount, searchLimit, includeAlpha, channelWeight)
+	seedCount := n
+	for _, idx := range scored[:count] {
+		if slices.Contains(dst[:seedCount], idx) {
+			continue
+		}
+		dst[n] = idx
+		n++
+	}
+	return n
 }
@@ -0,0 +1,127 @@
+package astc
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestPartitionCandidatesWithSeeds(t *testing.T) {
+	texels := make([]byte, 36*4)
+	for i := 0; i < 36; i++ {
+		v := uint8(0)
+		if i%6 >= 3 {
+			v = 255
+		}
+		copy(texels[i*4:], []byte{v, v, v, 255})
+	}
+	pt := getPartitionTable(6, 6, 1, 2)
+	unitWeights := [4]float32{1, 1, 1, 1}
+
+	var unseeded [4]int
+	n := selectBestPartitionIndices(unseeded[:], texels, pt, 2, 64, false, unitWeights)
+	var got [4]int
+	if m := partitionCandidatesWithSeeds(got[:], 4, [2]uint16{}, texels, pt, 2, 64, false, unitWeights); m != n || got != unseeded {
+		t.Fatalf("no seeds: got %v (%d), want %v (%d)", got[:m], m, unseeded[:n], n)
+	}
+
+	// Seeds come first; a duplicate seed and seeds for other partition counts are dropped, and
+	// scored indices equal to a seed are not repeated.
+	seed := packPartitionSeed(2, unseeded[1])
+	m := partitionCandidatesWithSeeds(got[:], 4, [2]uint16{seed, seed}, texels, pt, 2, 64, false, unitWeights)
+	if m < 3 || got[0] != unseeded[1] || slices.Index(got[1:m], unseeded[1]) >= 0 {
+		t.Fatalf("duplicate seeds: got %v", got[:m])
+	}
+	var best3 [3]int
+	selectBestPartitionIndices(best3[:], texels, pt, 2, 64, false, unitWeights)
+	m = partitionCandidatesWithSeeds(got[:], 4, [2]uint16{packPartitionSeed(3, 7), packPartitionSeed(2, 900)}, texels, pt, 2, 64, false, unitWeights)
+	if m != 4 || got[0] != 900 || !slices.Equal(got[1:m], best3[:]) {
+		t.Fatalf("mixed seeds: got %v", got[:m])
+	}
+}
+
+func TestSelectBestPartitionIndices_ChannelWeights(t *testing.T) {
+	// Red splits the block into left and right halves, green (with a larger step) into top and
+	// bottom halves.
+	texels := make([]byte, 36*4)
+	texels16 := make([][4]uint16, 36)
+	for i := range 36 {
+		var r, g uint8
+		if i%6 >= 3 {
+			r = 100
+		}
+		if i/6 >= 3 {
+			g = 200
+		}
+		copy(texels[i*4:], []byte{r, g, 0, 255})
+		texels16[i] = [4]uint16{uint16(r) << 8, uint16(g) << 8, 0, 0xffff}
+	}
+	pt := getPartitionTable(6, 6, 1, 2)
+	channelSSE := func(pidx, c int) float64 {
+		var sum, sq, n [2]float64
+		for i, p := range pt.partitionsForIndex(pidx)[:36] {
+			v := float64(texels[i*4+c])
+			sum[p] += v
+			sq[p] += v * v
+			n[p]++
+		}
+		return sq[0] - sum[0]*sum[0]/n[0] + sq[1] - sum[1]*sum[1]/n[1]
+	}
+
+	for _, tc := range []struct {
+		name    string
+		weights [4]float32
+		channel int
+	}{
+		{"unit", [4]float32{1, 1, 1, 1}, 1},
+		{"uniform", [4]float32{3, 3, 3, 3}, 1},
+		{"red", [4]float32{1, 0.01, 1, 1}, 0},
+	} {
+		var best, best16 [1]int
+		if n := selectBestPartitionIndices(best[:], texels, pt, 2, 1024, false, tc.weights); n != 1 {
+			t.Fatalf("%s: got %d candidates", tc.name, n)
+		}
+		if n := selectBestPartitionIndicesU16(best16[:], texels16, pt, 2, 1024, false, tc.weights); n != 1 || best16 != best {
+			t.Fatalf("%s: U16 picked %v, U8 picked %v", tc.name, best16, best)
+		}
+		// The winner separates the channel that dominates the weighted error.
+		if sse := channelSSE(best[0], tc.channel); sse != 0 {
+			t.Fatalf("%s: partition %d leaves channel %d SSE %v", tc.name, best[0], tc.channel, sse)
+		}
+	}
+}
+
+func TestLumaSplitPartition(t *testing.T) {
+	pt := getPartitionTable(6, 6, 1, 2)
+	const want = 37
+	assign := pt.partitionsForIndex(want)
+	luma := make([]int, 36)
+	for i, p := range assign {
+		luma[i] = 100 + 300*int(p)
+	}
+	sameMask := func(a, b int) bool { return pt.masks[a] == pt.masks[b] }
+
+	if got, ok := lumaSplitPartition(pt, luma, 64); !ok || !sameMask(got, want) {
+		t.Fatalf("exact split: got %d (%v), want %d", got, ok, want)
+	}
+
+	// Swapped partitions and two stray texels still match; the index limit is respected.
+	for i := range luma {
+		luma[i] = 500 - luma[i]
+	}
+	luma[0] += 150
+	luma[35] -= 150
+	if got, ok := lumaSplitPartition(pt, luma, 64); !ok || !sameMask(got, want) {
+		t.Fatalf("inverted split: got %d (%v), want %d", got, ok, want)
+	}
+	if got, ok := lumaSplitPartition(pt, luma, want); ok && sameMask(got, want) {
+		t.Fatalf("index limit %d: got %d", want, got)
+	}
+
+	// Flat blocks are not split.
+	for i := range luma {
+		luma[i] = 300 + i%2*10
+	}
+	if got, ok := lumaSplitPartition(pt, luma, 64); ok {
+		t.Fatalf("flat block: got %d", got)
+	}
+}
@@ -5,9 +5,9 @@ import "sort"
 // selectBestPartitionIndicesU16 picks a small set of promising partition seeds to try for 16-bit
 // per-channel texel data.
 //
-// The semantics match selectBestPartitionIndices(), but operate on code values in the 0..65535
-// range (e.g. UNORM16 or LNS codes).
-func selectBestPartitionIndicesU16(dst []int, texels [][4]uint16, pt *partitionTable, partitionCount int, searchLimit int, includeAlpha bool) int {
+// The semantics match selectBestPartitionIndices(), including the channel weighting, but operate
+// on code values in the 0..65535 range (e.g. UNORM16 or LNS codes).
+func selectBestPartitionIndicesU16(dst []int, texels [][4]uint16, pt *partitionTable, partitionCount int, searchLimit int, includeAlpha bool, channelWeight [4]float32) int {
 	if pt == nil || len(dst) == 0 || searchLimit <= 0 || partitionCount < 2 || partitionCount > 4 {
 		return 0
 	}
@@ -20,15 +20,16 @@ func selectBestPartitionIndicesU16(dst []int, texels [][4]uint16, pt *partitionT
 	if limit > (1 << partitionIndexBits) {
 		limit = 1 << partitionIndexBits
 	}
+	wR, wG, wB, wA := partitionScoreWeights(channelWeight, includeAlpha)
 
-	var scoresArr [128]uint64
+	var scoresArr [128]float64
 	scores := scoresArr[:len(dst)]
 
 	bestCount := 0
 	for pidx := 0; pidx < limit; pidx++ {
 		assign := pt.partitionsForIndex(pidx)
 
-		var score uint64
+		var score float64
 		switch partitionCount {
 		case 2:
 			var count0, count1 uint32
@@ -74,25 +75,25 @@ func selectBestPartitionIndicesU16(dst []int, texels [][4]uint16, pt *partitionT
 			n1 := uint64(count1)
 
 			s := sum0r
-			score += sq0r - (s*s)/n0
+			score += wR * float64(sq0r-(s*s)/n0)
 			s = sum0g
-			score += sq0g - (s*s)/n0
+			score += wG * float64(sq0g-(s*s)/n0)
 			s = sum0b
-			score += sq0b - (s*s)/n0
+			score += wB * float64(sq0b-(s*s)/n0)
 			if includeAlpha {
 				s = sum0a
-				score += sq0a - (s*s)/n0
+				score += wA * float64(sq0a-(s*s)/n0)
 			}
 
 			s = sum1r
-			score += sq1r - (s*s)/n1
+			score += wR * float64(sq1r-(s*s)/n1)
 			s = sum1g
-			score += sq1g - (s*s)/n1
+			score += wG * float64(sq1g-(s*s)/n1)
 			s = sum1b
-			score += sq1b - (s*s)/n1
+			score += wB * float64(sq1b-(s*s)/n1)
 			if includeAlpha {
 				s = sum1a
-				score += sq1a - (s*s)/n1
+				score += wA * float64(sq1a-(s*s)/n1)
 			}
 		case 3:
 			var count0, count1, count2 uint32
@@ -152,36 +153,36 @@ func selectBestPartitionIndicesU16(dst []int, texels [][4]uint16, pt *partitionT
 			n2 := uint64(count2)
 
 			s := sum0r
-			score += sq0r - (s*s)/n0
+			score += wR * float64(sq0r-(s*s)/n0)
 			s = sum0g
-			score += sq0g - (s*s)/n0
+			score += wG * float64(sq0g-(s*s)/n0)
 			s = sum0b
-			score += sq0b - (s*s)/n0
+			score += wB * float64(sq0b-(s*s)/n0)
 			if includeAlpha {
 				s = sum0a
-				score += sq0a - (s*s)/n0
+				score += wA * float64(sq0a-(s*s)/n0)
 			}
 
 			s = sum1r
-			score += sq1r - (s*s)/n1
+			score += wR * float64(sq1r-(s*s)/n1)
 			s = sum1g
-			score += sq1g - (s*s)/n1
+			score += wG * float64(sq1g-(s*s)/n1)
 			s = sum1b
-			score += sq1b - (s*s)/n1
+			score += wB * float64(sq1b-(s*s)/n1)
 			if includeAlpha {
 				s = sum1a
-				score += sq1a - (s*s)/n1
+				score += wA * float64(sq1a-(s*s)/n1)
 			}
 
 			s = sum2r
-			score += sq2r - (s*s)/n2
+			score += wR * float64(sq2r-(s*s)/n2)
 			s = sum2g
-			score += sq2g - (s*s)/n2
+			score += wG * float64(sq2g-(s*s)/n2)
 			s = sum2b
-			score += sq2b - (s*s)/n2
+			score += wB * float64(sq2b-(s*s)/n2)
 			if includeAlpha {
 				s = sum2a
-				score += sq2a - (s*s)/n2
+				score += wA * float64(sq2a-(s*s)/n2)
 			}
 		case 4:
 			var count0, count1, count2, count3 uint32
@@ -254,47 +255,47 @@ func selectBestPartitionIndicesU16(dst []int, texels [][4]uint16, pt *partitionT
 			n3 := uint64(count3)
 
 			s := sum0r
-			score += sq0r - (s*s)/n0
+			score += wR * float64(sq0r-(s*s)/n0)
 			s = sum0g
-			score += sq0g - (s*s)/n0
+			score += wG * float64(sq0g-(s*s)/n0)
 			s = sum0b
-			score += sq0b - (s*s)/n0
+			score += wB * float64(sq0b-(s*s)/n0)
 			if includeAlpha {
 				s = sum0a
-				score += sq0a - (s*s)/n0
+				score += wA * float64(sq0a-(s*s)/n0)
 			}
 
 			s = sum1r
-			score += sq1r - (s*s)/n1
+			score += wR * float64(sq1r-(s*s)/n1)
 			s = sum1g
-			score += sq1g - (s*s)/n1
+			score += wG * float64(sq1g-(s*s)/n1)
 			s = sum1b
-			score += sq1b - (s*s)/n1
+			score += wB * float64(sq1b-(s*s)/n1)
 			if includeAlpha {
 				s = sum1a
-				score += sq1a - (s*s)/n1
+				score += wA * float64(sq1a-(s*s)/n1)
 			}
 
 			s = sum2r
-			score += sq2r - (s*s)/n2
+			score += wR * float64(sq2r-(s*s)/n2)
 			s = sum2g
-			score += sq2g - (s*s)/n2
+			score += wG * float64(sq2g-(s*s)/n2)
 			s = sum2b
-			score += sq2b - (s*s)/n2
+			score += wB * float64(sq2b-(s*s)/n2)
 			if includeAlpha {
 				s = sum2a
-				score += sq2a - (s*s)/n2
+				score += wA * float64(sq2a-(s*s)/n2)
 			}
 
 			s = sum3r
-			score += sq3r - (s*s)/n3
+			score += wR * float64(sq3r-(s*s)/n3)
 			s = sum3g
-			score += sq3g - (s*s)/n3
+			score += wG * float64(sq3g-(s*s)/n3)
 			s = sum3b
-			score += sq3b - (s*s)/n3
+			score += wB * float64(sq3b-(s*s)/n3)
 			if includeAlpha {
 				s = sum3a
-				score += sq3a - (s*s)/n3
+				score += wA * float64(sq3a-(s*s)/n3)
 			}
 		}
 
@@ -0,0 +1,69 @@
+package astc
+
+import (
+	"errors"
+	"image"
+	"image/color"
+)
+
+// PartitionPalette holds the colors RenderPartitionPattern draws partitions 0 to 3 with (a
+// color-blind safe set), followed by the transparent color of the gaps between 3D slices.
+var PartitionPalette = color.Palette{
+	color.RGBA{0xE6, 0x9F, 0x00, 0xFF},
+	color.RGBA{0x56, 0xB4, 0xE9, 0xFF},
+	color.RGBA{0x00, 0x9E, 0x73, 0xFF},
+	color.RGBA{0xF0, 0xE4, 0x42, 0xFF},
+	color.RGBA{},
+}
+
+// PartitionPattern returns the partition of each texel of a blockX x blockY x blockZ block under
+// the canonical ASTC partition pattern with the given partition count (1-4) and 10-bit partition
+// index, in x, then y, then z order. Partition counts above 1 hash the index into a pattern as the
+// ASTC specification defines, so some indices leave partitions empty; 1 always yields zeros.
+func PartitionPattern(blockX, blockY, blockZ, partitionCount, index int) ([]uint8, error) {
+	if err := validateBlockSize(blockX, blockY, blockZ); err != nil {
+		return nil, err
+	}
+	if partitionCount < 1 || partitionCount > blockMaxPartitions {
+		return nil, errors.New("astc: partition count must be 1-4")
+	}
+	if index < 0 || index >= 1<<partitionIndexBits {
+		return nil, errors.New("astc: partition index must be 0-1023")
+	}
+	out := make([]uint8, blockX*blockY*blockZ)
+	copy(out, getPartitionTable(blockX, blockY, blockZ, partitionCount).partitionsForIndex(index))
+	return out, nil
+}
+
+// RenderPartitionPattern draws the pattern PartitionPattern returns, each texel as a scale x scale
+// square colored from PartitionPalette, for documentation and tools. The slices of a 3D block are
+// drawn left to right, separated by transparent gaps one texel wide.
+func RenderPartitionPattern(blockX, blockY, blockZ, partitionCount, index, scale int) (*image.Paletted, error) {
+	if scale < 1 {
+		return nil, errors.New("astc: render scale must be positive")
+	}
+	pattern, err := PartitionPattern(blockX, blockY, blockZ, partitionCount, index)
+	if err != nil {
+		return nil, err
+	}
+	gap := len(PartitionPalette) - 1
+	img := image.NewPaletted(image.Rect(0, 0, (blockZ*(blockX+1)-1)*scale, blockY*scale), PartitionPalette)
+	for i := range img.Pix {
+		img.Pix[i] = uint8(gap)
+	}
+	for z := range blockZ {
+		for y := range blockY {
+			for x := range blockX {
+				p := pattern[(z*blockY+y)*blockX+x]
+				x0, y0 := (z*(blockX+1)+x)*scale, y*scale
+				for py := y0; py < y0+scale; py++ {
+					row := img.Pix[py*img.Stride+x0 : py*img.Stride+x0+scale]
+					for i := range row {
+						row[i] = p
+					}
+				}
+			}
+		}
+	}
+	return img, nil
+}
@@ -0,0 +1,62 @@
+package astc_test
+
+import (
+	"testing"
+
+	"github.com/arm-software/astc-encoder/astc"
+)
+
+func TestRenderPartitionPattern(t *testing.T) {
+	const scale = 3
+	for _, tc := range []struct{ x, y, z, count int }{
+		{4, 4, 1, 1}, {6, 6, 1, 2}, {12, 10, 1, 3}, {8, 8, 1, 4}, {4, 4, 4, 3},
+	} {
+		seen := make([]bool, tc.count)
+		for _, index := range []int{0, 17, 1023} {
+			pattern, err := astc.PartitionPattern(tc.x, tc.y, tc.z, tc.count, index)
+			if err != nil {
+				t.Fatal(err)
+			}
+			img, err := astc.RenderPartitionPattern(tc.x, tc.y, tc.z, tc.count, index, scale)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if w, h := img.Bounds().Dx(), img.Bounds().Dy(); w != (tc.z*(tc.x+1)-1)*scale || h != tc.y*scale {
+				t.Fatalf("%v index %d: image %dx%d", tc, index, w, h)
+			}
+			for z := range tc.z {
+				for y := range tc.y {
+					for x := range tc.x {
+						p := pattern[(z*tc.y+y)*tc.x+x]
+						if int(p) >= tc.count {
+							t.Fatalf("%v index %d: partition %d", tc, index, p)
+						}
+						seen[p] = true
+						px, py := (z*(tc.x+1)+x)*scale+scale-1, y*scale+scale-1
+						if got := img.ColorIndexAt(px, py); got != p {
+							t.Fatalf("%v index %d: pixel (%d,%d) = %d, want %d", tc, index, px, py, got, p)
+						}
+					}
+				}
+				if z > 0 {
+					if got := img.ColorIndexAt((z*(tc.x+1)-1)*scale, 0); int(got) != len(astc.PartitionPalette)-1 {
+						t.Fatalf("%v: slice gap drawn with color %d", tc, got)
+					}
+				}
+			}
+		}
+		for p, ok := range seen {
+			if !ok {
+				t.Fatalf("%v: partition %d never used", tc, p)
+			}
+		}
+	}
+
+	for _, tc := range []struct{ x, y, z, count, index, scale int }{
+		{7, 7, 1, 2, 0, 1}, {4, 4, 1, 0, 0, 1}, {4, 4, 1, 5, 0, 1}, {4, 4, 1, 2, 1024, 1}, {4, 4, 1, 2, 0, 0},
+	} {
+		if _, err := astc.RenderPartitionPattern(tc.x, tc.y, tc.z, tc.count, tc.index, tc.scale); err == nil {
+			t.Fatalf("%v: expected error", tc)
+		}
+	}
+}
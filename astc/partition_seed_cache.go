@@ -0,0 +1,233 @@
+package astc
+
+import "math"
+
+// Values of partitionSeed.state.
+const (
+	partitionSeedUnset uint8 = iota
+	partitionSeedReady
+	// The partitioning leaves a partition empty, or its trimmed seed equals the untrimmed one.
+	partitionSeedSkip
+)
+
+// partitionSeed is the block-mode independent part of evaluating one partitioning: the texels
+// whose colors seed each partition's endpoints, and which partitions are constant.
+type partitionSeed struct {
+	state          uint8
+	anyConstPart   bool
+	minIdx, maxIdx [blockMaxPartitions]uint8
+	constPart      [blockMaxPartitions]bool
+}
+
+// partitionSeedCacheInline is the size of the scratch array the encoders back a
+// partitionSeedCache with, enough for the candidate lists of every preset without allocating.
+const partitionSeedCacheInline = 64
+
+// partitionSeedCache holds the partitionSeed of every (partition count, candidate slot) the mode
+// loop of a block visits. The slot scans are computed on first use and shared by every block mode,
+// rather than repeated per mode.
+type partitionSeedCache struct {
+	base    [blockMaxPartitions + 1]int
+	entries []partitionSeed
+}
+
+// makePartitionSeedCache returns a cache for slots[pc] candidate slots of each partition count pc,
+// backed by buf if it is large enough.
+func makePartitionSeedCache(buf []partitionSeed, slots *[blockMaxPartitions + 1]int) partitionSeedCache {
+	var c partitionSeedCache
+	n := 0
+	for pc, s := range slots {
+		c.base[pc] = n
+		n += s
+	}
+	if n <= len(buf) {
+		c.entries = buf[:n]
+	} else {
+		c.entries = make([]partitionSeed, n)
+	}
+	return c
+}
+
+func (c *partitionSeedCache) get(partitionCount, slot int) *partitionSeed {
+	return &c.entries[c.base[partitionCount]+slot]
+}
+
+// computeRGBA8 fills s for the partitioning assign (nil for one partition) of an RGBA8 block. The
+// seeds are the lowest and highest luma texels of each partition (ties broken on alpha), or for
+// seed != 0 their trim percentiles, or for normal maps the extremes of the principal axis of
+// (R, A).
+func (s *partitionSeed) computeRGBA8(texels []byte, texelLuma, texelAlpha []int, assign []uint8, partitionCount, seed int, normalMap, constantPartitions bool, trim float32) {
+	var count [4]uint16
+	var minL, maxL, minA, maxA [4]int
+	var minIdx, maxIdx [4]int
+	var sumX, sumY, sumXX, sumYY, sumXY [4]float64
+	for p := 0; p < partitionCount; p++ {
+		minL[p] = math.MaxInt
+		maxL[p] = math.MinInt
+		minA[p] = math.MaxInt
+		maxA[p] = math.MinInt
+	}
+
+	for t := range texelLuma {
+		part := 0
+		if assign != nil {
+			part = int(assign[t])
+		}
+		count[part]++
+
+		l := texelLuma[t]
+		ai := texelAlpha[t]
+		if normalMap {
+			off := t * 4
+			x := float64(texels[off+0])
+			y := float64(texels[off+3])
+			sumX[part] += x
+			sumY[part] += y
+			sumXX[part] += x * x
+			sumYY[part] += y * y
+			sumXY[part] += x * y
+		}
+		if l < minL[part] || (l == minL[part] && ai < minA[part]) {
+			minL[part] = l
+			minA[part] = ai
+			minIdx[part] = t
+		}
+		if l > maxL[part] || (l == maxL[part] && ai > maxA[part]) {
+			maxL[part] = l
+			maxA[part] = ai
+			maxIdx[part] = t
+		}
+	}
+
+	s.state = partitionSeedSkip
+	if partitionCount != 1 {
+		for p := 0; p < partitionCount; p++ {
+			if count[p] == 0 {
+				return
+			}
+		}
+	}
+
+	if constantPartitions && partitionCount != 1 {
+		s.anyConstPart = findConstantPartitions(texels, assign, partitionCount, &s.constPart)
+	}
+
+	if seed != 0 && !trimEndpointSeeds(texelLuma, texelAlpha, assign, partitionCount, &count, trim, &minIdx, &maxIdx) {
+		return
+	}
+
+	if normalMap {
+		// Use a simple 2D PCA on (R, A) to pick endpoints for the L+A line. This better matches
+		// reference behavior for ASTCENC_FLG_MAP_NORMAL than luma-only endpoint selection.
+		var meanX [4]float64
+		var meanY [4]float64
+		var dirX [4]float64
+		var dirY [4]float64
+
+		for p := 0; p < partitionCount; p++ {
+			n := float64(count[p])
+			if n <= 0 {
+				continue
+			}
+
+			mx := sumX[p] / n
+			my := sumY[p] / n
+			meanX[p] = mx
+			meanY[p] = my
+
+			// Covariance matrix of centered data.
+			cov00 := sumXX[p]/n - mx*mx
+			cov11 := sumYY[p]/n - my*my
+			cov01 := sumXY[p]/n - mx*my
+
+			// Principal axis direction for 2x2 covariance.
+			dx, dy := 1.0, 0.0
+			if cov01 != 0 || cov00 != cov11 {
+				theta := 0.5 * math.Atan2(2*cov01, cov00-cov11)
+				dx = math.Cos(theta)
+				dy = math.Sin(theta)
+			} else if cov11 > cov00 {
+				dx, dy = 0.0, 1.0
+			}
+			dirX[p] = dx
+			dirY[p] = dy
+		}
+
+		minProj := [4]float64{math.Inf(1), math.Inf(1), math.Inf(1), math.Inf(1)}
+		maxProj := [4]float64{math.Inf(-1), math.Inf(-1), math.Inf(-1), math.Inf(-1)}
+		for t := range texelLuma {
+			part := 0
+			if assign != nil {
+				part = int(assign[t])
+			}
+			off := t * 4
+			x := float64(texels[off+0]) - meanX[part]
+			y := float64(texels[off+3]) - meanY[part]
+			proj := x*dirX[part] + y*dirY[part]
+			if proj < minProj[part] {
+				minProj[part] = proj
+				minIdx[part] = t
+			}
+			if proj > maxProj[part] {
+				maxProj[part] = proj
+				maxIdx[part] = t
+			}
+		}
+	}
+
+	for p := 0; p < partitionCount; p++ {
+		s.minIdx[p] = uint8(minIdx[p])
+		s.maxIdx[p] = uint8(maxIdx[p])
+	}
+	s.state = partitionSeedReady
+}
+
+// computeF32 fills s for the partitioning assign (nil for one partition) of a float block from
+// the per-texel luma and alpha: the seeds are the lowest and highest luma texels of each partition,
+// ties broken on alpha.
+func (s *partitionSeed) computeF32(texelLuma, texelAlpha []float32, assign []uint8, partitionCount int) {
+	var count [blockMaxPartitions]uint16
+	var minL, maxL, minA, maxA [blockMaxPartitions]float32
+	var minIdx, maxIdx [blockMaxPartitions]int
+	for p := 0; p < partitionCount; p++ {
+		minL[p] = float32(math.Inf(1))
+		maxL[p] = float32(math.Inf(-1))
+		minA[p] = float32(math.Inf(1))
+		maxA[p] = float32(math.Inf(-1))
+	}
+
+	for t := range texelLuma {
+		part := 0
+		if assign != nil {
+			part = int(assign[t])
+		}
+		count[part]++
+
+		l := texelLuma[t]
+		a := texelAlpha[t]
+		if l < minL[part] || (l == minL[part] && a < minA[part]) {
+			minL[part] = l
+			minA[part] = a
+			minIdx[part] = t
+		}
+		if l > maxL[part] || (l == maxL[part] && a > maxA[part]) {
+			maxL[part] = l
+			maxA[part] = a
+			maxIdx[part] = t
+		}
+	}
+
+	s.state = partitionSeedSkip
+	if partitionCount != 1 {
+		for p := 0; p < partitionCount; p++ {
+			if count[p] == 0 {
+				return
+			}
+		}
+	}
+	for p := 0; p < partitionCount; p++ {
+		s.minIdx[p] = uint8(minIdx[p])
+		s.maxIdx[p] = uint8(maxIdx[p])
+	}
+	s.state = partitionSeedReady
+}
@@ -13,8 +13,14 @@ type partitionTable struct {
 	texelCount int
 	// data is indexed as [partitionIndex][texelIndex] where partitionIndex is 0..1023.
 	data []uint8
+	// masks holds, for 2-partition tables only, a bitmask per partition index of the texels in
+	// partition 1.
+	masks []texelMask
 }
 
+// texelMask is a bit set over the texels of a block.
+type texelMask [(blockMaxTexels + 63) / 64]uint64
+
 var partitionTables struct {
 	mu sync.RWMutex
 	m  map[partitionTableKey]*partitionTable
@@ -66,11 +72,27 @@ func getPartitionTable(blockX, blockY, blockZ, partitionCount int) *partitionTab
 		}
 	}
 
-	t := &partitionTable{texelCount: texelCount, data: data}
+	t := newPartitionTable(texelCount, partitionCount, data)
 	partitionTables.m[key] = t
 	return t
 }
 
+// newPartitionTable makes the partition table of partitionCount partitions from its data, adding
+// the derived lookups. Tables built here and tables loaded by LoadAccelerationCache both go
+// through it, so the encoder sees the same table either way.
+func newPartitionTable(texelCount, partitionCount int, data []uint8) *partitionTable {
+	t := &partitionTable{texelCount: texelCount, data: data}
+	if partitionCount == 2 {
+		t.masks = make([]texelMask, 1<<partitionIndexBits)
+		for i, p := range data {
+			if p != 0 {
+				t.masks[i/texelCount][i%texelCount/64] |= 1 << (i % texelCount % 64)
+			}
+		}
+	}
+	return t
+}
+
 func (t *partitionTable) partitionsForIndex(partitionIndex int) []uint8 {
 	if t == nil {
 		return nil
@@ -0,0 +1,49 @@
+package astc
+
+import "fmt"
+
+// BlockPatch replaces one block of an encoded image.
+type BlockPatch struct {
+	// X, Y and Z are the block coordinates (in blocks, not texels).
+	X, Y, Z int
+	// Block is the replacement payload.
+	Block [BlockBytes]byte
+}
+
+// PatchBlocks overwrites blocks of the .astc file in data in place, for targeted fixes from
+// external tools (a re-encoded hot spot, a hand-tuned edge block) without re-encoding the image.
+//
+// Every patch is checked before any block is written: its coordinates must lie inside the block
+// grid, and its payload must be a legal block for the file's footprint (a block every decoder
+// would turn into the error color is rejected). The header does not record whether the file is
+// HDR, so blocks with HDR endpoint modes and void-extent blocks with FP16 colors are accepted
+// even though an LDR decoder turns them into the error color; callers patching LDR files must
+// check for those themselves. On error data is left unchanged. Later patches of the same block
+// win.
+func PatchBlocks(data []byte, patches []BlockPatch) error {
+	h, blocks, err := ParseFile(data)
+	if err != nil {
+		return err
+	}
+	blocksX, blocksY, blocksZ, _, err := h.BlockCount()
+	if err != nil {
+		return err
+	}
+
+	ctx := getDecodeContext(int(h.BlockX), int(h.BlockY), int(h.BlockZ))
+	for i := range patches {
+		p := &patches[i]
+		if p.X < 0 || p.X >= blocksX || p.Y < 0 || p.Y >= blocksY || p.Z < 0 || p.Z >= blocksZ {
+			return fmt.Errorf("astc: patch %d: block (%d,%d,%d) outside %dx%dx%d block grid", i, p.X, p.Y, p.Z, blocksX, blocksY, blocksZ)
+		}
+		if physicalToSymbolicWithCtx(p.Block[:], ctx).blockType == symBlockError {
+			return fmt.Errorf("astc: patch %d: block is not legal for %dx%dx%d footprint", i, h.BlockX, h.BlockY, h.BlockZ)
+		}
+	}
+
+	for _, p := range patches {
+		off := ((p.Z*blocksY+p.Y)*blocksX + p.X) * BlockBytes
+		copy(blocks[off:off+BlockBytes], p.Block[:])
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package astc_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/arm-software/astc-encoder/astc"
+)
+
+func TestPatchBlocks(t *testing.T) {
+	const w, h = 12, 8
+	pix := make([]byte, w*h*4)
+	for i := range pix {
+		pix[i] = uint8(i * 7)
+	}
+	data, err := astc.EncodeRGBA8WithProfileAndQuality(pix, w, h, 4, 4, astc.ProfileLDR, astc.EncodeFast)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	orig := append([]byte(nil), data...)
+
+	red := astc.EncodeConstBlockRGBA8(255, 0, 0, 255)
+	blue := astc.EncodeConstBlockRGBA8(0, 0, 255, 255)
+	if err := astc.PatchBlocks(data, []astc.BlockPatch{{X: 2, Y: 1, Block: red}, {X: 0, Y: 0, Block: red}, {X: 0, Y: 0, Block: blue}}); err != nil {
+		t.Fatalf("PatchBlocks: %v", err)
+	}
+	dec, _, _, err := astc.DecodeRGBA8WithProfile(data, astc.ProfileLDR)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	texel := func(x, y int) []byte { return dec[(y*w+x)*4 : (y*w+x)*4+4] }
+	if got := texel(11, 7); !bytes.Equal(got, []byte{255, 0, 0, 255}) {
+		t.Fatalf("patched block (2,1) texel = %v, want red", got)
+	}
+	if got := texel(1, 2); !bytes.Equal(got, []byte{0, 0, 255, 255}) {
+		t.Fatalf("patched block (0,0) texel = %v, want blue (last patch wins)", got)
+	}
+	// Untouched blocks keep their payload.
+	for _, i := range []int{1, 3, 4} {
+		off := astc.HeaderSize + i*astc.BlockBytes
+		if !bytes.Equal(data[off:off+astc.BlockBytes], orig[off:off+astc.BlockBytes]) {
+			t.Fatalf("block %d changed", i)
+		}
+	}
+}
+
+func TestPatchBlocks_Rejects(t *testing.T) {
+	data, err := astc.EncodeRGBA8WithProfileAndQuality(make([]byte, 8*8*4), 8, 8, 4, 4, astc.ProfileLDR, astc.EncodeFast)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	good := astc.EncodeConstBlockRGBA8(1, 2, 3, 4)
+	var reserved [astc.BlockBytes]byte // block mode 0 is reserved
+
+	// A block encoded for 12x12 uses a weight grid larger than a 4x4 footprint allows.
+	var wide [astc.BlockBytes]byte
+	{
+		big := make([]byte, 12*12*4)
+		for i := range big {
+			big[i] = uint8(i * 13)
+		}
+		enc, err := astc.EncodeRGBA8WithProfileAndQuality(big, 12, 12, 12, 12, astc.ProfileLDR, astc.EncodeMedium)
+		if err != nil {
+			t.Fatalf("encode 12x12: %v", err)
+		}
+		copy(wide[:], enc[astc.HeaderSize:])
+	}
+
+	cases := []struct {
+		name  string
+		patch astc.BlockPatch
+	}{
+		{"negative", astc.BlockPatch{X: -1, Block: good}},
+		{"x out of range", astc.BlockPatch{X: 2, Block: good}},
+		{"z out of range", astc.BlockPatch{Z: 1, Block: good}},
+		{"reserved mode", astc.BlockPatch{Block: reserved}},
+		{"wrong footprint", astc.BlockPatch{Block: wide}},
+	}
+	for _, tc := range cases {
+		before := append([]byte(nil), data...)
+		// The valid first patch must not be applied when a later one fails.
+		if err := astc.PatchBlocks(data, []astc.BlockPatch{{X: 1, Y: 1, Block: good}, tc.patch}); err == nil {
+			t.Fatalf("%s: expected error", tc.name)
+		}
+		if !bytes.Equal(data, before) {
+			t.Fatalf("%s: data modified on error", tc.name)
+		}
+	}
+	if err := astc.PatchBlocks(data[:astc.HeaderSize+astc.BlockBytes], nil); err == nil {
+		t.Fatalf("expected error for truncated file")
+	}
+}
@@ -0,0 +1,93 @@
+package astc
+
+import "errors"
+
+// RowPitch returns the size in bytes of a row of width texels of bytesPerTexel bytes, rounded up
+// to a multiple of alignment, as required for upload buffers (e.g. 256 for Vulkan staging buffers
+// copied to optimal-tiling images on many drivers, or 4 for glPixelStorei(GL_UNPACK_ALIGNMENT)).
+// An alignment of 0 or 1 gives tightly packed rows, as in stb_image surfaces.
+func RowPitch(width, bytesPerTexel, alignment int) int {
+	row := width * bytesPerTexel
+	if alignment <= 1 {
+		return row
+	}
+	return (row + alignment - 1) / alignment * alignment
+}
+
+// pitchedLen returns the number of bytes spanned by a width x height x depth RGBA8 image with the
+// given pitches, which must already be validated.
+func pitchedLen(width, height, depth, rowPitch, slicePitch int) int {
+	return (depth-1)*slicePitch + (height-1)*rowPitch + width*4
+}
+
+// validatePitches checks RGBA8 row and slice pitches for a width x height image.
+func validatePitches(width, height, rowPitch, slicePitch int) error {
+	if rowPitch < width*4 || slicePitch < height*rowPitch {
+		return errors.New("astc: pitch smaller than the image")
+	}
+	return nil
+}
+
+// CopyRGBA8Pitched copies a width x height RGBA8 image between buffers with different row pitches,
+// e.g. from a tightly packed stb-style surface (pitch width*4) into a staging buffer with aligned
+// rows (see RowPitch), or back. Padding bytes at the end of dst rows are left unchanged. dst and
+// src must not overlap.
+func CopyRGBA8Pitched(dst []byte, dstPitch int, src []byte, srcPitch int, width, height int) error {
+	if width <= 0 || height <= 0 {
+		return errors.New("astc: invalid image dimensions")
+	}
+	if dstPitch < width*4 || srcPitch < width*4 {
+		return errors.New("astc: pitch smaller than the image")
+	}
+	if len(src) < pitchedLen(width, height, 1, srcPitch, 0) {
+		return errors.New("astc: input buffer too small")
+	}
+	if len(dst) < pitchedLen(width, height, 1, dstPitch, 0) {
+		return errors.New("astc: output buffer too small")
+	}
+	row := width * 4
+	for y := 0; y < height; y++ {
+		copy(dst[y*dstPitch:y*dstPitch+row], src[y*srcPitch:y*srcPitch+row])
+	}
+	return nil
+}
+
+// DecodeRGBA8VolumeFromParsedWithProfileIntoPitched is DecodeRGBA8VolumeFromParsedWithProfileInto
+// for a destination whose rows start rowPitch bytes apart and slices slicePitch bytes apart, so
+// blocks are decoded straight into an upload buffer with aligned rows. Padding bytes are left
+// unchanged. dst must hold at least (depth-1)*slicePitch + (height-1)*rowPitch + width*4 bytes,
+// and may overlap blocks.
+//
+// Limitations:
+//   - Only LDR profiles (ProfileLDR, ProfileLDRSRGB).
+func DecodeRGBA8VolumeFromParsedWithProfileIntoPitched(profile Profile, h Header, blocks []byte, dst []byte, rowPitch, slicePitch int) error {
+	width := int(h.SizeX)
+	height := int(h.SizeY)
+	depth := int(h.SizeZ)
+	if width <= 0 || height <= 0 || depth <= 0 {
+		return errors.New("astc: invalid image dimensions")
+	}
+	if err := validatePitches(width, height, rowPitch, slicePitch); err != nil {
+		return err
+	}
+	if len(dst) < pitchedLen(width, height, depth, rowPitch, slicePitch) {
+		return errors.New("astc: output buffer too small")
+	}
+	return decodeRGBA8VolumeFromParsedWithContext(profile, nil, h, blocks, dst, rowPitch, slicePitch)
+}
+
+// DecodeRGBA8WithProfileIntoPitched decodes a 2D .astc file into dst with rows rowPitch bytes
+// apart (see RowPitch and DecodeRGBA8VolumeFromParsedWithProfileIntoPitched).
+func DecodeRGBA8WithProfileIntoPitched(astcData []byte, profile Profile, dst []byte, rowPitch int) (width, height int, err error) {
+	h, blocks, err := ParseFile(astcData)
+	if err != nil {
+		return 0, 0, err
+	}
+	if h.SizeZ != 1 {
+		return 0, 0, errors.New("astc: DecodeRGBA8WithProfileIntoPitched only supports 2D images (z==1)")
+	}
+	if err := DecodeRGBA8VolumeFromParsedWithProfileIntoPitched(profile, h, blocks, dst, rowPitch, int(h.SizeY)*rowPitch); err != nil {
+		return 0, 0, err
+	}
+	return int(h.SizeX), int(h.SizeY), nil
+}
@@ -0,0 +1,124 @@
+package astc_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/arm-software/astc-encoder/astc"
+)
+
+func TestRowPitch(t *testing.T) {
+	for _, tc := range []struct{ width, bpp, align, want int }{
+		{13, 4, 0, 52},
+		{13, 4, 1, 52},
+		{13, 4, 4, 52},
+		{13, 4, 8, 56},
+		{13, 4, 256, 256},
+		{65, 4, 256, 512},
+		{64, 4, 256, 256},
+	} {
+		if got := astc.RowPitch(tc.width, tc.bpp, tc.align); got != tc.want {
+			t.Fatalf("RowPitch(%d, %d, %d) = %d, want %d", tc.width, tc.bpp, tc.align, got, tc.want)
+		}
+	}
+}
+
+func TestDecodeRGBA8Pitched(t *testing.T) {
+	const w, h, d = 13, 11, 3
+	pix := make([]byte, w*h*d*4)
+	for i := range pix {
+		pix[i] = uint8(i*7 + i/5)
+	}
+	data, err := astc.EncodeRGBA8VolumeWithProfileAndQuality(pix, w, h, d, 4, 4, 3, astc.ProfileLDR, astc.EncodeFast)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	want, _, _, _, err := astc.DecodeRGBA8VolumeWithProfile(data, astc.ProfileLDR)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	hdr, blocks, err := astc.ParseFile(data)
+	if err != nil {
+		t.Fatalf("ParseFile: %v", err)
+	}
+
+	rowPitch := astc.RowPitch(w, 4, 256)
+	slicePitch := rowPitch*h + 512
+	dst := bytes.Repeat([]byte{0xEE}, (d-1)*slicePitch+(h-1)*rowPitch+w*4)
+	if err := astc.DecodeRGBA8VolumeFromParsedWithProfileIntoPitched(astc.ProfileLDR, hdr, blocks, dst, rowPitch, slicePitch); err != nil {
+		t.Fatalf("DecodeRGBA8VolumeFromParsedWithProfileIntoPitched: %v", err)
+	}
+	for z := 0; z < d; z++ {
+		for y := 0; y < h; y++ {
+			off := z*slicePitch + y*rowPitch
+			if !bytes.Equal(dst[off:off+w*4], want[(z*h+y)*w*4:][:w*4]) {
+				t.Fatalf("row %d of slice %d differs", y, z)
+			}
+			if end := min(off+rowPitch, len(dst)); bytes.Count(dst[off+w*4:end], []byte{0xEE}) != end-off-w*4 {
+				t.Fatalf("padding after row %d of slice %d was modified", y, z)
+			}
+		}
+	}
+
+	if err := astc.DecodeRGBA8VolumeFromParsedWithProfileIntoPitched(astc.ProfileLDR, hdr, blocks, dst[:len(dst)-1], rowPitch, slicePitch); err == nil {
+		t.Fatalf("expected error for short dst")
+	}
+	if err := astc.DecodeRGBA8VolumeFromParsedWithProfileIntoPitched(astc.ProfileLDR, hdr, blocks, dst, w*4-1, slicePitch); err == nil {
+		t.Fatalf("expected error for short row pitch")
+	}
+
+	// 2D convenience form.
+	data2D, err := astc.EncodeRGBA8WithProfileAndQuality(pix[:w*h*4], w, h, 6, 6, astc.ProfileLDR, astc.EncodeFast)
+	if err != nil {
+		t.Fatalf("encode 2D: %v", err)
+	}
+	want2D, _, _, err := astc.DecodeRGBA8WithProfile(data2D, astc.ProfileLDR)
+	if err != nil {
+		t.Fatalf("decode 2D: %v", err)
+	}
+	dst2D := make([]byte, (h-1)*rowPitch+w*4)
+	gw, gh, err := astc.DecodeRGBA8WithProfileIntoPitched(data2D, astc.ProfileLDR, dst2D, rowPitch)
+	if err != nil || gw != w || gh != h {
+		t.Fatalf("DecodeRGBA8WithProfileIntoPitched: %dx%d, %v", gw, gh, err)
+	}
+	tight := make([]byte, w*h*4)
+	if err := astc.CopyRGBA8Pitched(tight, w*4, dst2D, rowPitch, w, h); err != nil {
+		t.Fatalf("CopyRGBA8Pitched: %v", err)
+	}
+	if !bytes.Equal(tight, want2D) {
+		t.Fatalf("pitched 2D decode differs from tight decode")
+	}
+	if _, _, err := astc.DecodeRGBA8WithProfileIntoPitched(data, astc.ProfileLDR, dst, rowPitch); err == nil {
+		t.Fatalf("expected error for a volume")
+	}
+}
+
+func TestCopyRGBA8Pitched(t *testing.T) {
+	const w, h = 5, 3
+	src := make([]byte, w*h*4)
+	for i := range src {
+		src[i] = uint8(i + 1)
+	}
+	pitch := astc.RowPitch(w, 4, 8)
+	staged := make([]byte, pitch*h)
+	if err := astc.CopyRGBA8Pitched(staged, pitch, src, w*4, w, h); err != nil {
+		t.Fatalf("to pitched: %v", err)
+	}
+	if staged[w*4] != 0 || staged[pitch] != src[w*4] {
+		t.Fatalf("unexpected pitched layout: %v", staged[:pitch+1])
+	}
+	back := make([]byte, len(src))
+	if err := astc.CopyRGBA8Pitched(back, w*4, staged, pitch, w, h); err != nil {
+		t.Fatalf("from pitched: %v", err)
+	}
+	if !bytes.Equal(back, src) {
+		t.Fatalf("round trip differs")
+	}
+
+	if err := astc.CopyRGBA8Pitched(staged, w*4-1, src, w*4, w, h); err == nil {
+		t.Fatalf("expected error for short pitch")
+	}
+	if err := astc.CopyRGBA8Pitched(staged[:pitch*(h-1)], pitch, src, w*4, w, h); err == nil {
+		t.Fatalf("expected error for short dst")
+	}
+}
@@ -0,0 +1,76 @@
+package astc
+
+import (
+	"image"
+	"strconv"
+	"strings"
+)
+
+// SuggestProfile picks the encoding profile for img from the color metadata of its source file,
+// to avoid the most common misconfiguration: encoding sRGB color with ProfileLDR (or linear data
+// with ProfileLDRSRGB), which shifts every mid-tone when the texture is sampled.
+//
+// The Go image decoders drop color metadata, so the caller passes what its container parser
+// found, keyed by PNG chunk or OpenEXR attribute name. The first key present decides, in the
+// precedence order of the PNG specification:
+//
+//   - "chromaticities" (OpenEXR): ProfileHDR; EXR pixels are linear floats of unbounded range.
+//   - "cICP" (PNG): "primaries,transfer,matrix,range" code points. Transfer 8 (linear) gives
+//     ProfileLDR, 16 (PQ) and 18 (HLG) ProfileHDR, any other ProfileLDRSRGB.
+//   - "iCCP" (PNG): the embedded ICC profile's name. Names containing "linear" or "ACEScg" give
+//     ProfileLDR, others ProfileLDRSRGB.
+//   - "sRGB" (PNG), with any value: ProfileLDRSRGB.
+//   - "gAMA" (PNG): the file gamma, either as stored ("45455") or as a number ("0.45455"). A
+//     gamma of 1 (within 10%) gives ProfileLDR, any other ProfileLDRSRGB.
+//
+// Without usable metadata, single-channel images (image.Gray, image.Gray16, image.Alpha and
+// image.Alpha16), which usually hold masks, heights or roughness, give ProfileLDR, and color images
+// give ProfileLDRSRGB, since untagged color images are assumed to be sRGB by the PNG specification
+// and by browsers. Linear color data without metadata, such as normal maps, still needs ProfileLDR
+// chosen explicitly.
+func SuggestProfile(img image.Image, metadata map[string]string) Profile {
+	if _, ok := metadata["chromaticities"]; ok {
+		return ProfileHDR
+	}
+	if v, ok := metadata["cICP"]; ok {
+		if fields := strings.Split(v, ","); len(fields) >= 2 {
+			if transfer, err := strconv.Atoi(strings.TrimSpace(fields[1])); err == nil {
+				switch transfer {
+				case 8:
+					return ProfileLDR
+				case 16, 18:
+					return ProfileHDR
+				default:
+					return ProfileLDRSRGB
+				}
+			}
+		}
+	}
+	if v, ok := metadata["iCCP"]; ok {
+		name := strings.ToLower(v)
+		if strings.Contains(name, "linear") || strings.Contains(name, "acescg") {
+			return ProfileLDR
+		}
+		return ProfileLDRSRGB
+	}
+	if _, ok := metadata["sRGB"]; ok {
+		return ProfileLDRSRGB
+	}
+	if v, ok := metadata["gAMA"]; ok {
+		if gamma, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil && gamma > 0 {
+			if gamma > 10 {
+				gamma /= 100000 // stored as gamma * 100000
+			}
+			if gamma >= 0.9 && gamma <= 1.1 {
+				return ProfileLDR
+			}
+			return ProfileLDRSRGB
+		}
+	}
+
+	switch img.(type) {
+	case *image.Gray, *image.Gray16, *image.Alpha, *image.Alpha16:
+		return ProfileLDR
+	}
+	return ProfileLDRSRGB
+}
@@ -0,0 +1,39 @@
+package astc_test
+
+import (
+	"image"
+	"testing"
+
+	"github.com/arm-software/astc-encoder/astc"
+)
+
+func TestSuggestProfile(t *testing.T) {
+	color := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+	gray := image.NewGray(image.Rect(0, 0, 4, 4))
+	for _, c := range []struct {
+		name     string
+		img      image.Image
+		metadata map[string]string
+		want     astc.Profile
+	}{
+		{"untagged color", color, nil, astc.ProfileLDRSRGB},
+		{"untagged gray", gray, nil, astc.ProfileLDR},
+		{"sRGB chunk", gray, map[string]string{"sRGB": "0"}, astc.ProfileLDRSRGB},
+		{"gAMA 1/2.2 stored", color, map[string]string{"gAMA": "45455"}, astc.ProfileLDRSRGB},
+		{"gAMA 1 stored", color, map[string]string{"gAMA": "100000"}, astc.ProfileLDR},
+		{"gAMA 1 number", color, map[string]string{"gAMA": "1.0"}, astc.ProfileLDR},
+		{"gAMA unparsable", gray, map[string]string{"gAMA": "?"}, astc.ProfileLDR},
+		{"iCCP sRGB", color, map[string]string{"iCCP": "sRGB IEC61966-2.1"}, astc.ProfileLDRSRGB},
+		{"iCCP linear", color, map[string]string{"iCCP": "Linear Rec.709"}, astc.ProfileLDR},
+		{"iCCP over gAMA", color, map[string]string{"iCCP": "Display P3", "gAMA": "100000"}, astc.ProfileLDRSRGB},
+		{"cICP sRGB", gray, map[string]string{"cICP": "1,13,0,1"}, astc.ProfileLDRSRGB},
+		{"cICP linear", color, map[string]string{"cICP": "1,8,0,1"}, astc.ProfileLDR},
+		{"cICP PQ", color, map[string]string{"cICP": "9, 16, 0, 1"}, astc.ProfileHDR},
+		{"cICP over sRGB", color, map[string]string{"cICP": "9,18,0,1", "sRGB": "0"}, astc.ProfileHDR},
+		{"EXR", color, map[string]string{"chromaticities": "0.64 0.33 0.3 0.6 0.15 0.06 0.3127 0.329"}, astc.ProfileHDR},
+	} {
+		if got := astc.SuggestProfile(c.img, c.metadata); got != c.want {
+			t.Errorf("%s: SuggestProfile = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package astc
+
+import "time"
+
+// progressEpoch is the reference of the monotonic start times in opState.startNanos.
+var progressEpoch = time.Now()
+
+// Progress reports how far the current or last compression of c has come: done of total blocks
+// encoded, and eta, the estimated time until the rest are, from the average block rate since the
+// compression started. eta is 0 until the first block completes and once all have. Unlike
+// Config.ProgressCallback, which runs on the encoding goroutines, Progress can be polled from any
+// goroutine (e.g. a GUI's timer) while the compression runs; it takes no locks and may also be
+// called from the callback. A Context that has not compressed anything reports zeros.
+func (c *Context) Progress() (done, total uint32, eta time.Duration) {
+	if c == nil {
+		return 0, 0, 0
+	}
+	// Load done first: total and the start time are stored before any block completes.
+	done = c.compress.doneBlocks.Load()
+	total = c.compress.totalBlocks.Load()
+	if done == 0 || done >= total {
+		return min(done, total), total, 0
+	}
+	elapsed := time.Since(progressEpoch) - time.Duration(c.compress.startNanos.Load())
+	if elapsed <= 0 {
+		return done, total, 0
+	}
+	eta = time.Duration(float64(elapsed) * float64(total-done) / float64(done))
+	return done, total, eta
+}
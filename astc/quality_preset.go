@@ -0,0 +1,161 @@
+package astc
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// encodeQualityNames are the names of the EncodeQuality values, in order.
+var encodeQualityNames = [...]string{"fastest", "fast", "medium", "thorough", "verythorough", "exhaustive"}
+
+// String returns the preset name of q ("fastest", ..., "exhaustive"), as ParseEncodeQuality
+// accepts it.
+func (q EncodeQuality) String() string {
+	if int(q) < len(encodeQualityNames) {
+		return encodeQualityNames[q]
+	}
+	return fmt.Sprintf("EncodeQuality(%d)", uint8(q))
+}
+
+// Level returns the upstream quality level (0-100) of the preset q, the value ConfigInit and
+// WithQualityLevel take: 0, 10, 60, 98, 99 and 100 from EncodeFastest to EncodeExhaustive.
+// Presets are ordered by effort, so q < r implies q.Level() < r.Level() for presets q and r.
+// Values above EncodeExhaustive are not presets and return the EncodeMedium level, 60, so the
+// ordering does not extend to them; NewEncoder rejects them with ErrBadQuality.
+func (q EncodeQuality) Level() float32 {
+	switch q {
+	case EncodeFastest:
+		return 0
+	case EncodeFast:
+		return 10
+	case EncodeThorough:
+		return 98
+	case EncodeVeryThorough:
+		return 99
+	case EncodeExhaustive:
+		return 100
+	default:
+		return 60
+	}
+}
+
+// ParseEncodeQuality returns the EncodeQuality named name, ignoring case and surrounding spaces;
+// "very-thorough" is accepted for EncodeVeryThorough.
+func ParseEncodeQuality(name string) (EncodeQuality, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "very-thorough" {
+		return EncodeVeryThorough, nil
+	}
+	for q, n := range encodeQualityNames {
+		if n == name {
+			return EncodeQuality(q), nil
+		}
+	}
+	return 0, newError(ErrBadQuality, fmt.Sprintf("astc: unknown quality %q", name))
+}
+
+// qualityPreset is a preset registered with RegisterQualityPreset.
+type qualityPreset struct {
+	level    float32
+	cfgDelta func(*Config)
+}
+
+var (
+	qualityPresetsMu sync.RWMutex
+	qualityPresets   = map[string]qualityPreset{}
+)
+
+// RegisterQualityPreset defines a named quality preset, e.g. an in-house "ci-fast" or "ship", so
+// CLI tools and configuration files can select it by name like the built-in ones: level is the
+// upstream quality level (0-100) its tuning starts from, and cfgDelta, if not nil, then adjusts
+// the Config (tuning limits, channel weights, ...). cfgDelta runs for every Config the preset is
+// resolved to and must not retain it.
+//
+// Names are matched exactly. The built-in names ("fastest" to "exhaustive", see
+// EncodeQuality.String) and names already registered are rejected; presets cannot be removed.
+// Registration is safe for concurrent use but usually happens in an init function.
+func RegisterQualityPreset(name string, level float32, cfgDelta func(*Config)) error {
+	if name == "" || strings.TrimSpace(name) != name {
+		return newError(ErrBadParam, "astc: invalid quality preset name")
+	}
+	if !(level >= 0 && level <= 100) {
+		return newError(ErrBadQuality, "astc: quality preset level must be 0-100")
+	}
+	if _, err := ParseEncodeQuality(name); err == nil {
+		return newError(ErrBadParam, fmt.Sprintf("astc: quality preset %q is built in", name))
+	}
+	qualityPresetsMu.Lock()
+	defer qualityPresetsMu.Unlock()
+	if _, ok := qualityPresets[name]; ok {
+		return newError(ErrBadParam, fmt.Sprintf("astc: quality preset %q already registered", name))
+	}
+	qualityPresets[name] = qualityPreset{level: level, cfgDelta: cfgDelta}
+	return nil
+}
+
+// lookupQualityPreset resolves a built-in or registered preset name.
+func lookupQualityPreset(name string) (qualityPreset, error) {
+	if q, err := ParseEncodeQuality(name); err == nil {
+		return qualityPreset{level: q.Level()}, nil
+	}
+	qualityPresetsMu.RLock()
+	p, ok := qualityPresets[name]
+	qualityPresetsMu.RUnlock()
+	if !ok {
+		return qualityPreset{}, newError(ErrBadQuality, fmt.Sprintf("astc: unknown quality preset %q", name))
+	}
+	return p, nil
+}
+
+// QualityPresetNames returns the names of the built-in and registered quality presets in
+// increasing order of their levels, ties in name order.
+func QualityPresetNames() []string {
+	type entry struct {
+		name  string
+		level float32
+	}
+	var entries []entry
+	for q, n := range encodeQualityNames {
+		entries = append(entries, entry{n, EncodeQuality(q).Level()})
+	}
+	qualityPresetsMu.RLock()
+	for n, p := range qualityPresets {
+		entries = append(entries, entry{n, p.level})
+	}
+	qualityPresetsMu.RUnlock()
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].level != entries[j].level {
+			return entries[i].level < entries[j].level
+		}
+		return entries[i].name < entries[j].name
+	})
+	names := make([]string, len(entries))
+	for i, e := range entries {
+		names[i] = e.name
+	}
+	return names
+}
+
+// QualityPresetConfig returns the Config of the named quality preset (built in or registered)
+// for a profile, block footprint and flags: ConfigInit at the preset's level, adjusted by its
+// cfgDelta and validated like ContextAlloc does.
+func QualityPresetConfig(name string, profile Profile, blockX, blockY, blockZ int, flags Flags) (Config, error) {
+	p, err := lookupQualityPreset(name)
+	if err != nil {
+		return Config{}, err
+	}
+	cfg, err := ConfigInit(profile, blockX, blockY, blockZ, p.level, flags)
+	if err != nil {
+		return Config{}, err
+	}
+	if p.cfgDelta != nil {
+		p.cfgDelta(&cfg)
+	}
+	check := cfg
+	if err := validateAndClampConfig(&check); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
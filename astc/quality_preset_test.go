@@ -0,0 +1,96 @@
+package astc_test
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/arm-software/astc-encoder/astc"
+)
+
+func TestEncodeQuality_NamesAndLevels(t *testing.T) {
+	prev := float32(-1)
+	for q := astc.EncodeFastest; q <= astc.EncodeExhaustive; q++ {
+		got, err := astc.ParseEncodeQuality(" " + q.String() + " ")
+		if err != nil || got != q {
+			t.Fatalf("ParseEncodeQuality(%q) = %v, %v", q.String(), got, err)
+		}
+		if q.Level() <= prev {
+			t.Fatalf("%v level %v does not exceed %v", q, q.Level(), prev)
+		}
+		prev = q.Level()
+	}
+	if q, err := astc.ParseEncodeQuality("Very-Thorough"); err != nil || q != astc.EncodeVeryThorough {
+		t.Fatalf("ParseEncodeQuality(Very-Thorough) = %v, %v", q, err)
+	}
+	if _, err := astc.ParseEncodeQuality("ship"); !errors.Is(err, astc.ErrBadQuality) {
+		t.Fatalf("ParseEncodeQuality(ship): got %v, want ErrBadQuality", err)
+	}
+}
+
+func TestRegisterQualityPreset(t *testing.T) {
+	delta := func(cfg *astc.Config) {
+		cfg.TuneCandidateLimit = 1
+		cfg.CWAWeight = 2
+	}
+	if err := astc.RegisterQualityPreset("test-ci-fast", 5, delta); err != nil {
+		t.Fatalf("RegisterQualityPreset: %v", err)
+	}
+
+	want, err := astc.ConfigInit(astc.ProfileLDR, 6, 6, 1, 5, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	delta(&want)
+	got, err := astc.QualityPresetConfig("test-ci-fast", astc.ProfileLDR, 6, 6, 1, 0)
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Fatalf("QualityPresetConfig = %+v, %v; want %+v", got, err, want)
+	}
+	if got, err := astc.QualityPresetConfig("thorough", astc.ProfileLDR, 6, 6, 1, 0); err != nil || got.TuneDBLimit == 0 {
+		t.Fatalf("QualityPresetConfig(thorough) = %+v, %v", got, err)
+	}
+
+	// WithConfig adjustments apply after the preset's, and a later quality option replaces it.
+	enc, err := astc.NewEncoder(astc.WithBlockSize(6, 6), astc.WithQualityPreset("test-ci-fast"),
+		astc.WithConfig(func(cfg *astc.Config) { cfg.CWAWeight = 3 }))
+	if err != nil {
+		t.Fatalf("NewEncoder: %v", err)
+	}
+	want.CWAWeight = 3
+	if cfg := enc.Config(); !reflect.DeepEqual(cfg, want) {
+		t.Fatalf("Encoder config = %+v, want %+v", cfg, want)
+	}
+	enc, err = astc.NewEncoder(astc.WithBlockSize(6, 6), astc.WithQualityPreset("test-ci-fast"), astc.WithQuality(astc.EncodeThorough))
+	if err != nil {
+		t.Fatalf("NewEncoder: %v", err)
+	}
+	if want, _ := astc.QualityPresetConfig("thorough", astc.ProfileLDR, 6, 6, 1, 0); !reflect.DeepEqual(enc.Config(), want) {
+		t.Fatal("WithQuality after WithQualityPreset did not replace the preset")
+	}
+
+	names := astc.QualityPresetNames()
+	if slices.Index(names, "fastest") != 0 || slices.Index(names, "test-ci-fast") != 1 ||
+		slices.Index(names, "fast") != 2 || names[len(names)-1] != "exhaustive" {
+		t.Fatalf("QualityPresetNames = %v", names)
+	}
+
+	for _, tc := range []struct {
+		name  string
+		level float32
+		want  error
+	}{
+		{"test-ci-fast", 5, astc.ErrBadParam},
+		{"medium", 50, astc.ErrBadParam},
+		{"", 50, astc.ErrBadParam},
+		{" padded", 50, astc.ErrBadParam},
+		{"test-too-high", 101, astc.ErrBadQuality},
+	} {
+		if err := astc.RegisterQualityPreset(tc.name, tc.level, nil); !errors.Is(err, tc.want) {
+			t.Fatalf("RegisterQualityPreset(%q, %v): got %v, want %v", tc.name, tc.level, err, tc.want)
+		}
+	}
+	if _, err := astc.NewEncoder(astc.WithQualityPreset("test-unknown")); !errors.Is(err, astc.ErrBadQuality) {
+		t.Fatalf("NewEncoder with an unknown preset: got %v, want ErrBadQuality", err)
+	}
+}
@@ -0,0 +1,12 @@
+// Package remote is a client for astcd, the ASTC encoder service in cmd/astcd.
+//
+// astcd lets build farms run the native-enabled encoder on a few machines while keeping CGO out of
+// the binaries that use it. Requests and responses are JSON; pixel and .astc payloads are carried
+// as base64 strings. The endpoints are:
+//   - POST /v1/encode: EncodeRequest -> EncodeResponse
+//   - POST /v1/decode: DecodeRequest -> DecodeResponse
+//   - GET /v1/info: Info
+//
+// Failed requests return a non-2xx status and an ErrorResponse. This package does not import the
+// native implementation.
+package remote
@@ -0,0 +1,175 @@
+package remote
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/arm-software/astc-encoder/astc"
+)
+
+// Implementation names accepted in requests.
+const (
+	ImplGo     = "go"
+	ImplNative = "native"
+)
+
+// EncodeRequest asks the service to compress an RGBA8 image.
+type EncodeRequest struct {
+	// Impl selects the implementation, ImplGo (the default) or ImplNative.
+	Impl    string             `json:"impl,omitempty"`
+	Profile astc.Profile       `json:"profile"`
+	Quality astc.EncodeQuality `json:"quality"`
+
+	// BlockZ may be zero for 2D footprints.
+	BlockX int `json:"block_x"`
+	BlockY int `json:"block_y"`
+	BlockZ int `json:"block_z,omitempty"`
+
+	// Depth may be zero for 2D images.
+	Width  int `json:"width"`
+	Height int `json:"height"`
+	Depth  int `json:"depth,omitempty"`
+
+	// RGBA8 holds width*height*depth*4 bytes in x-major, then y, then z order.
+	RGBA8 []byte `json:"rgba8"`
+}
+
+// EncodeResponse is the result of an encode request.
+type EncodeResponse struct {
+	// ASTC is the complete .astc file.
+	ASTC []byte `json:"astc"`
+}
+
+// DecodeRequest asks the service to decompress a .astc file to RGBA8.
+type DecodeRequest struct {
+	// Impl selects the implementation, ImplGo (the default) or ImplNative.
+	Impl    string       `json:"impl,omitempty"`
+	Profile astc.Profile `json:"profile"`
+	ASTC    []byte       `json:"astc"`
+}
+
+// DecodeResponse is the result of a decode request.
+type DecodeResponse struct {
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	Depth  int    `json:"depth"`
+	RGBA8  []byte `json:"rgba8"`
+}
+
+// Info describes a running service.
+type Info struct {
+	// Implementations lists the implementations the service accepts.
+	Implementations []string `json:"implementations"`
+	// NativeVersion is the upstream astcenc version of the native implementation, if available.
+	NativeVersion string `json:"native_version,omitempty"`
+	// Threads is the number of threads the native implementation uses per request. Pure-Go
+	// requests are encoded on one thread each.
+	Threads int `json:"threads"`
+}
+
+// ErrorResponse is the body of a failed request.
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// Error is returned by Client methods when the service rejects a request.
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("astc/remote: %s (HTTP %d)", e.Message, e.StatusCode)
+}
+
+// Client calls an astcd service. It is safe for concurrent use.
+type Client struct {
+	// BaseURL is the service root, e.g. "http://encoder-1:8080".
+	BaseURL string
+	// HTTPClient is used for requests; nil means http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// NewClient returns a client for the service at baseURL.
+func NewClient(baseURL string) *Client {
+	return &Client{BaseURL: baseURL}
+}
+
+// Encode compresses req.RGBA8 and returns the .astc file.
+func (c *Client) Encode(ctx context.Context, req *EncodeRequest) ([]byte, error) {
+	if req == nil {
+		return nil, errors.New("astc/remote: nil request")
+	}
+	var resp EncodeResponse
+	if err := c.do(ctx, http.MethodPost, "/v1/encode", req, &resp); err != nil {
+		return nil, err
+	}
+	return resp.ASTC, nil
+}
+
+// Decode decompresses req.ASTC.
+func (c *Client) Decode(ctx context.Context, req *DecodeRequest) (*DecodeResponse, error) {
+	if req == nil {
+		return nil, errors.New("astc/remote: nil request")
+	}
+	var resp DecodeResponse
+	if err := c.do(ctx, http.MethodPost, "/v1/decode", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Info returns the capabilities of the service.
+func (c *Client) Info(ctx context.Context) (*Info, error) {
+	var resp Info
+	if err := c.do(ctx, http.MethodGet, "/v1/info", nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *Client) do(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(c.BaseURL, "/")+path, body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	hc := c.HTTPClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var e ErrorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&e); err != nil || e.Error == "" {
+			e.Error = http.StatusText(resp.StatusCode)
+		}
+		return &Error{StatusCode: resp.StatusCode, Message: e.Error}
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("astc/remote: invalid response: %w", err)
+	}
+	return nil
+}
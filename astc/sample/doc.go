@@ -0,0 +1,12 @@
+// Package sample emulates GPU texture sampling of ASTC-compressed images on the CPU.
+//
+// It decodes only the blocks a lookup touches and applies the same conversions a GPU applies in
+// the default FP16 decode mode, so shading code can be unit-tested against the values a GPU would
+// return:
+//   - Decoded texels are rounded to FP16 precision.
+//   - ProfileLDRSRGB texels are decoded to 8 bits and converted to linear before filtering.
+//   - Bilinear weights use 8 fractional bits of sub-texel precision.
+//   - The filtered result is rounded to FP16 precision.
+//
+// Addressing is clamp-to-edge. Only 2D images are supported.
+package sample
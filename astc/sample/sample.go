@@ -0,0 +1,172 @@
+package sample
+
+import (
+	"errors"
+	"math"
+
+	"github.com/arm-software/astc-encoder/astc"
+)
+
+// subTexelBits is the bilinear weight precision. D3D and Vulkan require at least 8 bits, which is
+// what most hardware implements.
+const subTexelBits = 8
+
+// FetchTexel returns the texel at integer coordinates (x, y) as a GPU would fetch it, clamping the
+// coordinates to the image edge.
+func FetchTexel(blocks []byte, hdr astc.Header, profile astc.Profile, x, y int) ([4]float32, error) {
+	f, err := newFetcher(blocks, hdr, profile)
+	if err != nil {
+		return [4]float32{}, err
+	}
+	return f.texel(x, y)
+}
+
+// SampleBilinear returns the bilinearly filtered color at normalized coordinates (u, v), where
+// (0, 0) is the top-left corner of the first texel and (1, 1) the bottom-right corner of the last.
+//
+// The blocks slice is the block payload as returned by astc.ParseFile.
+func SampleBilinear(blocks []byte, hdr astc.Header, profile astc.Profile, u, v float32) ([4]float32, error) {
+	f, err := newFetcher(blocks, hdr, profile)
+	if err != nil {
+		return [4]float32{}, err
+	}
+
+	x0, fx := texelCoord(u, int(hdr.SizeX))
+	y0, fy := texelCoord(v, int(hdr.SizeY))
+
+	var t [4][4]float32
+	for i := range t {
+		if t[i], err = f.texel(x0+i&1, y0+i>>1); err != nil {
+			return [4]float32{}, err
+		}
+	}
+	t00, t10, t01, t11 := t[0], t[1], t[2], t[3]
+
+	var out [4]float32
+	for c := 0; c < 4; c++ {
+		top := t00[c] + (t10[c]-t00[c])*fx
+		bottom := t01[c] + (t11[c]-t01[c])*fx
+		out[c] = roundToHalf(top + (bottom-top)*fy)
+	}
+	return out, nil
+}
+
+// texelCoord maps a normalized coordinate to the left/top texel of the bilinear footprint and the
+// quantized interpolation fraction.
+func texelCoord(u float32, size int) (int, float32) {
+	if !(u == u) {
+		u = 0
+	}
+	pos := float64(u)*float64(size) - 0.5
+	base := math.Floor(pos)
+	frac := math.Round((pos-base)*(1<<subTexelBits)) / (1 << subTexelBits)
+	if frac >= 1 {
+		base++
+		frac = 0
+	}
+	return int(base), float32(frac)
+}
+
+type fetcher struct {
+	blocks  []byte
+	profile astc.Profile
+
+	width, height  int
+	blockX, blockY int
+	blocksX        int
+	blockHdr       astc.Header
+	cachedBlock    int
+	texelsF32      []float32
+	texelsU8       []byte
+}
+
+func newFetcher(blocks []byte, hdr astc.Header, profile astc.Profile) (*fetcher, error) {
+	if hdr.SizeZ != 1 || hdr.BlockZ != 1 {
+		return nil, errors.New("astc/sample: only 2D images are supported")
+	}
+	blocksX, _, _, total, err := hdr.BlockCount()
+	if err != nil {
+		return nil, err
+	}
+	if len(blocks) < total*astc.BlockBytes {
+		return nil, errors.New("astc/sample: block payload too short")
+	}
+
+	f := &fetcher{
+		blocks:      blocks,
+		profile:     profile,
+		width:       int(hdr.SizeX),
+		height:      int(hdr.SizeY),
+		blockX:      int(hdr.BlockX),
+		blockY:      int(hdr.BlockY),
+		blocksX:     blocksX,
+		cachedBlock: -1,
+		blockHdr: astc.Header{
+			BlockX: hdr.BlockX,
+			BlockY: hdr.BlockY,
+			BlockZ: 1,
+			SizeX:  uint32(hdr.BlockX),
+			SizeY:  uint32(hdr.BlockY),
+			SizeZ:  1,
+		},
+	}
+	texelCount := f.blockX * f.blockY
+	if profile == astc.ProfileLDRSRGB {
+		f.texelsU8 = make([]byte, texelCount*4)
+	} else {
+		f.texelsF32 = make([]float32, texelCount*4)
+	}
+	return f, nil
+}
+
+// texel returns the texel at (x, y) after clamping, decoding its block unless it is the cached one.
+// An error decoding the block is returned rather than sampling an undefined texel.
+func (f *fetcher) texel(x, y int) ([4]float32, error) {
+	x = clampInt(x, 0, f.width-1)
+	y = clampInt(y, 0, f.height-1)
+
+	idx := (y/f.blockY)*f.blocksX + x/f.blockX
+	if idx != f.cachedBlock {
+		block := f.blocks[idx*astc.BlockBytes : (idx+1)*astc.BlockBytes]
+		var err error
+		if f.texelsU8 != nil {
+			err = astc.DecodeRGBA8VolumeFromParsedWithProfileInto(f.profile, f.blockHdr, block, f.texelsU8)
+		} else {
+			err = astc.DecodeRGBAF32VolumeFromParsedWithProfileInto(f.profile, f.blockHdr, block, f.texelsF32)
+		}
+		if err != nil {
+			f.cachedBlock = -1
+			return [4]float32{}, err
+		}
+		f.cachedBlock = idx
+	}
+
+	off := ((y%f.blockY)*f.blockX + x%f.blockX) * 4
+	var out [4]float32
+	if f.texelsU8 != nil {
+		for c := 0; c < 3; c++ {
+			out[c] = roundToHalf(astc.SRGB8ToLinear(f.texelsU8[off+c]))
+		}
+		out[3] = roundToHalf(float32(f.texelsU8[off+3]) / 255)
+		return out, nil
+	}
+	for c := 0; c < 4; c++ {
+		out[c] = roundToHalf(f.texelsF32[off+c])
+	}
+	return out, nil
+}
+
+// roundToHalf rounds v to the nearest FP16-representable value with the encoder's own conversion.
+func roundToHalf(v float32) float32 {
+	return astc.HalfToFloat32(astc.Float32ToHalf(v))
+}
+
+func clampInt(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
@@ -0,0 +1,95 @@
+package sample_test
+
+import (
+	"testing"
+
+	"github.com/arm-software/astc-encoder/astc"
+	"github.com/arm-software/astc-encoder/astc/sample"
+)
+
+func encodeGradient(t *testing.T, w, h int) (astc.Header, []byte) {
+	t.Helper()
+	pix := make([]byte, w*h*4)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			off := (y*w + x) * 4
+			pix[off+0] = uint8(x * 255 / (w - 1))
+			pix[off+1] = uint8(y * 255 / (h - 1))
+			pix[off+2] = 128
+			pix[off+3] = 255
+		}
+	}
+	data, err := astc.EncodeRGBA8(pix, w, h, 6, 6)
+	if err != nil {
+		t.Fatalf("EncodeRGBA8: %v", err)
+	}
+	hdr, blocks, err := astc.ParseFile(data)
+	if err != nil {
+		t.Fatalf("ParseFile: %v", err)
+	}
+	return hdr, blocks
+}
+
+func TestSampleBilinear_TexelCentersMatchFetch(t *testing.T) {
+	const w, h = 20, 14
+	hdr, blocks := encodeGradient(t, w, h)
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			u := (float32(x) + 0.5) / w
+			v := (float32(y) + 0.5) / h
+			got, err := sample.SampleBilinear(blocks, hdr, astc.ProfileLDR, u, v)
+			if err != nil {
+				t.Fatalf("SampleBilinear: %v", err)
+			}
+			want, err := sample.FetchTexel(blocks, hdr, astc.ProfileLDR, x, y)
+			if err != nil {
+				t.Fatalf("FetchTexel: %v", err)
+			}
+			if got != want {
+				t.Fatalf("(%d,%d): sample %v != fetch %v", x, y, got, want)
+			}
+		}
+	}
+}
+
+func TestSampleBilinear_MidpointAndClamp(t *testing.T) {
+	const w, h = 20, 14
+	hdr, blocks := encodeGradient(t, w, h)
+
+	// Halfway between texels 5 and 6 (which straddle a 6x6 block boundary) on row 3.
+	a, _ := sample.FetchTexel(blocks, hdr, astc.ProfileLDR, 5, 3)
+	b, _ := sample.FetchTexel(blocks, hdr, astc.ProfileLDR, 6, 3)
+	got, err := sample.SampleBilinear(blocks, hdr, astc.ProfileLDR, 6.0/w, 3.5/h)
+	if err != nil {
+		t.Fatalf("SampleBilinear: %v", err)
+	}
+	for c := 0; c < 4; c++ {
+		want := (a[c] + b[c]) / 2
+		if d := got[c] - want; d > 1e-3 || d < -1e-3 {
+			t.Fatalf("channel %d: got %v want %v", c, got[c], want)
+		}
+	}
+
+	// Coordinates outside [0,1] clamp to the edge texel.
+	corner, _ := sample.FetchTexel(blocks, hdr, astc.ProfileLDR, w-1, h-1)
+	got, err = sample.SampleBilinear(blocks, hdr, astc.ProfileLDR, 1.5, 2)
+	if err != nil {
+		t.Fatalf("SampleBilinear: %v", err)
+	}
+	if got != corner {
+		t.Fatalf("clamped sample %v != corner texel %v", got, corner)
+	}
+}
+
+func TestSampleBilinear_Errors(t *testing.T) {
+	hdr := astc.Header{BlockX: 4, BlockY: 4, BlockZ: 1, SizeX: 8, SizeY: 8, SizeZ: 1}
+	if _, err := sample.SampleBilinear(make([]byte, astc.BlockBytes), hdr, astc.ProfileLDR, 0.5, 0.5); err == nil {
+		t.Fatalf("expected error for short block payload")
+	}
+	hdr.SizeZ = 4
+	hdr.BlockZ = 4
+	if _, err := sample.FetchTexel(make([]byte, 4*astc.BlockBytes), hdr, astc.ProfileLDR, 0, 0); err == nil {
+		t.Fatalf("expected error for 3D image")
+	}
+}
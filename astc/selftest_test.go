@@ -0,0 +1,33 @@
+package astc_test
+
+import (
+	"testing"
+
+	"github.com/arm-software/astc-encoder/astc"
+)
+
+func TestSelfTest(t *testing.T) {
+	report, err := astc.SelfTest()
+	if err != nil {
+		t.Fatalf("SelfTest: %v", err)
+	}
+	if !report.OK() || len(report.Suites) == 0 {
+		t.Fatalf("SelfTest report not OK: %+v", report.Suites)
+	}
+
+	// The vectors cover every endpoint format, quantization level and plane configuration.
+	if report.EndpointFormats != 16 || report.ColorQuantLevels != 17 || report.WeightQuantLevels != 12 {
+		t.Fatalf("coverage: %d endpoint formats, %d color quant levels, %d weight quant levels",
+			report.EndpointFormats, report.ColorQuantLevels, report.WeightQuantLevels)
+	}
+	for i, n := range report.PartitionCounts {
+		if n == 0 {
+			t.Fatalf("no vectors with partition count %d (0 = constant color): %v", i, report.PartitionCounts)
+		}
+	}
+	for c, n := range report.DualPlane {
+		if n == 0 {
+			t.Fatalf("no dual-plane vectors with second-plane component %d", c)
+		}
+	}
+}
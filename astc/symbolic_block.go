@@ -96,7 +96,7 @@ func physicalToSymbolicWithCtx(block []byte, ctx *decodeContext) (scb symbolicBl
 	scb.blockType = symBlockNonConst
 
 	// Decode block mode and validate for the given block size.
-	bmi := ctx.blockModes[blockMode]
+	bmi := ctx.blockMode(blockMode)
 	if !bmi.ok {
 		scb.blockType = symBlockError
 		return scb
@@ -0,0 +1,75 @@
+package astc_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/arm-software/astc-encoder/astc"
+	"github.com/arm-software/astc-encoder/astc/testimage"
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestEncoder_EncodeToTargetCompressedSize(t *testing.T) {
+	const w, h = 96, 96
+	src, err := testimage.RGBA8(testimage.KindPerlin, w, h, 1, testimage.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	img := &astc.Image{DimX: w, DimY: h, DimZ: 1, DataType: astc.TypeU8, DataU8: src}
+	zenc, err := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zenc.Close()
+	calls := 0
+	estimate := func(blocks []byte) int {
+		calls++
+		return len(zenc.EncodeAll(blocks, nil))
+	}
+
+	enc, err := astc.NewEncoder(astc.WithBlockSize(6, 6))
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain, err := enc.Encode(img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	full := estimate(plain[astc.HeaderSize:])
+
+	// A target the plain encoding meets returns it unchanged.
+	calls = 0
+	out, err := enc.EncodeToTargetCompressedSize(img, full, estimate)
+	if err != nil || !bytes.Equal(out, plain) || calls != 1 {
+		t.Fatalf("target met: err %v, equal %v, %d estimates", err, bytes.Equal(out, plain), calls)
+	}
+
+	target := full * 4 / 5
+	calls = 0
+	out, err = enc.EncodeToTargetCompressedSize(img, target, estimate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls > 7 {
+		t.Fatalf("%d estimates, want at most 7", calls)
+	}
+	if got := estimate(out[astc.HeaderSize:]); got > target {
+		t.Fatalf("estimated size %d, target %d", got, target)
+	}
+	dec, _, _, err := astc.DecodeRGBA8WithProfile(out, astc.ProfileLDR)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := psnrU8(src, dec, 4); p < 25 {
+		t.Fatalf("PSNR %.2f dB after size reduction", p)
+	}
+
+	out, err = enc.EncodeToTargetCompressedSize(img, 16, estimate)
+	if !errors.Is(err, astc.ErrTargetSizeNotReached) || len(out) != len(plain) {
+		t.Fatalf("unreachable target: len %d, err %v", len(out), err)
+	}
+	if _, err := enc.EncodeToTargetCompressedSize(img, target, nil); astc.ErrorCodeOf(err) != astc.ErrBadParam {
+		t.Fatalf("nil estimator: %v", err)
+	}
+}
@@ -0,0 +1,7 @@
+// Package testimage generates deterministic synthetic images for benchmarking and encoder tuning.
+//
+// Each Kind models a content class that stresses a different part of the encoder (smooth ramps,
+// band-limited noise, high-contrast text, binary alpha, HDR luminance range). The output depends
+// only on the kind, the dimensions and the Options, so results measured on different machines and
+// Go versions are comparable.
+package testimage
@@ -0,0 +1,427 @@
+package testimage
+
+import (
+	"errors"
+	"math"
+)
+
+// Kind selects the content class of a generated image.
+type Kind uint8
+
+const (
+	// KindPattern is the arithmetic RGBA pattern used by cmd/astcbench. It ignores Options.
+	KindPattern Kind = iota
+	// KindGradient is a set of smooth linear ramps, one direction per channel.
+	KindGradient
+	// KindPerlin is fractal Perlin noise, independent per RGB channel, with opaque alpha.
+	KindPerlin
+	// KindText is dark glyph-like strokes on a light background, with opaque alpha.
+	KindText
+	// KindAlphaCutout is noisy foliage-like color with a binary (0 or 1) alpha mask.
+	KindAlphaCutout
+	// KindHDRSky is an equirectangular sky probe with a sun far brighter than 1.0. RGBA8 output
+	// clamps it.
+	KindHDRSky
+)
+
+var kindNames = [...]string{"pattern", "gradient", "perlin", "text", "alpha-cutout", "hdr-sky"}
+
+// String returns the name of the kind as accepted by ParseKind.
+func (k Kind) String() string {
+	if int(k) < len(kindNames) {
+		return kindNames[k]
+	}
+	return "unknown"
+}
+
+// ParseKind returns the Kind with the given name.
+func ParseKind(name string) (Kind, error) {
+	for i, n := range kindNames {
+		if n == name {
+			return Kind(i), nil
+		}
+	}
+	return 0, errors.New("astc/testimage: unknown image kind")
+}
+
+// Options controls the statistical properties of the generated content.
+type Options struct {
+	// Seed selects the random variation. The same seed always produces the same image.
+	Seed uint64
+	// FeatureSize is the approximate size in texels of the dominant structures: the noise base
+	// period, glyph height and cutout shapes. Zero selects 32.
+	FeatureSize float64
+	// Octaves is the number of noise octaves summed by the noise-based kinds. Zero selects 4.
+	Octaves int
+}
+
+func (o Options) withDefaults() Options {
+	if o.FeatureSize <= 0 {
+		o.FeatureSize = 32
+	}
+	if o.Octaves <= 0 {
+		o.Octaves = 4
+	}
+	return o
+}
+
+// RGBA8 returns a width x height x depth RGBA8 image of the given kind, in x-fastest, then y, then
+// z order.
+func RGBA8(k Kind, width, height, depth int, opts Options) ([]byte, error) {
+	if err := validate(k, width, height, depth); err != nil {
+		return nil, err
+	}
+	pix := make([]byte, width*height*depth*4)
+	if k == KindPattern {
+		fillPatternRGBA8(pix, width, height, depth)
+		return pix, nil
+	}
+
+	g := newGenerator(k, width, height, depth, opts.withDefaults())
+	for z := 0; z < depth; z++ {
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				off := ((z*height+y)*width + x) * 4
+				c := g.texel(x, y, z)
+				for i := 0; i < 4; i++ {
+					pix[off+i] = unorm8(c[i])
+				}
+			}
+		}
+	}
+	return pix, nil
+}
+
+// RGBAF32 returns a width x height x depth RGBA float32 image of the given kind, in x-fastest,
+// then y, then z order. LDR kinds produce values in 0..1.
+//
+// KindPattern produces the cmd/astcbench HDR pattern: the RGBA8 pattern scaled by 4, 2 and 6 in
+// RGB, with alpha left in 0..1.
+func RGBAF32(k Kind, width, height, depth int, opts Options) ([]float32, error) {
+	if err := validate(k, width, height, depth); err != nil {
+		return nil, err
+	}
+	pix := make([]float32, width*height*depth*4)
+	if k == KindPattern {
+		fillPatternRGBAF32(pix, width, height, depth)
+		return pix, nil
+	}
+
+	g := newGenerator(k, width, height, depth, opts.withDefaults())
+	for z := 0; z < depth; z++ {
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				off := ((z*height+y)*width + x) * 4
+				c := g.texel(x, y, z)
+				for i := 0; i < 4; i++ {
+					pix[off+i] = float32(c[i])
+				}
+			}
+		}
+	}
+	return pix, nil
+}
+
+func validate(k Kind, width, height, depth int) error {
+	if int(k) >= len(kindNames) {
+		return errors.New("astc/testimage: unknown image kind")
+	}
+	if width <= 0 || height <= 0 || depth <= 0 {
+		return errors.New("astc/testimage: invalid image dimensions")
+	}
+	return nil
+}
+
+func unorm8(v float64) uint8 {
+	if !(v > 0) {
+		return 0
+	}
+	if v >= 1 {
+		return 255
+	}
+	return uint8(v*255 + 0.5)
+}
+
+func fillPatternRGBA8(pix []byte, width, height, depth int) {
+	for z := 0; z < depth; z++ {
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				off := ((z*height+y)*width + x) * 4
+				pix[off+0] = uint8(uint32(x*3 + y*5 + z*7))
+				pix[off+1] = uint8(uint32(x*11 + y*13 + z*17))
+				pix[off+2] = uint8(uint32(x ^ y ^ z))
+				pix[off+3] = uint8(255 - uint32((x*5+y*7+z*3)&0xFF))
+			}
+		}
+	}
+}
+
+func fillPatternRGBAF32(pix []float32, width, height, depth int) {
+	for z := 0; z < depth; z++ {
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				off := ((z*height+y)*width + x) * 4
+				pix[off+0] = float32(uint8(uint32(x*3+y*5+z*7))) / 255.0 * 4.0
+				pix[off+1] = float32(uint8(uint32(x*11+y*13+z*17))) / 255.0 * 2.0
+				pix[off+2] = float32(uint8(uint32(x^y^z))) / 255.0 * 6.0
+				pix[off+3] = float32(uint8(255-uint32((x*5+y*7+z*3)&0xFF))) / 255.0
+			}
+		}
+	}
+}
+
+// generator evaluates one of the procedural kinds at integer texel coordinates.
+type generator struct {
+	kind                 Kind
+	width, height, depth int
+	opts                 Options
+
+	// Per-image random parameters drawn from the seed.
+	dirs   [4][3]float64 // gradient directions
+	colors [4][3]float64 // palette
+	sun    [3]float64    // sun direction
+}
+
+func newGenerator(k Kind, width, height, depth int, opts Options) *generator {
+	g := &generator{kind: k, width: width, height: height, depth: depth, opts: opts}
+	r := rng{state: opts.Seed}
+	for i := range g.dirs {
+		a := r.float() * 2 * math.Pi
+		g.dirs[i] = [3]float64{math.Cos(a), math.Sin(a), r.float()*2 - 1}
+	}
+	for i := range g.colors {
+		g.colors[i] = [3]float64{r.float(), r.float(), r.float()}
+	}
+	az := r.float() * 2 * math.Pi
+	el := 0.1 + r.float()*0.6
+	g.sun = [3]float64{math.Cos(el) * math.Cos(az), math.Sin(el), math.Cos(el) * math.Sin(az)}
+	return g
+}
+
+func (g *generator) texel(x, y, z int) [4]float64 {
+	fx, fy, fz := float64(x)+0.5, float64(y)+0.5, float64(z)+0.5
+	switch g.kind {
+	case KindGradient:
+		return g.gradient(fx, fy, fz)
+	case KindPerlin:
+		return g.perlin(fx, fy, fz)
+	case KindText:
+		return g.text(x, y, z)
+	case KindAlphaCutout:
+		return g.alphaCutout(fx, fy, fz)
+	case KindHDRSky:
+		return g.hdrSky(fx, fy)
+	default:
+		return [4]float64{}
+	}
+}
+
+func (g *generator) gradient(x, y, z float64) [4]float64 {
+	// Normalize so every ramp spans the full range across the image.
+	u := [3]float64{x / float64(g.width), y / float64(g.height), z / float64(g.depth)}
+	var out [4]float64
+	for c := 0; c < 4; c++ {
+		d := g.dirs[c]
+		lo, hi := 0.0, 0.0
+		for i := 0; i < 3; i++ {
+			if d[i] < 0 {
+				lo += d[i]
+			} else {
+				hi += d[i]
+			}
+		}
+		v := d[0]*u[0] + d[1]*u[1] + d[2]*u[2]
+		if hi > lo {
+			out[c] = (v - lo) / (hi - lo)
+		}
+	}
+	return out
+}
+
+// fbm sums octaves of Perlin noise at halving periods, returning a value in about -1..1.
+func (g *generator) fbm(x, y, z float64, channel uint64) float64 {
+	freq := 1 / g.opts.FeatureSize
+	amp, sum, norm := 1.0, 0.0, 0.0
+	for o := 0; o < g.opts.Octaves; o++ {
+		seed := g.opts.Seed ^ (channel*0x9E3779B97F4A7C15 + uint64(o)*0xBF58476D1CE4E5B9)
+		sum += amp * perlin3(x*freq, y*freq, z*freq, seed)
+		norm += amp
+		amp *= 0.5
+		freq *= 2
+	}
+	return sum / norm
+}
+
+func (g *generator) perlin(x, y, z float64) [4]float64 {
+	var out [4]float64
+	for c := 0; c < 3; c++ {
+		out[c] = clamp01(0.5 + 0.7*g.fbm(x, y, z, uint64(c)))
+	}
+	out[3] = 1
+	return out
+}
+
+func (g *generator) text(x, y, z int) [4]float64 {
+	// Glyphs are 3x5 random bitmaps in cells of (4*scale) x (6*scale) texels, leaving a one-cell
+	// gap for spacing.
+	scale := max(1, int(g.opts.FeatureSize/6+0.5))
+	cx, cy := x/(4*scale), y/(6*scale)
+	gx, gy := (x%(4*scale))/scale, (y%(6*scale))/scale
+
+	bg := [3]float64{0.92, 0.9, 0.86}
+	ink := g.colors[int(hash3(0, cy, z, g.opts.Seed)%2)]
+	for i := range ink {
+		ink[i] *= 0.3
+	}
+
+	on := false
+	if gx < 3 && gy < 5 {
+		h := hash3(cx, cy, z, g.opts.Seed^0x5851F42D4C957F2D)
+		// Roughly one cell in eight is a space.
+		on = h&7 != 0 && h>>(3+gy*3+gx)&1 != 0
+	}
+	c := bg
+	if on {
+		c = ink
+	}
+	return [4]float64{c[0], c[1], c[2], 1}
+}
+
+func (g *generator) alphaCutout(x, y, z float64) [4]float64 {
+	shape := g.fbm(x, y, z, 7)
+	detail := 0.5 + 0.5*g.fbm(x*4, y*4, z*4, 8)
+	base := [3]float64{0.15, 0.45, 0.1}
+	var out [4]float64
+	for c := 0; c < 3; c++ {
+		out[c] = clamp01(base[c] * (0.5 + detail))
+	}
+	if shape > 0 {
+		out[3] = 1
+	}
+	return out
+}
+
+func (g *generator) hdrSky(x, y float64) [4]float64 {
+	// Equirectangular mapping: x is azimuth and y runs from zenith to nadir.
+	az := x / float64(g.width) * 2 * math.Pi
+	el := math.Pi/2 - y/float64(g.height)*math.Pi
+	dir := [3]float64{math.Cos(el) * math.Cos(az), math.Sin(el), math.Cos(el) * math.Sin(az)}
+
+	var c [3]float64
+	if dir[1] < 0 {
+		// Ground: dim, slightly noisy brown.
+		n := 0.8 + 0.2*g.fbm(x, y, 0, 9)
+		c = [3]float64{0.12 * n, 0.09 * n, 0.06 * n}
+	} else {
+		t := math.Pow(1-dir[1], 3)
+		zenith := [3]float64{0.25, 0.45, 1.1}
+		horizon := [3]float64{1.4, 1.5, 1.6}
+		for i := range c {
+			c[i] = zenith[i] + (horizon[i]-zenith[i])*t
+		}
+	}
+
+	cosSun := dir[0]*g.sun[0] + dir[1]*g.sun[1] + dir[2]*g.sun[2]
+	halo := 8 * math.Pow(math.Max(cosSun, 0), 64)
+	disk := 0.0
+	if cosSun > 0.9995 {
+		disk = 20000
+	}
+	for i, tint := range [3]float64{1, 0.95, 0.85} {
+		c[i] += (halo + disk) * tint
+	}
+	return [4]float64{c[0], c[1], c[2], 1}
+}
+
+func clamp01(v float64) float64 {
+	return math.Min(math.Max(v, 0), 1)
+}
+
+// perlin3 is 3D gradient noise with lattice gradients drawn from seed, returning about -1..1.
+func perlin3(x, y, z float64, seed uint64) float64 {
+	x0, y0, z0 := math.Floor(x), math.Floor(y), math.Floor(z)
+	fx, fy, fz := x-x0, y-y0, z-z0
+	ix, iy, iz := int(x0), int(y0), int(z0)
+	u, v, w := fade(fx), fade(fy), fade(fz)
+
+	var n [8]float64
+	for i := range n {
+		dx, dy, dz := i&1, (i>>1)&1, i>>2
+		h := hash3(ix+dx, iy+dy, iz+dz, seed)
+		n[i] = grad(h, fx-float64(dx), fy-float64(dy), fz-float64(dz))
+	}
+	x00 := lerp(n[0], n[1], u)
+	x10 := lerp(n[2], n[3], u)
+	x01 := lerp(n[4], n[5], u)
+	x11 := lerp(n[6], n[7], u)
+	return lerp(lerp(x00, x10, v), lerp(x01, x11, v), w)
+}
+
+func fade(t float64) float64 {
+	return t * t * t * (t*(t*6-15) + 10)
+}
+
+func lerp(a, b, t float64) float64 {
+	return a + (b-a)*t
+}
+
+// grad returns the dot product of (x, y, z) with one of the 12 Perlin edge gradients.
+func grad(h uint64, x, y, z float64) float64 {
+	switch h % 12 {
+	case 0:
+		return x + y
+	case 1:
+		return -x + y
+	case 2:
+		return x - y
+	case 3:
+		return -x - y
+	case 4:
+		return x + z
+	case 5:
+		return -x + z
+	case 6:
+		return x - z
+	case 7:
+		return -x - z
+	case 8:
+		return y + z
+	case 9:
+		return -y + z
+	case 10:
+		return y - z
+	default:
+		return -y - z
+	}
+}
+
+func hash3(x, y, z int, seed uint64) uint64 {
+	h := seed
+	h = mix64(h ^ uint64(int64(x))*0x9E3779B97F4A7C15)
+	h = mix64(h ^ uint64(int64(y))*0xC2B2AE3D27D4EB4F)
+	h = mix64(h ^ uint64(int64(z))*0x165667B19E3779F9)
+	return h
+}
+
+// mix64 is the splitmix64 finalizer.
+func mix64(z uint64) uint64 {
+	z = (z ^ (z >> 30)) * 0xBF58476D1CE4E5B9
+	z = (z ^ (z >> 27)) * 0x94D049BB133111EB
+	return z ^ (z >> 31)
+}
+
+// rng is a splitmix64 generator. It is used instead of math/rand so the output stays stable
+// across Go releases.
+type rng struct {
+	state uint64
+}
+
+func (r *rng) next() uint64 {
+	r.state += 0x9E3779B97F4A7C15
+	return mix64(r.state)
+}
+
+// float returns a value in [0, 1).
+func (r *rng) float() float64 {
+	return float64(r.next()>>11) / (1 << 53)
+}
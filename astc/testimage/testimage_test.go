@@ -0,0 +1,104 @@
+package testimage_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/arm-software/astc-encoder/astc/testimage"
+)
+
+func TestRGBA8_Deterministic(t *testing.T) {
+	for k := testimage.KindPattern; k <= testimage.KindHDRSky; k++ {
+		a, err := testimage.RGBA8(k, 40, 24, 2, testimage.Options{Seed: 7})
+		if err != nil {
+			t.Fatalf("%v: %v", k, err)
+		}
+		if len(a) != 40*24*2*4 {
+			t.Fatalf("%v: unexpected length %d", k, len(a))
+		}
+		b, _ := testimage.RGBA8(k, 40, 24, 2, testimage.Options{Seed: 7})
+		if !bytes.Equal(a, b) {
+			t.Fatalf("%v: same seed produced different images", k)
+		}
+		if k == testimage.KindPattern {
+			continue
+		}
+		c, _ := testimage.RGBA8(k, 40, 24, 2, testimage.Options{Seed: 8})
+		if bytes.Equal(a, c) {
+			t.Fatalf("%v: different seeds produced identical images", k)
+		}
+	}
+}
+
+func TestRGBA8_Pattern(t *testing.T) {
+	pix, err := testimage.RGBA8(testimage.KindPattern, 300, 2, 2, testimage.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	x, y, z := 299, 1, 1
+	off := ((z*2+y)*300 + x) * 4
+	want := []byte{uint8(x*3 + y*5 + z*7), uint8(x*11 + y*13 + z*17), uint8(x ^ y ^ z), uint8(255 - (x*5+y*7+z*3)&0xFF)}
+	if !bytes.Equal(pix[off:off+4], want) {
+		t.Fatalf("got %v, want %v", pix[off:off+4], want)
+	}
+}
+
+func TestKindProperties(t *testing.T) {
+	cutout, err := testimage.RGBA8(testimage.KindAlphaCutout, 64, 64, 1, testimage.Options{Seed: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var opaque, clear int
+	for i := 3; i < len(cutout); i += 4 {
+		switch cutout[i] {
+		case 0:
+			clear++
+		case 255:
+			opaque++
+		default:
+			t.Fatalf("alpha cutout has non-binary alpha %d", cutout[i])
+		}
+	}
+	if opaque == 0 || clear == 0 {
+		t.Fatalf("alpha cutout has no edges: %d opaque, %d clear", opaque, clear)
+	}
+
+	sky, err := testimage.RGBAF32(testimage.KindHDRSky, 256, 128, 1, testimage.Options{Seed: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var peak float32
+	for i := 0; i < len(sky); i += 4 {
+		peak = max(peak, sky[i])
+	}
+	if peak < 100 {
+		t.Fatalf("HDR sky peak %f, want a bright sun", peak)
+	}
+
+	for _, k := range []testimage.Kind{testimage.KindGradient, testimage.KindPerlin, testimage.KindText} {
+		pix, err := testimage.RGBAF32(k, 32, 32, 1, testimage.Options{Seed: 3, FeatureSize: 8, Octaves: 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, v := range pix {
+			if v < 0 || v > 1 {
+				t.Fatalf("%v: value %f at %d outside 0..1", k, v, i)
+			}
+		}
+	}
+}
+
+func TestParseKind(t *testing.T) {
+	for k := testimage.KindPattern; k <= testimage.KindHDRSky; k++ {
+		got, err := testimage.ParseKind(k.String())
+		if err != nil || got != k {
+			t.Fatalf("ParseKind(%q) = %v, %v", k.String(), got, err)
+		}
+	}
+	if _, err := testimage.ParseKind("nope"); err == nil {
+		t.Fatalf("expected error for unknown kind")
+	}
+	if _, err := testimage.RGBA8(testimage.KindPerlin, 0, 1, 1, testimage.Options{}); err == nil {
+		t.Fatalf("expected error for invalid dimensions")
+	}
+}
@@ -0,0 +1,129 @@
+package transcode
+
+import "math"
+
+// BC1BlockBytes is the size of one encoded BC1 block.
+const BC1BlockBytes = 8
+
+// bc1AlphaThreshold is the alpha below which a texel is encoded as transparent black.
+const bc1AlphaThreshold = 128
+
+// BC1 palette positions, as weights out of 3 (four-color mode) or 2 (three-color mode) from
+// color0 towards color1. Index 3 of the three-color mode is transparent.
+var bc1Weights4 = [4]int{0, 3, 1, 2}
+var bc1Weights3 = [3]int{0, 2, 1}
+
+// EncodeBC1Block encodes a 4x4 tile of RGBA8 texels (row-major) into one BC1 block. Texels with
+// alpha below 128 are encoded as transparent black using BC1's three-color mode.
+func EncodeBC1Block(texels *[64]byte) [BC1BlockBytes]byte {
+	var px [16][4]float64
+	var opaque []int
+	for i := 0; i < 16; i++ {
+		for c := 0; c < 3; c++ {
+			px[i][c] = float64(texels[i*4+c])
+		}
+		if texels[i*4+3] >= bc1AlphaThreshold {
+			opaque = append(opaque, i)
+		}
+	}
+	if len(opaque) == 0 {
+		return packBC1(0, 0, [16]int{3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3})
+	}
+
+	best, bestErr := encodeBC1Mode(&px, opaque, true)
+	if len(opaque) == 16 && bestErr > 0 {
+		if blk, err := encodeBC1Mode(&px, opaque, false); err < bestErr {
+			best = blk
+		}
+	}
+	return best
+}
+
+// encodeBC1Mode encodes the opaque texels using the three-color mode (threeColor) or the
+// four-color mode and returns the block and its squared error. Texels not listed are transparent.
+func encodeBC1Mode(px *[16][4]float64, opaque []int, threeColor bool) ([BC1BlockBytes]byte, float64) {
+	weights, scale := bc1Weights4[:], 3
+	if threeColor {
+		weights, scale = bc1Weights3[:], 2
+	}
+
+	e0, e1 := fitLine(px, opaque, 3)
+	var best [BC1BlockBytes]byte
+	bestErr := math.Inf(1)
+	for iter := 0; iter < 3; iter++ {
+		c0, c1 := packRGB565(e0), packRGB565(e1)
+
+		// Order the endpoints for the mode; swapping them mirrors the palette.
+		swap := (c0 > c1) == threeColor && c0 != c1
+		if swap {
+			c0, c1 = c1, c0
+		}
+		q0, q1 := unpackRGB565(c0), unpackRGB565(c1)
+
+		var idx [16]int
+		for i := range idx {
+			idx[i] = 3
+		}
+		var err float64
+		for _, t := range opaque {
+			bi, be := 0, math.Inf(1)
+			for i, w := range weights {
+				var d float64
+				for c := 0; c < 3; c++ {
+					v := (q0[c]*float64(scale-w) + q1[c]*float64(w)) / float64(scale)
+					d += (px[t][c] - v) * (px[t][c] - v)
+				}
+				if d < be {
+					bi, be = i, d
+				}
+			}
+			idx[t] = bi
+			err += be
+		}
+		if !threeColor && c0 == c1 {
+			// Equal endpoints decode in three-color mode; index 0 still selects color0.
+			for _, t := range opaque {
+				idx[t] = 0
+			}
+		}
+		if err < bestErr {
+			best, bestErr = packBC1(c0, c1, idx), err
+		}
+		if err == 0 {
+			break
+		}
+		e0, e1 = refineEndpoints(px, opaque, idx[:], weights, scale, 3, q0, q1)
+	}
+	return best, bestErr
+}
+
+func packRGB565(c [4]float64) uint16 {
+	r := uint16(math.Round(c[0] * 31 / 255))
+	g := uint16(math.Round(c[1] * 63 / 255))
+	b := uint16(math.Round(c[2] * 31 / 255))
+	return r<<11 | g<<5 | b
+}
+
+func unpackRGB565(v uint16) [4]float64 {
+	r := int(v>>11) & 31
+	g := int(v>>5) & 63
+	b := int(v) & 31
+	return [4]float64{float64(r<<3 | r>>2), float64(g<<2 | g>>4), float64(b<<3 | b>>2), 255}
+}
+
+func packBC1(c0, c1 uint16, idx [16]int) [BC1BlockBytes]byte {
+	var out [BC1BlockBytes]byte
+	out[0] = byte(c0)
+	out[1] = byte(c0 >> 8)
+	out[2] = byte(c1)
+	out[3] = byte(c1 >> 8)
+	var bits uint32
+	for i := 0; i < 16; i++ {
+		bits |= uint32(idx[i]) << (2 * i)
+	}
+	out[4] = byte(bits)
+	out[5] = byte(bits >> 8)
+	out[6] = byte(bits >> 16)
+	out[7] = byte(bits >> 24)
+	return out
+}
@@ -0,0 +1,301 @@
+package transcode
+
+// BC7 encoder using mode 6 (one subset, RGBA 7.7.7.7 endpoints with per-endpoint p-bits, 4-bit
+// indices) for every block, and mode 1 (two subsets, RGB 6.6.6 endpoints with shared p-bits,
+// 3-bit indices) for opaque blocks where a two-subset split fits better.
+
+// BC7BlockBytes is the size of one encoded BC7 block.
+const BC7BlockBytes = 16
+
+var bc7Weights3 = [8]int{0, 9, 18, 27, 37, 46, 55, 64}
+var bc7Weights4 = [16]int{0, 4, 9, 13, 17, 21, 26, 30, 34, 38, 43, 47, 51, 55, 60, 64}
+
+// bc7Partitions2 holds the BC7 two-subset partition shapes; bit i is set when texel i belongs to
+// subset 1.
+var bc7Partitions2 = [64]uint16{
+	0xCCCC, 0x8888, 0xEEEE, 0xECC8, 0xC880, 0xFEEC, 0xFEC8, 0xEC80,
+	0xC800, 0xFFEC, 0xFE80, 0xE800, 0xFFE8, 0xFF00, 0xFFF0, 0xF000,
+	0xF710, 0x008E, 0x7100, 0x08CE, 0x008C, 0x7310, 0x3100, 0x8CCE,
+	0x088C, 0x3110, 0x6666, 0x366C, 0x17E8, 0x0FF0, 0x718E, 0x399C,
+	0xAAAA, 0xF0F0, 0x5A5A, 0x33CC, 0x3C3C, 0x55AA, 0x9696, 0xA55A,
+	0x73CE, 0x13C8, 0x324C, 0x3BDC, 0x6996, 0xC33C, 0x9966, 0x0660,
+	0x0272, 0x04E4, 0x4E40, 0x2720, 0xC936, 0x936C, 0x39C6, 0x639C,
+	0x9336, 0x9CC6, 0x817E, 0xE718, 0xCCF0, 0x0FCC, 0x7744, 0xEE22,
+}
+
+// bc7Anchors2 is the anchor texel of subset 1 for each two-subset partition.
+var bc7Anchors2 = [64]uint8{
+	15, 15, 15, 15, 15, 15, 15, 15,
+	15, 15, 15, 15, 15, 15, 15, 15,
+	15, 2, 8, 2, 2, 8, 8, 15,
+	2, 8, 2, 2, 8, 8, 2, 2,
+	15, 15, 6, 8, 2, 8, 15, 15,
+	2, 8, 2, 2, 2, 15, 15, 6,
+	6, 2, 6, 8, 15, 15, 2, 2,
+	15, 15, 15, 15, 15, 2, 2, 15,
+}
+
+// bc7Mode1Candidates is the number of best-estimated partitions fully encoded with mode 1.
+const bc7Mode1Candidates = 4
+
+// EncodeBC7Block encodes a 4x4 tile of RGBA8 texels (row-major) into one BC7 block.
+func EncodeBC7Block(texels *[64]byte) [BC7BlockBytes]byte {
+	var px [16][4]float64
+	opaque := true
+	for i := 0; i < 16; i++ {
+		for c := 0; c < 4; c++ {
+			px[i][c] = float64(texels[i*4+c])
+		}
+		if texels[i*4+3] != 255 {
+			opaque = false
+		}
+	}
+
+	best, bestErr := encodeBC7Mode6(&px)
+	if opaque && bestErr > 0 {
+		for _, p := range bc7BestPartitions(&px) {
+			blk, err := encodeBC7Mode1(&px, p)
+			if err < bestErr {
+				best, bestErr = blk, err
+			}
+		}
+	}
+	return best
+}
+
+// encodeBC7Mode6 returns the mode 6 encoding of px and its squared error.
+func encodeBC7Mode6(px *[16][4]float64) ([BC7BlockBytes]byte, float64) {
+	var all [16]int
+	for i := range all {
+		all[i] = i
+	}
+	e0, e1 := fitLine(px, all[:], 4)
+
+	var bestIdx [16]int
+	var bestQ [2][4]int
+	var bestP [2]int
+	bestErr := -1.0
+	for iter := 0; iter < 3; iter++ {
+		// Each endpoint has its own p-bit; try all four combinations.
+		var cand [2][2][4]int
+		var candEp [2][2][4]float64
+		for e, src := range [2][4]float64{e0, e1} {
+			for pb := 0; pb < 2; pb++ {
+				cand[e][pb], candEp[e][pb], _ = quantizeBC7Endpoint(src, 4, 7, pb)
+			}
+		}
+		var ep [2][4]float64
+		var idx [16]int
+		iterErr := -1.0
+		for combo := 0; combo < 4; combo++ {
+			p := [2]int{combo & 1, combo >> 1}
+			var ti [16]int
+			err := assignBC7Indices(px, all[:], candEp[0][p[0]], candEp[1][p[1]], bc7Weights4[:], 4, ti[:])
+			if iterErr < 0 || err < iterErr {
+				iterErr, idx = err, ti
+				ep = [2][4]float64{candEp[0][p[0]], candEp[1][p[1]]}
+			}
+			if bestErr < 0 || err < bestErr {
+				bestErr, bestIdx, bestP = err, ti, p
+				bestQ = [2][4]int{cand[0][p[0]], cand[1][p[1]]}
+			}
+		}
+		if bestErr == 0 {
+			break
+		}
+		e0, e1 = refineEndpoints(px, all[:], idx[:], bc7Weights4[:], 64, 4, ep[0], ep[1])
+	}
+
+	// The anchor index stores one bit less; swap endpoints so its top bit is zero.
+	if bestIdx[0] >= 8 {
+		bestQ[0], bestQ[1] = bestQ[1], bestQ[0]
+		bestP[0], bestP[1] = bestP[1], bestP[0]
+		for i := range bestIdx {
+			bestIdx[i] = 15 - bestIdx[i]
+		}
+	}
+
+	var w bitWriter
+	w.write(1<<6, 7)
+	for c := 0; c < 4; c++ {
+		w.write(uint32(bestQ[0][c]), 7)
+		w.write(uint32(bestQ[1][c]), 7)
+	}
+	w.write(uint32(bestP[0]), 1)
+	w.write(uint32(bestP[1]), 1)
+	for i := 0; i < 16; i++ {
+		bits := 4
+		if i == 0 {
+			bits = 3
+		}
+		w.write(uint32(bestIdx[i]), bits)
+	}
+	return w.b, bestErr
+}
+
+// encodeBC7Mode1 returns the mode 1 encoding of an opaque px using partition part, and its squared
+// error.
+func encodeBC7Mode1(px *[16][4]float64, part int) ([BC7BlockBytes]byte, float64) {
+	subsets := bc7Subsets(part)
+
+	var q [2][2][4]int
+	var p [2]int
+	var idx [16]int
+	var total float64
+	for s := 0; s < 2; s++ {
+		texels := subsets[s]
+		e0, e1 := fitLine(px, texels, 3)
+
+		bestErr := -1.0
+		var subIdx [16]int
+		for iter := 0; iter < 2; iter++ {
+			// The p-bit is shared by both endpoints of a subset; pick the better of the two.
+			for pb := 0; pb < 2; pb++ {
+				var sq [2][4]int
+				var ep [2][4]float64
+				sq[0], ep[0], _ = quantizeBC7Endpoint(e0, 3, 6, pb)
+				sq[1], ep[1], _ = quantizeBC7Endpoint(e1, 3, 6, pb)
+				ep[0][3], ep[1][3] = 255, 255
+
+				var ti [16]int
+				err := assignBC7Indices(px, texels, ep[0], ep[1], bc7Weights3[:], 4, ti[:])
+				if bestErr < 0 || err < bestErr {
+					bestErr = err
+					q[s] = sq
+					p[s] = pb
+					for _, t := range texels {
+						subIdx[t] = ti[t]
+					}
+				}
+			}
+			var ep0, ep1 [4]float64
+			for c := 0; c < 3; c++ {
+				ep0[c] = expandBC7(q[s][0][c]<<1|p[s], 7)
+				ep1[c] = expandBC7(q[s][1][c]<<1|p[s], 7)
+			}
+			e0, e1 = refineEndpoints(px, texels, subIdx[:], bc7Weights3[:], 64, 3, ep0, ep1)
+		}
+		for _, t := range texels {
+			idx[t] = subIdx[t]
+		}
+		total += bestErr
+	}
+
+	// Fix up the anchors of both subsets.
+	anchors := [2]int{0, int(bc7Anchors2[part])}
+	for s := 0; s < 2; s++ {
+		if idx[anchors[s]] >= 4 {
+			q[s][0], q[s][1] = q[s][1], q[s][0]
+			for _, t := range subsets[s] {
+				idx[t] = 7 - idx[t]
+			}
+		}
+	}
+
+	var w bitWriter
+	w.write(1<<1, 2)
+	w.write(uint32(part), 6)
+	for c := 0; c < 3; c++ {
+		for s := 0; s < 2; s++ {
+			w.write(uint32(q[s][0][c]), 6)
+			w.write(uint32(q[s][1][c]), 6)
+		}
+	}
+	w.write(uint32(p[0]), 1)
+	w.write(uint32(p[1]), 1)
+	for i := 0; i < 16; i++ {
+		bits := 3
+		if i == anchors[0] || i == anchors[1] {
+			bits = 2
+		}
+		w.write(uint32(idx[i]), bits)
+	}
+	return w.b, total
+}
+
+// bc7Subsets splits the texel indices of a tile by two-subset partition.
+func bc7Subsets(part int) [2][]int {
+	var out [2][]int
+	mask := bc7Partitions2[part]
+	for i := 0; i < 16; i++ {
+		s := int(mask>>i) & 1
+		out[s] = append(out[s], i)
+	}
+	return out
+}
+
+// bc7BestPartitions ranks the two-subset partitions by how well each subset fits a line in RGB
+// space and returns the best few.
+func bc7BestPartitions(px *[16][4]float64) []int {
+	var best [bc7Mode1Candidates]int
+	var bestErr [bc7Mode1Candidates]float64
+	n := 0
+	for part := 0; part < 64; part++ {
+		subsets := bc7Subsets(part)
+		err := lineFitResidual(px, subsets[0], 3) + lineFitResidual(px, subsets[1], 3)
+
+		pos := n
+		for pos > 0 && bestErr[pos-1] > err {
+			pos--
+		}
+		if pos >= bc7Mode1Candidates {
+			continue
+		}
+		if n < bc7Mode1Candidates {
+			n++
+		}
+		for i := n - 1; i > pos; i-- {
+			best[i], bestErr[i] = best[i-1], bestErr[i-1]
+		}
+		best[pos], bestErr[pos] = part, err
+	}
+	return best[:n]
+}
+
+// quantizeBC7Endpoint quantizes the first channels of a color to bits-wide values for p-bit p,
+// returning the quantized values, the reconstructed 8-bit color and the squared error.
+func quantizeBC7Endpoint(v [4]float64, channels, bits, p int) (q [4]int, out [4]float64, err float64) {
+	maxQ := (1 << bits) - 1
+	step := float64(int(1) << (8 - bits))
+	for c := 0; c < channels; c++ {
+		x := int(v[c]/step + 0.5)
+
+		// Reconstruction replicates the top bits, so check the neighbors of the rounded value.
+		bestX, bestD := 0, -1.0
+		for cand := x - 1; cand <= x+1; cand++ {
+			if cand < 0 || cand > maxQ {
+				continue
+			}
+			r := expandBC7(cand<<1|p, bits+1)
+			d := (r - v[c]) * (r - v[c])
+			if bestD < 0 || d < bestD {
+				bestX, bestD = cand, d
+			}
+		}
+		q[c] = bestX
+		out[c] = expandBC7(bestX<<1|p, bits+1)
+		err += bestD
+	}
+	return q, out, err
+}
+
+// expandBC7 expands a bits-wide endpoint value to 8 bits by replicating its top bits.
+func expandBC7(v, bits int) float64 {
+	v <<= 8 - bits
+	return float64(v | v>>bits)
+}
+
+// bitWriter packs fields LSB-first into a 128-bit block.
+type bitWriter struct {
+	b   [16]byte
+	pos int
+}
+
+func (w *bitWriter) write(v uint32, n int) {
+	for i := 0; i < n; i++ {
+		if v>>i&1 != 0 {
+			w.b[w.pos>>3] |= 1 << (w.pos & 7)
+		}
+		w.pos++
+	}
+}
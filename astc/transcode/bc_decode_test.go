@@ -0,0 +1,212 @@
+package transcode
+
+import (
+	"math"
+	"testing"
+)
+
+// Reference decoders for the BC7 modes and BC1 layouts produced by the encoders.
+
+type bitReader struct {
+	b   [16]byte
+	pos int
+}
+
+func (r *bitReader) read(n int) int {
+	v := 0
+	for i := 0; i < n; i++ {
+		v |= int(r.b[r.pos>>3]>>(r.pos&7)&1) << i
+		r.pos++
+	}
+	return v
+}
+
+func decodeBC7Block(t *testing.T, blk [BC7BlockBytes]byte) (out [64]byte) {
+	t.Helper()
+	r := bitReader{b: blk}
+	mode := 0
+	for r.read(1) == 0 {
+		mode++
+	}
+	interp := func(e0, e1, w int) byte { return byte((e0*(64-w) + e1*w + 32) >> 6) }
+
+	switch mode {
+	case 6:
+		var e [2][4]int
+		for c := 0; c < 4; c++ {
+			e[0][c] = r.read(7)
+			e[1][c] = r.read(7)
+		}
+		p0, p1 := r.read(1), r.read(1)
+		for c := 0; c < 4; c++ {
+			e[0][c] = e[0][c]<<1 | p0
+			e[1][c] = e[1][c]<<1 | p1
+		}
+		for i := 0; i < 16; i++ {
+			bits := 4
+			if i == 0 {
+				bits = 3
+			}
+			w := bc7Weights4[r.read(bits)]
+			for c := 0; c < 4; c++ {
+				out[i*4+c] = interp(e[0][c], e[1][c], w)
+			}
+		}
+	case 1:
+		part := r.read(6)
+		var e [2][2][3]int
+		for c := 0; c < 3; c++ {
+			for s := 0; s < 2; s++ {
+				e[s][0][c] = r.read(6)
+				e[s][1][c] = r.read(6)
+			}
+		}
+		p := [2]int{r.read(1), r.read(1)}
+		for s := 0; s < 2; s++ {
+			for c := 0; c < 3; c++ {
+				for k := 0; k < 2; k++ {
+					v := e[s][k][c]<<1 | p[s]
+					e[s][k][c] = v<<1 | v>>6
+				}
+			}
+		}
+		anchor := int(bc7Anchors2[part])
+		for i := 0; i < 16; i++ {
+			bits := 3
+			if i == 0 || i == anchor {
+				bits = 2
+			}
+			w := bc7Weights3[r.read(bits)]
+			s := int(bc7Partitions2[part]>>i) & 1
+			for c := 0; c < 3; c++ {
+				out[i*4+c] = interp(e[s][0][c], e[s][1][c], w)
+			}
+			out[i*4+3] = 255
+		}
+	default:
+		t.Fatalf("unexpected BC7 mode %d", mode)
+	}
+	return out
+}
+
+func decodeBC1Block(blk [BC1BlockBytes]byte) (out [64]byte) {
+	c0 := uint16(blk[0]) | uint16(blk[1])<<8
+	c1 := uint16(blk[2]) | uint16(blk[3])<<8
+	q0, q1 := unpackRGB565(c0), unpackRGB565(c1)
+	var palette [4][4]float64
+	palette[0], palette[1] = q0, q1
+	for c := 0; c < 3; c++ {
+		if c0 > c1 {
+			palette[2][c] = (2*q0[c] + q1[c]) / 3
+			palette[3][c] = (q0[c] + 2*q1[c]) / 3
+		} else {
+			palette[2][c] = (q0[c] + q1[c]) / 2
+		}
+	}
+	palette[2][3] = 255
+	if c0 > c1 {
+		palette[3][3] = 255
+	}
+	bits := uint32(blk[4]) | uint32(blk[5])<<8 | uint32(blk[6])<<16 | uint32(blk[7])<<24
+	for i := 0; i < 16; i++ {
+		p := palette[bits>>(2*i)&3]
+		for c := 0; c < 4; c++ {
+			out[i*4+c] = byte(math.Round(p[c]))
+		}
+	}
+	return out
+}
+
+func tileError(a, b *[64]byte) float64 {
+	var sum float64
+	for i := range a {
+		d := float64(a[i]) - float64(b[i])
+		sum += d * d
+	}
+	return sum
+}
+
+func testTiles() [][64]byte {
+	seed := uint32(1)
+	next := func() int {
+		seed = seed*1664525 + 1013904223
+		return int(seed >> 24)
+	}
+	var tiles [][64]byte
+	for n := 0; n < 300; n++ {
+		var tile [64]byte
+		kind := n % 3
+		a, b := [4]int{next(), next(), next(), next()}, [4]int{next(), next(), next(), next()}
+		for i := 0; i < 16; i++ {
+			x, y := i%4, i/4
+			for c := 0; c < 4; c++ {
+				var v int
+				switch kind {
+				case 0: // Gradient with noise.
+					v = a[c] + (b[c]-a[c])*(x+y)/6 + next()%9 - 4
+				case 1: // Hard edge between two colors.
+					v = a[c]
+					if x > y {
+						v = b[c]
+					}
+				default: // Noise.
+					v = next()
+				}
+				if c == 3 && n%2 == 0 {
+					v = 255
+				}
+				tile[i*4+c] = byte(min(max(v, 0), 255))
+			}
+		}
+		tiles = append(tiles, tile)
+	}
+	return tiles
+}
+
+func TestEncodeBC7Block_Quality(t *testing.T) {
+	var total float64
+	for i, tile := range testTiles() {
+		dec := decodeBC7Block(t, EncodeBC7Block(&tile))
+		total += tileError(&tile, &dec)
+		if i%3 != 2 && tileError(&tile, &dec) > 16*4*64 {
+			t.Fatalf("tile %d: error too high (%v)\nin  %v\nout %v", i, tileError(&tile, &dec), tile, dec)
+		}
+	}
+
+	var flat [64]byte
+	for i := 0; i < 16; i++ {
+		copy(flat[i*4:], []byte{12, 200, 77, 255})
+	}
+	// Mode 6 cannot represent every constant exactly, but must come within one step.
+	dec := decodeBC7Block(t, EncodeBC7Block(&flat))
+	for i := range flat {
+		if d := int(dec[i]) - int(flat[i]); d > 1 || d < -1 {
+			t.Fatalf("constant tile off by more than 1: %v", dec[:4])
+		}
+	}
+}
+
+func TestEncodeBC1Block_Quality(t *testing.T) {
+	for i, tile := range testTiles() {
+		if i%3 == 2 {
+			continue
+		}
+		dec := decodeBC1Block(EncodeBC1Block(&tile))
+		for p := 0; p < 16; p++ {
+			if (tile[p*4+3] >= bc1AlphaThreshold) != (dec[p*4+3] == 255) {
+				t.Fatalf("tile %d texel %d: alpha %d decoded as %d", i, p, tile[p*4+3], dec[p*4+3])
+			}
+		}
+	}
+
+	var tile [64]byte
+	for i := 0; i < 16; i++ {
+		v := byte(i * 16)
+		copy(tile[i*4:], []byte{v, v, v, 255})
+	}
+	// Four evenly spaced levels over 0..240 give an RMS error of about 23.
+	dec := decodeBC1Block(EncodeBC1Block(&tile))
+	if rms := math.Sqrt(tileError(&tile, &dec) / 48); rms > 26 {
+		t.Fatalf("gradient RMS error too high: %v\nout %v", rms, dec)
+	}
+}
@@ -0,0 +1,9 @@
+// Package transcode converts ASTC images into BC7 or BC1 (DXT1) blocks so that platforms without
+// ASTC hardware support can consume the same assets.
+//
+// Transcoding decodes the ASTC payload one row of blocks at a time and re-encodes each 4x4 tile,
+// so memory use is bounded by a strip of the image rather than the whole decoded image.
+//
+// Only LDR profiles are supported. For ProfileLDRSRGB the sRGB-encoded texels are transcoded as-is
+// and the output is intended for the sRGB BC7/BC1 formats.
+package transcode
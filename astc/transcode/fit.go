@@ -0,0 +1,176 @@
+package transcode
+
+import "math"
+
+// Endpoint fitting shared by the BC7 and BC1 encoders. Texels are float64 RGBA in 0..255 and the
+// first `channels` channels take part in the fit.
+
+// principalAxis returns the mean and the dominant direction of the texels, plus the unnormalized
+// covariance trace and largest eigenvalue.
+func principalAxis(px *[16][4]float64, texels []int, channels int) (mean, axis [4]float64, trace, lambda float64) {
+	if len(texels) == 0 {
+		return mean, axis, 0, 0
+	}
+	for _, t := range texels {
+		for c := 0; c < channels; c++ {
+			mean[c] += px[t][c]
+		}
+	}
+	inv := 1 / float64(len(texels))
+	for c := 0; c < channels; c++ {
+		mean[c] *= inv
+	}
+
+	var cov [4][4]float64
+	for _, t := range texels {
+		var d [4]float64
+		for c := 0; c < channels; c++ {
+			d[c] = px[t][c] - mean[c]
+		}
+		for i := 0; i < channels; i++ {
+			for j := i; j < channels; j++ {
+				cov[i][j] += d[i] * d[j]
+			}
+		}
+	}
+	for i := 0; i < channels; i++ {
+		trace += cov[i][i]
+		for j := 0; j < i; j++ {
+			cov[i][j] = cov[j][i]
+		}
+	}
+	if trace == 0 {
+		return mean, axis, 0, 0
+	}
+
+	// Power iteration, seeded with the channel of largest variance.
+	seed := 0
+	for c := 1; c < channels; c++ {
+		if cov[c][c] > cov[seed][seed] {
+			seed = c
+		}
+	}
+	axis[seed] = 1
+	for iter := 0; iter < 8; iter++ {
+		var next [4]float64
+		for i := 0; i < channels; i++ {
+			for j := 0; j < channels; j++ {
+				next[i] += cov[i][j] * axis[j]
+			}
+		}
+		var norm float64
+		for c := 0; c < channels; c++ {
+			norm += next[c] * next[c]
+		}
+		if norm == 0 {
+			break
+		}
+		norm = 1 / math.Sqrt(norm)
+		for c := 0; c < channels; c++ {
+			axis[c] = next[c] * norm
+		}
+	}
+	for i := 0; i < channels; i++ {
+		for j := 0; j < channels; j++ {
+			lambda += axis[i] * cov[i][j] * axis[j]
+		}
+	}
+	return mean, axis, trace, lambda
+}
+
+// fitLine returns endpoints spanning the texels' projection onto their principal axis.
+func fitLine(px *[16][4]float64, texels []int, channels int) (e0, e1 [4]float64) {
+	mean, axis, _, _ := principalAxis(px, texels, channels)
+	minT, maxT := math.Inf(1), math.Inf(-1)
+	for _, t := range texels {
+		var proj float64
+		for c := 0; c < channels; c++ {
+			proj += (px[t][c] - mean[c]) * axis[c]
+		}
+		minT = math.Min(minT, proj)
+		maxT = math.Max(maxT, proj)
+	}
+	if len(texels) == 0 {
+		minT, maxT = 0, 0
+	}
+	for c := 0; c < channels; c++ {
+		e0[c] = clamp255(mean[c] + axis[c]*minT)
+		e1[c] = clamp255(mean[c] + axis[c]*maxT)
+	}
+	return e0, e1
+}
+
+// lineFitResidual returns the squared distance of the texels from their principal line.
+func lineFitResidual(px *[16][4]float64, texels []int, channels int) float64 {
+	_, _, trace, lambda := principalAxis(px, texels, channels)
+	return math.Max(trace-lambda, 0)
+}
+
+// assignBC7Indices picks the nearest interpolated color for each texel, using BC7 6-bit
+// weights, writes the index to idx[t] and returns the summed squared error.
+func assignBC7Indices(px *[16][4]float64, texels []int, e0, e1 [4]float64, weights []int, channels int, idx []int) float64 {
+	var palette [16][4]float64
+	for i, w := range weights {
+		for c := 0; c < channels; c++ {
+			palette[i][c] = float64((int(e0[c])*(64-w) + int(e1[c])*w + 32) >> 6)
+		}
+	}
+
+	var total float64
+	for _, t := range texels {
+		best, bestErr := 0, math.Inf(1)
+		for i := range weights {
+			var err float64
+			for c := 0; c < channels; c++ {
+				d := px[t][c] - palette[i][c]
+				err += d * d
+			}
+			if err < bestErr {
+				best, bestErr = i, err
+			}
+		}
+		idx[t] = best
+		total += bestErr
+	}
+	return total
+}
+
+// refineEndpoints solves for the endpoints that minimize the squared error of the texels given
+// their palette indices, where index i interpolates weights[i]/scale of the way from e0 to e1. If
+// the system is degenerate (all texels on one index) e0/e1 are returned.
+func refineEndpoints(px *[16][4]float64, texels []int, idx []int, weights []int, scale int, channels int, e0, e1 [4]float64) (r0, r1 [4]float64) {
+	maxW := float64(scale)
+	var aa, ab, bb float64
+	var ax, bx [4]float64
+	for _, t := range texels {
+		b := float64(weights[idx[t]]) / maxW
+		a := 1 - b
+		aa += a * a
+		ab += a * b
+		bb += b * b
+		for c := 0; c < channels; c++ {
+			ax[c] += a * px[t][c]
+			bx[c] += b * px[t][c]
+		}
+	}
+	det := aa*bb - ab*ab
+	if math.Abs(det) < 1e-9 {
+		return e0, e1
+	}
+	r0, r1 = e0, e1
+	for c := 0; c < channels; c++ {
+		r0[c] = clamp255((ax[c]*bb - bx[c]*ab) / det)
+		r1[c] = clamp255((bx[c]*aa - ax[c]*ab) / det)
+	}
+	return r0, r1
+}
+
+func clamp255(v float64) float64 {
+	if v < 0 {
+		return 0
+	}
+	if v > 255 {
+		return 255
+	}
+	return v
+}
@@ -0,0 +1,150 @@
+package transcode
+
+import (
+	"errors"
+
+	"github.com/arm-software/astc-encoder/astc"
+)
+
+// Format selects the block-compressed output format.
+type Format uint8
+
+const (
+	// FormatBC7 produces BC7 (BPTC) blocks, 16 bytes per 4x4 tile.
+	FormatBC7 Format = iota
+	// FormatBC1 produces BC1 (DXT1) blocks, 8 bytes per 4x4 tile. Use it where BC7 is unavailable;
+	// alpha is reduced to 1-bit transparency.
+	FormatBC1
+)
+
+// BlockBytes returns the size of one encoded block in format f, or 0 for an unknown format.
+func BlockBytes(f Format) int {
+	switch f {
+	case FormatBC7:
+		return BC7BlockBytes
+	case FormatBC1:
+		return BC1BlockBytes
+	default:
+		return 0
+	}
+}
+
+// EncodedSize returns the number of bytes needed to hold a width x height image in format f.
+// Blocks are stored in row-major order, with partial edge tiles padded by edge replication.
+func EncodedSize(width, height int, f Format) int {
+	return ((width + 3) / 4) * ((height + 3) / 4) * BlockBytes(f)
+}
+
+// Transcode converts the ASTC blocks of a 2D image (as returned by astc.ParseFile) into format f,
+// writing EncodedSize(h.SizeX, h.SizeY, f) bytes to dst.
+//
+// Only one or two rows of ASTC blocks are decoded at a time. dst must not overlap blocks.
+func Transcode(h astc.Header, blocks []byte, profile astc.Profile, f Format, dst []byte) error {
+	if profile != astc.ProfileLDR && profile != astc.ProfileLDRSRGB {
+		return errors.New("astc/transcode: only LDR profiles are supported")
+	}
+	if h.SizeZ != 1 || h.BlockZ != 1 {
+		return errors.New("astc/transcode: only 2D images are supported")
+	}
+	blockBytes := BlockBytes(f)
+	if blockBytes == 0 {
+		return errors.New("astc/transcode: unknown output format")
+	}
+	blocksX, _, _, total, err := h.BlockCount()
+	if err != nil {
+		return err
+	}
+	if len(blocks) < total*astc.BlockBytes {
+		return errors.New("astc/transcode: block payload too short")
+	}
+	width, height := int(h.SizeX), int(h.SizeY)
+	if len(dst) < EncodedSize(width, height, f) {
+		return errors.New("astc/transcode: output buffer too small")
+	}
+
+	s := strips{
+		h:       h,
+		blocks:  blocks,
+		profile: profile,
+		rowLen:  blocksX * astc.BlockBytes,
+	}
+	for i := range s.rows {
+		s.rows[i].index = -1
+		s.rows[i].pix = make([]byte, width*int(h.BlockY)*4)
+	}
+
+	var tile [64]byte
+	out := dst
+	for ty := 0; ty < height; ty += 4 {
+		for tx := 0; tx < width; tx += 4 {
+			for y := 0; y < 4; y++ {
+				row, err := s.row(min(ty+y, height-1))
+				if err != nil {
+					return err
+				}
+				for x := 0; x < 4; x++ {
+					sx := min(tx+x, width-1)
+					copy(tile[(y*4+x)*4:(y*4+x)*4+4], row[sx*4:sx*4+4])
+				}
+			}
+			switch f {
+			case FormatBC7:
+				blk := EncodeBC7Block(&tile)
+				copy(out, blk[:])
+			case FormatBC1:
+				blk := EncodeBC1Block(&tile)
+				copy(out, blk[:])
+			}
+			out = out[blockBytes:]
+		}
+	}
+	return nil
+}
+
+// TranscodeFile parses a .astc file and transcodes it into format f. It returns the encoded blocks
+// and the image dimensions.
+func TranscodeFile(astcData []byte, profile astc.Profile, f Format) (out []byte, width, height int, err error) {
+	h, blocks, err := astc.ParseFile(astcData)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	width, height = int(h.SizeX), int(h.SizeY)
+	out = make([]byte, EncodedSize(width, height, f))
+	if err := Transcode(h, blocks, profile, f, out); err != nil {
+		return nil, 0, 0, err
+	}
+	return out, width, height, nil
+}
+
+// strips caches decoded rows of ASTC blocks. A 4-texel tile row spans at most two ASTC block rows
+// since ASTC blocks are at least 4 texels tall.
+type strips struct {
+	h       astc.Header
+	blocks  []byte
+	profile astc.Profile
+	rowLen  int
+
+	rows [2]struct {
+		index int
+		pix   []byte
+	}
+}
+
+// row returns the decoded RGBA8 texels of image row y.
+func (s *strips) row(y int) ([]byte, error) {
+	blockY := int(s.h.BlockY)
+	by := y / blockY
+	slot := &s.rows[by&1]
+	if slot.index != by {
+		rowHeader := s.h
+		rowHeader.SizeY = uint32(min(blockY, int(s.h.SizeY)-by*blockY))
+		src := s.blocks[by*s.rowLen : (by+1)*s.rowLen]
+		if err := astc.DecodeRGBA8VolumeFromParsedWithProfileInto(s.profile, rowHeader, src, slot.pix); err != nil {
+			return nil, err
+		}
+		slot.index = by
+	}
+	width := int(s.h.SizeX)
+	off := (y - by*blockY) * width * 4
+	return slot.pix[off : off+width*4], nil
+}
@@ -0,0 +1,66 @@
+package transcode_test
+
+import (
+	"testing"
+
+	"github.com/arm-software/astc-encoder/astc"
+	"github.com/arm-software/astc-encoder/astc/transcode"
+)
+
+func TestTranscode_SizesAndErrors(t *testing.T) {
+	const w, h = 18, 13
+	pix := make([]byte, w*h*4)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			off := (y*w + x) * 4
+			pix[off+0] = uint8(x * 14)
+			pix[off+1] = uint8(y * 19)
+			pix[off+2] = uint8(255 - x*7)
+			pix[off+3] = 255
+		}
+	}
+	data, err := astc.EncodeRGBA8(pix, w, h, 6, 6)
+	if err != nil {
+		t.Fatalf("EncodeRGBA8: %v", err)
+	}
+
+	for _, f := range []transcode.Format{transcode.FormatBC7, transcode.FormatBC1} {
+		out, gw, gh, err := transcode.TranscodeFile(data, astc.ProfileLDR, f)
+		if err != nil {
+			t.Fatalf("format %d: TranscodeFile: %v", f, err)
+		}
+		if gw != w || gh != h {
+			t.Fatalf("format %d: unexpected size %dx%d", f, gw, gh)
+		}
+		if want := 5 * 4 * transcode.BlockBytes(f); len(out) != want || transcode.EncodedSize(w, h, f) != want {
+			t.Fatalf("format %d: got %d bytes, want %d", f, len(out), want)
+		}
+	}
+
+	hdr, blocks, err := astc.ParseFile(data)
+	if err != nil {
+		t.Fatalf("ParseFile: %v", err)
+	}
+	dst := make([]byte, transcode.EncodedSize(w, h, transcode.FormatBC7))
+	if err := transcode.Transcode(hdr, blocks, astc.ProfileHDR, transcode.FormatBC7, dst); err == nil {
+		t.Fatalf("expected error for HDR profile")
+	}
+	if err := transcode.Transcode(hdr, blocks, astc.ProfileLDR, transcode.FormatBC7, dst[:len(dst)-1]); err == nil {
+		t.Fatalf("expected error for short output buffer")
+	}
+	if err := transcode.Transcode(hdr, blocks[:len(blocks)-1], astc.ProfileLDR, transcode.FormatBC7, dst); err == nil {
+		t.Fatalf("expected error for short block payload")
+	}
+}
+
+func TestEncodeBC7Block_ConstantTileIsDeterministic(t *testing.T) {
+	var tile [64]byte
+	for i := 0; i < 16; i++ {
+		copy(tile[i*4:], []byte{40, 80, 120, 200})
+	}
+	a := transcode.EncodeBC7Block(&tile)
+	b := transcode.EncodeBC7Block(&tile)
+	if a != b {
+		t.Fatalf("non-deterministic output")
+	}
+}
@@ -0,0 +1,146 @@
+package astc
+
+import "errors"
+
+// VoidExtent describes the extent coordinates stored in an ASTC void-extent (constant-color) block.
+//
+// A void-extent block may record the region of the texture, in normalized texture coordinates, over
+// which the color is constant. Encoders that do not track this (including this package and
+// upstream astcenc) write the "no extent" encoding, for which HasExtent is false.
+type VoidExtent struct {
+	// HDR reports an FP16 constant color; otherwise the color is UNORM16.
+	HDR bool
+	// Is3D reports the 3D void-extent layout, used by blocks with BlockZ > 1.
+	Is3D bool
+	// HasExtent reports whether the coordinates below are meaningful.
+	HasExtent bool
+
+	// Low and High are the raw fixed-point S, T and P coordinates. 2D blocks store 13-bit values and
+	// leave P zero; 3D blocks store 9-bit values.
+	Low  [3]uint16
+	High [3]uint16
+}
+
+// S returns the S range in normalized texture coordinates (0..1). Without an extent it returns the
+// whole texture.
+func (v VoidExtent) S() (lo, hi float32) { return v.axis(0) }
+
+// T returns the T range in normalized texture coordinates (0..1). Without an extent it returns the
+// whole texture.
+func (v VoidExtent) T() (lo, hi float32) { return v.axis(1) }
+
+// P returns the P (depth) range in normalized texture coordinates (0..1). For 2D blocks, and
+// without an extent, it returns the whole texture.
+func (v VoidExtent) P() (lo, hi float32) {
+	if !v.Is3D {
+		return 0, 1
+	}
+	return v.axis(2)
+}
+
+func (v VoidExtent) axis(i int) (lo, hi float32) {
+	if !v.HasExtent {
+		return 0, 1
+	}
+	scale := float32(1.0 / 0x1FFF)
+	if v.Is3D {
+		scale = 1.0 / 0x1FF
+	}
+	return float32(v.Low[i]) * scale, float32(v.High[i]) * scale
+}
+
+// IsVoidExtentBlock reports whether block is a void-extent (constant-color) block, valid or not.
+func IsVoidExtentBlock(block []byte) bool {
+	return len(block) >= BlockBytes && readBits(9, 0, block) == 0x1FC
+}
+
+// DecodeVoidExtent returns the extent coordinates of a void-extent block for a block footprint with
+// depth blockZ. It fails for short inputs, other block types, and void-extent blocks the decoder
+// treats as errors (malformed coordinates or reserved bits).
+func DecodeVoidExtent(block []byte, blockZ int) (VoidExtent, error) {
+	if len(block) < BlockBytes {
+		return VoidExtent{}, ioErrUnexpectedEOF("astc block", BlockBytes, len(block))
+	}
+	if !IsVoidExtentBlock(block) {
+		return VoidExtent{}, errors.New("astc: not a void-extent block")
+	}
+
+	v := VoidExtent{HDR: readBits(1, 9, block) != 0, Is3D: blockZ > 1}
+	if !v.Is3D {
+		if readBits(2, 10, block) != 3 {
+			return VoidExtent{}, errors.New("astc: invalid void-extent block: reserved bits not set")
+		}
+		v.Low[0] = uint16(readBits(8, 12, block) | readBits(5, 20, block)<<8)
+		v.High[0] = uint16(readBits(8, 25, block) | readBits(5, 33, block)<<8)
+		v.Low[1] = uint16(readBits(8, 38, block) | readBits(5, 46, block)<<8)
+		v.High[1] = uint16(readBits(8, 51, block) | readBits(5, 59, block)<<8)
+		allOnes := v.Low[0] == 0x1FFF && v.High[0] == 0x1FFF && v.Low[1] == 0x1FFF && v.High[1] == 0x1FFF
+		if allOnes {
+			return VoidExtent{HDR: v.HDR}, nil
+		}
+		if v.Low[0] >= v.High[0] || v.Low[1] >= v.High[1] {
+			return VoidExtent{}, errors.New("astc: invalid void-extent block: empty extent")
+		}
+		v.HasExtent = true
+		return v, nil
+	}
+
+	for i := 0; i < 3; i++ {
+		v.Low[i] = uint16(readBits(9, 10+18*i, block))
+		v.High[i] = uint16(readBits(9, 19+18*i, block))
+	}
+	allOnes := true
+	for i := 0; i < 3; i++ {
+		allOnes = allOnes && v.Low[i] == 0x1FF && v.High[i] == 0x1FF
+	}
+	if allOnes {
+		return VoidExtent{HDR: v.HDR, Is3D: true}, nil
+	}
+	for i := 0; i < 3; i++ {
+		if v.Low[i] >= v.High[i] {
+			return VoidExtent{}, errors.New("astc: invalid void-extent block: empty extent")
+		}
+	}
+	v.HasExtent = true
+	return v, nil
+}
+
+// VoidExtentBlock is one void-extent block found by VoidExtentBlocks.
+type VoidExtentBlock struct {
+	// X, Y and Z are the block coordinates (in blocks, not texels).
+	X, Y, Z int
+	Extent  VoidExtent
+}
+
+// VoidExtentBlocks enumerates the valid void-extent blocks of an encoded image, in file order.
+// Streaming systems can use the result to skip residency for constant regions.
+//
+// The blocks slice is the block payload as returned by ParseFile.
+func VoidExtentBlocks(h Header, blocks []byte) ([]VoidExtentBlock, error) {
+	blocksX, blocksY, _, total, err := h.BlockCount()
+	if err != nil {
+		return nil, err
+	}
+	if len(blocks) < total*BlockBytes {
+		return nil, ioErrUnexpectedEOF("astc blocks", total*BlockBytes, len(blocks))
+	}
+
+	var out []VoidExtentBlock
+	for i := 0; i < total; i++ {
+		block := blocks[i*BlockBytes : (i+1)*BlockBytes]
+		if !IsVoidExtentBlock(block) {
+			continue
+		}
+		v, err := DecodeVoidExtent(block, int(h.BlockZ))
+		if err != nil {
+			continue
+		}
+		out = append(out, VoidExtentBlock{
+			X:      i % blocksX,
+			Y:      (i / blocksX) % blocksY,
+			Z:      i / (blocksX * blocksY),
+			Extent: v,
+		})
+	}
+	return out, nil
+}
@@ -0,0 +1,131 @@
+package astc_test
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/arm-software/astc-encoder/astc"
+)
+
+// withVoidExtent2D returns a copy of a constant-color block with the given 13-bit S/T extent.
+func withVoidExtent2D(block [astc.BlockBytes]byte, lowS, highS, lowT, highT uint64) [astc.BlockBytes]byte {
+	w := binary.LittleEndian.Uint64(block[:8]) & 0xFFF
+	w |= lowS<<12 | highS<<25 | lowT<<38 | highT<<51
+	binary.LittleEndian.PutUint64(block[:8], w)
+	return block
+}
+
+func TestDecodeVoidExtent_2D(t *testing.T) {
+	plain := astc.EncodeConstBlockRGBA8(1, 2, 3, 4)
+	v, err := astc.DecodeVoidExtent(plain[:], 1)
+	if err != nil {
+		t.Fatalf("DecodeVoidExtent: %v", err)
+	}
+	if v.HasExtent || v.HDR || v.Is3D {
+		t.Fatalf("unexpected extent for encoder output: %+v", v)
+	}
+	if lo, hi := v.S(); lo != 0 || hi != 1 {
+		t.Fatalf("S() without extent = %v,%v want 0,1", lo, hi)
+	}
+
+	blk := withVoidExtent2D(plain, 0, 0x1FFF, 0x800, 0x1000)
+	v, err = astc.DecodeVoidExtent(blk[:], 1)
+	if err != nil {
+		t.Fatalf("DecodeVoidExtent: %v", err)
+	}
+	if !v.HasExtent || v.Low != [3]uint16{0, 0x800, 0} || v.High != [3]uint16{0x1FFF, 0x1000, 0} {
+		t.Fatalf("unexpected extent: %+v", v)
+	}
+	if lo, hi := v.S(); lo != 0 || hi != 1 {
+		t.Fatalf("S() = %v,%v want 0,1", lo, hi)
+	}
+	if lo, hi := v.T(); lo < 0.25 || lo > 0.2501 || hi < 0.5 || hi > 0.5001 {
+		t.Fatalf("T() = %v,%v want ~0.25,~0.5", lo, hi)
+	}
+	if lo, hi := v.P(); lo != 0 || hi != 1 {
+		t.Fatalf("P() for a 2D block = %v,%v want 0,1", lo, hi)
+	}
+
+	// The decoder treats an empty extent as an error block.
+	bad := withVoidExtent2D(plain, 0x100, 0x100, 0, 0x10)
+	if _, err := astc.DecodeVoidExtent(bad[:], 1); err == nil {
+		t.Fatalf("expected error for empty extent")
+	}
+	out := make([]byte, 4*4*4)
+	if err := astc.DecodeRGBA8VolumeFromParsedWithProfileInto(astc.ProfileLDR, astc.Header{BlockX: 4, BlockY: 4, BlockZ: 1, SizeX: 4, SizeY: 4, SizeZ: 1}, bad[:], out); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if out[0] != 0xFF || out[1] != 0 || out[2] != 0xFF {
+		t.Fatalf("expected error color for empty extent, got %v", out[:4])
+	}
+}
+
+func TestDecodeVoidExtent_3DAndErrors(t *testing.T) {
+	blk := astc.EncodeConstBlockRGBA8(5, 6, 7, 8)
+	v, err := astc.DecodeVoidExtent(blk[:], 4)
+	if err != nil {
+		t.Fatalf("DecodeVoidExtent: %v", err)
+	}
+	if v.HasExtent || !v.Is3D {
+		t.Fatalf("unexpected extent: %+v", v)
+	}
+
+	// 9-bit S/T/P extents at bits 10, 28 and 46.
+	w := binary.LittleEndian.Uint64(blk[:8]) & 0x3FF
+	w |= (0 | 511<<9) << 10
+	w |= (0 | 255<<9) << 28
+	w |= (1 | 2<<9) << 46
+	binary.LittleEndian.PutUint64(blk[:8], w)
+	v, err = astc.DecodeVoidExtent(blk[:], 4)
+	if err != nil {
+		t.Fatalf("DecodeVoidExtent: %v", err)
+	}
+	if !v.HasExtent || v.Low != [3]uint16{0, 0, 1} || v.High != [3]uint16{511, 255, 2} {
+		t.Fatalf("unexpected extent: %+v", v)
+	}
+	if lo, hi := v.P(); lo != 1.0/511 || hi != 2.0/511 {
+		t.Fatalf("P() = %v,%v", lo, hi)
+	}
+
+	hdr := astc.EncodeConstBlockF16(0x3C00, 0, 0, 0x3C00)
+	if v, err := astc.DecodeVoidExtent(hdr[:], 1); err != nil || !v.HDR {
+		t.Fatalf("DecodeVoidExtent(F16) = %+v, %v", v, err)
+	}
+	if _, err := astc.DecodeVoidExtent(blk[:8], 1); err == nil {
+		t.Fatalf("expected error for short block")
+	}
+	var nonConst [astc.BlockBytes]byte
+	if astc.IsVoidExtentBlock(nonConst[:]) {
+		t.Fatalf("zero block reported as void-extent")
+	}
+	if _, err := astc.DecodeVoidExtent(nonConst[:], 1); err == nil {
+		t.Fatalf("expected error for non-void-extent block")
+	}
+}
+
+func TestVoidExtentBlocks(t *testing.T) {
+	h := astc.Header{BlockX: 4, BlockY: 4, BlockZ: 1, SizeX: 12, SizeY: 8, SizeZ: 1}
+	blocks := make([]byte, 6*astc.BlockBytes)
+	c := astc.EncodeConstBlockRGBA8(9, 9, 9, 9)
+	copy(blocks[1*astc.BlockBytes:], c[:])
+	e := withVoidExtent2D(c, 0x100, 0x200, 0x300, 0x400)
+	copy(blocks[5*astc.BlockBytes:], e[:])
+
+	got, err := astc.VoidExtentBlocks(h, blocks)
+	if err != nil {
+		t.Fatalf("VoidExtentBlocks: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d blocks want 2", len(got))
+	}
+	if got[0].X != 1 || got[0].Y != 0 || got[0].Extent.HasExtent {
+		t.Fatalf("unexpected first block: %+v", got[0])
+	}
+	if got[1].X != 2 || got[1].Y != 1 || !got[1].Extent.HasExtent {
+		t.Fatalf("unexpected second block: %+v", got[1])
+	}
+
+	if _, err := astc.VoidExtentBlocks(h, blocks[:len(blocks)-1]); err == nil {
+		t.Fatalf("expected error for short payload")
+	}
+}
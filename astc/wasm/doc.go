@@ -0,0 +1,22 @@
+// Package wasm exposes the pure-Go ASTC codec to JavaScript when built for GOOS=js GOARCH=wasm,
+// so web-based asset preview tools can decode (and encode) .astc files client-side without an
+// emscripten build of the C library.
+//
+// Register installs an object on the JavaScript global scope whose methods take and return typed
+// arrays:
+//
+//   - parseHeader(data: Uint8Array) -> {blockX, blockY, blockZ, width, height, depth}
+//   - decodeRGBA8(data: Uint8Array, srgb?: boolean) -> {width, height, pixels: Uint8ClampedArray}
+//     (pixels can be passed straight to new ImageData)
+//   - encodeRGBA8(pixels: Uint8Array | Uint8ClampedArray, width, height, blockX, blockY,
+//     options?: {srgb?: boolean, quality?: "fastest" | "fast" | "medium" | "thorough" |
+//     "verythorough" | "exhaustive"}) -> Uint8Array (a complete .astc file)
+//
+// Failures are returned, not thrown, as an Error instance. cmd/astcwasm is a ready-made module
+// that registers the object as globalThis.astc:
+//
+//	GOOS=js GOARCH=wasm go build -tags astc_smalllut -o astc.wasm ./cmd/astcwasm
+//
+// The astc_smalllut tag keeps the float32 and normal-map tables of package astc out of memory until
+// first use. On other platforms the package is empty.
+package wasm
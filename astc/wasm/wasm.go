@@ -0,0 +1,116 @@
+//go:build js && wasm
+
+package wasm
+
+import (
+	"errors"
+	"syscall/js"
+
+	"github.com/arm-software/astc-encoder/astc"
+)
+
+// Register installs the codec object as the JavaScript global name (e.g. "astc"). The functions
+// stay valid for the lifetime of the Go program, so the caller must keep it running (for example
+// by blocking on an empty select in main).
+func Register(name string) {
+	obj := js.Global().Get("Object").New()
+	obj.Set("parseHeader", js.FuncOf(parseHeader))
+	obj.Set("decodeRGBA8", js.FuncOf(decodeRGBA8))
+	obj.Set("encodeRGBA8", js.FuncOf(encodeRGBA8))
+	js.Global().Set(name, obj)
+}
+
+func parseHeader(_ js.Value, args []js.Value) any {
+	data, err := bytesArg(args, 0)
+	if err != nil {
+		return jsError(err)
+	}
+	h, err := astc.ParseHeader(data)
+	if err != nil {
+		return jsError(err)
+	}
+	return map[string]any{
+		"blockX": int(h.BlockX),
+		"blockY": int(h.BlockY),
+		"blockZ": int(h.BlockZ),
+		"width":  int(h.SizeX),
+		"height": int(h.SizeY),
+		"depth":  int(h.SizeZ),
+	}
+}
+
+func decodeRGBA8(_ js.Value, args []js.Value) any {
+	data, err := bytesArg(args, 0)
+	if err != nil {
+		return jsError(err)
+	}
+	profile := astc.ProfileLDR
+	if len(args) > 1 && args[1].Truthy() {
+		profile = astc.ProfileLDRSRGB
+	}
+	pix, w, h, err := astc.DecodeRGBA8WithProfile(data, profile)
+	if err != nil {
+		return jsError(err)
+	}
+	out := js.Global().Get("Uint8ClampedArray").New(len(pix))
+	js.CopyBytesToJS(out, pix)
+	return map[string]any{"width": w, "height": h, "pixels": out}
+}
+
+func encodeRGBA8(_ js.Value, args []js.Value) any {
+	pix, err := bytesArg(args, 0)
+	if err != nil {
+		return jsError(err)
+	}
+	if len(args) < 5 {
+		return jsError(errors.New("astc/wasm: encodeRGBA8 needs pixels, width, height, blockX, blockY"))
+	}
+	for i := 1; i < 5; i++ {
+		if args[i].Type() != js.TypeNumber {
+			return jsError(errors.New("astc/wasm: encodeRGBA8 width, height, blockX and blockY must be numbers"))
+		}
+	}
+	width, height := args[1].Int(), args[2].Int()
+	blockX, blockY := args[3].Int(), args[4].Int()
+	profile, quality := astc.ProfileLDR, astc.EncodeMedium
+	if len(args) > 5 && args[5].Type() == js.TypeObject {
+		opts := args[5]
+		if opts.Get("srgb").Truthy() {
+			profile = astc.ProfileLDRSRGB
+		}
+		if q := opts.Get("quality"); q.Type() == js.TypeString {
+			if quality, err = astc.ParseEncodeQuality(q.String()); err != nil {
+				return jsError(err)
+			}
+		}
+	}
+	enc, err := astc.NewEncoder(astc.WithBlockSize(blockX, blockY), astc.WithProfile(profile), astc.WithQuality(quality))
+	if err != nil {
+		return jsError(err)
+	}
+	data, err := enc.EncodeRGBA8(pix, width, height)
+	if err != nil {
+		return jsError(err)
+	}
+	out := js.Global().Get("Uint8Array").New(len(data))
+	js.CopyBytesToJS(out, data)
+	return out
+}
+
+// bytesArg copies args[i], a Uint8Array or Uint8ClampedArray, into Go memory.
+func bytesArg(args []js.Value, i int) ([]byte, error) {
+	if i >= len(args) {
+		return nil, errors.New("astc/wasm: missing typed array argument")
+	}
+	v := args[i]
+	if !v.InstanceOf(js.Global().Get("Uint8Array")) && !v.InstanceOf(js.Global().Get("Uint8ClampedArray")) {
+		return nil, errors.New("astc/wasm: argument must be a Uint8Array or Uint8ClampedArray")
+	}
+	buf := make([]byte, v.Length())
+	js.CopyBytesToGo(buf, v)
+	return buf, nil
+}
+
+func jsError(err error) js.Value {
+	return js.Global().Get("Error").New(err.Error())
+}
@@ -0,0 +1,84 @@
+//go:build js && wasm
+
+package wasm_test
+
+import (
+	"syscall/js"
+	"testing"
+
+	"github.com/arm-software/astc-encoder/astc"
+	"github.com/arm-software/astc-encoder/astc/wasm"
+)
+
+func TestRegister_RoundTrip(t *testing.T) {
+	wasm.Register("astcTest")
+	obj := js.Global().Get("astcTest")
+
+	const w, h = 10, 7
+	pix := make([]byte, w*h*4)
+	for i := range pix {
+		pix[i] = uint8(i * 5)
+	}
+	jsPix := js.Global().Get("Uint8ClampedArray").New(len(pix))
+	js.CopyBytesToJS(jsPix, pix)
+
+	opts := js.Global().Get("Object").New()
+	opts.Set("quality", "fast")
+	enc := obj.Call("encodeRGBA8", jsPix, w, h, 5, 5, opts)
+	if enc.InstanceOf(js.Global().Get("Error")) {
+		t.Fatalf("encodeRGBA8: %s", enc.Get("message").String())
+	}
+	data := make([]byte, enc.Length())
+	js.CopyBytesToGo(data, enc)
+	goEnc, err := astc.NewEncoder(astc.WithBlockSize(5, 5), astc.WithQuality(astc.EncodeFast))
+	if err != nil {
+		t.Fatalf("NewEncoder: %v", err)
+	}
+	want, err := goEnc.EncodeRGBA8(pix, w, h)
+	if err != nil {
+		t.Fatalf("EncodeRGBA8: %v", err)
+	}
+	if string(data) != string(want) {
+		t.Fatalf("encodeRGBA8 output differs from the Go encoder")
+	}
+
+	hdr := obj.Call("parseHeader", enc)
+	if hdr.Get("blockX").Int() != 5 || hdr.Get("width").Int() != w || hdr.Get("depth").Int() != 1 {
+		t.Fatalf("parseHeader: unexpected result")
+	}
+
+	dec := obj.Call("decodeRGBA8", enc, false)
+	if dec.InstanceOf(js.Global().Get("Error")) {
+		t.Fatalf("decodeRGBA8: %s", dec.Get("message").String())
+	}
+	got := make([]byte, dec.Get("pixels").Length())
+	js.CopyBytesToGo(got, dec.Get("pixels"))
+	wantPix, _, _, err := astc.DecodeRGBA8WithProfile(data, astc.ProfileLDR)
+	if err != nil {
+		t.Fatalf("DecodeRGBA8WithProfile: %v", err)
+	}
+	if dec.Get("width").Int() != w || dec.Get("height").Int() != h || string(got) != string(wantPix) {
+		t.Fatalf("decodeRGBA8 result differs from the Go decoder")
+	}
+}
+
+func TestRegister_Errors(t *testing.T) {
+	wasm.Register("astcTest")
+	obj := js.Global().Get("astcTest")
+	errCtor := js.Global().Get("Error")
+
+	if r := obj.Call("decodeRGBA8", "not bytes"); !r.InstanceOf(errCtor) {
+		t.Fatalf("decodeRGBA8 accepted a string")
+	}
+	if r := obj.Call("decodeRGBA8", js.Global().Get("Uint8Array").New(3)); !r.InstanceOf(errCtor) {
+		t.Fatalf("decodeRGBA8 accepted a truncated file")
+	}
+	opts := js.Global().Get("Object").New()
+	opts.Set("quality", "best")
+	if r := obj.Call("encodeRGBA8", js.Global().Get("Uint8Array").New(64), 4, 4, 4, 4, opts); !r.InstanceOf(errCtor) {
+		t.Fatalf("encodeRGBA8 accepted an unknown quality")
+	}
+	if r := obj.Call("encodeRGBA8", js.Global().Get("Uint8Array").New(64), "4", 4, 4, 4); !r.InstanceOf(errCtor) {
+		t.Fatalf("encodeRGBA8 accepted a string width")
+	}
+}
@@ -0,0 +1,102 @@
+package astc
+
+import "errors"
+
+// WeightUnquantTable returns the unquantized weight value (0..64) for each ISE-encoded weight at
+// the given quantization level count (2, 3, 4, 5, 6, 8, 10, 12, 16, 20, 24 or 32).
+//
+// The table is indexed by the integer decoded from the block's integer sequence, so it already
+// accounts for the trit/quint scrambling of the ASTC weight encoding. The returned slice is a copy
+// of the table used by the decoder.
+func WeightUnquantTable(levels int) ([]uint8, error) {
+	q, ok := weightQuantForLevels(levels)
+	if !ok {
+		return nil, errors.New("astc: invalid weight quantization level count")
+	}
+	out := make([]uint8, levels)
+	copy(out, weightUnscrambleAndUnquantMap[q][:levels])
+	return out, nil
+}
+
+// GenerateWeightUnquantTable computes the same table as WeightUnquantTable from the weight
+// unquantization rules of the ASTC specification, without using any embedded tables. It is
+// intended for independent verification of the decoder tables.
+func GenerateWeightUnquantTable(levels int) ([]uint8, error) {
+	q, ok := weightQuantForLevels(levels)
+	if !ok {
+		return nil, errors.New("astc: invalid weight quantization level count")
+	}
+	out := make([]uint8, levels)
+	for code := range out {
+		out[code] = unquantizeWeightCode(q, code)
+	}
+	return out, nil
+}
+
+func weightQuantForLevels(levels int) (quantMethod, bool) {
+	for q := quant2; q <= quant32; q++ {
+		if quantLevel(q) == levels {
+			return q, true
+		}
+	}
+	return 0, false
+}
+
+// unquantizeWeightCode unquantizes one ISE-decoded weight integer to the 0..64 range, following
+// the weight unquantization procedure of the ASTC specification.
+func unquantizeWeightCode(q quantMethod, code int) uint8 {
+	btq := btqCounts[q]
+	bits := int(btq.bits)
+
+	var t int
+	switch {
+	case !btq.trits && !btq.quints:
+		// Bit replication of the stored value up to 6 bits.
+		v := code
+		for n := bits; n < 6; n += bits {
+			v = v<<bits | code
+		}
+		t = v >> (((6+bits-1)/bits)*bits - 6)
+	case bits == 0:
+		if btq.trits {
+			t = [3]int{0, 32, 63}[code]
+		} else {
+			t = [5]int{0, 16, 32, 47, 63}[code]
+		}
+	default:
+		d := code >> bits
+		a := 0
+		if code&1 != 0 {
+			a = 0x7F
+		}
+
+		// B and C follow the spec's weight unquantization table, with B given as a 7-bit pattern of
+		// the stored bits above the lowest one (b, or c and b).
+		b1 := (code >> 1) & 1
+		b2 := (code >> 2) & 1
+		var b, c int
+		switch {
+		case btq.trits && bits == 1:
+			c = 50
+		case btq.quints && bits == 1:
+			c = 28
+		case btq.trits && bits == 2: // b000b0b
+			b = b1<<6 | b1<<2 | b1
+			c = 23
+		case btq.quints && bits == 2: // b0000b0
+			b = b1<<6 | b1<<1
+			c = 13
+		case btq.trits && bits == 3: // cb000cb
+			b = b2<<6 | b1<<5 | b2<<1 | b1
+			c = 11
+		}
+		t = d*c + b
+		t ^= a
+		t = (a & 0x20) | (t >> 2)
+	}
+
+	if t > 32 {
+		t++
+	}
+	return uint8(t)
+}
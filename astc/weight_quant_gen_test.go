@@ -0,0 +1,40 @@
+package astc
+
+import "testing"
+
+func TestGenerateWeightUnquantTable_MatchesEmbeddedTables(t *testing.T) {
+	for q := quant2; q <= quant32; q++ {
+		levels := quantLevel(q)
+		gen, err := GenerateWeightUnquantTable(levels)
+		if err != nil {
+			t.Fatalf("levels=%d: GenerateWeightUnquantTable: %v", levels, err)
+		}
+		embedded, err := WeightUnquantTable(levels)
+		if err != nil {
+			t.Fatalf("levels=%d: WeightUnquantTable: %v", levels, err)
+		}
+
+		for code := 0; code < levels; code++ {
+			if gen[code] != embedded[code] {
+				t.Fatalf("levels=%d code=%d: generated %d, embedded %d", levels, code, gen[code], embedded[code])
+			}
+		}
+
+		// The sorted unquant table and scramble map are the generated table ordered by value.
+		for i := 0; i < levels; i++ {
+			code := weightScrambleMap[q][i]
+			if gen[code] != weightQuantToUnquant[q][i] {
+				t.Fatalf("levels=%d rank=%d: scramble map code %d unquantizes to %d, want %d", levels, i, code, gen[code], weightQuantToUnquant[q][i])
+			}
+			if i > 0 && weightQuantToUnquant[q][i] <= weightQuantToUnquant[q][i-1] {
+				t.Fatalf("levels=%d: weightQuantToUnquant not strictly increasing at %d", levels, i)
+			}
+		}
+	}
+
+	for _, levels := range []int{0, 1, 7, 33, 40} {
+		if _, err := WeightUnquantTable(levels); err == nil {
+			t.Fatalf("levels=%d: expected error", levels)
+		}
+	}
+}
@@ -1,7 +1,8 @@
 package astc
 
 // Weight quantization only uses quant methods QUANT_2 .. QUANT_32.
-// These tables are copied from Source/astcenc_weight_quant_xfer_tables.cpp.
+// These tables are copied from Source/astcenc_weight_quant_xfer_tables.cpp; see
+// GenerateWeightUnquantTable for the same values derived from the specification.
 
 var weightQuantToUnquant = [12][32]uint8{
 	// quant2
@@ -13,6 +13,7 @@ import (
 
 	"github.com/arm-software/astc-encoder/astc"
 	"github.com/arm-software/astc-encoder/astc/native"
+	"github.com/arm-software/astc-encoder/astc/testimage"
 )
 
 func main() {
@@ -34,8 +35,8 @@ func main() {
 
 func usage() {
 	fmt.Fprintln(os.Stderr, "usage:")
-	fmt.Fprintln(os.Stderr, "  astcbench decode -in <file.astc> [-impl go|native] [-profile ldr|srgb|hdr|hdr-rgb-ldr-a] [-iters N] [-out u8|f32] [-checksum fnv|none]")
-	fmt.Fprintln(os.Stderr, "  astcbench encode -w W -h H [-d D] -block 4x4[ xZ] [-impl go|native] [-profile ldr|srgb|hdr|hdr-rgb-ldr-a] [-quality fastest|fast|medium|thorough|verythorough|exhaustive] [-iters N] [-out file.astc] [-checksum fnv|none]")
+	fmt.Fprintln(os.Stderr, "  astcbench decode -in <file.astc> [-impl go|native] [-profile ldr|srgb|hdr|hdr-rgb-ldr-a] [-iters N] [-out u8|f32] [-checksum fnv|none] [-benchmem]")
+	fmt.Fprintln(os.Stderr, "  astcbench encode -w W -h H [-d D] -block 4x4[ xZ] [-impl go|native] [-profile ldr|srgb|hdr|hdr-rgb-ldr-a] [-quality fastest|fast|medium|thorough|verythorough|exhaustive] [-iters N] [-out file.astc] [-checksum fnv|none] [-benchmem]")
 }
 
 func decodeCmd(args []string) {
@@ -50,6 +51,7 @@ func decodeCmd(args []string) {
 		cpuprofile  string
 		memprofile  string
 		memprofRate int
+		benchmem    bool
 	)
 	fs.StringVar(&inPath, "in", "", "input .astc file")
 	fs.StringVar(&impl, "impl", "go", "implementation: go|native (native requires -tags astcenc_native)")
@@ -60,6 +62,7 @@ func decodeCmd(args []string) {
 	fs.StringVar(&cpuprofile, "cpuprofile", "", "optional CPU profile output path")
 	fs.StringVar(&memprofile, "memprofile", "", "optional memory profile output path")
 	fs.IntVar(&memprofRate, "memprofilerate", 0, "optional runtime.MemProfileRate override (0 = default)")
+	fs.BoolVar(&benchmem, "benchmem", false, "report allocs/op, bytes/op and peak RSS")
 	_ = fs.Parse(args)
 
 	if inPath == "" {
@@ -116,6 +119,8 @@ func decodeCmd(args []string) {
 		}()
 	}
 
+	mem := memBench{enabled: benchmem}
+	mem.start()
 	start := time.Now()
 	var checksum uint64
 	doChecksum := strings.ToLower(strings.TrimSpace(checksumOpt)) != "none"
@@ -142,8 +147,8 @@ func decodeCmd(args []string) {
 				}
 			}
 		case "native", "cgo":
-			if !native.Enabled() {
-				fmt.Fprintln(os.Stderr, "native impl requested but not enabled (build with -tags astcenc_native and CGO_ENABLED=1)")
+			if ok, reason := native.Available(); !ok {
+				fmt.Fprintln(os.Stderr, "native impl requested but not enabled:", reason)
 				os.Exit(2)
 			}
 			dec, err := native.NewDecoder(int(hdr.BlockX), int(hdr.BlockY), int(hdr.BlockZ), prof, 0)
@@ -181,8 +186,8 @@ func decodeCmd(args []string) {
 				}
 			}
 		case "native", "cgo":
-			if !native.Enabled() {
-				fmt.Fprintln(os.Stderr, "native impl requested but not enabled (build with -tags astcenc_native and CGO_ENABLED=1)")
+			if ok, reason := native.Available(); !ok {
+				fmt.Fprintln(os.Stderr, "native impl requested but not enabled:", reason)
 				os.Exit(2)
 			}
 			dec, err := native.NewDecoder(int(hdr.BlockX), int(hdr.BlockY), int(hdr.BlockZ), prof, 0)
@@ -211,6 +216,7 @@ func decodeCmd(args []string) {
 	}
 
 	dur := time.Since(start)
+	memResult := mem.result(iters)
 	texels := float64(w*h*d) * float64(iters)
 	mpixPerS := texels / dur.Seconds() / 1e6
 
@@ -236,7 +242,7 @@ func decodeCmd(args []string) {
 	if implOut == "cgo" {
 		implOut = "native"
 	}
-	fmt.Printf("RESULT impl=%s mode=decode out=%s profile=%s size=%dx%dx%d iters=%d seconds=%.6f mpix/s=%.3f checksum=%s\n",
+	fmt.Printf("RESULT impl=%s mode=decode out=%s profile=%s size=%dx%dx%d iters=%d seconds=%.6f mpix/s=%.3f checksum=%s%s\n",
 		implOut,
 		outKind,
 		profile,
@@ -245,6 +251,7 @@ func decodeCmd(args []string) {
 		dur.Seconds(),
 		mpixPerS,
 		checksumStr,
+		memResult,
 	)
 }
 
@@ -262,6 +269,9 @@ func encodeCmd(args []string) {
 		outPath     string
 		checksumOpt string
 		cpuprofile  string
+		benchmem    bool
+		imageKind   string
+		seed        uint64
 	)
 	fs.IntVar(&width, "w", 256, "width")
 	fs.IntVar(&height, "h", 256, "height")
@@ -274,6 +284,9 @@ func encodeCmd(args []string) {
 	fs.StringVar(&outPath, "out", "", "optional output .astc path (writes last iteration)")
 	fs.StringVar(&checksumOpt, "checksum", "fnv", "checksum: fnv|none (for benchmarking)")
 	fs.StringVar(&cpuprofile, "cpuprofile", "", "optional CPU profile output path")
+	fs.BoolVar(&benchmem, "benchmem", false, "report allocs/op, bytes/op and peak RSS")
+	fs.StringVar(&imageKind, "image", "pattern", "synthetic input: pattern|gradient|perlin|text|alpha-cutout|hdr-sky")
+	fs.Uint64Var(&seed, "seed", 0, "seed for the synthetic input")
 	_ = fs.Parse(args)
 
 	if width <= 0 || height <= 0 || depth <= 0 {
@@ -304,16 +317,31 @@ func encodeCmd(args []string) {
 		os.Exit(2)
 	}
 
+	kind, err := testimage.ParseKind(strings.ToLower(strings.TrimSpace(imageKind)))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
 	isHDRProfile := prof == astc.ProfileHDR || prof == astc.ProfileHDRRGBLDRAlpha
 
 	var pixU8 []byte
 	var pixF32 []float32
+	imgOpts := testimage.Options{Seed: seed}
 	if isHDRProfile {
-		pixF32 = make([]float32, width*height*depth*4)
-		fillPatternRGBAF32(pixF32, width, height, depth, prof)
+		pixF32, err = testimage.RGBAF32(kind, width, height, depth, imgOpts)
+		if err == nil && kind == testimage.KindPattern && prof == astc.ProfileHDR {
+			// The HDR-alpha profile benchmark also exercises HDR alpha.
+			for i := 3; i < len(pixF32); i += 4 {
+				pixF32[i] = 1.0 + pixF32[i]*2.0
+			}
+		}
 	} else {
-		pixU8 = make([]byte, width*height*depth*4)
-		fillPatternRGBA8(pixU8, width, height, depth)
+		pixU8, err = testimage.RGBA8(kind, width, height, depth, imgOpts)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
 	}
 
 	var cpuFile *os.File
@@ -335,15 +363,31 @@ func encodeCmd(args []string) {
 		}()
 	}
 
+	mem := memBench{enabled: benchmem}
+	mem.start()
 	start := time.Now()
 	var checksum uint64
 	doChecksum := strings.ToLower(strings.TrimSpace(checksumOpt)) != "none"
 	var last []byte
+	var goEnc *astc.Encoder
+	var goImg *astc.Image
 	var encU8 *native.Encoder
 	var encF32 *native.EncoderF32
+	if impl == "go" {
+		goEnc, err = astc.NewEncoder(astc.WithBlockSize3D(bx, by, bz), astc.WithProfile(prof), astc.WithQuality(q))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if isHDRProfile {
+			goImg = &astc.Image{DimX: width, DimY: height, DimZ: depth, DataType: astc.TypeF32, DataF32: pixF32}
+		} else {
+			goImg = &astc.Image{DimX: width, DimY: height, DimZ: depth, DataType: astc.TypeU8, DataU8: pixU8}
+		}
+	}
 	if impl == "native" || impl == "cgo" {
-		if !native.Enabled() {
-			fmt.Fprintln(os.Stderr, "native impl requested but not enabled (build with -tags astcenc_native and CGO_ENABLED=1)")
+		if ok, reason := native.Available(); !ok {
+			fmt.Fprintln(os.Stderr, "native impl requested but not enabled:", reason)
 			os.Exit(2)
 		}
 		if isHDRProfile {
@@ -366,19 +410,7 @@ func encodeCmd(args []string) {
 		var out []byte
 		switch impl {
 		case "go":
-			if isHDRProfile {
-				if depth == 1 && bz == 1 {
-					out, err = astc.EncodeRGBAF32WithProfileAndQuality(pixF32[:width*height*4], width, height, bx, by, prof, q)
-				} else {
-					out, err = astc.EncodeRGBAF32VolumeWithProfileAndQuality(pixF32, width, height, depth, bx, by, bz, prof, q)
-				}
-			} else {
-				if depth == 1 && bz == 1 {
-					out, err = astc.EncodeRGBA8WithProfileAndQuality(pixU8[:width*height*4], width, height, bx, by, prof, q)
-				} else {
-					out, err = astc.EncodeRGBA8VolumeWithProfileAndQuality(pixU8, width, height, depth, bx, by, bz, prof, q)
-				}
-			}
+			out, err = goEnc.Encode(goImg)
 		case "native", "cgo":
 			if isHDRProfile {
 				out, err = encF32.EncodeRGBAF32Volume(pixF32, width, height, depth)
@@ -399,6 +431,7 @@ func encodeCmd(args []string) {
 		last = out
 	}
 	dur := time.Since(start)
+	memResult := mem.result(iters)
 
 	if outPath != "" {
 		if err := os.WriteFile(outPath, last, 0o644); err != nil {
@@ -419,7 +452,7 @@ func encodeCmd(args []string) {
 	if implOut == "cgo" {
 		implOut = "native"
 	}
-	fmt.Printf("RESULT impl=%s mode=encode profile=%s block=%s size=%dx%dx%d iters=%d seconds=%.6f mpix/s=%.3f checksum=%s\n",
+	fmt.Printf("RESULT impl=%s mode=encode profile=%s block=%s size=%dx%dx%d iters=%d seconds=%.6f mpix/s=%.3f checksum=%s%s\n",
 		implOut,
 		profile,
 		block,
@@ -428,6 +461,7 @@ func encodeCmd(args []string) {
 		dur.Seconds(),
 		mpixPerS,
 		checksumStr,
+		memResult,
 	)
 }
 
@@ -482,52 +516,6 @@ func parseBlock3D(s string) (x, y, z int, err error) {
 	return x, y, z, nil
 }
 
-func fillPatternRGBA8(pix []byte, width, height, depth int) {
-	for z := 0; z < depth; z++ {
-		for y := 0; y < height; y++ {
-			for x := 0; x < width; x++ {
-				off := ((z*height+y)*width + x) * 4
-				r := uint32(x*3 + y*5 + z*7)
-				g := uint32(x*11 + y*13 + z*17)
-				b := uint32(x ^ y ^ z)
-				a := 255 - uint32((x*5+y*7+z*3)&0xFF)
-				pix[off+0] = uint8(r)
-				pix[off+1] = uint8(g)
-				pix[off+2] = uint8(b)
-				pix[off+3] = uint8(a)
-			}
-		}
-	}
-}
-
-func fillPatternRGBAF32(pix []float32, width, height, depth int, profile astc.Profile) {
-	for z := 0; z < depth; z++ {
-		for y := 0; y < height; y++ {
-			for x := 0; x < width; x++ {
-				off := ((z*height+y)*width + x) * 4
-				r := uint8(uint32(x*3 + y*5 + z*7))
-				g := uint8(uint32(x*11 + y*13 + z*17))
-				b := uint8(uint32(x ^ y ^ z))
-				a := uint8(255 - uint32((x*5+y*7+z*3)&0xFF))
-
-				rf := float32(r) / 255.0
-				gf := float32(g) / 255.0
-				bf := float32(b) / 255.0
-				af := float32(a) / 255.0
-
-				pix[off+0] = rf * 4.0
-				pix[off+1] = gf * 2.0
-				pix[off+2] = bf * 6.0
-				if profile == astc.ProfileHDR {
-					pix[off+3] = 1.0 + af*2.0
-				} else {
-					pix[off+3] = af
-				}
-			}
-		}
-	}
-}
-
 func fnv1a64(seed uint64, data []byte) uint64 {
 	const (
 		offset64 = 14695981039346656037
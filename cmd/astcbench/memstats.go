@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// memBench records Go heap allocation counters around a benchmark loop, similar to
+// `go test -benchmem`.
+//
+// Allocations made by the native (C++) implementation are not visible to the Go runtime; the
+// peak RSS figure covers both.
+type memBench struct {
+	enabled bool
+	before  runtime.MemStats
+}
+
+func (m *memBench) start() {
+	if !m.enabled {
+		return
+	}
+	runtime.GC()
+	runtime.ReadMemStats(&m.before)
+}
+
+// result returns the RESULT line suffix for iters iterations, or "" when disabled.
+func (m *memBench) result(iters int) string {
+	if !m.enabled {
+		return ""
+	}
+	var after runtime.MemStats
+	runtime.ReadMemStats(&after)
+
+	n := uint64(iters)
+	allocsPerOp := (after.Mallocs - m.before.Mallocs) / n
+	bytesPerOp := (after.TotalAlloc - m.before.TotalAlloc) / n
+
+	s := fmt.Sprintf(" allocs/op=%d bytes/op=%d", allocsPerOp, bytesPerOp)
+	if rss, ok := peakRSSBytes(); ok {
+		s += fmt.Sprintf(" peak-rss-kb=%d", rss/1024)
+	} else {
+		s += " peak-rss-kb=n/a"
+	}
+	return s
+}
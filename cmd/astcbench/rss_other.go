@@ -0,0 +1,6 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd)
+
+package main
+
+// peakRSSBytes is not implemented on this platform.
+func peakRSSBytes() (int64, bool) { return 0, false }
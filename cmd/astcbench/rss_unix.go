@@ -0,0 +1,22 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+
+package main
+
+import (
+	"runtime"
+	"syscall"
+)
+
+// peakRSSBytes returns the peak resident set size of the current process.
+func peakRSSBytes() (int64, bool) {
+	var ru syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &ru); err != nil {
+		return 0, false
+	}
+	rss := int64(ru.Maxrss)
+	if runtime.GOOS != "darwin" {
+		// ru_maxrss is reported in kilobytes everywhere except macOS.
+		rss *= 1024
+	}
+	return rss, true
+}
@@ -0,0 +1,64 @@
+// Command astcd serves ASTC encode and decode requests over HTTP.
+//
+// It keeps a pool of codec contexts per configuration and can use both the pure-Go and, when built
+// with -tags astcenc_native, the native implementation. Clients use package astc/remote.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"runtime"
+	"syscall"
+	"time"
+)
+
+func main() {
+	var (
+		addr         string
+		threads      int
+		poolSize     int
+		maxRequestMB int
+		maxPixels    int64
+	)
+	flag.StringVar(&addr, "addr", ":8080", "listen address")
+	flag.IntVar(&threads, "threads", runtime.GOMAXPROCS(0), "native implementation threads per request")
+	flag.IntVar(&poolSize, "pool", 2, "idle codec contexts kept per configuration")
+	flag.IntVar(&maxRequestMB, "max-request-mb", 512, "maximum request body size in MiB")
+	flag.Int64Var(&maxPixels, "max-pixels", 1<<28, "maximum texels of an encoded or decoded image")
+	flag.Parse()
+
+	if threads < 1 || poolSize < 0 || maxRequestMB < 1 || maxPixels < 1 {
+		fmt.Fprintln(os.Stderr, "usage: astcd [-addr :8080] [-threads N] [-pool N] [-max-request-mb N] [-max-pixels N]")
+		os.Exit(2)
+	}
+
+	srv := newServer(threads, poolSize, int64(maxRequestMB)<<20, maxPixels)
+	defer srv.close()
+
+	httpSrv := &http.Server{
+		Addr:              addr,
+		Handler:           srv.handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		_ = httpSrv.Shutdown(shutdownCtx)
+	}()
+
+	log.Printf("astcd: listening on %s (implementations %v, %d threads per request)", addr, srv.implementations(), threads)
+	if err := httpSrv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Print(err)
+		os.Exit(1)
+	}
+}
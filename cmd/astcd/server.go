@@ -0,0 +1,323 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/arm-software/astc-encoder/astc"
+	"github.com/arm-software/astc-encoder/astc/native"
+	"github.com/arm-software/astc-encoder/astc/remote"
+)
+
+// server handles astcd requests. Codec contexts are expensive to create for the native
+// implementation, so idle ones are kept per configuration and reused.
+//
+// Native codecs use threads threads per request. Pure-Go contexts are single-threaded, which lets
+// them reset implicitly between images; concurrent requests provide their parallelism.
+type server struct {
+	threads   int
+	poolSize  int
+	maxBytes  int64
+	maxPixels int64
+
+	mu   sync.Mutex
+	idle map[poolKey][]codec
+}
+
+// codec is a pooled *astc.Context, *native.Encoder or *native.Decoder.
+type codec interface {
+	Close() error
+}
+
+type poolKey struct {
+	impl    string
+	decode  bool
+	profile astc.Profile
+	quality astc.EncodeQuality
+
+	blockX, blockY, blockZ int
+}
+
+func newServer(threads, poolSize int, maxBytes, maxPixels int64) *server {
+	return &server{
+		threads:   threads,
+		poolSize:  poolSize,
+		maxBytes:  maxBytes,
+		maxPixels: maxPixels,
+		idle:      map[poolKey][]codec{},
+	}
+}
+
+func (s *server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/encode", s.handleEncode)
+	mux.HandleFunc("POST /v1/decode", s.handleDecode)
+	mux.HandleFunc("GET /v1/info", s.handleInfo)
+	return mux
+}
+
+// close releases the idle contexts.
+func (s *server) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for k, cs := range s.idle {
+		for _, c := range cs {
+			_ = c.Close()
+		}
+		delete(s.idle, k)
+	}
+}
+
+func (s *server) implementations() []string {
+	if native.Enabled() {
+		return []string{remote.ImplGo, remote.ImplNative}
+	}
+	return []string{remote.ImplGo}
+}
+
+// get returns an idle codec for k, or a new one from create.
+func (s *server) get(k poolKey, create func() (codec, error)) (codec, error) {
+	s.mu.Lock()
+	if cs := s.idle[k]; len(cs) > 0 {
+		c := cs[len(cs)-1]
+		s.idle[k] = cs[:len(cs)-1]
+		s.mu.Unlock()
+		return c, nil
+	}
+	s.mu.Unlock()
+	return create()
+}
+
+// put returns c to the pool, closing it if the pool for k is full.
+func (s *server) put(k poolKey, c codec) {
+	s.mu.Lock()
+	if len(s.idle[k]) < s.poolSize {
+		s.idle[k] = append(s.idle[k], c)
+		c = nil
+	}
+	s.mu.Unlock()
+	if c != nil {
+		_ = c.Close()
+	}
+}
+
+func (s *server) handleInfo(w http.ResponseWriter, r *http.Request) {
+	info := remote.Info{Implementations: s.implementations(), Threads: s.threads}
+	info.NativeVersion, _ = native.Version()
+	writeJSON(w, http.StatusOK, &info)
+}
+
+func (s *server) handleEncode(w http.ResponseWriter, r *http.Request) {
+	var req remote.EncodeRequest
+	if !s.readRequest(w, r, &req) {
+		return
+	}
+	impl, ok := s.checkImpl(w, req.Impl)
+	if !ok {
+		return
+	}
+	if req.BlockZ == 0 {
+		req.BlockZ = 1
+	}
+	if req.Depth == 0 {
+		req.Depth = 1
+	}
+
+	out, err := s.encode(impl, &req)
+	if err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, &remote.EncodeResponse{ASTC: out})
+}
+
+func (s *server) encode(impl string, req *remote.EncodeRequest) ([]byte, error) {
+	if req.Width <= 0 || req.Height <= 0 || req.Depth <= 0 || req.Width > 0xFFFFFF || req.Height > 0xFFFFFF || req.Depth > 0xFFFFFF {
+		return nil, errors.New("astcd: invalid image dimensions")
+	}
+	if req.BlockX <= 0 || req.BlockY <= 0 || req.BlockZ <= 0 || req.BlockX > 255 || req.BlockY > 255 || req.BlockZ > 255 {
+		return nil, errors.New("astcd: invalid block dimensions")
+	}
+	h := astc.Header{
+		BlockX: uint8(req.BlockX),
+		BlockY: uint8(req.BlockY),
+		BlockZ: uint8(req.BlockZ),
+		SizeX:  uint32(req.Width),
+		SizeY:  uint32(req.Height),
+		SizeZ:  uint32(req.Depth),
+	}
+	// The dimensions are untrusted: check them against the pixel limit and the payload before
+	// sizing any buffer from them.
+	if err := (astc.DecodeOptions{MaxPixels: s.maxPixels}).CheckLimits(h); err != nil {
+		return nil, err
+	}
+	if int64(len(req.RGBA8)) != int64(req.Width)*int64(req.Height)*int64(req.Depth)*4 {
+		return nil, fmt.Errorf("astcd: rgba8 is %d bytes, want %dx%dx%dx4", len(req.RGBA8), req.Width, req.Height, req.Depth)
+	}
+
+	k := poolKey{impl: impl, profile: req.Profile, quality: req.Quality, blockX: req.BlockX, blockY: req.BlockY, blockZ: req.BlockZ}
+	if impl == remote.ImplNative {
+		c, err := s.get(k, func() (codec, error) {
+			return native.NewEncoder(req.BlockX, req.BlockY, req.BlockZ, req.Profile, req.Quality, s.threads)
+		})
+		if err != nil {
+			return nil, err
+		}
+		out, err := c.(*native.Encoder).EncodeRGBA8Volume(req.RGBA8, req.Width, req.Height, req.Depth)
+		s.put(k, c)
+		return out, err
+	}
+
+	hdr, err := astc.MarshalHeader(h)
+	if err != nil {
+		return nil, err
+	}
+	_, _, _, total, err := h.BlockCount()
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := s.get(k, func() (codec, error) {
+		cfg, err := astc.ConfigInit(req.Profile, req.BlockX, req.BlockY, req.BlockZ, req.Quality.Level(), 0)
+		if err != nil {
+			return nil, err
+		}
+		return astc.ContextAlloc(&cfg, 1)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, astc.HeaderSize+total*astc.BlockBytes)
+	copy(out, hdr[:])
+	img := astc.Image{DimX: req.Width, DimY: req.Height, DimZ: req.Depth, DataType: astc.TypeU8, DataU8: req.RGBA8}
+	err = c.(*astc.Context).CompressImage(&img, astc.SwizzleRGBA, out[astc.HeaderSize:], 0)
+	s.put(k, c)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// errorStatus returns the HTTP status for a failed encode or decode: 413 for images over the
+// server's limits, 422 otherwise.
+func errorStatus(err error) int {
+	var tooLarge *astc.DecodeLimitError
+	if errors.As(err, &tooLarge) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusUnprocessableEntity
+}
+
+func (s *server) handleDecode(w http.ResponseWriter, r *http.Request) {
+	var req remote.DecodeRequest
+	if !s.readRequest(w, r, &req) {
+		return
+	}
+	impl, ok := s.checkImpl(w, req.Impl)
+	if !ok {
+		return
+	}
+
+	resp, err := s.decode(impl, &req)
+	if err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *server) decode(impl string, req *remote.DecodeRequest) (*remote.DecodeResponse, error) {
+	h, blocks, err := astc.ParseFile(req.ASTC)
+	if err != nil {
+		return nil, err
+	}
+	// The header is untrusted: a small file can claim an image far larger than the server's memory.
+	if err := (astc.DecodeOptions{MaxPixels: s.maxPixels}).CheckLimits(h); err != nil {
+		return nil, err
+	}
+	bx, by, bz := int(h.BlockX), int(h.BlockY), int(h.BlockZ)
+	resp := &remote.DecodeResponse{Width: int(h.SizeX), Height: int(h.SizeY), Depth: int(h.SizeZ)}
+	resp.RGBA8 = make([]byte, resp.Width*resp.Height*resp.Depth*4)
+
+	k := poolKey{impl: impl, decode: true, profile: req.Profile, blockX: bx, blockY: by, blockZ: bz}
+	if impl == remote.ImplNative {
+		c, err := s.get(k, func() (codec, error) {
+			return native.NewDecoder(bx, by, bz, req.Profile, s.threads)
+		})
+		if err != nil {
+			return nil, err
+		}
+		err = c.(*native.Decoder).DecodeRGBA8VolumeInto(resp.Width, resp.Height, resp.Depth, blocks, resp.RGBA8)
+		s.put(k, c)
+		if err != nil {
+			return nil, err
+		}
+		return resp, nil
+	}
+
+	c, err := s.get(k, func() (codec, error) {
+		cfg, err := astc.ConfigInit(req.Profile, bx, by, bz, 0, astc.FlagDecompressOnly)
+		if err != nil {
+			return nil, err
+		}
+		return astc.ContextAlloc(&cfg, 1)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	img := astc.Image{DimX: resp.Width, DimY: resp.Height, DimZ: resp.Depth, DataType: astc.TypeU8, DataU8: resp.RGBA8}
+	err = c.(*astc.Context).DecompressImage(blocks, &img, astc.SwizzleRGBA, 0)
+	s.put(k, c)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (s *server) readRequest(w http.ResponseWriter, r *http.Request, v any) bool {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, s.maxBytes))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		status := http.StatusBadRequest
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		writeError(w, status, fmt.Errorf("astcd: invalid request: %w", err))
+		return false
+	}
+	return true
+}
+
+// checkImpl resolves the requested implementation, reporting an error to the client if it is
+// unknown or unavailable.
+func (s *server) checkImpl(w http.ResponseWriter, impl string) (string, bool) {
+	switch impl {
+	case "", remote.ImplGo:
+		return remote.ImplGo, true
+	case remote.ImplNative:
+		if ok, reason := native.Available(); !ok {
+			writeError(w, http.StatusNotImplemented, fmt.Errorf("astcd: native implementation is not available in this build: %s", reason))
+			return "", false
+		}
+		return remote.ImplNative, true
+	default:
+		writeError(w, http.StatusBadRequest, fmt.Errorf("astcd: unknown implementation %q", impl))
+		return "", false
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, &remote.ErrorResponse{Error: err.Error()})
+}
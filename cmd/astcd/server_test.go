@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/arm-software/astc-encoder/astc"
+	"github.com/arm-software/astc-encoder/astc/native"
+	"github.com/arm-software/astc-encoder/astc/remote"
+)
+
+func TestServer_EncodeDecode(t *testing.T) {
+	srv := newServer(2, 1, 1<<20, 1<<20)
+	defer srv.close()
+	ts := httptest.NewServer(srv.handler())
+	defer ts.Close()
+	client := remote.NewClient(ts.URL)
+	ctx := context.Background()
+
+	const w, h = 20, 12
+	pix := make([]byte, w*h*4)
+	for i := range pix {
+		pix[i] = byte(i * 7)
+	}
+
+	impls := []string{remote.ImplGo}
+	if native.Enabled() {
+		impls = append(impls, remote.ImplNative)
+	}
+	info, err := client.Info(ctx)
+	if err != nil {
+		t.Fatalf("Info: %v", err)
+	}
+	if len(info.Implementations) != len(impls) || info.Threads != 2 {
+		t.Fatalf("unexpected info %+v", info)
+	}
+
+	for _, impl := range impls {
+		req := &remote.EncodeRequest{
+			Impl:    impl,
+			Profile: astc.ProfileLDR,
+			Quality: astc.EncodeFast,
+			BlockX:  6,
+			BlockY:  6,
+			Width:   w,
+			Height:  h,
+			RGBA8:   pix,
+		}
+
+		// Concurrent requests share the pool.
+		var wg sync.WaitGroup
+		files := make([][]byte, 4)
+		errs := make([]error, len(files))
+		for i := range files {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				files[i], errs[i] = client.Encode(ctx, req)
+			}(i)
+		}
+		wg.Wait()
+		for i := range files {
+			if errs[i] != nil {
+				t.Fatalf("%s: Encode: %v", impl, errs[i])
+			}
+			if !bytes.Equal(files[i], files[0]) {
+				t.Fatalf("%s: encodes differ", impl)
+			}
+		}
+		hdr, _, err := astc.ParseFile(files[0])
+		if err != nil {
+			t.Fatalf("%s: ParseFile: %v", impl, err)
+		}
+		if hdr != (astc.Header{BlockX: 6, BlockY: 6, BlockZ: 1, SizeX: w, SizeY: h, SizeZ: 1}) {
+			t.Fatalf("%s: unexpected header %+v", impl, hdr)
+		}
+
+		resp, err := client.Decode(ctx, &remote.DecodeRequest{Impl: impl, Profile: astc.ProfileLDR, ASTC: files[0]})
+		if err != nil {
+			t.Fatalf("%s: Decode: %v", impl, err)
+		}
+		want, _, _, err := astc.DecodeRGBA8WithProfile(files[0], astc.ProfileLDR)
+		if err != nil {
+			t.Fatalf("%s: DecodeRGBA8WithProfile: %v", impl, err)
+		}
+		if resp.Width != w || resp.Height != h || resp.Depth != 1 || !bytes.Equal(resp.RGBA8, want) {
+			t.Fatalf("%s: decode mismatch (%dx%dx%d)", impl, resp.Width, resp.Height, resp.Depth)
+		}
+	}
+}
+
+func TestServer_Errors(t *testing.T) {
+	srv := newServer(1, 1, 1024, 64)
+	defer srv.close()
+	ts := httptest.NewServer(srv.handler())
+	defer ts.Close()
+	client := remote.NewClient(ts.URL)
+	ctx := context.Background()
+
+	status := func(err error) int {
+		var e *remote.Error
+		if !errors.As(err, &e) {
+			t.Fatalf("expected *remote.Error, got %v", err)
+		}
+		return e.StatusCode
+	}
+
+	_, err := client.Encode(ctx, &remote.EncodeRequest{Impl: "gpu", BlockX: 4, BlockY: 4, Width: 4, Height: 4, RGBA8: make([]byte, 64)})
+	if got := status(err); got != http.StatusBadRequest {
+		t.Fatalf("unknown impl: status %d", got)
+	}
+	_, err = client.Encode(ctx, &remote.EncodeRequest{BlockX: 4, BlockY: 4, Width: 4, Height: 4, RGBA8: make([]byte, 60)})
+	if got := status(err); got != http.StatusUnprocessableEntity {
+		t.Fatalf("short pixels: status %d", got)
+	}
+	_, err = client.Encode(ctx, &remote.EncodeRequest{BlockX: 4, BlockY: 4, Width: 4, Height: 4, RGBA8: make([]byte, 68)})
+	if got := status(err); got != http.StatusUnprocessableEntity {
+		t.Fatalf("long pixels: status %d", got)
+	}
+	// The dimensions are checked before the output is sized from them.
+	_, err = client.Encode(ctx, &remote.EncodeRequest{BlockX: 4, BlockY: 4, Width: 16000000, Height: 16000000, RGBA8: make([]byte, 3)})
+	if got := status(err); got != http.StatusRequestEntityTooLarge {
+		t.Fatalf("large image: status %d", got)
+	}
+	_, err = client.Encode(ctx, &remote.EncodeRequest{BlockX: 4, BlockY: 4, Width: 16, Height: 16, RGBA8: make([]byte, 3)})
+	if got := status(err); got != http.StatusRequestEntityTooLarge {
+		t.Fatalf("over max pixels: status %d", got)
+	}
+	_, err = client.Encode(ctx, &remote.EncodeRequest{BlockX: 4, BlockY: 4, Width: 32, Height: 32, RGBA8: make([]byte, 32*32*4)})
+	if got := status(err); got != http.StatusRequestEntityTooLarge {
+		t.Fatalf("large request: status %d", got)
+	}
+	_, err = client.Decode(ctx, &remote.DecodeRequest{ASTC: []byte("not an astc file")})
+	if got := status(err); got != http.StatusUnprocessableEntity {
+		t.Fatalf("bad file: status %d", got)
+	}
+	hdr, err := astc.MarshalHeader(astc.Header{BlockX: 4, BlockY: 4, BlockZ: 1, SizeX: 16, SizeY: 16, SizeZ: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.Decode(ctx, &remote.DecodeRequest{ASTC: append(hdr[:], make([]byte, 16*16)...)})
+	if got := status(err); got != http.StatusRequestEntityTooLarge {
+		t.Fatalf("large image: status %d", got)
+	}
+	if !native.Enabled() {
+		_, err = client.Decode(ctx, &remote.DecodeRequest{Impl: remote.ImplNative})
+		if got := status(err); got != http.StatusNotImplemented {
+			t.Fatalf("native: status %d", got)
+		}
+	}
+}
@@ -30,6 +30,7 @@ func main() {
 		decode    bool
 		dumpInfo  bool
 		dumpBlock bool
+		accelPath string
 	)
 	flag.StringVar(&inPath, "in", "", "input file")
 	flag.StringVar(&outPath, "out", "", "output file")
@@ -41,6 +42,7 @@ func main() {
 	flag.BoolVar(&decode, "decode", false, "decode input .astc -> .png")
 	flag.BoolVar(&dumpInfo, "info", false, "print .astc header info and exit")
 	flag.BoolVar(&dumpBlock, "dump-first-block", false, "dump the first ASTC block payload as hex and exit")
+	flag.StringVar(&accelPath, "accel-cache", "", "acceleration table cache file: loaded if present, written after the first run")
 	flag.Parse()
 
 	if inPath == "" {
@@ -95,10 +97,13 @@ func main() {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(2)
 	}
-	if implVal == implNative && !native.Enabled() {
-		fmt.Fprintln(os.Stderr, "native implementation is not available in this build (build with -tags astcenc_native and CGO_ENABLED=1)")
+	if ok, reason := native.Available(); implVal == implNative && !ok {
+		fmt.Fprintln(os.Stderr, "native implementation is not available in this build:", reason)
 		os.Exit(2)
 	}
+	if accelPath != "" && implVal == implGo {
+		defer useAccelerationCache(accelPath)()
+	}
 
 	if encode {
 		bx, by, err := parseBlock(block)
@@ -119,9 +124,18 @@ func main() {
 		var astcData []byte
 		switch implVal {
 		case implGo:
-			astcData, err = astc.EncodeRGBA8WithProfileAndQuality(rgba.Pix, rgba.Rect.Dx(), rgba.Rect.Dy(), bx, by, profileVal, qualityVal)
+			var enc *astc.Encoder
+			enc, err = astc.NewEncoder(astc.WithBlockSize(bx, by), astc.WithProfile(profileVal), astc.WithQuality(qualityVal))
+			if err == nil {
+				astcData, err = enc.EncodeRGBA8(rgba.Pix, rgba.Rect.Dx(), rgba.Rect.Dy())
+			}
 		case implNative:
-			astcData, err = native.EncodeRGBA8WithProfileAndQuality(rgba.Pix, rgba.Rect.Dx(), rgba.Rect.Dy(), bx, by, profileVal, qualityVal)
+			var enc *native.Encoder
+			enc, err = native.NewEncoder(bx, by, 1, profileVal, qualityVal, 0)
+			if err == nil {
+				astcData, err = enc.EncodeRGBA8(rgba.Pix, rgba.Rect.Dx(), rgba.Rect.Dy())
+				enc.Close()
+			}
 		default:
 			err = fmt.Errorf("unsupported -impl %q", impl)
 		}
@@ -208,6 +222,32 @@ func main() {
 	}
 }
 
+// useAccelerationCache loads the acceleration table cache at path and returns a function which
+// rewrites it when it was missing or unusable. Cache problems are reported but never fatal.
+func useAccelerationCache(path string) func() {
+	f, err := os.Open(path)
+	if err == nil {
+		err = astc.LoadAccelerationCache(f)
+		f.Close()
+		if err == nil {
+			return func() {}
+		}
+	}
+	if !os.IsNotExist(err) {
+		fmt.Fprintln(os.Stderr, "astcencgo: ignoring acceleration cache:", err)
+	}
+	return func() {
+		var buf bytes.Buffer
+		err := astc.SaveAccelerationCache(&buf)
+		if err == nil {
+			err = os.WriteFile(path, buf.Bytes(), 0o644)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "astcencgo: writing acceleration cache:", err)
+		}
+	}
+}
+
 func parseBlock(s string) (x, y int, err error) {
 	parts := strings.Split(s, "x")
 	if len(parts) != 2 {
@@ -236,22 +276,11 @@ func parseProfile(s string) (astc.Profile, error) {
 }
 
 func parseQuality(s string) (astc.EncodeQuality, error) {
-	switch strings.ToLower(strings.TrimSpace(s)) {
-	case "fastest":
-		return astc.EncodeFastest, nil
-	case "fast":
-		return astc.EncodeFast, nil
-	case "medium":
-		return astc.EncodeMedium, nil
-	case "thorough":
-		return astc.EncodeThorough, nil
-	case "verythorough", "very-thorough":
-		return astc.EncodeVeryThorough, nil
-	case "exhaustive":
-		return astc.EncodeExhaustive, nil
-	default:
+	q, err := astc.ParseEncodeQuality(s)
+	if err != nil {
 		return 0, fmt.Errorf("invalid -quality %q (want fastest|fast|medium|thorough|verythorough|exhaustive)", s)
 	}
+	return q, nil
 }
 
 type implKind uint8
@@ -0,0 +1,16 @@
+//go:build js && wasm
+
+// Command astcwasm is a WebAssembly module that exposes the pure-Go ASTC codec to JavaScript as
+// globalThis.astc (see package astc/wasm). Build it with
+//
+//	GOOS=js GOARCH=wasm go build -tags astc_smalllut -o astc.wasm ./cmd/astcwasm
+//
+// and load it with the wasm_exec.js shipped in $(go env GOROOT)/lib/wasm.
+package main
+
+import "github.com/arm-software/astc-encoder/astc/wasm"
+
+func main() {
+	wasm.Register("astc")
+	select {}
+}
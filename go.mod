@@ -2,3 +2,4 @@ module github.com/arm-software/astc-encoder
 
 go 1.24.0
 
+require github.com/klauspost/compress v1.18.0